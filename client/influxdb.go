@@ -133,7 +133,11 @@ func (c *Client) Write(bp BatchPoints) (*Response, error) {
 				p.Tags[k] = v
 			}
 
-			if _, err := b.WriteString(p.MarshalString()); err != nil {
+			pt, err := tsdb.NewPoint(p.Measurement, p.Tags, p.Fields, p.Time)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := b.WriteString(pt.String()); err != nil {
 				return nil, err
 			}
 		}
@@ -372,8 +376,14 @@ func (p *Point) MarshalJSON() ([]byte, error) {
 	return json.Marshal(&point)
 }
 
+// MarshalString returns the line protocol encoding of the point.
+// Returns an empty string if the point is invalid.
 func (p *Point) MarshalString() string {
-	return tsdb.NewPoint(p.Measurement, p.Tags, p.Fields, p.Time).String()
+	pt, err := tsdb.NewPoint(p.Measurement, p.Tags, p.Fields, p.Time)
+	if err != nil {
+		return ""
+	}
+	return pt.String()
 }
 
 // UnmarshalJSON decodes the data into the Point struct
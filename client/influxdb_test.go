@@ -420,6 +420,19 @@ func TestPoint_MarshalOmitempty(t *testing.T) {
 	}
 }
 
+func TestPoint_MarshalString(t *testing.T) {
+	p := client.Point{Measurement: "cpu", Tags: map[string]string{"host": "serverA"}, Fields: map[string]interface{}{"value": 1.1}, Time: time.Unix(1, 0)}
+	if got, exp := p.MarshalString(), "cpu,host=serverA value=1.1 1000000000"; got != exp {
+		t.Fatalf("unexpected line: exp=%q got=%q", exp, got)
+	}
+
+	// Invalid points are encoded as an empty string.
+	p.Fields = nil
+	if got := p.MarshalString(); got != "" {
+		t.Fatalf("unexpected line: %q", got)
+	}
+}
+
 func TestEpochToTime(t *testing.T) {
 	now := time.Now()
 
@@ -306,6 +306,11 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, retentionPo
 		return ErrPartialWrite
 	}
 
+	// Let clients know they should back off and retry.
+	if writeError == tsdb.ErrWriteBufferFull {
+		return writeError
+	}
+
 	if writeError != nil {
 		return fmt.Errorf("write failed: %v", writeError)
 	}
@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/gogo/protobuf/proto"
@@ -20,14 +21,15 @@ type WritePointsRequest struct {
 
 // AddPoint adds a point to the WritePointRequest with field name 'value'
 func (w *WritePointsRequest) AddPoint(name string, value interface{}, timestamp time.Time, tags map[string]string) {
-	w.Points = append(w.Points, tsdb.NewPoint(
+	w.Points = append(w.Points, tsdb.MustNewPoint(
 		name, tags, map[string]interface{}{"value": value}, timestamp,
 	))
 }
 
 // WriteShardRequest represents the a request to write a slice of points to a shard
 type WriteShardRequest struct {
-	pb internal.WriteShardRequest
+	pb     internal.WriteShardRequest
+	points []tsdb.Point
 }
 
 // WriteShardResponse represents the response returned from a remote WriteShardRequest call
@@ -38,15 +40,18 @@ type WriteShardResponse struct {
 func (w *WriteShardRequest) SetShardID(id uint64) { w.pb.ShardID = &id }
 func (w *WriteShardRequest) ShardID() uint64      { return w.pb.GetShardID() }
 
-func (w *WriteShardRequest) Points() []tsdb.Point { return w.unmarshalPoints() }
+// Points returns the points of the request. Points received from another node
+// are decoded and validated by UnmarshalBinary.
+func (w *WriteShardRequest) Points() []tsdb.Point { return w.points }
 
 func (w *WriteShardRequest) AddPoint(name string, value interface{}, timestamp time.Time, tags map[string]string) {
-	w.AddPoints([]tsdb.Point{tsdb.NewPoint(
+	w.AddPoints([]tsdb.Point{tsdb.MustNewPoint(
 		name, tags, map[string]interface{}{"value": value}, timestamp,
 	)})
 }
 
 func (w *WriteShardRequest) AddPoints(points []tsdb.Point) {
+	w.points = append(w.points, points...)
 	w.pb.Points = append(w.pb.Points, w.marshalPoints(points)...)
 }
 
@@ -105,34 +110,37 @@ func (w *WriteShardRequest) marshalPoints(points []tsdb.Point) []*internal.Point
 }
 
 // UnmarshalBinary populates WritePointRequest from a binary format.
+// Returns an error if a point is invalid.
 func (w *WriteShardRequest) UnmarshalBinary(buf []byte) error {
 	if err := proto.Unmarshal(buf, &w.pb); err != nil {
 		return err
 	}
+	points, err := w.unmarshalPoints()
+	if err != nil {
+		return err
+	}
+	w.points = points
 	return nil
 }
 
-func (w *WriteShardRequest) unmarshalPoints() []tsdb.Point {
+func (w *WriteShardRequest) unmarshalPoints() ([]tsdb.Point, error) {
 	points := make([]tsdb.Point, len(w.pb.GetPoints()))
 	for i, p := range w.pb.GetPoints() {
-		pt := tsdb.NewPoint(
-			p.GetName(), map[string]string{},
-			map[string]interface{}{}, time.Unix(0, p.GetTime()))
-
+		fields := tsdb.Fields{}
 		for _, f := range p.GetFields() {
 			n := f.GetName()
 			if f.Int32 != nil {
-				pt.AddField(n, f.GetInt32())
+				fields[n] = f.GetInt32()
 			} else if f.Int64 != nil {
-				pt.AddField(n, f.GetInt64())
+				fields[n] = f.GetInt64()
 			} else if f.Float64 != nil {
-				pt.AddField(n, f.GetFloat64())
+				fields[n] = f.GetFloat64()
 			} else if f.Bool != nil {
-				pt.AddField(n, f.GetBool())
+				fields[n] = f.GetBool()
 			} else if f.String_ != nil {
-				pt.AddField(n, f.GetString_())
+				fields[n] = f.GetString_()
 			} else {
-				pt.AddField(n, f.GetBytes())
+				fields[n] = f.GetBytes()
 			}
 		}
 
@@ -140,10 +148,14 @@ func (w *WriteShardRequest) unmarshalPoints() []tsdb.Point {
 		for _, t := range p.GetTags() {
 			tags[t.GetKey()] = t.GetValue()
 		}
-		pt.SetTags(tags)
+
+		pt, err := tsdb.NewPoint(p.GetName(), tags, fields, time.Unix(0, p.GetTime()))
+		if err != nil {
+			return nil, fmt.Errorf("invalid point %d: %s", i, err)
+		}
 		points[i] = pt
 	}
-	return points
+	return points, nil
 }
 
 func (w *WriteShardResponse) SetCode(code int)          { w.pb.Code = proto.Int32(int32(code)) }
@@ -3,6 +3,9 @@ package cluster
 import (
 	"testing"
 	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdb/influxdb/cluster/internal"
 )
 
 func TestWriteShardRequestBinary(t *testing.T) {
@@ -73,6 +76,21 @@ func TestWriteShardRequestBinary(t *testing.T) {
 	}
 }
 
+// Ensure a request with an invalid point is rejected instead of panicking.
+func TestWriteShardRequestBinary_InvalidPoint(t *testing.T) {
+	sr := &WriteShardRequest{}
+	sr.SetShardID(uint64(1))
+	sr.pb.Points = []*internal.Point{{Name: proto.String("cpu"), Time: proto.Int64(0)}}
+
+	b, err := sr.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&WriteShardRequest{}).UnmarshalBinary(b); err == nil || err.Error() != "invalid point 0: missing fields" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestWriteShardResponseBinary(t *testing.T) {
 	sr := &WriteShardResponse{}
 	sr.SetCode(10)
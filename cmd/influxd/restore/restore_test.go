@@ -55,7 +55,7 @@ func TestRestoreCommand(t *testing.T) {
 	if err := s.CreateDatabase("db"); err != nil {
 		t.Fatalf("cannot create database: %s", err)
 	}
-	if index, err := s.WriteSeries("db", "default", []tsdb.Point{tsdb.NewPoint("cpu", nil, map[string]interface{}{"value": float64(100)}, now)}); err != nil {
+	if index, err := s.WriteSeries("db", "default", []tsdb.Point{tsdb.MustNewPoint("cpu", nil, map[string]interface{}{"value": float64(100)}, now)}); err != nil {
 		t.Fatalf("cannot write series: %s", err)
 	} else if err = s.Sync(1, index); err != nil {
 		t.Fatalf("shard sync: %s", err)
@@ -107,7 +107,7 @@ func TestRestoreCommand(t *testing.T) {
 	if err := s.CreateDatabase("newdb"); err != nil {
 		t.Fatalf("cannot create new database: %s", err)
 	}
-	if index, err := s.WriteSeries("newdb", "default", []tsdb.Point{tsdb.NewPoint("mem", nil, map[string]interface{}{"value": float64(1000)}, now)}); err != nil {
+	if index, err := s.WriteSeries("newdb", "default", []tsdb.Point{tsdb.MustNewPoint("mem", nil, map[string]interface{}{"value": float64(1000)}, now)}); err != nil {
 		t.Fatalf("cannot write new series: %s", err)
 	} else if err = s.Sync(2, index); err != nil {
 		t.Fatalf("shard sync: %s", err)
@@ -99,6 +99,10 @@ func (c *Config) Validate() error {
 		return errors.New("HintedHandoff.Dir must be specified")
 	}
 
+	if err := c.HTTPD.Validate(); err != nil {
+		return fmt.Errorf("invalid http config: %v", err)
+	}
+
 	for _, g := range c.Graphites {
 		if err := g.Validate(); err != nil {
 			return fmt.Errorf("invalid graphite config: %v", err)
@@ -44,6 +44,7 @@ type Server struct {
 
 	MetaStore     *meta.Store
 	TSDBStore     *tsdb.Store
+	WriteBuffer   *tsdb.WriteBuffer
 	QueryExecutor *tsdb.QueryExecutor
 	PointsWriter  *cluster.PointsWriter
 	ShardWriter   *cluster.ShardWriter
@@ -88,6 +89,15 @@ func NewServer(c *Config, version string) (*Server, error) {
 	s.QueryExecutor = tsdb.NewQueryExecutor(s.TSDBStore)
 	s.QueryExecutor.MetaStore = s.MetaStore
 	s.QueryExecutor.MetaStatementExecutor = &meta.StatementExecutor{Store: s.MetaStore}
+	s.QueryExecutor.MaxSelectSeriesN = c.Data.MaxSelectSeriesN
+	s.QueryExecutor.MaxSelectGroupN = c.Data.MaxSelectGroupN
+	s.QueryExecutor.MaxSelectBucketN = c.Data.MaxSelectBucketN
+	s.QueryExecutor.MaxSelectPointN = c.Data.MaxSelectPointN
+	s.QueryExecutor.MaxSortMemory = c.Data.MaxSortMemory
+	s.QueryExecutor.SortTempDir = c.Data.SortTempDir
+	s.QueryExecutor.IteratorEngine = c.Data.IteratorEngine
+	s.QueryExecutor.QueryTimeout = time.Duration(c.Data.QueryTimeout)
+	s.QueryExecutor.PlanCache = tsdb.NewPlanCache(c.Data.PlanCacheSize)
 
 	// Set the shard writer
 	s.ShardWriter = cluster.NewShardWriter(time.Duration(c.Cluster.ShardWriterTimeout))
@@ -100,6 +110,13 @@ func NewServer(c *Config, version string) (*Server, error) {
 	s.PointsWriter = cluster.NewPointsWriter()
 	s.PointsWriter.MetaStore = s.MetaStore
 	s.PointsWriter.TSDBStore = s.TSDBStore
+	if c.Data.WriteBatchSize > 0 {
+		s.WriteBuffer = tsdb.NewWriteBuffer(s.TSDBStore)
+		s.WriteBuffer.BatchSize = c.Data.WriteBatchSize
+		s.WriteBuffer.BatchTimeout = time.Duration(c.Data.WriteBatchTimeout)
+		s.WriteBuffer.MaxPendingPoints = c.Data.WriteBufferMaxPoints
+		s.PointsWriter.TSDBStore = s.WriteBuffer
+	}
 	s.PointsWriter.ShardWriter = s.ShardWriter
 	s.PointsWriter.HintedHandoff = s.HintedHandoff
 
@@ -166,6 +183,7 @@ func (s *Server) appendHTTPDService(c httpd.Config) {
 	srv := httpd.NewService(c)
 	srv.Handler.MetaStore = s.MetaStore
 	srv.Handler.QueryExecutor = s.QueryExecutor
+	srv.Handler.QueryAuthorizer = s.QueryExecutor
 	srv.Handler.PointsWriter = s.PointsWriter
 	srv.Handler.Version = s.version
 
@@ -238,6 +256,7 @@ func (s *Server) appendUDPService(c udp.Config) {
 	}
 	srv := udp.NewService(c)
 	srv.PointsWriter = s.PointsWriter
+	s.QueryExecutor.StatsReporters = append(s.QueryExecutor.StatsReporters, srv)
 	s.Services = append(s.Services, srv)
 }
 
@@ -249,6 +268,7 @@ func (s *Server) appendContinuousQueryService(c continuous_querier.Config) {
 	srv.MetaStore = s.MetaStore
 	srv.QueryExecutor = s.QueryExecutor
 	srv.PointsWriter = s.PointsWriter
+	s.QueryExecutor.ContinuousQuerier = srv
 	s.Services = append(s.Services, srv)
 }
 
@@ -338,6 +358,9 @@ func (s *Server) Close() error {
 	if s.MetaStore != nil {
 		s.MetaStore.Close()
 	}
+	if s.WriteBuffer != nil {
+		s.WriteBuffer.Close()
+	}
 	if s.TSDBStore != nil {
 		s.TSDBStore.Close()
 	}
@@ -654,7 +654,7 @@ func TestServer_Query_Count(t *testing.T) {
 		&Query{
 			name:    "selecting count(value) should succeed",
 			command: `SELECT count(value) FROM db0.rp0.cpu`,
-			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
 		},
 		&Query{
 			name:    "selecting count(*) should error",
@@ -1170,7 +1170,7 @@ func TestServer_Query_MergeMany(t *testing.T) {
 		&Query{
 			name:    "GROUP by time",
 			command: `SELECT count(value) FROM db0.rp0.cpu WHERE time >= '1970-01-01T00:00:01Z' AND time <= '1970-01-01T00:00:06Z' GROUP BY time(1s)`,
-			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","count"],"values":[["1970-01-01T00:00:01Z",10],["1970-01-01T00:00:02Z",10],["1970-01-01T00:00:03Z",10],["1970-01-01T00:00:04Z",10],["1970-01-01T00:00:05Z",7],["1970-01-01T00:00:06Z",3]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","count_value"],"values":[["1970-01-01T00:00:01Z",10],["1970-01-01T00:00:02Z",10],["1970-01-01T00:00:03Z",10],["1970-01-01T00:00:04Z",10],["1970-01-01T00:00:05Z",7],["1970-01-01T00:00:06Z",3]]}]}]}`,
 		},
 		&Query{
 			skip:    true,
@@ -1226,17 +1226,17 @@ func TestServer_Query_SLimitAndSOffset(t *testing.T) {
 		&Query{
 			name:    "SLIMIT 2 SOFFSET 1",
 			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 2 SOFFSET 1`,
-			exp:     `{"results":[{"series":[{"name":"cpu","tags":{"host":"server-2","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-3","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"cpu","tags":{"host":"server-2","region":"us-east"},"columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-3","region":"us-east"},"columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
 		},
 		&Query{
 			name:    "SLIMIT 2 SOFFSET 3",
 			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 2 SOFFSET 3`,
-			exp:     `{"results":[{"series":[{"name":"cpu","tags":{"host":"server-4","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-5","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"cpu","tags":{"host":"server-4","region":"us-east"},"columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]},{"name":"cpu","tags":{"host":"server-5","region":"us-east"},"columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
 		},
 		&Query{
 			name:    "SLIMIT 3 SOFFSET 8",
 			command: `SELECT count(value) FROM db0.rp0.cpu GROUP BY * SLIMIT 3 SOFFSET 8`,
-			exp:     `{"results":[{"series":[{"name":"cpu","tags":{"host":"server-9","region":"us-east"},"columns":["time","count"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"cpu","tags":{"host":"server-9","region":"us-east"},"columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]}`,
 		},
 	}...)
 
@@ -1395,61 +1395,61 @@ func TestServer_Query_Aggregates(t *testing.T) {
 			name:    "stddev with just one point - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT STDDEV(value) FROM int`,
-			exp:     `{"results":[{"series":[{"name":"int","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"int","columns":["time","stddev_value"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
 		},
 		&Query{
 			name:    "large mean and stddev - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEAN(value), STDDEV(value) FROM intmax`,
-			exp:     `{"results":[{"series":[{"name":"intmax","columns":["time","mean","stddev"],"values":[["1970-01-01T00:00:00Z",` + maxInt64() + `,0]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmax","columns":["time","mean_value","stddev_value"],"values":[["1970-01-01T00:00:00Z",` + maxInt64() + `,0]]}]}]}`,
 		},
 		&Query{
 			name:    "mean and stddev - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEAN(value), STDDEV(value) FROM intmany WHERE time >= '2000-01-01' AND time < '2000-01-01T00:02:00Z' GROUP BY time(10m)`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","mean","stddev"],"values":[["2000-01-01T00:00:00Z",5,2.138089935299395]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","mean_value","stddev_value"],"values":[["2000-01-01T00:00:00Z",5,2.138089935299395]]}]}]}`,
 		},
 		&Query{
 			name:    "first - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT FIRST(value) FROM intmany`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","first"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","first_value"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
 		},
 		&Query{
 			name:    "last - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT LAST(value) FROM intmany`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","last"],"values":[["1970-01-01T00:00:00Z",9]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","last_value"],"values":[["1970-01-01T00:00:00Z",9]]}]}]}`,
 		},
 		&Query{
 			name:    "spread - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT SPREAD(value) FROM intmany`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","spread"],"values":[["1970-01-01T00:00:00Z",7]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","spread_value"],"values":[["1970-01-01T00:00:00Z",7]]}]}]}`,
 		},
 		&Query{
 			name:    "median - even count - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEDIAN(value) FROM intmany`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",4.5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","median_value"],"values":[["1970-01-01T00:00:00Z",4.5]]}]}]}`,
 		},
 		&Query{
 			name:    "median - odd count - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEDIAN(value) FROM intmany where time < '2000-01-01T00:01:10Z'`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","median_value"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
 		},
 		&Query{
 			name:    "distinct as call - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT DISTINCT(value) FROM intmany`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z",[2,4,5,7,9]]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","distinct_value"],"values":[["1970-01-01T00:00:00Z",[2,4,5,7,9]]]}]}]}`,
 		},
 		&Query{
 			name:    "distinct alt syntax - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT DISTINCT value FROM intmany`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z",[2,4,5,7,9]]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","distinct_value"],"values":[["1970-01-01T00:00:00Z",[2,4,5,7,9]]]}]}]}`,
 		},
 		&Query{
 			name:    "distinct select tag - int",
@@ -1467,13 +1467,13 @@ func TestServer_Query_Aggregates(t *testing.T) {
 			name:    "count distinct - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT COUNT(DISTINCT value) FROM intmany`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","count_distinct_value"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
 		},
 		&Query{
 			name:    "count distinct as call - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT COUNT(DISTINCT(value)) FROM intmany`,
-			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intmany","columns":["time","count_distinct_value"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
 		},
 		&Query{
 			name:    "count distinct select tag - int",
@@ -1491,32 +1491,31 @@ func TestServer_Query_Aggregates(t *testing.T) {
 			name:    "aggregation with no interval - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT count(value) FROM intoverlap WHERE time = '2000-01-01 00:00:00'`,
-			exp:     `{"results":[{"series":[{"name":"intoverlap","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intoverlap","columns":["time","count_value"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
 		},
 		&Query{
 			name:    "sum - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT SUM(value) FROM intoverlap WHERE time >= '2000-01-01 00:00:05' AND time <= '2000-01-01T00:00:10Z' GROUP BY time(10s), region`,
-			exp:     `{"results":[{"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",null],["2000-01-01T00:00:10Z",30]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum_value"],"values":[["2000-01-01T00:00:00Z",null],["2000-01-01T00:00:10Z",30]]}]}]}`,
 		},
 		&Query{
 			name:    "aggregation with a null field value - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT SUM(value) FROM intoverlap GROUP BY region`,
-			exp:     `{"results":[{"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",50]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum_value"],"values":[["1970-01-01T00:00:00Z",50]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum_value"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
 		},
 		&Query{
 			name:    "multiple aggregations - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT SUM(value), MEAN(value) FROM intoverlap GROUP BY region`,
-			exp:     `{"results":[{"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",50,25]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",100,100]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum_value","mean_value"],"values":[["1970-01-01T00:00:00Z",50,25]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum_value","mean_value"],"values":[["1970-01-01T00:00:00Z",100,100]]}]}]}`,
 		},
 		&Query{
-			skip:    true,
-			name:    "multiple aggregations with division - int FIXME issue #2879",
+			name:    "multiple aggregations with division - int",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT sum(value), mean(value), sum(value) / mean(value) as div FROM intoverlap GROUP BY region`,
-			exp:     `{"results":[{"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum","mean","div"],"values":[["1970-01-01T00:00:00Z",50,25,2]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","div"],"values":[["1970-01-01T00:00:00Z",100,100,1]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"intoverlap","tags":{"region":"us-east"},"columns":["time","sum_value","mean_value","div"],"values":[["1970-01-01T00:00:00Z",50,25,2]]},{"name":"intoverlap","tags":{"region":"us-west"},"columns":["time","sum_value","mean_value","div"],"values":[["1970-01-01T00:00:00Z",100,100,1]]}]}]}`,
 		},
 
 		// float64
@@ -1524,61 +1523,61 @@ func TestServer_Query_Aggregates(t *testing.T) {
 			name:    "stddev with just one point - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT STDDEV(value) FROM floatsingle`,
-			exp:     `{"results":[{"series":[{"name":"floatsingle","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatsingle","columns":["time","stddev_value"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
 		},
 		&Query{
 			name:    "large mean and stddev - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEAN(value), STDDEV(value) FROM floatmax`,
-			exp:     `{"results":[{"series":[{"name":"floatmax","columns":["time","mean","stddev"],"values":[["1970-01-01T00:00:00Z",` + maxFloat64() + `,0]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmax","columns":["time","mean_value","stddev_value"],"values":[["1970-01-01T00:00:00Z",` + maxFloat64() + `,0]]}]}]}`,
 		},
 		&Query{
 			name:    "mean and stddev - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEAN(value), STDDEV(value) FROM floatmany WHERE time >= '2000-01-01' AND time < '2000-01-01T00:02:00Z' GROUP BY time(10m)`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","mean","stddev"],"values":[["2000-01-01T00:00:00Z",5,2.138089935299395]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","mean_value","stddev_value"],"values":[["2000-01-01T00:00:00Z",5,2.138089935299395]]}]}]}`,
 		},
 		&Query{
 			name:    "first - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT FIRST(value) FROM floatmany`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","first"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","first_value"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
 		},
 		&Query{
 			name:    "last - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT LAST(value) FROM floatmany`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","last"],"values":[["1970-01-01T00:00:00Z",9]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","last_value"],"values":[["1970-01-01T00:00:00Z",9]]}]}]}`,
 		},
 		&Query{
 			name:    "spread - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT SPREAD(value) FROM floatmany`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","spread"],"values":[["1970-01-01T00:00:00Z",7]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","spread_value"],"values":[["1970-01-01T00:00:00Z",7]]}]}]}`,
 		},
 		&Query{
 			name:    "median - even count - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEDIAN(value) FROM floatmany`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",4.5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","median_value"],"values":[["1970-01-01T00:00:00Z",4.5]]}]}]}`,
 		},
 		&Query{
 			name:    "median - odd count - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEDIAN(value) FROM floatmany where time < '2000-01-01T00:01:10Z'`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","median_value"],"values":[["1970-01-01T00:00:00Z",4]]}]}]}`,
 		},
 		&Query{
 			name:    "distinct as call - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT DISTINCT(value) FROM floatmany`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z",[2,4,5,7,9]]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","distinct_value"],"values":[["1970-01-01T00:00:00Z",[2,4,5,7,9]]]}]}]}`,
 		},
 		&Query{
 			name:    "distinct alt syntax - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT DISTINCT value FROM floatmany`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","distinct"],"values":[["1970-01-01T00:00:00Z",[2,4,5,7,9]]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","distinct_value"],"values":[["1970-01-01T00:00:00Z",[2,4,5,7,9]]]}]}]}`,
 		},
 		&Query{
 			name:    "distinct select tag - float",
@@ -1596,13 +1595,13 @@ func TestServer_Query_Aggregates(t *testing.T) {
 			name:    "count distinct - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT COUNT(DISTINCT value) FROM floatmany`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","count_distinct_value"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
 		},
 		&Query{
 			name:    "count distinct as call - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT COUNT(DISTINCT(value)) FROM floatmany`,
-			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatmany","columns":["time","count_distinct_value"],"values":[["1970-01-01T00:00:00Z",5]]}]}]}`,
 		},
 		&Query{
 			name:    "count distinct select tag - float",
@@ -1620,25 +1619,25 @@ func TestServer_Query_Aggregates(t *testing.T) {
 			name:    "aggregation with no interval - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT count(value) FROM floatoverlap WHERE time = '2000-01-01 00:00:00'`,
-			exp:     `{"results":[{"series":[{"name":"floatoverlap","columns":["time","count"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatoverlap","columns":["time","count_value"],"values":[["2000-01-01T00:00:00Z",2]]}]}]}`,
 		},
 		&Query{
 			name:    "sum - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT SUM(value) FROM floatoverlap WHERE time >= '2000-01-01 00:00:05' AND time <= '2000-01-01T00:00:10Z' GROUP BY time(10s), region`,
-			exp:     `{"results":[{"series":[{"name":"floatoverlap","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["2000-01-01T00:00:00Z",null],["2000-01-01T00:00:10Z",30]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatoverlap","tags":{"region":"us-east"},"columns":["time","sum_value"],"values":[["2000-01-01T00:00:00Z",null],["2000-01-01T00:00:10Z",30]]}]}]}`,
 		},
 		&Query{
 			name:    "aggregation with a null field value - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT SUM(value) FROM floatoverlap GROUP BY region`,
-			exp:     `{"results":[{"series":[{"name":"floatoverlap","tags":{"region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",50]]},{"name":"floatoverlap","tags":{"region":"us-west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatoverlap","tags":{"region":"us-east"},"columns":["time","sum_value"],"values":[["1970-01-01T00:00:00Z",50]]},{"name":"floatoverlap","tags":{"region":"us-west"},"columns":["time","sum_value"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
 		},
 		&Query{
 			name:    "multiple aggregations - float",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT SUM(value), MEAN(value) FROM floatoverlap GROUP BY region`,
-			exp:     `{"results":[{"series":[{"name":"floatoverlap","tags":{"region":"us-east"},"columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",50,25]]},{"name":"floatoverlap","tags":{"region":"us-west"},"columns":["time","sum","mean"],"values":[["1970-01-01T00:00:00Z",100,100]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"floatoverlap","tags":{"region":"us-east"},"columns":["time","sum_value","mean_value"],"values":[["1970-01-01T00:00:00Z",50,25]]},{"name":"floatoverlap","tags":{"region":"us-west"},"columns":["time","sum_value","mean_value"],"values":[["1970-01-01T00:00:00Z",100,100]]}]}]}`,
 		},
 		&Query{
 			name:    "multiple aggregations with division - float",
@@ -1652,37 +1651,37 @@ func TestServer_Query_Aggregates(t *testing.T) {
 			name:    "STDDEV on string data - string",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT STDDEV(value) FROM stringdata`,
-			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","stddev"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","stddev_value"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
 		},
 		&Query{
 			name:    "MEAN on string data - string",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEAN(value) FROM stringdata`,
-			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","mean_value"],"values":[["1970-01-01T00:00:00Z",0]]}]}]}`,
 		},
 		&Query{
 			name:    "MEDIAN on string data - string",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT MEDIAN(value) FROM stringdata`,
-			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","median"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","median_value"],"values":[["1970-01-01T00:00:00Z",null]]}]}]}`,
 		},
 		&Query{
 			name:    "COUNT on string data - string",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT COUNT(value) FROM stringdata`,
-			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","count"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",2]]}]}]}`,
 		},
 		&Query{
 			name:    "FIRST on string data - string",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT FIRST(value) FROM stringdata`,
-			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","first"],"values":[["1970-01-01T00:00:00Z","first"]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","first_value"],"values":[["1970-01-01T00:00:00Z","first"]]}]}]}`,
 		},
 		&Query{
 			name:    "LAST on string data - string",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT LAST(value) FROM stringdata`,
-			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","last"],"values":[["1970-01-01T00:00:00Z","last"]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"stringdata","columns":["time","last_value"],"values":[["1970-01-01T00:00:00Z","last"]]}]}]}`,
 		},
 
 		// general queries
@@ -1690,13 +1689,13 @@ func TestServer_Query_Aggregates(t *testing.T) {
 			name:    "group by multiple dimensions",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT sum(value) FROM load GROUP BY region, host`,
-			exp:     `{"results":[{"series":[{"name":"load","tags":{"host":"serverA","region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",20]]},{"name":"load","tags":{"host":"serverB","region":"us-east"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",30]]},{"name":"load","tags":{"host":"serverC","region":"us-west"},"columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"load","tags":{"host":"serverA","region":"us-east"},"columns":["time","sum_value"],"values":[["1970-01-01T00:00:00Z",20]]},{"name":"load","tags":{"host":"serverB","region":"us-east"},"columns":["time","sum_value"],"values":[["1970-01-01T00:00:00Z",30]]},{"name":"load","tags":{"host":"serverC","region":"us-west"},"columns":["time","sum_value"],"values":[["1970-01-01T00:00:00Z",100]]}]}]}`,
 		},
 		&Query{
 			name:    "aggregation with WHERE and AND",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT sum(value) FROM cpu WHERE region='uk' AND host='serverZ'`,
-			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","sum"],"values":[["1970-01-01T00:00:00Z",50]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"cpu","columns":["time","sum_value"],"values":[["1970-01-01T00:00:00Z",50]]}]}]}`,
 		},
 	}...)
 
@@ -1869,13 +1868,13 @@ func TestServer_Query_Wildcards(t *testing.T) {
 			name:    "GROUP BY queries",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT mean(value) FROM wgroup GROUP BY *`,
-			exp:     `{"results":[{"series":[{"name":"wgroup","tags":{"region":"us-east"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",15]]},{"name":"wgroup","tags":{"region":"us-west"},"columns":["time","mean"],"values":[["1970-01-01T00:00:00Z",30]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"wgroup","tags":{"region":"us-east"},"columns":["time","mean_value"],"values":[["1970-01-01T00:00:00Z",15]]},{"name":"wgroup","tags":{"region":"us-west"},"columns":["time","mean_value"],"values":[["1970-01-01T00:00:00Z",30]]}]}]}`,
 		},
 		&Query{
 			name:    "GROUP BY queries with time",
 			params:  url.Values{"db": []string{"db0"}},
 			command: `SELECT mean(value) FROM wgroup WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-01T00:01:00Z' GROUP BY *,TIME(1m)`,
-			exp:     `{"results":[{"series":[{"name":"wgroup","tags":{"region":"us-east"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",15]]},{"name":"wgroup","tags":{"region":"us-west"},"columns":["time","mean"],"values":[["2000-01-01T00:00:00Z",30]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"wgroup","tags":{"region":"us-east"},"columns":["time","mean_value"],"values":[["2000-01-01T00:00:00Z",15]]},{"name":"wgroup","tags":{"region":"us-west"},"columns":["time","mean_value"],"values":[["2000-01-01T00:00:00Z",30]]}]}]}`,
 		},
 	}...)
 
@@ -2288,31 +2287,31 @@ func TestServer_Query_LimitAndOffset(t *testing.T) {
 		&Query{
 			name:    "limit on points with group by time",
 			command: `select mean(foo) from "limit" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 2`,
-			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean_foo"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "limit higher than the number of data points with group by time",
 			command: `select mean(foo) from "limit" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 20`,
-			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4],["2009-11-10T23:00:05Z",5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean_foo"],"values":[["2009-11-10T23:00:02Z",2],["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4],["2009-11-10T23:00:05Z",5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "limit and offset with group by time",
 			command: `select mean(foo) from "limit" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 2 OFFSET 1`,
-			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean"],"values":[["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean_foo"],"values":[["2009-11-10T23:00:03Z",3],["2009-11-10T23:00:04Z",4]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "limit + offset equal to the  number of points with group by time",
 			command: `select mean(foo) from "limit" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 3 OFFSET 3`,
-			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean_foo"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "limit + offset equal to the  number of points with group by time",
 			command: `select mean(foo) from "limit" WHERE time >= '2009-11-10T23:00:02Z' AND time < '2009-11-10T23:00:06Z' GROUP BY TIME(1s) LIMIT 3 OFFSET 3`,
-			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"limit","columns":["time","mean_foo"],"values":[["2009-11-10T23:00:05Z",5]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
@@ -2330,7 +2329,7 @@ func TestServer_Query_LimitAndOffset(t *testing.T) {
 		&Query{
 			name:    "limit1 higher than MaxGroupBy but the number of data points is less than MaxGroupBy",
 			command: `select mean(foo)  from "limit"  where  time >= '2009-11-10T23:00:02Z' and time < '2009-11-10T23:00:03Z' group by time(1s), * fill(0)  limit 2147483647`,
-			exp:     `{"results":[{"series":[{"name":"limit","tags":{"tennant":"paul"},"columns":["time","mean"],"values":[["2009-11-10T23:00:02Z",2]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"limit","tags":{"tennant":"paul"},"columns":["time","mean_foo"],"values":[["2009-11-10T23:00:02Z",2]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
@@ -2379,37 +2378,37 @@ func TestServer_Query_Fill(t *testing.T) {
 		&Query{
 			name:    "fill with value",
 			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(1)`,
-			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",10]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","mean_val"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",1],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "fill with previous",
 			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(previous)`,
-			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",4],["2009-11-10T23:00:15Z",10]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","mean_val"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",4],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "fill with none, i.e. clear out nulls",
 			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) FILL(none)`,
-			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:15Z",10]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","mean_val"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "fill defaults to null",
 			command: `select mean(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s)`,
-			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","mean"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",null],["2009-11-10T23:00:15Z",10]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","mean_val"],"values":[["2009-11-10T23:00:00Z",4],["2009-11-10T23:00:05Z",4],["2009-11-10T23:00:10Z",null],["2009-11-10T23:00:15Z",10]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "fill with count aggregate defaults to null",
 			command: `select count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s)`,
-			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",null],["2009-11-10T23:00:15Z",1]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","count_val"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",null],["2009-11-10T23:00:15Z",1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 		&Query{
 			name:    "fill with count aggregate specific value",
 			command: `select count(val) from fills where time >= '2009-11-10T23:00:00Z' and time < '2009-11-10T23:00:20Z' group by time(5s) fill(1234)`,
-			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","count"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",1234],["2009-11-10T23:00:15Z",1]]}]}]}`,
+			exp:     `{"results":[{"series":[{"name":"fills","columns":["time","count_val"],"values":[["2009-11-10T23:00:00Z",2],["2009-11-10T23:00:05Z",1],["2009-11-10T23:00:10Z",1234],["2009-11-10T23:00:15Z",1]]}]}]}`,
 			params:  url.Values{"db": []string{"db0"}},
 		},
 	}...)
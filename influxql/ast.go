@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"regexp"
 	"sort"
 	"strconv"
@@ -73,41 +75,72 @@ func (d DataType) String() string {
 type Node interface {
 	node()
 	String() string
+
+	// Pos returns the position of the node's first token in the query text.
+	// Nodes that were not created by the parser return the zero position.
+	Pos() Pos
+}
+
+// NodePos is embedded in AST nodes to record their position in the query text.
+type NodePos struct {
+	Position Pos
+}
+
+// Pos returns the position of the node's first token.
+func (n NodePos) Pos() Pos { return n.Position }
+
+// setPos sets the position of the node's first token.
+func (n *NodePos) setPos(pos Pos) { n.Position = pos }
+
+// setPos sets the position of a node if the node records one.
+func setPos(n Node, pos Pos) {
+	if n, ok := n.(interface {
+		setPos(Pos)
+	}); ok {
+		n.setPos(pos)
+	}
 }
 
 func (*Query) node()     {}
 func (Statements) node() {}
 
-func (*AlterRetentionPolicyStatement) node()  {}
-func (*CreateContinuousQueryStatement) node() {}
-func (*CreateDatabaseStatement) node()        {}
-func (*CreateRetentionPolicyStatement) node() {}
-func (*CreateUserStatement) node()            {}
-func (*Distinct) node()                       {}
-func (*DeleteStatement) node()                {}
-func (*DropContinuousQueryStatement) node()   {}
-func (*DropDatabaseStatement) node()          {}
-func (*DropMeasurementStatement) node()       {}
-func (*DropRetentionPolicyStatement) node()   {}
-func (*DropSeriesStatement) node()            {}
-func (*DropUserStatement) node()              {}
-func (*GrantStatement) node()                 {}
-func (*ShowContinuousQueriesStatement) node() {}
-func (*ShowGrantsForUserStatement) node()     {}
-func (*ShowServersStatement) node()           {}
-func (*ShowDatabasesStatement) node()         {}
-func (*ShowFieldKeysStatement) node()         {}
-func (*ShowRetentionPoliciesStatement) node() {}
-func (*ShowMeasurementsStatement) node()      {}
-func (*ShowSeriesStatement) node()            {}
-func (*ShowStatsStatement) node()             {}
-func (*ShowDiagnosticsStatement) node()       {}
-func (*ShowTagKeysStatement) node()           {}
-func (*ShowTagValuesStatement) node()         {}
-func (*ShowUsersStatement) node()             {}
-func (*RevokeStatement) node()                {}
-func (*SelectStatement) node()                {}
-func (*SetPasswordUserStatement) node()       {}
+func (*AlterRetentionPolicyStatement) node()    {}
+func (*BackfillContinuousQueryStatement) node() {}
+func (*CreateContinuousQueryStatement) node()   {}
+func (*CreateDatabaseStatement) node()          {}
+func (*CreateRetentionPolicyStatement) node()   {}
+func (*CreateSubscriptionStatement) node()      {}
+func (*CreateUserStatement) node()              {}
+func (*Distinct) node()                         {}
+func (*DeleteStatement) node()                  {}
+func (*DropContinuousQueryStatement) node()     {}
+func (*DropDatabaseStatement) node()            {}
+func (*DropMeasurementStatement) node()         {}
+func (*DropRetentionPolicyStatement) node()     {}
+func (*DropSeriesStatement) node()              {}
+func (*DropSubscriptionStatement) node()        {}
+func (*DropUserStatement) node()                {}
+func (*ExplainStatement) node()                 {}
+func (*GrantStatement) node()                   {}
+func (*KillQueryStatement) node()               {}
+func (*ShowContinuousQueriesStatement) node()   {}
+func (*ShowGrantsForUserStatement) node()       {}
+func (*ShowServersStatement) node()             {}
+func (*ShowDatabasesStatement) node()           {}
+func (*ShowFieldKeysStatement) node()           {}
+func (*ShowRetentionPoliciesStatement) node()   {}
+func (*ShowMeasurementsStatement) node()        {}
+func (*ShowQueriesStatement) node()             {}
+func (*ShowSeriesStatement) node()              {}
+func (*ShowStatsStatement) node()               {}
+func (*ShowSubscriptionsStatement) node()       {}
+func (*ShowDiagnosticsStatement) node()         {}
+func (*ShowTagKeysStatement) node()             {}
+func (*ShowTagValuesStatement) node()           {}
+func (*ShowUsersStatement) node()               {}
+func (*RevokeStatement) node()                  {}
+func (*SelectStatement) node()                  {}
+func (*SetPasswordUserStatement) node()         {}
 
 func (*BinaryExpr) node()      {}
 func (*BooleanLiteral) node()  {}
@@ -116,6 +149,8 @@ func (*Dimension) node()       {}
 func (Dimensions) node()       {}
 func (*DurationLiteral) node() {}
 func (*Field) node()           {}
+func (*IntegerLiteral) node()  {}
+func (*IsNullExpr) node()      {}
 func (Fields) node()           {}
 func (*Measurement) node()     {}
 func (Measurements) node()     {}
@@ -129,6 +164,7 @@ func (Sources) node()          {}
 func (*StringLiteral) node()   {}
 func (*Target) node()          {}
 func (*TimeLiteral) node()     {}
+func (*UnaryExpr) node()       {}
 func (*VarRef) node()          {}
 func (*Wildcard) node()        {}
 
@@ -140,6 +176,29 @@ type Query struct {
 // String returns a string representation of the query.
 func (q *Query) String() string { return q.Statements.String() }
 
+// Pos returns the position of the first statement in the query.
+func (q *Query) Pos() Pos { return q.Statements.Pos() }
+
+// Sanitize returns a copy of the query that is safe to log. Passwords are
+// always masked and string literal values are masked if maskLiterals is set.
+func (q *Query) Sanitize(maskLiterals bool) *Query {
+	other := &Query{Statements: make(Statements, len(q.Statements))}
+	for i, stmt := range q.Statements {
+		other.Statements[i] = SanitizeStatement(stmt, maskLiterals)
+	}
+	return other
+}
+
+// RequiredPrivileges returns the privileges required to execute every
+// statement in the query with duplicates removed.
+func (q *Query) RequiredPrivileges() ExecutionPrivileges {
+	var ep ExecutionPrivileges
+	for _, stmt := range q.Statements {
+		ep = ep.Merge(stmt.RequiredPrivileges())
+	}
+	return ep
+}
+
 // Statements represents a list of statements.
 type Statements []Statement
 
@@ -152,6 +211,14 @@ func (a Statements) String() string {
 	return strings.Join(str, ";\n")
 }
 
+// Pos returns the position of the first statement.
+func (a Statements) Pos() Pos {
+	if len(a) == 0 {
+		return Pos{}
+	}
+	return a[0].Pos()
+}
+
 // Statement represents a single command in InfluxQL.
 type Statement interface {
 	Node
@@ -173,6 +240,10 @@ type ExecutionPrivilege struct {
 	// If "", then the resource is the cluster.
 	Name string
 
+	// Name of the measurement in the database, if the privilege is only
+	// required on a single measurement.
+	Measurement string
+
 	// Privilege required.
 	Privilege Privilege
 }
@@ -180,35 +251,281 @@ type ExecutionPrivilege struct {
 // ExecutionPrivileges is a list of privileges required to execute a statement.
 type ExecutionPrivileges []ExecutionPrivilege
 
-func (*AlterRetentionPolicyStatement) stmt()  {}
-func (*CreateContinuousQueryStatement) stmt() {}
-func (*CreateDatabaseStatement) stmt()        {}
-func (*CreateRetentionPolicyStatement) stmt() {}
-func (*CreateUserStatement) stmt()            {}
-func (*DeleteStatement) stmt()                {}
-func (*DropContinuousQueryStatement) stmt()   {}
-func (*DropDatabaseStatement) stmt()          {}
-func (*DropMeasurementStatement) stmt()       {}
-func (*DropRetentionPolicyStatement) stmt()   {}
-func (*DropSeriesStatement) stmt()            {}
-func (*DropUserStatement) stmt()              {}
-func (*GrantStatement) stmt()                 {}
-func (*ShowContinuousQueriesStatement) stmt() {}
-func (*ShowGrantsForUserStatement) stmt()     {}
-func (*ShowServersStatement) stmt()           {}
-func (*ShowDatabasesStatement) stmt()         {}
-func (*ShowFieldKeysStatement) stmt()         {}
-func (*ShowMeasurementsStatement) stmt()      {}
-func (*ShowRetentionPoliciesStatement) stmt() {}
-func (*ShowSeriesStatement) stmt()            {}
-func (*ShowStatsStatement) stmt()             {}
-func (*ShowDiagnosticsStatement) stmt()       {}
-func (*ShowTagKeysStatement) stmt()           {}
-func (*ShowTagValuesStatement) stmt()         {}
-func (*ShowUsersStatement) stmt()             {}
-func (*RevokeStatement) stmt()                {}
-func (*SelectStatement) stmt()                {}
-func (*SetPasswordUserStatement) stmt()       {}
+// Merge returns the privileges in a and other with duplicates removed.
+func (a ExecutionPrivileges) Merge(other ExecutionPrivileges) ExecutionPrivileges {
+	merged := make(ExecutionPrivileges, 0, len(a)+len(other))
+	merged = append(merged, a...)
+	merged = append(merged, other...)
+	return merged.Dedupe()
+}
+
+// Dedupe returns a copy of a with a single privilege per database and
+// measurement. Since a higher privilege implies the lower ones, only the
+// highest privilege required on each resource is kept. The order in which
+// resources first appear is preserved.
+func (a ExecutionPrivileges) Dedupe() ExecutionPrivileges {
+	type resource struct{ name, measurement string }
+
+	var other ExecutionPrivileges
+	index := make(map[resource]int)
+	for _, p := range a {
+		r := resource{p.Name, p.Measurement}
+		if i, ok := index[r]; ok {
+			if p.Privilege > other[i].Privilege {
+				other[i].Privilege = p.Privilege
+			}
+			continue
+		}
+		index[r] = len(other)
+		other = append(other, p)
+	}
+	return other
+}
+
+// Unsatisfied returns the privileges in a that are not granted by g.
+// Privileges without a database name are checked against defaultDatabase and
+// are returned with that name filled in.
+func (a ExecutionPrivileges) Unsatisfied(g Grants, defaultDatabase string) ExecutionPrivileges {
+	var other ExecutionPrivileges
+	for _, p := range a {
+		if p.Name == "" {
+			p.Name = defaultDatabase
+		}
+		if !g.AuthorizeMeasurement(p.Privilege, p.Name, p.Measurement) {
+			other = append(other, p)
+		}
+	}
+	return other
+}
+
+// DatabasePrivileges returns the highest privilege required on the cluster and
+// on each database. Privileges without a database name are required on
+// defaultDatabase or, if it's blank, on the cluster. Privileges on a single
+// measurement are counted against the whole database.
+func (a ExecutionPrivileges) DatabasePrivileges(defaultDatabase string) (cluster Privilege, databases map[string]Privilege) {
+	databases = make(map[string]Privilege)
+	for _, p := range a {
+		name := p.Name
+		if name == "" {
+			name = defaultDatabase
+		}
+
+		if name == "" {
+			if p.Privilege > cluster {
+				cluster = p.Privilege
+			}
+		} else if p.Privilege > databases[name] {
+			databases[name] = p.Privilege
+		}
+	}
+	return cluster, databases
+}
+
+// SatisfiedBy returns true if every privilege in a is granted by g.
+func (a ExecutionPrivileges) SatisfiedBy(g Grants, defaultDatabase string) bool {
+	return len(a.Unsatisfied(g, defaultDatabase)) == 0
+}
+
+// Grants represents the set of privileges granted to a user.
+type Grants interface {
+	// AuthorizeMeasurement returns true if privilege is granted on the
+	// measurement in database. A blank measurement refers to the whole
+	// database and a blank database refers to the cluster.
+	AuthorizeMeasurement(privilege Privilege, database, measurement string) bool
+}
+
+// StatementKind is the broad category of a statement. It allows statements to
+// be audited without switching on every statement type.
+type StatementKind int
+
+const (
+	// ReadStatement reads points or series data.
+	ReadStatement StatementKind = iota
+	// WriteStatement writes or removes points or series data.
+	WriteStatement
+	// MetaStatement reads or changes databases, retention policies,
+	// continuous queries or subscriptions.
+	MetaStatement
+	// AdminStatement manages users, privileges or the cluster.
+	AdminStatement
+)
+
+// String returns a string representation of the statement kind.
+func (k StatementKind) String() string {
+	switch k {
+	case ReadStatement:
+		return "read"
+	case WriteStatement:
+		return "write"
+	case MetaStatement:
+		return "meta"
+	case AdminStatement:
+		return "admin"
+	}
+	return ""
+}
+
+// StatementType returns the kind of stmt. A SELECT statement with an INTO
+// clause is a write since it stores its results.
+func StatementType(stmt Statement) StatementKind {
+	switch stmt := stmt.(type) {
+	case *SelectStatement:
+		if stmt.Target != nil {
+			return WriteStatement
+		}
+		return ReadStatement
+	case *ExplainStatement:
+		return ReadStatement
+	case *ShowSeriesStatement, *ShowMeasurementsStatement, *ShowTagKeysStatement,
+		*ShowTagValuesStatement, *ShowFieldKeysStatement:
+		return ReadStatement
+	case *DeleteStatement, *DropSeriesStatement, *DropMeasurementStatement:
+		return WriteStatement
+	case *CreateUserStatement, *DropUserStatement, *SetPasswordUserStatement,
+		*GrantStatement, *RevokeStatement, *ShowUsersStatement, *ShowGrantsForUserStatement,
+		*ShowServersStatement, *ShowStatsStatement, *ShowDiagnosticsStatement,
+		*ShowQueriesStatement, *KillQueryStatement, *BackfillContinuousQueryStatement:
+		return AdminStatement
+	}
+	return MetaStatement
+}
+
+// AffectedDatabases returns the databases that stmt reads from or changes, in
+// the order they first appear. Data that isn't qualified by a database is
+// attributed to defaultDatabase. Statements that only affect users or the
+// cluster return no databases.
+func AffectedDatabases(stmt Statement, defaultDatabase string) []string {
+	var names []string
+	switch stmt := stmt.(type) {
+	case *SelectStatement:
+		names = sourceDatabases(stmt.Sources)
+		if stmt.Target != nil {
+			names = append(names, stmt.Target.Measurement.Database)
+		}
+	case *ExplainStatement:
+		if stmt.Statement != nil {
+			names = AffectedDatabases(stmt.Statement, defaultDatabase)
+		}
+	case *CreateContinuousQueryStatement:
+		names = []string{stmt.Database}
+		if stmt.Source != nil {
+			names = append(names, AffectedDatabases(stmt.Source, stmt.Database)...)
+		}
+	case *DeleteStatement:
+		names = sourceDatabases(Sources{stmt.Source})
+	case *DropSeriesStatement:
+		names = sourceDatabases(stmt.Sources)
+	case *ShowSeriesStatement:
+		names = sourceDatabases(stmt.Sources)
+	case *ShowTagKeysStatement:
+		names = sourceDatabases(stmt.Sources)
+	case *ShowTagValuesStatement:
+		names = sourceDatabases(stmt.Sources)
+	case *ShowFieldKeysStatement:
+		names = sourceDatabases(stmt.Sources)
+	case *ShowMeasurementsStatement, *DropMeasurementStatement:
+		names = []string{""}
+	case *CreateDatabaseStatement:
+		names = []string{stmt.Name}
+	case *DropDatabaseStatement:
+		names = []string{stmt.Name}
+	case *CreateRetentionPolicyStatement:
+		names = []string{stmt.Database}
+	case *AlterRetentionPolicyStatement:
+		names = []string{stmt.Database}
+	case *DropRetentionPolicyStatement:
+		names = []string{stmt.Database}
+	case *ShowRetentionPoliciesStatement:
+		names = []string{stmt.Database}
+	case *DropContinuousQueryStatement:
+		names = []string{stmt.Database}
+	case *BackfillContinuousQueryStatement:
+		names = []string{stmt.Database}
+	case *ShowContinuousQueriesStatement:
+		if stmt.Database != "" {
+			names = []string{stmt.Database}
+		}
+	case *CreateSubscriptionStatement:
+		names = []string{stmt.Database}
+	case *DropSubscriptionStatement:
+		names = []string{stmt.Database}
+	case *GrantStatement:
+		if stmt.On != "" {
+			names = []string{stmt.On}
+		}
+	case *RevokeStatement:
+		if stmt.On != "" {
+			names = []string{stmt.On}
+		}
+	}
+
+	// Resolve unqualified names and remove duplicates.
+	var a []string
+	seen := make(map[string]struct{})
+	for _, name := range names {
+		if name == "" {
+			name = defaultDatabase
+		}
+		if _, ok := seen[name]; ok || name == "" {
+			continue
+		}
+		seen[name] = struct{}{}
+		a = append(a, name)
+	}
+	return a
+}
+
+// sourceDatabases returns the database of each measurement in sources. Blank
+// names are returned for sources without a database.
+func sourceDatabases(sources Sources) []string {
+	var names []string
+	for _, src := range sources {
+		if m, ok := src.(*Measurement); ok {
+			names = append(names, m.Database)
+		}
+	}
+	if len(names) == 0 {
+		names = append(names, "")
+	}
+	return names
+}
+
+func (*AlterRetentionPolicyStatement) stmt()    {}
+func (*BackfillContinuousQueryStatement) stmt() {}
+func (*CreateContinuousQueryStatement) stmt()   {}
+func (*CreateDatabaseStatement) stmt()          {}
+func (*CreateRetentionPolicyStatement) stmt()   {}
+func (*CreateSubscriptionStatement) stmt()      {}
+func (*CreateUserStatement) stmt()              {}
+func (*DeleteStatement) stmt()                  {}
+func (*DropContinuousQueryStatement) stmt()     {}
+func (*DropDatabaseStatement) stmt()            {}
+func (*DropMeasurementStatement) stmt()         {}
+func (*DropRetentionPolicyStatement) stmt()     {}
+func (*DropSeriesStatement) stmt()              {}
+func (*DropSubscriptionStatement) stmt()        {}
+func (*DropUserStatement) stmt()                {}
+func (*ExplainStatement) stmt()                 {}
+func (*GrantStatement) stmt()                   {}
+func (*KillQueryStatement) stmt()               {}
+func (*ShowContinuousQueriesStatement) stmt()   {}
+func (*ShowGrantsForUserStatement) stmt()       {}
+func (*ShowServersStatement) stmt()             {}
+func (*ShowDatabasesStatement) stmt()           {}
+func (*ShowFieldKeysStatement) stmt()           {}
+func (*ShowMeasurementsStatement) stmt()        {}
+func (*ShowQueriesStatement) stmt()             {}
+func (*ShowRetentionPoliciesStatement) stmt()   {}
+func (*ShowSeriesStatement) stmt()              {}
+func (*ShowStatsStatement) stmt()               {}
+func (*ShowSubscriptionsStatement) stmt()       {}
+func (*ShowDiagnosticsStatement) stmt()         {}
+func (*ShowTagKeysStatement) stmt()             {}
+func (*ShowTagValuesStatement) stmt()           {}
+func (*ShowUsersStatement) stmt()               {}
+func (*RevokeStatement) stmt()                  {}
+func (*SelectStatement) stmt()                  {}
+func (*SetPasswordUserStatement) stmt()         {}
 
 // Expr represents an expression that can be evaluated to a value.
 type Expr interface {
@@ -221,12 +538,15 @@ func (*BooleanLiteral) expr()  {}
 func (*Call) expr()            {}
 func (*Distinct) expr()        {}
 func (*DurationLiteral) expr() {}
+func (*IntegerLiteral) expr()  {}
+func (*IsNullExpr) expr()      {}
 func (*nilLiteral) expr()      {}
 func (*NumberLiteral) expr()   {}
 func (*ParenExpr) expr()       {}
 func (*RegexLiteral) expr()    {}
 func (*StringLiteral) expr()   {}
 func (*TimeLiteral) expr()     {}
+func (*UnaryExpr) expr()       {}
 func (*VarRef) expr()          {}
 func (*Wildcard) expr()        {}
 
@@ -241,6 +561,14 @@ func (*Measurement) source() {}
 // Sources represents a list of sources.
 type Sources []Source
 
+// Pos returns the position of the first source.
+func (a Sources) Pos() Pos {
+	if len(a) == 0 {
+		return Pos{}
+	}
+	return a[0].Pos()
+}
+
 // String returns a string representation of a Sources array.
 func (a Sources) String() string {
 	var buf bytes.Buffer
@@ -258,6 +586,8 @@ func (a Sources) String() string {
 
 // SortField represents a field to sort results by.
 type SortField struct {
+	NodePos
+
 	// Name of the field
 	Name string
 
@@ -268,15 +598,29 @@ type SortField struct {
 // String returns a string representation of a sort field
 func (field *SortField) String() string {
 	var buf bytes.Buffer
-	_, _ = buf.WriteString(field.Name)
-	_, _ = buf.WriteString(" ")
-	_, _ = buf.WriteString(strconv.FormatBool(field.Ascending))
+	if field.Name != "" {
+		_, _ = buf.WriteString(field.Name)
+		_, _ = buf.WriteString(" ")
+	}
+	if field.Ascending {
+		_, _ = buf.WriteString("ASC")
+	} else {
+		_, _ = buf.WriteString("DESC")
+	}
 	return buf.String()
 }
 
 // SortFields represents an ordered list of ORDER BY fields
 type SortFields []*SortField
 
+// Pos returns the position of the first sort field.
+func (a SortFields) Pos() Pos {
+	if len(a) == 0 {
+		return Pos{}
+	}
+	return a[0].Pos()
+}
+
 // String returns a string representation of sort fields
 func (a SortFields) String() string {
 	fields := make([]string, 0, len(a))
@@ -288,6 +632,8 @@ func (a SortFields) String() string {
 
 // CreateDatabaseStatement represents a command for creating a new database.
 type CreateDatabaseStatement struct {
+	NodePos
+
 	// Name of the database to be created.
 	Name string
 }
@@ -300,6 +646,12 @@ func (s *CreateDatabaseStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *CreateDatabaseStatement) Clone() *CreateDatabaseStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a CreateDatabaseStatement.
 func (s *CreateDatabaseStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -307,6 +659,8 @@ func (s *CreateDatabaseStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // DropDatabaseStatement represents a command to drop a database.
 type DropDatabaseStatement struct {
+	NodePos
+
 	// Name of the database to be dropped.
 	Name string
 }
@@ -319,6 +673,12 @@ func (s *DropDatabaseStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *DropDatabaseStatement) Clone() *DropDatabaseStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a DropDatabaseStatement.
 func (s *DropDatabaseStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -326,6 +686,8 @@ func (s *DropDatabaseStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // DropRetentionPolicyStatement represents a command to drop a retention policy from a database.
 type DropRetentionPolicyStatement struct {
+	NodePos
+
 	// Name of the policy to drop.
 	Name string
 
@@ -343,6 +705,12 @@ func (s *DropRetentionPolicyStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *DropRetentionPolicyStatement) Clone() *DropRetentionPolicyStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a DropRetentionPolicyStatement.
 func (s *DropRetentionPolicyStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: s.Database, Privilege: WritePrivilege}}
@@ -350,32 +718,39 @@ func (s *DropRetentionPolicyStatement) RequiredPrivileges() ExecutionPrivileges
 
 // CreateUserStatement represents a command for creating a new user.
 type CreateUserStatement struct {
+	NodePos
+
 	// Name of the user to be created.
 	Name string
 
 	// User's password
 	Password string
 
-	// User's privilege level.
-	Privilege *Privilege
+	// True if the user is created with all privileges on the cluster.
+	Admin bool
 }
 
 // String returns a string representation of the create user statement.
 func (s *CreateUserStatement) String() string {
 	var buf bytes.Buffer
 	_, _ = buf.WriteString("CREATE USER ")
-	_, _ = buf.WriteString(s.Name)
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
 	_, _ = buf.WriteString(" WITH PASSWORD ")
-	_, _ = buf.WriteString(s.Password)
+	_, _ = buf.WriteString(QuoteString(s.Password))
 
-	if s.Privilege != nil {
-		_, _ = buf.WriteString(" WITH ")
-		_, _ = buf.WriteString(s.Privilege.String())
+	if s.Admin {
+		_, _ = buf.WriteString(" WITH ALL PRIVILEGES")
 	}
 
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *CreateUserStatement) Clone() *CreateUserStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a CreateUserStatement.
 func (s *CreateUserStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -383,6 +758,8 @@ func (s *CreateUserStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // DropUserStatement represents a command for dropping a user.
 type DropUserStatement struct {
+	NodePos
+
 	// Name of the user to drop.
 	Name string
 }
@@ -395,6 +772,12 @@ func (s *DropUserStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *DropUserStatement) Clone() *DropUserStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a DropUserStatement.
 func (s *DropUserStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -434,12 +817,17 @@ func (p Privilege) String() string {
 
 // GrantStatement represents a command for granting a privilege.
 type GrantStatement struct {
+	NodePos
+
 	// The privilege to be granted.
 	Privilege Privilege
 
 	// Thing to grant privilege on (e.g., a DB).
 	On string
 
+	// Measurement in the database to grant the privilege on, if any.
+	Measurement string
+
 	// Who to grant the privilege to.
 	User string
 }
@@ -452,12 +840,22 @@ func (s *GrantStatement) String() string {
 	if s.On != "" {
 		_, _ = buf.WriteString(" ON ")
 		_, _ = buf.WriteString(s.On)
+		if s.Measurement != "" {
+			_, _ = buf.WriteString(".")
+			_, _ = buf.WriteString(s.Measurement)
+		}
 	}
 	_, _ = buf.WriteString(" TO ")
 	_, _ = buf.WriteString(s.User)
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *GrantStatement) Clone() *GrantStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a GrantStatement.
 func (s *GrantStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -465,6 +863,8 @@ func (s *GrantStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // SetPasswordUserStatement represents a command for chaning user password.
 type SetPasswordUserStatement struct {
+	NodePos
+
 	// Plain Password
 	Password string
 
@@ -476,25 +876,36 @@ type SetPasswordUserStatement struct {
 func (s *SetPasswordUserStatement) String() string {
 	var buf bytes.Buffer
 	_, _ = buf.WriteString("SET PASSWORD FOR ")
-	_, _ = buf.WriteString(s.Name)
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
 	_, _ = buf.WriteString(" = ")
-	_, _ = buf.WriteString(s.Password)
+	_, _ = buf.WriteString(QuoteString(s.Password))
 	return buf.String()
 }
 
-// RequiredPrivileges returns the privilege required to execute a GrantStatement.
+// Clone returns a deep copy of the statement.
+func (s *SetPasswordUserStatement) Clone() *SetPasswordUserStatement {
+	other := *s
+	return &other
+}
+
+// RequiredPrivileges returns the privilege required to execute a SetPasswordUserStatement.
 func (s *SetPasswordUserStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
 // RevokeStatement represents a command to revoke a privilege from a user.
 type RevokeStatement struct {
+	NodePos
+
 	// Privilege to be revoked.
 	Privilege Privilege
 
 	// Thing to revoke privilege to (e.g., a DB)
 	On string
 
+	// Measurement in the database to revoke the privilege on, if any.
+	Measurement string
+
 	// Who to revoke privilege from.
 	User string
 }
@@ -507,12 +918,22 @@ func (s *RevokeStatement) String() string {
 	if s.On != "" {
 		_, _ = buf.WriteString(" ON ")
 		_, _ = buf.WriteString(s.On)
+		if s.Measurement != "" {
+			_, _ = buf.WriteString(".")
+			_, _ = buf.WriteString(s.Measurement)
+		}
 	}
 	_, _ = buf.WriteString(" FROM ")
 	_, _ = buf.WriteString(s.User)
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *RevokeStatement) Clone() *RevokeStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a RevokeStatement.
 func (s *RevokeStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -520,6 +941,8 @@ func (s *RevokeStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // CreateRetentionPolicyStatement represents a command to create a retention policy.
 type CreateRetentionPolicyStatement struct {
+	NodePos
+
 	// Name of policy to create.
 	Name string
 
@@ -553,6 +976,12 @@ func (s *CreateRetentionPolicyStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *CreateRetentionPolicyStatement) Clone() *CreateRetentionPolicyStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a CreateRetentionPolicyStatement.
 func (s *CreateRetentionPolicyStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -560,6 +989,8 @@ func (s *CreateRetentionPolicyStatement) RequiredPrivileges() ExecutionPrivilege
 
 // AlterRetentionPolicyStatement represents a command to alter an existing retention policy.
 type AlterRetentionPolicyStatement struct {
+	NodePos
+
 	// Name of policy to alter.
 	Name string
 
@@ -601,6 +1032,20 @@ func (s *AlterRetentionPolicyStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *AlterRetentionPolicyStatement) Clone() *AlterRetentionPolicyStatement {
+	other := *s
+	if s.Duration != nil {
+		d := *s.Duration
+		other.Duration = &d
+	}
+	if s.Replication != nil {
+		n := *s.Replication
+		other.Replication = &n
+	}
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute an AlterRetentionPolicyStatement.
 func (s *AlterRetentionPolicyStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -621,6 +1066,8 @@ const (
 
 // SelectStatement represents a command for extracting data from the database.
 type SelectStatement struct {
+	NodePos
+
 	// Expressions returned from the selection.
 	Fields Fields
 
@@ -652,9 +1099,12 @@ type SelectStatement struct {
 	SOffset int
 
 	// memoize the group by interval
-	groupByInterval time.Duration
+	groupByInterval    time.Duration
+	groupByIntervalErr error
+	groupByIntervalSet bool
 
-	// if it's a query for raw data values (i.e. not an aggregate)
+	// if it's a query for raw data values (i.e. not an aggregate). Queries
+	// that mix raw fields and aggregates are rejected by the parser.
 	IsRawQuery bool
 
 	// What fill option the select statement uses, if any
@@ -664,6 +1114,12 @@ type SelectStatement struct {
 	FillValue interface{}
 }
 
+// IsDescending returns true if the statement is ordered by time with the
+// latest values first.
+func (s *SelectStatement) IsDescending() bool {
+	return len(s.SortFields) > 0 && !s.SortFields[0].Ascending
+}
+
 // HasDerivative returns true if one of the function calls in the statement is a
 // derivative aggregate
 func (s *SelectStatement) HasDerivative() bool {
@@ -675,6 +1131,40 @@ func (s *SelectStatement) HasDerivative() bool {
 	return false
 }
 
+// HasHoltWinters returns true if one of the function calls is a holt_winters forecast.
+func (s *SelectStatement) HasHoltWinters() bool {
+	for _, f := range s.FunctionCalls() {
+		if strings.HasPrefix(f.Name, "holt_winters") {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTransform returns true if one of the function calls is a moving_average,
+// cumulative_sum, difference, elapsed or sample transformation.
+func (s *SelectStatement) HasTransform() bool {
+	for _, f := range s.FunctionCalls() {
+		if isTransform(f.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSimpleTransform returns true if one of the function calls is a transformation
+// with a variable ref as the first arg.
+func (s *SelectStatement) IsSimpleTransform() bool {
+	for _, f := range s.FunctionCalls() {
+		if isTransform(f.Name) {
+			if _, ok := f.Args[0].(*VarRef); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // IsSimpleDerivative return true if one of the function call is a derivative function with a
 // variable ref as the first arg
 func (s *SelectStatement) IsSimpleDerivative() bool {
@@ -692,10 +1182,12 @@ func (s *SelectStatement) IsSimpleDerivative() bool {
 // Clone returns a deep copy of the statement.
 func (s *SelectStatement) Clone() *SelectStatement {
 	clone := &SelectStatement{
+		NodePos:    s.NodePos,
 		Fields:     make(Fields, 0, len(s.Fields)),
 		Dimensions: make(Dimensions, 0, len(s.Dimensions)),
 		Sources:    cloneSources(s.Sources),
-		SortFields: make(SortFields, 0, len(s.SortFields)),
+		SortFields: cloneSortFields(s.SortFields),
+		Target:     cloneTarget(s.Target),
 		Condition:  CloneExpr(s.Condition),
 		Limit:      s.Limit,
 		Offset:     s.Offset,
@@ -704,30 +1196,24 @@ func (s *SelectStatement) Clone() *SelectStatement {
 		Fill:       s.Fill,
 		FillValue:  s.FillValue,
 		IsRawQuery: s.IsRawQuery,
-	}
-	if s.Target != nil {
-		clone.Target = &Target{
-			Measurement: &Measurement{
-				Database:        s.Target.Measurement.Database,
-				RetentionPolicy: s.Target.Measurement.RetentionPolicy,
-				Name:            s.Target.Measurement.Name,
-				Regex:           CloneRegexLiteral(s.Target.Measurement.Regex),
-			},
-		}
+
+		groupByInterval:    s.groupByInterval,
+		groupByIntervalErr: s.groupByIntervalErr,
+		groupByIntervalSet: s.groupByIntervalSet,
 	}
 	for _, f := range s.Fields {
-		clone.Fields = append(clone.Fields, &Field{Expr: CloneExpr(f.Expr), Alias: f.Alias})
+		clone.Fields = append(clone.Fields, &Field{NodePos: f.NodePos, Expr: CloneExpr(f.Expr), Alias: f.Alias})
 	}
 	for _, d := range s.Dimensions {
-		clone.Dimensions = append(clone.Dimensions, &Dimension{Expr: CloneExpr(d.Expr)})
-	}
-	for _, f := range s.SortFields {
-		clone.SortFields = append(clone.SortFields, &SortField{Name: f.Name, Ascending: f.Ascending})
+		clone.Dimensions = append(clone.Dimensions, &Dimension{NodePos: d.NodePos, Expr: CloneExpr(d.Expr)})
 	}
 	return clone
 }
 
 func cloneSources(sources Sources) Sources {
+	if sources == nil {
+		return nil
+	}
 	clone := make(Sources, 0, len(sources))
 	for _, s := range sources {
 		clone = append(clone, cloneSource(s))
@@ -742,30 +1228,66 @@ func cloneSource(s Source) Source {
 
 	switch s := s.(type) {
 	case *Measurement:
-		m := &Measurement{Database: s.Database, RetentionPolicy: s.RetentionPolicy, Name: s.Name}
-		if s.Regex != nil {
-			m.Regex = &RegexLiteral{Val: regexp.MustCompile(s.Regex.Val.String())}
-		}
-		return m
+		return cloneMeasurement(s)
 	default:
 		panic("unreachable")
 	}
 }
 
+func cloneMeasurement(m *Measurement) *Measurement {
+	if m == nil {
+		return nil
+	}
+	return &Measurement{
+		NodePos:         m.NodePos,
+		Database:        m.Database,
+		RetentionPolicy: m.RetentionPolicy,
+		Name:            m.Name,
+		Regex:           CloneRegexLiteral(m.Regex),
+	}
+}
+
+func cloneTarget(t *Target) *Target {
+	if t == nil {
+		return nil
+	}
+	return &Target{NodePos: t.NodePos, Measurement: cloneMeasurement(t.Measurement), Backref: t.Backref}
+}
+
+func cloneSortFields(fields SortFields) SortFields {
+	if fields == nil {
+		return nil
+	}
+	clone := make(SortFields, 0, len(fields))
+	for _, f := range fields {
+		clone = append(clone, &SortField{NodePos: f.NodePos, Name: f.Name, Ascending: f.Ascending})
+	}
+	return clone
+}
+
 // RewriteWildcards returns the re-written form of the select statement. Any wildcard query
-// fields are replaced with the supplied fields, and any wildcard GROUP BY fields are replaced
-// with the supplied dimensions.
-func (s *SelectStatement) RewriteWildcards(fields Fields, dimensions Dimensions) *SelectStatement {
+// fields are replaced with the supplied field keys, and any wildcard GROUP BY fields are
+// replaced with the supplied tag keys. Regex GROUP BY fields are replaced with the supplied
+// tag keys that match. Keys are expanded in sorted order.
+func (s *SelectStatement) RewriteWildcards(fieldKeys, tagKeys []string) *SelectStatement {
 	other := s.Clone()
 	selectWildcard, groupWildcard := false, false
 
+	// Sort the keys for consistent output without reordering the caller's keys.
+	var fields Fields
+	for _, key := range sortedKeys(fieldKeys) {
+		fields = append(fields, &Field{Expr: &VarRef{Val: key}})
+	}
+	var dimensions Dimensions
+	for _, key := range sortedKeys(tagKeys) {
+		dimensions = append(dimensions, &Dimension{Expr: &VarRef{Val: key}})
+	}
+
 	// Rewrite all wildcard query fields
 	rwFields := make(Fields, 0, len(s.Fields))
 	for _, f := range s.Fields {
 		switch f.Expr.(type) {
 		case *Wildcard:
-			// Sort wildcard fields for consistent output
-			sort.Sort(fields)
 			rwFields = append(rwFields, fields...)
 			selectWildcard = true
 		default:
@@ -777,10 +1299,16 @@ func (s *SelectStatement) RewriteWildcards(fields Fields, dimensions Dimensions)
 	// Rewrite all wildcard GROUP BY fields
 	rwDimensions := make(Dimensions, 0, len(s.Dimensions))
 	for _, d := range s.Dimensions {
-		switch d.Expr.(type) {
+		switch expr := d.Expr.(type) {
 		case *Wildcard:
 			rwDimensions = append(rwDimensions, dimensions...)
 			groupWildcard = true
+		case *RegexLiteral:
+			for _, dim := range dimensions {
+				if ref, ok := dim.Expr.(*VarRef); ok && expr.Val.MatchString(ref.Val) {
+					rwDimensions = append(rwDimensions, dim)
+				}
+			}
 		default:
 			rwDimensions = append(rwDimensions, d)
 		}
@@ -794,6 +1322,13 @@ func (s *SelectStatement) RewriteWildcards(fields Fields, dimensions Dimensions)
 	return other
 }
 
+// sortedKeys returns a sorted copy of keys.
+func sortedKeys(keys []string) []string {
+	a := append([]string{}, keys...)
+	sort.Strings(a)
+	return a
+}
+
 // RewriteDistinct rewrites the expresion to be a call for map/reduce to work correctly
 // This method assumes all validation has passed
 func (s *SelectStatement) RewriteDistinct() {
@@ -846,15 +1381,39 @@ func (s *SelectStatement) String() string {
 		_, _ = buf.WriteString(" OFFSET ")
 		_, _ = buf.WriteString(strconv.Itoa(s.Offset))
 	}
+	if s.SLimit > 0 {
+		_, _ = fmt.Fprintf(&buf, " SLIMIT %d", s.SLimit)
+	}
+	if s.SOffset > 0 {
+		_, _ = fmt.Fprintf(&buf, " SOFFSET %d", s.SOffset)
+	}
 	return buf.String()
 }
 
 // RequiredPrivileges returns the privilege required to execute the SelectStatement.
 func (s *SelectStatement) RequiredPrivileges() ExecutionPrivileges {
-	ep := ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+	// Read access is required on the measurement of every source. Sources
+	// without a database are read from the database the query is run against
+	// and regex sources require access to the whole database.
+	var ep ExecutionPrivileges
+	seen := make(map[ExecutionPrivilege]struct{})
+	for _, src := range s.Sources {
+		p := ExecutionPrivilege{Privilege: ReadPrivilege}
+		if m, ok := src.(*Measurement); ok {
+			p.Name, p.Measurement = m.Database, m.Name
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		ep = append(ep, p)
+	}
+	if len(ep) == 0 {
+		ep = ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+	}
 
 	if s.Target != nil {
-		p := ExecutionPrivilege{Name: s.Target.Measurement.Database, Privilege: WritePrivilege}
+		p := ExecutionPrivilege{Name: s.Target.Measurement.Database, Measurement: s.Target.Measurement.Name, Privilege: WritePrivilege}
 		ep = append(ep, p)
 	}
 	return ep
@@ -885,7 +1444,8 @@ func (s *SelectStatement) walkForTime(node Node) bool {
 	}
 }
 
-// HasWildcard returns whether or not the select statement has at least 1 wildcard
+// HasWildcard returns whether or not the select statement has at least 1 wildcard.
+// Regex dimensions are wildcards over the matching tag keys.
 func (s *SelectStatement) HasWildcard() bool {
 	for _, f := range s.Fields {
 		_, ok := f.Expr.(*Wildcard)
@@ -895,8 +1455,8 @@ func (s *SelectStatement) HasWildcard() bool {
 	}
 
 	for _, d := range s.Dimensions {
-		_, ok := d.Expr.(*Wildcard)
-		if ok {
+		switch d.Expr.(type) {
+		case *Wildcard, *RegexLiteral:
 			return true
 		}
 	}
@@ -924,7 +1484,50 @@ func (s *SelectStatement) hasTimeDimensions(node Node) bool {
 	}
 }
 
+// Reduce returns a copy of the statement with its condition, fields and time
+// dimension arguments reduced using the values in valuer. This is typically
+// used to replace now() with the same time throughout an execution.
+func (s *SelectStatement) Reduce(valuer Valuer) *SelectStatement {
+	other := s.Clone()
+	other.Condition = Reduce(other.Condition, valuer)
+	for _, f := range other.Fields {
+		f.Expr = Reduce(f.Expr, valuer)
+	}
+	for _, d := range other.Dimensions {
+		if call, ok := d.Expr.(*Call); ok && call.Name == "time" {
+			for i, arg := range call.Args {
+				call.Args[i] = Reduce(arg, valuer)
+			}
+		}
+	}
+
+	// The interval is read again from the reduced dimensions.
+	other.groupByInterval, other.groupByIntervalErr, other.groupByIntervalSet = 0, nil, false
+
+	return other
+}
+
+// Validate returns an error if the statement breaks a rule that isn't enforced
+// by the grammar, such as mixing aggregates with raw fields. The parser
+// validates the statements it returns, so this is only needed for statements
+// that are built or rewritten in code. The error is a *ParseError positioned
+// at the start of the statement.
+func (s *SelectStatement) Validate() error {
+	if err := s.validate(targetNotRequired); err != nil {
+		return &ParseError{Message: err.Error(), Pos: s.Pos()}
+	}
+	return nil
+}
+
 func (s *SelectStatement) validate(tr targetRequirement) error {
+	if err := s.validateDimensions(); err != nil {
+		return err
+	}
+
+	if err := s.validateAliases(); err != nil {
+		return err
+	}
+
 	if err := s.validateDistinct(); err != nil {
 		return err
 	}
@@ -941,25 +1544,70 @@ func (s *SelectStatement) validate(tr targetRequirement) error {
 		return err
 	}
 
+	if err := s.validateHoltWinters(); err != nil {
+		return err
+	}
+
+	if err := s.validateTransform(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (s *SelectStatement) validateAggregates(tr targetRequirement) error {
-	// First, determine if specific calls have at least one and only one argument
+func (s *SelectStatement) validateAliases() error {
+	// Generated names are made distinct by ColumnNames but the same alias
+	// can't be given to more than one field.
+	aliases := make(map[string]struct{})
 	for _, f := range s.Fields {
-		if c, ok := f.Expr.(*Call); ok {
-			switch c.Name {
-			case "derivative", "non_negative_derivative":
-				if min, max, got := 1, 2, len(c.Args); got > max || got < min {
-					return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", c.Name, min, max, got)
-				}
-			case "percentile":
-				if exp, got := 2, len(c.Args); got != exp {
-					return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", c.Name, exp, got)
+		if f.Alias == "" {
+			continue
+		} else if _, ok := aliases[f.Alias]; ok {
+			return fmt.Errorf("duplicate alias in select clause: %s", QuoteIdent(f.Alias))
+		}
+		aliases[f.Alias] = struct{}{}
+	}
+	return nil
+}
+
+func (s *SelectStatement) validateDimensions() error {
+	interval, err := s.GroupByInterval()
+	if err != nil {
+		return err
+	}
+
+	// Empty intervals only exist when grouping by time.
+	if s.Fill != NullFill && interval == 0 {
+		return fmt.Errorf("fill() requires a GROUP BY time interval")
+	}
+
+	return nil
+}
+
+func (s *SelectStatement) validateAggregates(tr targetRequirement) error {
+	// First, ensure every call has the right number and type of arguments.
+	// Nested calls are validated with the call they're passed to.
+	for _, c := range s.aggregateCalls() {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Aggregates return one value per interval, so raw fields can't be returned
+	// alongside them. Math on aggregates is applied to the values of each
+	// interval, so the operands must all be aggregates or literals.
+	if !s.IsRawQuery {
+		for _, f := range s.Fields {
+			switch expr := f.Expr.(type) {
+			case *VarRef:
+				// A tag in the GROUP BY clause has one value per series. Derivatives,
+				// forecasts and transforms report other fields with their own errors.
+				if !s.isGroupedBy(expr.Val) && !s.HasDerivative() && !s.HasHoltWinters() && !s.HasTransform() {
+					return fmt.Errorf("mixing aggregate and non-aggregate fields is not supported: %s", f.Expr)
 				}
-			default:
-				if exp, got := 1, len(c.Args); got != exp {
-					return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", c.Name, exp, got)
+			case *BinaryExpr, *ParenExpr, *UnaryExpr, *IsNullExpr:
+				if hasBareVarRef(f.Expr) {
+					return fmt.Errorf("mixing aggregate and non-aggregate fields is not supported: %s", f.Expr)
 				}
 			}
 		}
@@ -999,6 +1647,39 @@ func (s *SelectStatement) HasDistinct() bool {
 	return false
 }
 
+// hasBareVarRef returns true if expr references a field outside of a function call.
+// isGroupedBy returns true if the statement groups by the named tag, either
+// explicitly or with a wildcard.
+func (s *SelectStatement) isGroupedBy(name string) bool {
+	for _, d := range s.Dimensions {
+		if _, ok := d.Expr.(*Wildcard); ok {
+			return true
+		}
+	}
+	for _, n := range s.NamesInDimension() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasBareVarRef(expr Expr) bool {
+	switch expr := expr.(type) {
+	case *VarRef:
+		return true
+	case *BinaryExpr:
+		return hasBareVarRef(expr.LHS) || hasBareVarRef(expr.RHS)
+	case *ParenExpr:
+		return hasBareVarRef(expr.Expr)
+	case *UnaryExpr:
+		return hasBareVarRef(expr.Expr)
+	case *IsNullExpr:
+		return hasBareVarRef(expr.Expr)
+	}
+	return false
+}
+
 func (s *SelectStatement) validateDistinct() error {
 	if !s.HasDistinct() {
 		return nil
@@ -1087,7 +1768,7 @@ func (s *SelectStatement) validateDerivative() error {
 		return fmt.Errorf("derivative cannot be used with other fields")
 	}
 
-	aggr := s.FunctionCalls()
+	aggr := s.aggregateCalls()
 	if len(aggr) != 1 {
 		return fmt.Errorf("derivative cannot be used with other fields")
 	}
@@ -1109,82 +1790,171 @@ func (s *SelectStatement) validateDerivative() error {
 	// If a duration arg is pased, make sure it's a duration
 	if len(derivativeCall.Args) == 2 {
 		// Second must be a duration .e.g (1h)
-		if _, ok := derivativeCall.Args[1].(*DurationLiteral); !ok {
+		d, ok := derivativeCall.Args[1].(*DurationLiteral)
+		if !ok {
 			return fmt.Errorf("derivative requires a duration argument")
 		}
+
+		// Rates are normalized to the duration so it can't be zero
+		if d.Val <= 0 {
+			return fmt.Errorf("%s duration must be positive", derivativeCall.Name)
+		}
 	}
 
 	return nil
 }
 
-// GroupByIterval extracts the time interval, if specified.
-func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
-	// return if we've already pulled it out
-	if s.groupByInterval != 0 {
-		return s.groupByInterval, nil
+func (s *SelectStatement) validateHoltWinters() error {
+	if !s.HasHoltWinters() {
+		return nil
 	}
 
-	// Ignore if there are no dimensions.
-	if len(s.Dimensions) == 0 {
-		return 0, nil
+	// Forecasts are computed from the single column of aggregated results so
+	// holt_winters must be the only field in the query.
+	calls := s.aggregateCalls()
+	if len(s.Fields) != 1 || len(calls) != 1 {
+		return fmt.Errorf("holt_winters cannot be used with other fields")
 	}
+	c := calls[0]
 
-	for _, d := range s.Dimensions {
-		if call, ok := d.Expr.(*Call); ok && call.Name == "time" {
-			// Make sure there is exactly one argument.
-			if len(call.Args) != 1 {
-				return 0, errors.New("time dimension expected one argument")
-			}
+	// The aggregate being forecast must be grouped into regular intervals.
+	if interval, err := s.GroupByInterval(); err != nil {
+		return err
+	} else if interval == 0 {
+		return fmt.Errorf("%s requires a GROUP BY time interval", c.Name)
+	}
+
+	if n, ok := c.Args[1].(*IntegerLiteral); !ok || n.Val <= 0 {
+		return fmt.Errorf("%s requires a positive integer number of points to forecast", c.Name)
+	}
+	if season, ok := c.Args[2].(*IntegerLiteral); !ok || season.Val < 0 {
+		return fmt.Errorf("%s requires a non-negative integer season length", c.Name)
+	}
+
+	return nil
+}
 
-			// Ensure the argument is a duration.
-			lit, ok := call.Args[0].(*DurationLiteral)
-			if !ok {
-				return 0, errors.New("time dimension must have one duration argument")
+func (s *SelectStatement) validateTransform() error {
+	if !s.HasTransform() {
+		return nil
+	}
+
+	// Transforms are applied to the single column of values so they must be
+	// the only field in the query.
+	calls := s.aggregateCalls()
+	if len(s.Fields) != 1 || len(calls) != 1 {
+		for _, c := range calls {
+			if isTransform(c.Name) {
+				return fmt.Errorf("%s cannot be used with other fields", c.Name)
 			}
-			s.groupByInterval = lit.Val
-			return lit.Val, nil
 		}
 	}
-	return 0, nil
+	c := calls[0]
+
+	interval, err := s.GroupByInterval()
+	if err != nil {
+		return err
+	}
+
+	switch arg := c.Args[0].(type) {
+	case *Call:
+		// Only aggregates can be transformed, not the output of another transformation.
+		if isTransform(arg.Name) || strings.HasSuffix(arg.Name, "derivative") || strings.HasPrefix(arg.Name, "holt_winters") {
+			return fmt.Errorf("%s() cannot be nested in %s()", arg.Name, c.Name)
+		}
+		if interval == 0 {
+			return fmt.Errorf("%s of an aggregate requires a GROUP BY time interval", c.Name)
+		}
+	case *VarRef:
+		if interval != 0 {
+			return fmt.Errorf("%s of a field cannot be used with a GROUP BY time interval", c.Name)
+		}
+	}
+
+	if c.Name == "moving_average" {
+		if n, ok := c.Args[1].(*IntegerLiteral); !ok || n.Val < 2 {
+			return fmt.Errorf("moving_average window must be an integer greater than 1")
+		}
+	}
+
+	if c.Name == "sample" {
+		if n, ok := c.Args[1].(*IntegerLiteral); !ok || n.Val <= 0 {
+			return fmt.Errorf("sample requires a positive integer number of points")
+		}
+	}
+
+	if c.Name == "elapsed" && len(c.Args) == 2 {
+		if d, ok := c.Args[1].(*DurationLiteral); !ok || d.Val <= 0 {
+			return fmt.Errorf("elapsed unit must be a positive duration")
+		}
+	}
+
+	return nil
+}
+
+// GroupByInterval extracts the time interval, if specified.
+// Returns 0 if the statement isn't grouped by time. The dimensions are
+// validated by Dimensions.Normalize() and the result is memoized.
+func (s *SelectStatement) GroupByInterval() (time.Duration, error) {
+	// return if we've already pulled it out
+	if s.groupByIntervalSet {
+		return s.groupByInterval, s.groupByIntervalErr
+	}
+
+	// Wildcards and regexes are expanded into tags before the statement is executed.
+	dimensions := make(Dimensions, 0, len(s.Dimensions))
+	for _, d := range s.Dimensions {
+		switch d.Expr.(type) {
+		case *Wildcard, *RegexLiteral:
+		default:
+			dimensions = append(dimensions, d)
+		}
+	}
+
+	s.groupByInterval, _, s.groupByIntervalErr = dimensions.Normalize()
+	s.groupByIntervalSet = true
+	return s.groupByInterval, s.groupByIntervalErr
 }
 
 // SetTimeRange sets the start and end time of the select statement to [start, end). i.e. start inclusive, end exclusive.
+// Any existing time conditions are replaced while the rest of the condition is kept.
 // This is used commonly for continuous queries so the start and end are in buckets.
 func (s *SelectStatement) SetTimeRange(start, end time.Time) error {
-	cond := fmt.Sprintf("time >= '%s' AND time < '%s'", start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
-	if s.Condition != nil {
-		cond = fmt.Sprintf("%s AND %s", s.rewriteWithoutTimeDimensions(), cond)
+	if !start.Before(end) {
+		return fmt.Errorf("invalid time range: start time %s is not before end time %s",
+			start.UTC().Format(DateTimeFormat), end.UTC().Format(DateTimeFormat))
 	}
 
-	expr, err := NewParser(strings.NewReader(cond)).ParseExpr()
-	if err != nil {
-		return err
+	var cond Expr = &BinaryExpr{
+		Op:  AND,
+		LHS: &BinaryExpr{Op: GTE, LHS: &VarRef{Val: "time"}, RHS: &TimeLiteral{Val: start.UTC()}},
+		RHS: &BinaryExpr{Op: LT, LHS: &VarRef{Val: "time"}, RHS: &TimeLiteral{Val: end.UTC()}},
+	}
+	if s.Condition != nil {
+		// Drop the rest of the condition if it doesn't filter anything.
+		if other := s.rewriteWithoutTimeDimensions(); ConditionTruth(other) != AlwaysTrue {
+			cond = &BinaryExpr{Op: AND, LHS: parenthesize(other, AND, false), RHS: cond}
+		}
 	}
 
-	// fold out any previously replaced time dimensios and set the condition
-	s.Condition = Reduce(expr, nil)
+	// fold out any previously replaced time dimensions and set the condition
+	s.Condition = Reduce(cond, nil)
 
 	return nil
 }
 
 // rewriteWithoutTimeDimensions will remove any WHERE time... clauses from the select statement
 // This is necessary when setting an explicit time range to override any that previously existed.
-func (s *SelectStatement) rewriteWithoutTimeDimensions() string {
-	n := RewriteFunc(s.Condition, func(n Node) Node {
-		switch n := n.(type) {
-		case *BinaryExpr:
-			if n.LHS.String() == "time" {
+func (s *SelectStatement) rewriteWithoutTimeDimensions() Expr {
+	return RewriteExpr(CloneExpr(s.Condition), func(e Expr) Expr {
+		if e, ok := e.(*BinaryExpr); ok && (isTimeRef(e.LHS) || isTimeRef(e.RHS)) {
+			switch e.Op {
+			case EQ, NEQ, LT, LTE, GT, GTE:
 				return &BooleanLiteral{Val: true}
 			}
-			return n
-		case *Call:
-			return &BooleanLiteral{Val: true}
-		default:
-			return n
 		}
+		return e
 	})
-
-	return n.String()
 }
 
 /*
@@ -1220,20 +1990,15 @@ WHERE yyy.region == "uswest"
 */
 
 // Substatement returns a single-series statement for a given variable reference.
+// The dimensions, target, limits, offsets and fill option are kept and the
+// condition is filtered to the parts that apply to the source of the reference.
 func (s *SelectStatement) Substatement(ref *VarRef) (*SelectStatement, error) {
 	// Copy dimensions and properties to new statement.
-	other := &SelectStatement{
-		Fields:     Fields{{Expr: ref}},
-		Dimensions: s.Dimensions,
-		Limit:      s.Limit,
-		Offset:     s.Offset,
-		SortFields: s.SortFields,
-	}
+	other := s.Clone()
+	other.Fields = Fields{{Expr: ref}}
 
 	// If there is only one series source then return it with the whole condition.
 	if len(s.Sources) == 1 {
-		other.Sources = s.Sources
-		other.Condition = s.Condition
 		return other, nil
 	}
 
@@ -1242,7 +2007,12 @@ func (s *SelectStatement) Substatement(ref *VarRef) (*SelectStatement, error) {
 	if name == "" {
 		return nil, fmt.Errorf("field source not found: %s", ref.Val)
 	}
-	other.Sources = append(other.Sources, &Measurement{Name: name})
+	for _, src := range s.Sources {
+		if m, ok := src.(*Measurement); ok && m.Name == name {
+			other.Sources = cloneSources(Sources{m})
+			break
+		}
+	}
 
 	// Filter out conditions.
 	if s.Condition != nil {
@@ -1252,16 +2022,16 @@ func (s *SelectStatement) Substatement(ref *VarRef) (*SelectStatement, error) {
 	return other, nil
 }
 
-// NamesInWhere returns the field and tag names (idents) referenced in the where clause
+// NamesInWhere returns the distinct field and tag names (idents) referenced in the where clause
 func (s *SelectStatement) NamesInWhere() []string {
 	var a []string
 	if s.Condition != nil {
 		a = walkNames(s.Condition)
 	}
-	return a
+	return uniqueNames(a)
 }
 
-// NamesInSelect returns the field and tag names (idents) in the select clause
+// NamesInSelect returns the distinct field and tag names (idents) in the select clause
 func (s *SelectStatement) NamesInSelect() []string {
 	var a []string
 
@@ -1269,6 +2039,91 @@ func (s *SelectStatement) NamesInSelect() []string {
 		a = append(a, walkNames(f.Expr)...)
 	}
 
+	return uniqueNames(a)
+}
+
+// ColumnNames returns the names of the columns returned by the statement. The
+// first column is always time and the rest are the names of the fields.
+// Duplicate names are suffixed with a number to keep them distinct.
+func (s *SelectStatement) ColumnNames() []string {
+	names := []string{"time"}
+	seen := map[string]int{"time": 0}
+	for _, f := range s.Fields {
+		// Time is already the first column.
+		if ref, ok := f.Expr.(*VarRef); ok && ref.Val == "time" && f.Alias == "" {
+			continue
+		}
+		name := f.Name()
+
+		// Suffix duplicates with the next number that isn't already used.
+		if n, ok := seen[name]; ok {
+			for {
+				n++
+				if _, ok := seen[fmt.Sprintf("%s_%d", name, n)]; !ok {
+					break
+				}
+			}
+			seen[name] = n
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+		seen[name] = 0
+		names = append(names, name)
+	}
+	return names
+}
+
+// exprName returns the name of an unaliased field. Calls are named after the
+// function and their first argument, and other expressions are named after
+// the calls and variables in them.
+func exprName(expr Expr) string {
+	switch expr := expr.(type) {
+	case *VarRef:
+		return expr.Val
+	case *Distinct:
+		return "distinct_" + expr.Val
+	case *Call:
+		if len(expr.Args) > 0 {
+			if arg := exprName(expr.Args[0]); arg != "" {
+				return expr.Name + "_" + arg
+			}
+		}
+		return expr.Name
+	case *BinaryExpr:
+		lhs, rhs := exprName(expr.LHS), exprName(expr.RHS)
+		if lhs == "" || rhs == "" {
+			return lhs + rhs
+		}
+		return lhs + "_" + rhs
+	case *ParenExpr:
+		return exprName(expr.Expr)
+	case *UnaryExpr:
+		return exprName(expr.Expr)
+	}
+	return ""
+}
+
+// NamesInDimension returns the distinct tag names (idents) in the group by clause
+func (s *SelectStatement) NamesInDimension() []string {
+	var a []string
+
+	for _, d := range s.Dimensions {
+		a = append(a, walkNames(d.Expr)...)
+	}
+
+	return uniqueNames(a)
+}
+
+// uniqueNames returns the names with duplicates removed, keeping the first occurrence of each.
+func uniqueNames(names []string) []string {
+	var a []string
+	m := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		if _, ok := m[n]; ok {
+			continue
+		}
+		m[n] = struct{}{}
+		a = append(a, n)
+	}
 	return a
 }
 
@@ -1278,15 +2133,13 @@ func walkNames(exp Expr) []string {
 	case *VarRef:
 		return []string{expr.Val}
 	case *Call:
-		if len(expr.Args) == 0 {
-			return nil
-		}
-		lit, ok := expr.Args[0].(*VarRef)
-		if !ok {
-			return nil
+		var ret []string
+		for _, arg := range expr.Args {
+			ret = append(ret, walkNames(arg)...)
 		}
-
-		return []string{lit.Val}
+		return ret
+	case *Distinct:
+		return []string{expr.Val}
 	case *BinaryExpr:
 		var ret []string
 		ret = append(ret, walkNames(expr.LHS)...)
@@ -1294,44 +2147,81 @@ func walkNames(exp Expr) []string {
 		return ret
 	case *ParenExpr:
 		return walkNames(expr.Expr)
+	case *UnaryExpr:
+		return walkNames(expr.Expr)
+	case *IsNullExpr:
+		return walkNames(expr.Expr)
 	}
 
 	return nil
 }
 
-// FunctionCalls returns the Call objects from the query
+// FunctionCalls returns the Call objects from the query, including calls nested
+// in the arguments of other calls. Each call comes before the calls in its arguments.
 func (s *SelectStatement) FunctionCalls() []*Call {
 	var a []*Call
 	for _, f := range s.Fields {
-		a = append(a, walkFunctionCalls(f.Expr)...)
+		a = append(a, walkFunctionCalls(f.Expr, true)...)
+	}
+	return a
+}
+
+// FunctionNames returns the names of the function calls from the query, in the
+// same order as FunctionCalls.
+func (s *SelectStatement) FunctionNames() []string {
+	var a []string
+	for _, c := range s.FunctionCalls() {
+		a = append(a, c.Name)
+	}
+	return a
+}
+
+// aggregateCalls returns the outermost Call objects from the query. These are
+// the aggregates whose values are returned for each interval.
+func (s *SelectStatement) aggregateCalls() []*Call {
+	var a []*Call
+	for _, f := range s.Fields {
+		a = append(a, walkFunctionCalls(f.Expr, false)...)
 	}
 	return a
 }
 
-// walkFunctionCalls walks the Field of a query for any function calls made
-func walkFunctionCalls(exp Expr) []*Call {
+// walkFunctionCalls walks the Field of a query for any function calls made. If
+// nested is true then calls in the arguments of other calls are also returned.
+func walkFunctionCalls(exp Expr, nested bool) []*Call {
 	switch expr := exp.(type) {
 	case *VarRef:
 		return nil
 	case *Call:
-		return []*Call{expr}
+		ret := []*Call{expr}
+		if nested {
+			for _, arg := range expr.Args {
+				ret = append(ret, walkFunctionCalls(arg, nested)...)
+			}
+		}
+		return ret
 	case *BinaryExpr:
 		var ret []*Call
-		ret = append(ret, walkFunctionCalls(expr.LHS)...)
-		ret = append(ret, walkFunctionCalls(expr.RHS)...)
+		ret = append(ret, walkFunctionCalls(expr.LHS, nested)...)
+		ret = append(ret, walkFunctionCalls(expr.RHS, nested)...)
 		return ret
 	case *ParenExpr:
-		return walkFunctionCalls(expr.Expr)
+		return walkFunctionCalls(expr.Expr, nested)
+	case *UnaryExpr:
+		return walkFunctionCalls(expr.Expr, nested)
+	case *IsNullExpr:
+		return walkFunctionCalls(expr.Expr, nested)
 	}
 
 	return nil
 }
 
 // filters an expression to exclude expressions unrelated to a source.
+// Time applies to every source so it's always kept.
 func filterExprBySource(name string, expr Expr) Expr {
 	switch expr := expr.(type) {
 	case *VarRef:
-		if !strings.HasPrefix(expr.Val, name) {
+		if expr.Val != "time" && !strings.HasPrefix(expr.Val, name+".") {
 			return nil
 		}
 
@@ -1362,17 +2252,33 @@ func filterExprBySource(name string, expr Expr) Expr {
 			return nil
 		}
 		return &ParenExpr{Expr: exp}
+
+	case *UnaryExpr:
+		exp := filterExprBySource(name, expr.Expr)
+		if exp == nil {
+			return nil
+		}
+		return &UnaryExpr{Op: expr.Op, Expr: exp}
+
+	case *IsNullExpr:
+		exp := filterExprBySource(name, expr.Expr)
+		if exp == nil {
+			return nil
+		}
+		return &IsNullExpr{Expr: exp, Not: expr.Not}
 	}
 	return expr
 }
 
-// MatchSource returns the source name that matches a field name.
-// Returns a blank string if no sources match.
+// MatchSource returns the source name that matches a field name. A field
+// matches a source if it's prefixed by the whole source name and a dot, so
+// cpu.value matches cpu but cpu_user.value doesn't. Returns a blank string if
+// no sources match.
 func MatchSource(sources Sources, name string) string {
 	for _, src := range sources {
 		switch src := src.(type) {
 		case *Measurement:
-			if strings.HasPrefix(name, src.Name) {
+			if strings.HasPrefix(name, src.Name+".") {
 				return src.Name
 			}
 		}
@@ -1380,10 +2286,26 @@ func MatchSource(sources Sources, name string) string {
 	return ""
 }
 
+// SplitSourceRef splits a field name into the source and field names at the
+// first dot, e.g. cpu.value returns cpu and value. Returns a blank source if
+// the name isn't prefixed by a source.
+func SplitSourceRef(name string) (source, field string) {
+	if i := strings.Index(name, "."); i > 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
 // Target represents a target (destination) policy, measurement, and DB.
 type Target struct {
+	NodePos
+
 	// Measurement to write into.
 	Measurement *Measurement
+
+	// Backref is true if results are written into the measurement they were
+	// read from e.g. INTO "two_weeks".:MEASUREMENT
+	Backref bool
 }
 
 // String returns a string representation of the Target.
@@ -1395,13 +2317,19 @@ func (t *Target) String() string {
 	var buf bytes.Buffer
 	_, _ = buf.WriteString("INTO ")
 	_, _ = buf.WriteString(t.Measurement.String())
+	if t.Backref {
+		_, _ = buf.WriteString(":MEASUREMENT")
+	}
 
 	return buf.String()
 }
 
 // DeleteStatement represents a command for removing data from the database.
 type DeleteStatement struct {
-	// Data source that values are removed from.
+	NodePos
+
+	// Data source that values are removed from. The measurement may be
+	// qualified with the database and retention policy to delete from.
 	Source Source
 
 	// An expression evaluated on data point.
@@ -1411,22 +2339,88 @@ type DeleteStatement struct {
 // String returns a string representation of the delete statement.
 func (s *DeleteStatement) String() string {
 	var buf bytes.Buffer
-	_, _ = buf.WriteString("DELETE ")
+	_, _ = buf.WriteString("DELETE FROM ")
 	_, _ = buf.WriteString(s.Source.String())
 	if s.Condition != nil {
 		_, _ = buf.WriteString(" WHERE ")
 		_, _ = buf.WriteString(s.Condition.String())
 	}
-	return s.String()
+	return buf.String()
+}
+
+// Clone returns a deep copy of the statement.
+func (s *DeleteStatement) Clone() *DeleteStatement {
+	return &DeleteStatement{
+		NodePos:   s.NodePos,
+		Source:    cloneSource(s.Source),
+		Condition: CloneExpr(s.Condition),
+	}
 }
 
 // RequiredPrivileges returns the privilege required to execute a DeleteStatement.
 func (s *DeleteStatement) RequiredPrivileges() ExecutionPrivileges {
-	return ExecutionPrivileges{{Name: "", Privilege: WritePrivilege}}
+	var name string
+	if m, ok := s.Source.(*Measurement); ok {
+		name = m.Database
+	}
+	return ExecutionPrivileges{{Name: name, Privilege: WritePrivilege}}
+}
+
+// validate returns an error if the condition filters on anything other than
+// time or tags. Tag values are always strings so comparisons against any
+// other kind of value are field predicates.
+func (s *DeleteStatement) validate() error {
+	return validateDeleteCondition(s.Condition)
+}
+
+func validateDeleteCondition(expr Expr) error {
+	switch expr := expr.(type) {
+	case nil:
+		return nil
+	case *ParenExpr:
+		return validateDeleteCondition(expr.Expr)
+	case *BinaryExpr:
+		switch expr.Op {
+		case AND, OR:
+			if err := validateDeleteCondition(expr.LHS); err != nil {
+				return err
+			}
+			return validateDeleteCondition(expr.RHS)
+		}
+
+		ref, ok := expr.LHS.(*VarRef)
+		if !ok {
+			break
+		}
+
+		// Time can be compared with any time expression.
+		if strings.ToLower(ref.Val) == "time" {
+			switch expr.Op {
+			case EQ, LT, LTE, GT, GTE:
+				return nil
+			}
+			break
+		}
+
+		// Tags can only be matched against strings and regular expressions.
+		switch expr.RHS.(type) {
+		case *StringLiteral:
+			if expr.Op == EQ || expr.Op == NEQ {
+				return nil
+			}
+		case *RegexLiteral:
+			if expr.Op == EQREGEX || expr.Op == NEQREGEX {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("DELETE only supports time and tag conditions: %s", expr)
 }
 
 // ShowSeriesStatement represents a command for listing series in the database.
 type ShowSeriesStatement struct {
+	NodePos
+
 	// Measurement(s) the series are listed for.
 	Sources Sources
 
@@ -1473,6 +2467,18 @@ func (s *ShowSeriesStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowSeriesStatement) Clone() *ShowSeriesStatement {
+	return &ShowSeriesStatement{
+		NodePos:    s.NodePos,
+		Sources:    cloneSources(s.Sources),
+		Condition:  CloneExpr(s.Condition),
+		SortFields: cloneSortFields(s.SortFields),
+		Limit:      s.Limit,
+		Offset:     s.Offset,
+	}
+}
+
 // RequiredPrivileges returns the privilege required to execute a ShowSeriesStatement.
 func (s *ShowSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
@@ -1480,6 +2486,8 @@ func (s *ShowSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // DropSeriesStatement represents a command for removing a series from the database.
 type DropSeriesStatement struct {
+	NodePos
+
 	// Data source that fields are extracted from (optional)
 	Sources Sources
 
@@ -1504,24 +2512,78 @@ func (s *DropSeriesStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *DropSeriesStatement) Clone() *DropSeriesStatement {
+	return &DropSeriesStatement{
+		NodePos:   s.NodePos,
+		Sources:   cloneSources(s.Sources),
+		Condition: CloneExpr(s.Condition),
+	}
+}
+
 // RequiredPrivileges returns the privilige reqired to execute a DropSeriesStatement.
 func (s DropSeriesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: WritePrivilege}}
 }
 
 // ShowContinuousQueriesStatement represents a command for listing continuous queries.
-type ShowContinuousQueriesStatement struct{}
+type ShowContinuousQueriesStatement struct {
+	NodePos
+
+	// Database to list continuous queries for. All databases if blank.
+	Database string
+
+	// An expression evaluated on the "name" and "query" of each continuous query.
+	Condition Expr
+
+	// Maximum number of continuous queries returned per database.
+	// Unlimited if zero.
+	Limit int
+
+	// Returns continuous queries starting at an offset within each database.
+	Offset int
+}
 
 // String returns a string representation of the list continuous queries statement.
-func (s *ShowContinuousQueriesStatement) String() string { return "SHOW CONTINUOUS QUERIES" }
+func (s *ShowContinuousQueriesStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("SHOW CONTINUOUS QUERIES")
+
+	if s.Database != "" {
+		_, _ = buf.WriteString(" ON ")
+		_, _ = buf.WriteString(QuoteIdent(s.Database))
+	}
+	if s.Condition != nil {
+		_, _ = buf.WriteString(" WHERE ")
+		_, _ = buf.WriteString(s.Condition.String())
+	}
+	if s.Limit > 0 {
+		_, _ = buf.WriteString(" LIMIT ")
+		_, _ = buf.WriteString(strconv.Itoa(s.Limit))
+	}
+	if s.Offset > 0 {
+		_, _ = buf.WriteString(" OFFSET ")
+		_, _ = buf.WriteString(strconv.Itoa(s.Offset))
+	}
+	return buf.String()
+}
+
+// Clone returns a deep copy of the statement.
+func (s *ShowContinuousQueriesStatement) Clone() *ShowContinuousQueriesStatement {
+	other := *s
+	other.Condition = CloneExpr(s.Condition)
+	return &other
+}
 
 // RequiredPrivileges returns the privilege required to execute a ShowContinuousQueriesStatement.
 func (s *ShowContinuousQueriesStatement) RequiredPrivileges() ExecutionPrivileges {
-	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+	return ExecutionPrivileges{{Name: s.Database, Privilege: ReadPrivilege}}
 }
 
 // ShowGrantsForUserStatement represents a command for listing user privileges.
 type ShowGrantsForUserStatement struct {
+	NodePos
+
 	// Name of the user to display privileges.
 	Name string
 }
@@ -1530,33 +2592,55 @@ type ShowGrantsForUserStatement struct {
 func (s *ShowGrantsForUserStatement) String() string {
 	var buf bytes.Buffer
 	_, _ = buf.WriteString("SHOW GRANTS FOR ")
-	_, _ = buf.WriteString(s.Name)
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
 
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowGrantsForUserStatement) Clone() *ShowGrantsForUserStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a ShowGrantsForUserStatement
 func (s *ShowGrantsForUserStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
 // ShowServersStatement represents a command for listing all servers.
-type ShowServersStatement struct{}
+type ShowServersStatement struct {
+	NodePos
+}
 
 // String returns a string representation of the show servers command.
 func (s *ShowServersStatement) String() string { return "SHOW SERVERS" }
 
-// RequiredPrivileges returns the privilege required to execute a ShowServersStatement
+// Clone returns a deep copy of the statement.
+func (s *ShowServersStatement) Clone() *ShowServersStatement {
+	other := *s
+	return &other
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowServersStatement
 func (s *ShowServersStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
 // ShowDatabasesStatement represents a command for listing all databases in the cluster.
-type ShowDatabasesStatement struct{}
+type ShowDatabasesStatement struct {
+	NodePos
+}
 
 // String returns a string representation of the list databases command.
 func (s *ShowDatabasesStatement) String() string { return "SHOW DATABASES" }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowDatabasesStatement) Clone() *ShowDatabasesStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a ShowDatabasesStatement
 func (s *ShowDatabasesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -1564,6 +2648,8 @@ func (s *ShowDatabasesStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // CreateContinuousQueryStatement represents a command for creating a continuous query.
 type CreateContinuousQueryStatement struct {
+	NodePos
+
 	// Name of the continuous query to be created.
 	Name string
 
@@ -1572,11 +2658,40 @@ type CreateContinuousQueryStatement struct {
 
 	// Source of data (SELECT statement).
 	Source *SelectStatement
+
+	// Interval to run the query at. Defaults to the GROUP BY interval if zero.
+	ResampleEvery time.Duration
+
+	// Window of time covered by each run of the query. Defaults to the GROUP BY
+	// interval if zero.
+	ResampleFor time.Duration
+
+	// Backfill causes the query to also be run over historical data starting
+	// at the lower time bound of the source's WHERE clause.
+	Backfill bool
 }
 
 // String returns a string representation of the statement.
 func (s *CreateContinuousQueryStatement) String() string {
-	return fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s BEGIN %s END", QuoteIdent(s.Name), QuoteIdent(s.Database), s.Source.String())
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CREATE CONTINUOUS QUERY %s ON %s ", QuoteIdent(s.Name), QuoteIdent(s.Database))
+
+	if s.ResampleEvery > 0 || s.ResampleFor > 0 {
+		_, _ = buf.WriteString("RESAMPLE ")
+		if s.ResampleEvery > 0 {
+			fmt.Fprintf(&buf, "EVERY %s ", FormatDuration(s.ResampleEvery))
+		}
+		if s.ResampleFor > 0 {
+			fmt.Fprintf(&buf, "FOR %s ", FormatDuration(s.ResampleFor))
+		}
+	}
+
+	if s.Backfill {
+		_, _ = buf.WriteString("BACKFILL ")
+	}
+
+	fmt.Fprintf(&buf, "BEGIN %s END", s.Source.String())
+	return buf.String()
 }
 
 // DefaultDatabase returns the default database from the statement.
@@ -1584,28 +2699,79 @@ func (s *CreateContinuousQueryStatement) DefaultDatabase() string {
 	return s.Database
 }
 
+// Clone returns a deep copy of the statement.
+func (s *CreateContinuousQueryStatement) Clone() *CreateContinuousQueryStatement {
+	other := *s
+	if s.Source != nil {
+		other.Source = s.Source.Clone()
+	}
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a CreateContinuousQueryStatement.
 func (s *CreateContinuousQueryStatement) RequiredPrivileges() ExecutionPrivileges {
 	ep := ExecutionPrivileges{{Name: s.Database, Privilege: ReadPrivilege}}
+	if s.Source == nil {
+		return ep
+	}
 
-	// Selecting into a database that's different from the source?
-	if s.Source.Target.Measurement.Database != "" {
-		// Change source database privilege requirement to read.
-		ep[0].Privilege = ReadPrivilege
-
-		// Add destination database privilege requirement and set it to write.
-		p := ExecutionPrivilege{
-			Name:      s.Source.Target.Measurement.Database,
-			Privilege: WritePrivilege,
+	// The source statement runs against the continuous query's database so
+	// any of its privileges that don't name a database apply to it.
+	var other ExecutionPrivileges
+	for _, p := range s.Source.RequiredPrivileges() {
+		if p.Name == "" {
+			p.Name = s.Database
 		}
-		ep = append(ep, p)
+		other = append(other, p)
 	}
+	return ep.Merge(other)
+}
 
-	return ep
+// ExplainStatement represents a command for showing how a SELECT statement
+// would be executed. The statement is only executed if Analyze is set.
+type ExplainStatement struct {
+	NodePos
+
+	// Statement to explain.
+	Statement *SelectStatement
+
+	// Execute the statement and report runtime counters for each operator.
+	Analyze bool
+}
+
+// String returns a string representation of the statement.
+func (s *ExplainStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("EXPLAIN ")
+	if s.Analyze {
+		_, _ = buf.WriteString("ANALYZE ")
+	}
+	_, _ = buf.WriteString(s.Statement.String())
+	return buf.String()
+}
+
+// Clone returns a deep copy of the statement.
+func (s *ExplainStatement) Clone() *ExplainStatement {
+	other := *s
+	if s.Statement != nil {
+		other.Statement = s.Statement.Clone()
+	}
+	return &other
+}
+
+// RequiredPrivileges returns the privileges required to execute an ExplainStatement.
+// Explaining a statement requires the same privileges as executing it.
+func (s *ExplainStatement) RequiredPrivileges() ExecutionPrivileges {
+	if s.Statement == nil {
+		return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
+	}
+	return s.Statement.RequiredPrivileges()
 }
 
 // DropContinuousQueryStatement represents a command for removing a continuous query.
 type DropContinuousQueryStatement struct {
+	NodePos
+
 	Name     string
 	Database string
 }
@@ -1615,13 +2781,176 @@ func (s *DropContinuousQueryStatement) String() string {
 	return fmt.Sprintf("DROP CONTINUOUS QUERY %s", s.Name)
 }
 
+// Clone returns a deep copy of the statement.
+func (s *DropContinuousQueryStatement) Clone() *DropContinuousQueryStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a DropContinuousQueryStatement
 func (s *DropContinuousQueryStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: WritePrivilege}}
 }
 
+// BackfillContinuousQueryStatement represents a command for running a
+// continuous query over a historical time range.
+type BackfillContinuousQueryStatement struct {
+	NodePos
+
+	Name     string
+	Database string
+
+	// Time range to compute. Only conditions on time are allowed and
+	// a lower bound is required.
+	Condition Expr
+}
+
+// String returns a string representation of the statement.
+func (s *BackfillContinuousQueryStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "BACKFILL CONTINUOUS QUERY %s ON %s", QuoteIdent(s.Name), QuoteIdent(s.Database))
+	if s.Condition != nil {
+		_, _ = buf.WriteString(" WHERE ")
+		_, _ = buf.WriteString(s.Condition.String())
+	}
+	return buf.String()
+}
+
+// Clone returns a deep copy of the statement.
+func (s *BackfillContinuousQueryStatement) Clone() *BackfillContinuousQueryStatement {
+	other := *s
+	other.Condition = CloneExpr(s.Condition)
+	return &other
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a BackfillContinuousQueryStatement.
+func (s *BackfillContinuousQueryStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// validate returns an error if the condition filters on anything other than
+// time or has no lower time bound.
+func (s *BackfillContinuousQueryStatement) validate() error {
+	var err error
+	WalkFunc(s.Condition, func(n Node) {
+		if ref, ok := n.(*VarRef); ok && err == nil && strings.ToLower(ref.Val) != "time" {
+			err = fmt.Errorf("BACKFILL only supports time conditions: %s", ref.Val)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if min, _ := TimeRange(Reduce(s.Condition, &NowValuer{Now: time.Now()})); min.IsZero() {
+		return errors.New("BACKFILL requires a lower time bound in the WHERE clause")
+	}
+	return nil
+}
+
+// CreateSubscriptionStatement represents a command to add a subscription to the incoming data stream.
+type CreateSubscriptionStatement struct {
+	NodePos
+
+	// Name of the subscription to create.
+	Name string
+
+	// Database and retention policy whose writes are sent to the destinations.
+	Database        string
+	RetentionPolicy string
+
+	// Destination URLs that writes are sent to.
+	Destinations []string
+
+	// Either "ALL" to send each write to every destination or "ANY" to
+	// send each write to a single destination.
+	Mode string
+}
+
+// String returns a string representation of the create subscription statement.
+func (s *CreateSubscriptionStatement) String() string {
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("CREATE SUBSCRIPTION ")
+	_, _ = buf.WriteString(QuoteIdent(s.Name))
+	_, _ = buf.WriteString(" ON ")
+	_, _ = buf.WriteString(QuoteIdent(s.Database, s.RetentionPolicy))
+	_, _ = buf.WriteString(" DESTINATIONS ")
+	_, _ = buf.WriteString(s.Mode)
+	_, _ = buf.WriteString(" ")
+	for i, dest := range s.Destinations {
+		if i != 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(QuoteString(dest))
+	}
+	return buf.String()
+}
+
+// Clone returns a deep copy of the statement.
+func (s *CreateSubscriptionStatement) Clone() *CreateSubscriptionStatement {
+	other := *s
+	if s.Destinations != nil {
+		other.Destinations = make([]string, len(s.Destinations))
+		copy(other.Destinations, s.Destinations)
+	}
+	return &other
+}
+
+// RequiredPrivileges returns the privilege required to execute a CreateSubscriptionStatement.
+func (s *CreateSubscriptionStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// DropSubscriptionStatement represents a command to remove a subscription.
+type DropSubscriptionStatement struct {
+	NodePos
+
+	// Name of the subscription to drop.
+	Name string
+
+	// Database and retention policy the subscription belongs to.
+	Database        string
+	RetentionPolicy string
+}
+
+// String returns a string representation of the drop subscription statement.
+func (s *DropSubscriptionStatement) String() string {
+	return fmt.Sprintf("DROP SUBSCRIPTION %s ON %s", QuoteIdent(s.Name), QuoteIdent(s.Database, s.RetentionPolicy))
+}
+
+// Clone returns a deep copy of the statement.
+func (s *DropSubscriptionStatement) Clone() *DropSubscriptionStatement {
+	other := *s
+	return &other
+}
+
+// RequiredPrivileges returns the privilege required to execute a DropSubscriptionStatement.
+func (s *DropSubscriptionStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// ShowSubscriptionsStatement represents a command to list subscriptions.
+type ShowSubscriptionsStatement struct {
+	NodePos
+}
+
+// String returns a string representation of the show subscriptions statement.
+func (s *ShowSubscriptionsStatement) String() string { return "SHOW SUBSCRIPTIONS" }
+
+// Clone returns a deep copy of the statement.
+func (s *ShowSubscriptionsStatement) Clone() *ShowSubscriptionsStatement {
+	other := *s
+	return &other
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowSubscriptionsStatement.
+func (s *ShowSubscriptionsStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowMeasurementsStatement represents a command for listing measurements.
 type ShowMeasurementsStatement struct {
+	NodePos
+
 	// An expression evaluated on data point.
 	Condition Expr
 
@@ -1660,6 +2989,17 @@ func (s *ShowMeasurementsStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowMeasurementsStatement) Clone() *ShowMeasurementsStatement {
+	return &ShowMeasurementsStatement{
+		NodePos:    s.NodePos,
+		Condition:  CloneExpr(s.Condition),
+		SortFields: cloneSortFields(s.SortFields),
+		Limit:      s.Limit,
+		Offset:     s.Offset,
+	}
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a ShowMeasurementsStatement
 func (s *ShowMeasurementsStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
@@ -1667,6 +3007,8 @@ func (s *ShowMeasurementsStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // DropMeasurementStatement represents a command to drop a measurement.
 type DropMeasurementStatement struct {
+	NodePos
+
 	// Name of the measurement to be dropped.
 	Name string
 }
@@ -1679,6 +3021,12 @@ func (s *DropMeasurementStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *DropMeasurementStatement) Clone() *DropMeasurementStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a DropMeasurementStatement
 func (s *DropMeasurementStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
@@ -1686,6 +3034,8 @@ func (s *DropMeasurementStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // ShowRetentionPoliciesStatement represents a command for listing retention policies.
 type ShowRetentionPoliciesStatement struct {
+	NodePos
+
 	// Name of the database to list policies for.
 	Database string
 }
@@ -1698,6 +3048,12 @@ func (s *ShowRetentionPoliciesStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowRetentionPoliciesStatement) Clone() *ShowRetentionPoliciesStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a ShowRetentionPoliciesStatement
 func (s *ShowRetentionPoliciesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
@@ -1705,6 +3061,8 @@ func (s *ShowRetentionPoliciesStatement) RequiredPrivileges() ExecutionPrivilege
 
 // ShowRetentionPoliciesStatement represents a command for displaying stats for a given server.
 type ShowStatsStatement struct {
+	NodePos
+
 	// Hostname or IP of the server for stats.
 	Host string
 }
@@ -1719,24 +3077,81 @@ func (s *ShowStatsStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowStatsStatement) Clone() *ShowStatsStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a ShowStatsStatement
 func (s *ShowStatsStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
 // ShowDiagnosticsStatement represents a command for show node diagnostics.
-type ShowDiagnosticsStatement struct{}
+type ShowDiagnosticsStatement struct {
+	NodePos
+}
 
 // String returns a string representation of the ShowDiagnosticsStatement.
 func (s *ShowDiagnosticsStatement) String() string { return "SHOW DIAGNOSTICS" }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowDiagnosticsStatement) Clone() *ShowDiagnosticsStatement {
+	other := *s
+	return &other
+}
+
 // RequiredPrivileges returns the privilege required to execute a ShowDiagnosticsStatement
 func (s *ShowDiagnosticsStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
+// ShowQueriesStatement represents a command for listing the running queries.
+type ShowQueriesStatement struct {
+	NodePos
+}
+
+// String returns a string representation of the ShowQueriesStatement.
+func (s *ShowQueriesStatement) String() string { return "SHOW QUERIES" }
+
+// Clone returns a deep copy of the statement.
+func (s *ShowQueriesStatement) Clone() *ShowQueriesStatement {
+	other := *s
+	return &other
+}
+
+// RequiredPrivileges returns the privilege required to execute a ShowQueriesStatement
+func (s *ShowQueriesStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
+// KillQueryStatement represents a command for aborting a running query.
+type KillQueryStatement struct {
+	NodePos
+
+	// ID of the query, as listed by SHOW QUERIES.
+	QueryID uint64
+}
+
+// String returns a string representation of the KillQueryStatement.
+func (s *KillQueryStatement) String() string { return fmt.Sprintf("KILL QUERY %d", s.QueryID) }
+
+// Clone returns a deep copy of the statement.
+func (s *KillQueryStatement) Clone() *KillQueryStatement {
+	other := *s
+	return &other
+}
+
+// RequiredPrivileges returns the privilege required to execute a KillQueryStatement
+func (s *KillQueryStatement) RequiredPrivileges() ExecutionPrivileges {
+	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
+}
+
 // ShowTagKeysStatement represents a command for listing tag keys.
 type ShowTagKeysStatement struct {
+	NodePos
+
 	// Data sources that fields are extracted from.
 	Sources Sources
 
@@ -1782,6 +3197,18 @@ func (s *ShowTagKeysStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowTagKeysStatement) Clone() *ShowTagKeysStatement {
+	return &ShowTagKeysStatement{
+		NodePos:    s.NodePos,
+		Sources:    cloneSources(s.Sources),
+		Condition:  CloneExpr(s.Condition),
+		SortFields: cloneSortFields(s.SortFields),
+		Limit:      s.Limit,
+		Offset:     s.Offset,
+	}
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a ShowTagKeysStatement
 func (s *ShowTagKeysStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
@@ -1789,6 +3216,8 @@ func (s *ShowTagKeysStatement) RequiredPrivileges() ExecutionPrivileges {
 
 // ShowTagValuesStatement represents a command for listing tag values.
 type ShowTagValuesStatement struct {
+	NodePos
+
 	// Data source that fields are extracted from.
 	Sources Sources
 
@@ -1818,6 +3247,10 @@ func (s *ShowTagValuesStatement) String() string {
 		_, _ = buf.WriteString(" FROM ")
 		_, _ = buf.WriteString(s.Sources.String())
 	}
+	if len(s.TagKeys) > 0 {
+		_, _ = buf.WriteString(" ")
+		_, _ = buf.WriteString(formatTagKeys(s.TagKeys))
+	}
 	if s.Condition != nil {
 		_, _ = buf.WriteString(" WHERE ")
 		_, _ = buf.WriteString(s.Condition.String())
@@ -1837,26 +3270,63 @@ func (s *ShowTagValuesStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowTagValuesStatement) Clone() *ShowTagValuesStatement {
+	other := &ShowTagValuesStatement{
+		NodePos:    s.NodePos,
+		Sources:    cloneSources(s.Sources),
+		Condition:  CloneExpr(s.Condition),
+		SortFields: cloneSortFields(s.SortFields),
+		Limit:      s.Limit,
+		Offset:     s.Offset,
+	}
+	if s.TagKeys != nil {
+		other.TagKeys = make([]string, len(s.TagKeys))
+		copy(other.TagKeys, s.TagKeys)
+	}
+	return other
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a ShowTagValuesStatement
 func (s *ShowTagValuesStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
 }
 
 // ShowUsersStatement represents a command for listing users.
-type ShowUsersStatement struct{}
+type ShowUsersStatement struct {
+	NodePos
+
+	// If set, only this user is listed along with their grants. It isn't
+	// part of the query text and is set when a non-admin user is authorized
+	// to list their own account.
+	User string
+}
 
 // String retuns a string representation of the ShowUsersStatement.
 func (s *ShowUsersStatement) String() string {
 	return "SHOW USERS"
 }
 
-// RequiredPrivileges returns the privilege(s) required to execute a ShowUsersStatement
+// Clone returns a deep copy of the statement.
+func (s *ShowUsersStatement) Clone() *ShowUsersStatement {
+	other := *s
+	return &other
+}
+
+// RequiredPrivileges returns the privilege(s) required to execute a ShowUsersStatement.
+// Listing every user requires cluster admin. Listing a single user requires no
+// privileges since it's restricted to the caller by the authorizer.
 func (s *ShowUsersStatement) RequiredPrivileges() ExecutionPrivileges {
+	if s.User != "" {
+		return nil
+	}
 	return ExecutionPrivileges{{Name: "", Privilege: AllPrivileges}}
 }
 
 // ShowFieldKeysStatement represents a command for listing field keys.
 type ShowFieldKeysStatement struct {
+	NodePos
+
 	// Data sources that fields are extracted from.
 	Sources Sources
 
@@ -1895,6 +3365,17 @@ func (s *ShowFieldKeysStatement) String() string {
 	return buf.String()
 }
 
+// Clone returns a deep copy of the statement.
+func (s *ShowFieldKeysStatement) Clone() *ShowFieldKeysStatement {
+	return &ShowFieldKeysStatement{
+		NodePos:    s.NodePos,
+		Sources:    cloneSources(s.Sources),
+		SortFields: cloneSortFields(s.SortFields),
+		Limit:      s.Limit,
+		Offset:     s.Offset,
+	}
+}
+
 // RequiredPrivileges returns the privilege(s) required to execute a ShowFieldKeysStatement
 func (s *ShowFieldKeysStatement) RequiredPrivileges() ExecutionPrivileges {
 	return ExecutionPrivileges{{Name: "", Privilege: ReadPrivilege}}
@@ -1903,6 +3384,14 @@ func (s *ShowFieldKeysStatement) RequiredPrivileges() ExecutionPrivileges {
 // Fields represents a list of fields.
 type Fields []*Field
 
+// Pos returns the position of the first field.
+func (a Fields) Pos() Pos {
+	if len(a) == 0 {
+		return Pos{}
+	}
+	return a[0].Pos()
+}
+
 // String returns a string representation of the fields.
 func (a Fields) String() string {
 	var str []string
@@ -1914,28 +3403,21 @@ func (a Fields) String() string {
 
 // Field represents an expression retrieved from a select statement.
 type Field struct {
+	NodePos
+
 	Expr  Expr
 	Alias string
 }
 
 // Name returns the name of the field. Returns alias, if set.
-// Otherwise uses the function name or variable name.
+// Otherwise the name is generated from the function and variable names in
+// the expression, e.g. count_distinct_value for count(distinct(value)).
 func (f *Field) Name() string {
 	// Return alias, if set.
 	if f.Alias != "" {
 		return f.Alias
 	}
-
-	// Return the function name or variable name, if available.
-	switch expr := f.Expr.(type) {
-	case *Call:
-		return expr.Name
-	case *VarRef:
-		return expr.Val
-	}
-
-	// Otherwise return a blank name.
-	return ""
+	return exprName(f.Expr)
 }
 
 // String returns a string representation of the field.
@@ -1954,6 +3436,14 @@ func (f Fields) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
 // Dimensions represents a list of dimensions.
 type Dimensions []*Dimension
 
+// Pos returns the position of the first dimension.
+func (a Dimensions) Pos() Pos {
+	if len(a) == 0 {
+		return Pos{}
+	}
+	return a[0].Pos()
+}
+
 // String returns a string representation of the dimensions.
 func (a Dimensions) String() string {
 	var str []string
@@ -2000,6 +3490,8 @@ func (a Dimensions) Normalize() (time.Duration, []string, error) {
 
 // Dimension represents an expression that a select statement is grouped by.
 type Dimension struct {
+	NodePos
+
 	Expr Expr
 }
 
@@ -2009,6 +3501,14 @@ func (d *Dimension) String() string { return d.Expr.String() }
 // Measurements represents a list of measurements.
 type Measurements []*Measurement
 
+// Pos returns the position of the first measurement.
+func (a Measurements) Pos() Pos {
+	if len(a) == 0 {
+		return Pos{}
+	}
+	return a[0].Pos()
+}
+
 // String returns a string representation of the measurements.
 func (a Measurements) String() string {
 	var str []string
@@ -2020,6 +3520,8 @@ func (a Measurements) String() string {
 
 // Measurement represents a single measurement used as a datasource.
 type Measurement struct {
+	NodePos
+
 	Database        string
 	RetentionPolicy string
 	Name            string
@@ -2030,19 +3532,9 @@ type Measurement struct {
 func (m *Measurement) String() string {
 	var buf bytes.Buffer
 	if m.Database != "" {
-		_, _ = buf.WriteString(`"`)
-		_, _ = buf.WriteString(m.Database)
-		_, _ = buf.WriteString(`".`)
-	}
-
-	if m.RetentionPolicy != "" {
-		_, _ = buf.WriteString(`"`)
-		_, _ = buf.WriteString(m.RetentionPolicy)
-		_, _ = buf.WriteString(`"`)
-	}
-
-	if m.Database != "" || m.RetentionPolicy != "" {
-		_, _ = buf.WriteString(`.`)
+		_, _ = buf.WriteString(QuoteIdent(m.Database, m.RetentionPolicy, ""))
+	} else if m.RetentionPolicy != "" {
+		_, _ = buf.WriteString(QuoteIdent(m.RetentionPolicy, ""))
 	}
 
 	if m.Name != "" {
@@ -2056,6 +3548,8 @@ func (m *Measurement) String() string {
 
 // VarRef represents a reference to a variable.
 type VarRef struct {
+	NodePos
+
 	Val string
 }
 
@@ -2064,6 +3558,8 @@ func (r *VarRef) String() string { return r.Val }
 
 // Call represents a function call.
 type Call struct {
+	NodePos
+
 	Name string
 	Args []Expr
 }
@@ -2080,8 +3576,20 @@ func (c *Call) String() string {
 	return fmt.Sprintf("%s(%s)", c.Name, strings.Join(str, ", "))
 }
 
+// Validate returns an error if the call is not a supported function or if
+// its arguments don't match the function's definition.
+func (c *Call) Validate() error {
+	def := LookupFunction(c.Name)
+	if def == nil {
+		return fmt.Errorf("function not found: %q", c.Name)
+	}
+	return def.Validate(c)
+}
+
 // Distinct represents a DISTINCT expression.
 type Distinct struct {
+	NodePos
+
 	// Identifier following DISTINCT
 	Val string
 }
@@ -2103,14 +3611,28 @@ func (d *Distinct) NewCall() *Call {
 
 // NumberLiteral represents a numeric literal.
 type NumberLiteral struct {
+	NodePos
+
 	Val float64
 }
 
 // String returns a string representation of the literal.
 func (l *NumberLiteral) String() string { return strconv.FormatFloat(l.Val, 'f', 3, 64) }
 
+// IntegerLiteral represents an integer literal.
+type IntegerLiteral struct {
+	NodePos
+
+	Val int64
+}
+
+// String returns a string representation of the literal.
+func (l *IntegerLiteral) String() string { return strconv.FormatInt(l.Val, 10) }
+
 // BooleanLiteral represents a boolean literal.
 type BooleanLiteral struct {
+	NodePos
+
 	Val bool
 }
 
@@ -2140,6 +3662,8 @@ func isFalseLiteral(expr Expr) bool {
 
 // StringLiteral represents a string literal.
 type StringLiteral struct {
+	NodePos
+
 	Val string
 }
 
@@ -2148,6 +3672,8 @@ func (l *StringLiteral) String() string { return QuoteString(l.Val) }
 
 // TimeLiteral represents a point-in-time literal.
 type TimeLiteral struct {
+	NodePos
+
 	Val time.Time
 }
 
@@ -2158,6 +3684,8 @@ func (l *TimeLiteral) String() string {
 
 // DurationLiteral represents a duration literal.
 type DurationLiteral struct {
+	NodePos
+
 	Val time.Duration
 }
 
@@ -2166,13 +3694,17 @@ func (l *DurationLiteral) String() string { return FormatDuration(l.Val) }
 
 // nilLiteral represents a nil literal.
 // This is not available to the query language itself. It's only used internally.
-type nilLiteral struct{}
+type nilLiteral struct {
+	NodePos
+}
 
 // String returns a string representation of the literal.
 func (l *nilLiteral) String() string { return `nil` }
 
 // BinaryExpr represents an operation between two expressions.
 type BinaryExpr struct {
+	NodePos
+
 	Op  Token
 	LHS Expr
 	RHS Expr
@@ -2183,8 +3715,44 @@ func (e *BinaryExpr) String() string {
 	return fmt.Sprintf("%s %s %s", e.LHS.String(), e.Op.String(), e.RHS.String())
 }
 
-// ParenExpr represents a parenthesized expression.
-type ParenExpr struct {
+// UnaryExpr represents an operation on a single expression, such as
+// arithmetic negation (SUB) or logical negation (NOT).
+type UnaryExpr struct {
+	NodePos
+
+	Op   Token
+	Expr Expr
+}
+
+// String returns a string representation of the unary expression.
+func (e *UnaryExpr) String() string {
+	if e.Op == NOT {
+		return fmt.Sprintf("NOT %s", e.Expr.String())
+	}
+	return fmt.Sprintf("%s%s", e.Op.String(), e.Expr.String())
+}
+
+// IsNullExpr represents a test for the absence of a value, such as
+// "status IS NULL" or "status IS NOT NULL".
+type IsNullExpr struct {
+	NodePos
+
+	Expr Expr
+	Not  bool
+}
+
+// String returns a string representation of the null test.
+func (e *IsNullExpr) String() string {
+	if e.Not {
+		return fmt.Sprintf("%s IS NOT NULL", e.Expr.String())
+	}
+	return fmt.Sprintf("%s IS NULL", e.Expr.String())
+}
+
+// ParenExpr represents a parenthesized expression.
+type ParenExpr struct {
+	NodePos
+
 	Expr Expr
 }
 
@@ -2193,6 +3761,8 @@ func (e *ParenExpr) String() string { return fmt.Sprintf("(%s)", e.Expr.String()
 
 // RegexLiteral represents a regular expression.
 type RegexLiteral struct {
+	NodePos
+
 	Val *regexp.Regexp
 }
 
@@ -2210,7 +3780,7 @@ func CloneRegexLiteral(r *RegexLiteral) *RegexLiteral {
 		return nil
 	}
 
-	clone := &RegexLiteral{}
+	clone := &RegexLiteral{NodePos: r.NodePos}
 	if r.Val != nil {
 		clone.Val = regexp.MustCompile(r.Val.String())
 	}
@@ -2219,11 +3789,127 @@ func CloneRegexLiteral(r *RegexLiteral) *RegexLiteral {
 }
 
 // Wildcard represents a wild card expression.
-type Wildcard struct{}
+type Wildcard struct {
+	NodePos
+}
 
 // String returns a string representation of the wildcard.
 func (e *Wildcard) String() string { return "*" }
 
+// CloneStatement returns a deep copy of the statement.
+func CloneStatement(stmt Statement) Statement {
+	if stmt == nil {
+		return nil
+	}
+	switch stmt := stmt.(type) {
+	case *AlterRetentionPolicyStatement:
+		return stmt.Clone()
+	case *BackfillContinuousQueryStatement:
+		return stmt.Clone()
+	case *CreateContinuousQueryStatement:
+		return stmt.Clone()
+	case *CreateDatabaseStatement:
+		return stmt.Clone()
+	case *CreateRetentionPolicyStatement:
+		return stmt.Clone()
+	case *CreateSubscriptionStatement:
+		return stmt.Clone()
+	case *CreateUserStatement:
+		return stmt.Clone()
+	case *DeleteStatement:
+		return stmt.Clone()
+	case *DropContinuousQueryStatement:
+		return stmt.Clone()
+	case *DropDatabaseStatement:
+		return stmt.Clone()
+	case *DropMeasurementStatement:
+		return stmt.Clone()
+	case *DropRetentionPolicyStatement:
+		return stmt.Clone()
+	case *DropSeriesStatement:
+		return stmt.Clone()
+	case *DropSubscriptionStatement:
+		return stmt.Clone()
+	case *DropUserStatement:
+		return stmt.Clone()
+	case *ExplainStatement:
+		return stmt.Clone()
+	case *GrantStatement:
+		return stmt.Clone()
+	case *KillQueryStatement:
+		return stmt.Clone()
+	case *RevokeStatement:
+		return stmt.Clone()
+	case *SelectStatement:
+		return stmt.Clone()
+	case *SetPasswordUserStatement:
+		return stmt.Clone()
+	case *ShowContinuousQueriesStatement:
+		return stmt.Clone()
+	case *ShowDatabasesStatement:
+		return stmt.Clone()
+	case *ShowDiagnosticsStatement:
+		return stmt.Clone()
+	case *ShowFieldKeysStatement:
+		return stmt.Clone()
+	case *ShowGrantsForUserStatement:
+		return stmt.Clone()
+	case *ShowMeasurementsStatement:
+		return stmt.Clone()
+	case *ShowQueriesStatement:
+		return stmt.Clone()
+	case *ShowRetentionPoliciesStatement:
+		return stmt.Clone()
+	case *ShowSeriesStatement:
+		return stmt.Clone()
+	case *ShowServersStatement:
+		return stmt.Clone()
+	case *ShowStatsStatement:
+		return stmt.Clone()
+	case *ShowSubscriptionsStatement:
+		return stmt.Clone()
+	case *ShowTagKeysStatement:
+		return stmt.Clone()
+	case *ShowTagValuesStatement:
+		return stmt.Clone()
+	case *ShowUsersStatement:
+		return stmt.Clone()
+	}
+	panic("unreachable")
+}
+
+// redacted replaces sensitive values in sanitized statements.
+const redacted = "[REDACTED]"
+
+// SanitizeStatement returns a copy of the statement that is safe to log.
+// Passwords are always masked and string literal values are masked if
+// maskLiterals is set.
+func SanitizeStatement(stmt Statement, maskLiterals bool) Statement {
+	stmt = CloneStatement(stmt)
+	switch stmt := stmt.(type) {
+	case *CreateUserStatement:
+		stmt.Password = redacted
+	case *SetPasswordUserStatement:
+		stmt.Password = redacted
+	case *CreateSubscriptionStatement:
+		if maskLiterals {
+			for i := range stmt.Destinations {
+				stmt.Destinations[i] = redacted
+			}
+		}
+	}
+
+	if maskLiterals {
+		RewriteFunc(stmt, func(n Node) Node {
+			if lit, ok := n.(*StringLiteral); ok {
+				return &StringLiteral{NodePos: lit.NodePos, Val: redacted}
+			}
+			return n
+		})
+	}
+	return stmt
+}
+
 // CloneExpr returns a deep copy of the expression.
 func CloneExpr(expr Expr) Expr {
 	if expr == nil {
@@ -2231,92 +3917,424 @@ func CloneExpr(expr Expr) Expr {
 	}
 	switch expr := expr.(type) {
 	case *BinaryExpr:
-		return &BinaryExpr{Op: expr.Op, LHS: CloneExpr(expr.LHS), RHS: CloneExpr(expr.RHS)}
+		return &BinaryExpr{NodePos: expr.NodePos, Op: expr.Op, LHS: CloneExpr(expr.LHS), RHS: CloneExpr(expr.RHS)}
 	case *BooleanLiteral:
-		return &BooleanLiteral{Val: expr.Val}
+		return &BooleanLiteral{NodePos: expr.NodePos, Val: expr.Val}
 	case *Call:
-		args := make([]Expr, len(expr.Args))
-		for i, arg := range expr.Args {
-			args[i] = CloneExpr(arg)
+		var args []Expr
+		if expr.Args != nil {
+			args = make([]Expr, len(expr.Args))
+			for i, arg := range expr.Args {
+				args[i] = CloneExpr(arg)
+			}
 		}
-		return &Call{Name: expr.Name, Args: args}
+		return &Call{NodePos: expr.NodePos, Name: expr.Name, Args: args}
 	case *Distinct:
-		return &Distinct{Val: expr.Val}
+		return &Distinct{NodePos: expr.NodePos, Val: expr.Val}
 	case *DurationLiteral:
-		return &DurationLiteral{Val: expr.Val}
+		return &DurationLiteral{NodePos: expr.NodePos, Val: expr.Val}
+	case *IntegerLiteral:
+		return &IntegerLiteral{NodePos: expr.NodePos, Val: expr.Val}
+	case *nilLiteral:
+		return &nilLiteral{NodePos: expr.NodePos}
 	case *NumberLiteral:
-		return &NumberLiteral{Val: expr.Val}
+		return &NumberLiteral{NodePos: expr.NodePos, Val: expr.Val}
 	case *ParenExpr:
-		return &ParenExpr{Expr: CloneExpr(expr.Expr)}
+		return &ParenExpr{NodePos: expr.NodePos, Expr: CloneExpr(expr.Expr)}
 	case *RegexLiteral:
-		return &RegexLiteral{Val: expr.Val}
+		return &RegexLiteral{NodePos: expr.NodePos, Val: expr.Val}
 	case *StringLiteral:
-		return &StringLiteral{Val: expr.Val}
+		return &StringLiteral{NodePos: expr.NodePos, Val: expr.Val}
 	case *TimeLiteral:
-		return &TimeLiteral{Val: expr.Val}
+		return &TimeLiteral{NodePos: expr.NodePos, Val: expr.Val}
+	case *UnaryExpr:
+		return &UnaryExpr{NodePos: expr.NodePos, Op: expr.Op, Expr: CloneExpr(expr.Expr)}
+	case *IsNullExpr:
+		return &IsNullExpr{NodePos: expr.NodePos, Expr: CloneExpr(expr.Expr), Not: expr.Not}
 	case *VarRef:
-		return &VarRef{Val: expr.Val}
+		return &VarRef{NodePos: expr.NodePos, Val: expr.Val}
 	case *Wildcard:
-		return &Wildcard{}
+		return &Wildcard{NodePos: expr.NodePos}
 	}
 	panic("unreachable")
 }
 
 // TimeRange returns the minimum and maximum times specified by an expression.
-// Returns zero times if there is no bound.
+// Times that are only bounded on one side of an OR are unbounded, so the range
+// covers every time matched by the expression. Returns zero times if there is
+// no bound.
 func TimeRange(expr Expr) (min, max time.Time) {
-	WalkFunc(expr, func(n Node) {
-		if n, ok := n.(*BinaryExpr); ok {
-			// Extract literal expression & operator on LHS.
-			// Check for "time" on the left-hand side first.
-			// Otherwise check for for the right-hand side and flip the operator.
-			value, op := timeExprValue(n.LHS, n.RHS), n.Op
-			if value.IsZero() {
-				if value = timeExprValue(n.RHS, n.LHS); value.IsZero() {
-					return
-				} else if op == LT {
-					op = GT
-				} else if op == LTE {
-					op = GTE
-				} else if op == GT {
-					op = LT
-				} else if op == GTE {
-					op = LTE
-				}
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		return TimeRange(expr.Expr)
+	case *BinaryExpr:
+		switch expr.Op {
+		case AND:
+			lmin, lmax := TimeRange(expr.LHS)
+			rmin, rmax := TimeRange(expr.RHS)
+			return laterTime(lmin, rmin), earlierTime(lmax, rmax)
+		case OR:
+			lmin, lmax := TimeRange(expr.LHS)
+			rmin, rmax := TimeRange(expr.RHS)
+			if !lmin.IsZero() && !rmin.IsZero() {
+				min = earlierTime(lmin, rmin)
+			}
+			if !lmax.IsZero() && !rmax.IsZero() {
+				max = laterTime(lmax, rmax)
 			}
+			return min, max
+		}
+		return timeComparisonRange(expr)
+	}
+	return
+}
 
-			// Update the min/max depending on the operator.
-			// The GT & LT update the value by +/- 1µs not make them "not equal".
-			switch op {
-			case GT:
-				if min.IsZero() || value.After(min) {
-					min = value.Add(time.Microsecond)
-				}
-			case GTE:
-				if min.IsZero() || value.After(min) {
-					min = value
+// ConditionExpr splits a condition into the expression that bounds time and
+// the expression that filters on tags and fields. Either is nil if the
+// condition has no such part. Returns an error if time is combined with other
+// conditions in a way that can't be split, such as with OR.
+func ConditionExpr(cond Expr) (timeExpr, otherExpr Expr, err error) {
+	switch expr := cond.(type) {
+	case nil:
+		return nil, nil, nil
+	case *ParenExpr:
+		return ConditionExpr(expr.Expr)
+	case *BinaryExpr:
+		switch expr.Op {
+		case AND:
+			ltime, lother, err := ConditionExpr(expr.LHS)
+			if err != nil {
+				return nil, nil, err
+			}
+			rtime, rother, err := ConditionExpr(expr.RHS)
+			if err != nil {
+				return nil, nil, err
+			}
+			return conjunction(ltime, rtime), conjunction(lother, rother), nil
+		case OR:
+			if !hasTimeRef(expr) {
+				return nil, expr, nil
+			}
+
+			// Alternative time ranges can be split from the rest of the
+			// condition as long as nothing else is part of the alternatives.
+			ltime, lother, err := ConditionExpr(expr.LHS)
+			if err != nil {
+				return nil, nil, err
+			}
+			rtime, rother, err := ConditionExpr(expr.RHS)
+			if err != nil {
+				return nil, nil, err
+			} else if lother != nil || rother != nil || ltime == nil || rtime == nil {
+				return nil, nil, fmt.Errorf("cannot use OR between time and other conditions: %s", expr)
+			}
+			return &BinaryExpr{Op: OR, LHS: ltime, RHS: rtime}, nil, nil
+		case EQ, NEQ, LT, LTE, GT, GTE:
+			// The time must be compared against a value known before any
+			// points are read.
+			var value Expr
+			if isTimeRef(expr.LHS) {
+				value = expr.RHS
+			} else if isTimeRef(expr.RHS) {
+				value = expr.LHS
+			}
+
+			if value != nil {
+				if len(varRefNames(value)) > 0 {
+					return nil, nil, fmt.Errorf("invalid time comparison: %s", expr)
 				}
-			case LT:
-				if max.IsZero() || value.Before(max) {
-					max = value.Add(-time.Microsecond)
+				return expr, nil, nil
+			}
+		}
+	}
+
+	if hasTimeRef(cond) {
+		return nil, nil, fmt.Errorf("invalid time condition: %s", cond)
+	}
+	return nil, cond, nil
+}
+
+// ExprTagSets returns the alternative sets of tag values implied by the
+// equality comparisons in a condition, such as "host = 'serverA'". Every point
+// matching the condition has all of the tag values of at least one set.
+// A set with no tag values is returned if the condition doesn't restrict tags
+// and no sets are returned if it can't match any tags. Keys are not checked
+// against the tags of a measurement so callers should drop any field keys.
+func ExprTagSets(expr Expr) []map[string]string {
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		return ExprTagSets(expr.Expr)
+	case *BooleanLiteral:
+		if !expr.Val {
+			return nil
+		}
+	case *BinaryExpr:
+		switch expr.Op {
+		case AND:
+			// Combine each set on one side with each set on the other.
+			// Sets with different values for the same tag can't match.
+			var a []map[string]string
+			for _, lhs := range ExprTagSets(expr.LHS) {
+				for _, rhs := range ExprTagSets(expr.RHS) {
+					if set := mergeTagSets(lhs, rhs); set != nil {
+						a = appendTagSet(a, set)
+					}
 				}
-			case LTE:
-				if max.IsZero() || value.Before(max) {
-					max = value
+			}
+			return a
+		case OR:
+			var a []map[string]string
+			for _, set := range append(ExprTagSets(expr.LHS), ExprTagSets(expr.RHS)...) {
+				if len(set) == 0 {
+					return []map[string]string{{}}
 				}
-			case EQ:
-				if min.IsZero() || value.After(min) {
-					min = value
+				a = appendTagSet(a, set)
+			}
+			return a
+		case EQ:
+			key, value := expr.LHS, expr.RHS
+			if _, ok := key.(*VarRef); !ok {
+				key, value = value, key
+			}
+
+			ref, ok := key.(*VarRef)
+			str, ok2 := value.(*StringLiteral)
+			if ok && ok2 {
+				return []map[string]string{{ref.Val: str.Val}}
+			}
+		}
+	}
+	return []map[string]string{{}}
+}
+
+// mergeTagSets returns a set with the tag values of both sets.
+// Returns nil if the sets have different values for the same tag.
+func mergeTagSets(a, b map[string]string) map[string]string {
+	set := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		set[k] = v
+	}
+	for k, v := range b {
+		if other, ok := set[k]; ok && other != v {
+			return nil
+		}
+		set[k] = v
+	}
+	return set
+}
+
+// appendTagSet appends a tag set unless it's already in a.
+func appendTagSet(a []map[string]string, set map[string]string) []map[string]string {
+	for _, other := range a {
+		if isTagSetEqual(other, set) {
+			return a
+		}
+	}
+	return append(a, set)
+}
+
+// isTagSetEqual returns true if two sets have the same tag values.
+func isTagSetEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if other, ok := b[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
+
+// conjunction returns the AND of two conditions. Nil conditions are ignored.
+func conjunction(lhs, rhs Expr) Expr {
+	if lhs == nil {
+		return rhs
+	} else if rhs == nil {
+		return lhs
+	}
+	return &BinaryExpr{Op: AND, LHS: parenthesize(lhs, AND, false), RHS: parenthesize(rhs, AND, true)}
+}
+
+// hasTimeRef returns true if an expression references the time field.
+func hasTimeRef(expr Expr) bool {
+	for _, name := range varRefNames(expr) {
+		if strings.ToLower(name) == "time" {
+			return true
+		}
+	}
+	return false
+}
+
+// varRefNames returns the name of each variable referenced in an expression.
+func varRefNames(expr Expr) []string {
+	var a []string
+	WalkFunc(expr, func(n Node) {
+		if ref, ok := n.(*VarRef); ok {
+			a = append(a, ref.Val)
+		}
+	})
+	return a
+}
+
+// TimeRangeAsEpoch returns the minimum and maximum times specified by an
+// expression as nanoseconds since the epoch. Unlike TimeRange(), the flags
+// report whether each bound exists so an unbounded side can't be confused
+// with a bound at the zero time. Returns an error if the minimum time is
+// after the maximum time, as the expression can never be true.
+func TimeRangeAsEpoch(expr Expr) (min, max int64, hasMin, hasMax bool, err error) {
+	tmin, tmax := TimeRange(expr)
+	if !tmin.IsZero() && !tmax.IsZero() && tmin.After(tmax) {
+		return 0, 0, false, false, fmt.Errorf("invalid time range: min time %s is after max time %s",
+			tmin.UTC().Format(DateTimeFormat), tmax.UTC().Format(DateTimeFormat))
+	}
+
+	if !tmin.IsZero() {
+		min, hasMin = tmin.UnixNano(), true
+	}
+	if !tmax.IsZero() {
+		max, hasMax = tmax.UnixNano(), true
+	}
+	return
+}
+
+// TimeInterval is a range of times from Min to Max inclusive.
+// A zero time means the interval is unbounded on that side.
+type TimeInterval struct {
+	Min, Max time.Time
+}
+
+// empty returns true if no time is within the interval.
+func (i TimeInterval) empty() bool {
+	return !i.Min.IsZero() && !i.Max.IsZero() && i.Min.After(i.Max)
+}
+
+// TimeIntervals returns the disjoint intervals of time specified by an
+// expression in ascending order. Unlike TimeRange(), the gaps between the
+// time ranges of an OR are excluded. Returns a single unbounded interval if
+// the expression doesn't restrict time and no intervals if it matches no time.
+func TimeIntervals(expr Expr) []TimeInterval {
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		return TimeIntervals(expr.Expr)
+	case *BooleanLiteral:
+		if !expr.Val {
+			return nil
+		}
+	case *BinaryExpr:
+		switch expr.Op {
+		case AND:
+			// Intersect each interval on one side with each on the other.
+			var a []TimeInterval
+			for _, l := range TimeIntervals(expr.LHS) {
+				for _, r := range TimeIntervals(expr.RHS) {
+					a = append(a, TimeInterval{Min: laterTime(l.Min, r.Min), Max: earlierTime(l.Max, r.Max)})
 				}
-				if max.IsZero() || value.Before(max) {
-					max = value
+			}
+			return mergeTimeIntervals(a)
+		case OR:
+			return mergeTimeIntervals(append(TimeIntervals(expr.LHS), TimeIntervals(expr.RHS)...))
+		}
+
+		min, max := timeComparisonRange(expr)
+		return mergeTimeIntervals([]TimeInterval{{Min: min, Max: max}})
+	}
+	return []TimeInterval{{}}
+}
+
+// mergeTimeIntervals sorts intervals and combines those that overlap or are
+// adjacent to each other. Empty intervals are removed.
+func mergeTimeIntervals(a []TimeInterval) []TimeInterval {
+	other := make([]TimeInterval, 0, len(a))
+	for _, i := range a {
+		if !i.empty() {
+			other = append(other, i)
+		}
+	}
+	sort.Sort(timeIntervals(other))
+
+	var merged []TimeInterval
+	for _, i := range other {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.Max.IsZero() {
+				continue
+			} else if i.Min.IsZero() || !i.Min.After(last.Max.Add(time.Microsecond)) {
+				if i.Max.IsZero() || i.Max.After(last.Max) {
+					last.Max = i.Max
 				}
+				continue
 			}
 		}
-	})
+		merged = append(merged, i)
+	}
+	return merged
+}
+
+// timeIntervals sorts intervals by their minimum time.
+type timeIntervals []TimeInterval
+
+func (a timeIntervals) Len() int      { return len(a) }
+func (a timeIntervals) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a timeIntervals) Less(i, j int) bool {
+	return !a[j].Min.IsZero() && (a[i].Min.IsZero() || a[i].Min.Before(a[j].Min))
+}
+
+// timeComparisonRange returns the range of times matched by a comparison
+// against the time field. Returns zero times if the expression is not a time
+// comparison.
+func timeComparisonRange(n *BinaryExpr) (min, max time.Time) {
+	// Extract literal expression & operator on LHS.
+	// Check for "time" on the left-hand side first.
+	// Otherwise check for for the right-hand side and flip the operator.
+	value, op := timeExprValue(n.LHS, n.RHS), n.Op
+	if value.IsZero() {
+		if value = timeExprValue(n.RHS, n.LHS); value.IsZero() {
+			return
+		} else if op == LT {
+			op = GT
+		} else if op == LTE {
+			op = GTE
+		} else if op == GT {
+			op = LT
+		} else if op == GTE {
+			op = LTE
+		}
+	}
+
+	// Set the min/max depending on the operator.
+	// The GT & LT update the value by +/- 1µs not make them "not equal".
+	switch op {
+	case GT:
+		min = value.Add(time.Microsecond)
+	case GTE:
+		min = value
+	case LT:
+		max = value.Add(-time.Microsecond)
+	case LTE:
+		max = value
+	case EQ:
+		min, max = value, value
+	}
 	return
 }
 
+// laterTime returns the later of two minimum times. Zero times are unbounded.
+func laterTime(a, b time.Time) time.Time {
+	if a.IsZero() || b.After(a) {
+		return b
+	}
+	return a
+}
+
+// earlierTime returns the earlier of two maximum times. Zero times are unbounded.
+func earlierTime(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
 // timeExprValue returns the time literal value of a "time == <TimeLiteral>" expression.
 // Returns zero time if the expression is not a time expression.
 func timeExprValue(ref Expr, lit Expr) time.Time {
@@ -2358,7 +4376,21 @@ func Walk(v Visitor, node Node) {
 		}
 
 	case *CreateContinuousQueryStatement:
+		if n.Source != nil {
+			Walk(v, n.Source)
+		}
+
+	case *ExplainStatement:
+		if n.Statement != nil {
+			Walk(v, n.Statement)
+		}
+
+	case *DeleteStatement:
 		Walk(v, n.Source)
+		Walk(v, n.Condition)
+
+	case *BackfillContinuousQueryStatement:
+		Walk(v, n.Condition)
 
 	case *Dimension:
 		Walk(v, n.Expr)
@@ -2368,6 +4400,10 @@ func Walk(v Visitor, node Node) {
 			Walk(v, c)
 		}
 
+	case *DropSeriesStatement:
+		Walk(v, n.Sources)
+		Walk(v, n.Condition)
+
 	case *Field:
 		Walk(v, n.Expr)
 
@@ -2376,6 +4412,11 @@ func Walk(v Visitor, node Node) {
 			Walk(v, c)
 		}
 
+	case *Measurement:
+		if n != nil && n.Regex != nil {
+			Walk(v, n.Regex)
+		}
+
 	case *ParenExpr:
 		Walk(v, n.Expr)
 
@@ -2384,15 +4425,25 @@ func Walk(v Visitor, node Node) {
 
 	case *SelectStatement:
 		Walk(v, n.Fields)
-		Walk(v, n.Target)
+		if n.Target != nil {
+			Walk(v, n.Target)
+		}
 		Walk(v, n.Dimensions)
 		Walk(v, n.Sources)
 		Walk(v, n.Condition)
 		Walk(v, n.SortFields)
 
+	case *ShowContinuousQueriesStatement:
+		Walk(v, n.Condition)
+
+	case *ShowMeasurementsStatement:
+		Walk(v, n.Condition)
+		Walk(v, n.SortFields)
+
 	case *ShowSeriesStatement:
 		Walk(v, n.Sources)
 		Walk(v, n.Condition)
+		Walk(v, n.SortFields)
 
 	case *ShowTagKeysStatement:
 		Walk(v, n.Sources)
@@ -2424,9 +4475,15 @@ func Walk(v Visitor, node Node) {
 		}
 
 	case *Target:
-		if n != nil {
+		if n != nil && n.Measurement != nil {
 			Walk(v, n.Measurement)
 		}
+
+	case *UnaryExpr:
+		Walk(v, n.Expr)
+
+	case *IsNullExpr:
+		Walk(v, n.Expr)
 	}
 }
 
@@ -2459,15 +4516,106 @@ func Rewrite(r Rewriter, node Node) Node {
 
 	case *SelectStatement:
 		n.Fields = Rewrite(r, n.Fields).(Fields)
+		if n.Target != nil {
+			n.Target = Rewrite(r, n.Target).(*Target)
+		}
 		n.Dimensions = Rewrite(r, n.Dimensions).(Dimensions)
 		n.Sources = Rewrite(r, n.Sources).(Sources)
-		n.Condition = Rewrite(r, n.Condition).(Expr)
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+		n.SortFields = Rewrite(r, n.SortFields).(SortFields)
+
+	case *CreateContinuousQueryStatement:
+		if n.Source != nil {
+			n.Source = Rewrite(r, n.Source).(*SelectStatement)
+		}
+
+	case *ExplainStatement:
+		if n.Statement != nil {
+			n.Statement = Rewrite(r, n.Statement).(*SelectStatement)
+		}
+
+	case *DeleteStatement:
+		if n.Source != nil {
+			n.Source = Rewrite(r, n.Source).(Source)
+		}
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+
+	case *BackfillContinuousQueryStatement:
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+
+	case *DropSeriesStatement:
+		n.Sources = Rewrite(r, n.Sources).(Sources)
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+
+	case *ShowContinuousQueriesStatement:
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+
+	case *ShowFieldKeysStatement:
+		n.Sources = Rewrite(r, n.Sources).(Sources)
+		n.SortFields = Rewrite(r, n.SortFields).(SortFields)
+
+	case *ShowMeasurementsStatement:
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+		n.SortFields = Rewrite(r, n.SortFields).(SortFields)
+
+	case *ShowSeriesStatement:
+		n.Sources = Rewrite(r, n.Sources).(Sources)
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+		n.SortFields = Rewrite(r, n.SortFields).(SortFields)
+
+	case *ShowTagKeysStatement:
+		n.Sources = Rewrite(r, n.Sources).(Sources)
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+		n.SortFields = Rewrite(r, n.SortFields).(SortFields)
+
+	case *ShowTagValuesStatement:
+		n.Sources = Rewrite(r, n.Sources).(Sources)
+		if n.Condition != nil {
+			n.Condition = Rewrite(r, n.Condition).(Expr)
+		}
+		n.SortFields = Rewrite(r, n.SortFields).(SortFields)
+
+	case *Target:
+		if n != nil && n.Measurement != nil {
+			n.Measurement = Rewrite(r, n.Measurement).(*Measurement)
+		}
+
+	case *Measurement:
+		if n != nil && n.Regex != nil {
+			n.Regex = Rewrite(r, n.Regex).(*RegexLiteral)
+		}
+
+	case SortFields:
+		for i, sf := range n {
+			n[i] = Rewrite(r, sf).(*SortField)
+		}
 
 	case Fields:
 		for i, f := range n {
 			n[i] = Rewrite(r, f).(*Field)
 		}
 
+	case Sources:
+		for i, src := range n {
+			n[i] = Rewrite(r, src).(Source)
+		}
+
 	case *Field:
 		n.Expr = Rewrite(r, n.Expr).(Expr)
 
@@ -2486,6 +4634,12 @@ func Rewrite(r Rewriter, node Node) Node {
 	case *ParenExpr:
 		n.Expr = Rewrite(r, n.Expr).(Expr)
 
+	case *UnaryExpr:
+		n.Expr = Rewrite(r, n.Expr).(Expr)
+
+	case *IsNullExpr:
+		n.Expr = Rewrite(r, n.Expr).(Expr)
+
 	case *Call:
 		for i, expr := range n.Args {
 			n.Args[i] = Rewrite(r, expr).(Expr)
@@ -2504,39 +4658,248 @@ type rewriterFunc func(Node) Node
 
 func (fn rewriterFunc) Rewrite(n Node) Node { return fn(n) }
 
-// Eval evaluates expr against a map.
-func Eval(expr Expr, m map[string]interface{}) interface{} {
+// RewriteExpr recursively invokes fn to replace each expression.
+// Expressions are traversed depth-first and rewritten from leaf to root.
+func RewriteExpr(expr Expr, fn func(Expr) Expr) Expr {
 	if expr == nil {
 		return nil
 	}
+	return RewriteFunc(expr, func(n Node) Node {
+		return fn(n.(Expr))
+	}).(Expr)
+}
+
+// Fingerprint returns a hash of the structure of a statement. Literal values
+// such as strings, numbers, durations, booleans and regexes in conditions are
+// ignored so queries which only differ by their values share a fingerprint.
+func Fingerprint(stmt Statement) uint64 {
+	h := fnv.New64a()
+	write := func(tok Token, lit string) {
+		h.Write([]byte(tok.String()))
+		h.Write([]byte(lit))
+		h.Write([]byte{0})
+	}
+
+	// Scan the canonical form of the statement so that whitespace, casing
+	// and quoting don't affect the result.
+	s := NewScanner(strings.NewReader(stmt.String()))
+	for {
+		tok, _, lit := s.Scan()
+		switch tok {
+		case EOF:
+			return h.Sum64()
+		case WS:
+		case NUMBER, DURATION_VAL, STRING:
+			write(tok, "")
+		case TRUE, FALSE:
+			write(TRUE, "")
+		case EQREGEX, NEQREGEX:
+			write(tok, "")
+			if tok, _, lit = s.Scan(); tok == WS {
+				tok, _, lit = s.ScanRegex()
+			}
+			if tok == REGEX {
+				lit = ""
+			}
+			write(tok, lit)
+		default:
+			write(tok, lit)
+		}
+	}
+}
 
-	switch expr := expr.(type) {
-	case *BinaryExpr:
-		return evalBinaryExpr(expr, m)
+// Eval evaluates expr against a map. Returns nil if the expression can't be
+// evaluated, such as when a field is missing or the operand types don't match.
+func Eval(expr Expr, m map[string]interface{}) interface{} {
+	v, _ := eval(expr, m)
+	return v
+}
+
+// EvalExpr evaluates expr against a map. Unlike Eval, an error is returned if
+// operands have mismatched types or an operator can't be applied to them.
+// Missing fields are not an error and evaluate to nil.
+func EvalExpr(expr Expr, m map[string]interface{}) (interface{}, error) {
+	v, err := eval(expr, m)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// EvalBool evaluates a condition against a map. Conditions that evaluate to
+// nil, such as comparisons against missing fields, are false. An error is
+// returned if the condition can't be evaluated or isn't a boolean.
+func EvalBool(expr Expr, m map[string]interface{}) (bool, error) {
+	v, err := EvalExpr(expr, m)
+	if err != nil {
+		return false, err
+	}
+
+	switch v := v.(type) {
+	case bool:
+		return v, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("condition is not a boolean: %s", expr)
+}
+
+// eval evaluates expr against a map. The value is the same as Eval returns
+// and the error reports the first type mismatch found while evaluating it.
+func eval(expr Expr, m map[string]interface{}) (interface{}, error) {
+	if expr == nil {
+		return nil, nil
+	}
+
+	switch expr := expr.(type) {
+	case *BinaryExpr:
+		return evalBinaryExpr(expr, m)
 	case *BooleanLiteral:
-		return expr.Val
+		return expr.Val, nil
+	case *Call:
+		return evalCall(expr, m)
+	case *DurationLiteral:
+		return expr.Val, nil
+	case *IntegerLiteral:
+		return expr.Val, nil
 	case *NumberLiteral:
-		return expr.Val
+		return expr.Val, nil
 	case *ParenExpr:
-		return Eval(expr.Expr, m)
+		return eval(expr.Expr, m)
+	case *RegexLiteral:
+		// The pattern is compiled once by the parser and reused for each point.
+		return expr.Val, nil
 	case *StringLiteral:
-		return expr.Val
+		return expr.Val, nil
+	case *TimeLiteral:
+		return expr.Val, nil
+	case *UnaryExpr:
+		return evalUnaryExpr(expr, m)
+	case *IsNullExpr:
+		return evalIsNullExpr(expr, m)
 	case *VarRef:
-		return m[expr.Val]
+		return m[expr.Val], nil
 	default:
-		return nil
+		return nil, nil
+	}
+}
+
+func evalCall(expr *Call, m map[string]interface{}) (interface{}, error) {
+	// Only scalar functions can be evaluated against a single point.
+	fn := LookupScalarFunction(expr.Name)
+	if fn == nil {
+		return nil, nil
+	}
+
+	values := make([]interface{}, len(expr.Args))
+	for i, arg := range expr.Args {
+		v, err := eval(arg, m)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	if v := fn(values); v != nil {
+		return v, nil
+	}
+	return nil, fmt.Errorf("invalid arguments for %s(): %s", expr.Name, expr)
+}
+
+func evalUnaryExpr(expr *UnaryExpr, m map[string]interface{}) (interface{}, error) {
+	v, err := eval(expr.Expr, m)
+	switch expr.Op {
+	case NOT:
+		if v, ok := v.(bool); ok {
+			return !v, err
+		}
+	case SUB:
+		switch v := v.(type) {
+		case float64:
+			return -v, err
+		case int64:
+			if v == math.MinInt64 {
+				return -float64(v), err
+			}
+			return -v, err
+		case time.Duration:
+			return -v, err
+		}
+	}
+
+	if err == nil && v != nil {
+		err = fmt.Errorf("cannot use %s operator on %s: %s", expr.Op, InspectDataType(v), expr)
+	}
+	return nil, err
+}
+
+func evalIsNullExpr(expr *IsNullExpr, m map[string]interface{}) (interface{}, error) {
+	// A field that is missing from the map is null but a zero value is not.
+	if ref, ok := expr.Expr.(*VarRef); ok {
+		v, ok := m[ref.Val]
+		return (!ok || v == nil) != expr.Not, nil
+	}
+
+	v, err := eval(expr.Expr, m)
+	return (v == nil) != expr.Not, err
+}
+
+func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) (interface{}, error) {
+	lhs, err := eval(expr.LHS, m)
+	rhs, rerr := eval(expr.RHS, m)
+	if err == nil {
+		err = rerr
+	}
+
+	v := evalBinaryOperands(expr.Op, lhs, rhs)
+
+	// Missing values evaluate to nil but any other operands should be usable.
+	if err == nil && lhs != nil && rhs != nil && (v == nil || !comparableTypes(lhs, rhs)) {
+		ltype, rtype := InspectDataType(lhs), InspectDataType(rhs)
+		if IsRegexOp(expr.Op) {
+			err = fmt.Errorf("cannot match %s against a regular expression: %s", ltype, expr)
+		} else if expr.Op.Precedence() == EQ.Precedence() {
+			err = fmt.Errorf("cannot compare %s to %s: %s", ltype, rtype, expr)
+		} else {
+			err = fmt.Errorf("cannot use %s operator on %s and %s: %s", expr.Op, ltype, rtype, expr)
+		}
 	}
+	return v, err
 }
 
-func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) interface{} {
-	lhs := Eval(expr.LHS, m)
-	rhs := Eval(expr.RHS, m)
+// comparableTypes returns true if two values can be used as the operands of
+// the same binary expression.
+func comparableTypes(lhs, rhs interface{}) bool {
+	// Only strings can be matched against a regular expression.
+	if _, ok := rhs.(*regexp.Regexp); ok {
+		_, ok := lhs.(string)
+		return ok
+	}
+
+	ltype, rtype := InspectDataType(lhs), InspectDataType(rhs)
+	if ltype == rtype {
+		return true
+	}
+
+	switch ltype {
+	case Float, Integer:
+		return rtype == Float || rtype == Integer || rtype == Duration
+	case Time:
+		return rtype == Duration
+	case Duration:
+		return rtype == Float || rtype == Integer || rtype == Time
+	}
+	return false
+}
 
+// evalBinaryOperands evaluates op against the values of both operands.
+// Returns nil if op can't be applied to the values.
+func evalBinaryOperands(op Token, lhs, rhs interface{}) interface{} {
 	// Evaluate if both sides are simple types.
 	switch lhs := lhs.(type) {
 	case bool:
 		rhs, _ := rhs.(bool)
-		switch expr.Op {
+		switch op {
 		case AND:
 			return lhs && rhs
 		case OR:
@@ -2547,38 +4910,38 @@ func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) interface{} {
 			return lhs != rhs
 		}
 	case float64:
-		rhs, _ := rhs.(float64)
-		switch expr.Op {
-		case EQ:
-			return lhs == rhs
-		case NEQ:
-			return lhs != rhs
-		case LT:
-			return lhs < rhs
-		case LTE:
-			return lhs <= rhs
-		case GT:
-			return lhs > rhs
-		case GTE:
-			return lhs >= rhs
-		case ADD:
-			return lhs + rhs
-		case SUB:
-			return lhs - rhs
-		case MUL:
-			return lhs * rhs
-		case DIV:
-			if rhs == 0 {
-				return float64(0)
-			}
-			return lhs / rhs
+		switch rhs := rhs.(type) {
+		case float64:
+			return evalFloatBinaryExpr(op, lhs, rhs)
+		case int64:
+			return evalFloatBinaryExpr(op, lhs, float64(rhs))
 		}
 	case int64:
-		// we parse all number literals as float 64, so we have to convert from
-		// an interface to the float64, then cast to an int64 for comparison
-		rhsf, _ := rhs.(float64)
-		rhs := int64(rhsf)
-		switch expr.Op {
+		switch rhs := rhs.(type) {
+		case int64:
+			return evalIntegerBinaryExpr(op, lhs, rhs)
+		case float64:
+			// Promote the integer so a fractional RHS is not truncated.
+			return evalFloatBinaryExpr(op, float64(lhs), rhs)
+		case time.Duration:
+			// Multiplying an integer by a duration is commutative.
+			if op == MUL {
+				return time.Duration(lhs) * rhs
+			}
+		}
+	case string:
+		if re, ok := rhs.(*regexp.Regexp); ok {
+			switch op {
+			case EQREGEX:
+				return re.MatchString(lhs)
+			case NEQREGEX:
+				return !re.MatchString(lhs)
+			}
+			return nil
+		}
+
+		rhs, _ := rhs.(string)
+		switch op {
 		case EQ:
 			return lhs == rhs
 		case NEQ:
@@ -2591,30 +4954,249 @@ func evalBinaryExpr(expr *BinaryExpr, m map[string]interface{}) interface{} {
 			return lhs > rhs
 		case GTE:
 			return lhs >= rhs
-		case ADD:
-			return lhs + rhs
-		case SUB:
-			return lhs - rhs
-		case MUL:
-			return lhs * rhs
-		case DIV:
-			if rhs == 0 {
-				return int64(0)
+		case LIKE:
+			return MatchLike(rhs, lhs)
+		}
+	case time.Time:
+		switch rhs := rhs.(type) {
+		case time.Time:
+			return evalTimeBinaryExpr(op, lhs, rhs)
+		case time.Duration:
+			switch op {
+			case ADD:
+				return lhs.Add(rhs)
+			case SUB:
+				return lhs.Add(-rhs)
 			}
-			return lhs / rhs
 		}
-	case string:
-		rhs, _ := rhs.(string)
-		switch expr.Op {
-		case EQ:
-			return lhs == rhs
-		case NEQ:
-			return lhs != rhs
+	case time.Duration:
+		switch rhs := rhs.(type) {
+		case time.Duration:
+			return evalDurationBinaryExpr(op, lhs, rhs)
+		case time.Time:
+			// Adding a duration to a time is commutative.
+			if op == ADD {
+				return rhs.Add(lhs)
+			}
+		case int64:
+			return evalDurationScalarBinaryExpr(op, lhs, rhs)
+		case float64:
+			return evalDurationScalarBinaryExpr(op, lhs, int64(rhs))
+		}
+	}
+	return nil
+}
+
+// evalTimeBinaryExpr evaluates op against two time values.
+func evalTimeBinaryExpr(op Token, lhs, rhs time.Time) interface{} {
+	switch op {
+	case EQ:
+		return lhs.Equal(rhs)
+	case NEQ:
+		return !lhs.Equal(rhs)
+	case LT:
+		return lhs.Before(rhs)
+	case LTE:
+		return !lhs.After(rhs)
+	case GT:
+		return lhs.After(rhs)
+	case GTE:
+		return !lhs.Before(rhs)
+	case SUB:
+		return lhs.Sub(rhs)
+	}
+	return nil
+}
+
+// evalDurationBinaryExpr evaluates op against two duration values.
+func evalDurationBinaryExpr(op Token, lhs, rhs time.Duration) interface{} {
+	switch op {
+	case EQ:
+		return lhs == rhs
+	case NEQ:
+		return lhs != rhs
+	case LT:
+		return lhs < rhs
+	case LTE:
+		return lhs <= rhs
+	case GT:
+		return lhs > rhs
+	case GTE:
+		return lhs >= rhs
+	case ADD:
+		return lhs + rhs
+	case SUB:
+		return lhs - rhs
+	case MOD:
+		if rhs == 0 {
+			return time.Duration(0)
+		}
+		return lhs % rhs
+	}
+	return nil
+}
+
+// evalDurationScalarBinaryExpr evaluates op against a duration and a number.
+func evalDurationScalarBinaryExpr(op Token, lhs time.Duration, rhs int64) interface{} {
+	switch op {
+	case MUL:
+		return lhs * time.Duration(rhs)
+	case DIV:
+		if rhs == 0 {
+			return time.Duration(0)
+		}
+		return lhs / time.Duration(rhs)
+	}
+	return nil
+}
+
+// MatchLike returns true if s matches a LIKE pattern. The "%" and "*"
+// wildcards match any sequence of characters and a backslash matches the
+// character that follows it literally.
+func MatchLike(pattern, s string) bool {
+	segments := likeSegments(pattern)
+	if len(segments) == 1 {
+		return s == segments[0]
+	}
+
+	// The first and last segments are anchored to the start and end of the
+	// string. The segments in between can match anywhere after the previous one.
+	first, last := segments[0], segments[len(segments)-1]
+	if !strings.HasPrefix(s, first) {
+		return false
+	}
+	s = s[len(first):]
+
+	for _, segment := range segments[1 : len(segments)-1] {
+		i := strings.Index(s, segment)
+		if i == -1 {
+			return false
+		}
+		s = s[i+len(segment):]
+	}
+	return strings.HasSuffix(s, last)
+}
+
+// LikePrefix returns the literal prefix of a LIKE pattern and true if the
+// pattern only has wildcards at its end, such as "server%". These patterns
+// match the same strings as a prefix comparison.
+func LikePrefix(pattern string) (string, bool) {
+	segments := likeSegments(pattern)
+	if len(segments) == 1 {
+		return "", false
+	}
+	for _, segment := range segments[1:] {
+		if segment != "" {
+			return "", false
+		}
+	}
+	return segments[0], true
+}
+
+// likeSegments splits a LIKE pattern into the literal text between wildcards.
+func likeSegments(pattern string) []string {
+	var segments []string
+	var buf bytes.Buffer
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			_ = buf.WriteByte(pattern[i])
+		case '%', '*':
+			segments = append(segments, buf.String())
+			buf.Reset()
+		default:
+			_ = buf.WriteByte(ch)
+		}
+	}
+	return append(segments, buf.String())
+}
+
+// evalFloatBinaryExpr evaluates op against two float values.
+func evalFloatBinaryExpr(op Token, lhs, rhs float64) interface{} {
+	switch op {
+	case EQ:
+		return lhs == rhs
+	case NEQ:
+		return lhs != rhs
+	case LT:
+		return lhs < rhs
+	case LTE:
+		return lhs <= rhs
+	case GT:
+		return lhs > rhs
+	case GTE:
+		return lhs >= rhs
+	case ADD:
+		return lhs + rhs
+	case SUB:
+		return lhs - rhs
+	case MUL:
+		return lhs * rhs
+	case DIV:
+		if rhs == 0 {
+			return float64(0)
 		}
+		return lhs / rhs
+	case MOD:
+		if rhs == 0 {
+			return float64(0)
+		}
+		return math.Mod(lhs, rhs)
 	}
 	return nil
 }
 
+// evalIntegerBinaryExpr evaluates op against two integer values.
+func evalIntegerBinaryExpr(op Token, lhs, rhs int64) interface{} {
+	switch op {
+	case EQ:
+		return lhs == rhs
+	case NEQ:
+		return lhs != rhs
+	case LT:
+		return lhs < rhs
+	case LTE:
+		return lhs <= rhs
+	case GT:
+		return lhs > rhs
+	case GTE:
+		return lhs >= rhs
+	case ADD:
+		if v := lhs + rhs; (v > lhs) == (rhs > 0) {
+			return v
+		}
+	case SUB:
+		if v := lhs - rhs; (v < lhs) == (rhs > 0) {
+			return v
+		}
+	case MUL:
+		if lhs == 0 || rhs == 0 {
+			return int64(0)
+		} else if v := lhs * rhs; v/rhs == lhs && !(rhs == -1 && lhs == math.MinInt64) {
+			return v
+		}
+	case DIV:
+		if rhs == 0 {
+			return int64(0)
+		} else if !(rhs == -1 && lhs == math.MinInt64) {
+			return lhs / rhs
+		}
+	case MOD:
+		if rhs == 0 {
+			return int64(0)
+		}
+		return lhs % rhs
+	default:
+		return nil
+	}
+
+	// The result overflowed an int64 so return the closest float instead.
+	return evalFloatBinaryExpr(op, float64(lhs), float64(rhs))
+}
+
 // Reduce evaluates expr using the available values in valuer.
 // References that don't exist in valuer are ignored.
 func Reduce(expr Expr, valuer Valuer) Expr {
@@ -2639,6 +5221,10 @@ func reduce(expr Expr, valuer Valuer) Expr {
 		return reduceCall(expr, valuer)
 	case *ParenExpr:
 		return reduceParenExpr(expr, valuer)
+	case *UnaryExpr:
+		return reduceUnaryExpr(expr, valuer)
+	case *IsNullExpr:
+		return reduceIsNullExpr(expr, valuer)
 	case *VarRef:
 		return reduceVarRef(expr, valuer)
 	default:
@@ -2683,6 +5269,8 @@ func reduceBinaryExpr(expr *BinaryExpr, valuer Valuer) Expr {
 		return reduceBinaryExprBooleanLHS(op, lhs, rhs)
 	case *DurationLiteral:
 		return reduceBinaryExprDurationLHS(op, lhs, rhs)
+	case *IntegerLiteral:
+		return reduceBinaryExprIntegerLHS(op, lhs, rhs)
 	case *nilLiteral:
 		return reduceBinaryExprNilLHS(op, lhs, rhs)
 	case *NumberLiteral:
@@ -2723,6 +5311,11 @@ func reduceBinaryExprDurationLHS(op Token, lhs *DurationLiteral, rhs Expr) Expr
 			return &DurationLiteral{Val: lhs.Val + rhs.Val}
 		case SUB:
 			return &DurationLiteral{Val: lhs.Val - rhs.Val}
+		case MOD:
+			if rhs.Val == 0 {
+				return &DurationLiteral{Val: 0}
+			}
+			return &DurationLiteral{Val: lhs.Val % rhs.Val}
 		case EQ:
 			return &BooleanLiteral{Val: lhs.Val == rhs.Val}
 		case NEQ:
@@ -2746,6 +5339,16 @@ func reduceBinaryExprDurationLHS(op Token, lhs *DurationLiteral, rhs Expr) Expr
 			}
 			return &DurationLiteral{Val: lhs.Val / time.Duration(rhs.Val)}
 		}
+	case *IntegerLiteral:
+		switch op {
+		case MUL:
+			return &DurationLiteral{Val: lhs.Val * time.Duration(rhs.Val)}
+		case DIV:
+			if rhs.Val == 0 {
+				return &DurationLiteral{Val: 0}
+			}
+			return &DurationLiteral{Val: lhs.Val / time.Duration(rhs.Val)}
+		}
 	case *TimeLiteral:
 		switch op {
 		case ADD:
@@ -2757,6 +5360,44 @@ func reduceBinaryExprDurationLHS(op Token, lhs *DurationLiteral, rhs Expr) Expr
 	return &BinaryExpr{Op: op, LHS: lhs, RHS: rhs}
 }
 
+func reduceBinaryExprIntegerLHS(op Token, lhs *IntegerLiteral, rhs Expr) Expr {
+	switch rhs := rhs.(type) {
+	case *IntegerLiteral:
+		switch op {
+		case ADD, SUB, MUL, DIV, MOD:
+			// Arithmetic is only promoted to a float if it overflows.
+			switch v := evalIntegerBinaryExpr(op, lhs.Val, rhs.Val).(type) {
+			case int64:
+				return &IntegerLiteral{Val: v}
+			case float64:
+				return &NumberLiteral{Val: v}
+			}
+		case EQ:
+			return &BooleanLiteral{Val: lhs.Val == rhs.Val}
+		case NEQ:
+			return &BooleanLiteral{Val: lhs.Val != rhs.Val}
+		case GT:
+			return &BooleanLiteral{Val: lhs.Val > rhs.Val}
+		case GTE:
+			return &BooleanLiteral{Val: lhs.Val >= rhs.Val}
+		case LT:
+			return &BooleanLiteral{Val: lhs.Val < rhs.Val}
+		case LTE:
+			return &BooleanLiteral{Val: lhs.Val <= rhs.Val}
+		}
+	case *NumberLiteral:
+		return reduceBinaryExprNumberLHS(op, &NumberLiteral{Val: float64(lhs.Val)}, rhs)
+	case *DurationLiteral:
+		// Multiplying an integer by a duration is commutative.
+		if op == MUL {
+			return &DurationLiteral{Val: time.Duration(lhs.Val) * rhs.Val}
+		}
+	case *nilLiteral:
+		return &BooleanLiteral{Val: false}
+	}
+	return &BinaryExpr{Op: op, LHS: lhs, RHS: rhs}
+}
+
 func reduceBinaryExprNilLHS(op Token, lhs *nilLiteral, rhs Expr) Expr {
 	switch op {
 	case EQ, NEQ:
@@ -2780,6 +5421,11 @@ func reduceBinaryExprNumberLHS(op Token, lhs *NumberLiteral, rhs Expr) Expr {
 				return &NumberLiteral{Val: 0}
 			}
 			return &NumberLiteral{Val: lhs.Val / rhs.Val}
+		case MOD:
+			if rhs.Val == 0 {
+				return &NumberLiteral{Val: 0}
+			}
+			return &NumberLiteral{Val: math.Mod(lhs.Val, rhs.Val)}
 		case EQ:
 			return &BooleanLiteral{Val: lhs.Val == rhs.Val}
 		case NEQ:
@@ -2793,6 +5439,8 @@ func reduceBinaryExprNumberLHS(op Token, lhs *NumberLiteral, rhs Expr) Expr {
 		case LTE:
 			return &BooleanLiteral{Val: lhs.Val <= rhs.Val}
 		}
+	case *IntegerLiteral:
+		return reduceBinaryExprNumberLHS(op, lhs, &NumberLiteral{Val: float64(rhs.Val)})
 	case *nilLiteral:
 		return &BooleanLiteral{Val: false}
 	}
@@ -2807,12 +5455,29 @@ func reduceBinaryExprStringLHS(op Token, lhs *StringLiteral, rhs Expr) Expr {
 			return &BooleanLiteral{Val: lhs.Val == rhs.Val}
 		case NEQ:
 			return &BooleanLiteral{Val: lhs.Val != rhs.Val}
+		case LT:
+			return &BooleanLiteral{Val: lhs.Val < rhs.Val}
+		case LTE:
+			return &BooleanLiteral{Val: lhs.Val <= rhs.Val}
+		case GT:
+			return &BooleanLiteral{Val: lhs.Val > rhs.Val}
+		case GTE:
+			return &BooleanLiteral{Val: lhs.Val >= rhs.Val}
+		case LIKE:
+			return &BooleanLiteral{Val: MatchLike(rhs.Val, lhs.Val)}
 		case ADD:
 			return &StringLiteral{Val: lhs.Val + rhs.Val}
 		}
+	case *RegexLiteral:
+		switch op {
+		case EQREGEX:
+			return &BooleanLiteral{Val: rhs.Val.MatchString(lhs.Val)}
+		case NEQREGEX:
+			return &BooleanLiteral{Val: !rhs.Val.MatchString(lhs.Val)}
+		}
 	case *nilLiteral:
 		switch op {
-		case EQ, NEQ:
+		case EQ, NEQ, LIKE:
 			return &BooleanLiteral{Val: false}
 		}
 	}
@@ -2865,9 +5530,125 @@ func reduceCall(expr *Call, valuer Valuer) Expr {
 	for i, arg := range expr.Args {
 		args[i] = reduce(arg, valuer)
 	}
+
+	// Fold scalar functions if all of their arguments are constant.
+	if fn := LookupScalarFunction(expr.Name); fn != nil {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			if values[i] = Eval(arg, nil); values[i] == nil {
+				return &Call{Name: expr.Name, Args: args}
+			}
+		}
+
+		if v := fn(values); v != nil {
+			return literalOf(v)
+		}
+	}
 	return &Call{Name: expr.Name, Args: args}
 }
 
+func reduceUnaryExpr(expr *UnaryExpr, valuer Valuer) Expr {
+	subexpr := reduce(expr.Expr, valuer)
+	if expr.Op == NOT {
+		return reduceNot(subexpr)
+	} else if expr.Op != SUB {
+		return &UnaryExpr{Op: expr.Op, Expr: subexpr}
+	}
+
+	// Negate literals and remove double negations.
+	switch subexpr := subexpr.(type) {
+	case *DurationLiteral:
+		return &DurationLiteral{Val: -subexpr.Val}
+	case *IntegerLiteral:
+		if subexpr.Val == math.MinInt64 {
+			return &NumberLiteral{Val: -float64(subexpr.Val)}
+		}
+		return &IntegerLiteral{Val: -subexpr.Val}
+	case *NumberLiteral:
+		return &NumberLiteral{Val: -subexpr.Val}
+	case *UnaryExpr:
+		if subexpr.Op == SUB {
+			return subexpr.Expr
+		}
+	}
+	return &UnaryExpr{Op: expr.Op, Expr: subexpr}
+}
+
+// reduceNot returns the negation of a reduced expression. Negations are
+// pushed down through AND and OR using De Morgan's laws until they can be
+// folded into a literal or a comparison operator.
+func reduceNot(expr Expr) Expr {
+	// Negation applies to the whole of a parenthesized expression.
+	inner := expr
+	for {
+		e, ok := inner.(*ParenExpr)
+		if !ok {
+			break
+		}
+		inner = e.Expr
+	}
+
+	switch inner := inner.(type) {
+	case *BooleanLiteral:
+		return &BooleanLiteral{Val: !inner.Val}
+	case *UnaryExpr:
+		if inner.Op == NOT {
+			return inner.Expr
+		}
+	case *IsNullExpr:
+		return &IsNullExpr{Expr: inner.Expr, Not: !inner.Not}
+	case *BinaryExpr:
+		switch inner.Op {
+		case AND, OR:
+			op := AND
+			if inner.Op == AND {
+				op = OR
+			}
+			lhs, rhs := reduceNot(inner.LHS), reduceNot(inner.RHS)
+			return reduceParenExpr(&ParenExpr{Expr: &BinaryExpr{Op: op, LHS: lhs, RHS: rhs}}, nil)
+		case EQ, NEQ, LT, LTE, GT, GTE, EQREGEX, NEQREGEX:
+			return &BinaryExpr{Op: negateComparison(inner.Op), LHS: inner.LHS, RHS: inner.RHS}
+		}
+	}
+	return &UnaryExpr{Op: NOT, Expr: expr}
+}
+
+// negateComparison returns the comparison operator that is true whenever op is false.
+func negateComparison(op Token) Token {
+	switch op {
+	case EQ:
+		return NEQ
+	case NEQ:
+		return EQ
+	case LT:
+		return GTE
+	case LTE:
+		return GT
+	case GT:
+		return LTE
+	case GTE:
+		return LT
+	case EQREGEX:
+		return NEQREGEX
+	case NEQREGEX:
+		return EQREGEX
+	}
+	return ILLEGAL
+}
+
+func reduceIsNullExpr(expr *IsNullExpr, valuer Valuer) Expr {
+	subexpr := reduce(expr.Expr, valuer)
+
+	// Literals are known to be either null or not null.
+	switch subexpr.(type) {
+	case *nilLiteral:
+		return &BooleanLiteral{Val: !expr.Not}
+	case *BooleanLiteral, *DurationLiteral, *IntegerLiteral, *NumberLiteral, *StringLiteral, *TimeLiteral:
+		return &BooleanLiteral{Val: expr.Not}
+	}
+	return &IsNullExpr{Expr: subexpr, Not: expr.Not}
+}
+
 func reduceParenExpr(expr *ParenExpr, valuer Valuer) Expr {
 	subexpr := reduce(expr.Expr, valuer)
 	if subexpr, ok := subexpr.(*BinaryExpr); ok {
@@ -2890,6 +5671,12 @@ func reduceVarRef(expr *VarRef, valuer Valuer) Expr {
 	}
 
 	// Return the value as a literal.
+	return literalOf(v)
+}
+
+// literalOf returns the literal expression for a value.
+// Returns a nil literal if the value's type has no literal.
+func literalOf(v interface{}) Expr {
 	switch v := v.(type) {
 	case bool:
 		return &BooleanLiteral{Val: v}
@@ -2897,6 +5684,8 @@ func reduceVarRef(expr *VarRef, valuer Valuer) Expr {
 		return &DurationLiteral{Val: v}
 	case float64:
 		return &NumberLiteral{Val: v}
+	case int64:
+		return &IntegerLiteral{Val: v}
 	case string:
 		return &StringLiteral{Val: v}
 	case time.Time:
@@ -2906,6 +5695,480 @@ func reduceVarRef(expr *VarRef, valuer Valuer) Expr {
 	}
 }
 
+// Simplify returns an equivalent form of a condition that is cheaper to plan.
+// The expression is reduced without any values and then repeated and absorbed
+// operands of AND and OR are removed, overlapping comparisons against the time
+// field are merged into a single range and parentheses are only kept where
+// they're needed to preserve the meaning of the expression.
+func Simplify(expr Expr) Expr {
+	return simplify(Reduce(expr, nil))
+}
+
+func simplify(expr Expr) Expr {
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		return simplify(expr.Expr)
+	case *BinaryExpr:
+		if expr.Op == AND || expr.Op == OR {
+			return simplifyLogicalExpr(expr)
+		}
+		return &BinaryExpr{
+			Op:  expr.Op,
+			LHS: parenthesize(simplify(expr.LHS), expr.Op, false),
+			RHS: parenthesize(simplify(expr.RHS), expr.Op, true),
+		}
+	case *UnaryExpr:
+		subexpr := simplify(expr.Expr)
+		switch subexpr := subexpr.(type) {
+		case *BinaryExpr:
+			// NOT binds less tightly than comparisons.
+			if expr.Op != NOT || subexpr.Op.Precedence() < EQ.Precedence() {
+				return &UnaryExpr{Op: expr.Op, Expr: &ParenExpr{Expr: subexpr}}
+			}
+		case *UnaryExpr, *IsNullExpr:
+			if expr.Op != NOT {
+				return &UnaryExpr{Op: expr.Op, Expr: &ParenExpr{Expr: subexpr}}
+			}
+		}
+		return &UnaryExpr{Op: expr.Op, Expr: subexpr}
+	case *IsNullExpr:
+		return &IsNullExpr{Expr: parenthesize(simplify(expr.Expr), IS, false), Not: expr.Not}
+	case *Call:
+		var args []Expr
+		for _, arg := range expr.Args {
+			args = append(args, simplify(arg))
+		}
+		return &Call{Name: expr.Name, Args: args}
+	default:
+		return expr
+	}
+}
+
+// Truth describes whether a condition's value is known without any data.
+type Truth int
+
+const (
+	// DataDependent means the condition must be evaluated against each point.
+	DataDependent Truth = iota
+	// AlwaysTrue means the condition matches every point.
+	AlwaysTrue
+	// AlwaysFalse means the condition can't match any point.
+	AlwaysFalse
+)
+
+// ConditionTruth returns whether a condition is true for every point, false
+// for every point or depends on the data. A nil condition is always true.
+func ConditionTruth(cond Expr) Truth {
+	if cond == nil {
+		return AlwaysTrue
+	}
+
+	switch expr := Simplify(cond).(type) {
+	case *BooleanLiteral:
+		if expr.Val {
+			return AlwaysTrue
+		}
+		return AlwaysFalse
+	case *nilLiteral:
+		return AlwaysFalse
+	}
+	return DataDependent
+}
+
+// simplifyLogicalExpr simplifies a chain of AND or OR operators as a whole.
+func simplifyLogicalExpr(expr *BinaryExpr) Expr {
+	op := expr.Op
+
+	// Simplify each operand of the chain. An operand may simplify to
+	// another chain of the same operator which is then flattened into this one.
+	var operands []Expr
+	for _, e := range logicalOperands(expr, op) {
+		e = simplify(e)
+
+		// Literals are either the identity of the operator, which can be
+		// dropped, or determine the value of the whole chain.
+		if e, ok := e.(*BooleanLiteral); ok {
+			if e.Val == (op == AND) {
+				continue
+			}
+			return e
+		}
+		operands = append(operands, logicalOperands(e, op)...)
+	}
+
+	operands = absorbOperands(op, operands)
+	if op == AND {
+		if hasContradiction(operands) {
+			return &BooleanLiteral{Val: false}
+		}
+		operands = intersectTimeBounds(operands)
+	} else {
+		operands = unionTimeBounds(operands)
+	}
+
+	switch len(operands) {
+	case 0:
+		return &BooleanLiteral{Val: op == AND}
+	case 1:
+		return operands[0]
+	}
+
+	other := &BinaryExpr{Op: op, LHS: parenthesize(operands[0], op, false), RHS: parenthesize(operands[1], op, true)}
+	for _, e := range operands[2:] {
+		other = &BinaryExpr{Op: op, LHS: other, RHS: parenthesize(e, op, true)}
+	}
+	return other
+}
+
+// logicalOperands returns the operands of a chain of op operators.
+// Parenthesized chains of the same operator are included in the result.
+func logicalOperands(expr Expr, op Token) []Expr {
+	for {
+		e, ok := expr.(*ParenExpr)
+		if !ok {
+			break
+		}
+		expr = e.Expr
+	}
+
+	if e, ok := expr.(*BinaryExpr); ok && e.Op == op {
+		return append(logicalOperands(e.LHS, op), logicalOperands(e.RHS, op)...)
+	}
+	return []Expr{expr}
+}
+
+// absorbOperands removes the operands of an AND or OR chain that are implied
+// by another operand. This includes repeated operands and the absorption of
+// "a OR b" by "a" in "a AND (a OR b)", and of "a AND b" by "a" in an OR chain.
+func absorbOperands(op Token, operands []Expr) []Expr {
+	dual := AND
+	if op == AND {
+		dual = OR
+	}
+
+	// Key each operand by the string representation of its own operands.
+	keys := make([]map[string]struct{}, len(operands))
+	for i, e := range operands {
+		keys[i] = make(map[string]struct{})
+		for _, e := range logicalOperands(e, dual) {
+			keys[i][e.String()] = struct{}{}
+		}
+	}
+
+	// An operand is absorbed if another operand's keys are a subset of its
+	// own. Operands with the same keys are absorbed by the first of them.
+	absorbed := make([]bool, len(operands))
+	for i := range operands {
+		for j := range operands {
+			if i == j || absorbed[j] || !isKeySubset(keys[j], keys[i]) {
+				continue
+			} else if len(keys[j]) < len(keys[i]) || j < i {
+				absorbed[i] = true
+				break
+			}
+		}
+	}
+
+	other := operands[:0]
+	for i, e := range operands {
+		if !absorbed[i] {
+			other = append(other, e)
+		}
+	}
+	return other
+}
+
+// isKeySubset returns true if every key in a is also in b.
+func isKeySubset(a, b map[string]struct{}) bool {
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hasContradiction returns true if any two operands of an AND chain can't
+// both be true for the same point.
+func hasContradiction(operands []Expr) bool {
+	for i, a := range operands {
+		// A condition can't be true at the same time as its negation.
+		not := simplify(reduceNot(a)).String()
+
+		for _, b := range operands[i+1:] {
+			if b.String() == not {
+				return true
+			}
+
+			// A reference can't be equal to two different values.
+			ref0, lit0 := equalityOperands(a)
+			ref1, lit1 := equalityOperands(b)
+			if ref0 != "" && ref0 == ref1 && isFalseLiteral(reduce(&BinaryExpr{Op: EQ, LHS: lit0, RHS: lit1}, nil)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// equalityOperands returns the reference and literal of an equality
+// comparison such as "host = 'serverA'". Returns a blank reference if the
+// expression is not an equality comparison against a literal.
+func equalityOperands(expr Expr) (string, Expr) {
+	e, ok := expr.(*BinaryExpr)
+	if !ok || e.Op != EQ {
+		return "", nil
+	}
+
+	ref, lit := e.LHS, e.RHS
+	if _, ok := ref.(*VarRef); !ok {
+		ref, lit = lit, ref
+	}
+	if ref, ok := ref.(*VarRef); ok {
+		switch lit.(type) {
+		case *BooleanLiteral, *DurationLiteral, *IntegerLiteral, *NumberLiteral, *StringLiteral, *TimeLiteral:
+			return ref.Val, lit
+		}
+	}
+	return "", nil
+}
+
+// timeBound is a comparison of the time field against a time literal.
+type timeBound struct {
+	expr Expr      // original comparison
+	op   Token     // operator with time on the left-hand side
+	val  time.Time // literal time
+}
+
+// newTimeBound returns the time bound for an expression.
+// Returns nil if the expression is not a range comparison against a time.
+func newTimeBound(expr Expr) *timeBound {
+	e, ok := expr.(*BinaryExpr)
+	if !ok {
+		return nil
+	}
+
+	// Flip the operator if time is on the right-hand side.
+	op, val := e.Op, timeExprValue(e.LHS, e.RHS)
+	if val.IsZero() {
+		if val = timeExprValue(e.RHS, e.LHS); val.IsZero() {
+			return nil
+		}
+		switch op {
+		case LT:
+			op = GT
+		case LTE:
+			op = GTE
+		case GT:
+			op = LT
+		case GTE:
+			op = LTE
+		}
+	}
+
+	switch op {
+	case GT, GTE, LT, LTE:
+		return &timeBound{expr: expr, op: op, val: val}
+	}
+	return nil
+}
+
+// lower returns true if the bound is a minimum time.
+func (b *timeBound) lower() bool { return b.op == GT || b.op == GTE }
+
+// tighter returns true if b excludes more times than other.
+// Both bounds must be either minimum or maximum times.
+func (b *timeBound) tighter(other *timeBound) bool {
+	if !b.val.Equal(other.val) {
+		return b.val.After(other.val) == b.lower()
+	}
+	return (b.op == GT || b.op == LT) && (other.op == GTE || other.op == LTE)
+}
+
+// timeBounds is the range of times matched by a conjunction of time bounds.
+// A nil bound means the range is unbounded on that side.
+type timeBounds struct {
+	min, max *timeBound
+}
+
+// newTimeBounds returns the range of times matched by an AND chain of time
+// bounds. Returns nil if the expression contains any other condition or more
+// than one bound on either side.
+func newTimeBounds(expr Expr) *timeBounds {
+	r := &timeBounds{}
+	for _, e := range logicalOperands(expr, AND) {
+		b := newTimeBound(e)
+		if b == nil {
+			return nil
+		} else if b.lower() {
+			if r.min != nil {
+				return nil
+			}
+			r.min = b
+		} else {
+			if r.max != nil {
+				return nil
+			}
+			r.max = b
+		}
+	}
+	return r
+}
+
+// empty returns true if no time is within the range.
+func (r *timeBounds) empty() bool {
+	if r.min == nil || r.max == nil {
+		return false
+	} else if !r.min.val.Equal(r.max.val) {
+		return r.min.val.After(r.max.val)
+	}
+	return r.min.op == GT || r.max.op == LT
+}
+
+// expr returns the condition that matches the range.
+func (r *timeBounds) expr() Expr {
+	switch {
+	case r.min != nil && r.max != nil:
+		return &BinaryExpr{Op: AND, LHS: r.min.expr, RHS: r.max.expr}
+	case r.min != nil:
+		return r.min.expr
+	case r.max != nil:
+		return r.max.expr
+	default:
+		return &BooleanLiteral{Val: true}
+	}
+}
+
+// intersectTimeBounds replaces the time bounds in an AND chain with the
+// tightest minimum and maximum times. The chain is replaced by a false
+// literal if the bounds don't overlap.
+func intersectTimeBounds(operands []Expr) []Expr {
+	var r timeBounds
+	other := make([]Expr, 0, len(operands))
+	for _, e := range operands {
+		b := newTimeBound(e)
+		if b == nil {
+			other = append(other, e)
+			continue
+		}
+
+		// The first bound of each side holds the place of the merged bound.
+		bound := &r.max
+		if b.lower() {
+			bound = &r.min
+		}
+		if *bound == nil {
+			other = append(other, e)
+			*bound = b
+		} else if b.tighter(*bound) {
+			for i := range other {
+				if other[i] == (*bound).expr {
+					other[i] = e
+				}
+			}
+			*bound = b
+		}
+	}
+
+	if r.empty() {
+		return []Expr{&BooleanLiteral{Val: false}}
+	}
+	return other
+}
+
+// unionTimeBounds merges the time ranges in an OR chain which overlap or are
+// adjacent to each other. The chain is replaced by a true literal if the
+// merged range is unbounded.
+func unionTimeBounds(operands []Expr) []Expr {
+	other := make([]Expr, 0, len(operands))
+	ranges := make([]*timeBounds, 0, len(operands))
+	for _, e := range operands {
+		r := newTimeBounds(e)
+		if r == nil {
+			other, ranges = append(other, e), append(ranges, nil)
+			continue
+		}
+
+		// Merge with each overlapping range seen so far. Merging with one
+		// range can make the result overlap with another.
+		for merged := true; merged; {
+			merged = false
+			for i := range ranges {
+				if ranges[i] == nil || ranges[i].disjoint(r) {
+					continue
+				}
+				r, e = ranges[i].union(r), nil
+				other, ranges = append(other[:i], other[i+1:]...), append(ranges[:i], ranges[i+1:]...)
+				merged = true
+				break
+			}
+		}
+
+		if r.min == nil && r.max == nil {
+			return []Expr{&BooleanLiteral{Val: true}}
+		} else if e == nil {
+			e = r.expr()
+		}
+		other, ranges = append(other, e), append(ranges, r)
+	}
+	return other
+}
+
+// disjoint returns true if there is a time between the two ranges which is
+// not within either of them.
+func (r *timeBounds) disjoint(other *timeBounds) bool {
+	return isTimeGap(r.max, other.min) || isTimeGap(other.max, r.min)
+}
+
+// isTimeGap returns true if there are times after max and before min.
+func isTimeGap(max, min *timeBound) bool {
+	if max == nil || min == nil {
+		return false
+	} else if !max.val.Equal(min.val) {
+		return max.val.Before(min.val)
+	}
+	return max.op == LT && min.op == GT
+}
+
+// union returns the smallest range containing both ranges.
+func (r *timeBounds) union(other *timeBounds) *timeBounds {
+	u := &timeBounds{}
+	if r.min != nil && other.min != nil {
+		if u.min = r.min; r.min.tighter(other.min) {
+			u.min = other.min
+		}
+	}
+	if r.max != nil && other.max != nil {
+		if u.max = r.max; r.max.tighter(other.max) {
+			u.max = other.max
+		}
+	}
+	return u
+}
+
+// parenthesize wraps an operand of op in parentheses if it would otherwise
+// bind to a different operator when the expression is parsed again.
+func parenthesize(expr Expr, op Token, rhs bool) Expr {
+	prec := op.Precedence()
+
+	var needed bool
+	switch expr := expr.(type) {
+	case *BinaryExpr:
+		// Operators of equal precedence are left associative.
+		needed = expr.Op.Precedence() < prec || (expr.Op.Precedence() == prec && rhs)
+	case *IsNullExpr:
+		needed = IS.Precedence() < prec || (IS.Precedence() == prec && rhs)
+	case *UnaryExpr:
+		// NOT binds less tightly than comparisons.
+		needed = expr.Op == NOT && prec >= EQ.Precedence()
+	}
+
+	if needed {
+		return &ParenExpr{Expr: expr}
+	}
+	return expr
+}
+
 // Valuer is the interface that wraps the Value() method.
 //
 // Value returns the value and existence flag for a given key.
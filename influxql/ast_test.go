@@ -2,6 +2,7 @@ package influxql_test
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
@@ -25,6 +26,462 @@ func TestInspectDataType(t *testing.T) {
 	}
 }
 
+// Ensure the SELECT statement can be converted to a string and parsed back.
+func TestSelectStatement_String(t *testing.T) {
+	var tests = []struct {
+		stmt string
+		s    string
+	}{
+		{
+			stmt: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(0)`,
+			s:    `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(0)`,
+		},
+		{
+			stmt: `SELECT value INTO db.rp.:MEASUREMENT FROM /cpu.*/`,
+			s:    `SELECT value INTO "db"."rp".:MEASUREMENT FROM /cpu.*/`,
+		},
+		{
+			stmt: `SELECT value INTO db..:MEASUREMENT FROM cpu`,
+			s:    `SELECT value INTO "db"..:MEASUREMENT FROM cpu`,
+		},
+		{
+			stmt: `SELECT value INTO :measurement FROM cpu`,
+			s:    `SELECT value INTO :MEASUREMENT FROM cpu`,
+		},
+		{
+			stmt: `SELECT value FROM telegraf."30d".cpu, "30d".mem, "my\"db"..disk`,
+			s:    `SELECT value FROM "telegraf"."30d".cpu, "30d".mem, "my\"db"..disk`,
+		},
+		{
+			stmt: `SELECT value FROM cpu ORDER BY time desc LIMIT 10`,
+			s:    `SELECT value FROM cpu ORDER BY time DESC LIMIT 10`,
+		},
+		{
+			stmt: `SELECT value FROM cpu ORDER BY asc`,
+			s:    `SELECT value FROM cpu ORDER BY ASC`,
+		},
+		{
+			stmt: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY /dc_.*/,time(1h)`,
+			s:    `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY /dc_.*/, time(1h)`,
+		},
+		{
+			stmt: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(1.5)`,
+			s:    `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(1.5)`,
+		},
+		{
+			stmt: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(null)`,
+			s:    `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h)`,
+		},
+		{
+			stmt: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(none)`,
+			s:    `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(none)`,
+		},
+		{
+			stmt: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(previous)`,
+			s:    `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h) fill(previous)`,
+		},
+		{
+			stmt: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h), host SLIMIT 10 SOFFSET 20`,
+			s:    `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY time(1h), host SLIMIT 10 SOFFSET 20`,
+		},
+		{
+			stmt: `SELECT value FROM cpu LIMIT 5 OFFSET 1 SLIMIT 2 SOFFSET 3`,
+			s:    `SELECT value FROM cpu LIMIT 5 OFFSET 1 SLIMIT 2 SOFFSET 3`,
+		},
+	}
+
+	for i, tt := range tests {
+		stmt := MustParseSelectStatement(tt.stmt)
+		if s := stmt.String(); s != tt.s {
+			t.Errorf("%d. %q: unexpected string:\n\nexp=%s\n\ngot=%s\n\n", i, tt.stmt, tt.s, s)
+			continue
+		}
+
+		// Reparse the output and ensure it produces the same statement.
+		if other := MustParseSelectStatement(stmt.String()); other.String() != tt.s {
+			t.Errorf("%d. %q: round trip mismatch:\n\nexp=%s\n\ngot=%s\n\n", i, tt.stmt, tt.s, other.String())
+		}
+	}
+}
+
+// Ensure a DELETE statement can be converted to a string and parsed back.
+func TestDeleteStatement_String(t *testing.T) {
+	stmt, err := influxql.ParseStatement(`DELETE FROM db0.rp0.cpu WHERE time < now() - 1d AND host = 'serverA'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := stmt.String()
+	if s != `DELETE FROM "db0"."rp0".cpu WHERE time < now() - 1d AND host = 'serverA'` {
+		t.Fatalf("unexpected string: %s", s)
+	}
+	other, err := influxql.ParseStatement(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clearPos(stmt)
+	clearPos(other)
+	if !reflect.DeepEqual(stmt, other) {
+		t.Fatalf("unexpected statement after round trip: %s", other)
+	}
+
+	exp := influxql.ExecutionPrivileges{{Name: "db0", Privilege: influxql.WritePrivilege}}
+	if priv := stmt.RequiredPrivileges(); !reflect.DeepEqual(priv, exp) {
+		t.Fatalf("unexpected privileges: %#v", priv)
+	}
+}
+
+// Ensure a CREATE CONTINUOUS QUERY statement with RESAMPLE options can be converted to a string and parsed back.
+func TestCreateContinuousQueryStatement_String(t *testing.T) {
+	var tests = []string{
+		`CREATE CONTINUOUS QUERY cq ON db0 BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`,
+		`CREATE CONTINUOUS QUERY cq ON db0 RESAMPLE EVERY 10m BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`,
+		`CREATE CONTINUOUS QUERY cq ON db0 RESAMPLE FOR 30m BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`,
+		`CREATE CONTINUOUS QUERY cq ON db0 RESAMPLE EVERY 10m FOR 30m BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`,
+		`CREATE CONTINUOUS QUERY cq ON db0 RESAMPLE EVERY 10m BACKFILL BEGIN SELECT count(value) INTO cpu2 FROM cpu WHERE time > now() - 30d GROUP BY time(1m) END`,
+	}
+
+	for i, s := range tests {
+		stmt, err := influxql.ParseStatement(s)
+		if err != nil {
+			t.Fatalf("%d. %q: %s", i, s, err)
+		}
+		if stmt.String() != s {
+			t.Errorf("%d. unexpected string:\n\nexp=%s\n\ngot=%s\n\n", i, s, stmt.String())
+		}
+		if other, err := influxql.ParseStatement(stmt.String()); err != nil {
+			t.Fatalf("%d. %q: %s", i, s, err)
+		} else if !reflect.DeepEqual(stmt, other) {
+			t.Errorf("%d. %q: unexpected statement after round trip: %s", i, s, other)
+		}
+	}
+}
+
+// Ensure a BACKFILL CONTINUOUS QUERY statement can be converted to a string and parsed back.
+func TestBackfillContinuousQueryStatement_String(t *testing.T) {
+	s := `BACKFILL CONTINUOUS QUERY cq ON db0 WHERE time >= now() - 30d AND time < now() - 1d`
+	stmt, err := influxql.ParseStatement(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stmt.String() != s {
+		t.Fatalf("unexpected string:\n\nexp=%s\n\ngot=%s\n\n", s, stmt.String())
+	}
+
+	other, err := influxql.ParseStatement(stmt.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clearPos(stmt)
+	clearPos(other)
+	if !reflect.DeepEqual(stmt, other) {
+		t.Fatalf("unexpected statement after round trip: %s", other)
+	}
+
+	if typ := influxql.StatementType(stmt); typ != influxql.AdminStatement {
+		t.Fatalf("unexpected statement type: %s", typ)
+	}
+	if names := influxql.AffectedDatabases(stmt, ""); !reflect.DeepEqual(names, []string{"db0"}) {
+		t.Fatalf("unexpected affected databases: %v", names)
+	}
+}
+
+// Ensure a SHOW CONTINUOUS QUERIES statement can be converted to a string and parsed back.
+func TestShowContinuousQueriesStatement_String(t *testing.T) {
+	s := `SHOW CONTINUOUS QUERIES ON "my db" WHERE name = 'cq0' LIMIT 10 OFFSET 5`
+	stmt, err := influxql.ParseStatement(s)
+	if err != nil {
+		t.Fatal(err)
+	} else if stmt.String() != s {
+		t.Fatalf("unexpected string: %s", stmt.String())
+	}
+
+	exp := influxql.ExecutionPrivileges{{Name: "my db", Privilege: influxql.ReadPrivilege}}
+	if priv := stmt.RequiredPrivileges(); !reflect.DeepEqual(priv, exp) {
+		t.Fatalf("unexpected privileges: %#v", priv)
+	}
+}
+
+// Ensure subscription statements can be converted to a string and parsed back.
+func TestSubscriptionStatement_String(t *testing.T) {
+	var tests = []string{
+		`CREATE SUBSCRIPTION s0 ON "db0".rp0 DESTINATIONS ALL 'udp://h1:9090'`,
+		`CREATE SUBSCRIPTION "my sub" ON "my db".rp0 DESTINATIONS ANY 'udp://h1:9090', 'udp://h2:9090'`,
+		`DROP SUBSCRIPTION s0 ON "db0".rp0`,
+		`SHOW SUBSCRIPTIONS`,
+	}
+
+	for i, s := range tests {
+		stmt, err := influxql.ParseStatement(s)
+		if err != nil {
+			t.Fatalf("%d. %q: %s", i, s, err)
+		} else if stmt.String() != s {
+			t.Errorf("%d. unexpected string:\n\nexp=%s\n\ngot=%s\n\n", i, s, stmt.String())
+		}
+	}
+}
+
+// Ensure statements writing into a target require write access to the target's measurement.
+func TestStatement_RequiredPrivileges_Target(t *testing.T) {
+	var tests = []struct {
+		stmt string
+		exp  influxql.ExecutionPrivileges
+	}{
+		{
+			stmt: `SELECT value INTO cpu2 FROM cpu`,
+			exp:  influxql.ExecutionPrivileges{{Name: "", Measurement: "cpu", Privilege: influxql.ReadPrivilege}, {Name: "", Measurement: "cpu2", Privilege: influxql.WritePrivilege}},
+		},
+		{
+			stmt: `SELECT value INTO db1."rp1".cpu2 FROM cpu`,
+			exp:  influxql.ExecutionPrivileges{{Name: "", Measurement: "cpu", Privilege: influxql.ReadPrivilege}, {Name: "db1", Measurement: "cpu2", Privilege: influxql.WritePrivilege}},
+		},
+		{
+			stmt: `SELECT value INTO db1."rp1".:MEASUREMENT FROM /cpu.*/`,
+			exp:  influxql.ExecutionPrivileges{{Name: "", Privilege: influxql.ReadPrivilege}, {Name: "db1", Privilege: influxql.WritePrivilege}},
+		},
+		{
+			stmt: `SELECT value FROM telegraf."30d".cpu, cpu, db1..mem, telegraf..cpu`,
+			exp:  influxql.ExecutionPrivileges{{Name: "telegraf", Measurement: "cpu", Privilege: influxql.ReadPrivilege}, {Name: "", Measurement: "cpu", Privilege: influxql.ReadPrivilege}, {Name: "db1", Measurement: "mem", Privilege: influxql.ReadPrivilege}},
+		},
+		{
+			stmt: `CREATE CONTINUOUS QUERY cq ON db0 BEGIN SELECT count(value) INTO "rp1".cpu2 FROM cpu GROUP BY time(1m) END`,
+			exp:  influxql.ExecutionPrivileges{{Name: "db0", Privilege: influxql.ReadPrivilege}, {Name: "db0", Measurement: "cpu", Privilege: influxql.ReadPrivilege}, {Name: "db0", Measurement: "cpu2", Privilege: influxql.WritePrivilege}},
+		},
+		{
+			stmt: `CREATE CONTINUOUS QUERY cq ON db0 BEGIN SELECT count(value) INTO db1."rp1".cpu2 FROM cpu GROUP BY time(1m) END`,
+			exp:  influxql.ExecutionPrivileges{{Name: "db0", Privilege: influxql.ReadPrivilege}, {Name: "db0", Measurement: "cpu", Privilege: influxql.ReadPrivilege}, {Name: "db1", Measurement: "cpu2", Privilege: influxql.WritePrivilege}},
+		},
+		{
+			stmt: `CREATE CONTINUOUS QUERY cq ON db0 BEGIN SELECT count(value) INTO db1."rp1".:MEASUREMENT FROM db2..cpu, /mem.*/ GROUP BY time(1m) END`,
+			exp:  influxql.ExecutionPrivileges{{Name: "db0", Privilege: influxql.ReadPrivilege}, {Name: "db2", Measurement: "cpu", Privilege: influxql.ReadPrivilege}, {Name: "db1", Privilege: influxql.WritePrivilege}},
+		},
+	}
+
+	for i, tt := range tests {
+		stmt, err := influxql.ParseStatement(tt.stmt)
+		if err != nil {
+			t.Fatalf("%d. %q: %s", i, tt.stmt, err)
+		}
+		if priv := stmt.RequiredPrivileges(); !reflect.DeepEqual(tt.exp, priv) {
+			t.Errorf("%d. %q: unexpected privileges:\n\nexp=%#v\n\ngot=%#v\n\n", i, tt.stmt, tt.exp, priv)
+		}
+	}
+}
+
+// Ensure privileges can be merged and duplicate resources collapsed.
+func TestExecutionPrivileges_Merge(t *testing.T) {
+	a := influxql.ExecutionPrivileges{
+		{Name: "db0", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+		{Name: "db0", Privilege: influxql.ReadPrivilege},
+	}
+	b := influxql.ExecutionPrivileges{
+		{Name: "db0", Privilege: influxql.WritePrivilege},
+		{Name: "db0", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+		{Name: "db1", Measurement: "cpu", Privilege: influxql.WritePrivilege},
+	}
+
+	exp := influxql.ExecutionPrivileges{
+		{Name: "db0", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+		{Name: "db0", Privilege: influxql.WritePrivilege},
+		{Name: "db1", Measurement: "cpu", Privilege: influxql.WritePrivilege},
+	}
+	if got := a.Merge(b); !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected privileges:\n\nexp=%#v\n\ngot=%#v\n\n", exp, got)
+	}
+
+	// Ensure the receiver isn't modified.
+	if a[1].Privilege != influxql.ReadPrivilege {
+		t.Fatalf("unexpected privilege: %s", a[1].Privilege)
+	}
+}
+
+// Ensure the privileges of a query are combined by database.
+func TestQuery_RequiredPrivileges(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT value FROM cpu; SELECT value INTO db1..cpu2 FROM db1..cpu; SHOW USERS`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := influxql.ExecutionPrivileges{
+		{Name: "", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+		{Name: "db1", Measurement: "cpu", Privilege: influxql.ReadPrivilege},
+		{Name: "db1", Measurement: "cpu2", Privilege: influxql.WritePrivilege},
+		{Name: "", Privilege: influxql.AllPrivileges},
+	}
+	ep := q.RequiredPrivileges()
+	if !reflect.DeepEqual(exp, ep) {
+		t.Fatalf("unexpected privileges:\n\nexp=%#v\n\ngot=%#v\n\n", exp, ep)
+	}
+
+	// Unqualified privileges are required on the default database.
+	if cluster, dbs := ep.DatabasePrivileges("db0"); cluster != influxql.NoPrivileges {
+		t.Fatalf("unexpected cluster privilege: %s", cluster)
+	} else if !reflect.DeepEqual(dbs, map[string]influxql.Privilege{"db0": influxql.AllPrivileges, "db1": influxql.WritePrivilege}) {
+		t.Fatalf("unexpected database privileges: %#v", dbs)
+	}
+
+	// Without a default database they're required on the cluster.
+	if cluster, dbs := ep.DatabasePrivileges(""); cluster != influxql.AllPrivileges {
+		t.Fatalf("unexpected cluster privilege: %s", cluster)
+	} else if !reflect.DeepEqual(dbs, map[string]influxql.Privilege{"db1": influxql.WritePrivilege}) {
+		t.Fatalf("unexpected database privileges: %#v", dbs)
+	}
+}
+
+// Ensure privileges can be checked against a set of grants.
+func TestExecutionPrivileges_Unsatisfied(t *testing.T) {
+	g := Grants{
+		"db0":     influxql.ReadPrivilege,
+		"db1.cpu": influxql.WritePrivilege,
+	}
+
+	var tests = []struct {
+		privs influxql.ExecutionPrivileges
+		db    string
+		exp   influxql.ExecutionPrivileges
+	}{
+		// Database privileges cover every measurement.
+		{
+			privs: influxql.ExecutionPrivileges{{Name: "db0", Privilege: influxql.ReadPrivilege}, {Name: "db0", Measurement: "mem", Privilege: influxql.ReadPrivilege}},
+		},
+
+		// Blank database names use the default database.
+		{
+			privs: influxql.ExecutionPrivileges{{Name: "", Measurement: "cpu", Privilege: influxql.WritePrivilege}},
+			db:    "db1",
+		},
+		{
+			privs: influxql.ExecutionPrivileges{{Name: "", Measurement: "cpu", Privilege: influxql.WritePrivilege}},
+			db:    "db0",
+			exp:   influxql.ExecutionPrivileges{{Name: "db0", Measurement: "cpu", Privilege: influxql.WritePrivilege}},
+		},
+
+		// Measurement privileges don't cover the whole database.
+		{
+			privs: influxql.ExecutionPrivileges{{Name: "db1", Privilege: influxql.ReadPrivilege}, {Name: "db1", Measurement: "mem", Privilege: influxql.ReadPrivilege}},
+			exp:   influxql.ExecutionPrivileges{{Name: "db1", Privilege: influxql.ReadPrivilege}, {Name: "db1", Measurement: "mem", Privilege: influxql.ReadPrivilege}},
+		},
+
+		// Cluster privileges aren't granted.
+		{
+			privs: influxql.ExecutionPrivileges{{Name: "", Privilege: influxql.AllPrivileges}},
+			exp:   influxql.ExecutionPrivileges{{Name: "", Privilege: influxql.AllPrivileges}},
+		},
+	}
+
+	for i, tt := range tests {
+		if got := tt.privs.Unsatisfied(g, tt.db); !reflect.DeepEqual(tt.exp, got) {
+			t.Errorf("%d. unexpected privileges:\n\nexp=%#v\n\ngot=%#v\n\n", i, tt.exp, got)
+		} else if ok := tt.privs.SatisfiedBy(g, tt.db); ok != (len(tt.exp) == 0) {
+			t.Errorf("%d. unexpected satisfied: %v", i, ok)
+		}
+	}
+}
+
+// Grants is a test implementation of influxql.Grants. Keys are either a
+// database name or a database and measurement name joined by a dot.
+type Grants map[string]influxql.Privilege
+
+func (g Grants) AuthorizeMeasurement(privilege influxql.Privilege, database, measurement string) bool {
+	if p, ok := g[database]; ok && database != "" && p >= privilege {
+		return true
+	} else if measurement == "" {
+		return false
+	}
+	p, ok := g[database+"."+measurement]
+	return ok && p >= privilege
+}
+
+// Ensure a continuous query without a target doesn't panic when computing its privileges.
+func TestCreateContinuousQueryStatement_RequiredPrivileges_NoTarget(t *testing.T) {
+	stmt := &influxql.CreateContinuousQueryStatement{
+		Name:     "cq",
+		Database: "db0",
+		Source:   MustParseSelectStatement(`SELECT value FROM cpu`),
+	}
+
+	exp := influxql.ExecutionPrivileges{{Name: "db0", Privilege: influxql.ReadPrivilege}, {Name: "db0", Measurement: "cpu", Privilege: influxql.ReadPrivilege}}
+	if priv := stmt.RequiredPrivileges(); !reflect.DeepEqual(exp, priv) {
+		t.Fatalf("unexpected privileges:\n\nexp=%#v\n\ngot=%#v\n\n", exp, priv)
+	}
+
+	// A statement without a source only requires read access to its database.
+	stmt.Source = nil
+	if priv := stmt.RequiredPrivileges(); !reflect.DeepEqual(exp[:1], priv) {
+		t.Fatalf("unexpected privileges: %#v", priv)
+	}
+}
+
+// Ensure statements are classified and their affected databases extracted.
+func TestStatementType(t *testing.T) {
+	var tests = []struct {
+		stmt string
+		kind influxql.StatementKind
+		dbs  []string
+	}{
+		{stmt: `SELECT value FROM cpu, db1..mem`, kind: influxql.ReadStatement, dbs: []string{"db0", "db1"}},
+		{stmt: `SELECT value INTO db1..cpu2 FROM cpu`, kind: influxql.WriteStatement, dbs: []string{"db0", "db1"}},
+		{stmt: `SHOW TAG KEYS FROM db1..cpu`, kind: influxql.ReadStatement, dbs: []string{"db1"}},
+		{stmt: `SHOW MEASUREMENTS`, kind: influxql.ReadStatement, dbs: []string{"db0"}},
+		{stmt: `EXPLAIN SELECT value FROM db1..cpu`, kind: influxql.ReadStatement, dbs: []string{"db1"}},
+		{stmt: `DROP SERIES FROM cpu`, kind: influxql.WriteStatement, dbs: []string{"db0"}},
+		{stmt: `DROP MEASUREMENT cpu`, kind: influxql.WriteStatement, dbs: []string{"db0"}},
+		{stmt: `CREATE DATABASE db2`, kind: influxql.MetaStatement, dbs: []string{"db2"}},
+		{stmt: `DROP RETENTION POLICY rp0 ON db2`, kind: influxql.MetaStatement, dbs: []string{"db2"}},
+		{stmt: `CREATE CONTINUOUS QUERY cq ON db2 BEGIN SELECT count(value) INTO db3..cpu2 FROM cpu GROUP BY time(1m) END`, kind: influxql.MetaStatement, dbs: []string{"db2", "db3"}},
+		{stmt: `SHOW CONTINUOUS QUERIES`, kind: influxql.MetaStatement},
+		{stmt: `GRANT READ ON db2 TO jdoe`, kind: influxql.AdminStatement, dbs: []string{"db2"}},
+		{stmt: `CREATE USER jdoe WITH PASSWORD 'pass'`, kind: influxql.AdminStatement},
+		{stmt: `SHOW SERVERS`, kind: influxql.AdminStatement},
+		{stmt: `KILL QUERY 1`, kind: influxql.AdminStatement},
+	}
+
+	for i, tt := range tests {
+		stmt := influxql.MustParseStatement(tt.stmt)
+		if kind := influxql.StatementType(stmt); kind != tt.kind {
+			t.Errorf("%d. %s: unexpected kind: exp=%s got=%s", i, tt.stmt, tt.kind, kind)
+		}
+		if dbs := influxql.AffectedDatabases(stmt, "db0"); !reflect.DeepEqual(tt.dbs, dbs) {
+			t.Errorf("%d. %s: unexpected databases: exp=%v got=%v", i, tt.stmt, tt.dbs, dbs)
+		}
+	}
+}
+
+// Ensure the SET PASSWORD statement can be converted to a string and parsed back.
+func TestSetPasswordUserStatement_String(t *testing.T) {
+	stmt := &influxql.SetPasswordUserStatement{Name: "bob smith", Password: "it's secret"}
+	if s := stmt.String(); s != `SET PASSWORD FOR "bob smith" = 'it\'s secret'` {
+		t.Fatalf("unexpected string: %s", s)
+	}
+
+	other, err := influxql.ParseStatement(stmt.String())
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(stmt, other) {
+		t.Fatalf("unexpected statement:\n\nexp=%#v\n\ngot=%#v\n\n", stmt, other)
+	}
+
+	// Only admins can change passwords.
+	if priv := stmt.RequiredPrivileges(); !reflect.DeepEqual(priv, influxql.ExecutionPrivileges{{Name: "", Privilege: influxql.AllPrivileges}}) {
+		t.Fatalf("unexpected privileges: %#v", priv)
+	}
+}
+
+// Ensure the SHOW GRANTS statement can be converted to a string and parsed back.
+func TestShowGrantsForUserStatement_String(t *testing.T) {
+	stmt := &influxql.ShowGrantsForUserStatement{Name: "bob smith"}
+	if s := stmt.String(); s != `SHOW GRANTS FOR "bob smith"` {
+		t.Fatalf("unexpected string: %s", s)
+	}
+
+	other, err := influxql.ParseStatement(stmt.String())
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(stmt, other) {
+		t.Fatalf("unexpected statement:\n\nexp=%#v\n\ngot=%#v\n\n", stmt, other)
+	}
+}
+
 // Ensure the SELECT statement can extract substatements.
 func TestSelectStatement_Substatement(t *testing.T) {
 	var tests = []struct {
@@ -37,7 +494,7 @@ func TestSelectStatement_Substatement(t *testing.T) {
 		{
 			stmt: `SELECT value FROM myseries WHERE value > 1`,
 			expr: &influxql.VarRef{Val: "value"},
-			sub:  `SELECT value FROM myseries WHERE value > 1.000`,
+			sub:  `SELECT value FROM myseries WHERE value > 1`,
 		},
 
 		// 1. Simple join
@@ -65,14 +522,35 @@ func TestSelectStatement_Substatement(t *testing.T) {
 		{
 			stmt: `SELECT sum(aa.value) + sum(bb.value) FROM aa, bb WHERE aa.host = 'servera' AND (bb.host = 'serverb' OR bb.host = 'serverc') AND 1 = 2`,
 			expr: &influxql.VarRef{Val: "bb.value"},
-			sub:  `SELECT bb.value FROM bb WHERE (bb.host = 'serverb' OR bb.host = 'serverc') AND 1.000 = 2.000`,
+			sub:  `SELECT bb.value FROM bb WHERE (bb.host = 'serverb' OR bb.host = 'serverc') AND 1 = 2`,
 		},
 
 		// 5. 4 with different condition order
 		{
 			stmt: `SELECT sum(aa.value) + sum(bb.value) FROM aa, bb WHERE ((bb.host = 'serverb' OR bb.host = 'serverc') AND aa.host = 'servera') AND 1 = 2`,
 			expr: &influxql.VarRef{Val: "bb.value"},
-			sub:  `SELECT bb.value FROM bb WHERE ((bb.host = 'serverb' OR bb.host = 'serverc')) AND 1.000 = 2.000`,
+			sub:  `SELECT bb.value FROM bb WHERE ((bb.host = 'serverb' OR bb.host = 'serverc')) AND 1 = 2`,
+		},
+
+		// 6. Merge keeps the database and retention policy of the source
+		{
+			stmt: `SELECT sum(aa.value) + sum(bb.value) FROM db0."1h".aa, db1."1d".bb`,
+			expr: &influxql.VarRef{Val: "bb.value"},
+			sub:  `SELECT bb.value FROM "db1"."1d".bb`,
+		},
+
+		// 7. Join with a time condition and a source that prefixes another
+		{
+			stmt: `SELECT sum(aa.value) + sum(aab.value) FROM aa, aab WHERE time > now() - 1h AND aab.host = 'serverb' AND aa.host = 'servera'`,
+			expr: &influxql.VarRef{Val: "aa.value"},
+			sub:  `SELECT aa.value FROM aa WHERE time > now() - 1h AND aa.host = 'servera'`,
+		},
+
+		// 8. Group by interval, fill, limits and offsets are kept
+		{
+			stmt: `SELECT sum(aa.value) + sum(bb.value) INTO cc FROM aa, bb WHERE time > now() - 1h GROUP BY time(10m), host fill(0) LIMIT 10 OFFSET 5 SLIMIT 2 SOFFSET 1`,
+			expr: &influxql.VarRef{Val: "bb.value"},
+			sub:  `SELECT bb.value INTO cc FROM bb WHERE time > now() - 1h GROUP BY time(10m), host fill(0) LIMIT 10 OFFSET 5 SLIMIT 2 SOFFSET 1`,
 		},
 	}
 
@@ -96,6 +574,63 @@ func TestSelectStatement_Substatement(t *testing.T) {
 	}
 }
 
+// Ensure a statement can be reduced without modifying the original.
+func TestSelectStatement_Reduce(t *testing.T) {
+	now := mustParseTime("2000-01-01T00:00:00Z")
+	stmt := MustParseSelectStatement(`SELECT mean(value) * (2 + 3) FROM cpu WHERE time > now() - 1h AND host = 'serverA' GROUP BY time(10m), host`)
+
+	other := stmt.Reduce(&influxql.NowValuer{Now: now})
+	if s := other.String(); s != `SELECT mean(value) * 5 FROM cpu WHERE time > '1999-12-31 23:00:00' AND host = 'serverA' GROUP BY time(10m), host` {
+		t.Fatalf("unexpected statement: %s", s)
+	} else if s := stmt.String(); s != `SELECT mean(value) * (2 + 3) FROM cpu WHERE time > now() - 1h AND host = 'serverA' GROUP BY time(10m), host` {
+		t.Fatalf("original statement modified: %s", s)
+	} else if d, err := other.GroupByInterval(); err != nil || d != 10*time.Minute {
+		t.Fatalf("unexpected interval: %s (%v)", d, err)
+	}
+}
+
+// Ensure field names only match sources on a dot boundary.
+func TestMatchSource(t *testing.T) {
+	sources := MustParseSelectStatement(`SELECT value FROM cpu, cpu_user, "cpu.load"`).Sources
+	var tests = []struct {
+		name   string
+		source string
+	}{
+		{name: `cpu.value`, source: `cpu`},
+		{name: `cpu_user.value`, source: `cpu_user`},
+		{name: `cpu.load.value`, source: `cpu`},
+		{name: `cpu_system.value`, source: ``},
+		{name: `cpu`, source: ``},
+		{name: `value`, source: ``},
+	}
+
+	for i, tt := range tests {
+		if source := influxql.MatchSource(sources, tt.name); source != tt.source {
+			t.Errorf("%d. %s: unexpected source: exp=%q, got=%q", i, tt.name, tt.source, source)
+		}
+	}
+}
+
+// Ensure field names can be split into source and field names.
+func TestSplitSourceRef(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+		field  string
+	}{
+		{name: `cpu.value`, source: `cpu`, field: `value`},
+		{name: `cpu.load.value`, source: `cpu`, field: `load.value`},
+		{name: `value`, source: ``, field: `value`},
+		{name: `.value`, source: ``, field: `.value`},
+	}
+
+	for i, tt := range tests {
+		if source, field := influxql.SplitSourceRef(tt.name); source != tt.source || field != tt.field {
+			t.Errorf("%d. %s: unexpected split: exp=%q, %q, got=%q, %q", i, tt.name, tt.source, tt.field, source, field)
+		}
+	}
+}
+
 // Ensure the SELECT statement can extract GROUP BY interval.
 func TestSelectStatement_GroupByInterval(t *testing.T) {
 	q := "SELECT sum(value) from foo  where time < now() GROUP BY time(10m)"
@@ -114,6 +649,48 @@ func TestSelectStatement_GroupByInterval(t *testing.T) {
 	}
 }
 
+// Ensure the group by interval is validated with the rest of the dimensions.
+func TestSelectStatement_GroupByInterval_Dimensions(t *testing.T) {
+	for i, tt := range []struct {
+		dimensions influxql.Dimensions
+		d          time.Duration
+		err        string
+	}{
+		{},
+		{dimensions: influxql.Dimensions{{Expr: &influxql.VarRef{Val: "host"}}}},
+		{
+			dimensions: influxql.Dimensions{
+				{Expr: &influxql.VarRef{Val: "host"}},
+				{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Hour}}}},
+				{Expr: &influxql.Wildcard{}},
+			},
+			d: time.Hour,
+		},
+		{
+			dimensions: influxql.Dimensions{
+				{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Hour}}}},
+				{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: time.Minute}}}},
+			},
+			err: `multiple time dimensions not allowed`,
+		},
+		{
+			dimensions: influxql.Dimensions{{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.VarRef{Val: "host"}}}}},
+			err:        `time dimension must have one duration argument`,
+		},
+	} {
+		s := &influxql.SelectStatement{Dimensions: tt.dimensions}
+
+		// Check the result twice to ensure errors are memoized too.
+		for j := 0; j < 2; j++ {
+			if d, err := s.GroupByInterval(); errstring(err) != tt.err {
+				t.Errorf("%d. unexpected error: exp=%s, got=%s", i, tt.err, err)
+			} else if d != tt.d {
+				t.Errorf("%d. unexpected interval: exp=%s, got=%s", i, tt.d, d)
+			}
+		}
+	}
+}
+
 // Ensure the SELECT statment can have its start and end time set
 func TestSelectStatement_SetTimeRange(t *testing.T) {
 	q := "SELECT sum(value) from foo where time < now() GROUP BY time(10m)"
@@ -159,9 +736,6 @@ func TestSelectStatement_SetTimeRange(t *testing.T) {
 	s.SetTimeRange(start, end)
 	min, max = influxql.TimeRange(s.Condition)
 
-	// TODO: right now the SetTimeRange can't override the start time if it's more recent than what they're trying to set it to.
-	//       shouldn't matter for our purposes with continuous queries, but fix this later
-
 	if min != start {
 		t.Fatalf("start time wasn't set properly.\n  exp: %s\n  got: %s", start, min)
 	}
@@ -215,24 +789,165 @@ func TestSelectStatement_SetTimeRange(t *testing.T) {
 	}
 }
 
+// Ensure that setting a time range keeps the rest of the condition.
+func TestSelectStatement_SetTimeRange_Condition(t *testing.T) {
+	start, end := mustParseTime("2000-01-01T00:00:00Z"), mustParseTime("2000-01-02T00:00:00Z")
+	for i, tt := range []struct {
+		s    string
+		cond string
+		err  string
+	}{
+		{s: `SELECT sum(value) FROM cpu`, cond: `time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`},
+		{s: `SELECT sum(value) FROM cpu WHERE time > now() - 1h`, cond: `time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`},
+		{s: `SELECT sum(value) FROM cpu WHERE '2010-01-01' > TIME AND host = 'a'`, cond: `host = 'a' AND time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`},
+		{s: `SELECT sum(value) FROM cpu WHERE host = 'a' OR host = 'b'`, cond: `(host = 'a' OR host = 'b') AND time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`},
+		{s: `SELECT sum(value) FROM cpu WHERE abs(value) > 1 AND time < now()`, cond: `abs(value) > 1 AND time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`},
+		{s: `SELECT sum(value) FROM cpu WHERE host = 'a'`, err: `invalid time range: start time 2000-01-02 00:00:00 is not before end time 2000-01-01 00:00:00`},
+	} {
+		stmt := MustParseSelectStatement(tt.s)
+
+		var err error
+		if tt.err != "" {
+			err = stmt.SetTimeRange(end, start)
+		} else {
+			err = stmt.SetTimeRange(start, end)
+		}
+
+		if errstring(err) != tt.err {
+			t.Errorf("%d. %s: unexpected error: exp=%s, got=%s", i, tt.s, tt.err, err)
+		} else if err == nil && stmt.Condition.String() != tt.cond {
+			t.Errorf("%d. %s: unexpected condition:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, tt.cond, stmt.Condition.String())
+		}
+	}
+}
+
+// Ensure statements built in code are validated.
+func TestSelectStatement_Validate(t *testing.T) {
+	stmt := MustParseSelectStatement(`SELECT mean(value) FROM cpu GROUP BY host`)
+	if err := stmt.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	stmt.Fields = append(stmt.Fields, &influxql.Field{Expr: &influxql.VarRef{Val: "region"}})
+	if err := stmt.Validate(); errstring(err) != `mixing aggregate and non-aggregate fields is not supported: region at line 1, char 1` {
+		t.Fatalf("unexpected error: %s", errstring(err))
+	} else if _, ok := err.(*influxql.ParseError); !ok {
+		t.Fatalf("unexpected error type: %T", err)
+	}
+}
+
 // Ensure the idents from the select clause can come out
 func TestSelect_NamesInSelect(t *testing.T) {
-	s := MustParseSelectStatement("select count(asdf), bar from cpu")
+	s := MustParseSelectStatement("select count(asdf), bar from cpu group by bar")
 	a := s.NamesInSelect()
 	if !reflect.DeepEqual(a, []string{"asdf", "bar"}) {
 		t.Fatal("expected names asdf and bar")
 	}
 }
 
+// Ensure the idents from the select clause are distinct and include nested calls
+func TestSelect_NamesInSelect_Distinct(t *testing.T) {
+	s := MustParseSelectStatement("select count(distinct(host)), max(value) * 2 + min(other), last(value) from cpu")
+	a := s.NamesInSelect()
+	if !reflect.DeepEqual(a, []string{"host", "value", "other"}) {
+		t.Fatalf("exp: host,value,other\ngot: %s\n", strings.Join(a, ","))
+	}
+}
+
+// Ensure names are generated for unaliased fields.
+func TestField_Name(t *testing.T) {
+	var tests = []struct {
+		s    string
+		name string
+	}{
+		{s: `value`, name: "value"},
+		{s: `value AS v`, name: "v"},
+		{s: `mean(value)`, name: "mean_value"},
+		{s: `count(distinct(value))`, name: "count_distinct_value"},
+		{s: `count(DISTINCT value)`, name: "count_distinct_value"},
+		{s: `DISTINCT value`, name: "distinct_value"},
+		{s: `percentile(value, 90)`, name: "percentile_value"},
+		{s: `max(value) - min(value)`, name: "max_value_min_value"},
+		{s: `(value * 2) + 1`, name: "value"},
+		{s: `1 + 2`, name: ""},
+	}
+
+	for i, tt := range tests {
+		stmt := MustParseSelectStatement(`SELECT ` + tt.s + ` FROM cpu`)
+		if name := stmt.Fields[0].Name(); name != tt.name {
+			t.Errorf("%d. %s: unexpected name: exp=%q, got=%q", i, tt.s, tt.name, name)
+		}
+	}
+}
+
+// Ensure the column names of a statement are generated and distinct.
+func TestSelectStatement_ColumnNames(t *testing.T) {
+	var tests = []struct {
+		s       string
+		columns []string
+	}{
+		{s: `SELECT value FROM cpu`, columns: []string{"time", "value"}},
+		{s: `SELECT time, value, host FROM cpu`, columns: []string{"time", "value", "host"}},
+		{s: `SELECT mean(value), max(value) AS peak FROM cpu`, columns: []string{"time", "mean_value", "peak"}},
+		{s: `SELECT max(value) - min(value), -sum(value) FROM cpu`, columns: []string{"time", "max_value_min_value", "sum_value"}},
+		{s: `SELECT derivative(mean(value), 1m) FROM cpu WHERE time > now() - 1h GROUP BY time(1m)`, columns: []string{"time", "derivative_mean_value"}},
+		{s: `SELECT count(value), count(value), count(value) AS count_value_1 FROM cpu`, columns: []string{"time", "count_value", "count_value_1", "count_value_1_1"}},
+		{s: `SELECT mean(value) AS time FROM cpu`, columns: []string{"time", "time_1"}},
+	}
+
+	for i, tt := range tests {
+		if columns := MustParseSelectStatement(tt.s).ColumnNames(); !reflect.DeepEqual(columns, tt.columns) {
+			t.Errorf("%d. %s: unexpected columns: exp=%v, got=%v", i, tt.s, tt.columns, columns)
+		}
+	}
+}
+
 // Ensure the idents from the where clause can come out
 func TestSelect_NamesInWhere(t *testing.T) {
-	s := MustParseSelectStatement("select * from cpu where time > 23s AND (asdf = 'jkl' OR (foo = 'bar' AND baz = 'bar'))")
+	s := MustParseSelectStatement("select * from cpu where time > 23s AND (asdf = 'jkl' OR (foo = 'bar' AND baz = 'bar' AND asdf != 'x'))")
 	a := s.NamesInWhere()
 	if !reflect.DeepEqual(a, []string{"time", "asdf", "foo", "baz"}) {
 		t.Fatalf("exp: time,asdf,foo,baz\ngot: %s\n", strings.Join(a, ","))
 	}
 }
 
+// Ensure the idents from the group by clause can come out
+func TestSelect_NamesInDimension(t *testing.T) {
+	s := MustParseSelectStatement("select count(value) from cpu where time > now() - 1h group by host, time(1m), region, host")
+	a := s.NamesInDimension()
+	if !reflect.DeepEqual(a, []string{"host", "region"}) {
+		t.Fatalf("exp: host,region\ngot: %s\n", strings.Join(a, ","))
+	}
+}
+
+// Ensure the function calls from the select clause can come out, including nested calls.
+func TestSelectStatement_FunctionCalls(t *testing.T) {
+	var tests = []struct {
+		s     string
+		calls []string
+		names []string
+	}{
+		{s: `SELECT value FROM cpu`},
+		{s: `SELECT count(value), max(value) FROM cpu`, calls: []string{`count(value)`, `max(value)`}, names: []string{"count", "max"}},
+		{s: `SELECT derivative(mean(value), 1m) FROM cpu WHERE time > now() - 1h GROUP BY time(1m)`, calls: []string{`derivative(mean(value), 1m)`, `mean(value)`}, names: []string{"derivative", "mean"}},
+		{s: `SELECT count(distinct(host)), -mean(value) * 2 FROM cpu`, calls: []string{`count(distinct(host))`, `distinct(host)`, `mean(value)`}, names: []string{"count", "distinct", "mean"}},
+	}
+
+	for i, tt := range tests {
+		stmt := MustParseSelectStatement(tt.s)
+
+		var calls []string
+		for _, c := range stmt.FunctionCalls() {
+			calls = append(calls, c.String())
+		}
+		if !reflect.DeepEqual(calls, tt.calls) {
+			t.Errorf("%d. %s: unexpected calls: exp=%v, got=%v", i, tt.s, tt.calls, calls)
+		} else if names := stmt.FunctionNames(); !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("%d. %s: unexpected names: exp=%v, got=%v", i, tt.s, tt.names, names)
+		}
+	}
+}
+
 func TestSelectStatement_HasWildcard(t *testing.T) {
 	var tests = []struct {
 		stmt     string
@@ -274,6 +989,12 @@ func TestSelectStatement_HasWildcard(t *testing.T) {
 			wildcard: true,
 		},
 
+		// GROUP BY regex
+		{
+			stmt:     `SELECT value FROM cpu GROUP BY /dc_.*/`,
+			wildcard: true,
+		},
+
 		// GROUP BY wildcard with explicit
 		{
 			stmt:     `SELECT value FROM cpu GROUP BY *,host`,
@@ -311,14 +1032,8 @@ func TestSelectStatement_HasWildcard(t *testing.T) {
 
 // Test SELECT statement wildcard rewrite.
 func TestSelectStatement_RewriteWildcards(t *testing.T) {
-	var fields = influxql.Fields{
-		&influxql.Field{Expr: &influxql.VarRef{Val: "value1"}},
-		&influxql.Field{Expr: &influxql.VarRef{Val: "value2"}},
-	}
-	var dimensions = influxql.Dimensions{
-		&influxql.Dimension{Expr: &influxql.VarRef{Val: "host"}},
-		&influxql.Dimension{Expr: &influxql.VarRef{Val: "region"}},
-	}
+	var fieldKeys = []string{"value1", "value2"}
+	var tagKeys = []string{"region", "host"}
 
 	var tests = []struct {
 		stmt    string
@@ -380,6 +1095,18 @@ func TestSelectStatement_RewriteWildcards(t *testing.T) {
 			rewrite: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY host, region, time(1m) fill(0)`,
 		},
 
+		// GROUP BY regex
+		{
+			stmt:    `SELECT value FROM cpu GROUP BY /reg/`,
+			rewrite: `SELECT value FROM cpu GROUP BY region`,
+		},
+
+		// GROUP BY regex with time
+		{
+			stmt:    `SELECT mean(value) FROM cpu where time < now() GROUP BY /o/,time(1m)`,
+			rewrite: `SELECT mean(value) FROM cpu WHERE time < now() GROUP BY host, region, time(1m)`,
+		},
+
 		// GROUP BY wildcard with explicit
 		{
 			stmt:    `SELECT value FROM cpu GROUP BY *,host`,
@@ -408,7 +1135,7 @@ func TestSelectStatement_RewriteWildcards(t *testing.T) {
 		}
 
 		// Rewrite statement.
-		rw := stmt.(*influxql.SelectStatement).RewriteWildcards(fields, dimensions)
+		rw := stmt.(*influxql.SelectStatement).RewriteWildcards(fieldKeys, tagKeys)
 		if rw == nil {
 			t.Errorf("%d. %q: unexpected nil statement", i, tt.stmt)
 			continue
@@ -420,6 +1147,33 @@ func TestSelectStatement_RewriteWildcards(t *testing.T) {
 	}
 }
 
+// Ensure that rewriting wildcards does not reorder the supplied keys.
+func TestSelectStatement_RewriteWildcards_FieldOrder(t *testing.T) {
+	fieldKeys, tagKeys := []string{"value2", "value1"}, []string{"region", "host"}
+	stmt := MustParseSelectStatement(`SELECT * FROM cpu GROUP BY *`)
+
+	if rw := stmt.RewriteWildcards(fieldKeys, tagKeys).String(); rw != `SELECT value1, value2 FROM cpu GROUP BY host, region` {
+		t.Fatalf("unexpected rewrite: %s", rw)
+	} else if !reflect.DeepEqual(fieldKeys, []string{"value2", "value1"}) {
+		t.Fatalf("unexpected field keys: %v", fieldKeys)
+	} else if !reflect.DeepEqual(tagKeys, []string{"region", "host"}) {
+		t.Fatalf("unexpected tag keys: %v", tagKeys)
+	}
+}
+
+// Ensure that rewritten statements have no wildcards left.
+func TestSelectStatement_RewriteWildcards_HasWildcard(t *testing.T) {
+	for i, s := range []string{
+		`SELECT * FROM cpu`,
+		`SELECT * FROM cpu GROUP BY *`,
+		`SELECT mean(value) FROM cpu WHERE time < now() GROUP BY /o/, *, time(1m)`,
+	} {
+		if rw := MustParseSelectStatement(s).RewriteWildcards([]string{"value"}, []string{"host"}); rw.HasWildcard() {
+			t.Errorf("%d. %s: unexpected wildcard: %s", i, s, rw)
+		}
+	}
+}
+
 // Ensure that the IsRawQuery flag gets set properly
 func TestSelectStatement_IsRawQuerySet(t *testing.T) {
 	var tests = []struct {
@@ -454,6 +1208,14 @@ func TestSelectStatement_IsRawQuerySet(t *testing.T) {
 			stmt:  "select mean(*) from foo group by *",
 			isRaw: false,
 		},
+		{
+			stmt:  "select host, mean(value) from foo group by host",
+			isRaw: false,
+		},
+		{
+			stmt:  "select mean(value), host from foo group by *",
+			isRaw: false,
+		},
 	}
 
 	for i, tt := range tests {
@@ -489,11 +1251,23 @@ func TestTimeRange(t *testing.T) {
 		// Multiple time expressions.
 		{expr: `time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`, min: `2000-01-01 00:00:00`, max: `2000-01-01 23:59:59.999999`},
 
+		// Inclusive range.
+		{expr: `time BETWEEN '2000-01-01 00:00:00' AND '2000-01-02 00:00:00'`, min: `2000-01-01 00:00:00`, max: `2000-01-02 00:00:00`},
+		{expr: `host = 'a' AND time BETWEEN '2000-01-01' AND '2000-01-02' AND value > 1`, min: `2000-01-01 00:00:00`, max: `2000-01-02 00:00:00`},
+
+		// Alternative time ranges.
+		{expr: `time < '2000-01-01 00:00:00' OR time > '2000-01-02 00:00:00'`, min: `0001-01-01 00:00:00`, max: `0001-01-01 00:00:00`},
+		{expr: `time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00' OR time >= '2000-01-03 00:00:00' AND time < '2000-01-04 00:00:00'`, min: `2000-01-01 00:00:00`, max: `2000-01-03 23:59:59.999999`},
+		{expr: `host = 'a' OR time >= '2000-01-01 00:00:00'`, min: `0001-01-01 00:00:00`, max: `0001-01-01 00:00:00`},
+		{expr: `(host = 'a' OR host = 'b') AND time >= '2000-01-01 00:00:00'`, min: `2000-01-01 00:00:00`, max: `0001-01-01 00:00:00`},
+		{expr: `time >= '2000-01-02 00:00:00' AND (time < '2000-01-03 00:00:00' OR time = '2000-01-05 00:00:00')`, min: `2000-01-02 00:00:00`, max: `2000-01-05 00:00:00`},
+
 		// Min/max crossover
 		{expr: `time >= '2000-01-01 00:00:00' AND time <= '1999-01-01 00:00:00'`, min: `2000-01-01 00:00:00`, max: `1999-01-01 00:00:00`},
 
 		// Absolute time
 		{expr: `time = 1388534400s`, min: `2014-01-01 00:00:00`, max: `2014-01-01 00:00:00`},
+		{expr: `time >= '2014-01-01T02:00:00.000000001+02:00'`, min: `2014-01-01 00:00:00.000000001`, max: `0001-01-01 00:00:00`},
 
 		// Non-comparative expressions.
 		{expr: `time`, min: `0001-01-01 00:00:00`, max: `0001-01-01 00:00:00`},
@@ -517,6 +1291,202 @@ func TestTimeRange(t *testing.T) {
 	}
 }
 
+// Ensure a condition can be split into its time and other conditions.
+func TestConditionExpr(t *testing.T) {
+	for i, tt := range []struct {
+		cond  string
+		time  string
+		other string
+		err   string
+	}{
+		{cond: ``},
+		{cond: `host = 'a'`, other: `host = 'a'`},
+		{cond: `time > now() - 1h`, time: `time > now() - 1h`},
+		{cond: `'2000-01-01' <= TIME`, time: `'2000-01-01 00:00:00' <= TIME`},
+		{cond: `host = 'a' AND time > now() - 1h AND value > 1`, time: `time > now() - 1h`, other: `host = 'a' AND value > 1`},
+		{cond: `(host = 'a' OR host = 'b') AND time >= '2000-01-01' AND time < '2000-01-02'`, time: `time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`, other: `host = 'a' OR host = 'b'`},
+		{cond: `host = 'a' AND (region = 'b' AND time > '2000-01-01')`, time: `time > '2000-01-01 00:00:00'`, other: `host = 'a' AND region = 'b'`},
+		{cond: `host = 'a' AND (region = 'b' OR region = 'c') AND time BETWEEN '2000-01-01' AND '2000-01-02'`, time: `time >= '2000-01-01 00:00:00' AND time <= '2000-01-02 00:00:00'`, other: `host = 'a' AND (region = 'b' OR region = 'c')`},
+		{cond: `host = 'a' AND (time < '2000-01-01' OR time > '2000-01-02')`, time: `time < '2000-01-01 00:00:00' OR time > '2000-01-02 00:00:00'`, other: `host = 'a'`},
+		{cond: `(time < '2000-01-01' OR time > '2000-01-02') AND host = 'a'`, time: `time < '2000-01-01 00:00:00' OR time > '2000-01-02 00:00:00'`, other: `host = 'a'`},
+		{cond: `host = 'a' OR time > now() - 1h`, err: `cannot use OR between time and other conditions: host = 'a' OR time > now() - 1h`},
+		{cond: `time > value`, err: `invalid time comparison: time > value`},
+		{cond: `NOT time > now()`, err: `invalid time condition: NOT time > now()`},
+		{cond: `time + 1h > now()`, err: `invalid time condition: time + 1h > now()`},
+	} {
+		var cond influxql.Expr
+		if tt.cond != "" {
+			cond = MustParseExpr(tt.cond)
+		}
+
+		timeExpr, otherExpr, err := influxql.ConditionExpr(cond)
+		if errstring(err) != tt.err {
+			t.Errorf("%d. %s: unexpected error: exp=%s, got=%s", i, tt.cond, tt.err, err)
+			continue
+		}
+
+		var timeStr, otherStr string
+		if timeExpr != nil {
+			timeStr = timeExpr.String()
+		}
+		if otherExpr != nil {
+			otherStr = otherExpr.String()
+		}
+
+		if timeStr != tt.time {
+			t.Errorf("%d. %s: unexpected time condition: exp=%s, got=%s", i, tt.cond, tt.time, timeStr)
+		} else if otherStr != tt.other {
+			t.Errorf("%d. %s: unexpected other condition: exp=%s, got=%s", i, tt.cond, tt.other, otherStr)
+		}
+	}
+}
+
+// Ensure the tag values implied by a condition can be extracted.
+func TestExprTagSets(t *testing.T) {
+	for i, tt := range []struct {
+		expr string
+		sets []map[string]string
+	}{
+		{expr: `value > 1`, sets: []map[string]string{{}}},
+		{expr: `host = 'a'`, sets: []map[string]string{{"host": "a"}}},
+		{expr: `'a' = host AND value > 1`, sets: []map[string]string{{"host": "a"}}},
+		{expr: `host = 'a' AND region = 'b'`, sets: []map[string]string{{"host": "a", "region": "b"}}},
+		{expr: `host = 'a' AND host = 'b'`, sets: nil},
+		{expr: `host = 'a' OR host = 'b'`, sets: []map[string]string{{"host": "a"}, {"host": "b"}}},
+		{expr: `host = 'a' OR host = 'a'`, sets: []map[string]string{{"host": "a"}}},
+		{expr: `host = 'a' OR value > 1`, sets: []map[string]string{{}}},
+		{expr: `(host = 'a' OR host = 'b') AND region = 'c'`, sets: []map[string]string{{"host": "a", "region": "c"}, {"host": "b", "region": "c"}}},
+		{expr: `(host = 'a' OR host = 'b') AND (host = 'b' OR host = 'c')`, sets: []map[string]string{{"host": "b"}}},
+		{expr: `host = 'a' AND region = 'b' OR host = 'c'`, sets: []map[string]string{{"host": "a", "region": "b"}, {"host": "c"}}},
+		{expr: `host != 'a' AND false`, sets: nil},
+		{expr: `host = 1`, sets: []map[string]string{{}}},
+	} {
+		if sets := influxql.ExprTagSets(MustParseExpr(tt.expr)); !reflect.DeepEqual(sets, tt.sets) {
+			t.Errorf("%d. %s: unexpected tag sets:\n\nexp=%v\n\ngot=%v\n\n", i, tt.expr, tt.sets, sets)
+		}
+	}
+}
+
+// Ensure the time range of an expression can be returned with explicit bounds.
+func TestTimeRangeAsEpoch(t *testing.T) {
+	for i, tt := range []struct {
+		expr           string
+		min, max       int64
+		hasMin, hasMax bool
+		err            string
+	}{
+		{expr: `host = 'a'`},
+		{expr: `time >= 0s`, min: 0, hasMin: true},
+		{expr: `time < 10s`, max: 10*int64(time.Second) - int64(time.Microsecond), hasMax: true},
+		{expr: `time >= 1s AND time <= 2s`, min: int64(time.Second), max: 2 * int64(time.Second), hasMin: true, hasMax: true},
+		{expr: `time >= 1s AND time <= 1s`, min: int64(time.Second), max: int64(time.Second), hasMin: true, hasMax: true},
+		{expr: `time > 2s OR time < 1s`},
+		{expr: `time > 1s AND time < 1s`, err: `invalid time range: min time 1970-01-01 00:00:01.000001 is after max time 1970-01-01 00:00:00.999999`},
+		{expr: `time >= '2000-01-02 00:00:00' AND time <= '2000-01-01 00:00:00'`, err: `invalid time range: min time 2000-01-02 00:00:00 is after max time 2000-01-01 00:00:00`},
+	} {
+		min, max, hasMin, hasMax, err := influxql.TimeRangeAsEpoch(MustParseExpr(tt.expr))
+		if errstring(err) != tt.err {
+			t.Errorf("%d. %s: unexpected error: exp=%s, got=%s", i, tt.expr, tt.err, err)
+		} else if min != tt.min || hasMin != tt.hasMin {
+			t.Errorf("%d. %s: unexpected min: exp=%d (%v), got=%d (%v)", i, tt.expr, tt.min, tt.hasMin, min, hasMin)
+		} else if max != tt.max || hasMax != tt.hasMax {
+			t.Errorf("%d. %s: unexpected max: exp=%d (%v), got=%d (%v)", i, tt.expr, tt.max, tt.hasMax, max, hasMax)
+		}
+	}
+}
+
+// Ensure the disjoint time intervals of an expression can be extracted.
+func TestTimeIntervals(t *testing.T) {
+	for i, tt := range []struct {
+		expr      string
+		intervals string
+	}{
+		{expr: `host = 'a'`, intervals: `[-]`},
+		{expr: `time >= '2000-01-01 00:00:00'`, intervals: `[2000-01-01 00:00:00-]`},
+		{expr: `time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`, intervals: `[2000-01-01 00:00:00-2000-01-01 23:59:59.999999]`},
+		{expr: `time > '2000-01-02 00:00:00' AND time < '2000-01-01 00:00:00'`, intervals: ``},
+		{expr: `false OR time < '2000-01-01 00:00:00'`, intervals: `[-1999-12-31 23:59:59.999999]`},
+
+		// Disjoint ranges are kept separate and sorted.
+		{expr: `time > '2000-01-03 00:00:00' OR time < '2000-01-01 00:00:00'`, intervals: `[-1999-12-31 23:59:59.999999] [2000-01-03 00:00:00.000001-]`},
+		{expr: `time >= '2000-01-03 00:00:00' AND time < '2000-01-04 00:00:00' OR time >= '2000-01-01 00:00:00' AND time < '2000-01-02 00:00:00'`, intervals: `[2000-01-01 00:00:00-2000-01-01 23:59:59.999999] [2000-01-03 00:00:00-2000-01-03 23:59:59.999999]`},
+
+		// Overlapping and adjacent ranges are merged.
+		{expr: `time >= '2000-01-01 00:00:00' AND time < '2000-01-03 00:00:00' OR time >= '2000-01-02 00:00:00' AND time < '2000-01-04 00:00:00'`, intervals: `[2000-01-01 00:00:00-2000-01-03 23:59:59.999999]`},
+		{expr: `time < '2000-01-02 00:00:00' OR time >= '2000-01-02 00:00:00' AND time < '2000-01-03 00:00:00'`, intervals: `[-2000-01-02 23:59:59.999999]`},
+		{expr: `time < '2000-01-02 00:00:00' OR time > '2000-01-01 00:00:00'`, intervals: `[-]`},
+		{expr: `host = 'a' OR time > '2000-01-01 00:00:00'`, intervals: `[-]`},
+
+		// Intersections distribute over alternatives.
+		{expr: `time >= '2000-01-02 00:00:00' AND (time < '2000-01-03 00:00:00' OR time = '2000-01-05 00:00:00')`, intervals: `[2000-01-02 00:00:00-2000-01-02 23:59:59.999999] [2000-01-05 00:00:00-2000-01-05 00:00:00]`},
+		{expr: `(time < '2000-01-01 00:00:00' OR time > '2000-01-03 00:00:00') AND (time < '2000-01-02 00:00:00' OR time > '2000-01-04 00:00:00')`, intervals: `[-1999-12-31 23:59:59.999999] [2000-01-04 00:00:00.000001-]`},
+	} {
+		var a []string
+		for _, interval := range influxql.TimeIntervals(MustParseExpr(tt.expr)) {
+			var min, max string
+			if !interval.Min.IsZero() {
+				min = interval.Min.Format(influxql.DateTimeFormat)
+			}
+			if !interval.Max.IsZero() {
+				max = interval.Max.Format(influxql.DateTimeFormat)
+			}
+			a = append(a, fmt.Sprintf("[%s-%s]", min, max))
+		}
+
+		if s := strings.Join(a, " "); s != tt.intervals {
+			t.Errorf("%d. %s: unexpected intervals:\n\nexp=%s\n\ngot=%s\n\n", i, tt.expr, tt.intervals, s)
+		}
+	}
+}
+
+// Ensure strings can be matched against LIKE patterns.
+func TestMatchLike(t *testing.T) {
+	for i, tt := range []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{pattern: ``, s: ``, match: true},
+		{pattern: `server01`, s: `server01`, match: true},
+		{pattern: `server01`, s: `server02`, match: false},
+		{pattern: `server%`, s: `server01`, match: true},
+		{pattern: `server*`, s: `serve`, match: false},
+		{pattern: `%01`, s: `server01`, match: true},
+		{pattern: `%01`, s: `server010`, match: false},
+		{pattern: `s%r%1`, s: `server01`, match: true},
+		{pattern: `s%r%1`, s: `server10`, match: false},
+		{pattern: `ab%ba`, s: `aba`, match: false},
+		{pattern: `%`, s: ``, match: true},
+		{pattern: `100\%`, s: `100%`, match: true},
+		{pattern: `100\%`, s: `1000`, match: false},
+	} {
+		if match := influxql.MatchLike(tt.pattern, tt.s); match != tt.match {
+			t.Errorf("%d. %q LIKE %q: unexpected match: %v", i, tt.s, tt.pattern, match)
+		}
+	}
+}
+
+// Ensure prefix patterns are detected.
+func TestLikePrefix(t *testing.T) {
+	for i, tt := range []struct {
+		pattern string
+		prefix  string
+		ok      bool
+	}{
+		{pattern: `server%`, prefix: `server`, ok: true},
+		{pattern: `server%*`, prefix: `server`, ok: true},
+		{pattern: `%`, prefix: ``, ok: true},
+		{pattern: `server`, ok: false},
+		{pattern: `server%01`, ok: false},
+		{pattern: `%01`, ok: false},
+		{pattern: `100\%`, ok: false},
+	} {
+		if prefix, ok := influxql.LikePrefix(tt.pattern); prefix != tt.prefix || ok != tt.ok {
+			t.Errorf("%d. %q: unexpected prefix: %q, %v", i, tt.pattern, prefix, ok)
+		}
+	}
+}
+
 // Ensure that we see if a where clause has only time limitations
 func TestSelectStatement_OnlyTimeDimensions(t *testing.T) {
 	var tests = []struct {
@@ -572,11 +1542,205 @@ func TestRewrite(t *testing.T) {
 	})
 
 	// Verify that everything is flipped.
-	if act := act.String(); act != `2.000 = foo OR 1.000 > time` {
+	if act := act.String(); act != `2 = foo OR 1 > time` {
 		t.Fatalf("unexpected result: %s", act)
 	}
 }
 
+// Ensure that each source of a statement can be rewritten.
+func TestRewrite_Sources(t *testing.T) {
+	stmt := MustParseSelectStatement(`SELECT value FROM cpu, mem`)
+
+	// Qualify every measurement with a database.
+	influxql.RewriteFunc(stmt, func(n influxql.Node) influxql.Node {
+		if m, ok := n.(*influxql.Measurement); ok {
+			return &influxql.Measurement{Database: "db0", Name: m.Name}
+		}
+		return n
+	})
+
+	if s := stmt.String(); s != `SELECT value FROM "db0"..cpu, "db0"..mem` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+}
+
+// Ensure that the sources, targets and conditions of every statement can be rewritten.
+func TestRewrite_Statements(t *testing.T) {
+	for i, tt := range []struct {
+		stmt string
+		s    string
+	}{
+		{stmt: `SELECT value INTO cpu2 FROM cpu WHERE host = 'serverA'`, s: `SELECT value INTO "db0"..cpu2 FROM "db0"..cpu WHERE host = 'serverB'`},
+		{stmt: `DELETE FROM cpu WHERE host = 'serverA'`, s: `DELETE FROM "db0"..cpu WHERE host = 'serverB'`},
+		{stmt: `DROP SERIES FROM cpu WHERE host = 'serverA'`, s: `DROP SERIES FROM "db0"..cpu WHERE host = 'serverB'`},
+		{stmt: `SHOW SERIES FROM cpu WHERE host = 'serverA'`, s: `SHOW SERIES FROM "db0"..cpu WHERE host = 'serverB'`},
+		{stmt: `SHOW MEASUREMENTS WHERE host = 'serverA'`, s: `SHOW MEASUREMENTS WHERE host = 'serverB'`},
+		{stmt: `SHOW TAG KEYS FROM cpu WHERE host = 'serverA'`, s: `SHOW TAG KEYS FROM "db0"..cpu WHERE host = 'serverB'`},
+		{stmt: `SHOW TAG VALUES FROM cpu WITH KEY = region WHERE host = 'serverA'`, s: `SHOW TAG VALUES FROM "db0"..cpu WITH KEY = region WHERE host = 'serverB'`},
+		{stmt: `SHOW FIELD KEYS FROM cpu`, s: `SHOW FIELD KEYS FROM "db0"..cpu`},
+		{stmt: `SHOW CONTINUOUS QUERIES WHERE host = 'serverA'`, s: `SHOW CONTINUOUS QUERIES WHERE host = 'serverB'`},
+		{stmt: `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`, s: `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO "db0"..cpu2 FROM "db0"..cpu GROUP BY time(1m) END`},
+	} {
+		stmt := influxql.RewriteFunc(influxql.MustParseStatement(tt.stmt), func(n influxql.Node) influxql.Node {
+			switch n := n.(type) {
+			case *influxql.Measurement:
+				return &influxql.Measurement{Database: "db0", Name: n.Name}
+			case *influxql.StringLiteral:
+				return &influxql.StringLiteral{Val: "serverB"}
+			}
+			return n
+		})
+		if s := stmt.String(); s != tt.s {
+			t.Errorf("%d. %s: unexpected result:\n\nexp=%s\n\ngot=%s\n\n", i, tt.stmt, tt.s, s)
+		}
+	}
+}
+
+// Ensure an expression can be rewritten without the full node machinery.
+func TestRewriteExpr(t *testing.T) {
+	expr := influxql.RewriteExpr(MustParseExpr(`host = 'serverA' AND (value > 1 OR max(value) < 2)`), func(e influxql.Expr) influxql.Expr {
+		if ref, ok := e.(*influxql.VarRef); ok && ref.Val == "value" {
+			return &influxql.VarRef{Val: "usage"}
+		}
+		return e
+	})
+	if s := expr.String(); s != `host = 'serverA' AND (usage > 1 OR max(usage) < 2)` {
+		t.Fatalf("unexpected result: %s", s)
+	}
+
+	if expr := influxql.RewriteExpr(nil, func(e influxql.Expr) influxql.Expr { return e }); expr != nil {
+		t.Fatalf("unexpected result: %s", expr)
+	}
+}
+
+// Ensure that every node in a statement is visited.
+func TestWalk(t *testing.T) {
+	for i, tt := range []struct {
+		stmt string
+		exp  []string
+	}{
+		{stmt: `SELECT value INTO cpu2 FROM /cpu.*/ WHERE host = 'serverA' ORDER BY time DESC`, exp: []string{`value`, `cpu2`, `/cpu.*/`, `/cpu.*/`, `host`, `'serverA'`, `time DESC`}},
+		{stmt: `DELETE FROM cpu WHERE host = 'serverA'`, exp: []string{`cpu`, `host`, `'serverA'`}},
+		{stmt: `DROP SERIES FROM cpu WHERE host = 'serverA'`, exp: []string{`cpu`, `host`, `'serverA'`}},
+		{stmt: `SHOW SERIES FROM cpu WHERE host = 'serverA' ORDER BY time`, exp: []string{`cpu`, `host`, `'serverA'`, `time ASC`}},
+		{stmt: `SHOW MEASUREMENTS WHERE host = 'serverA' ORDER BY time`, exp: []string{`host`, `'serverA'`, `time ASC`}},
+		{stmt: `SHOW TAG KEYS FROM cpu WHERE host = 'serverA'`, exp: []string{`cpu`, `host`, `'serverA'`}},
+		{stmt: `SHOW TAG VALUES FROM cpu WITH KEY = host WHERE region = 'uswest'`, exp: []string{`cpu`, `region`, `'uswest'`}},
+		{stmt: `SHOW FIELD KEYS FROM cpu ORDER BY time`, exp: []string{`cpu`, `time ASC`}},
+		{stmt: `SHOW CONTINUOUS QUERIES WHERE name = 'cq0'`, exp: []string{`name`, `'cq0'`}},
+		{stmt: `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`, exp: []string{`value`, `cpu2`, `1m`, `cpu`}},
+	} {
+		var a []string
+		influxql.WalkFunc(influxql.MustParseStatement(tt.stmt), func(n influxql.Node) {
+			switch n := n.(type) {
+			case *influxql.VarRef, *influxql.StringLiteral, *influxql.DurationLiteral, *influxql.RegexLiteral, *influxql.Measurement, *influxql.SortField:
+				a = append(a, n.String())
+			}
+		})
+		if !reflect.DeepEqual(a, tt.exp) {
+			t.Errorf("%d. %s: unexpected nodes:\n\nexp=%q\n\ngot=%q\n\n", i, tt.stmt, tt.exp, a)
+		}
+	}
+}
+
+// Ensure every statement can be deep copied.
+func TestCloneStatement(t *testing.T) {
+	var tests = []string{
+		`SELECT mean(value) INTO db0.rp0.:MEASUREMENT FROM cpu, /mem.*/ WHERE host =~ /server\d/ AND time > now() - 1h GROUP BY time(10m), region fill(0) ORDER BY time DESC LIMIT 10`,
+		`DELETE FROM db0.rp0.cpu WHERE time < now() - 1d AND host = 'serverA'`,
+		`SHOW SERIES FROM cpu WHERE region = 'uswest' LIMIT 5`,
+		`DROP SERIES FROM cpu WHERE host = 'serverA'`,
+		`SHOW MEASUREMENTS WHERE region = 'uswest' LIMIT 5 OFFSET 1`,
+		`SHOW TAG KEYS FROM cpu WHERE region = 'uswest'`,
+		`SHOW TAG VALUES FROM cpu WITH KEY IN (host, region) WHERE region = 'uswest'`,
+		`SHOW FIELD KEYS FROM cpu`,
+		`SHOW CONTINUOUS QUERIES ON db0 WHERE name = 'cq0'`,
+		`CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(5m) END`,
+		`CREATE SUBSCRIPTION s0 ON db0.rp0 DESTINATIONS ALL 'udp://h1:9090', 'udp://h2:9090'`,
+		`CREATE USER bob WITH PASSWORD 'secret' WITH ALL PRIVILEGES`,
+		`ALTER RETENTION POLICY rp0 ON db0 DURATION 1h REPLICATION 2`,
+		`CREATE RETENTION POLICY rp0 ON db0 DURATION 1h REPLICATION 2 DEFAULT`,
+		`GRANT READ ON db0 TO bob`,
+		`SHOW DATABASES`,
+	}
+
+	for i, s := range tests {
+		stmt := influxql.MustParseStatement(s)
+		other := influxql.CloneStatement(stmt)
+		if !reflect.DeepEqual(stmt, other) {
+			t.Errorf("%d. %s: unexpected clone: %s", i, s, other)
+			continue
+		}
+
+		// Modifying the clone must not affect the original.
+		clearPos(other)
+		if exp := influxql.MustParseStatement(s); !reflect.DeepEqual(stmt, exp) {
+			t.Errorf("%d. %s: original shares nodes with its clone", i, s)
+		}
+	}
+}
+
+// Ensure sensitive values are masked in sanitized statements.
+func TestSanitizeStatement(t *testing.T) {
+	for i, tt := range []struct {
+		s            string
+		maskLiterals bool
+		exp          string
+	}{
+		{s: `CREATE USER bob WITH PASSWORD 'secret' WITH ALL PRIVILEGES`, exp: `CREATE USER bob WITH PASSWORD '[REDACTED]' WITH ALL PRIVILEGES`},
+		{s: `SET PASSWORD FOR bob = 'secret'`, exp: `SET PASSWORD FOR bob = '[REDACTED]'`},
+		{s: `SELECT value FROM cpu WHERE host = 'serverA'`, exp: `SELECT value FROM cpu WHERE host = 'serverA'`},
+		{s: `SELECT value FROM cpu WHERE host = 'serverA' AND value > 10`, maskLiterals: true, exp: `SELECT value FROM cpu WHERE host = '[REDACTED]' AND value > 10`},
+		{s: `CREATE SUBSCRIPTION s0 ON db0.rp0 DESTINATIONS ALL 'udp://bob:secret@h1:9090'`, maskLiterals: true, exp: `CREATE SUBSCRIPTION s0 ON "db0".rp0 DESTINATIONS ALL '[REDACTED]'`},
+	} {
+		stmt := influxql.MustParseStatement(tt.s)
+		orig := stmt.String()
+		if s := influxql.SanitizeStatement(stmt, tt.maskLiterals).String(); s != tt.exp {
+			t.Errorf("%d. %s: unexpected result:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, tt.exp, s)
+		} else if stmt.String() != orig {
+			t.Errorf("%d. %s: original statement modified: %s", i, tt.s, stmt.String())
+		}
+	}
+}
+
+// Ensure every statement in a query is sanitized.
+func TestQuery_Sanitize(t *testing.T) {
+	q, err := influxql.ParseQuery(`CREATE USER bob WITH PASSWORD 'secret'; SET PASSWORD FOR bob = 'secret'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := q.Sanitize(false).String(); s != "CREATE USER bob WITH PASSWORD '[REDACTED]';\nSET PASSWORD FOR bob = '[REDACTED]'" {
+		t.Fatalf("unexpected result: %s", s)
+	}
+}
+
+// Ensure statements that only differ by literal values share a fingerprint.
+func TestFingerprint(t *testing.T) {
+	for i, tt := range []struct {
+		s, other string
+		same     bool
+	}{
+		{s: `SELECT value FROM cpu WHERE host = 'serverA'`, other: `select value from "cpu" where host='serverB'`, same: true},
+		{s: `SELECT mean(value) FROM cpu WHERE time > now() - 1h GROUP BY time(10m)`, other: `SELECT mean(value) FROM cpu WHERE time > now() - 2d GROUP BY time(1m)`, same: true},
+		{s: `SELECT value FROM cpu WHERE value > 10 LIMIT 5`, other: `SELECT value FROM cpu WHERE value > 2.5 LIMIT 100`, same: true},
+		{s: `SELECT value FROM cpu WHERE host =~ /^server\d+$/`, other: `SELECT value FROM cpu WHERE host =~ /foo/`, same: true},
+		{s: `SELECT value FROM cpu WHERE active = true`, other: `SELECT value FROM cpu WHERE active = false`, same: true},
+		{s: `SELECT value FROM cpu WHERE host = 'serverA'`, other: `SELECT value FROM mem WHERE host = 'serverA'`, same: false},
+		{s: `SELECT value FROM cpu WHERE host = 'serverA'`, other: `SELECT value FROM cpu WHERE host != 'serverA'`, same: false},
+		{s: `SELECT value FROM cpu WHERE host =~ /serverA/`, other: `SELECT value FROM cpu WHERE host !~ /serverA/`, same: false},
+		{s: `SELECT value FROM /cpu/`, other: `SELECT value FROM /mem/`, same: false},
+		{s: `SELECT max(value) FROM cpu`, other: `SELECT min(value) FROM cpu`, same: false},
+		{s: `SHOW DATABASES`, other: `SHOW SERVERS`, same: false},
+	} {
+		a := influxql.Fingerprint(influxql.MustParseStatement(tt.s))
+		b := influxql.Fingerprint(influxql.MustParseStatement(tt.other))
+		if same := a == b; same != tt.same {
+			t.Errorf("%d. %s and %s: unexpected result: same=%v", i, tt.s, tt.other, same)
+		}
+	}
+}
+
 // Ensure an expression can be reduced.
 func TestEval(t *testing.T) {
 	for i, tt := range []struct {
@@ -585,9 +1749,26 @@ func TestEval(t *testing.T) {
 		data map[string]interface{}
 	}{
 		// Number literals.
-		{in: `1 + 2`, out: float64(3)},
+		{in: `1 + 2`, out: int64(3)},
+		{in: `1.5 + 2`, out: float64(3.5)},
+		{in: `foo + 1`, out: int64(9007199254740993), data: map[string]interface{}{"foo": int64(9007199254740992)}},
+		{in: `foo - bar`, out: int64(1), data: map[string]interface{}{"foo": int64(9007199254740993), "bar": int64(9007199254740992)}},
+		{in: `foo * 3`, out: int64(27021597764222979), data: map[string]interface{}{"foo": int64(9007199254740993)}},
+		{in: `foo / 2`, out: int64(3), data: map[string]interface{}{"foo": int64(7)}},
+		{in: `foo / -1`, out: float64(9223372036854775808), data: map[string]interface{}{"foo": int64(math.MinInt64)}},
+		{in: `foo + 1`, out: float64(9223372036854775808), data: map[string]interface{}{"foo": int64(math.MaxInt64)}},
+		{in: `foo - 1`, out: float64(-9223372036854775809), data: map[string]interface{}{"foo": int64(math.MinInt64)}},
+		{in: `foo * 4`, out: float64(1 << 64), data: map[string]interface{}{"foo": int64(1 << 62)}},
+		{in: `foo * -1`, out: float64(9223372036854775808), data: map[string]interface{}{"foo": int64(math.MinInt64)}},
+		{in: `-foo`, out: float64(9223372036854775808), data: map[string]interface{}{"foo": int64(math.MinInt64)}},
+		{in: `foo > 1`, out: true, data: map[string]interface{}{"foo": float64(1.5)}},
+		{in: `foo > 1.5`, out: true, data: map[string]interface{}{"foo": int64(2)}},
 		{in: `(foo*2) + ( (4/2) + (3 * 5) - 0.5 )`, out: float64(26.5), data: map[string]interface{}{"foo": float64(5)}},
 		{in: `foo / 2`, out: float64(2), data: map[string]interface{}{"foo": float64(4)}},
+		{in: `foo % 10 = 0`, out: true, data: map[string]interface{}{"foo": int64(120)}},
+		{in: `foo % 10`, out: int64(3), data: map[string]interface{}{"foo": int64(123)}},
+		{in: `foo % 2`, out: float64(1.5), data: map[string]interface{}{"foo": float64(5.5)}},
+		{in: `foo % 0`, out: int64(0), data: map[string]interface{}{"foo": int64(5)}},
 		{in: `4 = 4`, out: true},
 		{in: `4 <> 4`, out: false},
 		{in: `6 > 4`, out: true},
@@ -596,19 +1777,69 @@ func TestEval(t *testing.T) {
 		{in: `4 <= 4`, out: true},
 		{in: `4 AND 5`, out: nil},
 
+		// Negation.
+		{in: `-foo`, out: float64(-1.5), data: map[string]interface{}{"foo": float64(1.5)}},
+		{in: `-foo * 2`, out: int64(-4), data: map[string]interface{}{"foo": int64(2)}},
+		{in: `- (1 + 2)`, out: int64(-3)},
+		{in: `-foo`, out: nil, data: map[string]interface{}{"foo": "bar"}},
+		{in: `NOT foo = 'bar'`, out: false, data: map[string]interface{}{"foo": "bar"}},
+		{in: `NOT (foo = 'a' OR foo = 'b')`, out: true, data: map[string]interface{}{"foo": "c"}},
+		{in: `NOT foo`, out: nil, data: map[string]interface{}{"foo": int64(1)}},
+
 		// Boolean literals.
 		{in: `true AND false`, out: false},
 		{in: `true OR false`, out: true},
 
+		// Scalar function calls.
+		{in: `abs(foo) > 1`, out: true, data: map[string]interface{}{"foo": float64(-1.5)}},
+		{in: `lower(foo) = 'server01'`, out: true, data: map[string]interface{}{"foo": "SERVER01"}},
+		{in: `abs(foo)`, out: nil},
+		{in: `mean(foo)`, out: nil, data: map[string]interface{}{"foo": float64(1)}},
+
+		// Time and duration values.
+		{in: `foo > '2000-01-01T00:00:00Z'`, out: true, data: map[string]interface{}{"foo": mustParseTime("2000-01-01T00:00:01Z")}},
+		{in: `foo <= '2000-01-01T00:00:00Z'`, out: false, data: map[string]interface{}{"foo": mustParseTime("2000-01-01T00:00:01Z")}},
+		{in: `foo = '2000-01-01T00:00:00Z'`, out: true, data: map[string]interface{}{"foo": mustParseTime("2000-01-01T00:00:00Z")}},
+		{in: `foo + 1h`, out: mustParseTime("2000-01-01T01:00:00Z"), data: map[string]interface{}{"foo": mustParseTime("2000-01-01T00:00:00Z")}},
+		{in: `foo - 1h >= bar`, out: true, data: map[string]interface{}{"foo": mustParseTime("2000-01-01T01:00:00Z"), "bar": mustParseTime("2000-01-01T00:00:00Z")}},
+		{in: `1h + foo`, out: mustParseTime("2000-01-01T01:00:00Z"), data: map[string]interface{}{"foo": mustParseTime("2000-01-01T00:00:00Z")}},
+		{in: `foo - bar`, out: 30 * time.Minute, data: map[string]interface{}{"foo": mustParseTime("2000-01-01T00:30:00Z"), "bar": mustParseTime("2000-01-01T00:00:00Z")}},
+		{in: `foo < 1h`, out: true, data: map[string]interface{}{"foo": 10 * time.Minute}},
+		{in: `foo * 2 + 1m`, out: 21 * time.Minute, data: map[string]interface{}{"foo": 10 * time.Minute}},
+		{in: `2 * foo`, out: 20 * time.Minute, data: map[string]interface{}{"foo": 10 * time.Minute}},
+		{in: `foo % 1h`, out: 30 * time.Minute, data: map[string]interface{}{"foo": 90 * time.Minute}},
+		{in: `-foo`, out: -time.Minute, data: map[string]interface{}{"foo": time.Minute}},
+
 		// String literals.
 		{in: `'foo' = 'bar'`, out: false},
 		{in: `'foo' = 'foo'`, out: true},
+		{in: `'db01' < 'db20'`, out: true},
+		{in: `'db01' >= 'db20'`, out: false},
+		{in: `foo >= 'db01' AND foo < 'db20'`, out: true, data: map[string]interface{}{"foo": "db10"}},
+		{in: `foo >= 'db01' AND foo < 'db20'`, out: false, data: map[string]interface{}{"foo": "db20"}},
+		{in: `foo <= 'db01'`, out: true, data: map[string]interface{}{"foo": "db01"}},
+		{in: `foo > 'db01'`, out: false, data: map[string]interface{}{"foo": "db0"}},
 
 		// Variable references.
 		{in: `foo`, out: "bar", data: map[string]interface{}{"foo": "bar"}},
 		{in: `foo = 'bar'`, out: true, data: map[string]interface{}{"foo": "bar"}},
 		{in: `foo = 'bar'`, out: nil, data: map[string]interface{}{"foo": nil}},
 		{in: `foo <> 'bar'`, out: true, data: map[string]interface{}{"foo": "xxx"}},
+		{in: `foo LIKE 'ba%'`, out: true, data: map[string]interface{}{"foo": "bar"}},
+		{in: `foo LIKE 'ba%'`, out: false, data: map[string]interface{}{"foo": "foo"}},
+		{in: `foo LIKE 'ba%'`, out: nil, data: map[string]interface{}{"foo": int64(1)}},
+		{in: `foo =~ /^b/`, out: true, data: map[string]interface{}{"foo": "bar"}},
+		{in: `foo =~ /^b/`, out: false, data: map[string]interface{}{"foo": "foo"}},
+		{in: `foo !~ /^b/`, out: true, data: map[string]interface{}{"foo": "foo"}},
+		{in: `foo =~ /^b/`, out: nil},
+
+		// Null tests.
+		{in: `foo IS NULL`, out: true, data: map[string]interface{}{"bar": int64(1)}},
+		{in: `foo IS NULL`, out: false, data: map[string]interface{}{"foo": int64(0)}},
+		{in: `foo IS NULL`, out: true, data: map[string]interface{}{"foo": nil}},
+		{in: `foo IS NOT NULL`, out: true, data: map[string]interface{}{"foo": ""}},
+		{in: `foo IS NOT NULL`, out: false},
+		{in: `foo + 1 IS NULL`, out: true, data: map[string]interface{}{"foo": "bar"}},
 	} {
 		// Evaluate expression.
 		out := influxql.Eval(MustParseExpr(tt.in), tt.data)
@@ -621,6 +1852,62 @@ func TestEval(t *testing.T) {
 	}
 }
 
+// Ensure type mismatches are reported when evaluating an expression.
+func TestEvalExpr(t *testing.T) {
+	for i, tt := range []struct {
+		in   string
+		out  interface{}
+		err  string
+		data map[string]interface{}
+	}{
+		{in: `foo + 1`, out: int64(3), data: map[string]interface{}{"foo": int64(2)}},
+		{in: `foo = 'bar'`, out: nil},
+		{in: `foo = 'bar' AND baz = 1`, out: false, data: map[string]interface{}{"foo": "bar"}},
+		{in: `foo = 1`, err: `cannot compare string to integer: foo = 1`, data: map[string]interface{}{"foo": "bar"}},
+		{in: `host = 'a' AND (value > 'x')`, err: `cannot compare float to string: value > 'x'`, data: map[string]interface{}{"host": "a", "value": float64(1)}},
+		{in: `foo + 'bar'`, err: `cannot use + operator on integer and string: foo + 'bar'`, data: map[string]interface{}{"foo": int64(1)}},
+		{in: `foo - 'bar'`, err: `cannot use - operator on string and string: foo - 'bar'`, data: map[string]interface{}{"foo": "baz"}},
+		{in: `true AND 1`, err: `cannot use AND operator on boolean and integer: true AND 1`},
+		{in: `-foo`, err: `cannot use - operator on string: -foo`, data: map[string]interface{}{"foo": "bar"}},
+		{in: `NOT foo`, err: `cannot use NOT operator on float: NOT foo`, data: map[string]interface{}{"foo": float64(1)}},
+		{in: `foo > 1h`, err: `cannot compare time to duration: foo > 1h`, data: map[string]interface{}{"foo": mustParseTime("2000-01-01T00:00:00Z")}},
+		{in: `foo + 1`, err: `cannot use + operator on time and integer: foo + 1`, data: map[string]interface{}{"foo": mustParseTime("2000-01-01T00:00:00Z")}},
+		{in: `foo =~ /a/`, err: `cannot match float against a regular expression: foo =~ /a/`, data: map[string]interface{}{"foo": float64(1)}},
+		{in: `lower(foo)`, err: `invalid arguments for lower(): lower(foo)`, data: map[string]interface{}{"foo": float64(1)}},
+		{in: `sqrt(foo)`, err: `invalid arguments for sqrt(): sqrt(foo)`, data: map[string]interface{}{"foo": float64(-1)}},
+	} {
+		out, err := influxql.EvalExpr(MustParseExpr(tt.in), tt.data)
+		if errstring(err) != tt.err {
+			t.Errorf("%d. %s: unexpected error:\n\nexp=%s\n\ngot=%v\n\n", i, tt.in, tt.err, err)
+		} else if !reflect.DeepEqual(tt.out, out) {
+			t.Errorf("%d. %s: unexpected output:\n\nexp=%#v\n\ngot=%#v\n\n", i, tt.in, tt.out, out)
+		}
+	}
+}
+
+// Ensure conditions evaluate to a boolean.
+func TestEvalBool(t *testing.T) {
+	for i, tt := range []struct {
+		in   string
+		out  bool
+		err  string
+		data map[string]interface{}
+	}{
+		{in: `foo > 1`, out: true, data: map[string]interface{}{"foo": float64(2)}},
+		{in: `foo > 1`, out: false, data: map[string]interface{}{"foo": float64(0)}},
+		{in: `foo > 1`, out: false},
+		{in: `foo > 'a'`, err: `cannot compare float to string: foo > 'a'`, data: map[string]interface{}{"foo": float64(2)}},
+		{in: `foo + 1`, err: `condition is not a boolean: foo + 1`, data: map[string]interface{}{"foo": float64(2)}},
+	} {
+		out, err := influxql.EvalBool(MustParseExpr(tt.in), tt.data)
+		if errstring(err) != tt.err {
+			t.Errorf("%d. %s: unexpected error:\n\nexp=%s\n\ngot=%v\n\n", i, tt.in, tt.err, err)
+		} else if out != tt.out {
+			t.Errorf("%d. %s: unexpected output: %v", i, tt.in, out)
+		}
+	}
+}
+
 // Ensure an expression can be reduced.
 func TestReduce(t *testing.T) {
 	now := mustParseTime("2000-01-01T00:00:00Z")
@@ -631,17 +1918,48 @@ func TestReduce(t *testing.T) {
 		data Valuer
 	}{
 		// Number literals.
-		{in: `1 + 2`, out: `3.000`},
-		{in: `(foo*2) + ( (4/2) + (3 * 5) - 0.5 )`, out: `(foo * 2.000) + 16.500`},
-		{in: `foo(bar(2 + 3), 4)`, out: `foo(bar(5.000), 4.000)`},
-		{in: `4 / 0`, out: `0.000`},
+		{in: `1 + 2`, out: `3`},
+		{in: `1.0 + 2`, out: `3.000`},
+		{in: `9007199254740992 + 1`, out: `9007199254740993`},
+		{in: `(foo*2) + ( (4/2) + (3 * 5) - 0.5 )`, out: `(foo * 2) + 16.500`},
+		{in: `foo(bar(2 + 3), 4)`, out: `foo(bar(5), 4)`},
+		{in: `4 / 0`, out: `0`},
+		{in: `7 / 2`, out: `3`},
+		{in: `4.0 = 4`, out: `true`},
 		{in: `4 = 4`, out: `true`},
 		{in: `4 <> 4`, out: `false`},
 		{in: `6 > 4`, out: `true`},
 		{in: `4 >= 4`, out: `true`},
 		{in: `4 < 6`, out: `true`},
 		{in: `4 <= 4`, out: `true`},
-		{in: `4 AND 5`, out: `4.000 AND 5.000`},
+		{in: `4 AND 5`, out: `4 AND 5`},
+		{in: `7 % 3`, out: `1`},
+		{in: `9223372036854775807 + 1`, out: `9223372036854775808.000`},
+		{in: `9007199254740993 * 2`, out: `18014398509481986`},
+		{in: `7 / 2`, out: `3`},
+		{in: `7.5 % 2`, out: `1.500`},
+		{in: `1 + 7 % 3 * 2`, out: `3`},
+		{in: `cast_id % 10 = 0`, out: `cast_id % 10 = 0`},
+
+		// Negation.
+		{in: `- (1 + 2)`, out: `-3`},
+		{in: `-1.5 * 2`, out: `-3.000`},
+		{in: `- -foo`, out: `foo`},
+		{in: `-foo + 1`, out: `-foo + 1`},
+		{in: `now() -1h`, out: `'1999-12-31 23:00:00'`, data: map[string]interface{}{"now()": now}},
+
+		// Logical negation.
+		{in: `NOT true`, out: `false`},
+		{in: `NOT NOT foo`, out: `foo`},
+		{in: `NOT (NOT foo)`, out: `foo`},
+		{in: `NOT host = 'a'`, out: `host != 'a'`},
+		{in: `NOT value < 10`, out: `value >= 10`},
+		{in: `NOT host =~ /^a/`, out: `host !~ /^a/`},
+		{in: `NOT (host = 'a' OR host = 'b')`, out: `host != 'a' AND host != 'b'`},
+		{in: `region = 'x' AND NOT (host = 'a' AND value > 1)`, out: `region = 'x' AND (host != 'a' OR value <= 1)`},
+		{in: `NOT (host = 'a' OR true)`, out: `false`},
+		{in: `NOT foo`, out: `NOT foo`},
+		{in: `NOT foo AND bar = 1`, out: `false`, data: map[string]interface{}{"foo": true}},
 
 		// Boolean literals.
 		{in: `true AND false`, out: `false`},
@@ -654,6 +1972,31 @@ func TestReduce(t *testing.T) {
 		{in: `true <> false`, out: `true`},
 		{in: `true + false`, out: `true + false`},
 
+		// String literals.
+		{in: `'server01' LIKE 'server*'`, out: `true`},
+		{in: `'server01' LIKE '%01'`, out: `true`},
+		{in: `'server01' LIKE 'db%'`, out: `false`},
+		{in: `host LIKE 'server%'`, out: `host LIKE 'server%'`},
+		{in: `'db10' >= 'db01'`, out: `true`},
+		{in: `'db10' < 'db01'`, out: `false`},
+		{in: `'db10' <= 'db10'`, out: `true`},
+		{in: `'db10' > 'db2'`, out: `false`},
+		{in: `host >= 'db01' AND host < 'db20'`, out: `true`, data: map[string]interface{}{"host": "db10"}},
+		{in: `host >= 'db01' AND host < 'db20'`, out: `host >= 'db01' AND host < 'db20'`},
+		{in: `'server01' =~ /^server\d+$/`, out: `true`},
+		{in: `'server01' !~ /^server\d+$/`, out: `false`},
+		{in: `host =~ /^server/`, out: `host =~ /^server/`},
+		{in: `host =~ /^server/ AND region = 'a'`, out: `region = 'a'`, data: map[string]interface{}{"host": "server01"}},
+
+		// Null tests.
+		{in: `foo IS NULL`, out: `foo IS NULL`},
+		{in: `foo IS NOT NULL AND bar = 1`, out: `foo IS NOT NULL AND bar = 1`},
+		{in: `NOT foo IS NULL`, out: `foo IS NOT NULL`},
+		{in: `NOT (foo IS NOT NULL OR bar = 1)`, out: `foo IS NULL AND bar != 1`},
+		{in: `foo IS NULL`, out: `false`, data: map[string]interface{}{"foo": int64(0)}},
+		{in: `foo IS NOT NULL`, out: `false`, data: map[string]interface{}{"foo": nil}},
+		{in: `1 + 2 IS NOT NULL`, out: `true`},
+
 		// Time literals.
 		{in: `now() + 2h`, out: `'2000-01-01 02:00:00'`, data: map[string]interface{}{"now()": now}},
 		{in: `now() / 2h`, out: `'2000-01-01 00:00:00' / 2h`, data: map[string]interface{}{"now()": now}},
@@ -668,9 +2011,26 @@ func TestReduce(t *testing.T) {
 		{in: `now() AND now()`, out: `'2000-01-01 00:00:00' AND '2000-01-01 00:00:00'`, data: map[string]interface{}{"now()": now}},
 		{in: `now()`, out: `now()`},
 
+		// Scalar function calls.
+		{in: `abs(-3)`, out: `3`},
+		{in: `abs(-1.5) + 1`, out: `2.500`},
+		{in: `floor(10.7)`, out: `10.000`},
+		{in: `ceil(10.2) = 11`, out: `true`},
+		{in: `round(-2.5)`, out: `-3.000`},
+		{in: `floor(7)`, out: `7`},
+		{in: `sqrt(16)`, out: `4.000`},
+		{in: `lower('SERVER01')`, out: `'server01'`},
+		{in: `host = upper('a')`, out: `true`, data: map[string]interface{}{"host": "A"}},
+		{in: `host = upper('a')`, out: `host = 'A'`},
+		{in: `value > abs(foo)`, out: `value > abs(foo)`},
+		{in: `value > abs(foo)`, out: `value > 2`, data: map[string]interface{}{"foo": int64(-2)}},
+		{in: `lower(1)`, out: `lower(1)`},
+		{in: `mean(1)`, out: `mean(1)`},
+
 		// Duration literals.
 		{in: `10m + 1h - 60s`, out: `69m`},
 		{in: `(10m / 2) * 5`, out: `25m`},
+		{in: `90m % 1h`, out: `30m`},
 		{in: `60s = 1m`, out: `true`},
 		{in: `60s <> 1m`, out: `false`},
 		{in: `60s < 1h`, out: `true`},
@@ -679,7 +2039,8 @@ func TestReduce(t *testing.T) {
 		{in: `60s >= 1m`, out: `true`},
 		{in: `60s AND 1m`, out: `1m AND 1m`},
 		{in: `60m / 0`, out: `0s`},
-		{in: `60m + 50`, out: `1h + 50.000`},
+		{in: `60m + 50`, out: `1h + 50`},
+		{in: `2 * 10m`, out: `20m`},
 
 		// String literals.
 		{in: `'foo' + 'bar'`, out: `'foobar'`},
@@ -701,6 +2062,113 @@ func TestReduce(t *testing.T) {
 	}
 }
 
+// Ensure conditions can be classified by whether they depend on data.
+func TestConditionTruth(t *testing.T) {
+	for i, tt := range []struct {
+		in    string
+		truth influxql.Truth
+	}{
+		{in: ``, truth: influxql.AlwaysTrue},
+		{in: `1 = 1`, truth: influxql.AlwaysTrue},
+		{in: `true OR host = 'a'`, truth: influxql.AlwaysTrue},
+		{in: `time < '2000-01-02 00:00:00' OR time >= '2000-01-01 00:00:00'`, truth: influxql.AlwaysTrue},
+		{in: `1 = 2`, truth: influxql.AlwaysFalse},
+		{in: `host = 'a' AND 'a' = 'b'`, truth: influxql.AlwaysFalse},
+		{in: `host = 'a' AND host = 'b'`, truth: influxql.AlwaysFalse},
+		{in: `time > '2000-01-02 00:00:00' AND time < '2000-01-01 00:00:00'`, truth: influxql.AlwaysFalse},
+		{in: `host = 'a'`, truth: influxql.DataDependent},
+		{in: `host = 'a' OR host != 'a'`, truth: influxql.DataDependent},
+		{in: `1`, truth: influxql.DataDependent},
+	} {
+		var expr influxql.Expr
+		if tt.in != "" {
+			expr = MustParseExpr(tt.in)
+		}
+
+		if truth := influxql.ConditionTruth(expr); truth != tt.truth {
+			t.Errorf("%d. %s: unexpected truth: exp=%d, got=%d", i, tt.in, tt.truth, truth)
+		}
+	}
+}
+
+// Ensure conditions can be simplified.
+func TestSimplify(t *testing.T) {
+	for i, tt := range []struct {
+		in  string
+		out string
+	}{
+		// Redundant parentheses.
+		{in: `(host = 'a')`, out: `host = 'a'`},
+		{in: `((host = 'a') AND (region = 'b'))`, out: `host = 'a' AND region = 'b'`},
+		{in: `host = 'a' AND (region = 'b' AND value > 1)`, out: `host = 'a' AND region = 'b' AND value > 1`},
+		{in: `host = 'a' AND (region = 'b' OR value > 1)`, out: `host = 'a' AND (region = 'b' OR value > 1)`},
+		{in: `host = 'a' OR (region = 'b' AND value > 1)`, out: `host = 'a' OR region = 'b' AND value > 1`},
+		{in: `(a - b) - c > 1`, out: `a - b - c > 1`},
+		{in: `a - (b - c) > 1`, out: `a - (b - c) > 1`},
+		{in: `(a + b) * c > (1)`, out: `(a + b) * c > 1`},
+		{in: `(value + 1) IS NULL`, out: `value + 1 IS NULL`},
+		{in: `-(a + b) > 1`, out: `-(a + b) > 1`},
+		{in: `abs((value)) > 1`, out: `abs(value) > 1`},
+
+		// Constants.
+		{in: `1 = 1 AND host = 'a'`, out: `host = 'a'`},
+		{in: `1 = 2 OR (host = 'a')`, out: `host = 'a'`},
+		{in: `host = 'a' AND (1 = 2 AND region = 'b')`, out: `false`},
+
+		// Repeated and absorbed operands.
+		{in: `host = 'a' AND host = 'a'`, out: `host = 'a'`},
+		{in: `host = 'a' AND (host = 'a' OR region = 'b')`, out: `host = 'a'`},
+		{in: `(host = 'a' OR region = 'b') AND host = 'a'`, out: `host = 'a'`},
+		{in: `host = 'a' OR host = 'a' AND region = 'b'`, out: `host = 'a'`},
+		{in: `(host = 'a' OR region = 'b') AND (region = 'b' OR host = 'a')`, out: `host = 'a' OR region = 'b'`},
+		{in: `(host = 'a' OR region = 'b' OR value > 1) AND (host = 'a' OR region = 'b')`, out: `host = 'a' OR region = 'b'`},
+		{in: `(host = 'a' OR region = 'b') AND (host = 'a' OR value > 1)`, out: `(host = 'a' OR region = 'b') AND (host = 'a' OR value > 1)`},
+
+		// Contradictions.
+		{in: `host = 'a' AND region = 'b' AND host != 'a'`, out: `false`},
+		{in: `host = 'a' AND host = 'b'`, out: `false`},
+		{in: `value = 1 AND value = 1.0`, out: `value = 1 AND value = 1.000`},
+		{in: `value = 1 AND 2 = value`, out: `false`},
+		{in: `a AND NOT a`, out: `false`},
+		{in: `NOT (host = 'a' OR region = 'b') AND host = 'a'`, out: `false`},
+		{in: `host = 'a' OR host != 'a'`, out: `host = 'a' OR host != 'a'`},
+
+		// De Morgan's laws.
+		{in: `NOT (host = 'a' AND region = 'b')`, out: `host != 'a' OR region != 'b'`},
+		{in: `NOT (host = 'a' OR region = 'b') AND value > 1`, out: `host != 'a' AND region != 'b' AND value > 1`},
+		{in: `NOT (a OR b)`, out: `NOT a AND NOT b`},
+		{in: `NOT (a AND b) AND c`, out: `(NOT a OR NOT b) AND c`},
+
+		// Time ranges.
+		{in: `time > '2000-01-01 00:00:00' AND time > '2000-01-02 00:00:00'`, out: `time > '2000-01-02 00:00:00'`},
+		{in: `time >= '2000-01-02 00:00:00' AND time > '2000-01-02 00:00:00'`, out: `time > '2000-01-02 00:00:00'`},
+		{in: `time >= '2000-01-01 00:00:00' AND host = 'a' AND time < '2000-01-03 00:00:00' AND '2000-01-02 00:00:00' > time`, out: `time >= '2000-01-01 00:00:00' AND host = 'a' AND '2000-01-02 00:00:00' > time`},
+		{in: `time > '2000-01-02 00:00:00' AND time < '2000-01-01 00:00:00'`, out: `false`},
+		{in: `time > '2000-01-01 00:00:00' AND time < '2000-01-01 00:00:00'`, out: `false`},
+		{in: `time >= '2000-01-01 00:00:00' AND time <= '2000-01-01 00:00:00'`, out: `time >= '2000-01-01 00:00:00' AND time <= '2000-01-01 00:00:00'`},
+		{in: `time >= '2000-01-01 00:00:00' AND time < '2000-01-03 00:00:00' OR time >= '2000-01-02 00:00:00' AND time < '2000-01-04 00:00:00'`, out: `time >= '2000-01-01 00:00:00' AND time < '2000-01-04 00:00:00'`},
+		{in: `time < '2000-01-02 00:00:00' OR time >= '2000-01-02 00:00:00' AND time < '2000-01-03 00:00:00'`, out: `time < '2000-01-03 00:00:00'`},
+		{in: `time < '2000-01-02 00:00:00' OR time > '2000-01-02 00:00:00'`, out: `time < '2000-01-02 00:00:00' OR time > '2000-01-02 00:00:00'`},
+		{in: `time < '2000-01-02 00:00:00' OR time >= '2000-01-01 00:00:00'`, out: `true`},
+		{in: `time < '2000-01-01 00:00:00' OR host = 'a' OR time > '2000-01-03 00:00:00' OR time < '2000-01-04 00:00:00' AND time > '2000-01-02 00:00:00'`, out: `time < '2000-01-01 00:00:00' OR host = 'a' OR time > '2000-01-02 00:00:00'`},
+		{in: `time > now() - 1h AND time > now() - 2h`, out: `time > now() - 1h AND time > now() - 2h`},
+	} {
+		// Simplify the expression and make sure it parses back to itself.
+		expr := influxql.Simplify(MustParseExpr(tt.in))
+		if expr.String() != tt.out {
+			t.Errorf("%d. %s: unexpected expr:\n\nexp=%s\n\ngot=%s\n\n", i, tt.in, tt.out, expr.String())
+			continue
+		}
+
+		other := MustParseExpr(expr.String())
+		clearPos(expr)
+		clearPos(other)
+		if !reflect.DeepEqual(expr, other) {
+			t.Errorf("%d. %s: round trip mismatch:\n\nexp=%s\n\ngot=%s\n\n", i, tt.in, mustMarshalJSON(expr), mustMarshalJSON(other))
+		}
+	}
+}
+
 // Valuer represents a simple wrapper around a map to implement the influxql.Valuer interface.
 type Valuer map[string]interface{}
 
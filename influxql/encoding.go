@@ -0,0 +1,134 @@
+package influxql
+
+import (
+	"bytes"
+	"encoding/gob"
+	"regexp"
+)
+
+func init() {
+	// Register every concrete type that can be held by a Statement, Expr,
+	// or Source so gob can encode them through their interfaces.
+	for _, v := range []interface{}{
+		// Statements
+		&AlterRetentionPolicyStatement{},
+		&BackfillContinuousQueryStatement{},
+		&CreateContinuousQueryStatement{},
+		&CreateDatabaseStatement{},
+		&CreateRetentionPolicyStatement{},
+		&CreateSubscriptionStatement{},
+		&CreateUserStatement{},
+		&DeleteStatement{},
+		&DropContinuousQueryStatement{},
+		&DropDatabaseStatement{},
+		&DropMeasurementStatement{},
+		&DropRetentionPolicyStatement{},
+		&DropSeriesStatement{},
+		&DropSubscriptionStatement{},
+		&DropUserStatement{},
+		&ExplainStatement{},
+		&GrantStatement{},
+		&KillQueryStatement{},
+		&RevokeStatement{},
+		&SelectStatement{},
+		&SetPasswordUserStatement{},
+		&ShowContinuousQueriesStatement{},
+		&ShowDatabasesStatement{},
+		&ShowDiagnosticsStatement{},
+		&ShowFieldKeysStatement{},
+		&ShowGrantsForUserStatement{},
+		&ShowMeasurementsStatement{},
+		&ShowQueriesStatement{},
+		&ShowRetentionPoliciesStatement{},
+		&ShowSeriesStatement{},
+		&ShowServersStatement{},
+		&ShowStatsStatement{},
+		&ShowSubscriptionsStatement{},
+		&ShowTagKeysStatement{},
+		&ShowTagValuesStatement{},
+		&ShowUsersStatement{},
+
+		// Expressions
+		&BinaryExpr{},
+		&BooleanLiteral{},
+		&Call{},
+		&Distinct{},
+		&DurationLiteral{},
+		&IntegerLiteral{},
+		&IsNullExpr{},
+		&nilLiteral{},
+		&NumberLiteral{},
+		&ParenExpr{},
+		&RegexLiteral{},
+		&StringLiteral{},
+		&TimeLiteral{},
+		&UnaryExpr{},
+		&VarRef{},
+		&Wildcard{},
+
+		// Sources
+		&Measurement{},
+	} {
+		gob.Register(v)
+	}
+}
+
+// EncodeStatement returns a binary encoding of a statement. The statement can
+// be restored with DecodeStatement without formatting and re-parsing it, which
+// also preserves node positions and values that don't survive String().
+func EncodeStatement(stmt Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&stmt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeStatement decodes a statement encoded by EncodeStatement.
+func DecodeStatement(b []byte) (Statement, error) {
+	var stmt Statement
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&stmt); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// regexLiteral is the encoded form of a RegexLiteral.
+type regexLiteral struct {
+	Position Pos
+	Pattern  *string
+}
+
+// GobEncode encodes the regular expression as its pattern since compiled
+// expressions can't be encoded directly.
+func (r *RegexLiteral) GobEncode() ([]byte, error) {
+	other := regexLiteral{Position: r.Position}
+	if r.Val != nil {
+		pattern := r.Val.String()
+		other.Pattern = &pattern
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&other); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode decodes and compiles a regular expression encoded by GobEncode.
+func (r *RegexLiteral) GobDecode(b []byte) error {
+	var other regexLiteral
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&other); err != nil {
+		return err
+	}
+
+	r.Position, r.Val = other.Position, nil
+	if other.Pattern != nil {
+		re, err := regexp.Compile(*other.Pattern)
+		if err != nil {
+			return err
+		}
+		r.Val = re
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package influxql_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Ensure statements can be encoded and decoded without losing information.
+func TestEncodeStatement(t *testing.T) {
+	var tests = []string{
+		`SELECT mean(value) AS m, max(value) * 2 FROM db0."default".cpu WHERE host =~ /^server\d+$/ AND time > now() - 1h GROUP BY time(10m), region fill(0) ORDER BY time DESC LIMIT 10 SLIMIT 2`,
+		`SELECT * INTO db1."1h".:MEASUREMENT FROM /cpu.*/ WHERE time >= '2000-01-01T00:00:00Z'`,
+		`SELECT distinct(host) FROM cpu WHERE value > 2.5 OR active = true`,
+		`CREATE CONTINUOUS QUERY cq0 ON db0 RESAMPLE EVERY 1m FOR 1h BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(5m) END`,
+		`DELETE FROM cpu WHERE time < now() - 1d AND host = 'serverA'`,
+		`SHOW TAG VALUES FROM cpu WITH KEY = host WHERE region = 'uswest' LIMIT 5`,
+		`SHOW CONTINUOUS QUERIES ON db0 WHERE name = 'cq0'`,
+		`CREATE SUBSCRIPTION s0 ON db0.rp0 DESTINATIONS ANY 'udp://h1:9090', 'udp://h2:9090'`,
+		`GRANT READ ON db0 TO bob`,
+		`SHOW SERVERS`,
+	}
+
+	for i, s := range tests {
+		stmt := influxql.MustParseStatement(s)
+
+		b, err := influxql.EncodeStatement(stmt)
+		if err != nil {
+			t.Fatalf("%d. %s: encode: %s", i, s, err)
+		}
+
+		other, err := influxql.DecodeStatement(b)
+		if err != nil {
+			t.Fatalf("%d. %s: decode: %s", i, s, err)
+		} else if reflect.TypeOf(other) != reflect.TypeOf(stmt) {
+			t.Fatalf("%d. %s: unexpected type: %T", i, s, other)
+		} else if other.String() != stmt.String() {
+			t.Errorf("%d. unexpected string:\n\nexp=%s\n\ngot=%s\n\n", i, stmt.String(), other.String())
+		} else if other.Pos() != stmt.Pos() {
+			t.Errorf("%d. %s: unexpected position: %+v", i, s, other.Pos())
+		}
+	}
+}
+
+// Ensure positions of nested nodes survive encoding.
+func TestEncodeStatement_Pos(t *testing.T) {
+	stmt := influxql.MustParseStatement(`SELECT value FROM cpu WHERE host =~ /server/`).(*influxql.SelectStatement)
+
+	b, err := influxql.EncodeStatement(stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := influxql.DecodeStatement(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := stmt.Condition.(*influxql.BinaryExpr).RHS.Pos()
+	if pos := other.(*influxql.SelectStatement).Condition.(*influxql.BinaryExpr).RHS.Pos(); pos != exp {
+		t.Fatalf("unexpected regex position: exp=%+v got=%+v", exp, pos)
+	}
+}
+
+// Ensure invalid data returns an error.
+func TestDecodeStatement_Invalid(t *testing.T) {
+	if _, err := influxql.DecodeStatement([]byte("bad")); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"math"
+	"math/rand"
 	"sort"
 	"time"
 )
@@ -44,6 +45,7 @@ type MapReduceJob struct {
 	interval        int64            // the group by interval of the query
 	stmt            *SelectStatement // the select statement this job was created for
 	chunkSize       int              // the number of points to buffer in raw queries before returning a chunked response
+	closing         <-chan struct{}  // closed when the query is canceled
 }
 
 func (m *MapReduceJob) Open() error {
@@ -62,6 +64,27 @@ func (m *MapReduceJob) Close() {
 	}
 }
 
+// send writes a row to out. Returns false without writing the row if the
+// query is canceled first.
+func (m *MapReduceJob) send(out chan *Row, row *Row) bool {
+	select {
+	case out <- row:
+		return true
+	case <-m.closing:
+		return false
+	}
+}
+
+// canceled returns true if the query has been canceled.
+func (m *MapReduceJob) canceled() bool {
+	select {
+	case <-m.closing:
+		return true
+	default:
+		return false
+	}
+}
+
 func (m *MapReduceJob) Key() []byte {
 	if m.key == nil {
 		m.key = append([]byte(m.MeasurementName), m.TagSet.Key...)
@@ -71,25 +94,25 @@ func (m *MapReduceJob) Key() []byte {
 
 func (m *MapReduceJob) Execute(out chan *Row, filterEmptyResults bool) {
 	if err := m.Open(); err != nil {
-		out <- &Row{Err: err}
+		m.send(out, &Row{Err: err})
 		m.Close()
 		return
 	}
 	defer m.Close()
 
 	// if it's a raw query or a non-nested derivative we handle processing differently
-	if m.stmt.IsRawQuery || m.stmt.IsSimpleDerivative() {
+	if m.stmt.IsRawQuery || m.stmt.IsSimpleDerivative() || m.stmt.IsSimpleTransform() {
 		m.processRawQuery(out, filterEmptyResults)
 		return
 	}
 
 	// get the aggregates and the associated reduce functions
-	aggregates := m.stmt.FunctionCalls()
+	aggregates := m.stmt.aggregateCalls()
 	reduceFuncs := make([]ReduceFunc, len(aggregates))
 	for i, c := range aggregates {
 		reduceFunc, err := InitializeReduceFunc(c)
 		if err != nil {
-			out <- &Row{Err: err}
+			m.send(out, &Row{Err: err})
 			return
 		}
 		reduceFuncs[i] = reduceFunc
@@ -109,9 +132,33 @@ func (m *MapReduceJob) Execute(out chan *Row, filterEmptyResults bool) {
 		pointCountInResult = int((intervalTop - intervalBottom) / m.interval)
 	}
 
+	// ensure that the start time for the results is on the start of the window
+	startTimeBucket := m.TMin
+	if m.interval > 0 {
+		startTimeBucket = startTimeBucket / m.interval * m.interval
+	}
+
+	// For descending queries the limit and offset count back from the latest
+	// interval, so only the latest intervals are read from the mappers.
+	descending := m.stmt.IsDescending()
+	if descending && (m.stmt.Limit > 0 || m.stmt.Offset > 0) {
+		end := pointCountInResult - m.stmt.Offset
+		if end <= 0 {
+			return
+		}
+
+		n := end
+		if m.stmt.Limit > 0 && m.stmt.Limit < end {
+			n = m.stmt.Limit
+		}
+		startTimeBucket += int64(end-n) * m.interval
+		pointCountInResult = n
+		m.TMin = startTimeBucket
+	}
+
 	// For group by time queries, limit the number of data points returned by the limit and offset
 	// raw query limits are handled elsewhere
-	if m.stmt.Limit > 0 || m.stmt.Offset > 0 {
+	if !descending && (m.stmt.Limit > 0 || m.stmt.Offset > 0) {
 		// ensure that the offset isn't higher than the number of points we'd get
 		if m.stmt.Offset > pointCountInResult {
 			return
@@ -126,24 +173,18 @@ func (m *MapReduceJob) Execute(out chan *Row, filterEmptyResults bool) {
 
 	// If we are exceeding our MaxGroupByPoints and we aren't a raw query, error out
 	if pointCountInResult > MaxGroupByPoints {
-		out <- &Row{
+		m.send(out, &Row{
 			Err: errors.New("too many points in the group by interval. maybe you forgot to specify a where time clause?"),
-		}
+		})
 		return
 	}
 
 	// initialize the times of the aggregate points
 	resultValues := make([][]interface{}, pointCountInResult)
 
-	// ensure that the start time for the results is on the start of the window
-	startTimeBucket := m.TMin
-	if m.interval > 0 {
-		startTimeBucket = startTimeBucket / m.interval * m.interval
-	}
-
 	for i, _ := range resultValues {
 		var t int64
-		if m.stmt.Offset > 0 {
+		if m.stmt.Offset > 0 && !descending {
 			t = startTimeBucket + (int64(i+1) * m.interval * int64(m.stmt.Offset))
 		} else {
 			t = startTimeBucket + (int64(i+1) * m.interval) - m.interval
@@ -162,34 +203,35 @@ func (m *MapReduceJob) Execute(out chan *Row, filterEmptyResults bool) {
 
 	// This just makes sure that if they specify a start time less than what the start time would be with the offset,
 	// we just reset the start time to the later time to avoid going over data that won't show up in the result.
-	if m.stmt.Offset > 0 {
+	if m.stmt.Offset > 0 && !descending {
 		m.TMin = resultValues[0][0].(time.Time).UnixNano()
 	}
 
 	// now loop through the aggregate functions and populate everything
 	for i, c := range aggregates {
 		if err := m.processAggregate(c, reduceFuncs[i], resultValues); err != nil {
-			out <- &Row{
+			m.send(out, &Row{
 				Name: m.MeasurementName,
 				Tags: m.TagSet.Tags,
 				Err:  err,
-			}
+			})
 
 			return
 		}
 	}
 
+	// the values are incomplete if the query was canceled
+	if m.canceled() {
+		return
+	}
+
 	// filter out empty results
 	if filterEmptyResults && m.resultsEmpty(resultValues) {
 		return
 	}
 
 	// put together the row to return
-	columnNames := make([]string, len(m.stmt.Fields)+1)
-	columnNames[0] = "time"
-	for i, f := range m.stmt.Fields {
-		columnNames[i+1] = f.Name()
-	}
+	columnNames := m.stmt.ColumnNames()
 
 	// processes the result values if there's any math in there
 	resultValues = m.processResults(resultValues)
@@ -200,6 +242,17 @@ func (m *MapReduceJob) Execute(out chan *Row, filterEmptyResults bool) {
 	// process derivatives
 	resultValues = m.processDerivative(resultValues)
 
+	// process holt_winters forecasts
+	resultValues = m.processHoltWinters(resultValues)
+
+	// process transformations
+	resultValues = m.processTransform(resultValues)
+
+	// return the latest values first if requested
+	if descending {
+		reverseValues(resultValues)
+	}
+
 	row := &Row{
 		Name:    m.MeasurementName,
 		Tags:    m.TagSet.Tags,
@@ -208,7 +261,7 @@ func (m *MapReduceJob) Execute(out chan *Row, filterEmptyResults bool) {
 	}
 
 	// and we out
-	out <- row
+	m.send(out, row)
 }
 
 // processRawQuery will handle running the mappers and then reducing their output
@@ -217,7 +270,7 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 	// initialize the mappers
 	for _, mm := range m.Mappers {
 		if err := mm.Begin(nil, m.TMin, m.chunkSize); err != nil {
-			out <- &Row{Err: err}
+			m.send(out, &Row{Err: err})
 			return
 		}
 	}
@@ -232,9 +285,35 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 	valuesOffset := 0
 	valuesToReturn := make([]*rawQueryMapOutput, 0)
 
+	// The mappers of descending raw queries return the latest values first so
+	// the limit, offset and chunks are applied as the values are read.
+	// Derivatives and transformations are computed over the values in time
+	// order, so they need every value before the latest can be returned first
+	// and the limit and offset are applied once the values are reversed.
+	descending := m.stmt.IsRawQuery && m.stmt.IsDescending()
+	limit, offset, chunkSize := m.stmt.Limit, m.stmt.Offset, m.chunkSize
+	if m.stmt.IsDescending() && !descending {
+		limit, offset, chunkSize = 0, 0, math.MaxInt32
+	}
+
+	// after returns true if a value at time t is read after one at time u.
+	after := func(t, u int64) bool {
+		if descending {
+			return t < u
+		}
+		return t > u
+	}
+
 	var lastValueFromPreviousChunk *rawQueryMapOutput
+
+	// transformations carry their state over chunks
+	t := newTransformer(m.stmt)
 	// loop until we've emptied out all the mappers and sent everything out
 	for {
+		if m.canceled() {
+			return
+		}
+
 		// collect up to the limit for each mapper
 		for j, mm := range m.Mappers {
 			// only pull from mappers that potentially have more data and whose last output has been completely sent out.
@@ -244,7 +323,7 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 
 			res, err := mm.NextInterval()
 			if err != nil {
-				out <- &Row{Err: err}
+				m.send(out, &Row{Err: err})
 				return
 			}
 			if res != nil {
@@ -254,28 +333,30 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 			}
 		}
 
-		// process the mapper outputs. we can send out everything up to the min of the last time in the mappers
-		min := int64(math.MaxInt64)
+		// process the mapper outputs. we can send out everything up to the last time every mapper has read
+		last := int64(math.MaxInt64)
+		if descending {
+			last = math.MinInt64
+		}
 		for _, o := range mapperOutputs {
 			// some of the mappers could empty out before others so ignore them because they'll be nil
 			if o == nil {
 				continue
 			}
 
-			// find the min of the last point in each mapper
-			t := o[len(o)-1].Time
-			if t < min {
-				min = t
+			// of the last points of the mappers, find the one that is read first
+			if t := o[len(o)-1].Time; after(last, t) {
+				last = t
 			}
 		}
 
-		// now empty out all the mapper outputs up to the min time
+		// now empty out all the mapper outputs up to the last time
 		var values []*rawQueryMapOutput
 		for j, o := range mapperOutputs {
-			// find the index of the point up to the min
+			// find the index of the point up to the last time
 			ind := len(o)
 			for i, mo := range o {
-				if mo.Time > min {
+				if after(mo.Time, last) {
 					ind = i
 					break
 				}
@@ -299,11 +380,15 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 		}
 
 		// sort the values by time first so we can then handle offset and limit
-		sort.Sort(rawOutputs(values))
+		if descending {
+			sort.Sort(sort.Reverse(rawOutputs(values)))
+		} else {
+			sort.Sort(rawOutputs(values))
+		}
 
 		// get rid of any points that need to be offset
-		if valuesOffset < m.stmt.Offset {
-			offset := m.stmt.Offset - valuesOffset
+		if valuesOffset < offset {
+			offset := offset - valuesOffset
 
 			// if offset is bigger than the number of values we have, move to the next batch from the mappers
 			if offset > len(values) {
@@ -316,8 +401,8 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 		}
 
 		// ensure we don't send more than the limit
-		if valuesSent < m.stmt.Limit {
-			limit := m.stmt.Limit - valuesSent
+		if valuesSent < limit {
+			limit := limit - valuesSent
 			if len(values) > limit {
 				values = values[:limit]
 			}
@@ -328,27 +413,36 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 
 		// hit the chunk size? Send out what has been accumulated, but keep
 		// processing.
-		if len(valuesToReturn) >= m.chunkSize {
-			lastValueFromPreviousChunk = valuesToReturn[len(valuesToReturn)-1]
-
+		if len(valuesToReturn) >= chunkSize {
+			// the last raw value is where the next chunk's derivative starts from
+			last := valuesToReturn[len(valuesToReturn)-1]
 			valuesToReturn = m.processRawQueryDerivative(lastValueFromPreviousChunk, valuesToReturn)
-
-			row := m.processRawResults(valuesToReturn)
-			// perform post-processing, such as math.
-			row.Values = m.processResults(row.Values)
-			out <- row
+			lastValueFromPreviousChunk = last
+			valuesToReturn = t.processRaw(valuesToReturn)
+
+			// transformations may not emit anything until they have seen enough values
+			if len(valuesToReturn) > 0 {
+				row := m.processRawResults(valuesToReturn)
+				// perform post-processing, such as math.
+				row.Values = m.processResults(row.Values)
+				if !m.send(out, row) {
+					return
+				}
+			}
 			valuesToReturn = make([]*rawQueryMapOutput, 0)
 		}
 
 		// stop processing if we've hit the limit
-		if m.stmt.Limit != 0 && valuesSent >= m.stmt.Limit {
+		if limit != 0 && valuesSent >= limit {
 			break
 		}
 	}
 
+	valuesToReturn = append(t.processRaw(valuesToReturn), t.flush()...)
+
 	if len(valuesToReturn) == 0 {
 		if !filterEmptyResults {
-			out <- m.processRawResults(nil)
+			m.send(out, m.processRawResults(nil))
 		}
 	} else {
 		valuesToReturn = m.processRawQueryDerivative(lastValueFromPreviousChunk, valuesToReturn)
@@ -356,7 +450,33 @@ func (m *MapReduceJob) processRawQuery(out chan *Row, filterEmptyResults bool) {
 		row := m.processRawResults(valuesToReturn)
 		// perform post-processing, such as math.
 		row.Values = m.processResults(row.Values)
-		out <- row
+		if m.stmt.IsDescending() && !descending {
+			row.Values = m.processDescending(row.Values)
+		}
+		m.send(out, row)
+	}
+}
+
+// processDescending returns the values latest first with the statement's offset
+// and limit applied.
+func (m *MapReduceJob) processDescending(values [][]interface{}) [][]interface{} {
+	reverseValues(values)
+
+	if m.stmt.Offset >= len(values) {
+		return nil
+	}
+	values = values[m.stmt.Offset:]
+
+	if m.stmt.Limit > 0 && m.stmt.Limit < len(values) {
+		values = values[:m.stmt.Limit]
+	}
+	return values
+}
+
+// reverseValues reverses the order of values in place.
+func reverseValues(values [][]interface{}) {
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
 	}
 }
 
@@ -365,8 +485,8 @@ func (m *MapReduceJob) derivativeInterval() time.Duration {
 	if len(m.stmt.FunctionCalls()[0].Args) == 2 {
 		return m.stmt.FunctionCalls()[0].Args[1].(*DurationLiteral).Val
 	}
-	if m.stmt.groupByInterval > 0 {
-		return m.stmt.groupByInterval
+	if interval, _ := m.stmt.GroupByInterval(); interval > 0 {
+		return interval
 	}
 	return time.Second
 }
@@ -385,9 +505,9 @@ func (m *MapReduceJob) processRawQueryDerivative(lastValueFromPreviousChunk *raw
 		return valuesToReturn
 	}
 
-	// If we only have 1 value, then the value did not change, so return
-	// a single row with 0.0
-	if len(valuesToReturn) == 1 {
+	// If we only have 1 value and nothing before it, then the value did not
+	// change, so return a single row with 0.0
+	if len(valuesToReturn) == 1 && lastValueFromPreviousChunk == nil {
 		return []*rawQueryMapOutput{
 			&rawQueryMapOutput{
 				Time:   valuesToReturn[0].Time,
@@ -396,15 +516,18 @@ func (m *MapReduceJob) processRawQueryDerivative(lastValueFromPreviousChunk *raw
 		}
 	}
 
+	// The first value of the first chunk has nothing to be compared with
+	start := 0
 	if lastValueFromPreviousChunk == nil {
 		lastValueFromPreviousChunk = valuesToReturn[0]
+		start = 1
 	}
 
 	// Determines whether to drop negative differences
 	isNonNegative := m.isNonNegativeDerivative()
 
 	derivativeValues := []*rawQueryMapOutput{}
-	for i := 1; i < len(valuesToReturn); i++ {
+	for i := start; i < len(valuesToReturn); i++ {
 		v := valuesToReturn[i]
 
 		// Calculate the derivative of successive points by dividing the difference
@@ -491,6 +614,229 @@ func (m *MapReduceJob) processDerivative(results [][]interface{}) [][]interface{
 	return derivatives
 }
 
+// processHoltWinters returns the values forecasted from the results by the one
+// (and only) holt_winters function. holt_winters_with_fit also returns the
+// values fitted to the results.
+func (m *MapReduceJob) processHoltWinters(results [][]interface{}) [][]interface{} {
+	// Return early if we're not supposed to forecast
+	if !m.stmt.HasHoltWinters() {
+		return results
+	}
+
+	c := m.stmt.FunctionCalls()[0]
+	n := int(c.Args[1].(*IntegerLiteral).Val)
+	season := int(c.Args[2].(*IntegerLiteral).Val)
+
+	// Empty intervals are skipped when fitting the model
+	var times []time.Time
+	var values []float64
+	for _, r := range results {
+		if r[1] == nil {
+			continue
+		}
+		times = append(times, r[0].(time.Time))
+		values = append(values, i64tof64(r[1]))
+	}
+
+	// A trend can't be determined from less than two values
+	if len(values) < 2 {
+		return nil
+	}
+
+	fitted, forecast := HoltWinters(values, n, season)
+
+	var forecasts [][]interface{}
+	if c.Name == "holt_winters_with_fit" {
+		for i, v := range fitted {
+			forecasts = append(forecasts, []interface{}{times[i], v})
+		}
+	}
+
+	// Forecasted points continue at the group by interval after the last result
+	last := times[len(times)-1]
+	for i, v := range forecast {
+		t := last.Add(time.Duration(int64(i+1) * m.interval))
+		forecasts = append(forecasts, []interface{}{t, v})
+	}
+
+	return forecasts
+}
+
+// processTransform returns the results transformed by the one (and only)
+// moving_average, cumulative_sum, difference or elapsed function.
+func (m *MapReduceJob) processTransform(results [][]interface{}) [][]interface{} {
+	t := newTransformer(m.stmt)
+	if t == nil {
+		return results
+	}
+
+	transformed := [][]interface{}{}
+	for _, r := range results {
+		// Empty intervals are skipped
+		if r[1] == nil {
+			continue
+		}
+		if v, ok := t.next(r[0].(time.Time).UnixNano(), r[1]); ok {
+			transformed = append(transformed, []interface{}{r[0], v})
+		}
+	}
+	return transformed
+}
+
+// transformer applies a moving_average, cumulative_sum, difference, elapsed or
+// sample to a stream of values. State is kept between calls so raw values can be
+// transformed a chunk at a time.
+type transformer struct {
+	name string
+
+	// moving_average window
+	window []float64
+	pos    int
+	n      int
+
+	// previous value for difference, running total for cumulative_sum
+	prev interface{}
+	sum  interface{}
+
+	// elapsed unit and time of the previous value
+	unit     int64
+	prevTime int64
+	seen     bool
+
+	// sample reservoir and the number of values offered to it
+	reservoir []*rawQueryMapOutput
+	count     int64
+	rand      *rand.Rand
+}
+
+// newTransformer returns a transformer for the statement's transformation.
+// Returns nil if the statement has no transformation.
+func newTransformer(stmt *SelectStatement) *transformer {
+	if !stmt.HasTransform() {
+		return nil
+	}
+
+	c := stmt.FunctionCalls()[0]
+	t := &transformer{name: c.Name}
+	if c.Name == "moving_average" {
+		t.window = make([]float64, c.Args[1].(*IntegerLiteral).Val)
+	} else if c.Name == "sample" {
+		t.reservoir = make([]*rawQueryMapOutput, 0, c.Args[1].(*IntegerLiteral).Val)
+		t.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	} else if c.Name == "elapsed" {
+		t.unit = int64(time.Nanosecond)
+		if len(c.Args) == 2 {
+			t.unit = int64(c.Args[1].(*DurationLiteral).Val)
+		}
+	}
+	return t
+}
+
+// next adds v, with a timestamp of ts, to the stream and returns the
+// transformed value. Returns false if no value is emitted for v.
+func (t *transformer) next(ts int64, v interface{}) (interface{}, bool) {
+	switch t.name {
+	case "moving_average":
+		t.window[t.pos] = i64tof64(v)
+		t.pos = (t.pos + 1) % len(t.window)
+		if t.n < len(t.window) {
+			t.n++
+		}
+		if t.n < len(t.window) {
+			return nil, false
+		}
+
+		var sum float64
+		for _, f := range t.window {
+			sum += f
+		}
+		return sum / float64(len(t.window)), true
+	case "cumulative_sum":
+		if t.sum == nil {
+			t.sum = v
+		} else {
+			t.sum = addValues(t.sum, v)
+		}
+		return t.sum, true
+	case "difference":
+		prev := t.prev
+		t.prev = v
+		if prev == nil {
+			return nil, false
+		}
+		return subValues(v, prev), true
+	case "elapsed":
+		prevTime, seen := t.prevTime, t.seen
+		t.prevTime, t.seen = ts, true
+		if !seen {
+			return nil, false
+		}
+		return (ts - prevTime) / t.unit, true
+	case "sample":
+		// Reservoir sampling keeps each value offered with equal probability.
+		// Sampled values are only emitted when the transformer is flushed.
+		t.count++
+		o := &rawQueryMapOutput{Time: ts, Values: v}
+		if len(t.reservoir) < cap(t.reservoir) {
+			t.reservoir = append(t.reservoir, o)
+		} else if i := t.rand.Int63n(t.count); i < int64(len(t.reservoir)) {
+			t.reservoir[i] = o
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// processRaw transforms raw values. A nil transformer returns the values unchanged.
+func (t *transformer) processRaw(values []*rawQueryMapOutput) []*rawQueryMapOutput {
+	if t == nil {
+		return values
+	}
+
+	transformed := make([]*rawQueryMapOutput, 0, len(values))
+	for _, o := range values {
+		if v, ok := t.next(o.Time, o.Values); ok {
+			transformed = append(transformed, &rawQueryMapOutput{Time: o.Time, Values: v})
+		}
+	}
+	return transformed
+}
+
+// flush returns the values held back until the end of the stream. A nil
+// transformer returns no values.
+func (t *transformer) flush() []*rawQueryMapOutput {
+	if t == nil || t.name != "sample" {
+		return nil
+	}
+
+	values := t.reservoir
+	sort.Sort(rawOutputs(values))
+	t.reservoir = make([]*rawQueryMapOutput, 0, cap(values))
+	return values
+}
+
+// addValues returns the sum of a and b. Integers are kept as integers unless
+// mixed with floats.
+func addValues(a, b interface{}) interface{} {
+	if ai, ok := a.(int64); ok {
+		if bi, ok := b.(int64); ok {
+			return ai + bi
+		}
+	}
+	return i64tof64(a) + i64tof64(b)
+}
+
+// subValues returns the difference of a and b. Integers are kept as integers
+// unless mixed with floats.
+func subValues(a, b interface{}) interface{} {
+	if ai, ok := a.(int64); ok {
+		if bi, ok := b.(int64); ok {
+			return ai - bi
+		}
+	}
+	return i64tof64(a) - i64tof64(b)
+}
+
 // processsResults will apply any math that was specified in the select statement against the passed in results
 func (m *MapReduceJob) processResults(results [][]interface{}) [][]interface{} {
 	hasMath := false
@@ -499,6 +845,8 @@ func (m *MapReduceJob) processResults(results [][]interface{}) [][]interface{} {
 			hasMath = true
 		} else if _, ok := f.Expr.(*ParenExpr); ok {
 			hasMath = true
+		} else if _, ok := f.Expr.(*UnaryExpr); ok {
+			hasMath = true
 		}
 	}
 
@@ -581,6 +929,11 @@ func getProcessor(expr Expr, startIndex int) (processor, int) {
 		return getBinaryProcessor(expr, startIndex)
 	case *ParenExpr:
 		return getProcessor(expr.Expr, startIndex)
+	case *UnaryExpr:
+		return getUnaryProcessor(expr, startIndex)
+	case *IntegerLiteral:
+		// Aggregate results are floats so integer literals are promoted.
+		return newLiteralProcessor(float64(expr.Val)), startIndex
 	case *NumberLiteral:
 		return newLiteralProcessor(expr.Val), startIndex
 	case *StringLiteral:
@@ -616,66 +969,63 @@ func getBinaryProcessor(expr *BinaryExpr, startIndex int) (processor, int) {
 	return newBinaryExprEvaluator(expr.Op, lhs, rhs), index
 }
 
-func newBinaryExprEvaluator(op Token, lhs, rhs processor) processor {
-	switch op {
-	case ADD:
-		return func(values []interface{}) interface{} {
-			l := lhs(values)
-			r := rhs(values)
-			if lv, ok := l.(float64); ok {
-				if rv, ok := r.(float64); ok {
-					if rv != 0 {
-						return lv + rv
-					}
-				}
-			}
+func getUnaryProcessor(expr *UnaryExpr, startIndex int) (processor, int) {
+	p, index := getProcessor(expr.Expr, startIndex)
+	return func(values []interface{}) interface{} {
+		// Negating an empty interval is still empty.
+		v, ok := toFloat64(p(values))
+		if !ok || expr.Op != SUB {
 			return nil
 		}
-	case SUB:
-		return func(values []interface{}) interface{} {
-			l := lhs(values)
-			r := rhs(values)
-			if lv, ok := l.(float64); ok {
-				if rv, ok := r.(float64); ok {
-					if rv != 0 {
-						return lv - rv
-					}
-				}
-			}
+		return -v
+	}, index
+}
+
+func newBinaryExprEvaluator(op Token, lhs, rhs processor) processor {
+	return func(values []interface{}) interface{} {
+		// Operands are nil for empty intervals, so the result is too
+		lv, ok := toFloat64(lhs(values))
+		if !ok {
 			return nil
 		}
-	case MUL:
-		return func(values []interface{}) interface{} {
-			l := lhs(values)
-			r := rhs(values)
-			if lv, ok := l.(float64); ok {
-				if rv, ok := r.(float64); ok {
-					if rv != 0 {
-						return lv * rv
-					}
-				}
-			}
+		rv, ok := toFloat64(rhs(values))
+		if !ok {
 			return nil
 		}
-	case DIV:
-		return func(values []interface{}) interface{} {
-			l := lhs(values)
-			r := rhs(values)
-			if lv, ok := l.(float64); ok {
-				if rv, ok := r.(float64); ok {
-					if rv != 0 {
-						return lv / rv
-					}
-				}
+
+		switch op {
+		case ADD:
+			return lv + rv
+		case SUB:
+			return lv - rv
+		case MUL:
+			return lv * rv
+		case DIV:
+			if rv == 0 {
+				return nil
 			}
-			return nil
+			return lv / rv
+		case MOD:
+			if rv == 0 {
+				return nil
+			}
+			return math.Mod(lv, rv)
 		}
-	default:
+
 		// we shouldn't get here, but give them back nils if it goes this way
-		return func(values []interface{}) interface{} {
-			return nil
-		}
+		return nil
+	}
+}
+
+// toFloat64 returns v as a float64. Returns false if v isn't numeric.
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
 	}
+	return 0, false
 }
 
 // resultsEmpty will return true if the all the result values are empty or contain only nulls
@@ -775,6 +1125,10 @@ func (m *MapReduceJob) processAggregate(c *Call, reduceFunc ReduceFunc, resultVa
 
 	// populate the result values for each interval of time
 	for i, _ := range resultValues {
+		if m.canceled() {
+			return nil
+		}
+
 		// collect the results from each mapper
 		for j, mm := range m.Mappers {
 			res, err := mm.NextInterval()
@@ -849,7 +1203,17 @@ func (p *Planner) Plan(stmt *SelectStatement, chunkSize int) (*Executor, error)
 	now := p.Now().UTC()
 
 	// Replace instances of "now()" with the current time.
-	stmt.Condition = Reduce(stmt.Condition, &NowValuer{Now: now})
+	stmt = stmt.Reduce(&NowValuer{Now: now})
+
+	// Reject time bounds that can't be satisfied.
+	if _, _, _, _, err := TimeRangeAsEpoch(stmt.Condition); err != nil {
+		return nil, err
+	}
+
+	// Don't read any data if the condition can't match a point.
+	if ConditionTruth(stmt.Condition) == AlwaysFalse {
+		return &Executor{stmt: stmt}, nil
+	}
 
 	// Begin an unopened transaction.
 	tx, err := p.DB.Begin()
@@ -871,14 +1235,15 @@ func (p *Planner) Plan(stmt *SelectStatement, chunkSize int) (*Executor, error)
 
 	// LIMIT and OFFSET the unique series
 	if stmt.SLimit > 0 || stmt.SOffset > 0 {
-		if stmt.SOffset > len(jobs) {
+		if stmt.SOffset >= len(jobs) {
 			jobs = nil
 		} else {
-			if stmt.SOffset+stmt.SLimit > len(jobs) {
-				stmt.SLimit = len(jobs) - stmt.SOffset
+			end := len(jobs)
+			if stmt.SLimit > 0 && stmt.SOffset+stmt.SLimit < end {
+				end = stmt.SOffset + stmt.SLimit
 			}
 
-			jobs = jobs[stmt.SOffset : stmt.SOffset+stmt.SLimit]
+			jobs = jobs[stmt.SOffset:end]
 		}
 	}
 
@@ -901,9 +1266,14 @@ type Executor struct {
 }
 
 // Execute begins execution of the query and returns a channel to receive rows.
-func (e *Executor) Execute() <-chan *Row {
+// If closing is closed, execution stops, the jobs release their mappers and the
+// channel is closed without sending the remaining rows.
+func (e *Executor) Execute(closing <-chan struct{}) <-chan *Row {
 	// Create output channel and stream data in a separate goroutine.
 	out := make(chan *Row, 0)
+	for _, j := range e.jobs {
+		j.closing = closing
+	}
 	go e.execute(out)
 
 	return out
@@ -925,6 +1295,9 @@ func (e *Executor) execute(out chan *Row) {
 
 	// Execute each MRJob serially
 	for _, j := range e.jobs {
+		if j.canceled() {
+			break
+		}
 		j.Execute(out, filterEmptyResults)
 	}
 
@@ -972,6 +1345,25 @@ func (r *Row) tagsKeys() []string {
 	return a
 }
 
+// Chunk splits a row into rows of the same series holding up to size values
+// each. The row is returned as is if size is zero.
+func (r *Row) Chunk(size int) []*Row {
+	if size <= 0 || len(r.Values) <= size {
+		return []*Row{r}
+	}
+
+	rows := make([]*Row, 0, (len(r.Values)+size-1)/size)
+	for values := r.Values; len(values) > 0; {
+		n := size
+		if n > len(values) {
+			n = len(values)
+		}
+		rows = append(rows, &Row{Name: r.Name, Tags: r.Tags, Columns: r.Columns, Values: values[:n]})
+		values = values[n:]
+	}
+	return rows
+}
+
 // Rows represents a list of rows that can be sorted consistently by name/tag.
 type Rows []*Row
 
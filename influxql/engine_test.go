@@ -3,6 +3,7 @@ package influxql
 import (
 	"fmt"
 	"math"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -452,3 +453,357 @@ func TestProcessRawQueryDerivative(t *testing.T) {
 		}
 	}
 }
+
+// TestProcessRawQueryDerivative_Chunks ensures the derivative continues from
+// the last value of the previous chunk.
+func TestProcessRawQueryDerivative_Chunks(t *testing.T) {
+	m := derivativeJob(t, "derivative", "1s")
+	prev := &rawQueryMapOutput{Time: 0, Values: 1.0}
+
+	// A single value is compared with the previous chunk.
+	got := m.processRawQueryDerivative(prev, []*rawQueryMapOutput{{Time: int64(2 * time.Second), Values: 5.0}})
+	exp := []*rawQueryMapOutput{{Time: int64(2 * time.Second), Values: 2.0}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+
+	// Every value of a later chunk produces a derivative.
+	got = m.processRawQueryDerivative(prev, []*rawQueryMapOutput{
+		{Time: int64(time.Second), Values: 2.0},
+		{Time: int64(3 * time.Second), Values: 8.0},
+	})
+	exp = []*rawQueryMapOutput{
+		{Time: int64(time.Second), Values: 1.0},
+		{Time: int64(3 * time.Second), Values: 3.0},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+}
+
+func holtWintersJob(t *testing.T, fn string, n, season int) *MapReduceJob {
+	s := fmt.Sprintf("SELECT %s(mean(value), %d, %d) FROM foo WHERE time > now() - 1d GROUP BY time(1h)", fn, n, season)
+	q, err := ParseQuery(s)
+	if err != nil {
+		t.Fatalf("unable to parse query %q: %s", s, err)
+	}
+	return &MapReduceJob{
+		stmt:     q.Statements[0].(*SelectStatement),
+		interval: int64(time.Hour),
+	}
+}
+
+// TestProcessHoltWinters tests the processHoltWinters transformation function on the engine.
+func TestProcessHoltWinters(t *testing.T) {
+	// Build a series that increases linearly with a repeating pattern of 4 points.
+	pattern := []float64{0, 10, 0, -10}
+	var in [][]interface{}
+	for i := 0; i < 24; i++ {
+		in = append(in, []interface{}{time.Unix(0, 0).Add(time.Duration(i) * time.Hour), float64(i) + pattern[i%4]})
+	}
+
+	// Leading empty intervals are ignored.
+	in = append([][]interface{}{{time.Unix(0, 0).Add(-time.Hour), nil}}, in...)
+
+	// Forecasts should continue both the trend and the season.
+	m := holtWintersJob(t, "holt_winters", 4, 4)
+	got := m.processHoltWinters(in)
+	if len(got) != 4 {
+		t.Fatalf("unexpected number of forecasts: %d", len(got))
+	}
+	for i, row := range got {
+		if exp := time.Unix(0, 0).Add(time.Duration(24+i) * time.Hour); !row[0].(time.Time).Equal(exp) {
+			t.Errorf("%d. unexpected time: exp=%s got=%s", i, exp, row[0])
+		}
+		if exp := float64(24+i) + pattern[(24+i)%4]; math.Abs(row[1].(float64)-exp) > 1 {
+			t.Errorf("%d. unexpected forecast: exp=%v got=%v", i, exp, row[1])
+		}
+	}
+
+	// holt_winters_with_fit includes the fitted values for every non-empty interval.
+	m = holtWintersJob(t, "holt_winters_with_fit", 2, 4)
+	if got := m.processHoltWinters(in); len(got) != 24+2 {
+		t.Fatalf("unexpected number of values: %d", len(got))
+	}
+
+	// Nothing can be forecast from a single value.
+	if got := m.processHoltWinters(in[:1]); len(got) != 0 {
+		t.Fatalf("unexpected forecast: %v", got)
+	}
+}
+
+// TestHoltWinters_Linear ensures a non-seasonal model follows a linear trend.
+func TestHoltWinters_Linear(t *testing.T) {
+	fitted, forecast := HoltWinters([]float64{1, 3, 5, 7, 9}, 3, 0)
+	if len(fitted) != 5 {
+		t.Fatalf("unexpected fitted values: %v", fitted)
+	}
+	for i, v := range forecast {
+		if exp := float64(11 + 2*i); math.Abs(v-exp) > 1e-6 {
+			t.Errorf("%d. unexpected forecast: exp=%v got=%v", i, exp, v)
+		}
+	}
+}
+
+// TestProcessTransform tests the processTransform function on the engine.
+func TestProcessTransform(t *testing.T) {
+	tests := []struct {
+		fn  string
+		in  [][]interface{}
+		exp [][]interface{}
+	}{
+		{
+			fn: "moving_average(mean(value), 2)",
+			in: [][]interface{}{
+				{time.Unix(0, 0), 1.0},
+				{time.Unix(1, 0), 3.0},
+				{time.Unix(2, 0), nil},
+				{time.Unix(3, 0), 8.0},
+			},
+			exp: [][]interface{}{
+				{time.Unix(1, 0), 2.0},
+				{time.Unix(3, 0), 5.5},
+			},
+		},
+		{
+			fn: "cumulative_sum(sum(value))",
+			in: [][]interface{}{
+				{time.Unix(0, 0), int64(1)},
+				{time.Unix(1, 0), int64(2)},
+				{time.Unix(2, 0), int64(3)},
+			},
+			exp: [][]interface{}{
+				{time.Unix(0, 0), int64(1)},
+				{time.Unix(1, 0), int64(3)},
+				{time.Unix(2, 0), int64(6)},
+			},
+		},
+		{
+			fn: "difference(max(value))",
+			in: [][]interface{}{
+				{time.Unix(0, 0), 1.0},
+				{time.Unix(1, 0), 4.0},
+				{time.Unix(2, 0), 2.5},
+			},
+			exp: [][]interface{}{
+				{time.Unix(1, 0), 3.0},
+				{time.Unix(2, 0), -1.5},
+			},
+		},
+		{
+			fn:  "difference(max(value))",
+			in:  [][]interface{}{{time.Unix(0, 0), 1.0}},
+			exp: [][]interface{}{},
+		},
+	}
+
+	for i, test := range tests {
+		s := fmt.Sprintf("SELECT %s FROM foo WHERE time > now() - 1d GROUP BY time(1s)", test.fn)
+		q, err := ParseQuery(s)
+		if err != nil {
+			t.Fatalf("%d. unable to parse query %q: %s", i, s, err)
+		}
+		m := &MapReduceJob{stmt: q.Statements[0].(*SelectStatement)}
+
+		got := m.processTransform(test.in)
+		if !reflect.DeepEqual(got, test.exp) {
+			t.Errorf("%d. %s: unexpected results:\n\texp=%v\n\tgot=%v", i, test.fn, test.exp, got)
+		}
+	}
+}
+
+// TestTransformer_ProcessRaw ensures raw values are transformed across chunks.
+func TestTransformer_ProcessRaw(t *testing.T) {
+	q, err := ParseQuery(`SELECT moving_average(value, 3) FROM foo`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := newTransformer(q.Statements[0].(*SelectStatement))
+
+	// The window isn't full until the third value.
+	got := tr.processRaw([]*rawQueryMapOutput{{Time: 0, Values: 1.0}, {Time: 1, Values: int64(2)}})
+	if len(got) != 0 {
+		t.Fatalf("unexpected values: %v", got)
+	}
+
+	got = tr.processRaw([]*rawQueryMapOutput{{Time: 2, Values: 3.0}, {Time: 3, Values: 7.0}})
+	exp := []*rawQueryMapOutput{{Time: 2, Values: 2.0}, {Time: 3, Values: 4.0}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+}
+
+// TestTransformer_Elapsed ensures elapsed returns the time between consecutive
+// points in the requested unit.
+func TestTransformer_Elapsed(t *testing.T) {
+	q, err := ParseQuery(`SELECT elapsed(value, 1s) FROM foo`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := newTransformer(q.Statements[0].(*SelectStatement))
+
+	got := tr.processRaw([]*rawQueryMapOutput{
+		{Time: 0, Values: 1.0},
+		{Time: int64(2 * time.Second), Values: 3.0},
+	})
+	got = append(got, tr.processRaw([]*rawQueryMapOutput{{Time: int64(7 * time.Second), Values: 2.0}})...)
+	exp := []*rawQueryMapOutput{
+		{Time: int64(2 * time.Second), Values: int64(2)},
+		{Time: int64(7 * time.Second), Values: int64(5)},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+}
+
+// TestTransformer_Sample ensures sample returns N of the values in time order.
+func TestTransformer_Sample(t *testing.T) {
+	q, err := ParseQuery(`SELECT sample(value, 3) FROM foo`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := newTransformer(q.Statements[0].(*SelectStatement))
+
+	for i := 0; i < 10; i++ {
+		if got := tr.processRaw([]*rawQueryMapOutput{{Time: int64(i), Values: float64(i)}}); len(got) != 0 {
+			t.Fatalf("unexpected values before flush: %v", got)
+		}
+	}
+
+	got := tr.flush()
+	if len(got) != 3 {
+		t.Fatalf("unexpected number of values: %d", len(got))
+	}
+	for i, o := range got {
+		if o.Values != float64(o.Time) {
+			t.Errorf("%d. unexpected value: %v", i, o)
+		}
+		if i > 0 && got[i-1].Time >= o.Time {
+			t.Errorf("%d. values not in time order: %v", i, got)
+		}
+	}
+
+	// Series smaller than the sample size are returned in full.
+	tr = newTransformer(q.Statements[0].(*SelectStatement))
+	tr.processRaw([]*rawQueryMapOutput{{Time: 1, Values: 1.0}, {Time: 0, Values: 0.0}})
+	exp := []*rawQueryMapOutput{{Time: 0, Values: 0.0}, {Time: 1, Values: 1.0}}
+	if got := tr.flush(); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+}
+
+// TestProcessResults_Math ensures math between aggregates is applied to each interval.
+func TestProcessResults_Math(t *testing.T) {
+	q, err := ParseQuery(`SELECT mean(errors) / mean(requests), sum(errors) + sum(requests) FROM httpd WHERE time > now() - 1h GROUP BY time(1m)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &MapReduceJob{stmt: q.Statements[0].(*SelectStatement)}
+
+	// Columns hold mean(errors), mean(requests), sum(errors) and sum(requests).
+	got := m.processResults([][]interface{}{
+		{time.Unix(0, 0), 1.0, 4.0, int64(0), int64(3)},
+		{time.Unix(60, 0), 2.0, 0.0, int64(2), int64(0)},
+		{time.Unix(120, 0), nil, nil, nil, nil},
+	})
+	exp := [][]interface{}{
+		{time.Unix(0, 0), 0.25, 3.0},
+		{time.Unix(60, 0), nil, 2.0},
+		{time.Unix(120, 0), nil, nil},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected results:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+}
+
+// TestProcessDescending ensures values are reversed before the offset and limit are applied.
+func TestProcessDescending(t *testing.T) {
+	q, err := ParseQuery(`SELECT value FROM foo ORDER BY time DESC LIMIT 2 OFFSET 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &MapReduceJob{stmt: q.Statements[0].(*SelectStatement)}
+
+	got := m.processDescending([][]interface{}{{int64(0), 1.0}, {int64(1), 2.0}, {int64(2), 3.0}, {int64(3), 4.0}})
+	exp := [][]interface{}{{int64(2), 3.0}, {int64(1), 2.0}}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+
+	if got := m.processDescending([][]interface{}{{int64(0), 1.0}}); got != nil {
+		t.Fatalf("unexpected values: %v", got)
+	}
+}
+
+// TestProcessRawQuery_Descending ensures the latest values of the mappers are
+// merged first with the offset, limit and chunk size applied as they're read.
+func TestProcessRawQuery_Descending(t *testing.T) {
+	q, err := ParseQuery(`SELECT value FROM foo ORDER BY time DESC LIMIT 4 OFFSET 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &MapReduceJob{
+		TagSet: &TagSet{},
+		Mappers: []Mapper{
+			&testRawMapper{chunks: [][]*rawQueryMapOutput{{{Time: 8, Values: 8.0}, {Time: 6, Values: 6.0}}, {{Time: 4, Values: 4.0}, {Time: 2, Values: 2.0}}}},
+			&testRawMapper{chunks: [][]*rawQueryMapOutput{{{Time: 7, Values: 7.0}, {Time: 5, Values: 5.0}}, {{Time: 3, Values: 3.0}, {Time: 1, Values: 1.0}}}},
+		},
+		stmt:      q.Statements[0].(*SelectStatement),
+		chunkSize: 2,
+	}
+
+	out := make(chan *Row, 10)
+	m.processRawQuery(out, true)
+	close(out)
+
+	var got [][]interface{}
+	for row := range out {
+		var a []interface{}
+		for _, values := range row.Values {
+			a = append(a, values[1])
+		}
+		got = append(got, a)
+	}
+	if exp := [][]interface{}{{7.0, 6.0}, {5.0, 4.0}}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected values:\n\texp=%v\n\tgot=%v", exp, got)
+	}
+}
+
+// testRawMapper returns chunks of raw values.
+type testRawMapper struct {
+	chunks [][]*rawQueryMapOutput
+}
+
+func (m *testRawMapper) Open() error                   { return nil }
+func (m *testRawMapper) Close()                        {}
+func (m *testRawMapper) Begin(*Call, int64, int) error { return nil }
+
+func (m *testRawMapper) NextInterval() (interface{}, error) {
+	if len(m.chunks) == 0 {
+		return nil, nil
+	}
+	chunk := m.chunks[0]
+	m.chunks = m.chunks[1:]
+	return chunk, nil
+}
+
+// Ensure a row is split into rows of the same series with up to size values.
+func TestRow_Chunk(t *testing.T) {
+	row := &Row{Name: "cpu", Tags: map[string]string{"host": "serverA"}, Columns: []string{"time", "value"}, Values: [][]interface{}{{1}, {2}, {3}}}
+
+	if rows := row.Chunk(0); len(rows) != 1 || rows[0] != row {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	if rows := row.Chunk(3); len(rows) != 1 || rows[0] != row {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+
+	rows := row.Chunk(2)
+	if len(rows) != 2 {
+		t.Fatalf("unexpected row count: %d", len(rows))
+	} else if !reflect.DeepEqual(rows[0], &Row{Name: "cpu", Tags: row.Tags, Columns: row.Columns, Values: [][]interface{}{{1}, {2}}}) {
+		t.Fatalf("unexpected first row: %#v", rows[0])
+	} else if !reflect.DeepEqual(rows[1], &Row{Name: "cpu", Tags: row.Tags, Columns: row.Columns, Values: [][]interface{}{{3}}}) {
+		t.Fatalf("unexpected second row: %#v", rows[1])
+	}
+}
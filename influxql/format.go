@@ -0,0 +1,266 @@
+package influxql
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatOptions controls the layout of queries produced by Format.
+type FormatOptions struct {
+	// Indent is prepended to each line of a nested statement, such as the
+	// SELECT statement of a continuous query.
+	Indent string
+
+	// Width is the line length after which field, source and dimension
+	// lists are split one item per line and WHERE conditions are split on
+	// their top-level AND or OR operators. They are always split if zero.
+	Width int
+}
+
+// DefaultFormatOptions are the options used by Format when none are given.
+var DefaultFormatOptions = FormatOptions{
+	Indent: "  ",
+	Width:  80,
+}
+
+// Format returns a human readable representation of a node. Unlike String(),
+// each clause of a statement is written on its own line and long lists are
+// aligned under their first item. The output parses to the same statement.
+func Format(node Node, opts *FormatOptions) string {
+	if opts == nil {
+		opts = &DefaultFormatOptions
+	}
+	f := &formatter{opts: *opts}
+	return f.format(node)
+}
+
+// formatter builds the formatted representation of a node.
+type formatter struct {
+	opts FormatOptions
+}
+
+func (f *formatter) format(node Node) string {
+	switch n := node.(type) {
+	case *Query:
+		return f.format(n.Statements)
+	case Statements:
+		var str []string
+		for _, stmt := range n {
+			str = append(str, f.format(stmt))
+		}
+		return strings.Join(str, ";\n\n")
+	case *SelectStatement:
+		return f.formatSelectStatement(n)
+	case *CreateContinuousQueryStatement:
+		return f.formatCreateContinuousQueryStatement(n)
+	case *DeleteStatement:
+		return f.lines("DELETE FROM "+n.Source.String(), f.where(n.Condition))
+	case *BackfillContinuousQueryStatement:
+		return f.lines("BACKFILL CONTINUOUS QUERY "+QuoteIdent(n.Name)+" ON "+QuoteIdent(n.Database), f.where(n.Condition))
+	case *DropSeriesStatement:
+		return f.lines("DROP SERIES", f.sources(n.Sources), f.where(n.Condition))
+	case *ShowContinuousQueriesStatement:
+		head := "SHOW CONTINUOUS QUERIES"
+		if n.Database != "" {
+			head += " ON " + QuoteIdent(n.Database)
+		}
+		return f.lines(head, f.where(n.Condition), f.limit("LIMIT", n.Limit), f.limit("OFFSET", n.Offset))
+	case *ShowFieldKeysStatement:
+		return f.lines("SHOW FIELD KEYS", f.sources(n.Sources), f.sortFields(n.SortFields), f.limit("LIMIT", n.Limit), f.limit("OFFSET", n.Offset))
+	case *ShowMeasurementsStatement:
+		return f.lines("SHOW MEASUREMENTS", f.where(n.Condition), f.sortFields(n.SortFields), f.limit("LIMIT", n.Limit), f.limit("OFFSET", n.Offset))
+	case *ShowSeriesStatement:
+		return f.lines("SHOW SERIES", f.sources(n.Sources), f.where(n.Condition), f.sortFields(n.SortFields), f.limit("LIMIT", n.Limit), f.limit("OFFSET", n.Offset))
+	case *ShowTagKeysStatement:
+		return f.lines("SHOW TAG KEYS", f.sources(n.Sources), f.where(n.Condition), f.sortFields(n.SortFields), f.limit("LIMIT", n.Limit), f.limit("OFFSET", n.Offset))
+	case *ShowTagValuesStatement:
+		return f.lines("SHOW TAG VALUES", f.sources(n.Sources), formatTagKeys(n.TagKeys), f.where(n.Condition), f.sortFields(n.SortFields), f.limit("LIMIT", n.Limit), f.limit("OFFSET", n.Offset))
+	case nil:
+		return ""
+	default:
+		return node.String()
+	}
+}
+
+func (f *formatter) formatSelectStatement(s *SelectStatement) string {
+	fields := make([]string, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		fields = append(fields, field.String())
+	}
+
+	var target, dimensions, fill string
+	if s.Target != nil {
+		target = s.Target.String()
+	}
+	if len(s.Dimensions) > 0 {
+		var str []string
+		for _, d := range s.Dimensions {
+			str = append(str, d.String())
+		}
+		dimensions = f.list("GROUP BY", str)
+	}
+	switch s.Fill {
+	case NoFill:
+		fill = "fill(none)"
+	case NumberFill:
+		fill = fmt.Sprintf("fill(%v)", s.FillValue)
+	case PreviousFill:
+		fill = "fill(previous)"
+	}
+
+	return f.lines(
+		f.list("SELECT", fields),
+		target,
+		f.sources(s.Sources),
+		f.where(s.Condition),
+		dimensions,
+		fill,
+		f.sortFields(s.SortFields),
+		f.limit("LIMIT", s.Limit),
+		f.limit("OFFSET", s.Offset),
+		f.limit("SLIMIT", s.SLimit),
+		f.limit("SOFFSET", s.SOffset),
+	)
+}
+
+func (f *formatter) formatCreateContinuousQueryStatement(s *CreateContinuousQueryStatement) string {
+	var resample, backfill string
+	if s.ResampleEvery > 0 || s.ResampleFor > 0 {
+		resample = "RESAMPLE"
+		if s.ResampleEvery > 0 {
+			resample += " EVERY " + FormatDuration(s.ResampleEvery)
+		}
+		if s.ResampleFor > 0 {
+			resample += " FOR " + FormatDuration(s.ResampleFor)
+		}
+	}
+	if s.Backfill {
+		backfill = "BACKFILL"
+	}
+
+	// Indent each line of the source statement between BEGIN and END.
+	source := strings.Split(f.format(s.Source), "\n")
+	for i := range source {
+		source[i] = f.opts.Indent + source[i]
+	}
+
+	return f.lines(
+		fmt.Sprintf("CREATE CONTINUOUS QUERY %s ON %s", QuoteIdent(s.Name), QuoteIdent(s.Database)),
+		resample,
+		backfill,
+		"BEGIN",
+		strings.Join(source, "\n"),
+		"END",
+	)
+}
+
+// lines joins the non-blank clauses of a statement with newlines.
+func (f *formatter) lines(clauses ...string) string {
+	var buf bytes.Buffer
+	for _, c := range clauses {
+		if c == "" {
+			continue
+		}
+		if buf.Len() > 0 {
+			_ = buf.WriteByte('\n')
+		}
+		_, _ = buf.WriteString(c)
+	}
+	return buf.String()
+}
+
+// list returns a clause with a comma separated list of items. The list is
+// split one item per line, aligned under the first item, if it's too long.
+func (f *formatter) list(keyword string, items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	line := keyword + " " + strings.Join(items, ", ")
+	if len(items) == 1 || (f.opts.Width > 0 && len(line) <= f.opts.Width) {
+		return line
+	}
+	return keyword + " " + strings.Join(items, ",\n"+strings.Repeat(" ", len(keyword)+1))
+}
+
+// sources returns the FROM clause of a statement.
+func (f *formatter) sources(a Sources) string {
+	var str []string
+	for _, src := range a {
+		str = append(str, src.String())
+	}
+	return f.list("FROM", str)
+}
+
+// sortFields returns the ORDER BY clause of a statement.
+func (f *formatter) sortFields(a SortFields) string {
+	var str []string
+	for _, field := range a {
+		str = append(str, field.String())
+	}
+	return f.list("ORDER BY", str)
+}
+
+// where returns the WHERE clause of a statement. If the condition is too long
+// then each operand of a top-level chain of AND or OR operators is written on
+// its own line with the operator aligned to the end of WHERE.
+func (f *formatter) where(expr Expr) string {
+	if expr == nil {
+		return ""
+	}
+
+	line := "WHERE " + expr.String()
+	if f.opts.Width > 0 && len(line) <= f.opts.Width {
+		return line
+	}
+
+	e, ok := expr.(*BinaryExpr)
+	if !ok || (e.Op != AND && e.Op != OR) {
+		return line
+	}
+
+	operands := flattenBinaryExpr(e, e.Op)
+
+	var buf bytes.Buffer
+	_, _ = buf.WriteString("WHERE ")
+	_, _ = buf.WriteString(operands[0].String())
+	for _, operand := range operands[1:] {
+		_, _ = fmt.Fprintf(&buf, "\n%5s %s", e.Op.String(), operand.String())
+	}
+	return buf.String()
+}
+
+// limit returns a LIMIT or OFFSET style clause if n is set.
+func (f *formatter) limit(keyword string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return keyword + " " + strconv.Itoa(n)
+}
+
+// flattenBinaryExpr returns the operands of a left-associative chain of
+// binary expressions using the same operator.
+func flattenBinaryExpr(e *BinaryExpr, op Token) []Expr {
+	if lhs, ok := e.LHS.(*BinaryExpr); ok && lhs.Op == op {
+		return append(flattenBinaryExpr(lhs, op), e.RHS)
+	}
+	return []Expr{e.LHS, e.RHS}
+}
+
+// formatTagKeys returns the WITH KEY clause of a SHOW TAG VALUES statement.
+func formatTagKeys(keys []string) string {
+	switch len(keys) {
+	case 0:
+		return ""
+	case 1:
+		return "WITH KEY = " + QuoteIdent(keys[0])
+	}
+
+	var str []string
+	for _, k := range keys {
+		str = append(str, QuoteIdent(k))
+	}
+	return "WITH KEY IN (" + strings.Join(str, ", ") + ")"
+}
@@ -0,0 +1,108 @@
+package influxql_test
+
+import (
+	"testing"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Ensure statements can be formatted and parsed back.
+func TestFormat(t *testing.T) {
+	var tests = []struct {
+		s    string
+		opts *influxql.FormatOptions
+		exp  string
+	}{
+		{
+			s: `SELECT mean(value) AS m, max(value) FROM cpu WHERE host = 'serverA' AND time > now() - 1h GROUP BY time(10m), region fill(0) ORDER BY time DESC LIMIT 10`,
+			exp: `SELECT mean(value) AS m, max(value)
+FROM cpu
+WHERE host = 'serverA' AND time > now() - 1h
+GROUP BY time(10m), region
+fill(0)
+ORDER BY time DESC
+LIMIT 10`,
+		},
+		{
+			s:    `SELECT mean(value) AS m, max(value) INTO db0.rp0.:MEASUREMENT FROM cpu, /mem.*/ WHERE host = 'serverA' AND region = 'uswest' OR time > now() - 1h GROUP BY time(10m), region SLIMIT 2 SOFFSET 1`,
+			opts: &influxql.FormatOptions{},
+			exp: `SELECT mean(value) AS m,
+       max(value)
+INTO "db0"."rp0".:MEASUREMENT
+FROM cpu,
+     /mem.*/
+WHERE host = 'serverA' AND region = 'uswest'
+   OR time > now() - 1h
+GROUP BY time(10m),
+         region
+SLIMIT 2
+SOFFSET 1`,
+		},
+		{
+			s:    `SELECT value FROM cpu WHERE host = 'serverA' AND region = 'uswest' AND (value > 10 OR value < 2)`,
+			opts: &influxql.FormatOptions{Width: 40},
+			exp: `SELECT value
+FROM cpu
+WHERE host = 'serverA'
+  AND region = 'uswest'
+  AND (value > 10 OR value < 2)`,
+		},
+		{
+			s:    `CREATE CONTINUOUS QUERY cq0 ON db0 RESAMPLE EVERY 1m FOR 1h BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(5m), host END`,
+			opts: &influxql.FormatOptions{Indent: "    "},
+			exp: `CREATE CONTINUOUS QUERY cq0 ON db0
+RESAMPLE EVERY 1m FOR 1h
+BEGIN
+    SELECT count(value)
+    INTO cpu_count
+    FROM cpu
+    GROUP BY time(5m),
+             host
+END`,
+		},
+		{
+			s: `DELETE FROM cpu WHERE time < now() - 1d`,
+			exp: `DELETE FROM cpu
+WHERE time < now() - 1d`,
+		},
+		{
+			s: `SHOW TAG VALUES FROM cpu WITH KEY IN (host, region) WHERE region = 'uswest' LIMIT 5 OFFSET 1`,
+			exp: `SHOW TAG VALUES
+FROM cpu
+WITH KEY IN (host, region)
+WHERE region = 'uswest'
+LIMIT 5
+OFFSET 1`,
+		},
+		{
+			s:   `SHOW DATABASES`,
+			exp: `SHOW DATABASES`,
+		},
+	}
+
+	for i, tt := range tests {
+		stmt := influxql.MustParseStatement(tt.s)
+		s := influxql.Format(stmt, tt.opts)
+		if s != tt.exp {
+			t.Errorf("%d. %s: unexpected format:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, tt.exp, s)
+			continue
+		}
+
+		// Ensure the formatted statement parses to the same statement.
+		if other := influxql.MustParseStatement(s); other.String() != stmt.String() {
+			t.Errorf("%d. %s: round trip mismatch:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, stmt.String(), other.String())
+		}
+	}
+}
+
+// Ensure each statement in a query is formatted.
+func TestFormat_Query(t *testing.T) {
+	q, err := influxql.ParseQuery(`SELECT value FROM cpu; SHOW SERIES FROM cpu`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := influxql.Format(q, nil); s != "SELECT value\nFROM cpu;\n\nSHOW SERIES\nFROM cpu" {
+		t.Fatalf("unexpected format: %s", s)
+	}
+}
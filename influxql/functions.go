@@ -32,6 +32,262 @@ type ReduceFunc func([]interface{}) interface{}
 // server and marshal it into an interface the reduer can use
 type UnmarshalFunc func([]byte) (interface{}, error)
 
+// FunctionArg describes the kinds of expressions accepted as a function argument.
+// Values can be combined to accept more than one kind of expression.
+type FunctionArg int
+
+const (
+	// FieldArg accepts a field reference.
+	FieldArg FunctionArg = 1 << iota
+
+	// NumberArg accepts an integer or float literal.
+	NumberArg
+
+	// DurationArg accepts a duration literal.
+	DurationArg
+
+	// AggregateArg accepts a nested aggregate call, e.g. derivative(mean(value)).
+	AggregateArg
+
+	// DistinctArg accepts a distinct expression, e.g. count(distinct value).
+	DistinctArg
+
+	// WildcardArg accepts a wildcard, e.g. count(*).
+	WildcardArg
+)
+
+// String returns the name of the primary kind of expression accepted.
+func (a FunctionArg) String() string {
+	switch {
+	case a&FieldArg != 0:
+		return "field"
+	case a&NumberArg != 0:
+		return "number"
+	case a&DurationArg != 0:
+		return "duration"
+	case a&AggregateArg != 0:
+		return "aggregate"
+	case a&DistinctArg != 0:
+		return "distinct"
+	case a&WildcardArg != 0:
+		return "wildcard"
+	}
+	return "unknown"
+}
+
+// accepts returns true if expr is a valid argument of this kind.
+func (a FunctionArg) accepts(expr Expr) bool {
+	switch expr := expr.(type) {
+	case *VarRef:
+		return a&FieldArg != 0
+	case *IntegerLiteral, *NumberLiteral:
+		return a&NumberArg != 0
+	case *DurationLiteral:
+		return a&DurationArg != 0
+	case *Distinct:
+		return a&DistinctArg != 0
+	case *Wildcard:
+		return a&WildcardArg != 0
+	case *Call:
+		if expr.Name == "distinct" {
+			return a&DistinctArg != 0
+		}
+		return a&AggregateArg != 0
+	}
+	return false
+}
+
+// FunctionDef describes the arity and argument types of a query function.
+type FunctionDef struct {
+	// Name of the function.
+	Name string
+
+	// Args lists the kinds of expressions accepted for each argument.
+	Args []FunctionArg
+
+	// MinArgs is the number of required arguments. Any remaining
+	// arguments in Args are optional.
+	MinArgs int
+}
+
+// Validate returns an error if c has the wrong number or type of arguments.
+func (def *FunctionDef) Validate(c *Call) error {
+	if got := len(c.Args); got < def.MinArgs || got > len(def.Args) {
+		if def.MinArgs == len(def.Args) {
+			return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", c.Name, def.MinArgs, got)
+		}
+		return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", c.Name, def.MinArgs, len(def.Args), got)
+	}
+
+	for i, arg := range c.Args {
+		if !def.Args[i].accepts(arg) {
+			return fmt.Errorf("expected %s argument in %s()", def.Args[i], c.Name)
+		}
+
+		// Nested aggregates must be valid as well.
+		if call, ok := arg.(*Call); ok {
+			if err := call.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// functionDefs holds the definition of every supported query function.
+var functionDefs = map[string]*FunctionDef{}
+
+func init() {
+	for _, def := range []*FunctionDef{
+		{Name: "count", Args: []FunctionArg{FieldArg | DistinctArg | WildcardArg}, MinArgs: 1},
+		{Name: "distinct", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "sum", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "mean", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "median", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "min", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "max", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "spread", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "stddev", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "first", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "last", Args: []FunctionArg{FieldArg | WildcardArg}, MinArgs: 1},
+		{Name: "percentile", Args: []FunctionArg{FieldArg, NumberArg}, MinArgs: 2},
+		{Name: "derivative", Args: []FunctionArg{FieldArg | AggregateArg, DurationArg}, MinArgs: 1},
+		{Name: "non_negative_derivative", Args: []FunctionArg{FieldArg | AggregateArg, DurationArg}, MinArgs: 1},
+		{Name: "holt_winters", Args: []FunctionArg{AggregateArg, NumberArg, NumberArg}, MinArgs: 3},
+		{Name: "holt_winters_with_fit", Args: []FunctionArg{AggregateArg, NumberArg, NumberArg}, MinArgs: 3},
+		{Name: "moving_average", Args: []FunctionArg{FieldArg | AggregateArg, NumberArg}, MinArgs: 2},
+		{Name: "cumulative_sum", Args: []FunctionArg{FieldArg | AggregateArg}, MinArgs: 1},
+		{Name: "difference", Args: []FunctionArg{FieldArg | AggregateArg}, MinArgs: 1},
+		{Name: "elapsed", Args: []FunctionArg{FieldArg, DurationArg}, MinArgs: 1},
+		{Name: "sample", Args: []FunctionArg{FieldArg, NumberArg}, MinArgs: 2},
+	} {
+		functionDefs[def.Name] = def
+	}
+}
+
+// LookupFunction returns the definition of the named function.
+// Returns nil if the function is not supported.
+func LookupFunction(name string) *FunctionDef {
+	return functionDefs[name]
+}
+
+// isTransform returns true if the named function transforms the stream of
+// values produced by a field or a nested aggregate.
+func isTransform(name string) bool {
+	switch name {
+	case "moving_average", "cumulative_sum", "difference", "elapsed", "sample":
+		return true
+	}
+	return false
+}
+
+// ScalarFunc computes the value of a function from the values of its
+// arguments. Returns nil if the function can't be applied to the arguments.
+type ScalarFunc func(args []interface{}) interface{}
+
+// scalarFuncs holds every function that computes a single value from its
+// arguments. Calls to these functions are folded by Reduce() when all of
+// their arguments are constant.
+var scalarFuncs = map[string]ScalarFunc{}
+
+func init() {
+	scalarFuncs["abs"] = scalarAbs
+	scalarFuncs["ceil"] = scalarRound(math.Ceil)
+	scalarFuncs["floor"] = scalarRound(math.Floor)
+	scalarFuncs["round"] = scalarRound(func(v float64) float64 {
+		if v < 0 {
+			return math.Ceil(v - 0.5)
+		}
+		return math.Floor(v + 0.5)
+	})
+	scalarFuncs["sqrt"] = scalarFloat(math.Sqrt)
+	scalarFuncs["lower"] = scalarString(strings.ToLower)
+	scalarFuncs["upper"] = scalarString(strings.ToUpper)
+}
+
+// LookupScalarFunction returns the named scalar function.
+// Returns nil if the function is not a scalar function.
+func LookupScalarFunction(name string) ScalarFunc {
+	return scalarFuncs[name]
+}
+
+// scalarAbs returns the absolute value of a number.
+func scalarAbs(args []interface{}) interface{} {
+	if len(args) != 1 {
+		return nil
+	}
+
+	switch v := args[0].(type) {
+	case float64:
+		return math.Abs(v)
+	case int64:
+		if v == math.MinInt64 {
+			return -float64(v)
+		} else if v < 0 {
+			return -v
+		}
+		return v
+	}
+	return nil
+}
+
+// scalarRound returns a scalar function that rounds a float to an integral
+// value using fn. Integers are already integral and are returned unchanged.
+func scalarRound(fn func(float64) float64) ScalarFunc {
+	return func(args []interface{}) interface{} {
+		if len(args) != 1 {
+			return nil
+		}
+
+		switch v := args[0].(type) {
+		case float64:
+			return fn(v)
+		case int64:
+			return v
+		}
+		return nil
+	}
+}
+
+// scalarFloat returns a scalar function that applies fn to a number.
+// Results outside of fn's domain are rejected.
+func scalarFloat(fn func(float64) float64) ScalarFunc {
+	return func(args []interface{}) interface{} {
+		if len(args) != 1 {
+			return nil
+		}
+
+		var f float64
+		switch v := args[0].(type) {
+		case float64:
+			f = fn(v)
+		case int64:
+			f = fn(float64(v))
+		default:
+			return nil
+		}
+
+		if math.IsNaN(f) {
+			return nil
+		}
+		return f
+	}
+}
+
+// scalarString returns a scalar function that applies fn to a string.
+func scalarString(fn func(string) string) ScalarFunc {
+	return func(args []interface{}) interface{} {
+		if len(args) != 1 {
+			return nil
+		}
+
+		if v, ok := args[0].(string); ok {
+			return fn(v)
+		}
+		return nil
+	}
+}
+
 // InitializeMapFunc takes an aggregate call from the query and returns the MapFunc
 func InitializeMapFunc(c *Call) (MapFunc, error) {
 	// see if it's a query for raw data
@@ -49,13 +305,28 @@ func InitializeMapFunc(c *Call) (MapFunc, error) {
 		if len(c.Args) == 0 {
 			return nil, fmt.Errorf("expected field name argument for %s()", c.Name)
 		}
+	} else if strings.HasPrefix(c.Name, "holt_winters") {
+		// holt_winters requires an aggregate, the number of points to forecast and the season length
+		if len(c.Args) != 3 {
+			return nil, fmt.Errorf("expected three arguments for %s()", c.Name)
+		}
+	} else if c.Name == "moving_average" || c.Name == "sample" {
+		// moving_average and sample also require the window or sample size
+		if len(c.Args) != 2 {
+			return nil, fmt.Errorf("expected two arguments for %s()", c.Name)
+		}
+	} else if c.Name == "elapsed" {
+		// elapsed requires a field name and optional duration
+		if len(c.Args) == 0 || len(c.Args) > 2 {
+			return nil, fmt.Errorf("expected field name argument for %s()", c.Name)
+		}
 	} else if len(c.Args) != 1 {
 		return nil, fmt.Errorf("expected one argument for %s()", c.Name)
 	}
 
-	// derivative can take a nested aggregate function, everything else expects
-	// a variable reference as the first arg
-	if !strings.HasSuffix(c.Name, "derivative") {
+	// derivative, holt_winters and transforms can take a nested aggregate
+	// function, everything else expects a variable reference as the first arg
+	if !strings.HasSuffix(c.Name, "derivative") && !strings.HasPrefix(c.Name, "holt_winters") && !isTransform(c.Name) {
 		// Ensure the argument is appropriate for the aggregate function.
 		switch fc := c.Args[0].(type) {
 		case *VarRef:
@@ -105,8 +376,9 @@ func InitializeMapFunc(c *Call) (MapFunc, error) {
 	case "last":
 		return MapLast, nil
 	case "percentile":
-		_, ok := c.Args[1].(*NumberLiteral)
-		if !ok {
+		switch c.Args[1].(type) {
+		case *IntegerLiteral, *NumberLiteral:
+		default:
 			return nil, fmt.Errorf("expected float argument in percentile()")
 		}
 		return MapEcho, nil
@@ -117,6 +389,19 @@ func InitializeMapFunc(c *Call) (MapFunc, error) {
 			return InitializeMapFunc(fn)
 		}
 		return MapRawQuery, nil
+	case "holt_winters", "holt_winters_with_fit":
+		// Forecasts are made from the nested aggregate e.g. holt_winters(mean(value), 10, 4)
+		fn, ok := c.Args[0].(*Call)
+		if !ok {
+			return nil, fmt.Errorf("expected aggregate argument in %s()", c.Name)
+		}
+		return InitializeMapFunc(fn)
+	case "moving_average", "cumulative_sum", "difference", "elapsed", "sample":
+		// Transform the nested aggregate e.g. difference(max(value)), or the raw values
+		if fn, ok := c.Args[0].(*Call); ok {
+			return InitializeMapFunc(fn)
+		}
+		return MapRawQuery, nil
 	default:
 		return nil, fmt.Errorf("function not found: %q", c.Name)
 	}
@@ -161,11 +446,14 @@ func InitializeReduceFunc(c *Call) (ReduceFunc, error) {
 			return nil, fmt.Errorf("expected float argument in percentile()")
 		}
 
-		lit, ok := c.Args[1].(*NumberLiteral)
-		if !ok {
+		switch lit := c.Args[1].(type) {
+		case *IntegerLiteral:
+			return ReducePercentile(float64(lit.Val)), nil
+		case *NumberLiteral:
+			return ReducePercentile(lit.Val), nil
+		default:
 			return nil, fmt.Errorf("expected float argument in percentile()")
 		}
-		return ReducePercentile(lit.Val), nil
 	case "derivative", "non_negative_derivative":
 		// If the arg is another aggregate e.g. derivative(mean(value)), then
 		// use the map func for that nested aggregate
@@ -173,6 +461,21 @@ func InitializeReduceFunc(c *Call) (ReduceFunc, error) {
 			return InitializeReduceFunc(fn)
 		}
 		return nil, fmt.Errorf("expected function argument to %s", c.Name)
+	case "holt_winters", "holt_winters_with_fit":
+		// Forecasts are made from the nested aggregate e.g. holt_winters(mean(value), 10, 4)
+		fn, ok := c.Args[0].(*Call)
+		if !ok {
+			return nil, fmt.Errorf("expected aggregate argument in %s()", c.Name)
+		}
+		return InitializeReduceFunc(fn)
+	case "moving_average", "cumulative_sum", "difference", "elapsed", "sample":
+		// Only transforms of a nested aggregate are reduced, raw values are
+		// transformed as they are read
+		fn, ok := c.Args[0].(*Call)
+		if !ok {
+			return nil, fmt.Errorf("expected aggregate argument in %s()", c.Name)
+		}
+		return InitializeReduceFunc(fn)
 	default:
 		return nil, fmt.Errorf("function not found: %q", c.Name)
 	}
@@ -232,6 +535,18 @@ func InitializeUnmarshaller(c *Call) (UnmarshalFunc, error) {
 			err := json.Unmarshal(b, &a)
 			return a, err
 		}, nil
+	case "moving_average", "cumulative_sum", "difference", "elapsed", "sample":
+		// Mapper output is produced by the nested aggregate or is raw data
+		if fn, ok := c.Args[0].(*Call); ok {
+			return InitializeUnmarshaller(fn)
+		}
+		return InitializeUnmarshaller(nil)
+	case "holt_winters", "holt_winters_with_fit":
+		// Mapper output is produced by the nested aggregate
+		if fn, ok := c.Args[0].(*Call); ok {
+			return InitializeUnmarshaller(fn)
+		}
+		return nil, fmt.Errorf("expected aggregate argument in %s()", c.Name)
 	default:
 		return func(b []byte) (interface{}, error) {
 			var val interface{}
@@ -1079,10 +1394,184 @@ func ReducePercentile(percentile float64) ReduceFunc {
 	}
 }
 
+// HoltWinters fits an additive Holt-Winters model to values and returns the
+// fitted values along with n forecasted values. The smoothing parameters are
+// chosen to minimize the squared error of the fit. A season length of zero or
+// one fits a model without a seasonal component.
+func HoltWinters(values []float64, n, season int) (fitted, forecast []float64) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	if season < 2 || len(values) < season {
+		season = 1
+	}
+	m := &holtWintersModel{values: values, season: season}
+
+	// Search for the smoothing parameters that best fit the data.
+	params := []float64{0.5, 0.1, 0.1}
+	if season == 1 {
+		params = params[:2]
+	}
+	params = nelderMead(func(p []float64) float64 {
+		_, _, sse := m.run(p, 0)
+		return sse
+	}, params, 1000)
+
+	fitted, forecast, _ = m.run(params, n)
+	return fitted, forecast
+}
+
+// holtWintersModel is an additive triple exponential smoothing model.
+type holtWintersModel struct {
+	values []float64
+	season int
+}
+
+// run runs the model over its values with the smoothing parameters alpha,
+// beta and, if seasonal, gamma. Returns the fitted values, n forecasted
+// values, and the sum of squared errors of the fit.
+func (m *holtWintersModel) run(params []float64, n int) (fitted, forecast []float64, sse float64) {
+	y, season := m.values, m.season
+	alpha, beta, gamma := params[0], params[1], 0.0
+	if len(params) > 2 {
+		gamma = params[2]
+	}
+
+	// Initialize the trend from the slope across the first two seasons and
+	// the seasonal components from their deviation from the first season's mean.
+	var trend float64
+	if season > 1 && len(y) >= 2*season {
+		for i := 0; i < season; i++ {
+			trend += (y[i+season] - y[i]) / float64(season)
+		}
+		trend /= float64(season)
+	} else if len(y) > 1 {
+		trend = y[1] - y[0]
+	}
+
+	seasonals := make([]float64, season)
+	if season > 1 {
+		var mean float64
+		for i := 0; i < season; i++ {
+			mean += y[i]
+		}
+		mean /= float64(season)
+		for i := range seasonals {
+			seasonals[i] = y[i] - mean
+		}
+	}
+
+	// Start the level so the first value is predicted exactly.
+	level := y[0] - trend - seasonals[0]
+
+	fitted = make([]float64, len(y))
+	for t, v := range y {
+		i := t % season
+		fitted[t] = level + trend + seasonals[i]
+		sse += (v - fitted[t]) * (v - fitted[t])
+
+		prev := level
+		level = alpha*(v-seasonals[i]) + (1-alpha)*(level+trend)
+		trend = beta*(level-prev) + (1-beta)*trend
+		seasonals[i] = gamma*(v-level) + (1-gamma)*seasonals[i]
+	}
+
+	forecast = make([]float64, n)
+	for h := range forecast {
+		forecast[h] = level + float64(h+1)*trend + seasonals[(len(y)+h)%season]
+	}
+	return fitted, forecast, sse
+}
+
+// nelderMead minimizes f using the Nelder-Mead simplex method starting from
+// start. Parameters are constrained to the range [0, 1].
+func nelderMead(f func([]float64) float64, start []float64, maxIter int) []float64 {
+	clamp := func(p []float64) []float64 {
+		for i := range p {
+			p[i] = math.Max(0, math.Min(1, p[i]))
+		}
+		return p
+	}
+
+	// point returns c + t*(c - p) clamped to the parameter range.
+	point := func(c, p []float64, t float64) []float64 {
+		q := make([]float64, len(c))
+		for i := range c {
+			q[i] = c[i] + t*(c[i]-p[i])
+		}
+		return clamp(q)
+	}
+
+	// Build the initial simplex by stepping along each axis.
+	n := len(start)
+	simplex := make([][]float64, n+1)
+	scores := make([]float64, n+1)
+	for i := range simplex {
+		p := append([]float64(nil), start...)
+		if i > 0 {
+			if p[i-1] += 0.25; p[i-1] > 1 {
+				p[i-1] -= 0.5
+			}
+		}
+		simplex[i] = clamp(p)
+		scores[i] = f(simplex[i])
+	}
+
+	for iter := 0; iter < maxIter; iter++ {
+		// Order the vertices from best to worst.
+		for i := 1; i <= n; i++ {
+			for j := i; j > 0 && scores[j] < scores[j-1]; j-- {
+				simplex[j], simplex[j-1] = simplex[j-1], simplex[j]
+				scores[j], scores[j-1] = scores[j-1], scores[j]
+			}
+		}
+		if math.Abs(scores[n]-scores[0]) < 1e-10 {
+			break
+		}
+
+		// Compute the centroid of every vertex except the worst.
+		centroid := make([]float64, n)
+		for _, p := range simplex[:n] {
+			for i := range p {
+				centroid[i] += p[i] / float64(n)
+			}
+		}
+
+		worst := simplex[n]
+		reflected := point(centroid, worst, 1)
+		fr := f(reflected)
+
+		switch {
+		case fr < scores[0]:
+			// Try expanding further in the same direction.
+			expanded := point(centroid, worst, 2)
+			if fe := f(expanded); fe < fr {
+				simplex[n], scores[n] = expanded, fe
+			} else {
+				simplex[n], scores[n] = reflected, fr
+			}
+		case fr < scores[n-1]:
+			simplex[n], scores[n] = reflected, fr
+		default:
+			// Contract towards the centroid or shrink towards the best vertex.
+			contracted := point(centroid, worst, -0.5)
+			if fc := f(contracted); fc < scores[n] {
+				simplex[n], scores[n] = contracted, fc
+			} else {
+				for i := 1; i <= n; i++ {
+					simplex[i] = point(simplex[0], simplex[i], -0.5)
+					scores[i] = f(simplex[i])
+				}
+			}
+		}
+	}
+	return simplex[0]
+}
+
 // IsNumeric returns whether a given aggregate can only be run on numeric fields.
 func IsNumeric(c *Call) bool {
 	switch c.Name {
-	case "count", "first", "last", "distinct":
+	case "count", "first", "last", "distinct", "elapsed", "sample":
 		return false
 	default:
 		return true
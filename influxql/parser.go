@@ -17,7 +17,7 @@ const (
 	DateFormat = "2006-01-02"
 
 	// DateTimeFormat represents the format for date time literals.
-	DateTimeFormat = "2006-01-02 15:04:05.999999"
+	DateTimeFormat = "2006-01-02 15:04:05.999999999"
 )
 
 // Parser represents an InfluxQL parser.
@@ -74,6 +74,20 @@ func (p *Parser) ParseQuery() (*Query, error) {
 
 // ParseStatement parses an InfluxQL string and returns a Statement AST object.
 func (p *Parser) ParseStatement() (Statement, error) {
+	// Record the position of the first token on the statement.
+	_, pos, _ := p.scanIgnoreWhitespace()
+	p.unscan()
+
+	stmt, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	setPos(stmt, pos)
+	return stmt, nil
+}
+
+// parseStatement parses a statement based on its first token.
+func (p *Parser) parseStatement() (Statement, error) {
 	// Inspect the first token.
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	switch tok {
@@ -95,9 +109,41 @@ func (p *Parser) ParseStatement() (Statement, error) {
 		return p.parseAlterStatement()
 	case SET:
 		return p.parseSetStatement()
+	case EXPLAIN:
+		return p.parseExplainStatement()
+	case BACKFILL:
+		return p.parseBackfillContinuousQueryStatement()
+	case KILL:
+		return p.parseKillQueryStatement()
 	default:
-		return nil, newParseError(tokstr(tok, lit), []string{"SELECT", "DELETE", "SHOW", "CREATE", "DROP", "GRANT", "REVOKE", "ALTER", "SET"}, pos)
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT", "DELETE", "SHOW", "CREATE", "DROP", "GRANT", "REVOKE", "ALTER", "SET", "EXPLAIN", "BACKFILL", "KILL"}, pos)
+	}
+}
+
+// parseExplainStatement parses a string and returns an ExplainStatement.
+// This function assumes the EXPLAIN token has already been consumed.
+func (p *Parser) parseExplainStatement() (*ExplainStatement, error) {
+	stmt := &ExplainStatement{}
+
+	// Parse optional ANALYZE keyword.
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok == ANALYZE {
+		stmt.Analyze = true
+		tok, pos, lit = p.scanIgnoreWhitespace()
 	}
+
+	// Only SELECT statements can be explained.
+	if tok != SELECT {
+		return nil, newParseError(tokstr(tok, lit), []string{"SELECT"}, pos)
+	}
+
+	source, err := p.parseSelectStatement(targetNotRequired)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Statement = source
+
+	return stmt, nil
 }
 
 // parseShowStatement parses a string and returns a list statement.
@@ -121,6 +167,8 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return nil, newParseError(tokstr(tok, lit), []string{"KEYS", "VALUES"}, pos)
 	case MEASUREMENTS:
 		return p.parseShowMeasurementsStatement()
+	case QUERIES:
+		return &ShowQueriesStatement{}, nil
 	case RETENTION:
 		tok, pos, lit := p.scanIgnoreWhitespace()
 		if tok == POLICIES {
@@ -131,6 +179,8 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return p.parseShowSeriesStatement()
 	case STATS:
 		return p.parseShowStatsStatement()
+	case SUBSCRIPTIONS:
+		return p.parseShowSubscriptionsStatement()
 	case DIAGNOSTICS:
 		return p.parseShowDiagnosticsStatement()
 	case TAG:
@@ -145,7 +195,7 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return p.parseShowUsersStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASES", "FIELD", "GRANTS", "MEASUREMENTS", "RETENTION", "SERIES", "SERVERS", "TAG", "USERS"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASES", "FIELD", "GRANTS", "MEASUREMENTS", "QUERIES", "RETENTION", "SERIES", "SERVERS", "SUBSCRIPTIONS", "TAG", "USERS"}, pos)
 }
 
 // parseCreateStatement parses a string and returns a create statement.
@@ -164,9 +214,11 @@ func (p *Parser) parseCreateStatement() (Statement, error) {
 			return nil, newParseError(tokstr(tok, lit), []string{"POLICY"}, pos)
 		}
 		return p.parseCreateRetentionPolicyStatement()
+	} else if tok == SUBSCRIPTION {
+		return p.parseCreateSubscriptionStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASE", "USER", "RETENTION"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"CONTINUOUS", "DATABASE", "USER", "RETENTION", "SUBSCRIPTION"}, pos)
 }
 
 // parseDropStatement parses a string and returns a drop statement.
@@ -188,9 +240,11 @@ func (p *Parser) parseDropStatement() (Statement, error) {
 		return p.parseDropRetentionPolicyStatement()
 	} else if tok == USER {
 		return p.parseDropUserStatement()
+	} else if tok == SUBSCRIPTION {
+		return p.parseDropSubscriptionStatement()
 	}
 
-	return nil, newParseError(tokstr(tok, lit), []string{"SERIES", "CONTINUOUS", "MEASUREMENT"}, pos)
+	return nil, newParseError(tokstr(tok, lit), []string{"SERIES", "CONTINUOUS", "MEASUREMENT", "SUBSCRIPTION"}, pos)
 }
 
 // parseAlterStatement parses a string and returns an alter statement.
@@ -493,6 +547,9 @@ func (p *Parser) parseSegmentedIdents() ([]string, error) {
 		if ch := p.peekRune(); ch == '/' {
 			// Next segment is a regex so we're done.
 			break
+		} else if ch == ':' {
+			// Next segment is a backreference so we're done.
+			break
 		} else if ch == '.' {
 			// Add an empty identifier.
 			idents = append(idents, "")
@@ -540,11 +597,9 @@ func (p *Parser) parseRevokeStatement() (*RevokeStatement, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	if tok == ON {
 		// Parse the name of the thing we're revoking a privilege to use.
-		lit, err := p.parseIdent()
-		if err != nil {
+		if stmt.On, stmt.Measurement, err = p.parsePrivilegeTarget(); err != nil {
 			return nil, err
 		}
-		stmt.On = lit
 
 		tok, pos, lit = p.scanIgnoreWhitespace()
 	} else if priv != AllPrivileges {
@@ -584,11 +639,9 @@ func (p *Parser) parseGrantStatement() (*GrantStatement, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
 	if tok == ON {
 		// Parse the name of the thing we're granting a privilege to use.
-		lit, err := p.parseIdent()
-		if err != nil {
+		if stmt.On, stmt.Measurement, err = p.parsePrivilegeTarget(); err != nil {
 			return nil, err
 		}
-		stmt.On = lit
 
 		tok, pos, lit = p.scanIgnoreWhitespace()
 	} else if priv != AllPrivileges {
@@ -612,6 +665,26 @@ func (p *Parser) parseGrantStatement() (*GrantStatement, error) {
 	return stmt, nil
 }
 
+// parsePrivilegeTarget parses the database, and optionally the measurement
+// in the database, that a privilege is granted on: "<db>" or "<db>.<measurement>".
+func (p *Parser) parsePrivilegeTarget() (database, measurement string, err error) {
+	_, pos, _ := p.scanIgnoreWhitespace()
+	p.unscan()
+
+	idents, err := p.parseSegmentedIdents()
+	if err != nil {
+		return "", "", err
+	}
+
+	switch {
+	case len(idents) == 1:
+		return idents[0], "", nil
+	case len(idents) == 2 && idents[0] != "" && idents[1] != "":
+		return idents[0], idents[1], nil
+	}
+	return "", "", &ParseError{Message: "invalid privilege target: " + strings.Join(idents, "."), Pos: pos}
+}
+
 // parsePrivilege parses a string and returns a Privilege
 func (p *Parser) parsePrivilege() (Privilege, error) {
 	tok, pos, lit := p.scanIgnoreWhitespace()
@@ -696,12 +769,7 @@ func (p *Parser) parseSelectStatement(tr targetRequirement) (*SelectStatement, e
 	}
 
 	// Set if the query is a raw data query or one with an aggregate
-	stmt.IsRawQuery = true
-	WalkFunc(stmt.Fields, func(n Node) {
-		if _, ok := n.(*Call); ok {
-			stmt.IsRawQuery = false
-		}
-	})
+	stmt.IsRawQuery = len(stmt.FunctionCalls()) == 0
 
 	if err := stmt.validate(tr); err != nil {
 		return nil, err
@@ -720,7 +788,8 @@ const (
 
 // parseTarget parses a string and returns a Target.
 func (p *Parser) parseTarget(tr targetRequirement) (*Target, error) {
-	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != INTO {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != INTO {
 		if tr == targetRequired {
 			return nil, newParseError(tokstr(tok, lit), []string{"INTO"}, pos)
 		}
@@ -728,13 +797,43 @@ func (p *Parser) parseTarget(tr targetRequirement) (*Target, error) {
 		return nil, nil
 	}
 
+	t := &Target{Measurement: &Measurement{}}
+	t.Position = pos
+
+	// A backreference without a db or rp e.g. INTO :MEASUREMENT
+	tok, pos, _ = p.scanIgnoreWhitespace()
+	t.Measurement.Position = pos
+	if tok == COLON {
+		p.unscan()
+		if err := p.parseBackref(); err != nil {
+			return nil, err
+		}
+		t.Backref = true
+		return t, nil
+	}
+	p.unscan()
+
 	// db, rp, and / or measurement
 	idents, err := p.parseSegmentedIdents()
 	if err != nil {
 		return nil, err
 	}
 
-	t := &Target{Measurement: &Measurement{}}
+	// A backreference following the db and / or rp e.g. INTO db."1h".:MEASUREMENT
+	if tok, _, _ := p.scan(); tok == COLON {
+		p.unscan()
+		if err := p.parseBackref(); err != nil {
+			return nil, err
+		}
+		if len(idents) > 2 {
+			msg := fmt.Sprintf("too many segments in %s.:MEASUREMENT", QuoteIdent(idents...))
+			return nil, &ParseError{Message: msg}
+		}
+		t.Backref = true
+		idents = append(idents, "")
+	} else {
+		p.unscan()
+	}
 
 	switch len(idents) {
 	case 1:
@@ -751,6 +850,17 @@ func (p *Parser) parseTarget(tr targetRequirement) (*Target, error) {
 	return t, nil
 }
 
+// parseBackref parses the :MEASUREMENT backreference of an INTO target.
+func (p *Parser) parseBackref() error {
+	if tok, pos, lit := p.scan(); tok != COLON {
+		return newParseError(tokstr(tok, lit), []string{":"}, pos)
+	}
+	if tok, pos, lit := p.scan(); tok != MEASUREMENT {
+		return newParseError(tokstr(tok, lit), []string{"MEASUREMENT"}, pos)
+	}
+	return nil
+}
+
 // parseDeleteStatement parses a delete string and returns a DeleteStatement.
 // This function assumes the DELETE token has already been consumed.
 func (p *Parser) parseDeleteStatement() (*DeleteStatement, error) {
@@ -773,6 +883,10 @@ func (p *Parser) parseDeleteStatement() (*DeleteStatement, error) {
 	}
 	stmt.Condition = condition
 
+	if err := stmt.validate(); err != nil {
+		return nil, err
+	}
+
 	return stmt, nil
 }
 
@@ -1073,6 +1187,34 @@ func (p *Parser) parseShowContinuousQueriesStatement() (*ShowContinuousQueriesSt
 		return nil, newParseError(tokstr(tok, lit), []string{"QUERIES"}, pos)
 	}
 
+	// Parse optional database: "ON <db>".
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == ON {
+		ident, err := p.parseIdent()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Database = ident
+	} else {
+		p.unscan()
+	}
+
+	var err error
+
+	// Parse condition: "WHERE EXPR".
+	if stmt.Condition, err = p.parseCondition(); err != nil {
+		return nil, err
+	}
+
+	// Parse limit: "LIMIT <n>".
+	if stmt.Limit, err = p.parseOptionalTokenAndInt(LIMIT); err != nil {
+		return nil, err
+	}
+
+	// Parse offset: "OFFSET <n>".
+	if stmt.Offset, err = p.parseOptionalTokenAndInt(OFFSET); err != nil {
+		return nil, err
+	}
+
 	return stmt, nil
 }
 
@@ -1083,6 +1225,13 @@ func (p *Parser) parseShowServersStatement() (*ShowServersStatement, error) {
 	return stmt, nil
 }
 
+// parseShowSubscriptionsStatement parses a string and returns a ShowSubscriptionsStatement.
+// This function assumes the "SHOW SUBSCRIPTIONS" tokens have already been consumed.
+func (p *Parser) parseShowSubscriptionsStatement() (*ShowSubscriptionsStatement, error) {
+	stmt := &ShowSubscriptionsStatement{}
+	return stmt, nil
+}
+
 // parseGrantsForUserStatement parses a string and returns a ShowGrantsForUserStatement.
 // This function assumes the "SHOW GRANTS" tokens have already been consumed.
 func (p *Parser) parseGrantsForUserStatement() (*ShowGrantsForUserStatement, error) {
@@ -1138,16 +1287,34 @@ func (p *Parser) parseCreateContinuousQueryStatement() (*CreateContinuousQuerySt
 	}
 	stmt.Database = ident
 
+	// Parse the optional "RESAMPLE" clause.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == RESAMPLE {
+		if stmt.ResampleEvery, stmt.ResampleFor, err = p.parseResample(); err != nil {
+			return nil, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	// Parse the optional "BACKFILL" flag.
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == BACKFILL {
+		stmt.Backfill = true
+	} else {
+		p.unscan()
+	}
+
 	// Expect a "BEGIN SELECT" tokens.
 	if err := p.parseTokens([]Token{BEGIN, SELECT}); err != nil {
 		return nil, err
 	}
 
 	// Read the select statement to be used as the source.
+	_, pos, _ := p.s.curr()
 	source, err := p.parseSelectStatement(targetRequired)
 	if err != nil {
 		return nil, err
 	}
+	source.Position = pos
 	stmt.Source = source
 
 	// validate that the statement has a non-zero group by interval if it is aggregated
@@ -1166,6 +1333,20 @@ func (p *Parser) parseCreateContinuousQueryStatement() (*CreateContinuousQuerySt
 		}
 	}
 
+	// Validate that the resample window covers at least one GROUP BY interval.
+	if stmt.ResampleFor > 0 {
+		if d, _ := source.GroupByInterval(); d > 0 && stmt.ResampleFor < d {
+			return nil, fmt.Errorf("FOR duration must be >= GROUP BY time duration: must be a minimum of %s, got %s", FormatDuration(d), FormatDuration(stmt.ResampleFor))
+		}
+	}
+
+	// Validate that a backfill has a starting point.
+	if stmt.Backfill {
+		if min, _ := TimeRange(Reduce(source.Condition, &NowValuer{Now: time.Now()})); min.IsZero() {
+			return nil, errors.New("BACKFILL requires a lower time bound in the WHERE clause")
+		}
+	}
+
 	// Expect a "END" keyword.
 	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != END {
 		return nil, newParseError(tokstr(tok, lit), []string{"END"}, pos)
@@ -1174,6 +1355,50 @@ func (p *Parser) parseCreateContinuousQueryStatement() (*CreateContinuousQuerySt
 	return stmt, nil
 }
 
+// parseResample parses the "EVERY" and "FOR" options of a "RESAMPLE" clause.
+// This function assumes the RESAMPLE token has already been consumed.
+func (p *Parser) parseResample() (every, window time.Duration, err error) {
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == EVERY {
+		if every, err = p.parseResampleDuration(); err != nil {
+			return 0, 0, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	if tok, _, _ := p.scanIgnoreWhitespace(); tok == FOR {
+		if window, err = p.parseResampleDuration(); err != nil {
+			return 0, 0, err
+		}
+	} else {
+		p.unscan()
+	}
+
+	// At least one option is required.
+	if every == 0 && window == 0 {
+		tok, pos, lit := p.scanIgnoreWhitespace()
+		return 0, 0, newParseError(tokstr(tok, lit), []string{"EVERY", "FOR"}, pos)
+	}
+
+	return every, window, nil
+}
+
+// parseResampleDuration parses a positive duration for a "RESAMPLE" option.
+func (p *Parser) parseResampleDuration() (time.Duration, error) {
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != DURATION_VAL {
+		return 0, newParseError(tokstr(tok, lit), []string{"duration"}, pos)
+	}
+
+	d, err := ParseDuration(lit)
+	if err != nil {
+		return 0, &ParseError{Message: err.Error(), Pos: pos}
+	} else if d <= 0 {
+		return 0, &ParseError{Message: "duration must be positive", Pos: pos}
+	}
+	return d, nil
+}
+
 // parseCreateDatabaseStatement parses a string and returns a CreateDatabaseStatement.
 // This function assumes the "CREATE DATABASE" tokens have already been consumed.
 func (p *Parser) parseCreateDatabaseStatement() (*CreateDatabaseStatement, error) {
@@ -1229,6 +1454,97 @@ func (p *Parser) parseDropRetentionPolicyStatement() (*DropRetentionPolicyStatem
 	return stmt, nil
 }
 
+// parseCreateSubscriptionStatement parses a string and returns a CreateSubscriptionStatement.
+// This function assumes the "CREATE SUBSCRIPTION" tokens have already been consumed.
+func (p *Parser) parseCreateSubscriptionStatement() (*CreateSubscriptionStatement, error) {
+	stmt := &CreateSubscriptionStatement{}
+
+	// Parse the subscription name.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Parse "ON <db>.<rp>".
+	if stmt.Database, stmt.RetentionPolicy, err = p.parseSubscriptionTarget(); err != nil {
+		return nil, err
+	}
+
+	// Consume the required DESTINATIONS token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != DESTINATIONS {
+		return nil, newParseError(tokstr(tok, lit), []string{"DESTINATIONS"}, pos)
+	}
+
+	// Parse the destination mode.
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok != ALL && tok != ANY {
+		return nil, newParseError(tokstr(tok, lit), []string{"ALL", "ANY"}, pos)
+	}
+	stmt.Mode = tokens[tok]
+
+	// Parse a comma-separated list of destinations.
+	for {
+		dest, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Destinations = append(stmt.Destinations, dest)
+
+		if tok, _, _ := p.scanIgnoreWhitespace(); tok != COMMA {
+			p.unscan()
+			break
+		}
+	}
+
+	return stmt, nil
+}
+
+// parseDropSubscriptionStatement parses a string and returns a DropSubscriptionStatement.
+// This function assumes the "DROP SUBSCRIPTION" tokens have already been consumed.
+func (p *Parser) parseDropSubscriptionStatement() (*DropSubscriptionStatement, error) {
+	stmt := &DropSubscriptionStatement{}
+
+	// Parse the subscription name.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Parse "ON <db>.<rp>".
+	if stmt.Database, stmt.RetentionPolicy, err = p.parseSubscriptionTarget(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// parseSubscriptionTarget parses the "ON <db>.<rp>" clause of a subscription statement.
+func (p *Parser) parseSubscriptionTarget() (db, rp string, err error) {
+	// Consume the required ON token.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != ON {
+		return "", "", newParseError(tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Parse the database name.
+	if db, err = p.parseIdent(); err != nil {
+		return "", "", err
+	}
+
+	// Consume the required DOT token.
+	if tok, pos, lit := p.scan(); tok != DOT {
+		return "", "", newParseError(tokstr(tok, lit), []string{"."}, pos)
+	}
+
+	// Parse the retention policy name.
+	if rp, err = p.parseIdent(); err != nil {
+		return "", "", err
+	}
+
+	return db, rp, nil
+}
+
 // parseCreateUserStatement parses a string and returns a CreateUserStatement.
 // This function assumes the "CREATE USER" tokens have already been consumed.
 func (p *Parser) parseCreateUserStatement() (*CreateUserStatement, error) {
@@ -1263,7 +1579,7 @@ func (p *Parser) parseCreateUserStatement() (*CreateUserStatement, error) {
 	if err := p.parseTokens([]Token{ALL, PRIVILEGES}); err != nil {
 		return nil, err
 	}
-	stmt.Privilege = NewPrivilege(AllPrivileges)
+	stmt.Admin = true
 
 	return stmt, nil
 }
@@ -1366,13 +1682,70 @@ func (p *Parser) parseDropContinuousQueryStatement() (*DropContinuousQueryStatem
 	return stmt, nil
 }
 
+// parseKillQueryStatement parses a string and returns a KillQueryStatement.
+// This function assumes the KILL token has already been consumed.
+func (p *Parser) parseKillQueryStatement() (*KillQueryStatement, error) {
+	if err := p.parseTokens([]Token{QUERY}); err != nil {
+		return nil, err
+	}
+
+	id, err := p.parseUInt64()
+	if err != nil {
+		return nil, err
+	}
+	return &KillQueryStatement{QueryID: id}, nil
+}
+
+// parseBackfillContinuousQueryStatement parses a string and returns a BackfillContinuousQueryStatement.
+// This function assumes the BACKFILL token has already been consumed.
+func (p *Parser) parseBackfillContinuousQueryStatement() (*BackfillContinuousQueryStatement, error) {
+	stmt := &BackfillContinuousQueryStatement{}
+
+	// Expect "CONTINUOUS QUERY" tokens.
+	if err := p.parseTokens([]Token{CONTINUOUS, QUERY}); err != nil {
+		return nil, err
+	}
+
+	// Read the name of the query to run.
+	ident, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = ident
+
+	// Expect an "ON" keyword.
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != ON {
+		return nil, newParseError(tokstr(tok, lit), []string{"ON"}, pos)
+	}
+
+	// Read the name of the database the query belongs to.
+	if ident, err = p.parseIdent(); err != nil {
+		return nil, err
+	}
+	stmt.Database = ident
+
+	// Parse the time range: "WHERE EXPR".
+	if stmt.Condition, err = p.parseCondition(); err != nil {
+		return nil, err
+	}
+
+	if err := stmt.validate(); err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
 // parseFields parses a list of one or more fields.
 func (p *Parser) parseFields() (Fields, error) {
 	var fields Fields
 
 	// Check for "*" (i.e., "all fields")
-	if tok, _, _ := p.scanIgnoreWhitespace(); tok == MUL {
-		fields = append(fields, &Field{&Wildcard{}, ""})
+	if tok, pos, _ := p.scanIgnoreWhitespace(); tok == MUL {
+		f := &Field{Expr: &Wildcard{}}
+		setPos(f, pos)
+		setPos(f.Expr, pos)
+		fields = append(fields, f)
 		return fields, nil
 	}
 	p.unscan()
@@ -1406,6 +1779,7 @@ func (p *Parser) parseField() (*Field, error) {
 		return nil, err
 	}
 	f.Expr = expr
+	f.Position = expr.Pos()
 
 	// Parse the alias if the current and next tokens are "WS AS".
 	alias, err := p.parseAlias()
@@ -1475,11 +1849,14 @@ func (p *Parser) parseSource() (Source, error) {
 		return nil, err
 	} else if re != nil {
 		m.Regex = re
+		m.Position = re.Position
 		// Regex is always last so we're done.
 		return m, nil
 	}
 
 	// Didn't find a regex so parse segmented identifiers.
+	_, m.Position, _ = p.scanIgnoreWhitespace()
+	p.unscan()
 	idents, err := p.parseSegmentedIdents()
 	if err != nil {
 		return nil, err
@@ -1569,6 +1946,17 @@ func (p *Parser) parseDimensions() (Dimensions, error) {
 
 // parseDimension parses a single dimension.
 func (p *Parser) parseDimension() (*Dimension, error) {
+	// A regex groups by all matching tag keys.
+	re, err := p.parseRegex()
+	if err != nil {
+		return nil, err
+	} else if re != nil {
+		p.consumeWhitespace()
+		d := &Dimension{Expr: re}
+		d.Position = re.Position
+		return d, nil
+	}
+
 	// Parse the expression first.
 	expr, err := p.ParseExpr()
 	if err != nil {
@@ -1578,10 +1966,12 @@ func (p *Parser) parseDimension() (*Dimension, error) {
 	// Consume all trailing whitespace.
 	p.consumeWhitespace()
 
-	return &Dimension{Expr: expr}, nil
+	d := &Dimension{Expr: expr}
+	d.Position = expr.Pos()
+	return d, nil
 }
 
-// parseFill parses the fill call and its optios.
+// parseFill parses the fill call and its options.
 func (p *Parser) parseFill() (FillOption, interface{}, error) {
 	// Parse the expression first.
 	expr, err := p.ParseExpr()
@@ -1608,11 +1998,15 @@ func (p *Parser) parseFill() (FillOption, interface{}, error) {
 		case "previous":
 			return PreviousFill, nil, nil
 		default:
-			num, ok := lit.Args[0].(*NumberLiteral)
-			if !ok {
+			switch num := lit.Args[0].(type) {
+			case *IntegerLiteral:
+				// Fill values are floats, like the aggregates they take the place of.
+				return NumberFill, float64(num.Val), nil
+			case *NumberLiteral:
+				return NumberFill, num.Val, nil
+			default:
 				return NullFill, nil, fmt.Errorf("expected number argument in fill()")
 			}
-			return NumberFill, num.Val, nil
 		}
 	}
 }
@@ -1704,15 +2098,32 @@ func (p *Parser) parseSortFields() (SortFields, error) {
 
 // parseSortField parses one field of an ORDER BY clause.
 func (p *Parser) parseSortField() (*SortField, error) {
-	field := &SortField{}
+	field := &SortField{Ascending: true}
 
-	// Next token must be ASC, until other sort orders are supported.
-	tok, _, _ := p.scanIgnoreWhitespace()
-	if tok != ASC {
-		return nil, errors.New("only ORDER BY ASC supported at this time")
+	// The field name is optional. Only time is supported until other sort
+	// fields are supported.
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	field.Position = pos
+	if tok == IDENT {
+		if strings.ToLower(lit) != "time" {
+			return nil, errors.New("only ORDER BY time supported at this time")
+		}
+		field.Name = "time"
+		tok, _, _ = p.scanIgnoreWhitespace()
+	}
+
+	// Parse the optional sort order.
+	switch tok {
+	case ASC:
+	case DESC:
+		field.Ascending = false
+	default:
+		if field.Name == "" {
+			return nil, errors.New("only ORDER BY time supported at this time")
+		}
+		p.unscan()
 	}
 
-	field.Ascending = true
 	return field, nil
 }
 
@@ -1731,6 +2142,53 @@ func (p *Parser) parseVarRef() (*VarRef, error) {
 
 // ParseExpr parses an expression.
 func (p *Parser) ParseExpr() (Expr, error) {
+	expr, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	return rewriteEpochTimes(expr), nil
+}
+
+// rewriteEpochTimes replaces durations compared against the time field, such
+// as "time > 1434059627s", with the time at that offset from the epoch.
+func rewriteEpochTimes(expr Expr) Expr {
+	return RewriteExpr(expr, func(e Expr) Expr {
+		n, ok := e.(*BinaryExpr)
+		if !ok {
+			return e
+		}
+
+		switch n.Op {
+		case EQ, NEQ, LT, LTE, GT, GTE:
+			if isTimeRef(n.LHS) {
+				n.RHS = epochTimeLiteral(n.RHS)
+			} else if isTimeRef(n.RHS) {
+				n.LHS = epochTimeLiteral(n.LHS)
+			}
+		}
+		return n
+	})
+}
+
+// isTimeRef returns true if expr is a reference to the time field.
+func isTimeRef(expr Expr) bool {
+	ref, ok := expr.(*VarRef)
+	return ok && strings.ToLower(ref.Val) == "time"
+}
+
+// epochTimeLiteral returns the time literal for a duration since the epoch.
+// Other expressions are returned unchanged.
+func epochTimeLiteral(expr Expr) Expr {
+	if d, ok := expr.(*DurationLiteral); ok {
+		return &TimeLiteral{NodePos: d.NodePos, Val: time.Unix(0, int64(d.Val)).UTC()}
+	}
+	return expr
+}
+
+// parseExpr parses an expression whose binary operators all have a
+// precedence of at least minPrec. Parsing stops at the first operator with
+// a lower precedence so that it can be applied by the caller.
+func (p *Parser) parseExpr(minPrec int) (Expr, error) {
 	var err error
 	// Dummy root node.
 	root := &BinaryExpr{}
@@ -1744,16 +2202,39 @@ func (p *Parser) ParseExpr() (Expr, error) {
 
 	// Loop over operations and unary exprs and build a tree based on precendence.
 	for {
-		// If the next token is NOT an operator then return the expression.
-		op, _, _ := p.scanIgnoreWhitespace()
-		if !op.isOperator() {
-			p.unscan()
-			return root.RHS, nil
-		}
+		op, pos, lit := p.scanIgnoreWhitespace()
 
-		// Otherwise parse the next expression.
 		var rhs Expr
-		if IsRegexOp(op) {
+		if (op == NUMBER || op == DURATION_VAL) && (strings.HasPrefix(lit, "-") || strings.HasPrefix(lit, "+")) {
+			// The scanner reads a sign followed by a digit as part of the number,
+			// so "now() -1h" is an operator and an unsigned number.
+			tok := op
+			if op = ADD; lit[0] == '-' {
+				op = SUB
+			}
+			if op.Precedence() < minPrec {
+				p.unscan()
+				return root.RHS, nil
+			}
+			if rhs, err = parseNumericLiteral(tok, lit[1:], Pos{Line: pos.Line, Char: pos.Char + 1}); err != nil {
+				return nil, err
+			}
+		} else if op.Precedence() == 0 || op.Precedence() < minPrec {
+			// If the next token is NOT an operator or binds less tightly than
+			// the caller allows then return the expression.
+			p.unscan()
+			return root.RHS, nil
+		} else if op == BETWEEN {
+			// The bounds are held together until the LHS is known.
+			if rhs, err = p.parseBetweenBounds(); err != nil {
+				return nil, err
+			}
+		} else if op == IS {
+			// The null test is completed once the LHS is known.
+			if rhs, err = p.parseNullTest(); err != nil {
+				return nil, err
+			}
+		} else if IsRegexOp(op) {
 			// RHS of a regex operator must be a regular expression.
 			p.consumeWhitespace()
 			if rhs, err = p.parseRegex(); err != nil {
@@ -1773,7 +2254,7 @@ func (p *Parser) ParseExpr() (Expr, error) {
 			r, ok := node.RHS.(*BinaryExpr)
 			if !ok || r.Op.Precedence() >= op.Precedence() {
 				// Add the new expression here and break.
-				node.RHS = &BinaryExpr{LHS: node.RHS, RHS: rhs, Op: op}
+				node.RHS = newOperatorExpr(op, node.RHS, rhs)
 				break
 			}
 			node = r
@@ -1781,8 +2262,116 @@ func (p *Parser) ParseExpr() (Expr, error) {
 	}
 }
 
+// newOperatorExpr returns the expression for op applied to its operands.
+func newOperatorExpr(op Token, lhs, rhs Expr) Expr {
+	switch op {
+	case BETWEEN:
+		return expandBetween(lhs, rhs.(*BinaryExpr))
+	case IS:
+		expr := rhs.(*IsNullExpr)
+		expr.Expr = lhs
+		expr.Position = lhs.Pos()
+		return expr
+	}
+
+	expr := &BinaryExpr{LHS: lhs, RHS: rhs, Op: op}
+	expr.Position = lhs.Pos()
+	return expr
+}
+
+// parseBetweenBounds parses the "<min> AND <max>" bounds of a BETWEEN
+// expression and returns them as the operands of an AND expression.
+func (p *Parser) parseBetweenBounds() (*BinaryExpr, error) {
+	// Bounds may only use arithmetic so the AND separating them, and any
+	// AND following the upper bound, is not consumed as a logical operator.
+	min, err := p.parseExpr(ADD.Precedence())
+	if err != nil {
+		return nil, err
+	}
+
+	if tok, pos, lit := p.scanIgnoreWhitespace(); tok != AND {
+		return nil, newParseError(tokstr(tok, lit), []string{"AND"}, pos)
+	}
+
+	max, err := p.parseExpr(ADD.Precedence())
+	if err != nil {
+		return nil, err
+	}
+	return &BinaryExpr{Op: AND, LHS: min, RHS: max}, nil
+}
+
+// expandBetween rewrites "<lhs> BETWEEN <min> AND <max>" into the inclusive
+// range "(<lhs> >= <min> AND <lhs> <= <max>)" so that the rest of the query
+// engine, such as TimeRange(), doesn't need to know about BETWEEN.
+func expandBetween(lhs Expr, bounds *BinaryExpr) Expr {
+	pos := NodePos{Position: lhs.Pos()}
+	return &ParenExpr{
+		NodePos: pos,
+		Expr: &BinaryExpr{
+			NodePos: pos,
+			Op:      AND,
+			LHS:     &BinaryExpr{NodePos: pos, Op: GTE, LHS: lhs, RHS: bounds.LHS},
+			RHS:     &BinaryExpr{NodePos: pos, Op: LTE, LHS: CloneExpr(lhs), RHS: bounds.RHS},
+		},
+	}
+}
+
+// parseNullTest parses the "[NOT] NULL" following IS. The tested expression
+// is set by the caller.
+func (p *Parser) parseNullTest() (*IsNullExpr, error) {
+	expr := &IsNullExpr{}
+	tok, pos, lit := p.scanIgnoreWhitespace()
+	if tok == NOT {
+		expr.Not = true
+		tok, pos, lit = p.scanIgnoreWhitespace()
+	} else if tok != NULL {
+		return nil, newParseError(tokstr(tok, lit), []string{"NOT", "NULL"}, pos)
+	}
+
+	if tok != NULL {
+		return nil, newParseError(tokstr(tok, lit), []string{"NULL"}, pos)
+	}
+	return expr, nil
+}
+
+// parseNumericLiteral returns the literal for a NUMBER or DURATION_VAL token.
+func parseNumericLiteral(tok Token, lit string, pos Pos) (Expr, error) {
+	var expr Expr
+	switch tok {
+	case NUMBER:
+		// Numbers without a fractional part are integers unless they are
+		// too large to fit into an int64.
+		if v, err := strconv.ParseInt(lit, 10, 64); err == nil && !strings.Contains(lit, ".") {
+			expr = &IntegerLiteral{Val: v}
+		} else if v, err := strconv.ParseFloat(lit, 64); err == nil {
+			expr = &NumberLiteral{Val: v}
+		} else {
+			return nil, &ParseError{Message: "unable to parse number", Pos: pos}
+		}
+	case DURATION_VAL:
+		v, _ := ParseDuration(lit)
+		expr = &DurationLiteral{Val: v}
+	}
+	setPos(expr, pos)
+	return expr, nil
+}
+
 // parseUnaryExpr parses an non-binary expression.
 func (p *Parser) parseUnaryExpr() (Expr, error) {
+	// Record the position of the first token on the expression.
+	_, pos, _ := p.scanIgnoreWhitespace()
+	p.unscan()
+
+	expr, err := p.parseUnaryExprNoPos()
+	if err != nil {
+		return nil, err
+	}
+	setPos(expr, pos)
+	return expr, nil
+}
+
+// parseUnaryExprNoPos parses an non-binary expression without recording its position.
+func (p *Parser) parseUnaryExprNoPos() (Expr, error) {
 	// If the first token is a LPAREN then parse it as its own grouped expression.
 	if tok, _, _ := p.scanIgnoreWhitespace(); tok == LPAREN {
 		expr, err := p.ParseExpr()
@@ -1832,14 +2421,9 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 	case STRING:
 		// If literal looks like a date time then parse it as a time literal.
 		if isDateTimeString(lit) {
-			t, err := time.Parse(DateTimeFormat, lit)
+			t, err := parseDateTime(lit)
 			if err != nil {
-				// try to parse it as an RFCNano time
-				t, err := time.Parse(time.RFC3339Nano, lit)
-				if err != nil {
-					return nil, &ParseError{Message: "unable to parse datetime", Pos: pos}
-				}
-				return &TimeLiteral{Val: t}, nil
+				return nil, &ParseError{Message: "unable to parse datetime", Pos: pos}
 			}
 			return &TimeLiteral{Val: t}, nil
 		} else if isDateString(lit) {
@@ -1850,17 +2434,27 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 			return &TimeLiteral{Val: t}, nil
 		}
 		return &StringLiteral{Val: lit}, nil
-	case NUMBER:
-		v, err := strconv.ParseFloat(lit, 64)
-		if err != nil {
-			return nil, &ParseError{Message: "unable to parse number", Pos: pos}
-		}
-		return &NumberLiteral{Val: v}, nil
+	case NUMBER, DURATION_VAL:
+		return parseNumericLiteral(tok, lit, pos)
 	case TRUE, FALSE:
 		return &BooleanLiteral{Val: (tok == TRUE)}, nil
-	case DURATION_VAL:
-		v, _ := ParseDuration(lit)
-		return &DurationLiteral{Val: v}, nil
+	case ADD, SUB:
+		// Unary plus has no effect so only negation needs its own node.
+		expr, err := p.parseUnaryExpr()
+		if err != nil {
+			return nil, err
+		} else if tok == ADD {
+			return expr, nil
+		}
+		return &UnaryExpr{Op: SUB, Expr: expr}, nil
+	case NOT:
+		// NOT binds less tightly than comparisons so "NOT host = 'a'"
+		// negates the whole comparison.
+		expr, err := p.parseExpr(EQ.Precedence())
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Op: NOT, Expr: expr}, nil
 	case MUL:
 		return &Wildcard{}, nil
 	case REGEX:
@@ -1904,7 +2498,9 @@ func (p *Parser) parseRegex() (*RegexLiteral, error) {
 		return nil, &ParseError{Message: err.Error(), Pos: pos}
 	}
 
-	return &RegexLiteral{Val: re}, nil
+	r := &RegexLiteral{Val: re}
+	r.Position = pos
+	return r, nil
 }
 
 // parseCall parses a function call.
@@ -1984,13 +2580,13 @@ func ParseDuration(s string) (time.Duration, error) {
 
 	// Extract the unit of measure.
 	// If the last character is a digit then parse the whole string as microseconds.
-	// If the last two characters are "ms" the parse as milliseconds.
+	// If the last two characters are "ms" or "ns" the parse as milli or nanoseconds.
 	// Otherwise just use the last character as the unit of measure.
 	var num, uom string
 	if isDigit(rune(a[len(a)-1])) {
 		num, uom = s, "u"
-	} else if len(s) > 2 && s[len(s)-2:] == "ms" {
-		num, uom = string(a[:len(a)-2]), "ms"
+	} else if len(s) > 2 && (s[len(s)-2:] == "ms" || s[len(s)-2:] == "ns") {
+		num, uom = string(a[:len(a)-2]), s[len(s)-2:]
 	} else {
 		num, uom = string(a[:len(a)-1]), string(a[len(a)-1:])
 	}
@@ -2003,6 +2599,8 @@ func ParseDuration(s string) (time.Duration, error) {
 
 	// Multiply by the unit of measure.
 	switch uom {
+	case "ns":
+		return time.Duration(n), nil
 	case "u", "µ":
 		return time.Duration(n) * time.Microsecond, nil
 	case "ms":
@@ -2038,6 +2636,8 @@ func FormatDuration(d time.Duration) string {
 		return fmt.Sprintf("%ds", d/time.Second)
 	} else if d%time.Millisecond == 0 {
 		return fmt.Sprintf("%dms", d/time.Millisecond)
+	} else if d%time.Microsecond != 0 {
+		return fmt.Sprintf("%dns", d)
 	}
 	return fmt.Sprintf("%d", d/time.Microsecond)
 }
@@ -2103,6 +2703,27 @@ func split(s string) (a []rune) {
 	return
 }
 
+// dateTimeFormats are the layouts accepted for date time literals.
+var dateTimeFormats = []string{
+	DateTimeFormat,
+	DateTimeFormat + "Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// parseDateTime parses a date time literal in any of the accepted layouts.
+// Times with an offset from UTC are converted to UTC.
+func parseDateTime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range dateTimeFormats {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
 // isDateString returns true if the string looks like a date-only time literal.
 func isDateString(s string) bool { return dateStringRegexp.MatchString(s) }
 
@@ -162,6 +162,33 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement ordered by time descending
+		{
+			s: `SELECT field1 FROM myseries ORDER BY time DESC LIMIT 10`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "field1"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "myseries"}},
+				SortFields: []*influxql.SortField{
+					{Name: "time", Ascending: false},
+				},
+				Limit: 10,
+			},
+		},
+
+		// SELECT statement ordered descending without a field name
+		{
+			s: `SELECT field1 FROM myseries ORDER BY DESC`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "field1"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "myseries"}},
+				SortFields: []*influxql.SortField{
+					{Ascending: false},
+				},
+			},
+		},
+
 		// SELECT statement with multiple ORDER BY fields
 		{
 			skip: true,
@@ -297,7 +324,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				Condition: &influxql.BinaryExpr{
 					Op:  influxql.GT,
 					LHS: &influxql.VarRef{Val: "load"},
-					RHS: &influxql.NumberLiteral{Val: 100},
+					RHS: &influxql.IntegerLiteral{Val: 100},
 				},
 			},
 		},
@@ -310,7 +337,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				Condition: &influxql.BinaryExpr{
 					Op:  influxql.GTE,
 					LHS: &influxql.VarRef{Val: "load"},
-					RHS: &influxql.NumberLiteral{Val: 100},
+					RHS: &influxql.IntegerLiteral{Val: 100},
 				},
 			},
 		},
@@ -323,7 +350,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				Condition: &influxql.BinaryExpr{
 					Op:  influxql.EQ,
 					LHS: &influxql.VarRef{Val: "load"},
-					RHS: &influxql.NumberLiteral{Val: 100},
+					RHS: &influxql.IntegerLiteral{Val: 100},
 				},
 			},
 		},
@@ -336,7 +363,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				Condition: &influxql.BinaryExpr{
 					Op:  influxql.LTE,
 					LHS: &influxql.VarRef{Val: "load"},
-					RHS: &influxql.NumberLiteral{Val: 100},
+					RHS: &influxql.IntegerLiteral{Val: 100},
 				},
 			},
 		},
@@ -349,7 +376,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				Condition: &influxql.BinaryExpr{
 					Op:  influxql.LT,
 					LHS: &influxql.VarRef{Val: "load"},
-					RHS: &influxql.NumberLiteral{Val: 100},
+					RHS: &influxql.IntegerLiteral{Val: 100},
 				},
 			},
 		},
@@ -362,7 +389,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				Condition: &influxql.BinaryExpr{
 					Op:  influxql.NEQ,
 					LHS: &influxql.VarRef{Val: "load"},
-					RHS: &influxql.NumberLiteral{Val: 100},
+					RHS: &influxql.IntegerLiteral{Val: 100},
 				},
 			},
 		},
@@ -419,6 +446,20 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with regex group by
+		{
+			s: `SELECT value FROM cpu GROUP BY /dc_.*/, host`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				Dimensions: []*influxql.Dimension{
+					{Expr: &influxql.RegexLiteral{Val: regexp.MustCompile("dc_.*")}},
+					{Expr: &influxql.VarRef{Val: "host"}},
+				},
+			},
+		},
+
 		// SELECT statement with group by
 		{
 			s: `SELECT sum(value) FROM "kbps" WHERE time > now() - 120s AND deliveryservice='steam-dns' and cachegroup = 'total' GROUP BY time(60s)`,
@@ -477,6 +518,25 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// SELECT statement with fill(null)
+		{
+			s: fmt.Sprintf(`SELECT mean(value) FROM cpu where time < '%s' GROUP BY time(5m) fill(null)`, now.UTC().Format(time.RFC3339Nano)),
+			stmt: &influxql.SelectStatement{
+				Fields: []*influxql.Field{{
+					Expr: &influxql.Call{
+						Name: "mean",
+						Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.LT,
+					LHS: &influxql.VarRef{Val: "time"},
+					RHS: &influxql.TimeLiteral{Val: now.UTC()},
+				},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: 5 * time.Minute}}}}},
+				Fill:       influxql.NullFill,
+			},
+		},
+
 		// SELECT statement with FILL(none) -- check case insensitivity
 		{
 			s: fmt.Sprintf(`SELECT mean(value) FROM cpu where time < '%s' GROUP BY time(5m) FILL(none)`, now.UTC().Format(time.RFC3339Nano)),
@@ -528,6 +588,37 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// DELETE statement with a database, retention policy and time range
+		{
+			s: `DELETE FROM telegraf."30d".cpu WHERE time < '2000-01-01T00:00:00Z' AND (region =~ /us-.*/ OR host != 'serverA')`,
+			stmt: &influxql.DeleteStatement{
+				Source: &influxql.Measurement{Database: "telegraf", RetentionPolicy: "30d", Name: "cpu"},
+				Condition: &influxql.BinaryExpr{
+					Op: influxql.AND,
+					LHS: &influxql.BinaryExpr{
+						Op:  influxql.LT,
+						LHS: &influxql.VarRef{Val: "time"},
+						RHS: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00Z")},
+					},
+					RHS: &influxql.ParenExpr{
+						Expr: &influxql.BinaryExpr{
+							Op: influxql.OR,
+							LHS: &influxql.BinaryExpr{
+								Op:  influxql.EQREGEX,
+								LHS: &influxql.VarRef{Val: "region"},
+								RHS: &influxql.RegexLiteral{Val: regexp.MustCompile("us-.*")},
+							},
+							RHS: &influxql.BinaryExpr{
+								Op:  influxql.NEQ,
+								LHS: &influxql.VarRef{Val: "host"},
+								RHS: &influxql.StringLiteral{Val: "serverA"},
+							},
+						},
+					},
+				},
+			},
+		},
+
 		// SHOW SERVERS
 		{
 			s:    `SHOW SERVERS`,
@@ -546,6 +637,36 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowDatabasesStatement{},
 		},
 
+		// EXPLAIN statement
+		{
+			s: `EXPLAIN SELECT value FROM cpu WHERE host = 'serverA'`,
+			stmt: &influxql.ExplainStatement{
+				Statement: &influxql.SelectStatement{
+					IsRawQuery: true,
+					Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+					Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+					Condition: &influxql.BinaryExpr{
+						Op:  influxql.EQ,
+						LHS: &influxql.VarRef{Val: "host"},
+						RHS: &influxql.StringLiteral{Val: "serverA"},
+					},
+				},
+			},
+		},
+
+		// EXPLAIN ANALYZE statement
+		{
+			s: `EXPLAIN ANALYZE SELECT value FROM cpu`,
+			stmt: &influxql.ExplainStatement{
+				Statement: &influxql.SelectStatement{
+					IsRawQuery: true,
+					Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+					Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+				},
+				Analyze: true,
+			},
+		},
+
 		// SHOW SERIES statement
 		{
 			s:    `SHOW SERIES`,
@@ -822,6 +943,21 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowContinuousQueriesStatement{},
 		},
 
+		// SHOW CONTINUOUS QUERIES with ON, WHERE, LIMIT and OFFSET
+		{
+			s: `SHOW CONTINUOUS QUERIES ON mydb WHERE name = 'cq0' LIMIT 10 OFFSET 20`,
+			stmt: &influxql.ShowContinuousQueriesStatement{
+				Database: "mydb",
+				Condition: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "name"},
+					RHS: &influxql.StringLiteral{Val: "cq0"},
+				},
+				Limit:  10,
+				Offset: 20,
+			},
+		},
+
 		// CREATE CONTINUOUS QUERY ... INTO <measurement>
 		{
 			s: `CREATE CONTINUOUS QUERY myquery ON testdb BEGIN SELECT count(field1) INTO measure1 FROM myseries GROUP BY time(5m) END`,
@@ -846,6 +982,57 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE CONTINUOUS QUERY ... RESAMPLE EVERY <duration> FOR <duration>
+		{
+			s: `CREATE CONTINUOUS QUERY myquery ON testdb RESAMPLE EVERY 1m FOR 1h BEGIN SELECT count(field1) INTO measure1 FROM myseries GROUP BY time(5m) END`,
+			stmt: &influxql.CreateContinuousQueryStatement{
+				Name:          "myquery",
+				Database:      "testdb",
+				ResampleEvery: time.Minute,
+				ResampleFor:   time.Hour,
+				Source: &influxql.SelectStatement{
+					Fields:  []*influxql.Field{{Expr: &influxql.Call{Name: "count", Args: []influxql.Expr{&influxql.VarRef{Val: "field1"}}}}},
+					Target:  &influxql.Target{Measurement: &influxql.Measurement{Name: "measure1"}},
+					Sources: []influxql.Source{&influxql.Measurement{Name: "myseries"}},
+					Dimensions: []*influxql.Dimension{
+						{
+							Expr: &influxql.Call{
+								Name: "time",
+								Args: []influxql.Expr{
+									&influxql.DurationLiteral{Val: 5 * time.Minute},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		// CREATE CONTINUOUS QUERY ... RESAMPLE FOR <duration>
+		{
+			s: `CREATE CONTINUOUS QUERY myquery ON testdb RESAMPLE FOR 10m BEGIN SELECT count(field1) INTO measure1 FROM myseries GROUP BY time(5m) END`,
+			stmt: &influxql.CreateContinuousQueryStatement{
+				Name:        "myquery",
+				Database:    "testdb",
+				ResampleFor: 10 * time.Minute,
+				Source: &influxql.SelectStatement{
+					Fields:  []*influxql.Field{{Expr: &influxql.Call{Name: "count", Args: []influxql.Expr{&influxql.VarRef{Val: "field1"}}}}},
+					Target:  &influxql.Target{Measurement: &influxql.Measurement{Name: "measure1"}},
+					Sources: []influxql.Source{&influxql.Measurement{Name: "myseries"}},
+					Dimensions: []*influxql.Dimension{
+						{
+							Expr: &influxql.Call{
+								Name: "time",
+								Args: []influxql.Expr{
+									&influxql.DurationLiteral{Val: 5 * time.Minute},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
 		{
 			s: `create continuous query "this.is-a.test" on segments begin select * into measure1 from cpu_load_short end`,
 			stmt: &influxql.CreateContinuousQueryStatement{
@@ -886,6 +1073,72 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE CONTINUOUS QUERY ... INTO <database>.<retention-policy>.<measurement>
+		{
+			s: `CREATE CONTINUOUS QUERY myquery ON testdb BEGIN SELECT count(field1) INTO otherdb."1h.policy1"."cpu.load" FROM myseries GROUP BY time(5m) END`,
+			stmt: &influxql.CreateContinuousQueryStatement{
+				Name:     "myquery",
+				Database: "testdb",
+				Source: &influxql.SelectStatement{
+					Fields: []*influxql.Field{{Expr: &influxql.Call{Name: "count", Args: []influxql.Expr{&influxql.VarRef{Val: "field1"}}}}},
+					Target: &influxql.Target{
+						Measurement: &influxql.Measurement{Database: "otherdb", RetentionPolicy: "1h.policy1", Name: "cpu.load"},
+					},
+					Sources: []influxql.Source{&influxql.Measurement{Name: "myseries"}},
+					Dimensions: []*influxql.Dimension{
+						{
+							Expr: &influxql.Call{
+								Name: "time",
+								Args: []influxql.Expr{
+									&influxql.DurationLiteral{Val: 5 * time.Minute},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		// SELECT ... INTO <database>..<measurement>
+		{
+			s: `SELECT value INTO otherdb..cpu FROM myseries`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+				Target: &influxql.Target{
+					Measurement: &influxql.Measurement{Database: "otherdb", Name: "cpu"},
+				},
+				Sources: []influxql.Source{&influxql.Measurement{Name: "myseries"}},
+			},
+		},
+
+		// SELECT ... INTO <retention policy>.:MEASUREMENT
+		{
+			s: `SELECT mean(value) INTO "two_weeks".:MEASUREMENT FROM /.*/ WHERE time > now() - 1h GROUP BY time(10m)`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: false,
+				Fields:     []*influxql.Field{{Expr: &influxql.Call{Name: "mean", Args: []influxql.Expr{&influxql.VarRef{Val: "value"}}}}},
+				Target: &influxql.Target{
+					Measurement: &influxql.Measurement{RetentionPolicy: "two_weeks"},
+					Backref:     true,
+				},
+				Sources:    []influxql.Source{&influxql.Measurement{Regex: &influxql.RegexLiteral{Val: regexp.MustCompile(".*")}}},
+				Condition:  &influxql.BinaryExpr{Op: influxql.GT, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.BinaryExpr{Op: influxql.SUB, LHS: &influxql.Call{Name: "now"}, RHS: &influxql.DurationLiteral{Val: time.Hour}}},
+				Dimensions: []*influxql.Dimension{{Expr: &influxql.Call{Name: "time", Args: []influxql.Expr{&influxql.DurationLiteral{Val: 10 * time.Minute}}}}},
+			},
+		},
+
+		// SELECT ... INTO :MEASUREMENT
+		{
+			s: `SELECT value INTO :MEASUREMENT FROM cpu`,
+			stmt: &influxql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*influxql.Field{{Expr: &influxql.VarRef{Val: "value"}}},
+				Target:     &influxql.Target{Measurement: &influxql.Measurement{}, Backref: true},
+				Sources:    []influxql.Source{&influxql.Measurement{Name: "cpu"}},
+			},
+		},
+
 		// CREATE CONTINUOUS QUERY for non-aggregate SELECT stmts
 		{
 			s: `CREATE CONTINUOUS QUERY myquery ON testdb BEGIN SELECT value INTO "policy1"."value" FROM myseries END`,
@@ -942,9 +1195,9 @@ func TestParser_ParseStatement(t *testing.T) {
 		{
 			s: `CREATE USER testuser WITH PASSWORD 'pwd1337' WITH ALL PRIVILEGES`,
 			stmt: &influxql.CreateUserStatement{
-				Name:      "testuser",
-				Password:  "pwd1337",
-				Privilege: influxql.NewPrivilege(influxql.AllPrivileges),
+				Name:     "testuser",
+				Password: "pwd1337",
+				Admin:    true,
 			},
 		},
 
@@ -963,6 +1216,28 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.DropContinuousQueryStatement{Name: "myquery", Database: "foo"},
 		},
 
+		// BACKFILL CONTINUOUS QUERY statement
+		{
+			s: `BACKFILL CONTINUOUS QUERY myquery ON foo WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-02T00:00:00Z'`,
+			stmt: &influxql.BackfillContinuousQueryStatement{
+				Name:     "myquery",
+				Database: "foo",
+				Condition: &influxql.BinaryExpr{
+					Op: influxql.AND,
+					LHS: &influxql.BinaryExpr{
+						Op:  influxql.GTE,
+						LHS: &influxql.VarRef{Val: "time"},
+						RHS: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00Z")},
+					},
+					RHS: &influxql.BinaryExpr{
+						Op:  influxql.LT,
+						LHS: &influxql.VarRef{Val: "time"},
+						RHS: &influxql.TimeLiteral{Val: mustParseTime("2000-01-02T00:00:00Z")},
+					},
+				},
+			},
+		},
+
 		// DROP DATABASE statement
 		{
 			s:    `DROP DATABASE testdb`,
@@ -984,6 +1259,42 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// CREATE SUBSCRIPTION statement
+		{
+			s: `CREATE SUBSCRIPTION "s" ON db."rp" DESTINATIONS ALL 'udp://host:9090'`,
+			stmt: &influxql.CreateSubscriptionStatement{
+				Name:            "s",
+				Database:        "db",
+				RetentionPolicy: "rp",
+				Destinations:    []string{"udp://host:9090"},
+				Mode:            "ALL",
+			},
+		},
+
+		// CREATE SUBSCRIPTION statement with multiple destinations
+		{
+			s: `create subscription s0 on db."default" destinations any 'udp://h1:9090', 'udp://h2:9090'`,
+			stmt: &influxql.CreateSubscriptionStatement{
+				Name:            "s0",
+				Database:        "db",
+				RetentionPolicy: "default",
+				Destinations:    []string{"udp://h1:9090", "udp://h2:9090"},
+				Mode:            "ANY",
+			},
+		},
+
+		// DROP SUBSCRIPTION statement
+		{
+			s:    `DROP SUBSCRIPTION "s" ON db."rp"`,
+			stmt: &influxql.DropSubscriptionStatement{Name: "s", Database: "db", RetentionPolicy: "rp"},
+		},
+
+		// SHOW SUBSCRIPTIONS statement
+		{
+			s:    `SHOW SUBSCRIPTIONS`,
+			stmt: &influxql.ShowSubscriptionsStatement{},
+		},
+
 		// DROP USER statement
 		{
 			s:    `DROP USER jdoe`,
@@ -1000,6 +1311,17 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		// GRANT READ on a measurement
+		{
+			s: `GRANT READ ON testdb.cpu TO jdoe`,
+			stmt: &influxql.GrantStatement{
+				Privilege:   influxql.ReadPrivilege,
+				On:          "testdb",
+				Measurement: "cpu",
+				User:        "jdoe",
+			},
+		},
+
 		// GRANT WRITE
 		{
 			s: `GRANT WRITE ON testdb TO jdoe`,
@@ -1189,10 +1511,25 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &influxql.ShowDiagnosticsStatement{},
 		},
 
+		// SHOW QUERIES
+		{
+			s:    `SHOW QUERIES`,
+			stmt: &influxql.ShowQueriesStatement{},
+		},
+
+		// KILL QUERY
+		{
+			s:    `KILL QUERY 4`,
+			stmt: &influxql.KillQueryStatement{QueryID: 4},
+		},
+
 		// Errors
-		{s: ``, err: `found EOF, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: ``, err: `found EOF, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET, EXPLAIN, BACKFILL, KILL at line 1, char 1`},
 		{s: `SELECT`, err: `found EOF, expected identifier, string, number, bool at line 1, char 8`},
-		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET at line 1, char 1`},
+		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, GRANT, REVOKE, ALTER, SET, EXPLAIN, BACKFILL, KILL at line 1, char 1`},
+		{s: `EXPLAIN SHOW SERIES`, err: `found SHOW, expected SELECT at line 1, char 9`},
+		{s: `EXPLAIN ANALYZE DROP SERIES FROM cpu`, err: `found DROP, expected SELECT at line 1, char 17`},
+		{s: `EXPLAIN SELECT`, err: `found EOF, expected identifier, string, number, bool at line 1, char 16`},
 		{s: `SELECT field1 X`, err: `found X, expected FROM at line 1, char 15`},
 		{s: `SELECT field1 FROM "series" WHERE X +;`, err: `found ;, expected identifier, string, number, bool at line 1, char 38`},
 		{s: `SELECT field1 FROM myseries GROUP`, err: `found EOF, expected BY at line 1, char 35`},
@@ -1200,14 +1537,23 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT field1 FROM myseries LIMIT 10.5`, err: `fractional parts not allowed in LIMIT at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries OFFSET`, err: `found EOF, expected number at line 1, char 36`},
 		{s: `SELECT field1 FROM myseries OFFSET 10.5`, err: `fractional parts not allowed in OFFSET at line 1, char 36`},
+		{s: `SELECT field1 FROM myseries SLIMIT`, err: `found EOF, expected number at line 1, char 36`},
+		{s: `SELECT field1 FROM myseries SLIMIT 10.5`, err: `fractional parts not allowed in SLIMIT at line 1, char 36`},
+		{s: `SELECT field1 FROM myseries SOFFSET`, err: `found EOF, expected number at line 1, char 37`},
+		{s: `SELECT field1 FROM myseries SOFFSET 10.5`, err: `fractional parts not allowed in SOFFSET at line 1, char 37`},
 		{s: `SELECT field1 FROM myseries ORDER`, err: `found EOF, expected BY at line 1, char 35`},
-		{s: `SELECT field1 FROM myseries ORDER BY /`, err: `only ORDER BY ASC supported at this time`},
-		{s: `SELECT field1 FROM myseries ORDER BY 1`, err: `only ORDER BY ASC supported at this time`},
-		{s: `SELECT field1 FROM myseries ORDER BY DESC`, err: `only ORDER BY ASC supported at this time`},
+		{s: `SELECT value INTO :cpu FROM myseries`, err: `found cpu, expected MEASUREMENT at line 1, char 20`},
+		{s: `SELECT value INTO db.rp.cpu.:MEASUREMENT FROM myseries`, err: `too many segments in "db"."rp".cpu.:MEASUREMENT at line 1, char 1`},
+		{s: `SELECT value INTO db.rp.:MEASUREMENT.cpu FROM myseries`, err: `found ., expected FROM at line 1, char 37`},
+		{s: `SELECT field1 FROM myseries ORDER BY /`, err: `only ORDER BY time supported at this time`},
+		{s: `SELECT field1 FROM myseries ORDER BY 1`, err: `only ORDER BY time supported at this time`},
+		{s: `SELECT field1 FROM myseries ORDER BY field1 DESC`, err: `only ORDER BY time supported at this time`},
 		{s: `SELECT field1 AS`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `SELECT field1 FROM foo group by time(1s)`, err: `GROUP BY requires at least one aggregate function`},
 		{s: `SELECT count(value) FROM foo group by time(1s)`, err: `aggregate functions with GROUP BY time require a WHERE time clause`},
 		{s: `SELECT count(value) FROM foo group by time(1s) where host = 'hosta.influxdb.org'`, err: `aggregate functions with GROUP BY time require a WHERE time clause`},
+		{s: `SELECT mean(value) FROM foo WHERE time < now() GROUP BY time(1m) fill()`, err: `fill requires an argument, e.g.: 0, null, none, previous`},
+		{s: `SELECT mean(value) FROM foo WHERE time < now() GROUP BY time(1m) fill(foo)`, err: `expected number argument in fill()`},
 		{s: `SELECT field1 FROM 12`, err: `found 12, expected identifier at line 1, char 20`},
 		{s: `SELECT 1000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000 FROM myseries`, err: `unable to parse number at line 1, char 8`},
 		{s: `SELECT 10.5h FROM myseries`, err: `found h, expected FROM at line 1, char 12`},
@@ -1221,20 +1567,67 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `SELECT count(distinct) FROM myseries`, err: `found ), expected (, identifier at line 1, char 22`},
 		{s: `SELECT count(distinct field1, field2) FROM myseries`, err: `count(distinct <field>) can only have one argument`},
 		{s: `select count(distinct(too, many, arguments)) from myseries`, err: `count(distinct <field>) can only have one argument`},
+		{s: `select percentile(*, 10) from myseries`, err: `expected field argument in percentile()`},
+		{s: `select foo(value) from myseries`, err: `function not found: "foo"`},
+		{s: `select mean(value) + bar(value) from myseries`, err: `function not found: "bar"`},
+		{s: `select percentile(value) from myseries`, err: `invalid number of arguments for percentile, expected 2, got 1`},
+		{s: `select percentile(value, host) from myseries`, err: `expected number argument in percentile()`},
+		{s: `select percentile(10, value) from myseries`, err: `expected field argument in percentile()`},
+		{s: `select derivative(value, 10) from myseries`, err: `expected duration argument in derivative()`},
+		{s: `select derivative(value, 0s) from myseries`, err: `derivative duration must be positive`},
+		{s: `select non_negative_derivative(mean(value), 0s) from myseries where time > now() - 1d group by time(1h)`, err: `non_negative_derivative duration must be positive`},
+		{s: `select derivative(mean(value, 1)) from myseries`, err: `invalid number of arguments for mean, expected 1, got 2`},
+		{s: `select holt_winters(value, 10, 4) from myseries where time > now() - 1d group by time(1h)`, err: `expected aggregate argument in holt_winters()`},
+		{s: `select holt_winters(mean(value), 10) from myseries where time > now() - 1d group by time(1h)`, err: `invalid number of arguments for holt_winters, expected 3, got 2`},
+		{s: `select holt_winters(mean(value), 10, 4) from myseries`, err: `holt_winters requires a GROUP BY time interval`},
+		{s: `select holt_winters(mean(value), 0, 4) from myseries where time > now() - 1d group by time(1h)`, err: `holt_winters requires a positive integer number of points to forecast`},
+		{s: `select holt_winters_with_fit(mean(value), 10, 1.5) from myseries where time > now() - 1d group by time(1h)`, err: `holt_winters_with_fit requires a non-negative integer season length`},
+		{s: `select holt_winters(mean(value), 10, 4), max(value) from myseries where time > now() - 1d group by time(1h)`, err: `holt_winters cannot be used with other fields`},
+		{s: `select moving_average(value) from myseries`, err: `invalid number of arguments for moving_average, expected 2, got 1`},
+		{s: `select moving_average(value, 1) from myseries`, err: `moving_average window must be an integer greater than 1`},
+		{s: `select moving_average(value, 2.5) from myseries`, err: `moving_average window must be an integer greater than 1`},
+		{s: `select cumulative_sum(value), max(value) from myseries`, err: `cumulative_sum cannot be used with other fields`},
+		{s: `select difference(max(value)) from myseries`, err: `difference of an aggregate requires a GROUP BY time interval`},
+		{s: `select difference(value) from myseries where time > now() - 1d group by time(1h)`, err: `difference of a field cannot be used with a GROUP BY time interval`},
+		{s: `select difference(cumulative_sum(max(value))) from myseries where time > now() - 1d group by time(1h)`, err: `cumulative_sum() cannot be nested in difference()`},
+		{s: `select cumulative_sum(1h) from myseries`, err: `expected field argument in cumulative_sum()`},
+		{s: `select elapsed(mean(value)) from myseries where time > now() - 1d group by time(1h)`, err: `expected field argument in elapsed()`},
+		{s: `select elapsed(value, 10) from myseries`, err: `expected duration argument in elapsed()`},
+		{s: `select elapsed(value, 0s) from myseries`, err: `elapsed unit must be a positive duration`},
+		{s: `select elapsed(value), max(value) from myseries`, err: `elapsed cannot be used with other fields`},
+		{s: `select mean(value) / value from myseries`, err: `mixing aggregate and non-aggregate fields is not supported: mean(value) / value`},
+		{s: `select value, mean(value) from myseries`, err: `mixing aggregate and non-aggregate fields is not supported: value`},
+		{s: `select mean(value) as v, max(value) as v from myseries`, err: `duplicate alias in select clause: v`},
+		{s: `select mean(value) from myseries where time > now() - 1h group by time(1m), time(5m)`, err: `multiple time dimensions not allowed`},
+		{s: `select mean(value) from myseries group by host fill(0)`, err: `fill() requires a GROUP BY time interval`},
+		{s: `select host, mean(value) from myseries group by region`, err: `mixing aggregate and non-aggregate fields is not supported: host`},
+		{s: `select (max(value) - min(value)) * value from myseries where time > now() - 1d group by time(1h)`, err: `mixing aggregate and non-aggregate fields is not supported: (max(value) - min(value)) * value`},
+		{s: `select sample(value) from myseries`, err: `invalid number of arguments for sample, expected 2, got 1`},
+		{s: `select sample(value, 0) from myseries`, err: `sample requires a positive integer number of points`},
+		{s: `select sample(value, 2.5) from myseries`, err: `sample requires a positive integer number of points`},
+		{s: `select sample(max(value), 2) from myseries where time > now() - 1d group by time(1h)`, err: `expected field argument in sample()`},
 		{s: `select count() from myseries`, err: `invalid number of arguments for count, expected 1, got 0`},
 		{s: `select derivative() from myseries`, err: `invalid number of arguments for derivative, expected at least 1 but no more than 2, got 0`},
 		{s: `select derivative(mean(value), 1h, 3) from myseries`, err: `invalid number of arguments for derivative, expected at least 1 but no more than 2, got 3`},
 		{s: `DELETE`, err: `found EOF, expected FROM at line 1, char 8`},
 		{s: `DELETE FROM`, err: `found EOF, expected identifier at line 1, char 13`},
 		{s: `DELETE FROM myseries WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
+		{s: `DELETE FROM myseries WHERE value > 10`, err: `DELETE only supports time and tag conditions: value > 10`},
+		{s: `DELETE FROM myseries WHERE time < now() AND host > 'server'`, err: `DELETE only supports time and tag conditions: host > 'server'`},
+		{s: `DELETE FROM myseries WHERE time != now()`, err: `DELETE only supports time and tag conditions: time != now()`},
+		{s: `DELETE FROM myseries WHERE 'host' = host`, err: `DELETE only supports time and tag conditions: 'host' = host`},
 		{s: `DROP MEASUREMENT`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `DROP SERIES`, err: `found EOF, expected FROM, WHERE at line 1, char 13`},
 		{s: `DROP SERIES FROM`, err: `found EOF, expected identifier at line 1, char 18`},
 		{s: `DROP SERIES FROM src WHERE`, err: `found EOF, expected identifier, string, number, bool at line 1, char 28`},
 		{s: `SHOW CONTINUOUS`, err: `found EOF, expected QUERIES at line 1, char 17`},
+		{s: `SHOW CONTINUOUS QUERIES ON`, err: `found EOF, expected identifier at line 1, char 28`},
+		{s: `SHOW CONTINUOUS QUERIES LIMIT`, err: `found EOF, expected number at line 1, char 31`},
+		{s: `KILL`, err: `found EOF, expected QUERY at line 1, char 6`},
+		{s: `KILL QUERY`, err: `found EOF, expected number at line 1, char 12`},
 		{s: `SHOW RETENTION`, err: `found EOF, expected POLICIES at line 1, char 16`},
 		{s: `SHOW RETENTION POLICIES`, err: `found EOF, expected identifier at line 1, char 25`},
-		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, FIELD, GRANTS, MEASUREMENTS, RETENTION, SERIES, SERVERS, TAG, USERS at line 1, char 6`},
+		{s: `SHOW FOO`, err: `found FOO, expected CONTINUOUS, DATABASES, FIELD, GRANTS, MEASUREMENTS, QUERIES, RETENTION, SERIES, SERVERS, SUBSCRIPTIONS, TAG, USERS at line 1, char 6`},
 		{s: `SHOW STATS ON`, err: `found EOF, expected string at line 1, char 15`},
 		{s: `SHOW GRANTS`, err: `found EOF, expected FOR at line 1, char 13`},
 		{s: `SHOW GRANTS FOR`, err: `found EOF, expected identifier at line 1, char 17`},
@@ -1242,15 +1635,34 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `DROP CONTINUOUS QUERY`, err: `found EOF, expected identifier at line 1, char 23`},
 		{s: `DROP CONTINUOUS QUERY myquery`, err: `found EOF, expected ON at line 1, char 31`},
 		{s: `DROP CONTINUOUS QUERY myquery ON`, err: `found EOF, expected identifier at line 1, char 34`},
+		{s: `BACKFILL CONTINUOUS`, err: `found EOF, expected QUERY at line 1, char 21`},
+		{s: `BACKFILL CONTINUOUS QUERY myquery`, err: `found EOF, expected ON at line 1, char 35`},
+		{s: `BACKFILL CONTINUOUS QUERY myquery ON foo`, err: `BACKFILL requires a lower time bound in the WHERE clause`},
+		{s: `BACKFILL CONTINUOUS QUERY myquery ON foo WHERE time < now()`, err: `BACKFILL requires a lower time bound in the WHERE clause`},
+		{s: `BACKFILL CONTINUOUS QUERY myquery ON foo WHERE time > now() - 1d AND host = 'a'`, err: `BACKFILL only supports time conditions: host`},
 		{s: `CREATE CONTINUOUS`, err: `found EOF, expected QUERY at line 1, char 19`},
 		{s: `CREATE CONTINUOUS QUERY`, err: `found EOF, expected identifier at line 1, char 25`},
-		{s: `DROP FOO`, err: `found FOO, expected SERIES, CONTINUOUS, MEASUREMENT at line 1, char 6`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`, err: `found BEGIN, expected EVERY, FOR at line 1, char 43`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE EVERY BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`, err: `found BEGIN, expected duration at line 1, char 49`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE EVERY 0s BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`, err: `duration must be positive at line 1, char 49`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db BACKFILL BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`, err: `BACKFILL requires a lower time bound in the WHERE clause`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db BACKFILL BEGIN SELECT count(value) INTO cpu2 FROM cpu WHERE time < now() GROUP BY time(1m) END`, err: `BACKFILL requires a lower time bound in the WHERE clause`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db BACKFILL RESAMPLE EVERY 1m BEGIN SELECT count(value) INTO cpu2 FROM cpu WHERE time > now() - 1d GROUP BY time(1m) END`, err: `found RESAMPLE, expected BEGIN at line 1, char 43`},
+		{s: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE FOR 30s BEGIN SELECT count(value) INTO cpu2 FROM cpu GROUP BY time(1m) END`, err: `FOR duration must be >= GROUP BY time duration: must be a minimum of 1m, got 30s`},
+		{s: `DROP FOO`, err: `found FOO, expected SERIES, CONTINUOUS, MEASUREMENT, SUBSCRIPTION at line 1, char 6`},
 		{s: `DROP DATABASE`, err: `found EOF, expected identifier at line 1, char 15`},
 		{s: `DROP RETENTION`, err: `found EOF, expected POLICY at line 1, char 16`},
 		{s: `DROP RETENTION POLICY`, err: `found EOF, expected identifier at line 1, char 23`},
 		{s: `DROP RETENTION POLICY "1h.cpu"`, err: `found EOF, expected ON at line 1, char 31`},
 		{s: `DROP RETENTION POLICY "1h.cpu" ON`, err: `found EOF, expected identifier at line 1, char 35`},
 		{s: `DROP USER`, err: `found EOF, expected identifier at line 1, char 11`},
+		{s: `CREATE SUBSCRIPTION`, err: `found EOF, expected identifier at line 1, char 21`},
+		{s: `CREATE SUBSCRIPTION "s" ON db`, err: `found EOF, expected . at line 1, char 31`},
+		{s: `CREATE SUBSCRIPTION "s" ON db."rp"`, err: `found EOF, expected DESTINATIONS at line 1, char 35`},
+		{s: `CREATE SUBSCRIPTION "s" ON db."rp" DESTINATIONS`, err: `found EOF, expected ALL, ANY at line 1, char 49`},
+		{s: `CREATE SUBSCRIPTION "s" ON db."rp" DESTINATIONS ALL`, err: `found EOF, expected string at line 1, char 53`},
+		{s: `CREATE SUBSCRIPTION "s" ON db."rp" DESTINATIONS ALL 'udp://host:9090',`, err: `found EOF, expected string at line 1, char 71`},
+		{s: `DROP SUBSCRIPTION "s" ON db`, err: `found EOF, expected . at line 1, char 29`},
 		{s: `CREATE USER testuser`, err: `found EOF, expected WITH at line 1, char 22`},
 		{s: `CREATE USER testuser WITH`, err: `found EOF, expected PASSWORD at line 1, char 27`},
 		{s: `CREATE USER testuser WITH PASSWORD`, err: `found EOF, expected string at line 1, char 36`},
@@ -1269,6 +1681,8 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `REVOKE READ ON`, err: `found EOF, expected identifier at line 1, char 16`},
 		{s: `REVOKE READ ON testdb`, err: `found EOF, expected FROM at line 1, char 23`},
 		{s: `REVOKE READ ON testdb FROM`, err: `found EOF, expected identifier at line 1, char 28`},
+		{s: `GRANT READ ON testdb.rp.cpu TO jdoe`, err: `invalid privilege target: testdb.rp.cpu at line 1, char 15`},
+		{s: `REVOKE READ ON testdb..cpu FROM jdoe`, err: `invalid privilege target: testdb..cpu at line 1, char 16`},
 		{s: `CREATE RETENTION`, err: `found EOF, expected POLICY at line 1, char 18`},
 		{s: `CREATE RETENTION POLICY`, err: `found EOF, expected identifier at line 1, char 25`},
 		{s: `CREATE RETENTION POLICY policy1`, err: `found EOF, expected ON at line 1, char 33`},
@@ -1301,6 +1715,7 @@ func TestParser_ParseStatement(t *testing.T) {
 			continue
 		}
 		stmt, err := influxql.NewParser(strings.NewReader(tt.s)).ParseStatement()
+		clearPos(stmt)
 
 		// We are memoizing a field so for testing we need to...
 		if s, ok := tt.stmt.(*influxql.SelectStatement); ok {
@@ -1309,6 +1724,8 @@ func TestParser_ParseStatement(t *testing.T) {
 			if st != nil && st.Source != nil {
 				tt.stmt.(*influxql.CreateContinuousQueryStatement).Source.GroupByInterval()
 			}
+		} else if s, ok := tt.stmt.(*influxql.ExplainStatement); ok {
+			s.Statement.GroupByInterval()
 		}
 
 		if !reflect.DeepEqual(tt.err, errstring(err)) {
@@ -1328,7 +1745,9 @@ func TestParser_ParseExpr(t *testing.T) {
 		err  string
 	}{
 		// Primitives
-		{s: `100`, expr: &influxql.NumberLiteral{Val: 100}},
+		{s: `100`, expr: &influxql.IntegerLiteral{Val: 100}},
+		{s: `100.0`, expr: &influxql.NumberLiteral{Val: 100}},
+		{s: `9223372036854775808`, expr: &influxql.NumberLiteral{Val: 9223372036854775808}},
 		{s: `'foo bar'`, expr: &influxql.StringLiteral{Val: "foo bar"}},
 		{s: `true`, expr: &influxql.BooleanLiteral{Val: true}},
 		{s: `false`, expr: &influxql.BooleanLiteral{Val: false}},
@@ -1336,16 +1755,73 @@ func TestParser_ParseExpr(t *testing.T) {
 		{s: `'2000-01-01 00:00:00'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00Z")}},
 		{s: `'2000-01-01 00:00:00.232'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00.232Z")}},
 		{s: `'2000-01-32 00:00:00'`, err: `unable to parse datetime at line 1, char 1`},
+		{s: `'2000-01-01 00:00:00.123456789'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00.123456789Z")}},
+		{s: `'2000-01-01 02:00:00+02:00'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00Z")}},
+		{s: `'2000-01-01T00:00:00'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00Z")}},
+		{s: `'2015-03-01T12:00:00+02:00'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2015-03-01T10:00:00Z")}},
+		{s: `'2015-03-01T12:00:00.5-05:30'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2015-03-01T17:30:00.5Z")}},
+		{s: `'2015-03-01T12:00:00+25:00'`, err: `unable to parse datetime at line 1, char 1`},
 		{s: `'2000-01-01'`, expr: &influxql.TimeLiteral{Val: mustParseTime("2000-01-01T00:00:00Z")}},
 		{s: `'2000-01-99'`, err: `unable to parse date at line 1, char 1`},
 
+		// Epoch times
+		{
+			s: `time > 1434059627s`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.GT,
+				LHS: &influxql.VarRef{Val: "time"},
+				RHS: &influxql.TimeLiteral{Val: mustParseTime("2015-06-11T21:53:47Z")},
+			},
+		},
+		{
+			s: `1434059627123ms <= TIME`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.LTE,
+				LHS: &influxql.TimeLiteral{Val: mustParseTime("2015-06-11T21:53:47.123Z")},
+				RHS: &influxql.VarRef{Val: "TIME"},
+			},
+		},
+		{
+			s: `NOT (time = 1434059627123456u)`,
+			expr: &influxql.UnaryExpr{
+				Op: influxql.NOT,
+				Expr: &influxql.ParenExpr{
+					Expr: &influxql.BinaryExpr{
+						Op:  influxql.EQ,
+						LHS: &influxql.VarRef{Val: "time"},
+						RHS: &influxql.TimeLiteral{Val: mustParseTime("2015-06-11T21:53:47.123456Z")},
+					},
+				},
+			},
+		},
+		{
+			s: `time < 1434059627123456789ns`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.LT,
+				LHS: &influxql.VarRef{Val: "time"},
+				RHS: &influxql.TimeLiteral{Val: mustParseTime("2015-06-11T21:53:47.123456789Z")},
+			},
+		},
+		{
+			s: `time > now() - 10s`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.GT,
+				LHS: &influxql.VarRef{Val: "time"},
+				RHS: &influxql.BinaryExpr{
+					Op:  influxql.SUB,
+					LHS: &influxql.Call{Name: "now"},
+					RHS: &influxql.DurationLiteral{Val: 10 * time.Second},
+				},
+			},
+		},
+
 		// Simple binary expression
 		{
 			s: `1 + 2`,
 			expr: &influxql.BinaryExpr{
 				Op:  influxql.ADD,
-				LHS: &influxql.NumberLiteral{Val: 1},
-				RHS: &influxql.NumberLiteral{Val: 2},
+				LHS: &influxql.IntegerLiteral{Val: 1},
+				RHS: &influxql.IntegerLiteral{Val: 2},
 			},
 		},
 
@@ -1356,10 +1832,10 @@ func TestParser_ParseExpr(t *testing.T) {
 				Op: influxql.ADD,
 				LHS: &influxql.BinaryExpr{
 					Op:  influxql.MUL,
-					LHS: &influxql.NumberLiteral{Val: 1},
-					RHS: &influxql.NumberLiteral{Val: 2},
+					LHS: &influxql.IntegerLiteral{Val: 1},
+					RHS: &influxql.IntegerLiteral{Val: 2},
 				},
-				RHS: &influxql.NumberLiteral{Val: 3},
+				RHS: &influxql.IntegerLiteral{Val: 3},
 			},
 		},
 
@@ -1368,11 +1844,11 @@ func TestParser_ParseExpr(t *testing.T) {
 			s: `1 + 2 * 3`,
 			expr: &influxql.BinaryExpr{
 				Op:  influxql.ADD,
-				LHS: &influxql.NumberLiteral{Val: 1},
+				LHS: &influxql.IntegerLiteral{Val: 1},
 				RHS: &influxql.BinaryExpr{
 					Op:  influxql.MUL,
-					LHS: &influxql.NumberLiteral{Val: 2},
-					RHS: &influxql.NumberLiteral{Val: 3},
+					LHS: &influxql.IntegerLiteral{Val: 2},
+					RHS: &influxql.IntegerLiteral{Val: 3},
 				},
 			},
 		},
@@ -1385,11 +1861,11 @@ func TestParser_ParseExpr(t *testing.T) {
 				LHS: &influxql.ParenExpr{
 					Expr: &influxql.BinaryExpr{
 						Op:  influxql.ADD,
-						LHS: &influxql.NumberLiteral{Val: 1},
-						RHS: &influxql.NumberLiteral{Val: 2},
+						LHS: &influxql.IntegerLiteral{Val: 1},
+						RHS: &influxql.IntegerLiteral{Val: 2},
 					},
 				},
-				RHS: &influxql.NumberLiteral{Val: 3},
+				RHS: &influxql.IntegerLiteral{Val: 3},
 			},
 		},
 
@@ -1400,10 +1876,10 @@ func TestParser_ParseExpr(t *testing.T) {
 				Op: influxql.MUL,
 				LHS: &influxql.BinaryExpr{
 					Op:  influxql.MUL,
-					LHS: &influxql.NumberLiteral{Val: 1},
-					RHS: &influxql.NumberLiteral{Val: 2},
+					LHS: &influxql.IntegerLiteral{Val: 1},
+					RHS: &influxql.IntegerLiteral{Val: 2},
 				},
-				RHS: &influxql.NumberLiteral{Val: 3},
+				RHS: &influxql.IntegerLiteral{Val: 3},
 			},
 		},
 
@@ -1429,14 +1905,14 @@ func TestParser_ParseExpr(t *testing.T) {
 						LHS: &influxql.BinaryExpr{
 							Op:  influxql.ADD,
 							LHS: &influxql.VarRef{Val: "value"},
-							RHS: &influxql.NumberLiteral{Val: 3},
+							RHS: &influxql.IntegerLiteral{Val: 3},
 						},
-						RHS: &influxql.NumberLiteral{Val: 30},
+						RHS: &influxql.IntegerLiteral{Val: 30},
 					},
 					RHS: &influxql.BinaryExpr{
 						Op:  influxql.ADD,
-						LHS: &influxql.NumberLiteral{Val: 1},
-						RHS: &influxql.NumberLiteral{Val: 2},
+						LHS: &influxql.IntegerLiteral{Val: 1},
+						RHS: &influxql.IntegerLiteral{Val: 2},
 					},
 				},
 				RHS: &influxql.BooleanLiteral{Val: true},
@@ -1469,6 +1945,238 @@ func TestParser_ParseExpr(t *testing.T) {
 			},
 		},
 
+		// Negative literals and negation.
+		{
+			s: `value > -10`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.GT,
+				LHS: &influxql.VarRef{Val: "value"},
+				RHS: &influxql.IntegerLiteral{Val: -10},
+			},
+		},
+		{
+			s: `-value * 2`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.MUL,
+				LHS: &influxql.UnaryExpr{Op: influxql.SUB, Expr: &influxql.VarRef{Val: "value"}},
+				RHS: &influxql.IntegerLiteral{Val: 2},
+			},
+		},
+		{
+			s: `- (1 + value)`,
+			expr: &influxql.UnaryExpr{
+				Op: influxql.SUB,
+				Expr: &influxql.ParenExpr{
+					Expr: &influxql.BinaryExpr{
+						Op:  influxql.ADD,
+						LHS: &influxql.IntegerLiteral{Val: 1},
+						RHS: &influxql.VarRef{Val: "value"},
+					},
+				},
+			},
+		},
+		{
+			s:    `+value`,
+			expr: &influxql.VarRef{Val: "value"},
+		},
+
+		// Modulo binds as tightly as multiplication.
+		{
+			s: `cast_id % 10 + 1 = 0`,
+			expr: &influxql.BinaryExpr{
+				Op: influxql.EQ,
+				LHS: &influxql.BinaryExpr{
+					Op: influxql.ADD,
+					LHS: &influxql.BinaryExpr{
+						Op:  influxql.MOD,
+						LHS: &influxql.VarRef{Val: "cast_id"},
+						RHS: &influxql.IntegerLiteral{Val: 10},
+					},
+					RHS: &influxql.IntegerLiteral{Val: 1},
+				},
+				RHS: &influxql.IntegerLiteral{Val: 0},
+			},
+		},
+
+		// LIKE binds as tightly as other comparisons.
+		{
+			s: `host LIKE 'server%' AND region = 'a'`,
+			expr: &influxql.BinaryExpr{
+				Op: influxql.AND,
+				LHS: &influxql.BinaryExpr{
+					Op:  influxql.LIKE,
+					LHS: &influxql.VarRef{Val: "host"},
+					RHS: &influxql.StringLiteral{Val: "server%"},
+				},
+				RHS: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "region"},
+					RHS: &influxql.StringLiteral{Val: "a"},
+				},
+			},
+		},
+
+		// Null tests bind as tightly as other comparisons.
+		{
+			s: `status IS NULL OR status IS NOT NULL AND value > 1`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.OR,
+				LHS: &influxql.IsNullExpr{Expr: &influxql.VarRef{Val: "status"}},
+				RHS: &influxql.BinaryExpr{
+					Op:  influxql.AND,
+					LHS: &influxql.IsNullExpr{Expr: &influxql.VarRef{Val: "status"}, Not: true},
+					RHS: &influxql.BinaryExpr{
+						Op:  influxql.GT,
+						LHS: &influxql.VarRef{Val: "value"},
+						RHS: &influxql.IntegerLiteral{Val: 1},
+					},
+				},
+			},
+		},
+		{
+			s: `value * 2 IS NULL`,
+			expr: &influxql.IsNullExpr{
+				Expr: &influxql.BinaryExpr{
+					Op:  influxql.MUL,
+					LHS: &influxql.VarRef{Val: "value"},
+					RHS: &influxql.IntegerLiteral{Val: 2},
+				},
+			},
+		},
+
+		// BETWEEN is an inclusive range of comparisons.
+		{
+			s: `time BETWEEN '2015-01-01' AND now() - 1d AND host = 'a'`,
+			expr: &influxql.BinaryExpr{
+				Op: influxql.AND,
+				LHS: &influxql.ParenExpr{
+					Expr: &influxql.BinaryExpr{
+						Op: influxql.AND,
+						LHS: &influxql.BinaryExpr{
+							Op:  influxql.GTE,
+							LHS: &influxql.VarRef{Val: "time"},
+							RHS: &influxql.TimeLiteral{Val: mustParseTime("2015-01-01T00:00:00Z")},
+						},
+						RHS: &influxql.BinaryExpr{
+							Op:  influxql.LTE,
+							LHS: &influxql.VarRef{Val: "time"},
+							RHS: &influxql.BinaryExpr{
+								Op:  influxql.SUB,
+								LHS: &influxql.Call{Name: "now"},
+								RHS: &influxql.DurationLiteral{Val: 24 * time.Hour},
+							},
+						},
+					},
+				},
+				RHS: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "host"},
+					RHS: &influxql.StringLiteral{Val: "a"},
+				},
+			},
+		},
+		{
+			s: `value * 2 BETWEEN 1 AND 10`,
+			expr: &influxql.ParenExpr{
+				Expr: &influxql.BinaryExpr{
+					Op: influxql.AND,
+					LHS: &influxql.BinaryExpr{
+						Op: influxql.GTE,
+						LHS: &influxql.BinaryExpr{
+							Op:  influxql.MUL,
+							LHS: &influxql.VarRef{Val: "value"},
+							RHS: &influxql.IntegerLiteral{Val: 2},
+						},
+						RHS: &influxql.IntegerLiteral{Val: 1},
+					},
+					RHS: &influxql.BinaryExpr{
+						Op: influxql.LTE,
+						LHS: &influxql.BinaryExpr{
+							Op:  influxql.MUL,
+							LHS: &influxql.VarRef{Val: "value"},
+							RHS: &influxql.IntegerLiteral{Val: 2},
+						},
+						RHS: &influxql.IntegerLiteral{Val: 10},
+					},
+				},
+			},
+		},
+
+		// Logical negation binds less tightly than comparisons.
+		{
+			s: `NOT host = 'a' AND region = 'b'`,
+			expr: &influxql.BinaryExpr{
+				Op: influxql.AND,
+				LHS: &influxql.UnaryExpr{
+					Op: influxql.NOT,
+					Expr: &influxql.BinaryExpr{
+						Op:  influxql.EQ,
+						LHS: &influxql.VarRef{Val: "host"},
+						RHS: &influxql.StringLiteral{Val: "a"},
+					},
+				},
+				RHS: &influxql.BinaryExpr{
+					Op:  influxql.EQ,
+					LHS: &influxql.VarRef{Val: "region"},
+					RHS: &influxql.StringLiteral{Val: "b"},
+				},
+			},
+		},
+		{
+			s: `NOT (host = 'a' OR host = 'b')`,
+			expr: &influxql.UnaryExpr{
+				Op: influxql.NOT,
+				Expr: &influxql.ParenExpr{
+					Expr: &influxql.BinaryExpr{
+						Op: influxql.OR,
+						LHS: &influxql.BinaryExpr{
+							Op:  influxql.EQ,
+							LHS: &influxql.VarRef{Val: "host"},
+							RHS: &influxql.StringLiteral{Val: "a"},
+						},
+						RHS: &influxql.BinaryExpr{
+							Op:  influxql.EQ,
+							LHS: &influxql.VarRef{Val: "host"},
+							RHS: &influxql.StringLiteral{Val: "b"},
+						},
+					},
+				},
+			},
+		},
+		{
+			s: `NOT value > 1 - 2`,
+			expr: &influxql.UnaryExpr{
+				Op: influxql.NOT,
+				Expr: &influxql.BinaryExpr{
+					Op:  influxql.GT,
+					LHS: &influxql.VarRef{Val: "value"},
+					RHS: &influxql.BinaryExpr{
+						Op:  influxql.SUB,
+						LHS: &influxql.IntegerLiteral{Val: 1},
+						RHS: &influxql.IntegerLiteral{Val: 2},
+					},
+				},
+			},
+		},
+
+		// A signed number after an expression is a binary operator.
+		{
+			s: `now() -1h`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.SUB,
+				LHS: &influxql.Call{Name: "now"},
+				RHS: &influxql.DurationLiteral{Val: time.Hour},
+			},
+		},
+		{
+			s: `value+1.5`,
+			expr: &influxql.BinaryExpr{
+				Op:  influxql.ADD,
+				LHS: &influxql.VarRef{Val: "value"},
+				RHS: &influxql.NumberLiteral{Val: 1.5},
+			},
+		},
+
 		// Function call (empty)
 		{
 			s: `my_func()`,
@@ -1483,19 +2191,26 @@ func TestParser_ParseExpr(t *testing.T) {
 			expr: &influxql.Call{
 				Name: "my_func",
 				Args: []influxql.Expr{
-					&influxql.NumberLiteral{Val: 1},
+					&influxql.IntegerLiteral{Val: 1},
 					&influxql.BinaryExpr{
 						Op:  influxql.ADD,
-						LHS: &influxql.NumberLiteral{Val: 2},
-						RHS: &influxql.NumberLiteral{Val: 3},
+						LHS: &influxql.IntegerLiteral{Val: 2},
+						RHS: &influxql.IntegerLiteral{Val: 3},
 					},
 				},
 			},
 		},
+
+		// Errors
+		{s: `time BETWEEN 1`, err: `found EOF, expected AND at line 1, char 15`},
+		{s: `time BETWEEN 1 OR 2`, err: `found OR, expected AND at line 1, char 16`},
+		{s: `status IS 1`, err: `found 1, expected NOT, NULL at line 1, char 11`},
+		{s: `status IS NOT 1`, err: `found 1, expected NULL at line 1, char 15`},
 	}
 
 	for i, tt := range tests {
 		expr, err := influxql.NewParser(strings.NewReader(tt.s)).ParseExpr()
+		clearPos(expr)
 		if !reflect.DeepEqual(tt.err, errstring(err)) {
 			t.Errorf("%d. %q: error mismatch:\n  exp=%s\n  got=%s\n\n", i, tt.s, tt.err, err)
 		} else if tt.err == "" && !reflect.DeepEqual(tt.expr, expr) {
@@ -1504,6 +2219,35 @@ func TestParser_ParseExpr(t *testing.T) {
 	}
 }
 
+// Ensure the parser records the position of each node's first token.
+func TestParser_Pos(t *testing.T) {
+	stmt := influxql.MustParseStatement("SELECT mean(value) AS v FROM db0..cpu\nWHERE time > now() GROUP BY time(1m) ORDER BY time DESC").(*influxql.SelectStatement)
+	cond := stmt.Condition.(*influxql.BinaryExpr)
+
+	for i, tt := range []struct {
+		node influxql.Node
+		pos  influxql.Pos
+	}{
+		{node: stmt, pos: influxql.Pos{Line: 0, Char: 0}},
+		{node: stmt.Fields, pos: influxql.Pos{Line: 0, Char: 7}},
+		{node: stmt.Fields[0].Expr.(*influxql.Call).Args[0], pos: influxql.Pos{Line: 0, Char: 12}},
+		{node: stmt.Sources, pos: influxql.Pos{Line: 0, Char: 29}},
+		{node: cond, pos: influxql.Pos{Line: 1, Char: 6}},
+		{node: cond.RHS, pos: influxql.Pos{Line: 1, Char: 13}},
+		{node: stmt.Dimensions[0], pos: influxql.Pos{Line: 1, Char: 28}},
+		{node: stmt.SortFields[0], pos: influxql.Pos{Line: 1, Char: 46}},
+	} {
+		if pos := tt.node.Pos(); pos != tt.pos {
+			t.Errorf("%d. %s: unexpected position: exp=%+v got=%+v", i, tt.node, tt.pos, pos)
+		}
+	}
+
+	// Nodes built without the parser have no position.
+	if pos := (&influxql.VarRef{Val: "value"}).Pos(); pos != (influxql.Pos{}) {
+		t.Errorf("unexpected position: %+v", pos)
+	}
+}
+
 // Ensure a time duration can be parsed.
 func TestParseDuration(t *testing.T) {
 	var tests = []struct {
@@ -1516,6 +2260,7 @@ func TestParseDuration(t *testing.T) {
 		{s: `10u`, d: 10 * time.Microsecond},
 		{s: `10µ`, d: 10 * time.Microsecond},
 		{s: `15ms`, d: 15 * time.Millisecond},
+		{s: `20ns`, d: 20 * time.Nanosecond},
 		{s: `100s`, d: 100 * time.Second},
 		{s: `2m`, d: 2 * time.Minute},
 		{s: `2h`, d: 2 * time.Hour},
@@ -1546,6 +2291,7 @@ func TestFormatDuration(t *testing.T) {
 	}{
 		{d: 3 * time.Microsecond, s: `3`},
 		{d: 1001 * time.Microsecond, s: `1001`},
+		{d: 1001 * time.Nanosecond, s: `1001ns`},
 		{d: 15 * time.Millisecond, s: `15ms`},
 		{d: 100 * time.Second, s: `100s`},
 		{d: 2 * time.Minute, s: `2m`},
@@ -1679,6 +2425,40 @@ func MustParseExpr(s string) influxql.Expr {
 	return expr
 }
 
+// clearPos zeroes the position recorded on every node reachable from v so
+// that parsed nodes can be compared against node literals.
+func clearPos(v interface{}) { clearPosValue(reflect.ValueOf(v)) }
+
+func clearPosValue(v reflect.Value) {
+	nodePosType := reflect.TypeOf(influxql.NodePos{})
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			clearPosValue(v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			clearPosValue(v.Index(i))
+		}
+	case reflect.Struct:
+		if v.Type() == nodePosType {
+			if v.CanSet() {
+				v.Set(reflect.Zero(nodePosType))
+			}
+			return
+		} else if v.Type().PkgPath() != nodePosType.PkgPath() {
+			return
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				clearPosValue(v.Field(i))
+			}
+		}
+	}
+}
+
 // errstring converts an error to its string representation.
 func errstring(err error) string {
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
 )
 
 // Scanner represents a lexical scanner for InfluxQL.
@@ -60,6 +61,8 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 		return MUL, pos, ""
 	case '/':
 		return DIV, pos, ""
+	case '%':
+		return MOD, pos, ""
 	case '=':
 		if ch1, _ := s.r.read(); ch1 == '~' {
 			return EQREGEX, pos, ""
@@ -95,6 +98,8 @@ func (s *Scanner) Scan() (tok Token, pos Pos, lit string) {
 		return COMMA, pos, ""
 	case ';':
 		return SEMICOLON, pos, ""
+	case ':':
+		return COLON, pos, ""
 	}
 
 	return ILLEGAL, pos, string(ch0)
@@ -250,10 +255,17 @@ func (s *Scanner) scanNumber() (tok Token, pos Pos, lit string) {
 
 	// Attempt to read as a duration if it doesn't have a fractional part.
 	if !strings.Contains(buf.String(), ".") {
-		// If the next rune is a duration unit (u,µ,ms,s) then return a duration token
+		// If the next rune is a duration unit (ns,u,µ,ms,s) then return a duration token
 		if ch0, _ := s.r.read(); ch0 == 'u' || ch0 == 'µ' || ch0 == 's' || ch0 == 'h' || ch0 == 'd' || ch0 == 'w' {
 			_, _ = buf.WriteRune(ch0)
 			return DURATION_VAL, pos, buf.String()
+		} else if ch0 == 'n' {
+			if ch1, _ := s.r.read(); ch1 == 's' {
+				_, _ = buf.WriteRune(ch0)
+				_, _ = buf.WriteRune(ch1)
+				return DURATION_VAL, pos, buf.String()
+			}
+			s.r.unread()
 		} else if ch0 == 'm' {
 			_, _ = buf.WriteRune(ch0)
 			if ch1, _ := s.r.read(); ch1 == 's' {
@@ -297,6 +309,129 @@ func isIdentChar(ch rune) bool { return isLetter(ch) || isDigit(ch) || ch == '_'
 // isIdentFirstChar returns true if the rune can be used as the first char in an unquoted identifer.
 func isIdentFirstChar(ch rune) bool { return isLetter(ch) || ch == '_' }
 
+// TokenInfo represents a token read by a Tokenizer.
+type TokenInfo struct {
+	Tok Token
+	Pos Pos // position of the first character of the token
+
+	// Literal value of identifiers, strings, numbers, durations, regexes
+	// and whitespace. Quotes and escapes are removed. Empty for keywords,
+	// operators and punctuation.
+	Lit string
+
+	// Exact text of the token in the input.
+	Text string
+}
+
+// Tokenizer splits InfluxQL text into a stream of tokens without parsing it.
+// Unlike Scanner, regexes are scanned wherever the parser would expect them
+// and the source text of every token is returned so concatenating the text
+// of each token reproduces the input. This is intended for tools such as
+// syntax highlighters and editors.
+type Tokenizer struct {
+	s   *Scanner
+	src string
+
+	lines []int // byte offset of the start of each line
+	end   int   // byte offset of the end of the last token
+
+	prev   Token // last non-whitespace token
+	clause Token // last keyword token
+}
+
+// NewTokenizer returns a new instance of Tokenizer for a string.
+func NewTokenizer(s string) *Tokenizer {
+	t := &Tokenizer{s: NewScanner(strings.NewReader(s)), src: s, lines: []int{0}}
+
+	// Record the start of each line using the same line endings as the reader.
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\n':
+			t.lines = append(t.lines, i+1)
+		case '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			t.lines = append(t.lines, i+1)
+		}
+	}
+	return t
+}
+
+// Next returns the next token. EOF is returned once the input is consumed.
+func (t *Tokenizer) Next() TokenInfo {
+	// Use the position of the first character of the token since the
+	// scanner reports some tokens, like strings, relative to other characters.
+	pos := t.s.r.next()
+
+	var tok Token
+	var lit string
+	if t.regexAllowed() && t.peekRune() == '/' {
+		tok, _, lit = t.s.ScanRegex()
+	} else {
+		tok, _, lit = t.s.Scan()
+	}
+
+	// Slice the token's text from the end of the previous token to the
+	// start of the next unread character.
+	start, end := t.end, t.offset(t.s.r.next())
+	t.end = end
+
+	if tok != WS {
+		t.prev = tok
+	}
+	if tok.IsKeyword() {
+		t.clause = tok
+	}
+
+	return TokenInfo{Tok: tok, Pos: pos, Lit: lit, Text: t.src[start:end]}
+}
+
+// regexAllowed returns true if a regex can follow the previous token.
+func (t *Tokenizer) regexAllowed() bool {
+	switch t.prev {
+	case EQREGEX, NEQREGEX, FROM, BY:
+		return true
+	case COMMA:
+		return t.clause == FROM || t.clause == BY
+	}
+	return false
+}
+
+// peekRune returns the next rune without consuming it.
+func (t *Tokenizer) peekRune() rune {
+	ch, _ := t.s.r.read()
+	t.s.r.unread()
+	return ch
+}
+
+// offset returns the byte offset of a position in the input.
+func (t *Tokenizer) offset(pos Pos) int {
+	if pos.Line >= len(t.lines) {
+		return len(t.src)
+	}
+
+	i := t.lines[pos.Line]
+	for n := 0; n < pos.Char && i < len(t.src); n++ {
+		_, size := utf8.DecodeRuneInString(t.src[i:])
+		i += size
+	}
+	return i
+}
+
+// Tokenize returns all tokens in a string, excluding the final EOF.
+func Tokenize(s string) []TokenInfo {
+	var a []TokenInfo
+	t := NewTokenizer(s)
+	for {
+		ti := t.Next()
+		if ti.Tok == EOF {
+			return a
+		}
+		a = append(a, ti)
+	}
+}
+
 // bufScanner represents a wrapper for scanner to add a buffer.
 // It provides a fixed-length circular buffer that can be unread.
 type bufScanner struct {
@@ -432,6 +567,14 @@ func (r *reader) unread() {
 	r.n++
 }
 
+// next returns the position of the next character to be read.
+func (r *reader) next() Pos {
+	if r.n > 0 {
+		return r.buf[(r.i-r.n+1+len(r.buf))%len(r.buf)].pos
+	}
+	return r.pos
+}
+
 // curr returns the last read character and position.
 func (r *reader) curr() (ch rune, pos Pos) {
 	i := (r.i - r.n + len(r.buf)) % len(r.buf)
@@ -514,6 +657,8 @@ func ScanString(r io.RuneScanner) (string, error) {
 				_, _ = buf.WriteRune('\\')
 			} else if ch1 == '"' {
 				_, _ = buf.WriteRune('"')
+			} else if ch1 == '\'' {
+				_, _ = buf.WriteRune('\'')
 			} else {
 				return string(ch0) + string(ch1), errBadEscape
 			}
@@ -1,6 +1,7 @@
 package influxql_test
 
 import (
+	"bytes"
 	"reflect"
 	"strings"
 	"testing"
@@ -34,6 +35,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `-`, tok: influxql.SUB},
 		{s: `*`, tok: influxql.MUL},
 		{s: `/`, tok: influxql.DIV},
+		{s: `%`, tok: influxql.MOD},
 
 		// Logical operators
 		{s: `AND`, tok: influxql.AND},
@@ -54,6 +56,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `)`, tok: influxql.RPAREN},
 		{s: `,`, tok: influxql.COMMA},
 		{s: `;`, tok: influxql.SEMICOLON},
+		{s: `:`, tok: influxql.COLON},
 		{s: `.`, tok: influxql.DOT},
 		{s: `=~`, tok: influxql.EQREGEX},
 		{s: `!~`, tok: influxql.NEQREGEX},
@@ -103,13 +106,17 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `10h`, tok: influxql.DURATION_VAL, lit: `10h`},
 		{s: `10d`, tok: influxql.DURATION_VAL, lit: `10d`},
 		{s: `10w`, tok: influxql.DURATION_VAL, lit: `10w`},
+		{s: `10ns`, tok: influxql.DURATION_VAL, lit: `10ns`},
 		{s: `10x`, tok: influxql.NUMBER, lit: `10`}, // non-duration unit
+		{s: `10n`, tok: influxql.NUMBER, lit: `10`},
 
 		// Keywords
 		{s: `ALL`, tok: influxql.ALL},
+		{s: `ANY`, tok: influxql.ANY},
 		{s: `ALTER`, tok: influxql.ALTER},
 		{s: `AS`, tok: influxql.AS},
 		{s: `ASC`, tok: influxql.ASC},
+		{s: `BACKFILL`, tok: influxql.BACKFILL},
 		{s: `BEGIN`, tok: influxql.BEGIN},
 		{s: `BY`, tok: influxql.BY},
 		{s: `CREATE`, tok: influxql.CREATE},
@@ -119,9 +126,11 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `DEFAULT`, tok: influxql.DEFAULT},
 		{s: `DELETE`, tok: influxql.DELETE},
 		{s: `DESC`, tok: influxql.DESC},
+		{s: `DESTINATIONS`, tok: influxql.DESTINATIONS},
 		{s: `DROP`, tok: influxql.DROP},
 		{s: `DURATION`, tok: influxql.DURATION},
 		{s: `END`, tok: influxql.END},
+		{s: `EVERY`, tok: influxql.EVERY},
 		{s: `EXISTS`, tok: influxql.EXISTS},
 		{s: `EXPLAIN`, tok: influxql.EXPLAIN},
 		{s: `FIELD`, tok: influxql.FIELD},
@@ -134,6 +143,7 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `INTO`, tok: influxql.INTO},
 		{s: `KEY`, tok: influxql.KEY},
 		{s: `KEYS`, tok: influxql.KEYS},
+		{s: `KILL`, tok: influxql.KILL},
 		{s: `LIMIT`, tok: influxql.LIMIT},
 		{s: `SHOW`, tok: influxql.SHOW},
 		{s: `MEASUREMENT`, tok: influxql.MEASUREMENT},
@@ -148,10 +158,13 @@ func TestScanner_Scan(t *testing.T) {
 		{s: `QUERIES`, tok: influxql.QUERIES},
 		{s: `QUERY`, tok: influxql.QUERY},
 		{s: `READ`, tok: influxql.READ},
+		{s: `RESAMPLE`, tok: influxql.RESAMPLE},
 		{s: `RETENTION`, tok: influxql.RETENTION},
 		{s: `REVOKE`, tok: influxql.REVOKE},
 		{s: `SELECT`, tok: influxql.SELECT},
 		{s: `SERIES`, tok: influxql.SERIES},
+		{s: `SUBSCRIPTION`, tok: influxql.SUBSCRIPTION},
+		{s: `SUBSCRIPTIONS`, tok: influxql.SUBSCRIPTIONS},
 		{s: `TAG`, tok: influxql.TAG},
 		{s: `TO`, tok: influxql.TO},
 		{s: `USER`, tok: influxql.USER},
@@ -243,6 +256,7 @@ func TestScanString(t *testing.T) {
 		{in: `"foo\nbar"`, out: "foo\nbar"},
 		{in: `"foo\\bar"`, out: `foo\bar`},
 		{in: `"foo\"bar"`, out: `foo"bar`},
+		{in: `'foo\'bar'`, out: `foo'bar`},
 
 		{in: `"foo` + "\n", out: `foo`, err: "bad string"}, // newline in string
 		{in: `"foo`, out: `foo`, err: "bad string"},        // unclosed quotes
@@ -284,3 +298,59 @@ func TestScanRegex(t *testing.T) {
 		}
 	}
 }
+
+// Ensure the tokenizer returns tokens with their positions and source text.
+func TestTokenize(t *testing.T) {
+	s := "select \"my val\" / 2 FROM cpu, /mem.*/\r\nWHERE host =~ /server\\d/ AND name = 'a\\'b'"
+	exp := []influxql.TokenInfo{
+		{Tok: influxql.SELECT, Pos: influxql.Pos{Line: 0, Char: 0}, Text: `select`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 0, Char: 6}, Lit: ` `, Text: ` `},
+		{Tok: influxql.IDENT, Pos: influxql.Pos{Line: 0, Char: 7}, Lit: `my val`, Text: `"my val"`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 0, Char: 15}, Lit: ` `, Text: ` `},
+		{Tok: influxql.DIV, Pos: influxql.Pos{Line: 0, Char: 16}, Text: `/`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 0, Char: 17}, Lit: ` `, Text: ` `},
+		{Tok: influxql.NUMBER, Pos: influxql.Pos{Line: 0, Char: 18}, Lit: `2`, Text: `2`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 0, Char: 19}, Lit: ` `, Text: ` `},
+		{Tok: influxql.FROM, Pos: influxql.Pos{Line: 0, Char: 20}, Text: `FROM`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 0, Char: 24}, Lit: ` `, Text: ` `},
+		{Tok: influxql.IDENT, Pos: influxql.Pos{Line: 0, Char: 25}, Lit: `cpu`, Text: `cpu`},
+		{Tok: influxql.COMMA, Pos: influxql.Pos{Line: 0, Char: 28}, Text: `,`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 0, Char: 29}, Lit: ` `, Text: ` `},
+		{Tok: influxql.REGEX, Pos: influxql.Pos{Line: 0, Char: 30}, Lit: `mem.*`, Text: `/mem.*/`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 0, Char: 37}, Lit: "\n", Text: "\r\n"},
+		{Tok: influxql.WHERE, Pos: influxql.Pos{Line: 1, Char: 0}, Text: `WHERE`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 1, Char: 5}, Lit: ` `, Text: ` `},
+		{Tok: influxql.IDENT, Pos: influxql.Pos{Line: 1, Char: 6}, Lit: `host`, Text: `host`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 1, Char: 10}, Lit: ` `, Text: ` `},
+		{Tok: influxql.EQREGEX, Pos: influxql.Pos{Line: 1, Char: 11}, Text: `=~`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 1, Char: 13}, Lit: ` `, Text: ` `},
+		{Tok: influxql.REGEX, Pos: influxql.Pos{Line: 1, Char: 14}, Lit: `server\d`, Text: `/server\d/`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 1, Char: 24}, Lit: ` `, Text: ` `},
+		{Tok: influxql.AND, Pos: influxql.Pos{Line: 1, Char: 25}, Text: `AND`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 1, Char: 28}, Lit: ` `, Text: ` `},
+		{Tok: influxql.IDENT, Pos: influxql.Pos{Line: 1, Char: 29}, Lit: `name`, Text: `name`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 1, Char: 33}, Lit: ` `, Text: ` `},
+		{Tok: influxql.EQ, Pos: influxql.Pos{Line: 1, Char: 34}, Text: `=`},
+		{Tok: influxql.WS, Pos: influxql.Pos{Line: 1, Char: 35}, Lit: ` `, Text: ` `},
+		{Tok: influxql.STRING, Pos: influxql.Pos{Line: 1, Char: 36}, Lit: `a'b`, Text: `'a\'b'`},
+	}
+
+	a := influxql.Tokenize(s)
+	if !reflect.DeepEqual(a, exp) {
+		for i := range a {
+			if i >= len(exp) || !reflect.DeepEqual(a[i], exp[i]) {
+				t.Fatalf("unexpected token %d: %#v", i, a[i])
+			}
+		}
+		t.Fatalf("unexpected token count: %d", len(a))
+	}
+
+	// Ensure the input can be rebuilt from the text of each token.
+	var buf bytes.Buffer
+	for _, ti := range a {
+		buf.WriteString(ti.Text)
+	}
+	if buf.String() != s {
+		t.Fatalf("unexpected text: %q", buf.String())
+	}
+}
@@ -33,6 +33,7 @@ const (
 	SUB // -
 	MUL // *
 	DIV // /
+	MOD // %
 
 	AND // AND
 	OR  // OR
@@ -45,6 +46,7 @@ const (
 	LTE      // <=
 	GT       // >
 	GTE      // >=
+	LIKE     // LIKE
 	operator_end
 
 	LPAREN    // (
@@ -52,14 +54,19 @@ const (
 	COMMA     // ,
 	SEMICOLON // ;
 	DOT       // .
+	COLON     // :
 
 	keyword_beg
 	// Keywords
 	ALL
 	ALTER
+	ANALYZE
+	ANY
 	AS
 	ASC
+	BACKFILL
 	BEGIN
+	BETWEEN
 	BY
 	CREATE
 	CONTINUOUS
@@ -68,10 +75,12 @@ const (
 	DEFAULT
 	DELETE
 	DESC
+	DESTINATIONS
 	DISTINCT
 	DROP
 	DURATION
 	END
+	EVERY
 	EXISTS
 	EXPLAIN
 	FIELD
@@ -86,11 +95,15 @@ const (
 	INNER
 	INSERT
 	INTO
+	IS
 	KEY
 	KEYS
+	KILL
 	LIMIT
 	MEASUREMENT
 	MEASUREMENTS
+	NOT
+	NULL
 	OFFSET
 	ON
 	ORDER
@@ -102,6 +115,7 @@ const (
 	QUERY
 	READ
 	REPLICATION
+	RESAMPLE
 	RETENTION
 	REVOKE
 	SELECT
@@ -113,6 +127,8 @@ const (
 	STATS
 	DIAGNOSTICS
 	SOFFSET
+	SUBSCRIPTION
+	SUBSCRIPTIONS
 	TAG
 	TO
 	USER
@@ -143,6 +159,7 @@ var tokens = [...]string{
 	SUB: "-",
 	MUL: "*",
 	DIV: "/",
+	MOD: "%",
 
 	AND: "AND",
 	OR:  "OR",
@@ -155,79 +172,94 @@ var tokens = [...]string{
 	LTE:      "<=",
 	GT:       ">",
 	GTE:      ">=",
+	LIKE:     "LIKE",
 
 	LPAREN:    "(",
 	RPAREN:    ")",
 	COMMA:     ",",
 	SEMICOLON: ";",
 	DOT:       ".",
+	COLON:     ":",
 
-	ALL:          "ALL",
-	ALTER:        "ALTER",
-	AS:           "AS",
-	ASC:          "ASC",
-	BEGIN:        "BEGIN",
-	BY:           "BY",
-	CREATE:       "CREATE",
-	CONTINUOUS:   "CONTINUOUS",
-	DATABASE:     "DATABASE",
-	DATABASES:    "DATABASES",
-	DEFAULT:      "DEFAULT",
-	DELETE:       "DELETE",
-	DESC:         "DESC",
-	DROP:         "DROP",
-	DISTINCT:     "DISTINCT",
-	DURATION:     "DURATION",
-	END:          "END",
-	EXISTS:       "EXISTS",
-	EXPLAIN:      "EXPLAIN",
-	FIELD:        "FIELD",
-	FOR:          "FOR",
-	FROM:         "FROM",
-	GRANT:        "GRANT",
-	GRANTS:       "GRANTS",
-	GROUP:        "GROUP",
-	IF:           "IF",
-	IN:           "IN",
-	INF:          "INF",
-	INNER:        "INNER",
-	INSERT:       "INSERT",
-	INTO:         "INTO",
-	KEY:          "KEY",
-	KEYS:         "KEYS",
-	LIMIT:        "LIMIT",
-	MEASUREMENT:  "MEASUREMENT",
-	MEASUREMENTS: "MEASUREMENTS",
-	OFFSET:       "OFFSET",
-	ON:           "ON",
-	ORDER:        "ORDER",
-	PASSWORD:     "PASSWORD",
-	POLICY:       "POLICY",
-	POLICIES:     "POLICIES",
-	PRIVILEGES:   "PRIVILEGES",
-	QUERIES:      "QUERIES",
-	QUERY:        "QUERY",
-	READ:         "READ",
-	REPLICATION:  "REPLICATION",
-	RETENTION:    "RETENTION",
-	REVOKE:       "REVOKE",
-	SELECT:       "SELECT",
-	SERIES:       "SERIES",
-	SERVERS:      "SERVERS",
-	SET:          "SET",
-	SHOW:         "SHOW",
-	SLIMIT:       "SLIMIT",
-	SOFFSET:      "SOFFSET",
-	STATS:        "STATS",
-	DIAGNOSTICS:  "DIAGNOSTICS",
-	TAG:          "TAG",
-	TO:           "TO",
-	USER:         "USER",
-	USERS:        "USERS",
-	VALUES:       "VALUES",
-	WHERE:        "WHERE",
-	WITH:         "WITH",
-	WRITE:        "WRITE",
+	ALL:           "ALL",
+	ALTER:         "ALTER",
+	ANALYZE:       "ANALYZE",
+	ANY:           "ANY",
+	AS:            "AS",
+	ASC:           "ASC",
+	BACKFILL:      "BACKFILL",
+	BEGIN:         "BEGIN",
+	BETWEEN:       "BETWEEN",
+	BY:            "BY",
+	CREATE:        "CREATE",
+	CONTINUOUS:    "CONTINUOUS",
+	DATABASE:      "DATABASE",
+	DATABASES:     "DATABASES",
+	DEFAULT:       "DEFAULT",
+	DELETE:        "DELETE",
+	DESC:          "DESC",
+	DESTINATIONS:  "DESTINATIONS",
+	DROP:          "DROP",
+	DISTINCT:      "DISTINCT",
+	DURATION:      "DURATION",
+	END:           "END",
+	EVERY:         "EVERY",
+	EXISTS:        "EXISTS",
+	EXPLAIN:       "EXPLAIN",
+	FIELD:         "FIELD",
+	FOR:           "FOR",
+	FROM:          "FROM",
+	GRANT:         "GRANT",
+	GRANTS:        "GRANTS",
+	GROUP:         "GROUP",
+	IF:            "IF",
+	IN:            "IN",
+	INF:           "INF",
+	INNER:         "INNER",
+	INSERT:        "INSERT",
+	INTO:          "INTO",
+	IS:            "IS",
+	KEY:           "KEY",
+	KEYS:          "KEYS",
+	KILL:          "KILL",
+	LIMIT:         "LIMIT",
+	MEASUREMENT:   "MEASUREMENT",
+	MEASUREMENTS:  "MEASUREMENTS",
+	NOT:           "NOT",
+	NULL:          "NULL",
+	OFFSET:        "OFFSET",
+	ON:            "ON",
+	ORDER:         "ORDER",
+	PASSWORD:      "PASSWORD",
+	POLICY:        "POLICY",
+	POLICIES:      "POLICIES",
+	PRIVILEGES:    "PRIVILEGES",
+	QUERIES:       "QUERIES",
+	QUERY:         "QUERY",
+	READ:          "READ",
+	REPLICATION:   "REPLICATION",
+	RESAMPLE:      "RESAMPLE",
+	RETENTION:     "RETENTION",
+	REVOKE:        "REVOKE",
+	SELECT:        "SELECT",
+	SERIES:        "SERIES",
+	SERVERS:       "SERVERS",
+	SET:           "SET",
+	SHOW:          "SHOW",
+	SLIMIT:        "SLIMIT",
+	SOFFSET:       "SOFFSET",
+	SUBSCRIPTION:  "SUBSCRIPTION",
+	SUBSCRIPTIONS: "SUBSCRIPTIONS",
+	STATS:         "STATS",
+	DIAGNOSTICS:   "DIAGNOSTICS",
+	TAG:           "TAG",
+	TO:            "TO",
+	USER:          "USER",
+	USERS:         "USERS",
+	VALUES:        "VALUES",
+	WHERE:         "WHERE",
+	WITH:          "WITH",
+	WRITE:         "WRITE",
 }
 
 var keywords map[string]Token
@@ -237,7 +269,7 @@ func init() {
 	for tok := keyword_beg + 1; tok < keyword_end; tok++ {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
-	for _, tok := range []Token{AND, OR} {
+	for _, tok := range []Token{AND, OR, LIKE} {
 		keywords[strings.ToLower(tokens[tok])] = tok
 	}
 	keywords["true"] = TRUE
@@ -259,18 +291,24 @@ func (tok Token) Precedence() int {
 		return 1
 	case AND:
 		return 2
-	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE:
+	case EQ, NEQ, EQREGEX, NEQREGEX, LT, LTE, GT, GTE, LIKE, BETWEEN, IS:
 		return 3
 	case ADD, SUB:
 		return 4
-	case MUL, DIV:
+	case MUL, DIV, MOD:
 		return 5
 	}
 	return 0
 }
 
-// isOperator returns true for operator tokens.
-func (tok Token) isOperator() bool { return tok > operator_beg && tok < operator_end }
+// IsLiteral returns true for literal tokens such as identifiers, numbers and strings.
+func (tok Token) IsLiteral() bool { return tok > literal_beg && tok < literal_end }
+
+// IsOperator returns true for operator tokens.
+func (tok Token) IsOperator() bool { return tok > operator_beg && tok < operator_end }
+
+// IsKeyword returns true for keyword tokens.
+func (tok Token) IsKeyword() bool { return tok > keyword_beg && tok < keyword_end }
 
 // tokstr returns a literal if provided, otherwise returns the token string.
 func tokstr(tok Token, lit string) string {
@@ -387,6 +387,15 @@ func (data *Data) CreateContinuousQuery(database, name, query string) error {
 		}
 	}
 
+	// Ensure another query doesn't already compute the same results.
+	if s := di.normalizeContinuousQuery(query); s != "" {
+		for i := range di.ContinuousQueries {
+			if di.normalizeContinuousQuery(di.ContinuousQueries[i].Query) == s {
+				return ErrContinuousQueryDuplicate(di.ContinuousQueries[i].Name)
+			}
+		}
+	}
+
 	// Append new query.
 	di.ContinuousQueries = append(di.ContinuousQueries, ContinuousQueryInfo{
 		Name:  name,
@@ -412,6 +421,22 @@ func (data *Data) DropContinuousQuery(database, name string) error {
 	return ErrContinuousQueryNotFound
 }
 
+// SetContinuousQueryBackfilled marks the history of a continuous query as computed.
+func (data *Data) SetContinuousQueryBackfilled(database, name string) error {
+	di := data.Database(database)
+	if di == nil {
+		return ErrDatabaseNotFound
+	}
+
+	for i := range di.ContinuousQueries {
+		if di.ContinuousQueries[i].Name == name {
+			di.ContinuousQueries[i].Backfilled = true
+			return nil
+		}
+	}
+	return ErrContinuousQueryNotFound
+}
+
 // User returns a user by username.
 func (data *Data) User(username string) *UserInfo {
 	for i := range data.Users {
@@ -478,6 +503,36 @@ func (data *Data) SetPrivilege(name, database string, p influxql.Privilege) erro
 	return nil
 }
 
+// SetMeasurementPrivilege sets a privilege for a user on a measurement in a database.
+func (data *Data) SetMeasurementPrivilege(name, database, measurement string, p influxql.Privilege) error {
+	ui := data.User(name)
+	if ui == nil {
+		return ErrUserNotFound
+	}
+
+	if ui.MeasurementPrivileges == nil {
+		ui.MeasurementPrivileges = make(map[string]map[string]influxql.Privilege)
+	}
+	if ui.MeasurementPrivileges[database] == nil {
+		ui.MeasurementPrivileges[database] = make(map[string]influxql.Privilege)
+	}
+	ui.MeasurementPrivileges[database][measurement] = p
+
+	return nil
+}
+
+// SetAdminPrivilege sets the admin privilege for a user.
+func (data *Data) SetAdminPrivilege(name string, admin bool) error {
+	ui := data.User(name)
+	if ui == nil {
+		return ErrUserNotFound
+	}
+
+	ui.Admin = admin
+
+	return nil
+}
+
 // UserPrivileges get privileges for a user.
 func (data *Data) UserPrivileges(name string) (map[string]influxql.Privilege, error) {
 	ui := data.User(name)
@@ -631,6 +686,39 @@ func (di DatabaseInfo) RetentionPolicy(name string) *RetentionPolicyInfo {
 	return nil
 }
 
+// normalizeContinuousQuery returns the canonical form of the SELECT statement
+// of a continuous query. Measurements are qualified with the database and its
+// default retention policy so queries only differing by that are equal.
+// Returns a blank string if the query can't be parsed.
+func (di DatabaseInfo) normalizeContinuousQuery(query string) string {
+	stmt, err := influxql.ParseStatement(query)
+	if err != nil {
+		return ""
+	}
+	cq, ok := stmt.(*influxql.CreateContinuousQueryStatement)
+	if !ok || cq.Source == nil {
+		return ""
+	}
+
+	qualify := func(m *influxql.Measurement) {
+		if m.Database == "" {
+			m.Database = di.Name
+		}
+		if m.Database == di.Name && m.RetentionPolicy == "" {
+			m.RetentionPolicy = di.DefaultRetentionPolicy
+		}
+	}
+	for _, src := range cq.Source.Sources {
+		if m, ok := src.(*influxql.Measurement); ok {
+			qualify(m)
+		}
+	}
+	if cq.Source.Target != nil && cq.Source.Target.Measurement != nil {
+		qualify(cq.Source.Target.Measurement)
+	}
+	return cq.Source.String()
+}
+
 // clone returns a deep copy of di.
 func (di DatabaseInfo) clone() DatabaseInfo {
 	other := di
@@ -911,6 +999,9 @@ func (si *ShardInfo) unmarshal(pb *internal.ShardInfo) {
 type ContinuousQueryInfo struct {
 	Name  string
 	Query string
+
+	// Backfilled is set once a BACKFILL query has computed its history.
+	Backfilled bool
 }
 
 // clone returns a deep copy of cqi.
@@ -919,8 +1010,9 @@ func (cqi ContinuousQueryInfo) clone() ContinuousQueryInfo { return cqi }
 // marshal serializes to a protobuf representation.
 func (cqi ContinuousQueryInfo) marshal() *internal.ContinuousQueryInfo {
 	return &internal.ContinuousQueryInfo{
-		Name:  proto.String(cqi.Name),
-		Query: proto.String(cqi.Query),
+		Name:       proto.String(cqi.Name),
+		Query:      proto.String(cqi.Query),
+		Backfilled: proto.Bool(cqi.Backfilled),
 	}
 }
 
@@ -928,6 +1020,7 @@ func (cqi ContinuousQueryInfo) marshal() *internal.ContinuousQueryInfo {
 func (cqi *ContinuousQueryInfo) unmarshal(pb *internal.ContinuousQueryInfo) {
 	cqi.Name = pb.GetName()
 	cqi.Query = pb.GetQuery()
+	cqi.Backfilled = pb.GetBackfilled()
 }
 
 // UserInfo represents metadata about a user in the system.
@@ -936,6 +1029,9 @@ type UserInfo struct {
 	Hash       string
 	Admin      bool
 	Privileges map[string]influxql.Privilege
+
+	// Privileges on single measurements, by database and measurement name.
+	MeasurementPrivileges map[string]map[string]influxql.Privilege
 }
 
 // Authorize returns true if the user is authorized and false if not.
@@ -944,6 +1040,19 @@ func (ui *UserInfo) Authorize(privilege influxql.Privilege, database string) boo
 	return (ok && p >= privilege) || (ui.Admin)
 }
 
+// AuthorizeMeasurement returns true if the user is authorized on a measurement
+// in a database, either by a privilege on the measurement or on the whole
+// database. A blank measurement requires the privilege on the whole database.
+func (ui *UserInfo) AuthorizeMeasurement(privilege influxql.Privilege, database, measurement string) bool {
+	if ui.Authorize(privilege, database) {
+		return true
+	} else if measurement == "" {
+		return false
+	}
+	p, ok := ui.MeasurementPrivileges[database][measurement]
+	return ok && p >= privilege
+}
+
 // clone returns a deep copy of si.
 func (ui UserInfo) clone() UserInfo {
 	other := ui
@@ -955,6 +1064,16 @@ func (ui UserInfo) clone() UserInfo {
 		}
 	}
 
+	if ui.MeasurementPrivileges != nil {
+		other.MeasurementPrivileges = make(map[string]map[string]influxql.Privilege)
+		for database, privs := range ui.MeasurementPrivileges {
+			other.MeasurementPrivileges[database] = make(map[string]influxql.Privilege)
+			for k, v := range privs {
+				other.MeasurementPrivileges[database][k] = v
+			}
+		}
+	}
+
 	return other
 }
 
@@ -973,6 +1092,16 @@ func (ui UserInfo) marshal() *internal.UserInfo {
 		})
 	}
 
+	for database, privs := range ui.MeasurementPrivileges {
+		for measurement, privilege := range privs {
+			pb.MeasurementPrivileges = append(pb.MeasurementPrivileges, &internal.MeasurementPrivilege{
+				Database:    proto.String(database),
+				Measurement: proto.String(measurement),
+				Privilege:   proto.Int32(int32(privilege)),
+			})
+		}
+	}
+
 	return pb
 }
 
@@ -986,6 +1115,16 @@ func (ui *UserInfo) unmarshal(pb *internal.UserInfo) {
 	for _, p := range pb.GetPrivileges() {
 		ui.Privileges[p.GetDatabase()] = influxql.Privilege(p.GetPrivilege())
 	}
+
+	for _, p := range pb.GetMeasurementPrivileges() {
+		if ui.MeasurementPrivileges == nil {
+			ui.MeasurementPrivileges = make(map[string]map[string]influxql.Privilege)
+		}
+		if ui.MeasurementPrivileges[p.GetDatabase()] == nil {
+			ui.MeasurementPrivileges[p.GetDatabase()] = make(map[string]influxql.Privilege)
+		}
+		ui.MeasurementPrivileges[p.GetDatabase()][p.GetMeasurement()] = influxql.Privilege(p.GetPrivilege())
+	}
 }
 
 // MarshalTime converts t to nanoseconds since epoch. A zero time returns 0.
@@ -442,6 +442,39 @@ func TestData_CreateContinuousQuery(t *testing.T) {
 	}
 }
 
+// Ensure a continuous query running the same SELECT as an existing query is rejected.
+func TestData_CreateContinuousQuery_ErrDuplicate(t *testing.T) {
+	data := meta.Data{Nodes: []meta.NodeInfo{{ID: 1}}}
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateRetentionPolicy("db0", &meta.RetentionPolicyInfo{Name: "rp0", ReplicaN: 1}); err != nil {
+		t.Fatal(err)
+	} else if err := data.SetDefaultRetentionPolicy("db0", "rp0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateContinuousQuery("db0", "cq0", `CREATE CONTINUOUS QUERY cq0 ON db0 BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(1m) END`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Queries only differing by formatting or qualified measurements are duplicates.
+	for i, q := range []string{
+		`CREATE CONTINUOUS QUERY cq1 ON db0 BEGIN select COUNT(value) into cpu_count from "cpu" group by time(60s) END`,
+		`CREATE CONTINUOUS QUERY cq1 ON db0 RESAMPLE EVERY 10m BEGIN SELECT count(value) INTO db0.rp0.cpu_count FROM db0.rp0.cpu GROUP BY time(1m) END`,
+	} {
+		if err := data.CreateContinuousQuery("db0", "cq1", q); err == nil || err.Error() != "continuous query duplicates existing continuous query: cq0" {
+			t.Fatalf("%d. unexpected error: %v", i, err)
+		}
+	}
+
+	// Queries with different literals or targets aren't duplicates.
+	if err := data.CreateContinuousQuery("db0", "cq1", `CREATE CONTINUOUS QUERY cq1 ON db0 BEGIN SELECT count(value) INTO cpu_count FROM cpu GROUP BY time(5m) END`); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateContinuousQuery("db0", "cq2", `CREATE CONTINUOUS QUERY cq2 ON db0 BEGIN SELECT count(value) INTO cpu_count2 FROM cpu GROUP BY time(1m) END`); err != nil {
+		t.Fatal(err)
+	} else if len(data.Databases[0].ContinuousQueries) != 3 {
+		t.Fatalf("unexpected queries: %#v", data.Databases[0].ContinuousQueries)
+	}
+}
+
 // Ensure a continuous query can be removed.
 func TestData_DropContinuousQuery(t *testing.T) {
 	var data meta.Data
@@ -462,6 +495,26 @@ func TestData_DropContinuousQuery(t *testing.T) {
 	}
 }
 
+// Ensure the history of a continuous query can be marked as computed.
+func TestData_SetContinuousQueryBackfilled(t *testing.T) {
+	var data meta.Data
+	if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateContinuousQuery("db0", "cq0", "SELECT count() FROM foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := data.SetContinuousQueryBackfilled("db0", "cq0"); err != nil {
+		t.Fatal(err)
+	} else if !data.Databases[0].ContinuousQueries[0].Backfilled {
+		t.Fatal("expected continuous query to be backfilled")
+	}
+
+	if err := data.SetContinuousQueryBackfilled("db0", "no_such_cq"); err != meta.ErrContinuousQueryNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 // Ensure a user can be created.
 func TestData_CreateUser(t *testing.T) {
 	var data meta.Data
@@ -656,6 +709,9 @@ func TestData_MarshalBinary(t *testing.T) {
 				Hash:       "ABC123",
 				Admin:      true,
 				Privileges: map[string]influxql.Privilege{"db0": influxql.AllPrivileges},
+				MeasurementPrivileges: map[string]map[string]influxql.Privilege{
+					"db1": {"cpu": influxql.ReadPrivilege},
+				},
 			},
 		},
 	}
@@ -80,6 +80,18 @@ var (
 	ErrContinuousQueryNotFound = errors.New("continuous query not found")
 )
 
+// ErrContinuousQueryDuplicate returns an error for creating a continuous query
+// that runs the same SELECT statement as the named existing query.
+func ErrContinuousQueryDuplicate(name string) error {
+	return fmt.Errorf("continuous query duplicates existing continuous query: %s", name)
+}
+
+var (
+	// ErrSubscriptionsNotSupported is returned when executing a subscription
+	// statement since the meta store does not persist subscriptions yet.
+	ErrSubscriptionsNotSupported = errors.New("subscriptions are not supported")
+)
+
 var (
 	// ErrUserExists is returned when creating an already existing user.
 	ErrUserExists = errors.New("user already exists")
@@ -6,9 +6,11 @@
 Package internal is a generated protocol buffer package.
 
 It is generated from these files:
+
 	internal/meta.proto
 
 It has these top-level messages:
+
 	Data
 	NodeInfo
 	DatabaseInfo
@@ -18,6 +20,7 @@ It has these top-level messages:
 	ContinuousQueryInfo
 	UserInfo
 	UserPrivilege
+	MeasurementPrivilege
 	Command
 	CreateNodeCommand
 	DeleteNodeCommand
@@ -36,6 +39,9 @@ It has these top-level messages:
 	UpdateUserCommand
 	SetPrivilegeCommand
 	SetDataCommand
+	SetAdminPrivilegeCommand
+	SetContinuousQueryBackfilledCommand
+	SetMeasurementPrivilegeCommand
 	Response
 */
 package internal
@@ -50,23 +56,26 @@ var _ = math.Inf
 type Command_Type int32
 
 const (
-	Command_CreateNodeCommand                Command_Type = 1
-	Command_DeleteNodeCommand                Command_Type = 2
-	Command_CreateDatabaseCommand            Command_Type = 3
-	Command_DropDatabaseCommand              Command_Type = 4
-	Command_CreateRetentionPolicyCommand     Command_Type = 5
-	Command_DropRetentionPolicyCommand       Command_Type = 6
-	Command_SetDefaultRetentionPolicyCommand Command_Type = 7
-	Command_UpdateRetentionPolicyCommand     Command_Type = 8
-	Command_CreateShardGroupCommand          Command_Type = 9
-	Command_DeleteShardGroupCommand          Command_Type = 10
-	Command_CreateContinuousQueryCommand     Command_Type = 11
-	Command_DropContinuousQueryCommand       Command_Type = 12
-	Command_CreateUserCommand                Command_Type = 13
-	Command_DropUserCommand                  Command_Type = 14
-	Command_UpdateUserCommand                Command_Type = 15
-	Command_SetPrivilegeCommand              Command_Type = 16
-	Command_SetDataCommand                   Command_Type = 17
+	Command_CreateNodeCommand                   Command_Type = 1
+	Command_DeleteNodeCommand                   Command_Type = 2
+	Command_CreateDatabaseCommand               Command_Type = 3
+	Command_DropDatabaseCommand                 Command_Type = 4
+	Command_CreateRetentionPolicyCommand        Command_Type = 5
+	Command_DropRetentionPolicyCommand          Command_Type = 6
+	Command_SetDefaultRetentionPolicyCommand    Command_Type = 7
+	Command_UpdateRetentionPolicyCommand        Command_Type = 8
+	Command_CreateShardGroupCommand             Command_Type = 9
+	Command_DeleteShardGroupCommand             Command_Type = 10
+	Command_CreateContinuousQueryCommand        Command_Type = 11
+	Command_DropContinuousQueryCommand          Command_Type = 12
+	Command_CreateUserCommand                   Command_Type = 13
+	Command_DropUserCommand                     Command_Type = 14
+	Command_UpdateUserCommand                   Command_Type = 15
+	Command_SetPrivilegeCommand                 Command_Type = 16
+	Command_SetDataCommand                      Command_Type = 17
+	Command_SetAdminPrivilegeCommand            Command_Type = 18
+	Command_SetContinuousQueryBackfilledCommand Command_Type = 19
+	Command_SetMeasurementPrivilegeCommand      Command_Type = 20
 )
 
 var Command_Type_name = map[int32]string{
@@ -87,25 +96,31 @@ var Command_Type_name = map[int32]string{
 	15: "UpdateUserCommand",
 	16: "SetPrivilegeCommand",
 	17: "SetDataCommand",
+	18: "SetAdminPrivilegeCommand",
+	19: "SetContinuousQueryBackfilledCommand",
+	20: "SetMeasurementPrivilegeCommand",
 }
 var Command_Type_value = map[string]int32{
-	"CreateNodeCommand":                1,
-	"DeleteNodeCommand":                2,
-	"CreateDatabaseCommand":            3,
-	"DropDatabaseCommand":              4,
-	"CreateRetentionPolicyCommand":     5,
-	"DropRetentionPolicyCommand":       6,
-	"SetDefaultRetentionPolicyCommand": 7,
-	"UpdateRetentionPolicyCommand":     8,
-	"CreateShardGroupCommand":          9,
-	"DeleteShardGroupCommand":          10,
-	"CreateContinuousQueryCommand":     11,
-	"DropContinuousQueryCommand":       12,
-	"CreateUserCommand":                13,
-	"DropUserCommand":                  14,
-	"UpdateUserCommand":                15,
-	"SetPrivilegeCommand":              16,
-	"SetDataCommand":                   17,
+	"CreateNodeCommand":                   1,
+	"DeleteNodeCommand":                   2,
+	"CreateDatabaseCommand":               3,
+	"DropDatabaseCommand":                 4,
+	"CreateRetentionPolicyCommand":        5,
+	"DropRetentionPolicyCommand":          6,
+	"SetDefaultRetentionPolicyCommand":    7,
+	"UpdateRetentionPolicyCommand":        8,
+	"CreateShardGroupCommand":             9,
+	"DeleteShardGroupCommand":             10,
+	"CreateContinuousQueryCommand":        11,
+	"DropContinuousQueryCommand":          12,
+	"CreateUserCommand":                   13,
+	"DropUserCommand":                     14,
+	"UpdateUserCommand":                   15,
+	"SetPrivilegeCommand":                 16,
+	"SetDataCommand":                      17,
+	"SetAdminPrivilegeCommand":            18,
+	"SetContinuousQueryBackfilledCommand": 19,
+	"SetMeasurementPrivilegeCommand":      20,
 }
 
 func (x Command_Type) Enum() *Command_Type {
@@ -392,6 +407,7 @@ func (m *ShardInfo) GetOwnerIDs() []uint64 {
 type ContinuousQueryInfo struct {
 	Name             *string `protobuf:"bytes,1,req" json:"Name,omitempty"`
 	Query            *string `protobuf:"bytes,2,req" json:"Query,omitempty"`
+	Backfilled       *bool   `protobuf:"varint,3,opt" json:"Backfilled,omitempty"`
 	XXX_unrecognized []byte  `json:"-"`
 }
 
@@ -413,12 +429,20 @@ func (m *ContinuousQueryInfo) GetQuery() string {
 	return ""
 }
 
+func (m *ContinuousQueryInfo) GetBackfilled() bool {
+	if m != nil && m.Backfilled != nil {
+		return *m.Backfilled
+	}
+	return false
+}
+
 type UserInfo struct {
-	Name             *string          `protobuf:"bytes,1,req" json:"Name,omitempty"`
-	Hash             *string          `protobuf:"bytes,2,req" json:"Hash,omitempty"`
-	Admin            *bool            `protobuf:"varint,3,req" json:"Admin,omitempty"`
-	Privileges       []*UserPrivilege `protobuf:"bytes,4,rep" json:"Privileges,omitempty"`
-	XXX_unrecognized []byte           `json:"-"`
+	Name                  *string                 `protobuf:"bytes,1,req" json:"Name,omitempty"`
+	Hash                  *string                 `protobuf:"bytes,2,req" json:"Hash,omitempty"`
+	Admin                 *bool                   `protobuf:"varint,3,req" json:"Admin,omitempty"`
+	Privileges            []*UserPrivilege        `protobuf:"bytes,4,rep" json:"Privileges,omitempty"`
+	MeasurementPrivileges []*MeasurementPrivilege `protobuf:"bytes,5,rep" json:"MeasurementPrivileges,omitempty"`
+	XXX_unrecognized      []byte                  `json:"-"`
 }
 
 func (m *UserInfo) Reset()         { *m = UserInfo{} }
@@ -453,6 +477,13 @@ func (m *UserInfo) GetPrivileges() []*UserPrivilege {
 	return nil
 }
 
+func (m *UserInfo) GetMeasurementPrivileges() []*MeasurementPrivilege {
+	if m != nil {
+		return m.MeasurementPrivileges
+	}
+	return nil
+}
+
 type UserPrivilege struct {
 	Database         *string `protobuf:"bytes,1,req" json:"Database,omitempty"`
 	Privilege        *int32  `protobuf:"varint,2,req" json:"Privilege,omitempty"`
@@ -477,6 +508,38 @@ func (m *UserPrivilege) GetPrivilege() int32 {
 	return 0
 }
 
+type MeasurementPrivilege struct {
+	Database         *string `protobuf:"bytes,1,req" json:"Database,omitempty"`
+	Measurement      *string `protobuf:"bytes,2,req" json:"Measurement,omitempty"`
+	Privilege        *int32  `protobuf:"varint,3,req" json:"Privilege,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *MeasurementPrivilege) Reset()         { *m = MeasurementPrivilege{} }
+func (m *MeasurementPrivilege) String() string { return proto.CompactTextString(m) }
+func (*MeasurementPrivilege) ProtoMessage()    {}
+
+func (m *MeasurementPrivilege) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *MeasurementPrivilege) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
+func (m *MeasurementPrivilege) GetPrivilege() int32 {
+	if m != nil && m.Privilege != nil {
+		return *m.Privilege
+	}
+	return 0
+}
+
 type Command struct {
 	Type             *Command_Type             `protobuf:"varint,1,req,name=type,enum=internal.Command_Type" json:"type,omitempty"`
 	XXX_extensions   map[int32]proto.Extension `json:"-"`
@@ -1076,6 +1139,118 @@ var E_SetDataCommand_Command = &proto.ExtensionDesc{
 	Tag:           "bytes,117,opt,name=command",
 }
 
+type SetAdminPrivilegeCommand struct {
+	Username         *string `protobuf:"bytes,1,req" json:"Username,omitempty"`
+	Admin            *bool   `protobuf:"varint,2,req" json:"Admin,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetAdminPrivilegeCommand) Reset()         { *m = SetAdminPrivilegeCommand{} }
+func (m *SetAdminPrivilegeCommand) String() string { return proto.CompactTextString(m) }
+func (*SetAdminPrivilegeCommand) ProtoMessage()    {}
+
+func (m *SetAdminPrivilegeCommand) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *SetAdminPrivilegeCommand) GetAdmin() bool {
+	if m != nil && m.Admin != nil {
+		return *m.Admin
+	}
+	return false
+}
+
+var E_SetAdminPrivilegeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetAdminPrivilegeCommand)(nil),
+	Field:         118,
+	Name:          "internal.SetAdminPrivilegeCommand.command",
+	Tag:           "bytes,118,opt,name=command",
+}
+
+type SetContinuousQueryBackfilledCommand struct {
+	Database         *string `protobuf:"bytes,1,req" json:"Database,omitempty"`
+	Name             *string `protobuf:"bytes,2,req" json:"Name,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetContinuousQueryBackfilledCommand) Reset()         { *m = SetContinuousQueryBackfilledCommand{} }
+func (m *SetContinuousQueryBackfilledCommand) String() string { return proto.CompactTextString(m) }
+func (*SetContinuousQueryBackfilledCommand) ProtoMessage()    {}
+
+func (m *SetContinuousQueryBackfilledCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetContinuousQueryBackfilledCommand) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+var E_SetContinuousQueryBackfilledCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetContinuousQueryBackfilledCommand)(nil),
+	Field:         119,
+	Name:          "internal.SetContinuousQueryBackfilledCommand.command",
+	Tag:           "bytes,119,opt,name=command",
+}
+
+type SetMeasurementPrivilegeCommand struct {
+	Username         *string `protobuf:"bytes,1,req" json:"Username,omitempty"`
+	Database         *string `protobuf:"bytes,2,req" json:"Database,omitempty"`
+	Measurement      *string `protobuf:"bytes,3,req" json:"Measurement,omitempty"`
+	Privilege        *int32  `protobuf:"varint,4,req" json:"Privilege,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *SetMeasurementPrivilegeCommand) Reset()         { *m = SetMeasurementPrivilegeCommand{} }
+func (m *SetMeasurementPrivilegeCommand) String() string { return proto.CompactTextString(m) }
+func (*SetMeasurementPrivilegeCommand) ProtoMessage()    {}
+
+func (m *SetMeasurementPrivilegeCommand) GetUsername() string {
+	if m != nil && m.Username != nil {
+		return *m.Username
+	}
+	return ""
+}
+
+func (m *SetMeasurementPrivilegeCommand) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *SetMeasurementPrivilegeCommand) GetMeasurement() string {
+	if m != nil && m.Measurement != nil {
+		return *m.Measurement
+	}
+	return ""
+}
+
+func (m *SetMeasurementPrivilegeCommand) GetPrivilege() int32 {
+	if m != nil && m.Privilege != nil {
+		return *m.Privilege
+	}
+	return 0
+}
+
+var E_SetMeasurementPrivilegeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetMeasurementPrivilegeCommand)(nil),
+	Field:         120,
+	Name:          "internal.SetMeasurementPrivilegeCommand.command",
+	Tag:           "bytes,120,opt,name=command",
+}
+
 type Response struct {
 	OK               *bool   `protobuf:"varint,1,req" json:"OK,omitempty"`
 	Error            *string `protobuf:"bytes,2,opt" json:"Error,omitempty"`
@@ -1127,4 +1302,7 @@ func init() {
 	proto.RegisterExtension(E_UpdateUserCommand_Command)
 	proto.RegisterExtension(E_SetPrivilegeCommand_Command)
 	proto.RegisterExtension(E_SetDataCommand_Command)
+	proto.RegisterExtension(E_SetAdminPrivilegeCommand_Command)
+	proto.RegisterExtension(E_SetContinuousQueryBackfilledCommand_Command)
+	proto.RegisterExtension(E_SetMeasurementPrivilegeCommand_Command)
 }
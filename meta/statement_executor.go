@@ -2,6 +2,7 @@ package meta
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/influxdb/influxdb/influxql"
 )
@@ -27,6 +28,8 @@ type StatementExecutor struct {
 		UpdateUser(name, password string) error
 		DropUser(name string) error
 		SetPrivilege(username, database string, p influxql.Privilege) error
+		SetMeasurementPrivilege(username, database, measurement string, p influxql.Privilege) error
+		SetAdminPrivilege(username string, admin bool) error
 		UserPrivileges(username string) (map[string]influxql.Privilege, error)
 
 		CreateContinuousQuery(database, name, query string) error
@@ -73,6 +76,8 @@ func (e *StatementExecutor) ExecuteStatement(stmt influxql.Statement) *influxql.
 		return e.executeDropContinuousQueryStatement(stmt)
 	case *influxql.ShowContinuousQueriesStatement:
 		return e.executeShowContinuousQueriesStatement(stmt)
+	case *influxql.CreateSubscriptionStatement, *influxql.DropSubscriptionStatement, *influxql.ShowSubscriptionsStatement:
+		return &influxql.Result{Err: ErrSubscriptionsNotSupported}
 	default:
 		panic(fmt.Sprintf("unsupported statement type: %T", stmt))
 	}
@@ -106,9 +111,16 @@ func (e *StatementExecutor) executeShowGrantsForUserStatement(q *influxql.ShowGr
 		return &influxql.Result{Err: err}
 	}
 
+	// Sort databases so grants are returned in a stable order.
+	dbs := make([]string, 0, len(priv))
+	for d := range priv {
+		dbs = append(dbs, d)
+	}
+	sort.Strings(dbs)
+
 	row := &influxql.Row{Columns: []string{"database", "privilege"}}
-	for d, p := range priv {
-		row.Values = append(row.Values, []interface{}{d, p.String()})
+	for _, d := range dbs {
+		row.Values = append(row.Values, []interface{}{d, priv[d].String()})
 	}
 	return &influxql.Result{Series: []*influxql.Row{row}}
 }
@@ -127,12 +139,7 @@ func (e *StatementExecutor) executeShowServersStatement(q *influxql.ShowServersS
 }
 
 func (e *StatementExecutor) executeCreateUserStatement(q *influxql.CreateUserStatement) *influxql.Result {
-	admin := false
-	if q.Privilege != nil {
-		admin = (*q.Privilege == influxql.AllPrivileges)
-	}
-
-	_, err := e.Store.CreateUser(q.Name, q.Password, admin)
+	_, err := e.Store.CreateUser(q.Name, q.Password, q.Admin)
 	return &influxql.Result{Err: err}
 }
 
@@ -150,6 +157,17 @@ func (e *StatementExecutor) executeShowUsersStatement(q *influxql.ShowUsersState
 		return &influxql.Result{Err: err}
 	}
 
+	// Only return the requested user and their grants if one is set.
+	if q.User != "" {
+		row := &influxql.Row{Columns: []string{"user", "admin", "grants"}}
+		for _, ui := range uis {
+			if ui.Name == q.User {
+				row.Values = append(row.Values, []interface{}{ui.Name, ui.Admin, userGrants(&ui)})
+			}
+		}
+		return &influxql.Result{Series: []*influxql.Row{row}}
+	}
+
 	row := &influxql.Row{Columns: []string{"user", "admin"}}
 	for _, ui := range uis {
 		row.Values = append(row.Values, []interface{}{ui.Name, ui.Admin})
@@ -157,11 +175,45 @@ func (e *StatementExecutor) executeShowUsersStatement(q *influxql.ShowUsersState
 	return &influxql.Result{Series: []*influxql.Row{row}}
 }
 
+// userGrants returns a sorted list of the privileges granted to a user on
+// databases and measurements, such as "READ ON db0".
+func userGrants(ui *UserInfo) []string {
+	grants := make([]string, 0, len(ui.Privileges))
+	for db, p := range ui.Privileges {
+		if p == influxql.NoPrivileges {
+			continue
+		}
+		grants = append(grants, fmt.Sprintf("%s ON %s", p.String(), db))
+	}
+	for db, m := range ui.MeasurementPrivileges {
+		for name, p := range m {
+			if p == influxql.NoPrivileges {
+				continue
+			}
+			grants = append(grants, fmt.Sprintf("%s ON %s.%s", p.String(), db, name))
+		}
+	}
+	sort.Strings(grants)
+	return grants
+}
+
 func (e *StatementExecutor) executeGrantStatement(stmt *influxql.GrantStatement) *influxql.Result {
+	// Granting all privileges without a database makes the user an admin.
+	if stmt.On == "" {
+		return &influxql.Result{Err: e.Store.SetAdminPrivilege(stmt.User, true)}
+	} else if stmt.Measurement != "" {
+		return &influxql.Result{Err: e.Store.SetMeasurementPrivilege(stmt.User, stmt.On, stmt.Measurement, stmt.Privilege)}
+	}
 	return &influxql.Result{Err: e.Store.SetPrivilege(stmt.User, stmt.On, stmt.Privilege)}
 }
 
 func (e *StatementExecutor) executeRevokeStatement(stmt *influxql.RevokeStatement) *influxql.Result {
+	// Revoking all privileges without a database removes the user's admin rights.
+	if stmt.On == "" {
+		return &influxql.Result{Err: e.Store.SetAdminPrivilege(stmt.User, false)}
+	} else if stmt.Measurement != "" {
+		return &influxql.Result{Err: e.Store.SetMeasurementPrivilege(stmt.User, stmt.On, stmt.Measurement, influxql.NoPrivileges)}
+	}
 	return &influxql.Result{Err: e.Store.SetPrivilege(stmt.User, stmt.On, influxql.NoPrivileges)}
 }
 
@@ -236,15 +288,42 @@ func (e *StatementExecutor) executeDropContinuousQueryStatement(q *influxql.Drop
 }
 
 func (e *StatementExecutor) executeShowContinuousQueriesStatement(stmt *influxql.ShowContinuousQueriesStatement) *influxql.Result {
-	dis, err := e.Store.Databases()
-	if err != nil {
-		return &influxql.Result{Err: err}
+	var dis []DatabaseInfo
+	if stmt.Database != "" {
+		di, err := e.Store.Database(stmt.Database)
+		if err != nil {
+			return &influxql.Result{Err: err}
+		} else if di == nil {
+			return &influxql.Result{Err: ErrDatabaseNotFound}
+		}
+		dis = []DatabaseInfo{*di}
+	} else {
+		var err error
+		if dis, err = e.Store.Databases(); err != nil {
+			return &influxql.Result{Err: err}
+		}
 	}
 
 	rows := []*influxql.Row{}
 	for _, di := range dis {
 		row := &influxql.Row{Columns: []string{"name", "query"}, Name: di.Name}
+		n := 0
 		for _, cqi := range di.ContinuousQueries {
+			// Skip queries that don't match the condition.
+			if stmt.Condition != nil {
+				if ok, _ := influxql.Eval(stmt.Condition, map[string]interface{}{"name": cqi.Name, "query": cqi.Query}).(bool); !ok {
+					continue
+				}
+			}
+
+			// Apply the offset and limit within each database.
+			n++
+			if n <= stmt.Offset {
+				continue
+			} else if stmt.Limit > 0 && n > stmt.Offset+stmt.Limit {
+				break
+			}
+
 			row.Values = append(row.Values, []interface{}{cqi.Name, cqi.Query})
 		}
 		rows = append(rows, row)
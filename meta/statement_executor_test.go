@@ -85,7 +85,6 @@ func TestStatementExecutor_ExecuteStatement_ShowDatabases_Err(t *testing.T) {
 
 // Ensure a SHOW GRANTS FOR statement can be executed.
 func TestStatementExecutor_ExecuteStatement_ShowGrantsFor(t *testing.T) {
-	t.Skip("Intermittent test failure: issue 3028")
 	e := NewStatementExecutor()
 	e.Store.UserPrivilegesFn = func(username string) (map[string]influxql.Privilege, error) {
 		if username != "dejan" {
@@ -267,6 +266,34 @@ func TestStatementExecutor_ExecuteStatement_ShowUsers(t *testing.T) {
 	}
 }
 
+// Ensure a SHOW USERS statement restricted to a single user returns their grants.
+func TestStatementExecutor_ExecuteStatement_ShowUsers_User(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{
+			{Name: "susy", Admin: true},
+			{
+				Name:                  "bob",
+				Privileges:            map[string]influxql.Privilege{"db0": influxql.ReadPrivilege, "db1": influxql.NoPrivileges},
+				MeasurementPrivileges: map[string]map[string]influxql.Privilege{"db1": {"cpu": influxql.WritePrivilege}},
+			},
+		}, nil
+	}
+
+	if res := e.ExecuteStatement(&influxql.ShowUsersStatement{User: "bob"}); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Columns: []string{"user", "admin", "grants"},
+			Values: [][]interface{}{
+				{"bob", false, []string{"READ ON db0", "WRITE ON db1.cpu"}},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
 // Ensure a SHOW USERS statement returns an error from the store.
 func TestStatementExecutor_ExecuteStatement_ShowUsers_Err(t *testing.T) {
 	e := NewStatementExecutor()
@@ -312,6 +339,48 @@ func TestStatementExecutor_ExecuteStatement_Grant_Err(t *testing.T) {
 	}
 }
 
+// Ensure a GRANT ALL PRIVILEGES statement without a database grants admin privileges.
+func TestStatementExecutor_ExecuteStatement_GrantAdmin(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.SetAdminPrivilegeFn = func(username string, admin bool) error {
+		if username != "susy" {
+			t.Fatalf("unexpected username: %s", username)
+		} else if !admin {
+			t.Fatalf("unexpected admin: %v", admin)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`GRANT ALL PRIVILEGES TO susy`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
+// Ensure a GRANT statement on a measurement can be executed.
+func TestStatementExecutor_ExecuteStatement_GrantMeasurement(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.SetMeasurementPrivilegeFn = func(username, database, measurement string, p influxql.Privilege) error {
+		if username != "susy" {
+			t.Fatalf("unexpected username: %s", username)
+		} else if database != "foo" {
+			t.Fatalf("unexpected database: %s", database)
+		} else if measurement != "cpu" {
+			t.Fatalf("unexpected measurement: %s", measurement)
+		} else if p != influxql.WritePrivilege {
+			t.Fatalf("unexpected privilege: %s", p)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`GRANT WRITE ON foo.cpu TO susy`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
 // Ensure a REVOKE statement can be executed.
 func TestStatementExecutor_ExecuteStatement_Revoke(t *testing.T) {
 	e := NewStatementExecutor()
@@ -333,6 +402,25 @@ func TestStatementExecutor_ExecuteStatement_Revoke(t *testing.T) {
 	}
 }
 
+// Ensure a REVOKE ALL PRIVILEGES statement without a database revokes admin privileges.
+func TestStatementExecutor_ExecuteStatement_RevokeAdmin(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.SetAdminPrivilegeFn = func(username string, admin bool) error {
+		if username != "susy" {
+			t.Fatalf("unexpected username: %s", username)
+		} else if admin {
+			t.Fatalf("unexpected admin: %v", admin)
+		}
+		return nil
+	}
+
+	if res := e.ExecuteStatement(influxql.MustParseStatement(`REVOKE ALL PRIVILEGES FROM susy`)); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if res.Series != nil {
+		t.Fatalf("unexpected rows: %#v", res.Series)
+	}
+}
+
 // Ensure a REVOKE statement returns errors from the store.
 func TestStatementExecutor_ExecuteStatement_Revoke_Err(t *testing.T) {
 	e := NewStatementExecutor()
@@ -665,6 +753,40 @@ func TestStatementExecutor_ExecuteStatement_ShowContinuousQueries(t *testing.T)
 	}
 }
 
+// Ensure a SHOW CONTINUOUS QUERIES statement can be scoped to a database and filtered.
+func TestStatementExecutor_ExecuteStatement_ShowContinuousQueries_Filter(t *testing.T) {
+	e := NewStatementExecutor()
+	e.Store.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		if name != "db0" {
+			t.Fatalf("unexpected database: %s", name)
+		}
+		return &meta.DatabaseInfo{
+			Name: "db0",
+			ContinuousQueries: []meta.ContinuousQueryInfo{
+				{Name: "cq0", Query: "SELECT count(*) INTO db1 FROM db0"},
+				{Name: "cq1", Query: "SELECT count(*) INTO db2 FROM db0"},
+				{Name: "cq2", Query: "SELECT count(*) INTO db3 FROM db0"},
+				{Name: "cq3", Query: "SELECT count(*) INTO db4 FROM db0"},
+			},
+		}, nil
+	}
+
+	stmt := influxql.MustParseStatement(`SHOW CONTINUOUS QUERIES ON db0 WHERE name != 'cq1' LIMIT 1 OFFSET 1`)
+	if res := e.ExecuteStatement(stmt); res.Err != nil {
+		t.Fatal(res.Err)
+	} else if !reflect.DeepEqual(res.Series, influxql.Rows{
+		{
+			Name:    "db0",
+			Columns: []string{"name", "query"},
+			Values: [][]interface{}{
+				{"cq2", "SELECT count(*) INTO db3 FROM db0"},
+			},
+		},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(res.Series))
+	}
+}
+
 // Ensure a SHOW CONTINUOUS QUERIES statement can return an error from the store.
 func TestStatementExecutor_ExecuteStatement_ShowContinuousQueries_Err(t *testing.T) {
 	e := NewStatementExecutor()
@@ -678,6 +800,19 @@ func TestStatementExecutor_ExecuteStatement_ShowContinuousQueries_Err(t *testing
 	}
 }
 
+// Ensure subscription statements return an error instead of panicking.
+func TestStatementExecutor_ExecuteStatement_Subscriptions(t *testing.T) {
+	for _, s := range []string{
+		`CREATE SUBSCRIPTION s0 ON db0.rp0 DESTINATIONS ALL 'udp://h1:9090'`,
+		`DROP SUBSCRIPTION s0 ON db0.rp0`,
+		`SHOW SUBSCRIPTIONS`,
+	} {
+		if res := NewStatementExecutor().ExecuteStatement(influxql.MustParseStatement(s)); res.Err != meta.ErrSubscriptionsNotSupported {
+			t.Fatalf("%s: unexpected error: %v", s, res.Err)
+		}
+	}
+}
+
 // Ensure that executing an unsupported statement will panic.
 func TestStatementExecutor_ExecuteStatement_Unsupported(t *testing.T) {
 	var panicked bool
@@ -730,6 +865,8 @@ type StatementExecutorStore struct {
 	UpdateUserFn                func(name, password string) error
 	DropUserFn                  func(name string) error
 	SetPrivilegeFn              func(username, database string, p influxql.Privilege) error
+	SetAdminPrivilegeFn         func(username string, admin bool) error
+	SetMeasurementPrivilegeFn   func(username, database, measurement string, p influxql.Privilege) error
 	UserPrivilegesFn            func(username string) (map[string]influxql.Privilege, error)
 	ContinuousQueriesFn         func() ([]meta.ContinuousQueryInfo, error)
 	CreateContinuousQueryFn     func(database, name, query string) error
@@ -796,6 +933,14 @@ func (s *StatementExecutorStore) SetPrivilege(username, database string, p influ
 	return s.SetPrivilegeFn(username, database, p)
 }
 
+func (s *StatementExecutorStore) SetAdminPrivilege(username string, admin bool) error {
+	return s.SetAdminPrivilegeFn(username, admin)
+}
+
+func (s *StatementExecutorStore) SetMeasurementPrivilege(username, database, measurement string, p influxql.Privilege) error {
+	return s.SetMeasurementPrivilegeFn(username, database, measurement, p)
+}
+
 func (s *StatementExecutorStore) UserPrivileges(username string) (map[string]influxql.Privilege, error) {
 	return s.UserPrivilegesFn(username)
 }
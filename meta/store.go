@@ -950,6 +950,16 @@ func (s *Store) DropContinuousQuery(database, name string) error {
 	)
 }
 
+// SetContinuousQueryBackfilled marks the history of a continuous query as computed.
+func (s *Store) SetContinuousQueryBackfilled(database, name string) error {
+	return s.exec(internal.Command_SetContinuousQueryBackfilledCommand, internal.E_SetContinuousQueryBackfilledCommand_Command,
+		&internal.SetContinuousQueryBackfilledCommand{
+			Database: proto.String(database),
+			Name:     proto.String(name),
+		},
+	)
+}
+
 // User returns a user by name.
 func (s *Store) User(name string) (ui *UserInfo, err error) {
 	err = s.read(func(data *Data) error {
@@ -1063,6 +1073,28 @@ func (s *Store) SetPrivilege(username, database string, p influxql.Privilege) er
 	)
 }
 
+// SetMeasurementPrivilege sets a privilege for a user on a measurement in a database.
+func (s *Store) SetMeasurementPrivilege(username, database, measurement string, p influxql.Privilege) error {
+	return s.exec(internal.Command_SetMeasurementPrivilegeCommand, internal.E_SetMeasurementPrivilegeCommand_Command,
+		&internal.SetMeasurementPrivilegeCommand{
+			Username:    proto.String(username),
+			Database:    proto.String(database),
+			Measurement: proto.String(measurement),
+			Privilege:   proto.Int32(int32(p)),
+		},
+	)
+}
+
+// SetAdminPrivilege sets the admin privilege for a user.
+func (s *Store) SetAdminPrivilege(username string, admin bool) error {
+	return s.exec(internal.Command_SetAdminPrivilegeCommand, internal.E_SetAdminPrivilegeCommand_Command,
+		&internal.SetAdminPrivilegeCommand{
+			Username: proto.String(username),
+			Admin:    proto.Bool(admin),
+		},
+	)
+}
+
 // UserPrivileges returns a list of all databases.
 func (s *Store) UserPrivileges(username string) (p map[string]influxql.Privilege, err error) {
 	err = s.read(func(data *Data) error {
@@ -1341,6 +1373,8 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 			return fsm.applyCreateContinuousQueryCommand(&cmd)
 		case internal.Command_DropContinuousQueryCommand:
 			return fsm.applyDropContinuousQueryCommand(&cmd)
+		case internal.Command_SetContinuousQueryBackfilledCommand:
+			return fsm.applySetContinuousQueryBackfilledCommand(&cmd)
 		case internal.Command_CreateUserCommand:
 			return fsm.applyCreateUserCommand(&cmd)
 		case internal.Command_DropUserCommand:
@@ -1349,6 +1383,10 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 			return fsm.applyUpdateUserCommand(&cmd)
 		case internal.Command_SetPrivilegeCommand:
 			return fsm.applySetPrivilegeCommand(&cmd)
+		case internal.Command_SetAdminPrivilegeCommand:
+			return fsm.applySetAdminPrivilegeCommand(&cmd)
+		case internal.Command_SetMeasurementPrivilegeCommand:
+			return fsm.applySetMeasurementPrivilegeCommand(&cmd)
 		case internal.Command_SetDataCommand:
 			return fsm.applySetDataCommand(&cmd)
 		default:
@@ -1554,6 +1592,20 @@ func (fsm *storeFSM) applyDropContinuousQueryCommand(cmd *internal.Command) inte
 	return nil
 }
 
+func (fsm *storeFSM) applySetContinuousQueryBackfilledCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetContinuousQueryBackfilledCommand_Command)
+	v := ext.(*internal.SetContinuousQueryBackfilledCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetContinuousQueryBackfilled(v.GetDatabase(), v.GetName()); err != nil {
+		return err
+	}
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateUserCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateUserCommand_Command)
 	v := ext.(*internal.CreateUserCommand)
@@ -1607,6 +1659,32 @@ func (fsm *storeFSM) applySetPrivilegeCommand(cmd *internal.Command) interface{}
 	return nil
 }
 
+func (fsm *storeFSM) applySetAdminPrivilegeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetAdminPrivilegeCommand_Command)
+	v := ext.(*internal.SetAdminPrivilegeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetAdminPrivilege(v.GetUsername(), v.GetAdmin()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applySetMeasurementPrivilegeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetMeasurementPrivilegeCommand_Command)
+	v := ext.(*internal.SetMeasurementPrivilegeCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	if err := other.SetMeasurementPrivilege(v.GetUsername(), v.GetDatabase(), v.GetMeasurement(), influxql.Privilege(v.GetPrivilege())); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
 func (fsm *storeFSM) applySetDataCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_SetDataCommand_Command)
 	v := ext.(*internal.SetDataCommand)
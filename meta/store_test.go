@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
 	"github.com/influxdb/influxdb/tcp"
 	"github.com/influxdb/influxdb/toml"
@@ -656,6 +657,75 @@ func TestStore_UpdateUser(t *testing.T) {
 	}
 }
 
+// Ensure the store can grant and revoke admin privileges.
+func TestStore_SetAdminPrivilege(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	// Create user.
+	if _, err := s.CreateUser("susy", "pass", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Grant admin privileges and verify.
+	if err := s.SetAdminPrivilege("susy", true); err != nil {
+		t.Fatal(err)
+	} else if ui, err := s.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if !ui.Admin {
+		t.Fatal("expected admin user")
+	}
+
+	// Revoke admin privileges and verify.
+	if err := s.SetAdminPrivilege("susy", false); err != nil {
+		t.Fatal(err)
+	} else if ui, err := s.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if ui.Admin {
+		t.Fatal("expected non-admin user")
+	}
+
+	// Unknown users return an error.
+	if err := s.SetAdminPrivilege("bob", true); err != meta.ErrUserNotFound {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the store can grant and revoke privileges on a measurement.
+func TestStore_SetMeasurementPrivilege(t *testing.T) {
+	t.Parallel()
+	s := MustOpenStore()
+	defer s.Close()
+
+	// Create user.
+	if _, err := s.CreateUser("susy", "pass", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Grant read on a single measurement and verify.
+	if err := s.SetMeasurementPrivilege("susy", "db0", "cpu", influxql.ReadPrivilege); err != nil {
+		t.Fatal(err)
+	} else if ui, err := s.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if !ui.AuthorizeMeasurement(influxql.ReadPrivilege, "db0", "cpu") {
+		t.Fatal("expected read privilege on db0.cpu")
+	} else if ui.AuthorizeMeasurement(influxql.ReadPrivilege, "db0", "mem") {
+		t.Fatal("unexpected read privilege on db0.mem")
+	} else if ui.AuthorizeMeasurement(influxql.WritePrivilege, "db0", "cpu") {
+		t.Fatal("unexpected write privilege on db0.cpu")
+	}
+
+	// Revoke the privilege and verify.
+	if err := s.SetMeasurementPrivilege("susy", "db0", "cpu", influxql.NoPrivileges); err != nil {
+		t.Fatal(err)
+	} else if ui, err := s.User("susy"); err != nil {
+		t.Fatal(err)
+	} else if ui.AuthorizeMeasurement(influxql.ReadPrivilege, "db0", "cpu") {
+		t.Fatal("unexpected read privilege on db0.cpu")
+	}
+}
+
 // Ensure the store can return the count of users in it.
 func TestStore_UserCount(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,203 @@
+// Package prometheus translates between the Prometheus remote read protocol
+// and InfluxQL.
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/prometheus/remote"
+)
+
+const (
+	// MetricNameLabel is the label holding the name of a metric. It is
+	// stored as the measurement of a series.
+	MetricNameLabel = "__name__"
+
+	// FieldName is the field holding the value of a sample.
+	FieldName = "value"
+)
+
+var (
+	// ErrMetricNameRequired is returned when a query has no matcher
+	// selecting metrics by name.
+	ErrMetricNameRequired = errors.New("query requires an equality or regex matcher on " + MetricNameLabel)
+)
+
+// ReadRequestToInfluxQLQuery converts a remote read request into a query with
+// a SELECT statement for each of the request's queries. Metrics are read from
+// the measurements of a retention policy, a blank retention policy being the
+// database's default.
+func ReadRequestToInfluxQLQuery(req *remote.ReadRequest, db, rp string) (*influxql.Query, error) {
+	q := &influxql.Query{}
+	for _, rq := range req.Queries {
+		stmt, err := newSelectStatement(rq, db, rp)
+		if err != nil {
+			return nil, err
+		}
+		q.Statements = append(q.Statements, stmt)
+	}
+	return q, nil
+}
+
+// newSelectStatement returns the statement reading the samples of every
+// series matching a query within its time range, grouped by series.
+func newSelectStatement(q *remote.Query, db, rp string) (*influxql.SelectStatement, error) {
+	var m *influxql.Measurement
+	cond := influxql.Expr(&influxql.BinaryExpr{
+		Op:  influxql.AND,
+		LHS: &influxql.BinaryExpr{Op: influxql.GTE, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.TimeLiteral{Val: msToTime(q.StartTimestampMs)}},
+		RHS: &influxql.BinaryExpr{Op: influxql.LTE, LHS: &influxql.VarRef{Val: "time"}, RHS: &influxql.TimeLiteral{Val: msToTime(q.EndTimestampMs)}},
+	})
+
+	for _, matcher := range q.Matchers {
+		if matcher.Name == MetricNameLabel {
+			if m != nil {
+				return nil, fmt.Errorf("query has more than one matcher on %s", MetricNameLabel)
+			}
+			source, err := newMeasurement(matcher, db, rp)
+			if err != nil {
+				return nil, err
+			}
+			m = source
+			continue
+		}
+
+		expr, err := newTagCondition(matcher)
+		if err != nil {
+			return nil, err
+		}
+		cond = &influxql.BinaryExpr{Op: influxql.AND, LHS: cond, RHS: expr}
+	}
+	if m == nil {
+		return nil, ErrMetricNameRequired
+	}
+
+	return &influxql.SelectStatement{
+		Fields:     influxql.Fields{{Expr: &influxql.VarRef{Val: FieldName}}},
+		Sources:    influxql.Sources{m},
+		Condition:  cond,
+		Dimensions: influxql.Dimensions{{Expr: &influxql.Wildcard{}}},
+		IsRawQuery: true,
+	}, nil
+}
+
+// newMeasurement returns the measurements matching the metric name matcher.
+func newMeasurement(matcher *remote.LabelMatcher, db, rp string) (*influxql.Measurement, error) {
+	m := &influxql.Measurement{Database: db, RetentionPolicy: rp}
+	switch matcher.Type {
+	case remote.LabelMatcher_EQ:
+		m.Name = matcher.Value
+	case remote.LabelMatcher_RE:
+		re, err := compileRegex(matcher.Value)
+		if err != nil {
+			return nil, err
+		}
+		m.Regex = &influxql.RegexLiteral{Val: re}
+	default:
+		return nil, fmt.Errorf("unsupported matcher type on %s: %s", MetricNameLabel, matcher.Type)
+	}
+	return m, nil
+}
+
+// newTagCondition returns the condition on tags of a label matcher.
+func newTagCondition(matcher *remote.LabelMatcher) (influxql.Expr, error) {
+	ref := &influxql.VarRef{Val: matcher.Name}
+	switch matcher.Type {
+	case remote.LabelMatcher_EQ:
+		return &influxql.BinaryExpr{Op: influxql.EQ, LHS: ref, RHS: &influxql.StringLiteral{Val: matcher.Value}}, nil
+	case remote.LabelMatcher_NEQ:
+		return &influxql.BinaryExpr{Op: influxql.NEQ, LHS: ref, RHS: &influxql.StringLiteral{Val: matcher.Value}}, nil
+	case remote.LabelMatcher_RE, remote.LabelMatcher_NRE:
+		re, err := compileRegex(matcher.Value)
+		if err != nil {
+			return nil, err
+		}
+		op := influxql.EQREGEX
+		if matcher.Type == remote.LabelMatcher_NRE {
+			op = influxql.NEQREGEX
+		}
+		return &influxql.BinaryExpr{Op: op, LHS: ref, RHS: &influxql.RegexLiteral{Val: re}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported matcher type: %s", matcher.Type)
+	}
+}
+
+// compileRegex compiles a Prometheus regex, which must match the whole value.
+func compileRegex(s string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("^(?:" + s + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %s", s, err)
+	}
+	return re, nil
+}
+
+// msToTime returns the time of a timestamp in milliseconds.
+func msToTime(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+// RowsToTimeSeries converts the rows of a statement created by
+// ReadRequestToInfluxQLQuery into time series. The measurement of a row is
+// returned as the metric name label and its tags as the other labels, sorted
+// by name. Missing values are skipped.
+func RowsToTimeSeries(rows influxql.Rows) ([]*remote.TimeSeries, error) {
+	a := make([]*remote.TimeSeries, 0, len(rows))
+	for _, row := range rows {
+		ts := &remote.TimeSeries{Labels: make([]*remote.LabelPair, 0, len(row.Tags)+1)}
+		ts.Labels = append(ts.Labels, &remote.LabelPair{Name: MetricNameLabel, Value: row.Name})
+		for k, v := range row.Tags {
+			ts.Labels = append(ts.Labels, &remote.LabelPair{Name: k, Value: v})
+		}
+		sort.Sort(labelPairs(ts.Labels))
+
+		timeIndex, valueIndex := columnIndex(row.Columns, "time"), columnIndex(row.Columns, FieldName)
+		if timeIndex == -1 || valueIndex == -1 {
+			return nil, fmt.Errorf("unexpected columns for %s: %v", row.Name, row.Columns)
+		}
+
+		for _, values := range row.Values {
+			t, ok := values[timeIndex].(time.Time)
+			if !ok {
+				return nil, fmt.Errorf("unexpected time type: %T", values[timeIndex])
+			}
+
+			var v float64
+			switch value := values[valueIndex].(type) {
+			case nil:
+				continue
+			case float64:
+				v = value
+			case int64:
+				v = float64(value)
+			default:
+				return nil, fmt.Errorf("unsupported value type for %s: %T", row.Name, value)
+			}
+
+			ts.Samples = append(ts.Samples, &remote.Sample{Value: v, Timestamp: t.UnixNano() / int64(time.Millisecond)})
+		}
+		a = append(a, ts)
+	}
+	return a, nil
+}
+
+// columnIndex returns the index of a column or -1 if there's no such column.
+func columnIndex(columns []string, name string) int {
+	for i, c := range columns {
+		if c == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// labelPairs sorts labels by name.
+type labelPairs []*remote.LabelPair
+
+func (a labelPairs) Len() int           { return len(a) }
+func (a labelPairs) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a labelPairs) Less(i, j int) bool { return a[i].Name < a[j].Name }
@@ -0,0 +1,156 @@
+package prometheus_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/prometheus"
+	"github.com/influxdb/influxdb/prometheus/remote"
+)
+
+// Ensure a read request is converted into a statement for each query.
+func TestReadRequestToInfluxQLQuery(t *testing.T) {
+	req := &remote.ReadRequest{
+		Queries: []*remote.Query{
+			{
+				StartTimestampMs: 1000,
+				EndTimestampMs:   2000,
+				Matchers: []*remote.LabelMatcher{
+					{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "cpu"},
+					{Type: remote.LabelMatcher_EQ, Name: "host", Value: "serverA"},
+					{Type: remote.LabelMatcher_NEQ, Name: "region", Value: "uswest"},
+				},
+			},
+			{
+				StartTimestampMs: 0,
+				EndTimestampMs:   60000,
+				Matchers: []*remote.LabelMatcher{
+					{Type: remote.LabelMatcher_RE, Name: "__name__", Value: "cpu|mem"},
+					{Type: remote.LabelMatcher_NRE, Name: "host", Value: "server[AB]"},
+				},
+			},
+		},
+	}
+
+	q, err := prometheus.ReadRequestToInfluxQLQuery(req, "db0", "rp0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{
+		`SELECT value FROM "db0"."rp0".cpu WHERE time >= '1970-01-01 00:00:01' AND time <= '1970-01-01 00:00:02' AND host = 'serverA' AND region != 'uswest' GROUP BY *`,
+		`SELECT value FROM "db0"."rp0"./^(?:cpu|mem)$/ WHERE time >= '1970-01-01 00:00:00' AND time <= '1970-01-01 00:01:00' AND host !~ /^(?:server[AB])$/ GROUP BY *`,
+	}
+	if len(q.Statements) != len(exp) {
+		t.Fatalf("unexpected statement count: %d", len(q.Statements))
+	}
+	for i, stmt := range q.Statements {
+		if got := stmt.String(); got != exp[i] {
+			t.Errorf("%d. unexpected statement:\n\nexp=%s\n\ngot=%s\n\n", i, exp[i], got)
+		}
+
+		// The statement must be valid.
+		if other, err := influxql.ParseStatement(stmt.String()); err != nil {
+			t.Errorf("%d. unable to parse statement: %s", i, err)
+		} else if other.String() != stmt.String() {
+			t.Errorf("%d. unexpected parsed statement: %s", i, other)
+		}
+	}
+}
+
+// Ensure invalid queries are rejected.
+func TestReadRequestToInfluxQLQuery_Err(t *testing.T) {
+	for i, tt := range []struct {
+		matchers []*remote.LabelMatcher
+		err      string
+	}{
+		{
+			matchers: []*remote.LabelMatcher{{Type: remote.LabelMatcher_EQ, Name: "host", Value: "serverA"}},
+			err:      `query requires an equality or regex matcher on __name__`,
+		},
+		{
+			matchers: []*remote.LabelMatcher{{Type: remote.LabelMatcher_NEQ, Name: "__name__", Value: "cpu"}},
+			err:      `unsupported matcher type on __name__: NEQ`,
+		},
+		{
+			matchers: []*remote.LabelMatcher{
+				{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "cpu"},
+				{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "mem"},
+			},
+			err: `query has more than one matcher on __name__`,
+		},
+		{
+			matchers: []*remote.LabelMatcher{
+				{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "cpu"},
+				{Type: remote.LabelMatcher_RE, Name: "host", Value: "server("},
+			},
+			err: "invalid regex \"server(\": error parsing regexp: missing closing ): `^(?:server()$`",
+		},
+	} {
+		req := &remote.ReadRequest{Queries: []*remote.Query{{Matchers: tt.matchers}}}
+		if _, err := prometheus.ReadRequestToInfluxQLQuery(req, "db0", ""); err == nil || err.Error() != tt.err {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
+
+// Ensure rows are converted into time series with sorted labels.
+func TestRowsToTimeSeries(t *testing.T) {
+	ts, err := prometheus.RowsToTimeSeries(influxql.Rows{
+		{
+			Name:    "cpu",
+			Tags:    map[string]string{"region": "uswest", "host": "serverA"},
+			Columns: []string{"time", "value"},
+			Values: [][]interface{}{
+				{time.Unix(1, 0), 1.5},
+				{time.Unix(2, 0), nil},
+				{time.Unix(3, 0), int64(3)},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []*remote.TimeSeries{
+		{
+			Labels: []*remote.LabelPair{
+				{Name: "__name__", Value: "cpu"},
+				{Name: "host", Value: "serverA"},
+				{Name: "region", Value: "uswest"},
+			},
+			Samples: []*remote.Sample{
+				{Value: 1.5, Timestamp: 1000},
+				{Value: 3, Timestamp: 3000},
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, ts) {
+		t.Fatalf("unexpected time series: %s", ts)
+	}
+
+	// The time series must survive encoding.
+	buf, err := proto.Marshal(&remote.ReadResponse{Results: []*remote.QueryResult{{Timeseries: ts}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp remote.ReadResponse
+	if err := proto.Unmarshal(buf, &resp); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(exp, resp.Results[0].Timeseries) {
+		t.Fatalf("unexpected decoded time series: %s", resp.Results[0].Timeseries)
+	}
+}
+
+// Ensure values that can't be represented as samples are rejected.
+func TestRowsToTimeSeries_ErrValueType(t *testing.T) {
+	_, err := prometheus.RowsToTimeSeries(influxql.Rows{
+		{Name: "cpu", Columns: []string{"time", "value"}, Values: [][]interface{}{{time.Unix(1, 0), "foo"}}},
+	})
+	if err == nil || err.Error() != "unsupported value type for cpu: string" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
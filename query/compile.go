@@ -0,0 +1,393 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+var (
+	// ErrWildcardNotRewritten is returned when compiling a statement whose
+	// wildcards haven't been expanded into fields and dimensions.
+	ErrWildcardNotRewritten = errors.New("wildcards must be rewritten before compiling")
+
+	// ErrQueryCanceled is returned by iterators whose query was canceled.
+	ErrQueryCanceled = errors.New("query canceled")
+)
+
+// Source represents the storage that points are read from.
+type Source interface {
+	// CreateIterator returns an iterator over the points of a measurement.
+	CreateIterator(opt IteratorOptions) (Iterator, error)
+
+	// TagKeys returns the tag keys of a measurement. Predicates on these
+	// keys are pushed down into the source.
+	TagKeys(database, retentionPolicy, measurement string) ([]string, error)
+}
+
+// IteratorOptions describes the points to be read from a source.
+type IteratorOptions struct {
+	// Measurement to read from.
+	Database        string
+	RetentionPolicy string
+	Measurement     string
+
+	// Names of the fields and tags used by the query.
+	Names []string
+
+	// Predicates on tags. Only points from series matching the condition
+	// may be returned. Predicates on fields are evaluated by the caller.
+	Condition influxql.Expr
+
+	// Time range, in nanoseconds since the epoch, inclusive of both ends.
+	StartTime int64
+	EndTime   int64
+
+	// If Limit is set, the source must return its points ordered by series
+	// and then by time in the direction of Ascending, with at most Limit
+	// points from each series.
+	Limit     int
+	Ascending bool
+
+	// If Ordered is set, the source must return its points ordered by the
+	// values of the tags in Dimensions and then by time in the direction of
+	// Ascending, as sorted by NewGroupSortIterator, instead of by series.
+	// Aggregate queries, and raw queries with a limit, are computed from
+	// points in this order without buffering their input.
+	Ordered    bool
+	Dimensions []string
+}
+
+// CompileOptions configures the iterators built for a statement.
+type CompileOptions struct {
+	// Maximum estimated bytes of points that a sort holds in memory. Once
+	// exceeded, sorted runs are written to temporary files in TempDir and
+	// merged. Zero means no limit.
+	MaxSortMemory int
+	TempDir       string
+
+	// If set, closing the channel cancels the query. Iterators that read
+	// their whole input return ErrQueryCanceled.
+	Closing <-chan struct{}
+
+	// Upper bound of the time range of statements without one. If zero,
+	// their time range is unbounded.
+	Now time.Time
+}
+
+// Supported returns an error if a statement uses features that the iterators
+// don't implement. Such statements must be executed by the map/reduce engine
+// of the influxql package instead.
+func Supported(stmt *influxql.SelectStatement) error {
+	if stmt.SLimit > 0 || stmt.SOffset > 0 {
+		return errors.New("SLIMIT and SOFFSET are not supported")
+	}
+	for _, s := range stmt.Sources {
+		if m, ok := s.(*influxql.Measurement); !ok || m.Regex != nil {
+			return fmt.Errorf("invalid source: %s", s)
+		}
+	}
+	for _, f := range stmt.Fields {
+		if _, ok := f.Expr.(*influxql.Distinct); ok {
+			return errors.New("DISTINCT must be rewritten as a call")
+		}
+	}
+
+	calls := stmt.FunctionCalls()
+	for _, c := range calls {
+		if _, ok := supportedAggregates[c.Name]; !ok {
+			return fmt.Errorf("unsupported function: %s()", c.Name)
+		}
+	}
+
+	// Empty intervals are filled between the bounds of the time range, so
+	// there must be a lower bound. The limit and offset of the intervals
+	// would count the filled ones.
+	if len(calls) > 0 {
+		interval, err := stmt.GroupByInterval()
+		if err != nil {
+			return err
+		} else if interval == 0 {
+			return nil
+		}
+		reduced := stmt.Reduce(&influxql.NowValuer{Now: time.Now().UTC()})
+		if min, _, err := timeRange(reduced, time.Time{}); err != nil {
+			return err
+		} else if min == 0 {
+			return errors.New("GROUP BY time requires a lower time bound")
+		}
+		if stmt.Limit > 0 || stmt.Offset > 0 {
+			return errors.New("LIMIT and OFFSET of GROUP BY time intervals are not supported")
+		}
+	}
+	return nil
+}
+
+// timeRange returns the time range of a statement in nanoseconds since the
+// epoch. The range ends at now if the statement has no upper bound.
+func timeRange(stmt *influxql.SelectStatement, now time.Time) (min, max int64, err error) {
+	timeCond, _, err := influxql.ConditionExpr(stmt.Condition)
+	if err != nil {
+		return 0, 0, err
+	}
+	min, max, hasMin, hasMax, err := influxql.TimeRangeAsEpoch(timeCond)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasMin {
+		min = 0
+	}
+	if !hasMax {
+		max = math.MaxInt64
+		if !now.IsZero() {
+			max = now.UnixNano()
+		}
+	}
+	return min, max, nil
+}
+
+// Compile returns an iterator that executes stmt against src. The statement
+// must have been validated and have its wildcards rewritten.
+func Compile(stmt *influxql.SelectStatement, src Source, opt CompileOptions) (Iterator, error) {
+	itr, _, err := (&compiler{src: src, opt: opt}).compile(stmt)
+	return itr, err
+}
+
+// compiler builds the iterator tree for a statement along with the plan that
+// describes it.
+type compiler struct {
+	// Source to read from. If nil, no predicates are pushed down.
+	src Source
+	opt CompileOptions
+
+	// Only build the plan. No iterators are created.
+	explain bool
+
+	// Wrap each iterator to collect its runtime counters.
+	analyze bool
+}
+
+// compile returns the root iterator and plan for stmt.
+func (c *compiler) compile(stmt *influxql.SelectStatement) (Iterator, *Plan, error) {
+	if stmt.HasWildcard() {
+		return nil, nil, ErrWildcardNotRewritten
+	}
+
+	// Split the time range from the rest of the condition.
+	_, cond, err := influxql.ConditionExpr(stmt.Condition)
+	if err != nil {
+		return nil, nil, err
+	}
+	min, max, err := timeRange(stmt, c.opt.Now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Split the condition of every source. The time range selects the shards
+	// to read and predicates on tags select the series. Only the remaining
+	// predicates are evaluated for each point.
+	scans := make([]scan, len(stmt.Sources))
+	for i, s := range stmt.Sources {
+		m, ok := s.(*influxql.Measurement)
+		if !ok || m.Regex != nil {
+			return nil, nil, fmt.Errorf("invalid source: %s", s)
+		}
+
+		var tagKeys []string
+		if c.src != nil {
+			if tagKeys, err = c.src.TagKeys(m.Database, m.RetentionPolicy, m.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+		scans[i].measurement = m
+		scans[i].tagCond, scans[i].fieldCond = PushdownCondition(cond, tagKeys)
+	}
+
+	// Aggregate queries read the points of each group in order so every
+	// window can be computed as soon as it's read.
+	aggregate := len(stmt.FunctionCalls()) > 0
+	dimensions := dimensionNames(stmt.Dimensions)
+	var interval time.Duration
+	if aggregate {
+		if interval, err = stmt.GroupByInterval(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The limit of a raw query can be pushed down into the sources if every
+	// point they return is kept. Each source then returns the points of each
+	// group in order so they can be merged without sorting the whole result.
+	ascending := len(stmt.SortFields) == 0 || stmt.SortFields[0].Ascending
+	var limit int
+	if !aggregate && stmt.Limit > 0 {
+		limit = stmt.Limit + stmt.Offset
+		for _, sc := range scans {
+			if sc.fieldCond != nil {
+				limit = 0
+			}
+		}
+	}
+
+	// Scan every source.
+	names := scanNames(stmt)
+	inputs := make(Iterators, 0, len(scans))
+	plans := make([]*Plan, 0, len(scans))
+	for _, sc := range scans {
+		plan := &Plan{
+			Operator: "scan",
+			Detail:   fmt.Sprintf("measurement=%s names=%s time=[%d, %d]", sc.measurement.String(), strings.Join(names, ","), min, max),
+		}
+		if sc.tagCond != nil {
+			plan.Detail += " condition=" + sc.tagCond.String()
+		}
+		if limit > 0 {
+			plan.Detail += fmt.Sprintf(" limit=%d ascending=%t", limit, ascending)
+		} else if aggregate {
+			plan.Detail += fmt.Sprintf(" ordered=true ascending=%t", ascending)
+		}
+
+		var itr Iterator
+		if !c.explain {
+			if itr, err = c.src.CreateIterator(IteratorOptions{
+				Database:        sc.measurement.Database,
+				RetentionPolicy: sc.measurement.RetentionPolicy,
+				Measurement:     sc.measurement.Name,
+				Names:           names,
+				Condition:       sc.tagCond,
+				StartTime:       min,
+				EndTime:         max,
+				Limit:           limit,
+				Ascending:       ascending,
+				Ordered:         aggregate || limit > 0,
+				Dimensions:      dimensions,
+			}); err != nil {
+				inputs.Close()
+				return nil, nil, err
+			}
+			itr = c.wrap(itr, plan)
+		}
+
+		if sc.fieldCond != nil {
+			plan = &Plan{Operator: "filter", Detail: "condition=" + sc.fieldCond.String(), Inputs: []*Plan{plan}}
+			if !c.explain {
+				itr = c.wrap(NewFilterIterator(itr, sc.fieldCond), plan)
+			}
+		}
+
+		// Each source is grouped before merging so the sources are merged
+		// by group.
+		if aggregate {
+			plan = &Plan{
+				Operator: "group",
+				Detail:   fmt.Sprintf("dimensions=%s interval=%s", strings.Join(dimensions, ","), interval),
+				Inputs:   []*Plan{plan},
+			}
+			if !c.explain {
+				itr = c.wrap(NewGroupIterator(itr, dimensions, interval.Nanoseconds(), min), plan)
+			}
+		}
+
+		plans = append(plans, plan)
+		if !c.explain {
+			inputs = append(inputs, itr)
+		}
+	}
+
+	var itr Iterator
+	plan := &Plan{Operator: "merge", Inputs: plans}
+	if limit > 0 || aggregate {
+		plan.Detail = "sorted=true"
+		itr = c.wrap(NewGroupMergeIterator(inputs, dimensions, ascending), plan)
+	} else {
+		itr = c.wrap(NewMultiIterator(inputs...), plan)
+	}
+
+	// Aggregate queries compute their fields for each window. Raw queries
+	// only need their fields computed for each point.
+	if aggregate {
+		aitr, err := NewAggregateIterator(itr, stmt.Fields)
+		if err != nil {
+			itr.Close()
+			return nil, nil, err
+		}
+		plan = &Plan{Operator: "aggregate", Detail: "fields=" + stmt.Fields.String(), Inputs: []*Plan{plan}}
+		itr = c.wrap(aitr, plan)
+	} else {
+		plan = &Plan{Operator: "project", Detail: "fields=" + stmt.Fields.String(), Inputs: []*Plan{plan}}
+		itr = c.wrap(NewProjectIterator(itr, stmt.Fields), plan)
+	}
+
+	// Sort each group by time in the requested direction unless the sources
+	// already returned their points in order.
+	if limit == 0 && !aggregate {
+		plan = &Plan{Operator: "sort", Detail: fmt.Sprintf("ascending=%t", ascending), Inputs: []*Plan{plan}}
+		if len(dimensions) > 0 {
+			plan.Detail += " dimensions=" + strings.Join(dimensions, ",")
+		}
+		if c.opt.MaxSortMemory > 0 {
+			plan.Detail += fmt.Sprintf(" max-memory=%d", c.opt.MaxSortMemory)
+		}
+
+		sitr := NewGroupSortIterator(itr, dimensions, ascending)
+		sitr.MaxMemory = c.opt.MaxSortMemory
+		sitr.TempDir = c.opt.TempDir
+		sitr.Closing = c.opt.Closing
+		itr = c.wrap(sitr, plan)
+	}
+
+	// The limit and offset apply to each group.
+	if stmt.Limit > 0 || stmt.Offset > 0 {
+		plan = &Plan{Operator: "limit", Detail: fmt.Sprintf("limit=%d offset=%d", stmt.Limit, stmt.Offset), Inputs: []*Plan{plan}}
+		itr = c.wrap(NewGroupLimitIterator(itr, dimensions, stmt.Limit, stmt.Offset), plan)
+	}
+	return itr, plan, nil
+}
+
+// wrap returns itr wrapped to collect counters into plan when analyzing.
+func (c *compiler) wrap(itr Iterator, plan *Plan) Iterator {
+	if !c.analyze {
+		return itr
+	}
+	plan.Stats = &IteratorStats{}
+	return &analyzeIterator{input: itr, stats: plan.Stats}
+}
+
+// scan represents a source of a statement and how its condition is split.
+type scan struct {
+	measurement *influxql.Measurement
+	tagCond     influxql.Expr
+	fieldCond   influxql.Expr
+}
+
+// scanNames returns the names of the fields and tags that must be read by a
+// scan to execute the statement.
+func scanNames(stmt *influxql.SelectStatement) []string {
+	var names []string
+	seen := map[string]struct{}{"time": {}}
+	for _, a := range [][]string{stmt.NamesInSelect(), stmt.NamesInWhere(), stmt.NamesInDimension()} {
+		for _, name := range a {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// dimensionNames returns the tag keys of the dimensions. The time dimension
+// is handled separately by the group by interval.
+func dimensionNames(dimensions influxql.Dimensions) []string {
+	var names []string
+	for _, d := range dimensions {
+		if ref, ok := d.Expr.(*influxql.VarRef); ok {
+			names = append(names, ref.Val)
+		}
+	}
+	return names
+}
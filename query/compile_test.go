@@ -0,0 +1,167 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdb/influxdb/query"
+)
+
+// Ensure a raw query can be compiled and executed.
+func TestCompile_Raw(t *testing.T) {
+	var src Source
+	src.CreateIteratorFn = func(opt query.IteratorOptions) (query.Iterator, error) {
+		if opt.Measurement != "cpu" {
+			t.Fatalf("unexpected measurement: %s", opt.Measurement)
+		} else if !reflect.DeepEqual(opt.Names, []string{"value", "host"}) {
+			t.Fatalf("unexpected names: %v", opt.Names)
+		} else if opt.StartTime != 10 {
+			t.Fatalf("unexpected start time: %d", opt.StartTime)
+		}
+		return query.NewSliceIterator([]*query.Point{
+			{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 10, Values: map[string]interface{}{"value": float64(1)}},
+			{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 20, Values: map[string]interface{}{"value": float64(2)}},
+			{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 30, Values: map[string]interface{}{"value": float64(3)}},
+			{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 40, Values: map[string]interface{}{"value": float64(4)}},
+		}), nil
+	}
+
+	itr, err := query.Compile(MustParseSelectStatement(`SELECT value FROM cpu WHERE time >= '1970-01-01T00:00:00.00000001Z' AND host = 'A' ORDER BY time DESC LIMIT 2`), &src, query.CompileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 40, Values: map[string]interface{}{"value": float64(4)}},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 30, Values: map[string]interface{}{"value": float64(3)}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure an aggregate query can be compiled and executed.
+func TestCompile_Aggregate(t *testing.T) {
+	var src Source
+	src.CreateIteratorFn = func(opt query.IteratorOptions) (query.Iterator, error) {
+		if !opt.Ordered || !opt.Ascending || !reflect.DeepEqual(opt.Dimensions, []string{"host"}) {
+			t.Fatalf("unexpected order: ordered=%t ascending=%t dimensions=%v", opt.Ordered, opt.Ascending, opt.Dimensions)
+		}
+		return query.NewSliceIterator([]*query.Point{
+			{Name: "cpu", Tags: map[string]string{"host": "A", "region": "east"}, Time: 10, Values: map[string]interface{}{"value": float64(1)}},
+			{Name: "cpu", Tags: map[string]string{"host": "A", "region": "west"}, Time: 30, Values: map[string]interface{}{"value": float64(3)}},
+			{Name: "cpu", Tags: map[string]string{"host": "B", "region": "east"}, Time: 20, Values: map[string]interface{}{"value": float64(2)}},
+		}), nil
+	}
+
+	itr, err := query.Compile(MustParseSelectStatement(`SELECT sum(value) FROM cpu WHERE time >= 0 GROUP BY time(20ns), host`), &src, query.CompileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 0, Window: 0, Values: map[string]interface{}{"sum_value": float64(1)}},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 20, Window: 20, Values: map[string]interface{}{"sum_value": float64(3)}},
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 20, Window: 20, Values: map[string]interface{}{"sum_value": float64(2)}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the limit of a raw query is pushed down into the sources.
+func TestCompile_LimitPushdown(t *testing.T) {
+	var src Source
+	src.TagKeysFn = func(database, retentionPolicy, measurement string) ([]string, error) {
+		return []string{"host"}, nil
+	}
+	src.CreateIteratorFn = func(opt query.IteratorOptions) (query.Iterator, error) {
+		if opt.Limit != 3 || opt.Ascending {
+			t.Fatalf("unexpected limit: %d ascending=%t", opt.Limit, opt.Ascending)
+		}
+		switch opt.Measurement {
+		case "cpu":
+			return query.NewSliceIterator([]*query.Point{
+				{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 40, Values: map[string]interface{}{"value": float64(4)}},
+				{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 30, Values: map[string]interface{}{"value": float64(3)}},
+				{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 10, Values: map[string]interface{}{"value": float64(1)}},
+			}), nil
+		default:
+			return query.NewSliceIterator([]*query.Point{
+				{Name: "mem", Tags: map[string]string{"host": "A"}, Time: 20, Values: map[string]interface{}{"value": float64(2)}},
+			}), nil
+		}
+	}
+
+	stmt := MustParseSelectStatement(`SELECT value FROM cpu, mem WHERE host = 'A' ORDER BY time DESC LIMIT 2 OFFSET 1`)
+	itr, err := query.Compile(stmt, &src, query.CompileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 30, Values: map[string]interface{}{"value": float64(3)}},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 10, Values: map[string]interface{}{"value": float64(1)}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+
+	// The sources are merged in order so the result isn't sorted again.
+	plan, err := query.Explain(stmt, &src, query.CompileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	} else if a := plan.Lines(); !reflect.DeepEqual(a, []string{
+		`limit limit=2 offset=1`,
+		`  project fields=value`,
+		`    merge sorted=true`,
+		`      scan measurement=cpu names=value,host time=[0, 9223372036854775807] condition=host = 'A' limit=3 ascending=false`,
+		`      scan measurement=mem names=value,host time=[0, 9223372036854775807] condition=host = 'A' limit=3 ascending=false`,
+	}) {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+}
+
+// Ensure statements with wildcards must be rewritten before compiling.
+func TestCompile_ErrWildcardNotRewritten(t *testing.T) {
+	if _, err := query.Compile(MustParseSelectStatement(`SELECT * FROM cpu`), &Source{}, query.CompileOptions{}); err != query.ErrWildcardNotRewritten {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure statements using features the iterators don't implement are rejected.
+func TestSupported(t *testing.T) {
+	for i, tt := range []struct {
+		s   string
+		err string
+	}{
+		{s: `SELECT value FROM cpu GROUP BY host LIMIT 1`},
+		{s: `SELECT mean(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m), host fill(0)`},
+		{s: `SELECT value FROM cpu SLIMIT 1`, err: `SLIMIT and SOFFSET are not supported`},
+		{s: `SELECT value FROM /cpu/`, err: `invalid source: /cpu/`},
+		{s: `SELECT DISTINCT value FROM cpu`, err: `DISTINCT must be rewritten as a call`},
+		{s: `SELECT derivative(value) FROM cpu`, err: `unsupported function: derivative()`},
+		{s: `SELECT count(value) FROM cpu WHERE time >= 0 GROUP BY time(1m)`, err: `GROUP BY time requires a lower time bound`},
+		{s: `SELECT count(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m) LIMIT 1`, err: `LIMIT and OFFSET of GROUP BY time intervals are not supported`},
+	} {
+		err := query.Supported(MustParseSelectStatement(tt.s))
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%d. %s: unexpected error: %v", i, tt.s, err)
+		}
+	}
+}
+
+// Source is a mock implementation of query.Source.
+type Source struct {
+	CreateIteratorFn func(opt query.IteratorOptions) (query.Iterator, error)
+	TagKeysFn        func(database, retentionPolicy, measurement string) ([]string, error)
+}
+
+func (s *Source) CreateIterator(opt query.IteratorOptions) (query.Iterator, error) {
+	return s.CreateIteratorFn(opt)
+}
+
+// TagKeys returns no tag keys if TagKeysFn isn't set so nothing is pushed down.
+func (s *Source) TagKeys(database, retentionPolicy, measurement string) ([]string, error) {
+	if s.TagKeysFn == nil {
+		return nil, nil
+	}
+	return s.TagKeysFn(database, retentionPolicy, measurement)
+}
@@ -0,0 +1,20 @@
+/*
+Package query executes SELECT statements as a tree of iterators.
+
+A validated statement is compiled into a pipeline where each stage reads
+points from the stage below it:
+
+	scan -> filter -> group -> merge -> aggregate -> limit
+
+Sources return the points of an aggregate query ordered by group and time
+so each window is aggregated as soon as it has been read. Raw queries
+replace the group and aggregate stages with a projection of the selected
+fields followed by a sort. Each stage is an independent Iterator so it can
+be constructed and tested on its own.
+
+An Emitter turns the points of the pipeline into the rows of a result, one
+for each group, filling the empty intervals of aggregate queries. Statements
+using features the iterators don't implement are reported by Supported and
+are left to the map/reduce engine of the influxql package.
+*/
+package query
@@ -0,0 +1,189 @@
+package query
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// ErrTooManyIntervals is returned when a statement groups its time range into
+// more than influxql.MaxGroupByPoints intervals.
+var ErrTooManyIntervals = errors.New("too many points in the group by interval. maybe you forgot to specify a where time clause?")
+
+// Emitter returns the points of a compiled statement as rows, one for each
+// measurement and group of the statement's dimensions. The input must be
+// ordered by group, as returned by Compile, so each row is returned as soon
+// as its last point is read.
+type Emitter struct {
+	input      Iterator
+	dimensions []string
+	columns    []string
+	names      []string
+
+	// Start times of the intervals of an aggregate query grouped by time,
+	// in the order they're returned. Intervals without a point are filled.
+	windows   []int64
+	fill      influxql.FillOption
+	fillValue interface{}
+
+	next *Point
+}
+
+// NewEmitter returns a new instance of Emitter for the points of stmt read
+// from input. Statements without an upper time bound end at now.
+func NewEmitter(input Iterator, stmt *influxql.SelectStatement, now time.Time) (*Emitter, error) {
+	e := &Emitter{
+		input:      input,
+		dimensions: dimensionNames(stmt.Dimensions),
+		columns:    stmt.ColumnNames(),
+		names:      fieldNames(stmt.Fields),
+		fill:       stmt.Fill,
+		fillValue:  stmt.FillValue,
+	}
+
+	if len(stmt.FunctionCalls()) == 0 {
+		return e, nil
+	}
+	interval, err := stmt.GroupByInterval()
+	if err != nil {
+		return nil, err
+	} else if interval == 0 {
+		return e, nil
+	}
+	min, max, err := timeRange(stmt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every interval from the one holding the start of the time range.
+	e.windows = []int64{}
+	for t := window(min, interval.Nanoseconds()); t <= max; t += interval.Nanoseconds() {
+		if len(e.windows) == influxql.MaxGroupByPoints {
+			return nil, ErrTooManyIntervals
+		}
+		e.windows = append(e.windows, t)
+	}
+	if len(stmt.SortFields) > 0 && !stmt.SortFields[0].Ascending {
+		for i, j := 0, len(e.windows)-1; i < j; i, j = i+1, j-1 {
+			e.windows[i], e.windows[j] = e.windows[j], e.windows[i]
+		}
+	}
+	return e, nil
+}
+
+// Emit returns the row of the next group. Returns nil when there are no more
+// points.
+func (e *Emitter) Emit() (*influxql.Row, error) {
+	// Read the first point of the group.
+	if e.next == nil {
+		p, err := e.input.Next()
+		if err != nil || p == nil {
+			return nil, err
+		}
+		e.next = p
+	}
+
+	// Read the remaining points of the group.
+	first := e.next
+	key := first.groupKey(e.dimensions)
+	points := []*Point{first}
+	e.next = nil
+	for {
+		p, err := e.input.Next()
+		if err != nil {
+			return nil, err
+		} else if p == nil {
+			break
+		} else if p.groupKey(e.dimensions) != key {
+			e.next = p
+			break
+		}
+		points = append(points, p)
+	}
+
+	row := &influxql.Row{Name: first.Name, Columns: e.columns}
+	if len(e.dimensions) > 0 {
+		row.Tags = make(map[string]string, len(e.dimensions))
+		for _, dim := range e.dimensions {
+			row.Tags[dim] = first.Tags[dim]
+		}
+	}
+
+	if e.windows == nil {
+		for _, p := range points {
+			row.Values = append(row.Values, e.values(p))
+		}
+		return row, nil
+	}
+
+	// Add a row of values for every interval. Points are in the same
+	// order as the intervals.
+	for _, w := range e.windows {
+		if len(points) > 0 && points[0].Time == w {
+			row.Values = append(row.Values, e.values(points[0]))
+			points = points[1:]
+			continue
+		}
+		values := make([]interface{}, len(e.columns))
+		values[0] = time.Unix(0, w).UTC()
+		row.Values = append(row.Values, values)
+	}
+	row.Values = e.fillValues(row.Values)
+	return row, nil
+}
+
+// values returns the time and the values of the columns of a point.
+func (e *Emitter) values(p *Point) []interface{} {
+	values := make([]interface{}, len(e.columns))
+	values[0] = time.Unix(0, p.Time).UTC()
+	for i, name := range e.names {
+		values[i+1] = p.Values[name]
+	}
+	return values
+}
+
+// fillValues replaces the missing values of the intervals with the fill
+// option of the statement. Intervals with missing values are removed by
+// fill(none).
+func (e *Emitter) fillValues(a [][]interface{}) [][]interface{} {
+	switch e.fill {
+	case influxql.NullFill:
+		return a
+	case influxql.NoFill:
+		other := a[:0]
+		for _, values := range a {
+			if !hasNil(values[1:]) {
+				other = append(other, values)
+			}
+		}
+		return other
+	}
+
+	for i, values := range a {
+		for j := 1; j < len(values); j++ {
+			if values[j] != nil {
+				continue
+			}
+			switch e.fill {
+			case influxql.PreviousFill:
+				if i > 0 {
+					values[j] = a[i-1][j]
+				}
+			case influxql.NumberFill:
+				values[j] = e.fillValue
+			}
+		}
+	}
+	return a
+}
+
+// hasNil returns true if any of the values is nil.
+func hasNil(values []interface{}) bool {
+	for _, v := range values {
+		if v == nil {
+			return true
+		}
+	}
+	return false
+}
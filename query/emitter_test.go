@@ -0,0 +1,109 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/query"
+)
+
+// Ensure the emitter returns a row for each group with the dimension tags.
+func TestEmitter(t *testing.T) {
+	e, err := query.NewEmitter(query.NewSliceIterator([]*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A", "region": "east"}, Time: 1, Values: map[string]interface{}{"value": float64(1)}},
+		{Name: "cpu", Tags: map[string]string{"host": "B", "region": "east"}, Time: 2, Values: map[string]interface{}{"value": float64(2)}},
+		{Name: "cpu", Tags: map[string]string{"host": "C", "region": "west"}, Time: 3, Values: map[string]interface{}{"value": float64(3)}},
+		{Name: "mem", Tags: map[string]string{"host": "A", "region": "east"}, Time: 4, Values: map[string]interface{}{"value": float64(4)}},
+	}), MustParseSelectStatement(`SELECT value FROM cpu, mem GROUP BY region`), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a := MustEmitAll(e); !reflect.DeepEqual(a, []*influxql.Row{
+		{Name: "cpu", Tags: map[string]string{"region": "east"}, Columns: []string{"time", "value"}, Values: [][]interface{}{
+			{time.Unix(0, 1).UTC(), float64(1)},
+			{time.Unix(0, 2).UTC(), float64(2)},
+		}},
+		{Name: "cpu", Tags: map[string]string{"region": "west"}, Columns: []string{"time", "value"}, Values: [][]interface{}{
+			{time.Unix(0, 3).UTC(), float64(3)},
+		}},
+		{Name: "mem", Tags: map[string]string{"region": "east"}, Columns: []string{"time", "value"}, Values: [][]interface{}{
+			{time.Unix(0, 4).UTC(), float64(4)},
+		}},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the emitter fills the empty intervals of an aggregate query.
+func TestEmitter_Fill(t *testing.T) {
+	for i, tt := range []struct {
+		fill   string
+		values [][]interface{}
+	}{
+		{fill: "", values: [][]interface{}{{0, nil}, {10, float64(1)}, {20, nil}, {30, float64(3)}}},
+		{fill: "fill(none)", values: [][]interface{}{{10, float64(1)}, {30, float64(3)}}},
+		{fill: "fill(previous)", values: [][]interface{}{{0, nil}, {10, float64(1)}, {20, float64(1)}, {30, float64(3)}}},
+		{fill: "fill(100)", values: [][]interface{}{{0, float64(100)}, {10, float64(1)}, {20, float64(100)}, {30, float64(3)}}},
+	} {
+		e, err := query.NewEmitter(query.NewSliceIterator([]*query.Point{
+			{Name: "cpu", Time: 10e9, Values: map[string]interface{}{"sum_value": float64(1)}},
+			{Name: "cpu", Time: 30e9, Values: map[string]interface{}{"sum_value": float64(3)}},
+		}), MustParseSelectStatement(`SELECT sum(value) FROM cpu WHERE time >= '1970-01-01T00:00:05Z' AND time < '1970-01-01T00:00:40Z' GROUP BY time(10s) `+tt.fill), time.Time{})
+		if err != nil {
+			t.Fatalf("%d. %s", i, err)
+		}
+
+		// Convert the expected times from seconds.
+		for _, values := range tt.values {
+			values[0] = time.Unix(int64(values[0].(int)), 0).UTC()
+		}
+
+		if a := MustEmitAll(e); len(a) != 1 || !reflect.DeepEqual(a[0].Values, tt.values) {
+			t.Errorf("%d. %s: unexpected rows: %s", i, tt.fill, spew.Sdump(a))
+		}
+	}
+}
+
+// Ensure the intervals of a descending aggregate query are in reverse order.
+func TestEmitter_Descending(t *testing.T) {
+	e, err := query.NewEmitter(query.NewSliceIterator([]*query.Point{
+		{Name: "cpu", Time: 20, Values: map[string]interface{}{"count_value": int64(2)}},
+	}), MustParseSelectStatement(`SELECT count(value) FROM cpu WHERE time >= '1970-01-01T00:00:00.000000010Z' GROUP BY time(10ns) ORDER BY time DESC`), time.Unix(0, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a := MustEmitAll(e); len(a) != 1 || !reflect.DeepEqual(a[0].Values, [][]interface{}{
+		{time.Unix(0, 30).UTC(), nil},
+		{time.Unix(0, 20).UTC(), int64(2)},
+		{time.Unix(0, 10).UTC(), nil},
+	}) {
+		t.Fatalf("unexpected rows: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the emitter returns an error if a statement has too many intervals.
+func TestEmitter_ErrTooManyIntervals(t *testing.T) {
+	_, err := query.NewEmitter(query.NewSliceIterator(nil), MustParseSelectStatement(`SELECT count(value) FROM cpu WHERE time >= '1970-01-01T00:00:00.000000001Z' GROUP BY time(1ns)`), time.Unix(1, 0))
+	if err != query.ErrTooManyIntervals {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// MustEmitAll returns all rows of an emitter. Panic on error.
+func MustEmitAll(e *query.Emitter) []*influxql.Row {
+	var a []*influxql.Row
+	for {
+		row, err := e.Emit()
+		if err != nil {
+			panic(err.Error())
+		} else if row == nil {
+			return a
+		}
+		a = append(a, row)
+	}
+}
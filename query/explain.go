@@ -0,0 +1,150 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Plan represents a single operator in the iterator tree built by Compile.
+type Plan struct {
+	// Operator is the name of the iterator, e.g. "scan" or "filter".
+	Operator string
+
+	// Detail describes how the operator is configured.
+	Detail string
+
+	// Inputs are the operators this operator reads from.
+	Inputs []*Plan
+
+	// Stats holds the counters collected while executing the operator.
+	// Only set by Analyze.
+	Stats *IteratorStats
+}
+
+// Lines returns the plan as an indented tree, one operator per line.
+func (p *Plan) Lines() []string {
+	var lines []string
+	p.lines(0, &lines)
+	return lines
+}
+
+func (p *Plan) lines(depth int, lines *[]string) {
+	line := strings.Repeat("  ", depth) + p.Operator
+	if p.Detail != "" {
+		line += " " + p.Detail
+	}
+	if p.Stats != nil {
+		line += " " + p.Stats.String()
+	}
+	*lines = append(*lines, line)
+
+	for _, input := range p.Inputs {
+		input.lines(depth+1, lines)
+	}
+}
+
+// String returns the plan as an indented tree.
+func (p *Plan) String() string { return strings.Join(p.Lines(), "\n") }
+
+// IteratorStats represents the counters collected for an operator.
+type IteratorStats struct {
+	// Number of points returned by the operator. For a scan this is the
+	// number of points read from the source.
+	PointN int
+
+	// Number of blocks decoded. Only reported by sources.
+	BlockN int
+
+	// Time spent in the operator, excluding the time spent in its inputs.
+	Duration time.Duration
+}
+
+// String returns a string representation of the counters.
+func (s *IteratorStats) String() string {
+	return fmt.Sprintf("(points=%d blocks=%d time=%s)", s.PointN, s.BlockN, s.Duration)
+}
+
+// StatsIterator is implemented by source iterators that report their own counters.
+type StatsIterator interface {
+	Iterator
+	Stats() IteratorStats
+}
+
+// Explain returns the plan Compile would build for stmt without reading any
+// points. The statement must have been validated and have its wildcards rewritten.
+// The source is only used to push predicates down and may be nil.
+func Explain(stmt *influxql.SelectStatement, src Source, opt CompileOptions) (*Plan, error) {
+	_, plan, err := (&compiler{src: src, opt: opt, explain: true}).compile(stmt)
+	return plan, err
+}
+
+// Analyze executes stmt against src and returns its plan with the counters
+// collected for each operator. The resulting points are discarded.
+func Analyze(stmt *influxql.SelectStatement, src Source, opt CompileOptions) (*Plan, error) {
+	itr, plan, err := (&compiler{src: src, opt: opt, analyze: true}).compile(stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read every point and close the iterators so sources report their counters.
+	for {
+		select {
+		case <-opt.Closing:
+			itr.Close()
+			return nil, ErrQueryCanceled
+		default:
+		}
+
+		p, err := itr.Next()
+		if err != nil {
+			itr.Close()
+			return nil, err
+		} else if p == nil {
+			break
+		}
+	}
+	if err := itr.Close(); err != nil {
+		return nil, err
+	}
+
+	subtractInputDurations(plan)
+	return plan, nil
+}
+
+// subtractInputDurations converts the duration of each operator from the
+// time spent in its Next() calls to the time spent in the operator itself.
+func subtractInputDurations(p *Plan) {
+	for _, input := range p.Inputs {
+		p.Stats.Duration -= input.Stats.Duration
+		subtractInputDurations(input)
+	}
+}
+
+// analyzeIterator collects counters for the iterator it wraps.
+type analyzeIterator struct {
+	input Iterator
+	stats *IteratorStats
+}
+
+// Next returns the next point from the input and updates the counters.
+func (itr *analyzeIterator) Next() (*Point, error) {
+	start := time.Now()
+	p, err := itr.input.Next()
+	itr.stats.Duration += time.Since(start)
+
+	if p != nil {
+		itr.stats.PointN++
+	}
+	return p, err
+}
+
+// Close copies the counters reported by the input and closes it.
+func (itr *analyzeIterator) Close() error {
+	if input, ok := itr.input.(StatsIterator); ok {
+		itr.stats.BlockN = input.Stats().BlockN
+	}
+	return itr.input.Close()
+}
@@ -0,0 +1,97 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdb/influxdb/query"
+)
+
+// Ensure an aggregate query is explained as a tree of iterators.
+func TestExplain(t *testing.T) {
+	plan, err := query.Explain(MustParseSelectStatement(`SELECT sum(value) FROM cpu WHERE time >= '1970-01-01T00:00:00.00000001Z' AND host = 'A' GROUP BY time(20ns), region LIMIT 2`), nil, query.CompileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a := plan.Lines(); !reflect.DeepEqual(a, []string{
+		`limit limit=2 offset=0`,
+		`  aggregate fields=sum(value)`,
+		`    merge sorted=true`,
+		`      group dimensions=region interval=20ns`,
+		`        filter condition=host = 'A'`,
+		`          scan measurement=cpu names=value,host,region time=[10, 9223372036854775807] ordered=true ascending=true`,
+	}) {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+}
+
+// Ensure statements with wildcards must be rewritten before explaining.
+func TestExplain_ErrWildcardNotRewritten(t *testing.T) {
+	if _, err := query.Explain(MustParseSelectStatement(`SELECT * FROM cpu`), nil, query.CompileOptions{}); err != query.ErrWildcardNotRewritten {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure analyzing a query executes it and collects counters for each operator.
+func TestAnalyze(t *testing.T) {
+	var src Source
+	src.CreateIteratorFn = func(opt query.IteratorOptions) (query.Iterator, error) {
+		return &StatsIterator{
+			Iterator: query.NewSliceIterator([]*query.Point{
+				{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 10, Values: map[string]interface{}{"value": float64(1)}},
+				{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 20, Values: map[string]interface{}{"value": float64(2)}},
+				{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 30, Values: map[string]interface{}{"value": float64(3)}},
+			}),
+			BlockN: 2,
+		}, nil
+	}
+
+	plan, err := query.Analyze(MustParseSelectStatement(`SELECT value FROM cpu WHERE host = 'A' LIMIT 1`), &src, query.CompileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the counters from the root operator down to the scan.
+	var a [][2]int
+	for p := plan; p != nil; {
+		if p.Stats == nil {
+			t.Fatalf("missing stats: %s", p.Operator)
+		} else if p.Stats.Duration < 0 {
+			t.Fatalf("negative duration: %s", p.Operator)
+		}
+		a = append(a, [2]int{p.Stats.PointN, p.Stats.BlockN})
+
+		if len(p.Inputs) == 0 {
+			break
+		}
+		p = p.Inputs[0]
+	}
+	if !reflect.DeepEqual(a, [][2]int{{1, 0}, {2, 0}, {2, 0}, {2, 0}, {2, 0}, {3, 2}}) {
+		t.Fatalf("unexpected counters: %v\n%s", a, plan)
+	}
+}
+
+// Ensure analyzing a query stops when the query is canceled.
+func TestAnalyze_Canceled(t *testing.T) {
+	var src Source
+	src.CreateIteratorFn = func(opt query.IteratorOptions) (query.Iterator, error) {
+		return query.NewSliceIterator([]*query.Point{{Name: "cpu", Time: 10, Values: map[string]interface{}{"value": float64(1)}}}), nil
+	}
+
+	closing := make(chan struct{})
+	close(closing)
+	if _, err := query.Analyze(MustParseSelectStatement(`SELECT value FROM cpu`), &src, query.CompileOptions{Closing: closing}); err != query.ErrQueryCanceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// StatsIterator is a source iterator that reports a fixed number of blocks decoded.
+type StatsIterator struct {
+	query.Iterator
+	BlockN int
+}
+
+func (itr *StatsIterator) Stats() query.IteratorStats {
+	return query.IteratorStats{BlockN: itr.BlockN}
+}
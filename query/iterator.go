@@ -0,0 +1,811 @@
+package query
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// Point represents a single point passed between iterators.
+type Point struct {
+	Name   string
+	Tags   map[string]string
+	Time   int64
+	Values map[string]interface{}
+
+	// Window is the start time of the group by interval that the point
+	// belongs to. It's only set by the group iterator.
+	Window int64
+}
+
+// valueMap returns the tags and values of the point in a single map so the
+// point can be evaluated against an expression. Values replace tags with the
+// same name.
+func (p *Point) valueMap() map[string]interface{} {
+	m := make(map[string]interface{}, len(p.Tags)+len(p.Values))
+	for k, v := range p.Tags {
+		m[k] = v
+	}
+	for k, v := range p.Values {
+		m[k] = v
+	}
+	return m
+}
+
+// seriesKey returns a key that identifies the series of the point.
+func (p *Point) seriesKey() string {
+	return p.Name + "," + tagsKey(p.Tags)
+}
+
+// groupKey returns a key that identifies the group of the point when grouped
+// by the tags in dimensions. It's the series key the point has once its tags
+// are reduced to the dimensions by a GroupIterator.
+func (p *Point) groupKey(dimensions []string) string {
+	tags := make(map[string]string, len(dimensions))
+	for _, dim := range dimensions {
+		tags[dim] = p.Tags[dim]
+	}
+	return p.Name + "," + tagsKey(tags)
+}
+
+// tagsKey returns the tags as a string sorted by key.
+func tagsKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	a := make([]string, len(keys))
+	for i, k := range keys {
+		a[i] = k + "=" + tags[k]
+	}
+	return strings.Join(a, ",")
+}
+
+// Iterator represents a forward-only iterator over a set of points.
+type Iterator interface {
+	// Next returns the next point. Returns nil when there are no more points.
+	Next() (*Point, error)
+
+	// Close releases any resources held by the iterator and its inputs.
+	Close() error
+}
+
+// Iterators represents a list of iterators.
+type Iterators []Iterator
+
+// Close closes every iterator and returns the first error.
+func (a Iterators) Close() error {
+	var err error
+	for _, itr := range a {
+		if e := itr.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// readAll returns every point from an iterator.
+func readAll(itr Iterator) ([]*Point, error) {
+	var a []*Point
+	for {
+		p, err := itr.Next()
+		if err != nil {
+			return nil, err
+		} else if p == nil {
+			return a, nil
+		}
+		a = append(a, p)
+	}
+}
+
+// SliceIterator returns points from a slice.
+type SliceIterator struct {
+	points []*Point
+}
+
+// NewSliceIterator returns a new instance of SliceIterator.
+func NewSliceIterator(points []*Point) *SliceIterator {
+	return &SliceIterator{points: points}
+}
+
+// Next returns the next point in the slice.
+func (itr *SliceIterator) Next() (*Point, error) {
+	if len(itr.points) == 0 {
+		return nil, nil
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return p, nil
+}
+
+// Close removes the remaining points.
+func (itr *SliceIterator) Close() error {
+	itr.points = nil
+	return nil
+}
+
+// MultiIterator returns the points of each input in turn.
+type MultiIterator struct {
+	inputs Iterators
+	i      int
+}
+
+// NewMultiIterator returns a new instance of MultiIterator.
+func NewMultiIterator(inputs ...Iterator) *MultiIterator {
+	return &MultiIterator{inputs: inputs}
+}
+
+// Next returns the next point from the current input.
+func (itr *MultiIterator) Next() (*Point, error) {
+	for itr.i < len(itr.inputs) {
+		p, err := itr.inputs[itr.i].Next()
+		if err != nil {
+			return nil, err
+		} else if p != nil {
+			return p, nil
+		}
+		itr.i++
+	}
+	return nil, nil
+}
+
+// Close closes all inputs.
+func (itr *MultiIterator) Close() error { return itr.inputs.Close() }
+
+// SortedMergeIterator merges inputs that are each ordered by series and then
+// by time into a single stream with the same order. Only the next point of
+// each input is held in memory.
+type SortedMergeIterator struct {
+	inputs Iterators
+	heap   *sortedMergeHeap
+	key    func(*Point) string
+	init   bool
+}
+
+// NewSortedMergeIterator returns a new instance of SortedMergeIterator.
+func NewSortedMergeIterator(inputs []Iterator, ascending bool) *SortedMergeIterator {
+	return &SortedMergeIterator{
+		inputs: inputs,
+		heap:   &sortedMergeHeap{ascending: ascending},
+		key:    (*Point).seriesKey,
+	}
+}
+
+// NewGroupMergeIterator returns a SortedMergeIterator for inputs that are
+// ordered by the group of each point, as sorted by NewGroupSortIterator,
+// instead of by series.
+func NewGroupMergeIterator(inputs []Iterator, dimensions []string, ascending bool) *SortedMergeIterator {
+	itr := NewSortedMergeIterator(inputs, ascending)
+	itr.key = func(p *Point) string { return p.groupKey(dimensions) }
+	return itr
+}
+
+// Next returns the next point across all inputs.
+func (itr *SortedMergeIterator) Next() (*Point, error) {
+	// Read the first point from every input.
+	if !itr.init {
+		for i, input := range itr.inputs {
+			p, err := input.Next()
+			if err != nil {
+				return nil, err
+			} else if p != nil {
+				itr.heap.items = append(itr.heap.items, sortedMergeItem{key: itr.key(p), point: p, input: input, index: i})
+			}
+		}
+		heap.Init(itr.heap)
+		itr.init = true
+	}
+
+	if itr.heap.Len() == 0 {
+		return nil, nil
+	}
+
+	// Return the lowest point and replace it with the next one from its input.
+	item := &itr.heap.items[0]
+	p := item.point
+	next, err := item.input.Next()
+	if err != nil {
+		return nil, err
+	} else if next != nil {
+		item.key, item.point = itr.key(next), next
+		heap.Fix(itr.heap, 0)
+	} else {
+		heap.Pop(itr.heap)
+	}
+	return p, nil
+}
+
+// Close closes all inputs.
+func (itr *SortedMergeIterator) Close() error { return itr.inputs.Close() }
+
+// sortedMergeItem is the next point of an input to a SortedMergeIterator.
+type sortedMergeItem struct {
+	key   string
+	point *Point
+	input Iterator
+	index int
+}
+
+// sortedMergeHeap orders the next point of each input by series and time.
+// Equal points are ordered by input.
+type sortedMergeHeap struct {
+	items     []sortedMergeItem
+	ascending bool
+}
+
+func (h *sortedMergeHeap) Len() int      { return len(h.items) }
+func (h *sortedMergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *sortedMergeHeap) Less(i, j int) bool {
+	x, y := h.items[i], h.items[j]
+	if x.key != y.key {
+		return x.key < y.key
+	} else if x.point.Time != y.point.Time {
+		return (x.point.Time < y.point.Time) == h.ascending
+	}
+	return x.index < y.index
+}
+
+func (h *sortedMergeHeap) Push(x interface{}) { h.items = append(h.items, x.(sortedMergeItem)) }
+func (h *sortedMergeHeap) Pop() interface{} {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
+}
+
+// FilterIterator returns the points from its input that match a condition.
+type FilterIterator struct {
+	input Iterator
+	cond  influxql.Expr
+}
+
+// NewFilterIterator returns a new instance of FilterIterator.
+func NewFilterIterator(input Iterator, cond influxql.Expr) *FilterIterator {
+	return &FilterIterator{input: input, cond: cond}
+}
+
+// Next returns the next point that matches the condition.
+func (itr *FilterIterator) Next() (*Point, error) {
+	for {
+		p, err := itr.input.Next()
+		if err != nil || p == nil {
+			return nil, err
+		}
+
+		if ok, err := influxql.EvalBool(itr.cond, p.valueMap()); err != nil {
+			return nil, err
+		} else if ok {
+			return p, nil
+		}
+	}
+}
+
+// Close closes the input.
+func (itr *FilterIterator) Close() error { return itr.input.Close() }
+
+// GroupIterator reduces the tags of each point from its input to the group by
+// dimensions and sets its window to the start of its group by interval.
+//
+// Points are returned as they're read so the input must already be ordered by
+// group and then by time, as it is by NewGroupSortIterator. The points of each
+// window are then returned together.
+type GroupIterator struct {
+	input      Iterator
+	dimensions []string
+	interval   int64
+	start      int64
+}
+
+// NewGroupIterator returns a new instance of GroupIterator. Points are grouped
+// into windows of interval nanoseconds. If interval is zero then every point
+// is in a single window beginning at start.
+func NewGroupIterator(input Iterator, dimensions []string, interval, start int64) *GroupIterator {
+	return &GroupIterator{
+		input:      input,
+		dimensions: dimensions,
+		interval:   interval,
+		start:      start,
+	}
+}
+
+// Next returns the next point with its tags reduced and its window set.
+func (itr *GroupIterator) Next() (*Point, error) {
+	p, err := itr.input.Next()
+	if err != nil || p == nil {
+		return nil, err
+	}
+
+	other := *p
+	other.Tags = make(map[string]string, len(itr.dimensions))
+	for _, dim := range itr.dimensions {
+		other.Tags[dim] = p.Tags[dim]
+	}
+
+	other.Window = itr.start
+	if itr.interval > 0 {
+		other.Window = window(p.Time, itr.interval)
+	}
+	return &other, nil
+}
+
+// Close closes the input.
+func (itr *GroupIterator) Close() error { return itr.input.Close() }
+
+// window returns the start of the interval holding t. Intervals are aligned
+// to the epoch, including those before it.
+func window(t, interval int64) int64 {
+	w := t - t%interval
+	if w > t {
+		w -= interval
+	}
+	return w
+}
+
+// AggregateIterator computes the fields of an aggregate query for each
+// window of its input. The input must be grouped by a GroupIterator.
+//
+// Only the points of the current window are held in memory. Each window is
+// returned as soon as a point from another window is read.
+type AggregateIterator struct {
+	input  Iterator
+	fields []aggregateField
+	calls  []*aggregateCall
+	next   *Point
+}
+
+// aggregateField is a field computed from the calls in a window.
+type aggregateField struct {
+	name string
+	expr influxql.Expr
+}
+
+// aggregateCall is an aggregate function applied to a single field.
+type aggregateCall struct {
+	key      string
+	field    string
+	mapFn    influxql.MapFunc
+	reduceFn influxql.ReduceFunc
+}
+
+// supportedAggregates are the functions that can be computed by an
+// AggregateIterator. Transforms and nested aggregates aren't supported.
+var supportedAggregates = map[string]struct{}{
+	"count": {}, "distinct": {}, "sum": {}, "mean": {}, "median": {}, "min": {},
+	"max": {}, "spread": {}, "stddev": {}, "first": {}, "last": {}, "percentile": {},
+}
+
+// NewAggregateIterator returns a new instance of AggregateIterator. Fields are
+// named in the same way as the columns of a SELECT statement.
+func NewAggregateIterator(input Iterator, fields influxql.Fields) (*AggregateIterator, error) {
+	itr := &AggregateIterator{input: input}
+	seen := make(map[string]*aggregateCall)
+
+	// Calls are replaced in each field by a reference to their result.
+	var err error
+	names := fieldNames(fields)
+	for i, f := range timelessFields(fields) {
+		expr := rewriteCalls(f.Expr, func(c *influxql.Call) influxql.Expr {
+			key := c.String()
+			if _, ok := seen[key]; !ok {
+				call, e := newAggregateCall(c)
+				if e != nil && err == nil {
+					err = e
+				}
+				seen[key] = call
+				itr.calls = append(itr.calls, call)
+			}
+			return &influxql.VarRef{Val: key}
+		})
+		itr.fields = append(itr.fields, aggregateField{name: names[i], expr: expr})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return itr, nil
+}
+
+// newAggregateCall returns the map and reduce functions for a call.
+func newAggregateCall(c *influxql.Call) (*aggregateCall, error) {
+	if _, ok := supportedAggregates[c.Name]; !ok {
+		return nil, fmt.Errorf("unsupported function: %s()", c.Name)
+	}
+
+	mapFn, err := influxql.InitializeMapFunc(c)
+	if err != nil {
+		return nil, err
+	}
+	reduceFn, err := influxql.InitializeReduceFunc(c)
+	if err != nil {
+		return nil, err
+	}
+
+	field := callField(c)
+	if field == "" {
+		return nil, fmt.Errorf("expected field argument in %s()", c.Name)
+	}
+	return &aggregateCall{key: c.String(), field: field, mapFn: mapFn, reduceFn: reduceFn}, nil
+}
+
+// callField returns the name of the field read by a call.
+func callField(c *influxql.Call) string {
+	if len(c.Args) == 0 {
+		return ""
+	}
+	switch arg := c.Args[0].(type) {
+	case *influxql.VarRef:
+		return arg.Val
+	case *influxql.Distinct:
+		return arg.Val
+	case *influxql.Call:
+		if arg.Name == "distinct" {
+			return callField(arg)
+		}
+	}
+	return ""
+}
+
+// Next returns the aggregated point for the next window.
+func (itr *AggregateIterator) Next() (*Point, error) {
+	// Read the first point of the window.
+	if itr.next == nil {
+		p, err := itr.input.Next()
+		if err != nil || p == nil {
+			return nil, err
+		}
+		itr.next = p
+	}
+
+	// Read the remaining points of the window.
+	first := itr.next
+	key := first.seriesKey()
+	window := []*Point{first}
+	itr.next = nil
+	for {
+		p, err := itr.input.Next()
+		if err != nil {
+			return nil, err
+		} else if p == nil {
+			break
+		} else if p.Window != first.Window || p.seriesKey() != key {
+			itr.next = p
+			break
+		}
+		window = append(window, p)
+	}
+
+	// Compute each call and then the fields from the results.
+	m := make(map[string]interface{}, len(first.Tags)+len(itr.calls))
+	for k, v := range first.Tags {
+		m[k] = v
+	}
+	for _, call := range itr.calls {
+		values := []interface{}{call.mapFn(&windowIterator{points: window, field: call.field})}
+		m[call.key] = call.reduceFn(values)
+	}
+
+	p := &Point{
+		Name:   first.Name,
+		Tags:   first.Tags,
+		Time:   first.Window,
+		Values: make(map[string]interface{}, len(itr.fields)),
+		Window: first.Window,
+	}
+	for _, f := range itr.fields {
+		p.Values[f.name] = influxql.Eval(f.expr, m)
+	}
+	return p, nil
+}
+
+// Close closes the input.
+func (itr *AggregateIterator) Close() error { return itr.input.Close() }
+
+// windowIterator adapts the points of a window to the iterator used by the
+// map functions of the influxql package.
+type windowIterator struct {
+	points []*Point
+	field  string
+}
+
+// Next returns the time and value of the next point with the field set.
+func (itr *windowIterator) Next() (seriesKey string, time int64, value interface{}) {
+	for len(itr.points) > 0 {
+		p := itr.points[0]
+		itr.points = itr.points[1:]
+		if v, ok := p.Values[itr.field]; ok && v != nil {
+			return "", p.Time, v
+		}
+	}
+	return "", 0, nil
+}
+
+// ProjectIterator computes the fields of a raw query for each point of its
+// input. Points without any of the fields are skipped.
+type ProjectIterator struct {
+	input  Iterator
+	fields influxql.Fields
+	names  []string
+}
+
+// NewProjectIterator returns a new instance of ProjectIterator. Fields are
+// named in the same way as the columns of a SELECT statement.
+func NewProjectIterator(input Iterator, fields influxql.Fields) *ProjectIterator {
+	return &ProjectIterator{
+		input:  input,
+		fields: timelessFields(fields),
+		names:  fieldNames(fields),
+	}
+}
+
+// Next returns the next point with at least one field.
+func (itr *ProjectIterator) Next() (*Point, error) {
+	for {
+		p, err := itr.input.Next()
+		if err != nil || p == nil {
+			return nil, err
+		}
+
+		m := p.valueMap()
+		other := &Point{Name: p.Name, Tags: p.Tags, Time: p.Time, Window: p.Window}
+		other.Values = make(map[string]interface{}, len(itr.fields))
+		var found bool
+		for i, f := range itr.fields {
+			v := influxql.Eval(f.Expr, m)
+			other.Values[itr.names[i]] = v
+			found = found || v != nil
+		}
+		if found {
+			return other, nil
+		}
+	}
+}
+
+// Close closes the input.
+func (itr *ProjectIterator) Close() error { return itr.input.Close() }
+
+// SortIterator orders the points of its input by series and then by time.
+//
+// The whole input is read before the first point is returned. If MaxMemory is
+// set, sorted runs of points are written to temporary files whenever the
+// points held in memory exceed it, and the runs are merged as they're read.
+type SortIterator struct {
+	input     Iterator
+	ascending bool
+	key       func(*Point) string
+
+	// Maximum estimated bytes of points held in memory. Zero means no limit.
+	MaxMemory int
+
+	// Directory to write runs to. Defaults to the system's temp directory.
+	TempDir string
+
+	// If set, closing the channel stops the sort with ErrQueryCanceled.
+	Closing <-chan struct{}
+
+	itr  Iterator
+	runs Iterators
+}
+
+// NewSortIterator returns a new instance of SortIterator.
+func NewSortIterator(input Iterator, ascending bool) *SortIterator {
+	return &SortIterator{input: input, ascending: ascending, key: (*Point).seriesKey}
+}
+
+// NewGroupSortIterator returns a SortIterator that orders points by the
+// values of the tags in dimensions instead of by series. The points of every
+// series in a group are merged by time.
+func NewGroupSortIterator(input Iterator, dimensions []string, ascending bool) *SortIterator {
+	itr := NewSortIterator(input, ascending)
+	itr.key = func(p *Point) string { return p.groupKey(dimensions) }
+	return itr
+}
+
+// Next returns the next point in sorted order.
+func (itr *SortIterator) Next() (*Point, error) {
+	if itr.itr == nil {
+		if err := itr.load(); err != nil {
+			return nil, err
+		}
+	}
+	if itr.canceled() {
+		return nil, ErrQueryCanceled
+	}
+	return itr.itr.Next()
+}
+
+// load reads the whole input and spills sorted runs to disk if the points
+// don't fit in memory.
+func (itr *SortIterator) load() error {
+	var points []*Point
+	var size int
+	for {
+		if itr.canceled() {
+			return ErrQueryCanceled
+		}
+
+		p, err := itr.input.Next()
+		if err != nil {
+			return err
+		} else if p == nil {
+			break
+		}
+		points = append(points, p)
+
+		if size += p.size(); itr.MaxMemory > 0 && size > itr.MaxMemory {
+			run, err := createSortRun(itr.TempDir, itr.sort(points))
+			if err != nil {
+				return err
+			}
+			itr.runs = append(itr.runs, run)
+			points, size = nil, 0
+		}
+	}
+
+	// Points that fit in memory are returned directly. Otherwise the runs
+	// and the remaining points are merged. Earlier runs hold earlier input
+	// so equal points keep the order they were read in.
+	if len(itr.runs) == 0 {
+		itr.itr = NewSliceIterator(itr.sort(points))
+		return nil
+	}
+	inputs := make([]Iterator, 0, len(itr.runs)+1)
+	inputs = append(inputs, itr.runs...)
+	inputs = append(inputs, NewSliceIterator(itr.sort(points)))
+
+	merge := NewSortedMergeIterator(inputs, itr.ascending)
+	merge.key = itr.key
+	itr.itr, itr.runs = merge, nil
+	return nil
+}
+
+// sort returns the points in sorted order.
+func (itr *SortIterator) sort(points []*Point) []*Point {
+	a := make(sortedPoints, len(points))
+	for i, p := range points {
+		a[i] = sortedPoint{key: itr.key(p), point: p}
+	}
+	sort.Stable(sortedPointsBy{a, itr.ascending})
+
+	other := make([]*Point, len(a))
+	for i := range a {
+		other[i] = a[i].point
+	}
+	return other
+}
+
+// canceled returns true if the closing channel has been closed.
+func (itr *SortIterator) canceled() bool {
+	select {
+	case <-itr.Closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close closes the input and removes any runs written to disk.
+func (itr *SortIterator) Close() error {
+	var err error
+	if itr.itr != nil {
+		err = itr.itr.Close()
+	}
+	if e := itr.runs.Close(); e != nil && err == nil {
+		err = e
+	}
+	if e := itr.input.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
+
+// sortedPoint is a point along with its sort key.
+type sortedPoint struct {
+	key   string
+	point *Point
+}
+
+type sortedPoints []sortedPoint
+
+// sortedPointsBy sorts points by key and then by time in either direction.
+type sortedPointsBy struct {
+	a         sortedPoints
+	ascending bool
+}
+
+func (s sortedPointsBy) Len() int      { return len(s.a) }
+func (s sortedPointsBy) Swap(i, j int) { s.a[i], s.a[j] = s.a[j], s.a[i] }
+func (s sortedPointsBy) Less(i, j int) bool {
+	if s.a[i].key != s.a[j].key {
+		return s.a[i].key < s.a[j].key
+	} else if s.ascending {
+		return s.a[i].point.Time < s.a[j].point.Time
+	}
+	return s.a[i].point.Time > s.a[j].point.Time
+}
+
+// LimitIterator returns at most limit points from each series of its input
+// after skipping the first offset points of the series.
+type LimitIterator struct {
+	input  Iterator
+	limit  int
+	offset int
+	key    func(*Point) string
+	counts map[string]int
+}
+
+// NewLimitIterator returns a new instance of LimitIterator. A limit of zero
+// returns every point after the offset.
+func NewLimitIterator(input Iterator, limit, offset int) *LimitIterator {
+	return &LimitIterator{
+		input:  input,
+		limit:  limit,
+		offset: offset,
+		key:    (*Point).seriesKey,
+		counts: make(map[string]int),
+	}
+}
+
+// NewGroupLimitIterator returns a LimitIterator that applies the limit and
+// offset to the points of each group of the tags in dimensions instead of
+// to each series.
+func NewGroupLimitIterator(input Iterator, dimensions []string, limit, offset int) *LimitIterator {
+	itr := NewLimitIterator(input, limit, offset)
+	itr.key = func(p *Point) string { return p.groupKey(dimensions) }
+	return itr
+}
+
+// Next returns the next point within the limit and offset of its series.
+func (itr *LimitIterator) Next() (*Point, error) {
+	for {
+		p, err := itr.input.Next()
+		if err != nil || p == nil {
+			return nil, err
+		}
+
+		key := itr.key(p)
+		n := itr.counts[key]
+		itr.counts[key] = n + 1
+		if n < itr.offset || (itr.limit > 0 && n >= itr.offset+itr.limit) {
+			continue
+		}
+		return p, nil
+	}
+}
+
+// Close closes the input.
+func (itr *LimitIterator) Close() error { return itr.input.Close() }
+
+// timelessFields returns the fields without an unaliased reference to time,
+// which is always returned as the time of each point.
+func timelessFields(fields influxql.Fields) influxql.Fields {
+	var a influxql.Fields
+	for _, f := range fields {
+		if ref, ok := f.Expr.(*influxql.VarRef); ok && ref.Val == "time" && f.Alias == "" {
+			continue
+		}
+		a = append(a, f)
+	}
+	return a
+}
+
+// fieldNames returns the names of the fields returned by timelessFields.
+func fieldNames(fields influxql.Fields) []string {
+	stmt := &influxql.SelectStatement{Fields: fields}
+	return stmt.ColumnNames()[1:]
+}
+
+// rewriteCalls replaces the outermost calls in an expression.
+func rewriteCalls(expr influxql.Expr, fn func(*influxql.Call) influxql.Expr) influxql.Expr {
+	switch expr := expr.(type) {
+	case *influxql.Call:
+		return fn(expr)
+	case *influxql.BinaryExpr:
+		return &influxql.BinaryExpr{Op: expr.Op, LHS: rewriteCalls(expr.LHS, fn), RHS: rewriteCalls(expr.RHS, fn)}
+	case *influxql.ParenExpr:
+		return &influxql.ParenExpr{Expr: rewriteCalls(expr.Expr, fn)}
+	}
+	return expr
+}
@@ -0,0 +1,336 @@
+package query_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/query"
+)
+
+// Ensure the sorted merge iterator merges ordered inputs by series and time.
+func TestSortedMergeIterator(t *testing.T) {
+	itr := query.NewSortedMergeIterator([]query.Iterator{
+		query.NewSliceIterator([]*query.Point{
+			{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 3},
+			{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 1},
+			{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 2},
+		}),
+		query.NewSliceIterator([]*query.Point{
+			{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 2},
+			{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 4},
+		}),
+		query.NewSliceIterator(nil),
+	}, false)
+
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 3},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 2},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 1},
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 4},
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 2},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the filter iterator only returns points matching its condition.
+func TestFilterIterator(t *testing.T) {
+	itr := query.NewFilterIterator(query.NewSliceIterator([]*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 1, Values: map[string]interface{}{"value": float64(10)}},
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 2, Values: map[string]interface{}{"value": float64(20)}},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 3, Values: map[string]interface{}{"value": float64(30)}},
+	}), MustParseExpr(`host = 'A' AND value > 15`))
+
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 3, Values: map[string]interface{}{"value": float64(30)}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the group iterator reduces tags to the dimensions and sets windows.
+func TestGroupIterator(t *testing.T) {
+	itr := query.NewGroupIterator(query.NewSliceIterator([]*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A", "region": "west"}, Time: 5},
+		{Name: "cpu", Tags: map[string]string{"host": "A", "region": "east"}, Time: 12},
+		{Name: "cpu", Tags: map[string]string{"region": "west"}, Time: 3},
+	}), []string{"host"}, 10, 0)
+
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 5, Window: 0},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 12, Window: 10},
+		{Name: "cpu", Tags: map[string]string{"host": ""}, Time: 3, Window: 0},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the group iterator aligns the windows of points before the epoch.
+func TestGroupIterator_NegativeTime(t *testing.T) {
+	itr := query.NewGroupIterator(query.NewSliceIterator([]*query.Point{
+		{Name: "cpu", Time: -15},
+		{Name: "cpu", Time: -10},
+		{Name: "cpu", Time: -1},
+	}), nil, 10, 0)
+
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{}, Time: -15, Window: -20},
+		{Name: "cpu", Tags: map[string]string{}, Time: -10, Window: -10},
+		{Name: "cpu", Tags: map[string]string{}, Time: -1, Window: -10},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the aggregate iterator computes a point for each window.
+func TestAggregateIterator(t *testing.T) {
+	stmt := MustParseSelectStatement(`SELECT count(value), mean(value) * 2 AS double, max(value) FROM cpu GROUP BY host`)
+	itr, err := query.NewAggregateIterator(query.NewSliceIterator([]*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 1, Window: 0, Values: map[string]interface{}{"value": float64(1)}},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 2, Window: 0, Values: map[string]interface{}{"value": float64(3)}},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 12, Window: 10, Values: map[string]interface{}{"value": float64(5)}},
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 3, Window: 0, Values: map[string]interface{}{"value": float64(7)}},
+	}), stmt.Fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 0, Window: 0, Values: map[string]interface{}{"count_value": float64(2), "double": float64(4), "max_value": float64(3)}},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 10, Window: 10, Values: map[string]interface{}{"count_value": float64(1), "double": float64(10), "max_value": float64(5)}},
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 0, Window: 0, Values: map[string]interface{}{"count_value": float64(1), "double": float64(14), "max_value": float64(7)}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the aggregate iterator returns each window without reading past
+// the first point of the next window.
+func TestAggregateIterator_Streaming(t *testing.T) {
+	stmt := MustParseSelectStatement(`SELECT count(value) FROM cpu`)
+	itr, err := query.NewAggregateIterator(&ErrIterator{
+		Iterator: query.NewSliceIterator([]*query.Point{
+			{Name: "cpu", Time: 1, Window: 0, Values: map[string]interface{}{"value": float64(1)}},
+			{Name: "cpu", Time: 2, Window: 0, Values: map[string]interface{}{"value": float64(2)}},
+			{Name: "cpu", Time: 12, Window: 10, Values: map[string]interface{}{"value": float64(3)}},
+		}),
+		Err: errors.New("marker"),
+	}, stmt.Fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p, err := itr.Next(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(p, &query.Point{Name: "cpu", Time: 0, Window: 0, Values: map[string]interface{}{"count_value": float64(2)}}) {
+		t.Fatalf("unexpected point: %s", spew.Sdump(p))
+	}
+	if _, err := itr.Next(); err == nil || err.Error() != "marker" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the aggregate iterator rejects functions it can't compute.
+func TestAggregateIterator_ErrUnsupportedFunction(t *testing.T) {
+	stmt := MustParseSelectStatement(`SELECT derivative(value) FROM cpu`)
+	if _, err := query.NewAggregateIterator(query.NewSliceIterator(nil), stmt.Fields); err == nil || err.Error() != `unsupported function: derivative()` {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// Ensure the project iterator computes fields and skips points without them.
+func TestProjectIterator(t *testing.T) {
+	stmt := MustParseSelectStatement(`SELECT value * 2, host FROM cpu`)
+	itr := query.NewProjectIterator(query.NewSliceIterator([]*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 1, Values: map[string]interface{}{"value": float64(10)}},
+		{Name: "cpu", Time: 2, Values: map[string]interface{}{"other": float64(20)}},
+	}), stmt.Fields)
+
+	if a := MustReadAll(itr); !reflect.DeepEqual(a, []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 1, Values: map[string]interface{}{"value": float64(20), "host": "A"}},
+	}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the sort iterator orders points by series and time.
+func TestSortIterator(t *testing.T) {
+	input := []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 1},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 2},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 1},
+	}
+
+	if a := MustReadAll(query.NewSortIterator(query.NewSliceIterator(input), true)); !reflect.DeepEqual(a, []*query.Point{input[2], input[1], input[0]}) {
+		t.Fatalf("unexpected ascending points: %s", spew.Sdump(a))
+	}
+	if a := MustReadAll(query.NewSortIterator(query.NewSliceIterator(input), false)); !reflect.DeepEqual(a, []*query.Point{input[1], input[2], input[0]}) {
+		t.Fatalf("unexpected descending points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the sort iterator spills sorted runs to disk once its memory limit is
+// exceeded and removes them when closed.
+func TestSortIterator_Spill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var input []*query.Point
+	for i := 0; i < 100; i++ {
+		input = append(input, &query.Point{
+			Name:   "cpu",
+			Tags:   map[string]string{"host": fmt.Sprintf("server%d", i%3)},
+			Time:   int64((i * 37) % 100),
+			Values: map[string]interface{}{"value": float64(i), "label": "x", "missing": nil},
+		})
+	}
+	exp := MustReadAll(query.NewSortIterator(query.NewSliceIterator(input), false))
+
+	itr := query.NewSortIterator(query.NewSliceIterator(input), false)
+	itr.MaxMemory = 2000
+	itr.TempDir = dir
+
+	var a []*query.Point
+	for {
+		p, err := itr.Next()
+		if err != nil {
+			t.Fatal(err)
+		} else if p == nil {
+			break
+		}
+		a = append(a, p)
+
+		// Runs remain on disk until the iterator is closed.
+		if len(a) == 1 {
+			if names := MustReadDirNames(dir); len(names) < 2 {
+				t.Fatalf("expected runs to be spilled: %v", names)
+			}
+		}
+	}
+	if !reflect.DeepEqual(a, exp) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+
+	if err := itr.Close(); err != nil {
+		t.Fatal(err)
+	} else if names := MustReadDirNames(dir); len(names) != 0 {
+		t.Fatalf("runs not removed: %v", names)
+	}
+}
+
+// Ensure the sort iterator stops when its query is canceled.
+func TestSortIterator_Canceled(t *testing.T) {
+	closing := make(chan struct{})
+	close(closing)
+
+	itr := query.NewSortIterator(query.NewSliceIterator([]*query.Point{{Name: "cpu", Time: 1}}), true)
+	itr.Closing = closing
+	defer itr.Close()
+
+	if _, err := itr.Next(); err != query.ErrQueryCanceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the group sort iterator merges the series of each group by time.
+func TestGroupSortIterator(t *testing.T) {
+	input := []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "B", "region": "west"}, Time: 15},
+		{Name: "cpu", Tags: map[string]string{"host": "A", "region": "east"}, Time: 12},
+		{Name: "cpu", Tags: map[string]string{"host": "A", "region": "west"}, Time: 5},
+		{Name: "cpu", Tags: map[string]string{"host": "B", "region": "west"}, Time: 3},
+	}
+
+	if a := MustReadAll(query.NewGroupSortIterator(query.NewSliceIterator(input), []string{"region"}, true)); !reflect.DeepEqual(a, []*query.Point{input[1], input[3], input[2], input[0]}) {
+		t.Fatalf("unexpected ascending points: %s", spew.Sdump(a))
+	}
+	if a := MustReadAll(query.NewGroupSortIterator(query.NewSliceIterator(input), nil, false)); !reflect.DeepEqual(a, []*query.Point{input[0], input[1], input[2], input[3]}) {
+		t.Fatalf("unexpected descending points: %s", spew.Sdump(a))
+	}
+}
+
+// Ensure the limit iterator applies the limit and offset to each series.
+func TestLimitIterator(t *testing.T) {
+	input := []*query.Point{
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 1},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 2},
+		{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 3},
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 1},
+		{Name: "cpu", Tags: map[string]string{"host": "B"}, Time: 2},
+	}
+
+	if a := MustReadAll(query.NewLimitIterator(query.NewSliceIterator(input), 1, 1)); !reflect.DeepEqual(a, []*query.Point{input[1], input[4]}) {
+		t.Fatalf("unexpected points: %s", spew.Sdump(a))
+	}
+}
+
+// ErrIterator returns an error once its iterator has no more points.
+type ErrIterator struct {
+	query.Iterator
+	Err error
+}
+
+func (itr *ErrIterator) Next() (*query.Point, error) {
+	if p, err := itr.Iterator.Next(); p != nil || err != nil {
+		return p, err
+	}
+	return nil, itr.Err
+}
+
+// MustReadDirNames returns the names of the files in a directory. Panic on error.
+func MustReadDirNames(path string) []string {
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	var names []string
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	return names
+}
+
+// MustReadAll reads all points from an iterator. Panic on error.
+func MustReadAll(itr query.Iterator) []*query.Point {
+	defer itr.Close()
+
+	var a []*query.Point
+	for {
+		p, err := itr.Next()
+		if err != nil {
+			panic(err.Error())
+		} else if p == nil {
+			return a
+		}
+		a = append(a, p)
+	}
+}
+
+// MustParseSelectStatement parses a select statement. Panic on error.
+func MustParseSelectStatement(s string) *influxql.SelectStatement {
+	stmt, err := influxql.NewParser(strings.NewReader(s)).ParseStatement()
+	if err != nil {
+		panic(err.Error())
+	}
+	return stmt.(*influxql.SelectStatement)
+}
+
+// MustParseExpr parses an expression. Panic on error.
+func MustParseExpr(s string) influxql.Expr {
+	expr, err := influxql.NewParser(strings.NewReader(s)).ParseExpr()
+	if err != nil {
+		panic(err.Error())
+	}
+	return expr
+}
@@ -0,0 +1,80 @@
+package query
+
+import (
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// PushdownCondition splits a condition into the predicates on tags, which a
+// source can answer by looking up series in its index, and the residual
+// predicates that must be evaluated for each point. Only predicates joined by
+// AND at the top of the condition can be separated. The time range must
+// already have been removed from the condition.
+func PushdownCondition(cond influxql.Expr, tagKeys []string) (tagCond, fieldCond influxql.Expr) {
+	if cond == nil {
+		return nil, nil
+	}
+
+	tags := make(map[string]struct{}, len(tagKeys))
+	for _, k := range tagKeys {
+		tags[k] = struct{}{}
+	}
+
+	for _, expr := range conjuncts(cond) {
+		if isTagPredicate(expr, tags) {
+			tagCond = and(tagCond, expr)
+		} else {
+			fieldCond = and(fieldCond, expr)
+		}
+	}
+	return tagCond, fieldCond
+}
+
+// conjuncts returns the expressions joined by AND at the top of expr.
+func conjuncts(expr influxql.Expr) []influxql.Expr {
+	switch expr := expr.(type) {
+	case *influxql.ParenExpr:
+		if e, ok := expr.Expr.(*influxql.BinaryExpr); ok && e.Op == influxql.AND {
+			return conjuncts(e)
+		}
+	case *influxql.BinaryExpr:
+		if expr.Op == influxql.AND {
+			return append(conjuncts(expr.LHS), conjuncts(expr.RHS)...)
+		}
+	}
+	return []influxql.Expr{expr}
+}
+
+// isTagPredicate returns true if expr only compares tags to strings or
+// regular expressions.
+func isTagPredicate(expr influxql.Expr, tags map[string]struct{}) bool {
+	switch expr := expr.(type) {
+	case *influxql.ParenExpr:
+		return isTagPredicate(expr.Expr, tags)
+	case *influxql.BinaryExpr:
+		switch expr.Op {
+		case influxql.AND, influxql.OR:
+			return isTagPredicate(expr.LHS, tags) && isTagPredicate(expr.RHS, tags)
+		case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
+			ref, ok := expr.LHS.(*influxql.VarRef)
+			if !ok {
+				return false
+			} else if _, ok := tags[ref.Val]; !ok {
+				return false
+			}
+
+			switch expr.RHS.(type) {
+			case *influxql.StringLiteral, *influxql.RegexLiteral:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// and joins two expressions with AND. Either may be nil.
+func and(lhs, rhs influxql.Expr) influxql.Expr {
+	if lhs == nil {
+		return rhs
+	}
+	return &influxql.BinaryExpr{Op: influxql.AND, LHS: lhs, RHS: rhs}
+}
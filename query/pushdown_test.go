@@ -0,0 +1,68 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/query"
+)
+
+// Ensure predicates on tags are separated from predicates on fields.
+func TestPushdownCondition(t *testing.T) {
+	var tests = []struct {
+		cond      string
+		tagCond   string
+		fieldCond string
+	}{
+		{cond: `host = 'A'`, tagCond: `host = 'A'`},
+		{cond: `value > 1`, fieldCond: `value > 1`},
+		{cond: `host = 'A' AND value > 1`, tagCond: `host = 'A'`, fieldCond: `value > 1`},
+		{cond: `(host =~ /^a/ AND region != 'west') AND value > 1 AND other = 'x'`, tagCond: `host =~ /^a/ AND region != 'west'`, fieldCond: `value > 1 AND other = 'x'`},
+		{cond: `(host = 'A' OR region = 'east') AND value > 1`, tagCond: `(host = 'A' OR region = 'east')`, fieldCond: `value > 1`},
+		{cond: `host = 'A' OR value > 1`, fieldCond: `host = 'A' OR value > 1`},
+		{cond: `host = region`, fieldCond: `host = region`},
+	}
+
+	for i, tt := range tests {
+		tagCond, fieldCond := query.PushdownCondition(MustParseExpr(tt.cond), []string{"host", "region"})
+		if s := exprString(tagCond); s != tt.tagCond {
+			t.Errorf("%d. %s: unexpected tag condition: exp=%s got=%s", i, tt.cond, tt.tagCond, s)
+		}
+		if s := exprString(fieldCond); s != tt.fieldCond {
+			t.Errorf("%d. %s: unexpected field condition: exp=%s got=%s", i, tt.cond, tt.fieldCond, s)
+		}
+	}
+}
+
+// Ensure predicates on tags are pushed down into the source when compiling.
+func TestCompile_Pushdown(t *testing.T) {
+	var src Source
+	src.TagKeysFn = func(database, retentionPolicy, measurement string) ([]string, error) {
+		return []string{"host"}, nil
+	}
+	src.CreateIteratorFn = func(opt query.IteratorOptions) (query.Iterator, error) {
+		if s := exprString(opt.Condition); s != `host = 'A'` {
+			t.Fatalf("unexpected condition: %s", s)
+		}
+		return query.NewSliceIterator([]*query.Point{
+			{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 10, Values: map[string]interface{}{"value": float64(1)}},
+			{Name: "cpu", Tags: map[string]string{"host": "A"}, Time: 20, Values: map[string]interface{}{"value": float64(2)}},
+		}), nil
+	}
+
+	itr, err := query.Compile(MustParseSelectStatement(`SELECT value FROM cpu WHERE host = 'A' AND value > 1`), &src, query.CompileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := MustReadAll(itr); len(a) != 1 || a[0].Time != 20 {
+		t.Fatalf("unexpected points: %v", a)
+	}
+}
+
+// exprString returns the string representation of expr or a blank string if it's nil.
+func exprString(expr influxql.Expr) string {
+	if expr == nil {
+		return ""
+	}
+	return expr.String()
+}
@@ -0,0 +1,87 @@
+package query
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// sortRun returns the points of a sorted run written to a temporary file
+// by a SortIterator. The file is removed when the run is closed.
+type sortRun struct {
+	f   *os.File
+	dec *gob.Decoder
+}
+
+// createSortRun writes points to a new temporary file in dir and returns a
+// run that reads them back in the same order.
+func createSortRun(dir string, points []*Point) (*sortRun, error) {
+	f, err := ioutil.TempFile(dir, "influxdb-sort-")
+	if err != nil {
+		return nil, err
+	}
+	r := &sortRun{f: f}
+
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	for _, p := range points {
+		if err := enc.Encode(p); err != nil {
+			r.Close()
+			return nil, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	if _, err := f.Seek(0, os.SEEK_SET); err != nil {
+		r.Close()
+		return nil, err
+	}
+	r.dec = gob.NewDecoder(bufio.NewReader(f))
+	return r, nil
+}
+
+// Next returns the next point in the run.
+func (r *sortRun) Next() (*Point, error) {
+	var p Point
+	if err := r.dec.Decode(&p); err == io.EOF {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Close closes and removes the file.
+func (r *sortRun) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	if e := os.Remove(r.f.Name()); e != nil && err == nil {
+		err = e
+	}
+	r.f = nil
+	return err
+}
+
+// size returns the estimated number of bytes of memory used by the point.
+func (p *Point) size() int {
+	const overhead = 16
+
+	n := 64 + len(p.Name)
+	for k, v := range p.Tags {
+		n += len(k) + len(v) + 2*overhead
+	}
+	for k, v := range p.Values {
+		n += len(k) + 2*overhead
+		if s, ok := v.(string); ok {
+			n += len(s)
+		}
+	}
+	return n
+}
@@ -255,7 +255,11 @@ func Unmarshal(packet *gollectd.Packet) []tsdb.Point {
 		if packet.TypeInstance != "" {
 			tags["type_instance"] = packet.TypeInstance
 		}
-		p := tsdb.NewPoint(name, tags, fields, timestamp)
+		// Values that can't be stored, such as NaN gauges, are dropped.
+		p, err := tsdb.NewPoint(name, tags, fields, timestamp)
+		if err != nil {
+			continue
+		}
 
 		points = append(points, p)
 	}
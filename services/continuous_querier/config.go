@@ -14,6 +14,8 @@ const (
 	DefaultComputeRunsPerInterval = 10
 
 	DefaultComputeNoMoreThan = 2 * time.Minute
+
+	DefaultBackfillIntervalsPerChunk = 100
 )
 
 // Config represents a configuration for the continuous query service.
@@ -47,6 +49,10 @@ type Config struct {
 	// If you have a group by time(5m) then you'll get five computes per interval. Any group by time window larger
 	// than 10m will get computed 10 times for each interval.
 	ComputeNoMoreThan toml.Duration `toml:"compute-no-more-than"`
+
+	// BackfillIntervalsPerChunk sets how many group by intervals are computed by each query
+	// when backfilling historical data for a continuous query created with BACKFILL.
+	BackfillIntervalsPerChunk int `toml:"backfill-intervals-per-chunk"`
 }
 
 // NewConfig returns a new instance of Config with defaults.
@@ -57,5 +63,7 @@ func NewConfig() Config {
 		RecomputeNoOlderThan:   toml.Duration(DefaultRecomputeNoOlderThan),
 		ComputeRunsPerInterval: DefaultComputeRunsPerInterval,
 		ComputeNoMoreThan:      toml.Duration(DefaultComputeNoMoreThan),
+
+		BackfillIntervalsPerChunk: DefaultBackfillIntervalsPerChunk,
 	}
 }
@@ -16,6 +16,7 @@ recompute-previous-n = 1
 recompute-no-older-than = "10s"
 compute-runs-per-interval = 2
 compute-no-more-than = "20s"
+backfill-intervals-per-chunk = 50
 enabled = true
 `, &c); err != nil {
 		t.Fatal(err)
@@ -30,6 +31,8 @@ enabled = true
 		t.Fatalf("unexpected compute runs per interval: %d", c.ComputeRunsPerInterval)
 	} else if time.Duration(c.ComputeNoMoreThan) != 20*time.Second {
 		t.Fatalf("unexpected compute no more than: %v", c.ComputeNoMoreThan)
+	} else if c.BackfillIntervalsPerChunk != 50 {
+		t.Fatalf("unexpected backfill intervals per chunk: %d", c.BackfillIntervalsPerChunk)
 	} else if c.Enabled != true {
 		t.Fatalf("unexpected enabled: %v", c.Enabled)
 	}
@@ -20,15 +20,22 @@ const (
 	NoChunkingSize = 0
 )
 
+// errServiceClosed is returned by a backfill stopped by closing the service.
+var errServiceClosed = errors.New("service closed")
+
 // ContinuousQuerier represents a service that executes continuous queries.
 type ContinuousQuerier interface {
 	// Run executes the named query in the named database.  Blank database or name matches all.
 	Run(database, name string) error
+
+	// Backfill executes the named query in the named database over the time
+	// range [start, end). A zero end time means the current time.
+	Backfill(database, name string, start, end time.Time) error
 }
 
 // queryExecutor is an internal interface to make testing easier.
 type queryExecutor interface {
-	ExecuteQuery(query *influxql.Query, database string, chunkSize int) (<-chan *influxql.Result, error)
+	ExecuteQuery(query *influxql.Query, database string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error)
 }
 
 // metaStore is an internal interface to make testing easier.
@@ -36,6 +43,7 @@ type metaStore interface {
 	IsLeader() bool
 	Databases() ([]meta.DatabaseInfo, error)
 	Database(name string) (*meta.DatabaseInfo, error)
+	SetContinuousQueryBackfilled(database, name string) error
 }
 
 // pointsWriter is an internal interface to make testing easier.
@@ -57,6 +65,11 @@ type Service struct {
 	lastRuns map[string]time.Time
 	stop     chan struct{}
 	wg       *sync.WaitGroup
+
+	// backfills tracks the BACKFILL CQs computing their history in the background.
+	mu         sync.Mutex
+	backfills  map[backfillKey]bool
+	backfillWG sync.WaitGroup
 }
 
 // NewService returns a new instance of Service.
@@ -67,6 +80,7 @@ func NewService(c Config) *Service {
 		RunCh:       make(chan struct{}),
 		Logger:      log.New(os.Stderr, "[continuous_querier] ", log.LstdFlags),
 		lastRuns:    map[string]time.Time{},
+		backfills:   map[backfillKey]bool{},
 	}
 	return s
 }
@@ -95,6 +109,7 @@ func (s *Service) Close() error {
 	}
 	close(s.stop)
 	s.wg.Wait()
+	s.backfillWG.Wait()
 	s.wg = nil
 	s.stop = nil
 	return nil
@@ -144,6 +159,56 @@ func (s *Service) Run(database, name string) error {
 	return nil
 }
 
+// Backfill runs the specified continuous query over the historical time range
+// [start, end) in chunks of BackfillIntervalsPerChunk group by intervals and
+// writes the results to its target. A zero end time means the current time.
+// It's used to recompute results after a query is changed or archived data
+// is restored, and returns once the whole range has been computed.
+func (s *Service) Backfill(database, name string, start, end time.Time) error {
+	dbi, err := s.MetaStore.Database(database)
+	if err != nil {
+		return err
+	} else if dbi == nil {
+		return tsdb.ErrDatabaseNotFound(database)
+	}
+
+	// Find the requested CQ.
+	var cqi *meta.ContinuousQueryInfo
+	for i := range dbi.ContinuousQueries {
+		if dbi.ContinuousQueries[i].Name == name {
+			cqi = &dbi.ContinuousQueries[i]
+			break
+		}
+	}
+	if cqi == nil {
+		return meta.ErrContinuousQueryNotFound
+	}
+
+	if end.IsZero() {
+		end = time.Now()
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("invalid backfill time range: start time %s is not before end time %s",
+			start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	}
+
+	cq, err := s.newContinuousQuery(dbi, cqi)
+	if err != nil {
+		return err
+	}
+
+	interval, err := cq.q.GroupByInterval()
+	if err != nil {
+		return err
+	} else if interval == 0 {
+		return fmt.Errorf("continuous query has no GROUP BY time interval: %s", name)
+	}
+
+	s.Logger.Printf("backfilling continuous query %s on %s from %s to %s", name, database,
+		start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	return s.backfillContinuousQuery(cq, start, end, interval, nil)
+}
+
 // backgroundLoop runs on a go routine and periodically executes CQs.
 func (s *Service) backgroundLoop() {
 	defer s.wg.Done()
@@ -189,7 +254,7 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 	//s.stats.Inc("continuousQueryExecuted")
 
 	// Local wrapper / helper.
-	cq, err := NewContinuousQuery(dbi.Name, cqi)
+	cq, err := s.newContinuousQuery(dbi, cqi)
 	if err != nil {
 		return err
 	}
@@ -197,11 +262,6 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 	// Get the last time this CQ was run from the service's cache.
 	cq.LastRun = s.lastRuns[cqi.Name]
 
-	// Set the retention policy to default if it wasn't specified in the query.
-	if cq.intoRP() == "" {
-		cq.setIntoRP(dbi.DefaultRetentionPolicy)
-	}
-
 	// See if this query needs to be run.
 	computeNoMoreThan := time.Duration(s.Config.ComputeNoMoreThan)
 	run, err := cq.shouldRunContinuousQuery(s.Config.ComputeRunsPerInterval, computeNoMoreThan)
@@ -224,13 +284,25 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 		return nil
 	}
 
+	// Find where a pending backfill starts before the time range is replaced.
+	var backfillFrom time.Time
+	if cq.backfill && !cqi.Backfilled && !s.backfilling(dbi.Name, cqi.Name) {
+		backfillFrom, _ = influxql.TimeRange(influxql.Reduce(cq.q.Condition, &influxql.NowValuer{Now: now}))
+	}
+
 	// Calculate and set the time range for the query.
 	startTime := now.Round(interval)
 	if startTime.UnixNano() > now.UnixNano() {
 		startTime = startTime.Add(-interval)
 	}
 
-	if err := cq.q.SetTimeRange(startTime, startTime.Add(interval)); err != nil {
+	// A RESAMPLE FOR window recomputes every interval it covers in a single run.
+	endTime := startTime.Add(interval)
+	if cq.resampleFor > 0 {
+		startTime = endTime.Add(-cq.resampleFor)
+	}
+
+	if err := cq.q.SetTimeRange(startTime, endTime); err != nil {
 		s.Logger.Printf("error setting time range: %s\n", err)
 	}
 
@@ -240,6 +312,18 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 		return err
 	}
 
+	// Compute everything before the current window the first time a BACKFILL query runs.
+	if !backfillFrom.IsZero() {
+		if err := s.startBackfill(dbi, cqi, backfillFrom, startTime, interval); err != nil {
+			return err
+		}
+	}
+
+	// The RESAMPLE FOR window replaces the configured recompute behavior.
+	if cq.resampleFor > 0 {
+		return nil
+	}
+
 	recomputeNoOlderThan := time.Duration(s.Config.RecomputeNoOlderThan)
 
 	for i := 0; i < s.Config.RecomputePreviousN; i++ {
@@ -264,6 +348,118 @@ func (s *Service) ExecuteContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.Conti
 	return nil
 }
 
+// newContinuousQuery returns the wrapper of a CQ with the database and
+// retention policy of its target resolved.
+func (s *Service) newContinuousQuery(dbi *meta.DatabaseInfo, cqi *meta.ContinuousQueryInfo) (*ContinuousQuery, error) {
+	cq, err := NewContinuousQuery(dbi.Name, cqi)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write into the CQ's database if the target doesn't specify one.
+	if cq.intoDB() == "" {
+		cq.setIntoDB(dbi.Name)
+	}
+
+	// Set the retention policy to the target database's default if it wasn't specified in the query.
+	if cq.intoRP() == "" {
+		intoDBI := dbi
+		if cq.intoDB() != dbi.Name {
+			if intoDBI, err = s.MetaStore.Database(cq.intoDB()); err != nil {
+				return nil, err
+			} else if intoDBI == nil {
+				return nil, fmt.Errorf("target database not found: %s", cq.intoDB())
+			}
+		}
+		cq.setIntoRP(intoDBI.DefaultRetentionPolicy)
+	}
+	return cq, nil
+}
+
+// backfillKey identifies a CQ by its database as CQ names are only unique
+// within a database.
+type backfillKey struct {
+	database string
+	name     string
+}
+
+// backfilling returns true if the history of the named CQ is being computed.
+func (s *Service) backfilling(database, name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.backfills[backfillKey{database, name}]
+}
+
+// startBackfill computes the history of a BACKFILL CQ over [from, to) in the
+// background so it doesn't hold up the other CQs. Once it's done the CQ is
+// marked as backfilled in the meta store so the history isn't computed again
+// after a restart or on another node.
+func (s *Service) startBackfill(dbi *meta.DatabaseInfo, cqi *meta.ContinuousQueryInfo, from, to time.Time, interval time.Duration) error {
+	// The backfill has its own copy of the query as the time range is changed for each chunk.
+	cq, err := s.newContinuousQuery(dbi, cqi)
+	if err != nil {
+		return err
+	}
+
+	key := backfillKey{dbi.Name, cqi.Name}
+	s.mu.Lock()
+	s.backfills[key] = true
+	s.mu.Unlock()
+
+	stop := s.stop
+	s.backfillWG.Add(1)
+	go func() {
+		defer s.backfillWG.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.backfills, key)
+			s.mu.Unlock()
+		}()
+
+		if err := s.backfillContinuousQuery(cq, from, to, interval, stop); err != nil {
+			s.Logger.Printf("error backfilling continuous query %s: %s", cqi.Name, err)
+			return
+		}
+		if err := s.MetaStore.SetContinuousQueryBackfilled(dbi.Name, cqi.Name); err != nil {
+			s.Logger.Printf("error marking continuous query %s as backfilled: %s", cqi.Name, err)
+		}
+	}()
+	return nil
+}
+
+// backfillContinuousQuery runs the CQ over the historical range [from, to) in chunks
+// of BackfillIntervalsPerChunk group by intervals. It stops early if stop is closed.
+func (s *Service) backfillContinuousQuery(cq *ContinuousQuery, from, to time.Time, interval time.Duration, stop <-chan struct{}) error {
+	chunk := interval * time.Duration(s.Config.BackfillIntervalsPerChunk)
+	if chunk < interval {
+		chunk = interval
+	}
+
+	for start := from.Truncate(interval); start.Before(to); start = start.Add(chunk) {
+		select {
+		case <-stop:
+			return errServiceClosed
+		default:
+		}
+
+		end := start.Add(chunk)
+		if end.After(to) {
+			end = to
+		}
+
+		if err := cq.q.SetTimeRange(start, end); err != nil {
+			s.Logger.Printf("error setting time range: %s\n", err)
+			return err
+		}
+
+		if err := s.runContinuousQueryAndWriteResult(cq); err != nil {
+			s.Logger.Printf("error during backfill: %s. running: %s\n", err, cq.q.String())
+			return err
+		}
+	}
+	return nil
+}
+
 // runContinuousQueryAndWriteResult will run the query against the cluster and write the results back in
 func (s *Service) runContinuousQueryAndWriteResult(cq *ContinuousQuery) error {
 	// Wrap the CQ's inner SELECT statement in a Query for the QueryExecutor.
@@ -271,8 +467,11 @@ func (s *Service) runContinuousQueryAndWriteResult(cq *ContinuousQuery) error {
 		Statements: influxql.Statements{cq.q},
 	}
 
-	// Execute the SELECT.
-	ch, err := s.QueryExecutor.ExecuteQuery(q, cq.Database, NoChunkingSize)
+	// Execute the SELECT. The query is canceled if an error stops the
+	// results from being read.
+	closing := make(chan struct{})
+	defer close(closing)
+	ch, err := s.QueryExecutor.ExecuteQuery(q, cq.Database, NoChunkingSize, 0, closing)
 	if err != nil {
 		return err
 	}
@@ -284,8 +483,14 @@ func (s *Service) runContinuousQueryAndWriteResult(cq *ContinuousQuery) error {
 		}
 
 		for _, row := range result.Series {
+			// Write into the source measurement if the target is a backreference.
+			measurement := cq.intoMeasurement()
+			if cq.q.Target.Backref {
+				measurement = row.Name
+			}
+
 			// Convert the result row to points.
-			points, err := s.convertRowToPoints(cq.intoMeasurement(), row)
+			points, err := s.convertRowToPoints(measurement, row)
 			if err != nil {
 				log.Println(err)
 				continue
@@ -350,7 +555,10 @@ func (s *Service) convertRowToPoints(measurementName string, row *influxql.Row)
 			vals[fieldName] = v[fieldIndex]
 		}
 
-		p := tsdb.NewPoint(measurementName, row.Tags, vals, v[timeIndex].(time.Time))
+		p, err := tsdb.NewPoint(measurementName, row.Tags, vals, v[timeIndex].(time.Time))
+		if err != nil {
+			return nil, err
+		}
 
 		points = append(points, p)
 	}
@@ -364,9 +572,18 @@ type ContinuousQuery struct {
 	Info     *meta.ContinuousQueryInfo
 	LastRun  time.Time
 	q        *influxql.SelectStatement
+
+	// resampleEvery and resampleFor override how often the query runs and
+	// how much time each run covers. Zero means use the GROUP BY interval.
+	resampleEvery time.Duration
+	resampleFor   time.Duration
+
+	// backfill is set if the query should also be run over historical data.
+	backfill bool
 }
 
 func (cq *ContinuousQuery) intoDB() string          { return cq.q.Target.Measurement.Database }
+func (cq *ContinuousQuery) setIntoDB(db string)     { cq.q.Target.Measurement.Database = db }
 func (cq *ContinuousQuery) intoRP() string          { return cq.q.Target.Measurement.RetentionPolicy }
 func (cq *ContinuousQuery) setIntoRP(rp string)     { cq.q.Target.Measurement.RetentionPolicy = rp }
 func (cq *ContinuousQuery) intoMeasurement() string { return cq.q.Target.Measurement.Name }
@@ -387,6 +604,10 @@ func NewContinuousQuery(database string, cqi *meta.ContinuousQueryInfo) (*Contin
 		Database: database,
 		Info:     cqi,
 		q:        q.Source,
+
+		resampleEvery: q.ResampleEvery,
+		resampleFor:   q.ResampleFor,
+		backfill:      q.Backfill,
 	}
 
 	return cquery, nil
@@ -414,6 +635,10 @@ func (cq *ContinuousQuery) shouldRunContinuousQuery(runsPerInterval int, noMoreT
 	if computeEvery < noMoreThan {
 		computeEvery = noMoreThan
 	}
+	// an explicit RESAMPLE EVERY takes precedence over the config
+	if cq.resampleEvery > 0 {
+		computeEvery = cq.resampleEvery
+	}
 
 	// if we've passed the amount of time since the last run, do it up
 	if cq.LastRun.Add(computeEvery).UnixNano() <= time.Now().UnixNano() {
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -61,6 +63,262 @@ func TestExecuteContinuousQuery_HappyPath(t *testing.T) {
 	}
 }
 
+// Test ExecuteContinuousQuery writes to the database and retention policy in the INTO target.
+func TestExecuteContinuousQuery_IntoTarget(t *testing.T) {
+	s := NewTestService(t)
+	ms := s.MetaStore.(*MetaStore)
+	ms.CreateContinuousQuery("db", "cq_target", `CREATE CONTINUOUS QUERY cq_target ON db BEGIN SELECT count(cpu) INTO db2.."cpu_count" FROM cpu WHERE time > now() - 1h GROUP BY time(1s) END`)
+	dbi, _ := ms.Database("db")
+	cqi := dbi.ContinuousQueries[len(dbi.ContinuousQueries)-1]
+
+	qe := s.QueryExecutor.(*QueryExecutor)
+	qe.Results = []*influxql.Result{genResult(1, 10)}
+
+	pw := s.PointsWriter.(*PointsWriter)
+	pw.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		if p.Database != "db2" {
+			return fmt.Errorf("database: exp = db2, got = %s", p.Database)
+		} else if p.RetentionPolicy != "default" {
+			return fmt.Errorf("retention policy: exp = default, got = %s", p.RetentionPolicy)
+		}
+		return nil
+	}
+
+	if err := s.ExecuteContinuousQuery(dbi, &cqi); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test ExecuteContinuousQuery writes into the source measurement for a :MEASUREMENT target.
+func TestExecuteContinuousQuery_Backref(t *testing.T) {
+	s := NewTestService(t)
+	ms := s.MetaStore.(*MetaStore)
+	ms.CreateContinuousQuery("db", "cq_backref", `CREATE CONTINUOUS QUERY cq_backref ON db BEGIN SELECT count(cpu) INTO "default".:MEASUREMENT FROM /.*/ WHERE time > now() - 1h GROUP BY time(1s) END`)
+	dbi, _ := ms.Database("db")
+	cqi := dbi.ContinuousQueries[len(dbi.ContinuousQueries)-1]
+
+	qe := s.QueryExecutor.(*QueryExecutor)
+	qe.Results = []*influxql.Result{genResult(1, 10)}
+
+	pw := s.PointsWriter.(*PointsWriter)
+	pw.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		for _, pt := range p.Points {
+			if pt.Name() != "cpu" {
+				return fmt.Errorf("measurement: exp = cpu, got = %s", pt.Name())
+			}
+		}
+		return nil
+	}
+
+	if err := s.ExecuteContinuousQuery(dbi, &cqi); err != nil {
+		t.Error(err)
+	}
+}
+
+// Test ExecuteContinuousQuery covers the RESAMPLE FOR window in a single run.
+func TestExecuteContinuousQuery_ResampleFor(t *testing.T) {
+	s := NewTestService(t)
+	ms := s.MetaStore.(*MetaStore)
+	ms.CreateContinuousQuery("db", "cq_resample", `CREATE CONTINUOUS QUERY cq_resample ON db RESAMPLE FOR 1h BEGIN SELECT count(cpu) INTO cpu_count FROM cpu GROUP BY time(1m) END`)
+	dbi, _ := ms.Database("db")
+	cqi := dbi.ContinuousQueries[len(dbi.ContinuousQueries)-1]
+
+	callCnt := 0
+	qe := s.QueryExecutor.(*QueryExecutor)
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		callCnt++
+		stmt := query.Statements[0].(*influxql.SelectStatement)
+		min, max := influxql.TimeRange(stmt.Condition)
+		if d := max.Sub(min); d < time.Hour-time.Microsecond || d > time.Hour {
+			return nil, fmt.Errorf("time range: exp = 1h, got = %s", d)
+		}
+		return nil, nil
+	}
+
+	if err := s.ExecuteContinuousQuery(dbi, &cqi); err != nil {
+		t.Fatal(err)
+	} else if callCnt != 1 {
+		t.Fatalf("query executions: exp = 1, got = %d", callCnt)
+	}
+}
+
+// Test ExecuteContinuousQuery computes historical data in chunks for a BACKFILL query.
+func TestExecuteContinuousQuery_Backfill(t *testing.T) {
+	s := NewTestService(t)
+	s.Config.RecomputePreviousN = 0
+	s.Config.BackfillIntervalsPerChunk = 5
+	ms := s.MetaStore.(*MetaStore)
+	ms.CreateContinuousQuery("db", "cq_backfill", `CREATE CONTINUOUS QUERY cq_backfill ON db BACKFILL BEGIN SELECT count(cpu) INTO cpu_count FROM cpu WHERE time >= now() - 10m GROUP BY time(1m) END`)
+	dbi, _ := ms.Database("db")
+	cqi := dbi.ContinuousQueries[len(dbi.ContinuousQueries)-1]
+
+	var mu sync.Mutex
+	var ranges []time.Duration
+	qe := s.QueryExecutor.(*QueryExecutor)
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		min, max := influxql.TimeRange(query.Statements[0].(*influxql.SelectStatement).Condition)
+		mu.Lock()
+		ranges = append(ranges, max.Add(time.Microsecond).Sub(min))
+		mu.Unlock()
+		return nil, nil
+	}
+
+	if err := s.ExecuteContinuousQuery(dbi, &cqi); err != nil {
+		t.Fatal(err)
+	}
+	s.backfillWG.Wait()
+
+	// Expect the current window followed by two 5 minute backfill chunks.
+	exp := []time.Duration{time.Minute, 5 * time.Minute, 5 * time.Minute}
+	if !reflect.DeepEqual(ranges, exp) {
+		t.Fatalf("time ranges: exp = %v, got = %v", exp, ranges)
+	}
+
+	// The completed backfill should be recorded in the meta store.
+	dbi, _ = ms.Database("db")
+	cqi = dbi.ContinuousQueries[len(dbi.ContinuousQueries)-1]
+	if !cqi.Backfilled {
+		t.Fatal("expected continuous query to be marked as backfilled")
+	}
+
+	// The backfill should only happen once, even after a restart.
+	ranges = nil
+	s = NewTestService(t)
+	s.Config.RecomputePreviousN = 0
+	s.MetaStore = ms
+	s.QueryExecutor = qe
+	if err := s.ExecuteContinuousQuery(dbi, &cqi); err != nil {
+		t.Fatal(err)
+	}
+	s.backfillWG.Wait()
+	if len(ranges) != 1 {
+		t.Fatalf("unexpected query executions: %v", ranges)
+	}
+}
+
+// Test BACKFILL CQs with the same name in different databases are backfilled
+// independently.
+func TestExecuteContinuousQuery_Backfill_SameName(t *testing.T) {
+	s := NewTestService(t)
+	s.Config.RecomputePreviousN = 0
+	s.Config.BackfillIntervalsPerChunk = 5
+	ms := s.MetaStore.(*MetaStore)
+	for _, db := range []string{"db", "db2"} {
+		ms.CreateContinuousQuery(db, "cq_backfill", `CREATE CONTINUOUS QUERY cq_backfill ON `+db+` BACKFILL BEGIN SELECT count(cpu) INTO cpu_count FROM cpu WHERE time >= now() - 10m GROUP BY time(1m) END`)
+	}
+
+	// The backfill of db is held up until the backfill of db2 is started.
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var n int
+	qe := s.QueryExecutor.(*QueryExecutor)
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		min, max := influxql.TimeRange(query.Statements[0].(*influxql.SelectStatement).Condition)
+		if max.Add(time.Microsecond).Sub(min) == time.Minute {
+			return nil, nil
+		}
+		if database == "db" {
+			<-release
+		} else {
+			mu.Lock()
+			n++
+			mu.Unlock()
+		}
+		return nil, nil
+	}
+
+	for _, db := range []string{"db", "db2"} {
+		dbi, _ := ms.Database(db)
+		cqi := dbi.ContinuousQueries[len(dbi.ContinuousQueries)-1]
+		delete(s.lastRuns, cqi.Name)
+		if err := s.ExecuteContinuousQuery(dbi, &cqi); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(release)
+	s.backfillWG.Wait()
+
+	if n != 2 {
+		t.Fatalf("db2 backfill chunks: exp = 2, got = %d", n)
+	}
+	for _, db := range []string{"db", "db2"} {
+		dbi, _ := ms.Database(db)
+		if cqi := dbi.ContinuousQueries[len(dbi.ContinuousQueries)-1]; !cqi.Backfilled {
+			t.Fatalf("expected continuous query on %s to be marked as backfilled", db)
+		}
+	}
+}
+
+// Test Backfill computes a historical time range in chunks on demand.
+func TestService_Backfill(t *testing.T) {
+	s := NewTestService(t)
+	s.Config.BackfillIntervalsPerChunk = 5
+	ms := s.MetaStore.(*MetaStore)
+	ms.CreateContinuousQuery("db", "cq_minute", `CREATE CONTINUOUS QUERY cq_minute ON db BEGIN SELECT count(cpu) INTO cpu_count FROM cpu GROUP BY time(1m) END`)
+
+	type timeRange struct{ min, max time.Time }
+	var ranges []timeRange
+	qe := s.QueryExecutor.(*QueryExecutor)
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		min, max := influxql.TimeRange(query.Statements[0].(*influxql.SelectStatement).Condition)
+		ranges = append(ranges, timeRange{min, max.Add(time.Microsecond)})
+		return nil, nil
+	}
+
+	start := time.Date(2000, 1, 1, 0, 0, 30, 0, time.UTC)
+	end := time.Date(2000, 1, 1, 0, 12, 0, 0, time.UTC)
+	if err := s.Backfill("db", "cq_minute", start, end); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expect the start to be truncated to the interval and the last chunk to stop at the end.
+	exp := []timeRange{
+		{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2000, 1, 1, 0, 5, 0, 0, time.UTC)},
+		{time.Date(2000, 1, 1, 0, 5, 0, 0, time.UTC), time.Date(2000, 1, 1, 0, 10, 0, 0, time.UTC)},
+		{time.Date(2000, 1, 1, 0, 10, 0, 0, time.UTC), end},
+	}
+	if !reflect.DeepEqual(ranges, exp) {
+		t.Fatalf("time ranges: exp = %v, got = %v", exp, ranges)
+	}
+
+	// A backfill doesn't count as a regular run of the CQ.
+	if _, ok := s.lastRuns["cq_minute"]; ok {
+		t.Fatal("expected backfill to leave the last run time unset")
+	}
+
+	if err := s.Backfill("db", "no_such_cq", start, end); err != meta.ErrContinuousQueryNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := s.Backfill("db", "cq_minute", end, start); err == nil || !strings.Contains(err.Error(), "invalid backfill time range") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Test RESAMPLE EVERY overrides how often a CQ is run.
+func TestContinuousQuery_ResampleEvery(t *testing.T) {
+	cq, err := NewContinuousQuery("db", &meta.ContinuousQueryInfo{
+		Name:  "cq",
+		Query: `CREATE CONTINUOUS QUERY cq ON db RESAMPLE EVERY 1h BEGIN SELECT count(cpu) INTO cpu_count FROM cpu GROUP BY time(1m) END`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cq.LastRun = time.Now().Add(-30 * time.Minute)
+	if run, err := cq.shouldRunContinuousQuery(10, time.Second); err != nil {
+		t.Fatal(err)
+	} else if run {
+		t.Fatal("expected query not to run before the RESAMPLE EVERY interval")
+	}
+
+	cq.LastRun = time.Now().Add(-time.Hour)
+	if run, err := cq.shouldRunContinuousQuery(10, time.Second); err != nil {
+		t.Fatal(err)
+	} else if !run {
+		t.Fatal("expected query to run after the RESAMPLE EVERY interval")
+	}
+}
+
 // Test the service happy path.
 func TestService_HappyPath(t *testing.T) {
 	s := NewTestService(t)
@@ -102,7 +360,7 @@ func TestService_Run(t *testing.T) {
 
 	// Set a callback for ExecuteQuery.
 	qe := s.QueryExecutor.(*QueryExecutor)
-	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int) (<-chan *influxql.Result, error) {
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		callCnt++
 		if callCnt >= expectCallCnt {
 			done <- struct{}{}
@@ -149,7 +407,7 @@ func TestService_NotLeader(t *testing.T) {
 	done := make(chan struct{})
 	qe := s.QueryExecutor.(*QueryExecutor)
 	// Set a callback for ExecuteQuery. Shouldn't get called because we're not the leader.
-	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int) (<-chan *influxql.Result, error) {
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		done <- struct{}{}
 		return nil, unexpectedErr
 	}
@@ -174,7 +432,7 @@ func TestService_MetaStoreFailsToGetDatabases(t *testing.T) {
 	done := make(chan struct{})
 	qe := s.QueryExecutor.(*QueryExecutor)
 	// Set ExecuteQuery callback, which shouldn't get called because of meta store failure.
-	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int) (<-chan *influxql.Result, error) {
+	qe.ExecuteQueryFn = func(query *influxql.Query, database string, chunkSize int, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		done <- struct{}{}
 		return nil, unexpectedErr
 	}
@@ -361,9 +619,27 @@ func (ms *MetaStore) CreateContinuousQuery(database, name, query string) error {
 	return nil
 }
 
+// SetContinuousQueryBackfilled marks the history of a CQ as computed.
+func (ms *MetaStore) SetContinuousQueryBackfilled(database, name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	dbi, err := ms.database(database)
+	if err != nil {
+		return err
+	}
+	for i := range dbi.ContinuousQueries {
+		if dbi.ContinuousQueries[i].Name == name {
+			dbi.ContinuousQueries[i].Backfilled = true
+			return nil
+		}
+	}
+	return fmt.Errorf("continuous query not found: %s", name)
+}
+
 // QueryExecutor is a mock query executor.
 type QueryExecutor struct {
-	ExecuteQueryFn      func(query *influxql.Query, database string, chunkSize int) (<-chan *influxql.Result, error)
+	ExecuteQueryFn      func(query *influxql.Query, database string, chunkSize int, closing <-chan struct{}) (<-chan *influxql.Result, error)
 	Results             []*influxql.Result
 	ResultInterval      time.Duration
 	Err                 error
@@ -382,11 +658,11 @@ func NewQueryExecutor(t *testing.T) *QueryExecutor {
 }
 
 // ExecuteQuery returns a channel that the caller can read query results from.
-func (qe *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chunkSize int) (<-chan *influxql.Result, error) {
+func (qe *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 
 	// If the test set a callback, call it.
 	if qe.ExecuteQueryFn != nil {
-		if _, err := qe.ExecuteQueryFn(query, database, chunkSize); err != nil {
+		if _, err := qe.ExecuteQueryFn(query, database, chunkSize, closing); err != nil {
 			return nil, err
 		}
 	}
@@ -129,9 +129,7 @@ func (p *Parser) Parse(line string) (tsdb.Point, error) {
 			tags[k] = v
 		}
 	}
-	point := tsdb.NewPoint(measurement, tags, fieldValues, timestamp)
-
-	return point, nil
+	return tsdb.NewPoint(measurement, tags, fieldValues, timestamp)
 }
 
 // template represents a pattern and tags to map a graphite metric string to a influxdb Point
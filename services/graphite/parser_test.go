@@ -223,7 +223,7 @@ func TestFilterMatchDefault(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("miss.servers.localhost.cpu_load",
+	exp := tsdb.MustNewPoint("miss.servers.localhost.cpu_load",
 		tsdb.Tags{},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -244,7 +244,7 @@ func TestFilterMatchMultipleMeasurement(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu.cpu_load.10",
+	exp := tsdb.MustNewPoint("cpu.cpu_load.10",
 		tsdb.Tags{"host": "localhost"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -268,7 +268,7 @@ func TestFilterMatchMultipleMeasurementSeparator(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_cpu_load_10",
+	exp := tsdb.MustNewPoint("cpu_cpu_load_10",
 		tsdb.Tags{"host": "localhost"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -289,7 +289,7 @@ func TestFilterMatchSingle(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "localhost"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -310,7 +310,7 @@ func TestParseNoMatch(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("servers.localhost.memory.VmallocChunk",
+	exp := tsdb.MustNewPoint("servers.localhost.memory.VmallocChunk",
 		tsdb.Tags{},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -331,7 +331,7 @@ func TestFilterMatchWildcard(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "localhost"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -354,7 +354,7 @@ func TestFilterMatchExactBeforeWildcard(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "localhost"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -382,7 +382,7 @@ func TestFilterMatchMostLongestFilter(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "localhost", "resource": "cpu"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -409,7 +409,7 @@ func TestFilterMatchMultipleWildcards(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "server01"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -434,7 +434,7 @@ func TestParseDefaultTags(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "localhost", "region": "us-east", "zone": "1c"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -458,7 +458,7 @@ func TestParseDefaultTemplateTags(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "localhost", "region": "us-east", "zone": "1c"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -482,7 +482,7 @@ func TestParseDefaultTemplateTagsOverridGlobal(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "localhost", "region": "us-east", "zone": "1c"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -506,7 +506,7 @@ func TestParseTemplateWhitespace(t *testing.T) {
 		t.Fatalf("unexpected error creating parser, got %v", err)
 	}
 
-	exp := tsdb.NewPoint("cpu_load",
+	exp := tsdb.MustNewPoint("cpu_load",
 		tsdb.Tags{"host": "localhost", "region": "us-east", "zone": "1c"},
 		tsdb.Fields{"value": float64(11)},
 		time.Unix(1435077219, 0))
@@ -45,7 +45,7 @@ func Test_ServerGraphiteTCP(t *testing.T) {
 			} else if req.RetentionPolicy != "" {
 				t.Fatalf("unexpected retention policy: %s", req.RetentionPolicy)
 			} else if !reflect.DeepEqual(req.Points, []tsdb.Point{
-				tsdb.NewPoint(
+				tsdb.MustNewPoint(
 					"cpu",
 					map[string]string{},
 					map[string]interface{}{"value": 23.456},
@@ -118,7 +118,7 @@ func Test_ServerGraphiteUDP(t *testing.T) {
 			} else if req.RetentionPolicy != "" {
 				t.Fatalf("unexpected retention policy: %s", req.RetentionPolicy)
 			} else if !reflect.DeepEqual(req.Points, []tsdb.Point{
-				tsdb.NewPoint(
+				tsdb.MustNewPoint(
 					"cpu",
 					map[string]string{},
 					map[string]interface{}{"value": 23.456},
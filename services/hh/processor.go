@@ -190,7 +190,7 @@ func (p *Processor) marshalWrite(shardID uint64, points []tsdb.Point) []byte {
 	b := make([]byte, 8)
 	binary.BigEndian.PutUint64(b, shardID)
 	for _, p := range points {
-		b = append(b, []byte(p.String())...)
+		b = p.AppendString(b)
 		b = append(b, '\n')
 	}
 	return b
@@ -1,5 +1,25 @@
 package httpd
 
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	// DefaultHttpsMinVersion is the default minimum TLS version of HTTPS connections.
+	DefaultHttpsMinVersion = "1.2"
+)
+
+// tlsVersions maps the supported minimum TLS versions to their identifiers.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+}
+
 type Config struct {
 	Enabled      bool   `toml:"enabled"`
 	BindAddress  string `toml:"bind-address"`
@@ -7,12 +27,72 @@ type Config struct {
 	LogEnabled   bool   `toml:"log-enabled"`
 	WriteTracing bool   `toml:"write-tracing"`
 	PprofEnabled bool   `toml:"pprof-enabled"`
+
+	// HTTPS settings. The private key is read from the certificate file if
+	// no key file is given. Client certificates are required, and verified
+	// against the certificate authorities of the client CA file, if it's set.
+	HttpsEnabled     bool   `toml:"https-enabled"`
+	HttpsCertificate string `toml:"https-certificate"`
+	HttpsPrivateKey  string `toml:"https-private-key"`
+	HttpsMinVersion  string `toml:"https-min-version"`
+	HttpsClientCA    string `toml:"https-client-ca"`
 }
 
 func NewConfig() Config {
 	return Config{
-		Enabled:     true,
-		BindAddress: ":8086",
-		LogEnabled:  true,
+		Enabled:         true,
+		BindAddress:     ":8086",
+		LogEnabled:      true,
+		HttpsMinVersion: DefaultHttpsMinVersion,
 	}
 }
+
+// Validate validates the HTTPS settings if HTTPS is enabled.
+func (c *Config) Validate() error {
+	if !c.HttpsEnabled {
+		return nil
+	}
+	if c.HttpsCertificate == "" {
+		return errors.New("https-certificate must be specified when https is enabled")
+	}
+	if _, ok := tlsVersions[c.HttpsMinVersion]; c.HttpsMinVersion != "" && !ok {
+		return fmt.Errorf("invalid https-min-version: %q", c.HttpsMinVersion)
+	}
+	return nil
+}
+
+// TLSConfig returns the TLS configuration of the HTTPS settings.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	key := c.HttpsPrivateKey
+	if key == "" {
+		key = c.HttpsCertificate
+	}
+	cert, err := tls.LoadX509KeyPair(c.HttpsCertificate, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load certificate: %s", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tlsVersions[c.HttpsMinVersion],
+	}
+
+	if c.HttpsClientCA != "" {
+		buf, err := ioutil.ReadFile(c.HttpsClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client CA: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(buf) {
+			return nil, fmt.Errorf("no certificates found in client CA: %s", c.HttpsClientCA)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
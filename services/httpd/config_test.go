@@ -1,7 +1,19 @@
 package httpd_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/influxdb/influxdb/services/httpd"
@@ -17,6 +29,11 @@ auth-enabled = true
 log-enabled = true
 write-tracing = true
 pprof-enabled = true
+https-enabled = true
+https-certificate = "/etc/ssl/influxdb.crt"
+https-private-key = "/etc/ssl/influxdb.key"
+https-min-version = "1.1"
+https-client-ca = "/etc/ssl/ca.crt"
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -34,6 +51,66 @@ pprof-enabled = true
 		t.Fatalf("unexpected write tracing: %v", c.WriteTracing)
 	} else if c.PprofEnabled != true {
 		t.Fatalf("unexpected pprof enabled: %v", c.PprofEnabled)
+	} else if c.HttpsEnabled != true {
+		t.Fatalf("unexpected https enabled: %v", c.HttpsEnabled)
+	} else if c.HttpsCertificate != "/etc/ssl/influxdb.crt" {
+		t.Fatalf("unexpected https certificate: %s", c.HttpsCertificate)
+	} else if c.HttpsPrivateKey != "/etc/ssl/influxdb.key" {
+		t.Fatalf("unexpected https private key: %s", c.HttpsPrivateKey)
+	} else if c.HttpsMinVersion != "1.1" {
+		t.Fatalf("unexpected https min version: %s", c.HttpsMinVersion)
+	} else if c.HttpsClientCA != "/etc/ssl/ca.crt" {
+		t.Fatalf("unexpected https client ca: %s", c.HttpsClientCA)
+	}
+}
+
+// Ensure invalid HTTPS settings are rejected.
+func TestConfig_Validate(t *testing.T) {
+	for i, tt := range []struct {
+		c   httpd.Config
+		err string
+	}{
+		{c: httpd.Config{HttpsCertificate: "x", HttpsMinVersion: "2.0"}},
+		{c: httpd.Config{HttpsEnabled: true}, err: `https-certificate must be specified when https is enabled`},
+		{c: httpd.Config{HttpsEnabled: true, HttpsCertificate: "x", HttpsMinVersion: "2.0"}, err: `invalid https-min-version: "2.0"`},
+		{c: httpd.Config{HttpsEnabled: true, HttpsCertificate: "x", HttpsMinVersion: "1.2"}},
+	} {
+		if err := tt.c.Validate(); (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		}
+	}
+}
+
+// Ensure the TLS configuration is built from the HTTPS settings.
+func TestConfig_TLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "httpd-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Write the certificate and its key into the same file.
+	path := filepath.Join(dir, "influxdb.pem")
+	if err := ioutil.WriteFile(path, MustGenerateCertificate(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := httpd.Config{HttpsEnabled: true, HttpsCertificate: path, HttpsMinVersion: "1.1", HttpsClientCA: path}
+	config, err := c.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(config.Certificates) != 1 {
+		t.Fatalf("unexpected certificate count: %d", len(config.Certificates))
+	} else if config.MinVersion != tls.VersionTLS11 {
+		t.Fatalf("unexpected min version: %x", config.MinVersion)
+	} else if config.ClientAuth != tls.RequireAndVerifyClientCert || config.ClientCAs == nil {
+		t.Fatalf("unexpected client auth: %v", config.ClientAuth)
+	}
+
+	// A missing key is an error.
+	c = httpd.Config{HttpsEnabled: true, HttpsCertificate: filepath.Join(dir, "missing.pem")}
+	if _, err := c.TLSConfig(); err == nil {
+		t.Fatal("expected error")
 	}
 }
 
@@ -44,3 +121,34 @@ func TestConfig_WriteTracing(t *testing.T) {
 		t.Fatalf("write tracing was not set")
 	}
 }
+
+// MustGenerateCertificate returns a PEM encoded self-signed certificate
+// followed by its private key.
+func MustGenerateCertificate() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+
+	buf := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return append(buf, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+}
@@ -0,0 +1,98 @@
+package httpd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+var (
+	// ErrInvalidCursor is returned when a cursor can't be decoded.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrPaginationStatement is returned when a paginated query isn't a
+	// single SELECT statement.
+	ErrPaginationStatement = errors.New("pagination requires a single SELECT statement")
+)
+
+// queryCursor is the state of a paginated query. It's encoded into the opaque
+// token returned with each page, which clients pass back to read the next one
+// instead of the query. As the query is authorized again for every page the
+// token doesn't need to be protected.
+//
+// Pages are read with LIMIT and OFFSET so, like them, a page holds up to
+// PageSize points of each series of the query.
+type queryCursor struct {
+	Query    string `json:"q"`
+	Database string `json:"db"`
+	PageSize int    `json:"page_size"`
+	Offset   int    `json:"offset"`
+
+	// Limit of the statement before the page was applied.
+	limit int
+}
+
+// decodeQueryCursor returns the cursor of a token.
+func decodeQueryCursor(token string) (*queryCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c queryCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Query == "" || c.PageSize <= 0 || c.Offset < 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// String returns the token of the cursor.
+func (c *queryCursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// apply limits the statement of a query to the page of the cursor. One more
+// point per series than fits on the page is read to know if there's another.
+func (c *queryCursor) apply(query *influxql.Query) error {
+	if len(query.Statements) != 1 {
+		return ErrPaginationStatement
+	}
+	stmt, ok := query.Statements[0].(*influxql.SelectStatement)
+	if !ok {
+		return ErrPaginationStatement
+	}
+
+	c.limit = stmt.Limit
+	if c.limit > 0 && c.Offset >= c.limit {
+		return ErrInvalidCursor
+	}
+
+	stmt.Offset += c.Offset
+	stmt.Limit = c.PageSize + 1
+	if c.limit > 0 && c.limit-c.Offset < stmt.Limit {
+		stmt.Limit = c.limit - c.Offset
+	}
+	return nil
+}
+
+// next trims the series of the results of a page to the page size and returns
+// the cursor of the next page. Returns nil if it's the last page.
+func (c *queryCursor) next(results []*influxql.Result) *queryCursor {
+	more := false
+	for _, r := range results {
+		for _, row := range r.Series {
+			if len(row.Values) > c.PageSize {
+				row.Values = row.Values[:c.PageSize]
+				more = true
+			}
+		}
+	}
+
+	offset := c.Offset + c.PageSize
+	if !more || (c.limit > 0 && offset >= c.limit) {
+		return nil
+	}
+	return &queryCursor{Query: c.Query, Database: c.Database, PageSize: c.PageSize, Offset: offset}
+}
@@ -60,7 +60,11 @@ type Handler struct {
 	}
 
 	QueryExecutor interface {
-		ExecuteQuery(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error)
+		ExecuteQuery(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error)
+	}
+
+	QueryAuthorizer interface {
+		AuthorizeQuery(u *meta.UserInfo, stmt influxql.Statement, database string) error
 	}
 
 	PointsWriter interface {
@@ -89,6 +93,10 @@ func NewHandler(requireAuthentication, loggingEnabled, writeTrace bool) *Handler
 			"query", // Query serving route.
 			"GET", "/query", true, true, h.serveQuery,
 		},
+		route{
+			"query", // Query serving route for queries too long for a URL.
+			"POST", "/query", true, true, h.serveQuery,
+		},
 		route{
 			"write", // Data-ingest route.
 			"OPTIONS", "/write", true, true, h.serveOptions,
@@ -105,6 +113,10 @@ func NewHandler(requireAuthentication, loggingEnabled, writeTrace bool) *Handler
 			"ping-head",
 			"HEAD", "/ping", true, true, h.servePing,
 		},
+		route{ // Prometheus remote read
+			"prometheus-read",
+			"POST", "/api/v1/prom/read", false, true, h.servePromRead,
+		},
 		route{ // Tell data node to run CQs that should be run
 			"process_continuous_queries",
 			"POST", "/data/process_continuous_queries", false, false, h.serveProcessContinuousQueries,
@@ -180,6 +192,12 @@ func (h *Handler) serveProcessContinuousQueries(w http.ResponseWriter, r *http.R
 	// Get the name of the CQ to run (blank means run all).
 	name := q.Get("name")
 
+	// Run a single CQ over a historical time range if a start time is given.
+	if q.Get("start") != "" {
+		h.serveBackfillContinuousQuery(w, r, user, db, name)
+		return
+	}
+
 	// Pass the request to the CQ service.
 	if err := h.ContinuousQuerier.Run(db, name); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -189,12 +207,75 @@ func (h *Handler) serveProcessContinuousQueries(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// serveQuery parses an incoming query and, if valid, executes the query.
-func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+// serveBackfillContinuousQuery runs a CQ over the RFC3339 time range given by
+// the start and end parameters. A blank end means the current time. When
+// authentication is enabled only admin users may backfill.
+func (h *Handler) serveBackfillContinuousQuery(w http.ResponseWriter, r *http.Request, user *meta.UserInfo, db, name string) {
 	q := r.URL.Query()
+
+	if db == "" || name == "" {
+		httpError(w, "db and name are required to backfill a continuous query", false, http.StatusBadRequest)
+		return
+	}
+	if h.requireAuthentication && (user == nil || !user.Admin) {
+		httpError(w, "only admin users may backfill continuous queries", false, http.StatusUnauthorized)
+		return
+	}
+
+	start, err := time.Parse(time.RFC3339Nano, q.Get("start"))
+	if err != nil {
+		httpError(w, "invalid start: "+q.Get("start"), false, http.StatusBadRequest)
+		return
+	}
+	var end time.Time
+	if s := q.Get("end"); s != "" {
+		if end, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			httpError(w, "invalid end: "+s, false, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.ContinuousQuerier.Backfill(db, name, start, end); err != nil {
+		httpError(w, err.Error(), false, http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveQuery parses an incoming query and, if valid, executes its statements
+// in order. Parameters are read from the URL or, for POST requests, from a
+// form encoded body.
+func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	if err := r.ParseForm(); err != nil {
+		httpError(w, "error parsing parameters: "+err.Error(), false, http.StatusBadRequest)
+		return
+	}
+	q := r.Form
 	pretty := q.Get("pretty") == "true"
 
 	qp := strings.TrimSpace(q.Get("q"))
+	db := q.Get("db")
+
+	// Results are paginated if a page size is given. The cursor returned with
+	// a page replaces the query and database parameters to read the next one.
+	var cursor *queryCursor
+	if token := q.Get("cursor"); token != "" {
+		c, err := decodeQueryCursor(token)
+		if err != nil {
+			httpError(w, err.Error(), pretty, http.StatusBadRequest)
+			return
+		}
+		cursor, qp, db = c, c.Query, c.Database
+	} else if s := q.Get("page_size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			httpError(w, "invalid page_size: "+s, pretty, http.StatusBadRequest)
+			return
+		}
+		cursor = &queryCursor{Query: qp, Database: db, PageSize: n}
+	}
+
 	if qp == "" {
 		httpError(w, `missing required parameter "q"`, pretty, http.StatusBadRequest)
 		return
@@ -203,7 +284,6 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 	epoch := strings.TrimSpace(q.Get("epoch"))
 
 	p := influxql.NewParser(strings.NewReader(qp))
-	db := q.Get("db")
 
 	// Parse query from query string.
 	query, err := p.ParseQuery()
@@ -212,29 +292,98 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 		return
 	}
 
-	// Parse chunk size. Use default if not provided or unparsable.
+	// Check authorization of the query. The privileges of every statement are
+	// checked in a single pass against the user's database grants. Statements
+	// are only authorized individually, which allows for measurement grants
+	// and detailed errors, if that fails.
+	if h.requireAuthentication && h.QueryAuthorizer != nil && !authorizeDatabases(user, query, db) {
+		for _, stmt := range query.Statements {
+			if err := h.QueryAuthorizer.AuthorizeQuery(user, stmt, db); err != nil {
+				httpError(w, "error authorizing query: "+err.Error(), pretty, http.StatusUnauthorized)
+				return
+			}
+		}
+	}
+
+	// Parse chunk size. Use default if not provided, unparsable or not positive.
 	chunked := (q.Get("chunked") == "true")
 	chunkSize := DefaultChunkSize
 	if chunked {
-		if n, err := strconv.ParseInt(q.Get("chunk_size"), 10, 64); err == nil {
+		if n, err := strconv.ParseInt(q.Get("chunk_size"), 10, 64); err == nil && n > 0 {
 			chunkSize = int(n)
 		}
 	}
 
-	// Execute query.
-	w.Header().Add("content-type", "application/json")
-	results, err := h.QueryExecutor.ExecuteQuery(query, db, chunkSize)
+	// Limit the query to the page of the cursor. Series of a page aren't
+	// split into chunks so they can be trimmed to the page size.
+	if cursor != nil {
+		if chunked {
+			httpError(w, "chunked responses can't be paginated", pretty, http.StatusBadRequest)
+			return
+		} else if err := cursor.apply(query); err != nil {
+			httpError(w, err.Error(), pretty, http.StatusBadRequest)
+			return
+		}
+		if chunkSize <= cursor.PageSize {
+			chunkSize = cursor.PageSize + 1
+		}
+	}
+
+	// Parse the timeout overriding the executor's limit. When authentication
+	// is enabled only admin users may set it.
+	var timeout time.Duration
+	if s := q.Get("timeout"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			httpError(w, "invalid timeout: "+s, pretty, http.StatusBadRequest)
+			return
+		}
+		if h.requireAuthentication && (user == nil || !user.Admin) {
+			httpError(w, "only admin users may set the query timeout", pretty, http.StatusUnauthorized)
+			return
+		}
+		timeout = d
+	}
 
+	// Cancel the query if the client disconnects before every result is written.
+	closing := make(chan struct{})
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		notify := notifier.CloseNotify()
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-notify:
+				close(closing)
+			case <-done:
+			}
+		}()
+	}
+
+	// Execute query.
+	results, err := h.QueryExecutor.ExecuteQuery(query, db, chunkSize, timeout, closing)
 	if _, ok := err.(meta.AuthError); ok {
-		w.WriteHeader(http.StatusUnauthorized)
+		httpError(w, err.Error(), pretty, http.StatusUnauthorized)
 		return
 	} else if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		httpError(w, "error executing query: "+err.Error(), pretty, http.StatusInternalServerError)
 		return
 	}
 
-	// if we're not chunking, this will be the in memory buffer for all results before sending to client
+	// Results are encoded as MessagePack if the client accepts it.
+	msgpack := strings.Contains(r.Header.Get("Accept"), MsgpackContentType)
+	if msgpack {
+		w.Header().Add("content-type", MsgpackContentType)
+	} else {
+		w.Header().Add("content-type", "application/json")
+	}
+
+	// If we're not chunking, results are combined into a single response. It's
+	// written as results are read unless it's pretty printed, MessagePack
+	// encoded or paginated, in which case this is the in memory buffer for all
+	// results before sending to client.
 	resp := Response{Results: make([]*influxql.Result, 0)}
+	stream := newResponseStreamer(w)
 	statusWritten := false
 
 	// pull all results from the channel
@@ -263,16 +412,33 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 			convertToEpoch(r, epoch)
 		}
 
-		// Write out result immediately if chunked.
+		// Write out result immediately if chunked, as one response per
+		// series of up to chunkSize values. JSON responses are each written
+		// on their own line.
 		if chunked {
-			w.Write(MarshalJSON(Response{
-				Results: []*influxql.Result{r},
-			}, pretty))
-			w.(http.Flusher).Flush()
+			for _, chunk := range chunkResult(r, chunkSize) {
+				resp := Response{Results: []*influxql.Result{chunk}}
+				if msgpack {
+					w.Write(resp.MarshalMsgpack())
+					continue
+				}
+				w.Write(MarshalJSON(resp, pretty))
+				w.Write([]byte("\n"))
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
 			continue
 		}
 
-		// It's not chunked so buffer results in memory.
+		// Write out the series of the result if not pretty printed.
+		if !pretty && !msgpack && cursor == nil {
+			stream.Write(r)
+			continue
+		}
+
+		// It's pretty printed, MessagePack encoded or paginated so buffer
+		// results in memory.
 		// Results for statements need to be combined together.
 		// We need to check if this new result is for the same statement as
 		// the last result, or for the next statement
@@ -287,9 +453,21 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user *meta.
 		}
 	}
 
-	// If it's not chunked we buffered everything in memory, so write it out
-	if !chunked {
+	// If it's paginated, trim the page and return the cursor of the next one.
+	if cursor != nil {
+		if next := cursor.next(resp.Results); next != nil {
+			resp.NextCursor = next.String()
+		}
+	}
+
+	// If it's pretty printed, MessagePack encoded or paginated we buffered
+	// everything in memory, so write it out
+	if !chunked && msgpack {
+		w.Write(resp.MarshalMsgpack())
+	} else if !chunked && (pretty || cursor != nil) {
 		w.Write(MarshalJSON(resp, pretty))
+	} else if !chunked {
+		stream.Close()
 	}
 }
 
@@ -380,6 +558,9 @@ func (h *Handler) serveWriteJSON(w http.ResponseWriter, r *http.Request, body []
 	}); influxdb.IsClientError(err) {
 		resultError(w, influxql.Result{Err: err}, http.StatusBadRequest)
 		return
+	} else if err == tsdb.ErrWriteBufferFull {
+		resultError(w, influxql.Result{Err: err}, http.StatusServiceUnavailable)
+		return
 	} else if err != nil {
 		resultError(w, influxql.Result{Err: err}, http.StatusInternalServerError)
 		return
@@ -418,16 +599,20 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 	precision := r.FormValue("precision")
 	if precision == "" {
 		precision = "n"
+	} else if !tsdb.ValidPrecision(precision) {
+		h.writeError(w, influxql.Result{Err: fmt.Errorf("invalid precision: %q", precision)}, http.StatusBadRequest)
+		return
 	}
 
-	points, err := tsdb.ParsePointsWithPrecision(body, time.Now().UTC(), precision)
-	if err != nil {
-		if err.Error() == "EOF" {
-			w.WriteHeader(http.StatusOK)
+	// Determine required consistency level.
+	consistency := cluster.ConsistencyLevelOne
+	if s := r.FormValue("consistency"); s != "" {
+		level, err := cluster.ParseConsistencyLevel(s)
+		if err != nil {
+			h.writeError(w, influxql.Result{Err: fmt.Errorf("%s: %q", err, s)}, http.StatusBadRequest)
 			return
 		}
-		h.writeError(w, influxql.Result{Err: err}, http.StatusBadRequest)
-		return
+		consistency = level
 	}
 
 	database := r.FormValue("db")
@@ -454,17 +639,11 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 		return
 	}
 
-	// Determine required consistency level.
-	consistency := cluster.ConsistencyLevelOne
-	switch r.Form.Get("consistency") {
-	case "all":
-		consistency = cluster.ConsistencyLevelAll
-	case "any":
-		consistency = cluster.ConsistencyLevelAny
-	case "one":
-		consistency = cluster.ConsistencyLevelOne
-	case "quorum":
-		consistency = cluster.ConsistencyLevelQuorum
+	// Parse every line, keeping the points of the lines that could be parsed.
+	points, lineErrs := tsdb.ParsePointsPartial(body, time.Now().UTC(), precision)
+	if len(lineErrs) > 0 && len(points) == 0 {
+		h.writeLineErrors(w, "write failed", len(lineErrs), lineErrs)
+		return
 	}
 
 	// Write points.
@@ -476,14 +655,48 @@ func (h *Handler) serveWriteLine(w http.ResponseWriter, r *http.Request, body []
 	}); influxdb.IsClientError(err) {
 		h.writeError(w, influxql.Result{Err: err}, http.StatusBadRequest)
 		return
+	} else if err == tsdb.ErrWriteBufferFull {
+		h.writeError(w, influxql.Result{Err: err}, http.StatusServiceUnavailable)
+		return
 	} else if err != nil {
 		h.writeError(w, influxql.Result{Err: err}, http.StatusInternalServerError)
 		return
 	}
 
+	if len(lineErrs) > 0 {
+		h.writeLineErrors(w, "partial write", len(points)+len(lineErrs), lineErrs)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// writeLineErrors writes a bad request response listing the lines of a line
+// protocol body of n points that couldn't be parsed, one per line.
+func (h *Handler) writeLineErrors(w http.ResponseWriter, msg string, n int, errs []*tsdb.LineError) {
+	w.WriteHeader(http.StatusBadRequest)
+	fmt.Fprintf(w, "%s: unable to parse %d of %d points\n", msg, len(errs), n)
+	for _, err := range errs {
+		fmt.Fprintln(w, err.Error())
+	}
+}
+
+// chunkResult splits a result into results holding a single series of up to
+// chunkSize values each. The error of the result is returned with its last chunk.
+func chunkResult(r *influxql.Result, chunkSize int) []*influxql.Result {
+	if len(r.Series) == 0 || (len(r.Series) == 1 && len(r.Series[0].Values) <= chunkSize) {
+		return []*influxql.Result{r}
+	}
+
+	var a []*influxql.Result
+	for _, row := range r.Series {
+		for _, chunk := range row.Chunk(chunkSize) {
+			a = append(a, &influxql.Result{StatementID: r.StatementID, Series: influxql.Rows{chunk}})
+		}
+	}
+	a[len(a)-1].Err = r.Err
+	return a
+}
+
 // serveOptions returns an empty response to comply with OPTIONS pre-flight requests
 func (h *Handler) serveOptions(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
@@ -590,6 +803,27 @@ func parseCredentials(r *http.Request) (string, string, error) {
 	}
 }
 
+// authorizeDatabases returns true if the user holds the privileges required by
+// every statement in the query on the whole of each database.
+func authorizeDatabases(u *meta.UserInfo, q *influxql.Query, db string) bool {
+	if u == nil {
+		return false
+	} else if u.Admin {
+		return true
+	}
+
+	cluster, databases := q.RequiredPrivileges().DatabasePrivileges(db)
+	if cluster != influxql.NoPrivileges {
+		return false
+	}
+	for name, p := range databases {
+		if !u.Authorize(p, name) {
+			return false
+		}
+	}
+	return true
+}
+
 // authenticate wraps a handler and ensures that if user credentials are passed in
 // an attempt is made to authenticate that user. If authentication fails, an error is returned.
 //
@@ -646,6 +880,13 @@ func (w gzipResponseWriter) Flush() {
 	w.Writer.(*gzip.Writer).Flush()
 }
 
+func (w gzipResponseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return nil
+}
+
 // determines if the client can accept compressed responses, and encodes accordingly
 func gzipFilter(inner http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -740,14 +981,18 @@ func recovery(inner http.Handler, name string, weblog *log.Logger) http.Handler
 type Response struct {
 	Results []*influxql.Result
 	Err     error
+
+	// Token to read the next page of a paginated query, if any.
+	NextCursor string
 }
 
 // MarshalJSON encodes a Response struct into JSON.
 func (r Response) MarshalJSON() ([]byte, error) {
 	// Define a struct that outputs "error" as a string.
 	var o struct {
-		Results []*influxql.Result `json:"results,omitempty"`
-		Err     string             `json:"error,omitempty"`
+		Results    []*influxql.Result `json:"results,omitempty"`
+		Err        string             `json:"error,omitempty"`
+		NextCursor string             `json:"next_cursor,omitempty"`
 	}
 
 	// Copy fields to output struct.
@@ -755,6 +1000,7 @@ func (r Response) MarshalJSON() ([]byte, error) {
 	if r.Err != nil {
 		o.Err = r.Err.Error()
 	}
+	o.NextCursor = r.NextCursor
 
 	return json.Marshal(&o)
 }
@@ -762,8 +1008,9 @@ func (r Response) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON decodes the data into the Response struct
 func (r *Response) UnmarshalJSON(b []byte) error {
 	var o struct {
-		Results []*influxql.Result `json:"results,omitempty"`
-		Err     string             `json:"error,omitempty"`
+		Results    []*influxql.Result `json:"results,omitempty"`
+		Err        string             `json:"error,omitempty"`
+		NextCursor string             `json:"next_cursor,omitempty"`
 	}
 
 	err := json.Unmarshal(b, &o)
@@ -774,6 +1021,7 @@ func (r *Response) UnmarshalJSON(b []byte) error {
 	if o.Err != "" {
 		r.Err = errors.New(o.Err)
 	}
+	r.NextCursor = o.NextCursor
 	return nil
 }
 
@@ -809,6 +1057,10 @@ func (h *Handler) serveDump(w http.ResponseWriter, r *http.Request, user *meta.U
 		return
 	}
 
+	// Cancel the current query if the dump stops before reading all of it.
+	closing := make(chan struct{})
+	defer close(closing)
+
 	// Fetch all the points for each measurement.
 	// From the 'select' query below, we get:
 	//
@@ -830,7 +1082,7 @@ func (h *Handler) serveDump(w http.ResponseWriter, r *http.Request, user *meta.U
 			return
 		}
 
-		res, err := h.QueryExecutor.ExecuteQuery(query, db, DefaultChunkSize)
+		res, err := h.QueryExecutor.ExecuteQuery(query, db, DefaultChunkSize, 0, closing)
 		if err != nil {
 			w.Write([]byte("*** SERVER-SIDE ERROR. MISSING DATA ***"))
 			w.Write(delim)
@@ -880,7 +1132,7 @@ func (h *Handler) serveDump(w http.ResponseWriter, r *http.Request, user *meta.U
 // Return all the measurements from the given DB
 func (h *Handler) showMeasurements(db string, user *meta.UserInfo) ([]string, error) {
 	var measurements []string
-	c, err := h.QueryExecutor.ExecuteQuery(&influxql.Query{Statements: []influxql.Statement{&influxql.ShowMeasurementsStatement{}}}, db, 0)
+	c, err := h.QueryExecutor.ExecuteQuery(&influxql.Query{Statements: []influxql.Statement{&influxql.ShowMeasurementsStatement{}}}, db, 0, 0, nil)
 	if err != nil {
 		return measurements, err
 	}
@@ -942,15 +1194,12 @@ func NormalizeBatchPoints(bp client.BatchPoints) ([]tsdb.Point, error) {
 			}
 		}
 
-		if p.Measurement == "" {
-			return points, fmt.Errorf("missing measurement")
-		}
-
-		if len(p.Fields) == 0 {
-			return points, fmt.Errorf("missing fields")
-		}
 		// Need to convert from a client.Point to a influxdb.Point
-		points = append(points, tsdb.NewPoint(p.Measurement, p.Tags, p.Fields, p.Time))
+		pt, err := tsdb.NewPoint(p.Measurement, p.Tags, p.Fields, p.Time)
+		if err != nil {
+			return points, err
+		}
+		points = append(points, pt)
 	}
 
 	return points, nil
@@ -1,6 +1,7 @@
 package httpd_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,12 +10,17 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	"github.com/influxdb/influxdb/client"
+	"github.com/influxdb/influxdb/cluster"
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/prometheus/remote"
 	"github.com/influxdb/influxdb/services/httpd"
 	"github.com/influxdb/influxdb/tsdb"
 )
@@ -133,7 +139,7 @@ func TestBatchWrite_UnmarshalRFC(t *testing.T) {
 // Ensure the handler returns results from a query (including nil results).
 func TestHandler_Query(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		if q.String() != `SELECT * FROM bar` {
 			t.Fatalf("unexpected query: %s", q.String())
 		} else if db != `foo` {
@@ -155,10 +161,135 @@ func TestHandler_Query(t *testing.T) {
 	}
 }
 
+// Ensure the handler executes the statements of a query sent in a POST body
+// and returns a result for each of them.
+func TestHandler_Query_POST(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		if q.String() != "SELECT * FROM bar;\nSHOW SERIES" {
+			t.Fatalf("unexpected query: %s", q.String())
+		} else if db != `foo` {
+			t.Fatalf("unexpected db: %s", db)
+		}
+		return NewResultChan(
+			&influxql.Result{StatementID: 0, Series: influxql.Rows{{Name: "series0"}}},
+			&influxql.Result{StatementID: 1, Err: errors.New("marker")},
+		), nil
+	}
+
+	w := httptest.NewRecorder()
+	r := MustNewRequest("POST", "/query?db=foo", strings.NewReader("q=SELECT+*+FROM+bar%3B+SHOW+SERIES"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"results":[{"series":[{"name":"series0"}]},{"error":"marker"}]}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler encodes results into MessagePack if the client accepts it.
+func TestHandler_Query_Msgpack(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		return NewResultChan(
+			&influxql.Result{StatementID: 1, Series: influxql.Rows{{Name: "series0", Values: [][]interface{}{{int64(1), 2.0}}}}},
+			&influxql.Result{StatementID: 1, Series: influxql.Rows{{Name: "series1"}}},
+		), nil
+	}
+
+	w := httptest.NewRecorder()
+	r := MustNewRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil)
+	r.Header.Set("Accept", "application/x-msgpack")
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if ct := w.Header().Get("Content-Type"); ct != "application/x-msgpack" {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+
+	exp := httpd.Response{Results: []*influxql.Result{{
+		StatementID: 1,
+		Series: influxql.Rows{
+			{Name: "series0", Values: [][]interface{}{{int64(1), 2.0}}},
+			{Name: "series1"},
+		},
+	}}}.MarshalMsgpack()
+	if !bytes.Equal(w.Body.Bytes(), exp) {
+		t.Fatalf("unexpected body: %x", w.Body.Bytes())
+	}
+}
+
+// Ensure the handler pages through results with the returned cursor.
+func TestHandler_Query_Paginated(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		if db != `foo` {
+			t.Fatalf("unexpected db: %s", db)
+		}
+
+		// Return one more point than the page size until the limit is reached.
+		switch q.String() {
+		case `SELECT * FROM bar LIMIT 3`:
+			return NewResultChan(&influxql.Result{Series: influxql.Rows{{Name: "bar", Values: [][]interface{}{{"a"}, {"b"}, {"c"}}}}}), nil
+		case `SELECT * FROM bar LIMIT 1 OFFSET 2`:
+			return NewResultChan(&influxql.Result{Series: influxql.Rows{{Name: "bar", Values: [][]interface{}{{"c"}}}}}), nil
+		default:
+			t.Fatalf("unexpected query: %s", q.String())
+			return nil, nil
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar+LIMIT+3&page_size=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+	var resp httpd.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(resp.Results[0].Series[0].Values, [][]interface{}{{"a"}, {"b"}}) {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	} else if resp.NextCursor == "" {
+		t.Fatalf("expected cursor: %s", w.Body.String())
+	}
+
+	// Read the last page.
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?cursor="+resp.NextCursor, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"results":[{"series":[{"name":"bar","values":[["c"]]}]}]}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler returns an error if a query can't be paginated.
+func TestHandler_Query_ErrPaginated(t *testing.T) {
+	h := NewHandler(false)
+	for i, tt := range []struct {
+		url  string
+		body string
+	}{
+		{url: "/query?db=foo&q=SELECT+*+FROM+bar&page_size=x", body: `{"error":"invalid page_size: x"}`},
+		{url: "/query?db=foo&q=SHOW+SERIES&page_size=2", body: `{"error":"pagination requires a single SELECT statement"}`},
+		{url: "/query?db=foo&q=SELECT+*+FROM+bar&page_size=2&chunked=true", body: `{"error":"chunked responses can't be paginated"}`},
+		{url: "/query?cursor=x", body: `{"error":"invalid cursor"}`},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewJSONRequest("GET", tt.url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%d. unexpected status: %d", i, w.Code)
+		} else if w.Body.String() != tt.body {
+			t.Errorf("%d. unexpected body: %s", i, w.Body.String())
+		}
+	}
+}
+
 // Ensure the handler merges results from the same statement.
 func TestHandler_Query_MergeResults(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		return NewResultChan(
 			&influxql.Result{StatementID: 1, Series: influxql.Rows{{Name: "series0"}}},
 			&influxql.Result{StatementID: 1, Series: influxql.Rows{{Name: "series1"}}},
@@ -177,7 +308,7 @@ func TestHandler_Query_MergeResults(t *testing.T) {
 // Ensure the handler can parse chunked and chunk size query parameters.
 func TestHandler_Query_Chunked(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		if chunkSize != 2 {
 			t.Fatalf("unexpected chunk size: %d", chunkSize)
 		}
@@ -191,11 +322,111 @@ func TestHandler_Query_Chunked(t *testing.T) {
 	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar&chunked=true&chunk_size=2", nil))
 	if w.Code != http.StatusOK {
 		t.Fatalf("unexpected status: %d", w.Code)
-	} else if w.Body.String() != `{"results":[{"series":[{"name":"series0"}]}]}{"results":[{"series":[{"name":"series1"}]}]}` {
+	} else if w.Body.String() != `{"results":[{"series":[{"name":"series0"}]}]}`+"\n"+`{"results":[{"series":[{"name":"series1"}]}]}`+"\n" {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler splits chunked results into one response per series of
+// up to chunk_size values.
+func TestHandler_Query_Chunked_SplitResult(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		return NewResultChan(&influxql.Result{
+			Series: influxql.Rows{
+				{Name: "series0", Values: [][]interface{}{{"a"}, {"b"}, {"c"}}},
+				{Name: "series1", Values: [][]interface{}{{"d"}}},
+			},
+			Err: errors.New("marker"),
+		}), nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+SERIES&chunked=true&chunk_size=2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if exp := `{"results":[{"series":[{"name":"series0","values":[["a"],["b"]]}]}]}` + "\n" +
+		`{"results":[{"series":[{"name":"series0","values":[["c"]]}]}]}` + "\n" +
+		`{"results":[{"series":[{"name":"series1","values":[["d"]]}],"error":"marker"}]}` + "\n"; w.Body.String() != exp {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure the handler streams the same response as it buffers when pretty printing.
+func TestHandler_Query_Stream(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		return NewResultChan(
+			&influxql.Result{StatementID: 0, Err: errors.New("marker")},
+			&influxql.Result{StatementID: 0, Series: influxql.Rows{{Name: "series0"}}},
+			&influxql.Result{StatementID: 1, Series: make([]*influxql.Row, 0)},
+			&influxql.Result{StatementID: 2, Series: influxql.Rows{{Name: "series1", Columns: []string{"time", "value"}, Values: [][]interface{}{{"2000-01-01T00:00:00Z", 1.5}}}}},
+			&influxql.Result{StatementID: 2, Series: influxql.Rows{{Name: "series2"}}},
+		), nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"results":[{"series":[{"name":"series0"}],"error":"marker"},{},{"series":[{"name":"series1","columns":["time","value"],"values":[["2000-01-01T00:00:00Z",1.5]]},{"name":"series2"}]}]}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+
+	pw := httptest.NewRecorder()
+	h.ServeHTTP(pw, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar&pretty=true", nil))
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, pw.Body.Bytes()); err != nil {
+		t.Fatal(err)
+	} else if buf.String() != w.Body.String() {
+		t.Fatalf("unexpected pretty body: %s", pw.Body.String())
+	}
+}
+
+// Ensure the handler returns an empty response if there are no results.
+func TestHandler_Query_Stream_NoResults(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		return NewResultChan(), nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{}` {
 		t.Fatalf("unexpected body: %s", w.Body.String())
 	}
 }
 
+// Ensure the handler cancels the query when the client disconnects.
+func TestHandler_Query_CloseNotify(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		// Keep the query running until it's canceled.
+		ch := make(chan *influxql.Result)
+		go func() {
+			<-closing
+			close(ch)
+		}()
+		return ch, nil
+	}
+
+	w := &CloseNotifyRecorder{ResponseRecorder: httptest.NewRecorder(), closed: make(chan bool, 1)}
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SELECT+*+FROM+bar", nil))
+		close(done)
+	}()
+
+	w.closed <- true
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("query not canceled")
+	}
+}
+
 // Ensure the handler returns a status 400 if the query is not passed in.
 func TestHandler_Query_ErrQueryRequired(t *testing.T) {
 	h := NewHandler(false)
@@ -223,7 +454,7 @@ func TestHandler_Query_ErrInvalidQuery(t *testing.T) {
 // Ensure the handler returns a status 401 if the user is not authorized.
 func TestHandler_Query_ErrUnauthorized(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		return nil, meta.NewAuthError("marker")
 	}
 
@@ -234,10 +465,331 @@ func TestHandler_Query_ErrUnauthorized(t *testing.T) {
 	}
 }
 
+// Ensure the handler returns a status 401 if a statement is not authorized.
+func TestHandler_Query_ErrAuthorizeQuery(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "susy"}}, nil
+	}
+	h.MetaStore.AuthenticateFn = func(username, password string) (*meta.UserInfo, error) {
+		return &meta.UserInfo{Name: username}, nil
+	}
+	h.QueryAuthorizer.AuthorizeQueryFn = func(u *meta.UserInfo, stmt influxql.Statement, database string) error {
+		if u.Name != "susy" {
+			t.Fatalf("unexpected user: %s", u.Name)
+		} else if stmt.String() != `SHOW SERIES FROM bar` {
+			t.Fatalf("unexpected statement: %s", stmt.String())
+		} else if database != "foo" {
+			t.Fatalf("unexpected database: %s", database)
+		}
+		return errors.New("marker")
+	}
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		t.Fatal("unexpected query execution")
+		return nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&u=susy&p=pass&q=SHOW+SERIES+FROM+bar", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if w.Body.String() != `{"error":"error authorizing query: marker"}` {
+		t.Fatalf("unexpected body: %s", w.Body.String())
+	}
+}
+
+// Ensure statements aren't authorized individually if the user has privileges on every database.
+func TestHandler_Query_AuthorizeDatabases(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "susy"}}, nil
+	}
+	h.MetaStore.AuthenticateFn = func(username, password string) (*meta.UserInfo, error) {
+		return &meta.UserInfo{Name: username, Privileges: map[string]influxql.Privilege{"foo": influxql.ReadPrivilege}}, nil
+	}
+	h.QueryAuthorizer.AuthorizeQueryFn = func(u *meta.UserInfo, stmt influxql.Statement, database string) error {
+		t.Fatal("unexpected statement authorization")
+		return nil
+	}
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		if n := len(q.Statements); n != 2 {
+			t.Fatalf("unexpected statement count: %d", n)
+		}
+		return NewResultChan(&influxql.Result{StatementID: 1, Series: influxql.Rows{{Name: "series0"}}}), nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&u=susy&p=pass&q=SELECT+*+FROM+bar%3BSHOW+SERIES+FROM+bar", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler passes the timeout set by an admin user to the query executor.
+func TestHandler_Query_Timeout(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "root", Admin: true}}, nil
+	}
+	h.MetaStore.AuthenticateFn = func(username, password string) (*meta.UserInfo, error) {
+		return &meta.UserInfo{Name: username, Admin: true}, nil
+	}
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		if timeout != 90*time.Second {
+			t.Fatalf("unexpected timeout: %s", timeout)
+		}
+		return NewResultChan(&influxql.Result{StatementID: 1, Series: influxql.Rows{{Name: "series0"}}}), nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&u=root&p=pass&timeout=90s&q=SELECT+*+FROM+bar", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler returns a status 401 if a user who isn't an admin sets the timeout.
+func TestHandler_Query_Timeout_ErrUnauthorized(t *testing.T) {
+	h := NewHandler(true)
+	h.MetaStore.UsersFn = func() ([]meta.UserInfo, error) {
+		return []meta.UserInfo{{Name: "susy"}}, nil
+	}
+	h.MetaStore.AuthenticateFn = func(username, password string) (*meta.UserInfo, error) {
+		return &meta.UserInfo{Name: username, Privileges: map[string]influxql.Privilege{"foo": influxql.ReadPrivilege}}, nil
+	}
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		t.Fatal("unexpected query execution")
+		return nil, nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&u=susy&p=pass&timeout=1h&q=SELECT+*+FROM+bar", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status: %d", w.Code)
+	}
+}
+
+// Ensure the handler backfills a continuous query over the requested time range.
+func TestHandler_ProcessContinuousQueries_Backfill(t *testing.T) {
+	h := NewHandler(false)
+	var called bool
+	h.ContinuousQuerier.BackfillFn = func(database, name string, start, end time.Time) error {
+		called = true
+		if database != "foo" || name != "cq" {
+			t.Fatalf("unexpected continuous query: %s on %s", name, database)
+		} else if !start.Equal(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected start: %s", start)
+		} else if !end.Equal(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected end: %s", end)
+		}
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/data/process_continuous_queries?db=foo&name=cq&start=2000-01-01T00:00:00Z&end=2000-01-02T00:00:00Z", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if !called {
+		t.Fatal("expected backfill")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/data/process_continuous_queries?db=foo&name=cq&start=yesterday", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := w.Body.String(); body != `{"error":"invalid start: yesterday"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
+// Ensure the handler writes the valid lines of a line protocol body and reports the others.
+func TestHandler_Write_Line_PartialFailure(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	var req *cluster.WritePointsRequest
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		req = p
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo&rp=bar&precision=s&consistency=quorum", bytes.NewBufferString("cpu value=1 10\ncpu value= 20\ncpu value=3 30\n")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := w.Body.String(); body != "partial write: unable to parse 1 of 3 points\nline 2: unable to parse 'cpu value= 20': missing field value\n" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+
+	if req == nil {
+		t.Fatal("expected write")
+	} else if req.Database != "foo" || req.RetentionPolicy != "bar" || req.ConsistencyLevel != cluster.ConsistencyLevelQuorum {
+		t.Fatalf("unexpected request: %#v", req)
+	} else if len(req.Points) != 2 {
+		t.Fatalf("unexpected point count: %d", len(req.Points))
+	} else if !req.Points[1].Time().Equal(time.Unix(30, 0)) {
+		t.Fatalf("unexpected time: %s", req.Points[1].Time())
+	}
+}
+
+// Ensure the handler doesn't write when no line of a line protocol body can be parsed.
+func TestHandler_Write_Line_ErrParse(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		t.Fatal("unexpected write")
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", bytes.NewBufferString("cpu\n")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := w.Body.String(); body != "write failed: unable to parse 1 of 1 points\nline 1: unable to parse 'cpu': invalid field format\n" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+// Ensure the handler asks clients to back off when the write buffer is full.
+func TestHandler_Write_Line_ErrWriteBufferFull(t *testing.T) {
+	h := NewHandler(false)
+	h.MetaStore.DatabaseFn = func(name string) (*meta.DatabaseInfo, error) {
+		return &meta.DatabaseInfo{Name: name}, nil
+	}
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		return tsdb.ErrWriteBufferFull
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo", bytes.NewBufferString("cpu value=1\n")))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := w.Body.String(); body != "write buffer full\n" {
+		t.Fatalf("unexpected body: %q", body)
+	}
+}
+
+// Ensure the handler rejects unknown precisions and consistency levels.
+func TestHandler_Write_Line_ErrInvalidParameter(t *testing.T) {
+	h := NewHandler(false)
+	h.PointsWriter.WritePointsFn = func(p *cluster.WritePointsRequest) error {
+		t.Fatal("unexpected write")
+		return nil
+	}
+
+	for _, tt := range []struct {
+		query string
+		err   string
+	}{
+		{query: "precision=ns", err: `invalid precision: "ns"` + "\n"},
+		{query: "consistency=some", err: `invalid consistency level: "some"` + "\n"},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, MustNewRequest("POST", "/write?db=foo&"+tt.query, bytes.NewBufferString("cpu value=1\n")))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: unexpected status: %d", tt.query, w.Code)
+		} else if body := w.Body.String(); body != tt.err {
+			t.Errorf("%s: unexpected body: %q", tt.query, body)
+		}
+	}
+}
+
+// Ensure the handler converts a Prometheus remote read request into queries
+// and returns their series as samples.
+func TestHandler_PromRead(t *testing.T) {
+	h := NewHandler(false)
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+		if db != "foo" {
+			t.Fatalf("unexpected db: %s", db)
+		}
+		switch q.String() {
+		case `SELECT value FROM "foo"."bar".cpu WHERE time >= '1970-01-01 00:00:01' AND time <= '1970-01-01 00:00:02' AND host = 'serverA' GROUP BY *`:
+			return NewResultChan(&influxql.Result{Series: influxql.Rows{{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values:  [][]interface{}{{time.Unix(1, 0), 1.5}, {time.Unix(2, 0), int64(2)}},
+			}}}), nil
+		case `SELECT value FROM "foo"."bar".mem WHERE time >= '1970-01-01 00:00:01' AND time <= '1970-01-01 00:00:02' GROUP BY *`:
+			return NewResultChan(&influxql.Result{Err: errors.New("measurement not found: mem")}), nil
+		default:
+			t.Fatalf("unexpected query: %s", q)
+			return nil, nil
+		}
+	}
+
+	buf, err := proto.Marshal(&remote.ReadRequest{Queries: []*remote.Query{
+		{
+			StartTimestampMs: 1000,
+			EndTimestampMs:   2000,
+			Matchers: []*remote.LabelMatcher{
+				{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "cpu"},
+				{Type: remote.LabelMatcher_EQ, Name: "host", Value: "serverA"},
+			},
+		},
+		{
+			StartTimestampMs: 1000,
+			EndTimestampMs:   2000,
+			Matchers:         []*remote.LabelMatcher{{Type: remote.LabelMatcher_EQ, Name: "__name__", Value: "mem"}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/prom/read?db=foo&rp=bar", bytes.NewReader(snappy.Encode(nil, buf))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", w.Code, w.Body.String())
+	} else if w.Header().Get("Content-Encoding") != "snappy" {
+		t.Fatalf("unexpected content encoding: %s", w.Header().Get("Content-Encoding"))
+	}
+
+	data, err := snappy.Decode(nil, w.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp remote.ReadResponse
+	if err := proto.Unmarshal(data, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if exp := (remote.ReadResponse{Results: []*remote.QueryResult{
+		{Timeseries: []*remote.TimeSeries{{
+			Labels:  []*remote.LabelPair{{Name: "__name__", Value: "cpu"}, {Name: "host", Value: "serverA"}},
+			Samples: []*remote.Sample{{Value: 1.5, Timestamp: 1000}, {Value: 2, Timestamp: 2000}},
+		}}},
+		{},
+	}}); !reflect.DeepEqual(exp, resp) {
+		t.Fatalf("unexpected response: %s", resp.String())
+	}
+}
+
+// Ensure the handler rejects remote read requests it can't convert into queries.
+func TestHandler_PromRead_ErrInvalidQuery(t *testing.T) {
+	h := NewHandler(false)
+	buf, err := proto.Marshal(&remote.ReadRequest{Queries: []*remote.Query{
+		{Matchers: []*remote.LabelMatcher{{Type: remote.LabelMatcher_EQ, Name: "host", Value: "serverA"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, MustNewRequest("POST", "/api/v1/prom/read?db=foo", bytes.NewReader(snappy.Encode(nil, buf))))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := w.Body.String(); body != `{"error":"query requires an equality or regex matcher on __name__"}` {
+		t.Fatalf("unexpected body: %s", body)
+	}
+}
+
 // Ensure the handler returns a status 500 if an error is returned from the query executor.
 func TestHandler_Query_ErrExecuteQuery(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		return nil, errors.New("marker")
 	}
 
@@ -245,13 +797,15 @@ func TestHandler_Query_ErrExecuteQuery(t *testing.T) {
 	h.ServeHTTP(w, MustNewJSONRequest("GET", "/query?db=foo&q=SHOW+SERIES+FROM+bar", nil))
 	if w.Code != http.StatusInternalServerError {
 		t.Fatalf("unexpected status: %d", w.Code)
+	} else if body := w.Body.String(); body != `{"error":"error executing query: marker"}` {
+		t.Fatalf("unexpected body: %s", body)
 	}
 }
 
 // Ensure the handler returns a status 200 if an error is returned in the result.
 func TestHandler_Query_ErrResult(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		return NewResultChan(&influxql.Result{Err: errors.New("measurement not found")}), nil
 	}
 
@@ -267,7 +821,7 @@ func TestHandler_Query_ErrResult(t *testing.T) {
 // Ensure the handler returns a status 401 if an auth error is returned from the result.
 func TestHandler_Query_Result_ErrUnauthorized(t *testing.T) {
 	h := NewHandler(false)
-	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
+	h.QueryExecutor.ExecuteQueryFn = func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
 		return NewResultChan(&influxql.Result{Err: meta.NewAuthError("marker")}), nil
 	}
 
@@ -322,7 +876,7 @@ func TestNormalizeBatchPoints(t *testing.T) {
 				},
 			},
 			p: []tsdb.Point{
-				tsdb.NewPoint("cpu", map[string]string{"region": "useast"}, map[string]interface{}{"value": 1.0}, now),
+				tsdb.MustNewPoint("cpu", map[string]string{"region": "useast"}, map[string]interface{}{"value": 1.0}, now),
 			},
 		},
 		{
@@ -334,7 +888,7 @@ func TestNormalizeBatchPoints(t *testing.T) {
 				},
 			},
 			p: []tsdb.Point{
-				tsdb.NewPoint("cpu", map[string]string{"region": "useast"}, map[string]interface{}{"value": 1.0}, now),
+				tsdb.MustNewPoint("cpu", map[string]string{"region": "useast"}, map[string]interface{}{"value": 1.0}, now),
 			},
 		},
 		{
@@ -347,8 +901,8 @@ func TestNormalizeBatchPoints(t *testing.T) {
 				},
 			},
 			p: []tsdb.Point{
-				tsdb.NewPoint("cpu", map[string]string{"day": "monday", "region": "useast"}, map[string]interface{}{"value": 1.0}, now),
-				tsdb.NewPoint("memory", map[string]string{"day": "monday"}, map[string]interface{}{"value": 2.0}, now),
+				tsdb.MustNewPoint("cpu", map[string]string{"day": "monday", "region": "useast"}, map[string]interface{}{"value": 1.0}, now),
+				tsdb.MustNewPoint("memory", map[string]string{"day": "monday"}, map[string]interface{}{"value": 2.0}, now),
 			},
 		},
 	}
@@ -374,8 +928,11 @@ func TestNormalizeBatchPoints(t *testing.T) {
 // NewHandler represents a test wrapper for httpd.Handler.
 type Handler struct {
 	*httpd.Handler
-	MetaStore     HandlerMetaStore
-	QueryExecutor HandlerQueryExecutor
+	MetaStore         HandlerMetaStore
+	QueryExecutor     HandlerQueryExecutor
+	QueryAuthorizer   HandlerQueryAuthorizer
+	ContinuousQuerier HandlerContinuousQuerier
+	PointsWriter      HandlerPointsWriter
 }
 
 // NewHandler returns a new instance of Handler.
@@ -385,6 +942,9 @@ func NewHandler(requireAuthentication bool) *Handler {
 	}
 	h.Handler.MetaStore = &h.MetaStore
 	h.Handler.QueryExecutor = &h.QueryExecutor
+	h.Handler.QueryAuthorizer = &h.QueryAuthorizer
+	h.Handler.ContinuousQuerier = &h.ContinuousQuerier
+	h.Handler.PointsWriter = &h.PointsWriter
 	h.Handler.Version = "0.0.0"
 	return h
 }
@@ -410,11 +970,43 @@ func (s *HandlerMetaStore) Users() ([]meta.UserInfo, error) {
 
 // HandlerQueryExecutor is a mock implementation of Handler.QueryExecutor.
 type HandlerQueryExecutor struct {
-	ExecuteQueryFn func(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error)
+	ExecuteQueryFn func(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error)
+}
+
+func (e *HandlerQueryExecutor) ExecuteQuery(q *influxql.Query, db string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+	return e.ExecuteQueryFn(q, db, chunkSize, timeout, closing)
 }
 
-func (e *HandlerQueryExecutor) ExecuteQuery(q *influxql.Query, db string, chunkSize int) (<-chan *influxql.Result, error) {
-	return e.ExecuteQueryFn(q, db, chunkSize)
+// HandlerContinuousQuerier is a mock implementation of Handler.ContinuousQuerier.
+type HandlerContinuousQuerier struct {
+	RunFn      func(database, name string) error
+	BackfillFn func(database, name string, start, end time.Time) error
+}
+
+func (c *HandlerContinuousQuerier) Run(database, name string) error {
+	return c.RunFn(database, name)
+}
+
+func (c *HandlerContinuousQuerier) Backfill(database, name string, start, end time.Time) error {
+	return c.BackfillFn(database, name, start, end)
+}
+
+// HandlerPointsWriter is a mock implementation of Handler.PointsWriter.
+type HandlerPointsWriter struct {
+	WritePointsFn func(p *cluster.WritePointsRequest) error
+}
+
+func (w *HandlerPointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	return w.WritePointsFn(p)
+}
+
+// HandlerQueryAuthorizer is a mock implementation of Handler.QueryAuthorizer.
+type HandlerQueryAuthorizer struct {
+	AuthorizeQueryFn func(u *meta.UserInfo, stmt influxql.Statement, database string) error
+}
+
+func (a *HandlerQueryAuthorizer) AuthorizeQuery(u *meta.UserInfo, stmt influxql.Statement, database string) error {
+	return a.AuthorizeQueryFn(u, stmt, database)
 }
 
 // MustNewRequest returns a new HTTP request. Panic on error.
@@ -438,6 +1030,15 @@ func matchRegex(pattern, s string) bool {
 	return regexp.MustCompile(pattern).MatchString(s)
 }
 
+// CloseNotifyRecorder is a response recorder that reports the client closing
+// the connection when a value is sent on closed.
+type CloseNotifyRecorder struct {
+	*httptest.ResponseRecorder
+	closed chan bool
+}
+
+func (w *CloseNotifyRecorder) CloseNotify() <-chan bool { return w.closed }
+
 // NewResultChan returns a channel that sends all results and then closes.
 func NewResultChan(results ...*influxql.Result) <-chan *influxql.Result {
 	ch := make(chan *influxql.Result, len(results))
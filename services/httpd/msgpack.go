@@ -0,0 +1,288 @@
+package httpd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// MsgpackContentType is the media type of MessagePack encoded responses.
+const MsgpackContentType = "application/x-msgpack"
+
+// MarshalMsgpack encodes a Response into MessagePack. The document has the
+// same layout as the JSON encoding but integer values stay integers and float
+// values stay floats, which JSON can't tell apart.
+func (r Response) MarshalMsgpack() []byte {
+	var e msgpackEncoder
+
+	n := 0
+	if len(r.Results) > 0 {
+		n++
+	}
+	if r.Err != nil {
+		n++
+	}
+	if r.NextCursor != "" {
+		n++
+	}
+	e.writeMapLen(n)
+
+	if len(r.Results) > 0 {
+		e.writeString("results")
+		e.writeArrayLen(len(r.Results))
+		for _, result := range r.Results {
+			e.writeResult(result)
+		}
+	}
+	if r.Err != nil {
+		e.writeString("error")
+		e.writeString(r.Err.Error())
+	}
+	if r.NextCursor != "" {
+		e.writeString("next_cursor")
+		e.writeString(r.NextCursor)
+	}
+	return e.buf
+}
+
+// msgpackEncoder appends MessagePack encoded values to a buffer.
+type msgpackEncoder struct {
+	buf []byte
+}
+
+// writeResult writes a result as a map of its series and error.
+func (e *msgpackEncoder) writeResult(r *influxql.Result) {
+	n := 0
+	if len(r.Series) > 0 {
+		n++
+	}
+	if r.Err != nil {
+		n++
+	}
+	e.writeMapLen(n)
+
+	if len(r.Series) > 0 {
+		e.writeString("series")
+		e.writeArrayLen(len(r.Series))
+		for _, row := range r.Series {
+			e.writeRow(row)
+		}
+	}
+	if r.Err != nil {
+		e.writeString("error")
+		e.writeString(r.Err.Error())
+	}
+}
+
+// writeRow writes a row as a map, omitting empty fields like its JSON encoding.
+func (e *msgpackEncoder) writeRow(row *influxql.Row) {
+	n := 0
+	for _, ok := range []bool{row.Name != "", len(row.Tags) > 0, len(row.Columns) > 0, len(row.Values) > 0, row.Err != nil} {
+		if ok {
+			n++
+		}
+	}
+	e.writeMapLen(n)
+
+	if row.Name != "" {
+		e.writeString("name")
+		e.writeString(row.Name)
+	}
+	if len(row.Tags) > 0 {
+		keys := make([]string, 0, len(row.Tags))
+		for k := range row.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		e.writeString("tags")
+		e.writeMapLen(len(keys))
+		for _, k := range keys {
+			e.writeString(k)
+			e.writeString(row.Tags[k])
+		}
+	}
+	if len(row.Columns) > 0 {
+		e.writeString("columns")
+		e.writeArrayLen(len(row.Columns))
+		for _, c := range row.Columns {
+			e.writeString(c)
+		}
+	}
+	if len(row.Values) > 0 {
+		e.writeString("values")
+		e.writeArrayLen(len(row.Values))
+		for _, values := range row.Values {
+			e.writeValue(values)
+		}
+	}
+	if row.Err != nil {
+		e.writeString("err")
+		e.writeString(row.Err.Error())
+	}
+}
+
+// writeValue writes a value of a row. Times are written as RFC3339 strings
+// as in JSON. Types without a MessagePack equivalent are written as strings.
+func (e *msgpackEncoder) writeValue(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if v {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case int:
+		e.writeInt(int64(v))
+	case int32:
+		e.writeInt(int64(v))
+	case int64:
+		e.writeInt(v)
+	case uint32:
+		e.writeUint(uint64(v))
+	case uint64:
+		e.writeUint(v)
+	case float32:
+		e.writeFloat(float64(v))
+	case float64:
+		e.writeFloat(v)
+	case string:
+		e.writeString(v)
+	case time.Time:
+		e.writeString(v.Format(time.RFC3339Nano))
+	case []interface{}:
+		e.writeArrayLen(len(v))
+		for _, elem := range v {
+			e.writeValue(elem)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		e.writeMapLen(len(keys))
+		for _, k := range keys {
+			e.writeString(k)
+			e.writeValue(v[k])
+		}
+	default:
+		e.writeString(fmt.Sprint(v))
+	}
+}
+
+// writeInt writes an integer in its most compact encoding.
+func (e *msgpackEncoder) writeInt(v int64) {
+	switch {
+	case v >= 0:
+		e.writeUint(uint64(v))
+	case v >= -32:
+		e.buf = append(e.buf, byte(v))
+	case v >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		e.buf = append(e.buf, 0xd1)
+		e.buf = appendUint16(e.buf, uint16(v))
+	case v >= math.MinInt32:
+		e.buf = append(e.buf, 0xd2)
+		e.buf = appendUint32(e.buf, uint32(v))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = appendUint64(e.buf, uint64(v))
+	}
+}
+
+// writeUint writes an unsigned integer in its most compact encoding.
+func (e *msgpackEncoder) writeUint(v uint64) {
+	switch {
+	case v <= math.MaxInt8:
+		e.buf = append(e.buf, byte(v))
+	case v <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd)
+		e.buf = appendUint16(e.buf, uint16(v))
+	case v <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = appendUint32(e.buf, uint32(v))
+	default:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = appendUint64(e.buf, v)
+	}
+}
+
+// writeFloat writes a float as a 64-bit float, even if it has no fraction.
+func (e *msgpackEncoder) writeFloat(v float64) {
+	e.buf = append(e.buf, 0xcb)
+	e.buf = appendUint64(e.buf, math.Float64bits(v))
+}
+
+// writeString writes a UTF-8 string.
+func (e *msgpackEncoder) writeString(s string) {
+	switch n := len(s); {
+	case n <= 31:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+// writeArrayLen writes the header of an array of n elements.
+func (e *msgpackEncoder) writeArrayLen(n int) {
+	switch {
+	case n <= 15:
+		e.buf = append(e.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xdc)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdd)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+}
+
+// writeMapLen writes the header of a map of n entries.
+func (e *msgpackEncoder) writeMapLen(n int) {
+	switch {
+	case n <= 15:
+		e.buf = append(e.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = append(e.buf, 0xde)
+		e.buf = appendUint16(e.buf, uint16(n))
+	default:
+		e.buf = append(e.buf, 0xdf)
+		e.buf = appendUint32(e.buf, uint32(n))
+	}
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var a [2]byte
+	binary.BigEndian.PutUint16(a[:], v)
+	return append(b, a[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var a [4]byte
+	binary.BigEndian.PutUint32(a[:], v)
+	return append(b, a[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var a [8]byte
+	binary.BigEndian.PutUint64(a[:], v)
+	return append(b, a[:]...)
+}
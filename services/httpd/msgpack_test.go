@@ -0,0 +1,74 @@
+package httpd_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/services/httpd"
+)
+
+// Ensure a response is encoded into MessagePack keeping integer and float types.
+func TestResponse_MarshalMsgpack(t *testing.T) {
+	resp := httpd.Response{Results: []*influxql.Result{{
+		Series: influxql.Rows{{
+			Name:    "cpu",
+			Tags:    map[string]string{"host": "A"},
+			Columns: []string{"time", "value"},
+			Values: [][]interface{}{
+				{time.Unix(0, 0).UTC(), 2.0},
+				{int64(-1), int64(200)},
+				{int64(-200), nil},
+				{true, "x"},
+			},
+		}},
+		Err: errors.New("marker"),
+	}}}
+
+	var exp []byte
+	exp = append(exp, 0x81, 0xa7)
+	exp = append(exp, "results"...)
+	exp = append(exp, 0x91, 0x82, 0xa6)
+	exp = append(exp, "series"...)
+	exp = append(exp, 0x91, 0x84, 0xa4)
+	exp = append(exp, "name"...)
+	exp = append(exp, 0xa3)
+	exp = append(exp, "cpu"...)
+	exp = append(exp, 0xa4)
+	exp = append(exp, "tags"...)
+	exp = append(exp, 0x81, 0xa4)
+	exp = append(exp, "host"...)
+	exp = append(exp, 0xa1, 'A', 0xa7)
+	exp = append(exp, "columns"...)
+	exp = append(exp, 0x92, 0xa4)
+	exp = append(exp, "time"...)
+	exp = append(exp, 0xa5)
+	exp = append(exp, "value"...)
+	exp = append(exp, 0xa6)
+	exp = append(exp, "values"...)
+	exp = append(exp, 0x94)
+	exp = append(exp, 0x92, 0xb4)
+	exp = append(exp, "1970-01-01T00:00:00Z"...)
+	exp = append(exp, 0xcb, 0x40, 0, 0, 0, 0, 0, 0, 0)
+	exp = append(exp, 0x92, 0xff, 0xcc, 0xc8)
+	exp = append(exp, 0x92, 0xd1, 0xff, 0x38, 0xc0)
+	exp = append(exp, 0x92, 0xc3, 0xa1, 'x')
+	exp = append(exp, 0xa5)
+	exp = append(exp, "error"...)
+	exp = append(exp, 0xa6)
+	exp = append(exp, "marker"...)
+
+	if b := resp.MarshalMsgpack(); !bytes.Equal(b, exp) {
+		t.Fatalf("unexpected encoding:\n\nexp=%x\n\ngot=%x\n\n", exp, b)
+	}
+}
+
+// Ensure an error response is encoded into MessagePack.
+func TestResponse_MarshalMsgpack_Err(t *testing.T) {
+	b := httpd.Response{Err: errors.New("marker")}.MarshalMsgpack()
+	if exp := append([]byte{0x81, 0xa5, 'e', 'r', 'r', 'o', 'r', 0xa6}, "marker"...); !bytes.Equal(b, exp) {
+		t.Fatalf("unexpected encoding: %x", b)
+	}
+}
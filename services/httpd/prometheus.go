@@ -0,0 +1,111 @@
+package httpd
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/prometheus"
+	"github.com/influxdb/influxdb/prometheus/remote"
+	"github.com/influxdb/influxdb/tsdb"
+)
+
+// servePromRead serves a Prometheus remote read request. The queries of the
+// snappy compressed request are converted into SELECT statements against the
+// database and retention policy given by the db and rp parameters and the
+// samples of the matching series are returned in the same format.
+func (h *Handler) servePromRead(w http.ResponseWriter, r *http.Request, user *meta.UserInfo) {
+	compressed, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpError(w, err.Error(), false, http.StatusBadRequest)
+		return
+	}
+	buf, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		httpError(w, "error decoding request: "+err.Error(), false, http.StatusBadRequest)
+		return
+	}
+	var req remote.ReadRequest
+	if err := proto.Unmarshal(buf, &req); err != nil {
+		httpError(w, "error decoding request: "+err.Error(), false, http.StatusBadRequest)
+		return
+	}
+
+	db := r.URL.Query().Get("db")
+	if db == "" {
+		httpError(w, "database is required", false, http.StatusBadRequest)
+		return
+	}
+
+	query, err := prometheus.ReadRequestToInfluxQLQuery(&req, db, r.URL.Query().Get("rp"))
+	if err != nil {
+		httpError(w, err.Error(), false, http.StatusBadRequest)
+		return
+	}
+
+	if h.requireAuthentication && h.QueryAuthorizer != nil && !authorizeDatabases(user, query, db) {
+		for _, stmt := range query.Statements {
+			if err := h.QueryAuthorizer.AuthorizeQuery(user, stmt, db); err != nil {
+				httpError(w, "error authorizing query: "+err.Error(), false, http.StatusUnauthorized)
+				return
+			}
+		}
+	}
+
+	// Each statement is executed on its own so metrics that don't exist
+	// return no series instead of failing the other queries.
+	resp := &remote.ReadResponse{Results: make([]*remote.QueryResult, len(query.Statements))}
+	for i, stmt := range query.Statements {
+		timeseries, err := h.executePromQuery(stmt, db)
+		if _, ok := err.(meta.AuthError); ok {
+			httpError(w, err.Error(), false, http.StatusUnauthorized)
+			return
+		} else if err != nil {
+			httpError(w, err.Error(), false, http.StatusInternalServerError)
+			return
+		}
+		resp.Results[i] = &remote.QueryResult{Timeseries: timeseries}
+	}
+
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		httpError(w, err.Error(), false, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.Header().Set("Content-Encoding", "snappy")
+	w.Write(snappy.Encode(nil, data))
+}
+
+// executePromQuery executes a statement of a remote read request and returns
+// the time series of its results.
+func (h *Handler) executePromQuery(stmt influxql.Statement, db string) ([]*remote.TimeSeries, error) {
+	// Stop the execution if the results aren't all read.
+	closing := make(chan struct{})
+	defer close(closing)
+
+	results, err := h.QueryExecutor.ExecuteQuery(&influxql.Query{Statements: influxql.Statements{stmt}}, db, 0, 0, closing)
+	if err != nil {
+		return nil, err
+	}
+
+	var a []*remote.TimeSeries
+	for r := range results {
+		if tsdb.IsMeasurementNotFound(r.Err) {
+			continue
+		} else if r.Err != nil {
+			return nil, r.Err
+		}
+
+		timeseries, err := prometheus.RowsToTimeSeries(r.Series)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, timeseries...)
+	}
+	return a, nil
+}
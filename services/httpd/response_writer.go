@@ -0,0 +1,107 @@
+package httpd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// responseStreamer writes the results of a query as a single JSON response
+// while they're read, instead of holding every result in memory first. As in
+// a buffered Response, results of the same statement are combined into one
+// and only the error of its first result is kept.
+type responseStreamer struct {
+	w   io.Writer
+	err error
+
+	started     bool // results array opened
+	open        bool // result of a statement opened
+	seriesOpen  bool // series array of the result opened
+	statementID int
+	resultErr   error
+}
+
+// newResponseStreamer returns a streamer writing to w.
+func newResponseStreamer(w io.Writer) *responseStreamer {
+	return &responseStreamer{w: w}
+}
+
+// Write writes the series of a result. The result is only closed once a
+// result of another statement is written or the response is closed.
+func (s *responseStreamer) Write(r *influxql.Result) error {
+	if !s.started {
+		s.write([]byte(`{"results":[`))
+		s.started = true
+	}
+
+	if !s.open || r.StatementID != s.statementID {
+		if s.open {
+			s.closeResult()
+			s.write([]byte(","))
+		}
+		s.write([]byte("{"))
+		s.open, s.statementID, s.resultErr = true, r.StatementID, r.Err
+	}
+
+	for _, row := range r.Series {
+		if !s.seriesOpen {
+			s.write([]byte(`"series":[`))
+			s.seriesOpen = true
+		} else {
+			s.write([]byte(","))
+		}
+		s.writeJSON(row)
+	}
+
+	if f, ok := s.w.(http.Flusher); ok && s.err == nil {
+		f.Flush()
+	}
+	return s.err
+}
+
+// Close ends the response.
+func (s *responseStreamer) Close() error {
+	if !s.started {
+		s.write([]byte("{}"))
+		return s.err
+	}
+	if s.open {
+		s.closeResult()
+	}
+	s.write([]byte("]}"))
+	return s.err
+}
+
+// closeResult ends the result of the current statement.
+func (s *responseStreamer) closeResult() {
+	if s.seriesOpen {
+		s.write([]byte("]"))
+	}
+	if s.resultErr != nil {
+		if s.seriesOpen {
+			s.write([]byte(","))
+		}
+		s.write([]byte(`"error":`))
+		s.writeJSON(s.resultErr.Error())
+	}
+	s.write([]byte("}"))
+	s.open, s.seriesOpen, s.resultErr = false, false, nil
+}
+
+// writeJSON writes the JSON encoding of v.
+func (s *responseStreamer) writeJSON(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b = []byte(err.Error())
+	}
+	s.write(b)
+}
+
+// write writes b unless a previous write failed.
+func (s *responseStreamer) write(b []byte) {
+	if s.err == nil {
+		_, s.err = s.w.Write(b)
+	}
+}
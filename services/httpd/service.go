@@ -1,6 +1,7 @@
 package httpd
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
@@ -11,9 +12,10 @@ import (
 
 // Service manages the listener and handler for an HTTP endpoint.
 type Service struct {
-	ln   net.Listener
-	addr string
-	err  chan error
+	ln     net.Listener
+	addr   string
+	config Config
+	err    chan error
 
 	Handler *Handler
 
@@ -23,8 +25,9 @@ type Service struct {
 // NewService returns a new instance of Service.
 func NewService(c Config) *Service {
 	s := &Service{
-		addr: c.BindAddress,
-		err:  make(chan error),
+		addr:   c.BindAddress,
+		config: c,
+		err:    make(chan error),
 		Handler: NewHandler(
 			c.AuthEnabled,
 			c.LogEnabled,
@@ -43,9 +46,20 @@ func (s *Service) Open() error {
 	if err != nil {
 		return err
 	}
-	s.ln = ln
 
-	s.Logger.Println("listening on HTTP:", ln.Addr().String())
+	// Serve HTTPS if enabled.
+	if s.config.HttpsEnabled {
+		config, err := s.config.TLSConfig()
+		if err != nil {
+			ln.Close()
+			return err
+		}
+		s.ln = tls.NewListener(ln, config)
+		s.Logger.Println("listening on HTTPS:", ln.Addr().String())
+	} else {
+		s.ln = ln
+		s.Logger.Println("listening on HTTP:", ln.Addr().String())
+	}
 
 	// Begin listening for requests in a separate goroutine.
 	go s.serve()
@@ -64,7 +64,7 @@ func pointsFromStats(st *Stats, tags map[string]string) []tsdb.Point {
 	var points []tsdb.Point
 	now := time.Now()
 	st.Walk(func(k string, v int64) {
-		point := tsdb.NewPoint(
+		point := tsdb.MustNewPoint(
 			st.name+"_"+k,
 			make(map[string]string),
 			map[string]interface{}{"value": int(v)},
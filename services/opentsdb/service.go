@@ -232,7 +232,11 @@ func (s *Service) handleTelnetConn(conn net.Conn) {
 			continue
 		}
 
-		p := tsdb.NewPoint(measurement, tags, fields, t)
+		p, err := tsdb.NewPoint(measurement, tags, fields, t)
+		if err != nil {
+			s.Logger.Println("TSDBServer: invalid point: ", err)
+			continue
+		}
 		if err := s.PointsWriter.WritePoints(&cluster.WritePointsRequest{
 			Database:         s.Database,
 			RetentionPolicy:  s.RetentionPolicy,
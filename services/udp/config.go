@@ -2,6 +2,11 @@ package udp
 
 import "github.com/influxdb/influxdb/toml"
 
+const (
+	// DefaultQueueSize is the default number of datagrams waiting to be parsed.
+	DefaultQueueSize = 1000
+)
+
 type Config struct {
 	Enabled     bool   `toml:"enabled"`
 	BindAddress string `toml:"bind-address"`
@@ -9,4 +14,18 @@ type Config struct {
 	Database     string        `toml:"database"`
 	BatchSize    int           `toml:"batch-size"`
 	BatchTimeout toml.Duration `toml:"batch-timeout"`
+
+	// Maximum number of datagrams waiting to be parsed. Datagrams received
+	// while the queue is full are dropped.
+	QueueSize int `toml:"queue-size"`
+}
+
+// WithDefaults takes the given config and returns a new config with any required
+// default values set.
+func (c *Config) WithDefaults() *Config {
+	d := *c
+	if d.QueueSize <= 0 {
+		d.QueueSize = DefaultQueueSize
+	}
+	return &d
 }
@@ -17,6 +17,7 @@ bind-address = ":4444"
 database = "awesomedb"
 batch-size = 100
 batch-timeout = "10ms"
+queue-size = 50
 `, &c); err != nil {
 		t.Fatal(err)
 	}
@@ -32,5 +33,7 @@ batch-timeout = "10ms"
 		t.Fatalf("unexpected batch size: %d", c.BatchSize)
 	} else if time.Duration(c.BatchTimeout) != (10 * time.Millisecond) {
 		t.Fatalf("unexpected batch timeout: %v", c.BatchTimeout)
+	} else if c.QueueSize != 50 {
+		t.Fatalf("unexpected queue size: %d", c.QueueSize)
 	}
 }
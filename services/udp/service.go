@@ -6,9 +6,11 @@ import (
 	"net"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/tsdb"
 )
 
@@ -27,8 +29,10 @@ type Service struct {
 	wg   sync.WaitGroup
 	done chan struct{}
 
+	packets chan []byte
 	batcher *tsdb.PointBatcher
 	config  Config
+	stats   Statistics
 
 	PointsWriter interface {
 		WritePoints(p *cluster.WritePointsRequest) error
@@ -37,11 +41,24 @@ type Service struct {
 	Logger *log.Logger
 }
 
+// Statistics are the counters of a Service. Datagrams are dropped rather than
+// slowing down the reads from the socket when parsing can't keep up.
+type Statistics struct {
+	PacketsReceived uint64 // Datagrams read from the socket.
+	PacketsDropped  uint64 // Datagrams dropped because the queue was full.
+	PointsReceived  uint64 // Points parsed from datagrams.
+	PointsInvalid   uint64 // Lines of datagrams that couldn't be parsed.
+	PointsWritten   uint64 // Points of batches written.
+	PointsDropped   uint64 // Points of batches that failed to write.
+}
+
 func NewService(c Config) *Service {
+	d := c.WithDefaults()
 	return &Service{
-		config:  c,
+		config:  *d,
 		done:    make(chan struct{}),
-		batcher: tsdb.NewPointBatcher(c.BatchSize, time.Duration(c.BatchTimeout)),
+		packets: make(chan []byte, d.QueueSize),
+		batcher: tsdb.NewPointBatcher(d.BatchSize, time.Duration(d.BatchTimeout)),
 		Logger:  log.New(os.Stderr, "[udp] ", log.LstdFlags),
 	}
 }
@@ -68,8 +85,9 @@ func (s *Service) Open() (err error) {
 
 	s.Logger.Printf("Started listening on %s", s.config.BindAddress)
 
-	s.wg.Add(2)
+	s.wg.Add(3)
 	go s.serve()
+	go s.parse()
 	go s.writePoints()
 
 	return nil
@@ -88,7 +106,10 @@ func (s *Service) writePoints() {
 				Points:           batch,
 			})
 			if err != nil {
+				atomic.AddUint64(&s.stats.PointsDropped, uint64(len(batch)))
 				s.Logger.Printf("Failed to write points batch to database %s: %s", s.config.Database, err)
+			} else {
+				atomic.AddUint64(&s.stats.PointsWritten, uint64(len(batch)))
 			}
 
 		case <-s.done:
@@ -101,9 +122,8 @@ func (s *Service) serve() {
 	defer s.wg.Done()
 
 	s.batcher.Start()
+	buf := make([]byte, UDPBufferSize)
 	for {
-		buf := make([]byte, UDPBufferSize)
-
 		select {
 		case <-s.done:
 			// We closed the connection, time to go.
@@ -117,15 +137,46 @@ func (s *Service) serve() {
 			s.Logger.Printf("Failed to read UDP message: %s", err)
 			continue
 		}
+		atomic.AddUint64(&s.stats.PacketsReceived, 1)
 
-		points, err := tsdb.ParsePoints(buf[:n])
-		if err != nil {
-			s.Logger.Printf("Failed to parse points: %s", err)
-			continue
+		// Queue a copy of the datagram for parsing unless the queue is full,
+		// so the read buffer can be reused and only n bytes stay queued.
+		packet := make([]byte, n)
+		copy(packet, buf[:n])
+		select {
+		case s.packets <- packet:
+		default:
+			atomic.AddUint64(&s.stats.PacketsDropped, 1)
 		}
+	}
+}
+
+// parse parses queued datagrams and sends their points to the batcher. Lines
+// that can't be parsed are skipped without dropping the rest of the datagram.
+func (s *Service) parse() {
+	defer s.wg.Done()
+
+	for {
+		var buf []byte
+		select {
+		case buf = <-s.packets:
+		case <-s.done:
+			return
+		}
+
+		points, errs := tsdb.ParsePointsPartial(buf, time.Now().UTC(), "n")
+		if len(errs) > 0 {
+			atomic.AddUint64(&s.stats.PointsInvalid, uint64(len(errs)))
+			s.Logger.Printf("Failed to parse points: %s", errs[0])
+		}
+		atomic.AddUint64(&s.stats.PointsReceived, uint64(len(points)))
 
 		for _, point := range points {
-			s.batcher.In() <- point
+			select {
+			case s.batcher.In() <- point:
+			case <-s.done:
+				return
+			}
 		}
 	}
 }
@@ -154,6 +205,36 @@ func (s *Service) SetLogger(l *log.Logger) {
 	s.Logger = l
 }
 
+// Addr returns the address the service listens on.
 func (s *Service) Addr() net.Addr {
+	if s.conn != nil {
+		return s.conn.LocalAddr()
+	}
 	return s.addr
 }
+
+// Stats returns the counters of the service. While each counter should be
+// closely correlated with the others, it is not guaranteed.
+func (s *Service) Stats() *Statistics {
+	return &Statistics{
+		PacketsReceived: atomic.LoadUint64(&s.stats.PacketsReceived),
+		PacketsDropped:  atomic.LoadUint64(&s.stats.PacketsDropped),
+		PointsReceived:  atomic.LoadUint64(&s.stats.PointsReceived),
+		PointsInvalid:   atomic.LoadUint64(&s.stats.PointsInvalid),
+		PointsWritten:   atomic.LoadUint64(&s.stats.PointsWritten),
+		PointsDropped:   atomic.LoadUint64(&s.stats.PointsDropped),
+	}
+}
+
+// StatsRow returns the counters of the service as a row for SHOW STATS.
+func (s *Service) StatsRow() *influxql.Row {
+	st := s.Stats()
+	return &influxql.Row{
+		Name:    "udp",
+		Columns: []string{"packets_received", "packets_dropped", "points_received", "points_invalid", "points_written", "points_dropped"},
+		Values: [][]interface{}{{
+			int64(st.PacketsReceived), int64(st.PacketsDropped), int64(st.PointsReceived),
+			int64(st.PointsInvalid), int64(st.PointsWritten), int64(st.PointsDropped),
+		}},
+	}
+}
@@ -0,0 +1,125 @@
+package udp_test
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdb/influxdb/cluster"
+	"github.com/influxdb/influxdb/services/udp"
+	"github.com/influxdb/influxdb/toml"
+)
+
+// Ensure the service writes the valid points of datagrams in batches and
+// counts the lines it couldn't parse.
+func TestService_WritePoints(t *testing.T) {
+	t.Parallel()
+
+	s := udp.NewService(udp.Config{
+		BindAddress:  "127.0.0.1:0",
+		Database:     "udpdb",
+		BatchSize:    2,
+		BatchTimeout: toml.Duration(time.Second),
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.PointsWriter = &PointsWriter{
+		WritePointsFn: func(req *cluster.WritePointsRequest) error {
+			defer wg.Done()
+			if req.Database != "udpdb" {
+				t.Errorf("unexpected database: %s", req.Database)
+			} else if len(req.Points) != 2 {
+				t.Errorf("unexpected points: %v", req.Points)
+			} else if req.Points[0].String() != "cpu value=1 1000000000" || req.Points[1].String() != "cpu value=3 3000000000" {
+				t.Errorf("unexpected points: %v", req.Points)
+			}
+			return nil
+		},
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	conn, err := net.Dial("udp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("cpu value=1 1000000000\ncpu value= 2000000000\ncpu value=3 3000000000\n")); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	// The counters are updated after the batch is written.
+	var st *udp.Statistics
+	for i := 0; i < 100; i++ {
+		if st = s.Stats(); st.PointsWritten == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if st.PacketsReceived != 1 || st.PacketsDropped != 0 {
+		t.Fatalf("unexpected packet counts: %#v", st)
+	} else if st.PointsReceived != 2 || st.PointsInvalid != 1 || st.PointsWritten != 2 || st.PointsDropped != 0 {
+		t.Fatalf("unexpected point counts: %#v", st)
+	}
+
+	row := s.StatsRow()
+	if row.Name != "udp" || len(row.Values) != 1 || len(row.Values[0]) != len(row.Columns) {
+		t.Fatalf("unexpected row: %#v", row)
+	}
+}
+
+// Ensure datagrams queued for parsing aren't overwritten by the next read.
+func TestService_WritePoints_Datagrams(t *testing.T) {
+	t.Parallel()
+
+	s := udp.NewService(udp.Config{
+		BindAddress:  "127.0.0.1:0",
+		Database:     "udpdb",
+		BatchSize:    2,
+		BatchTimeout: toml.Duration(time.Second),
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.PointsWriter = &PointsWriter{
+		WritePointsFn: func(req *cluster.WritePointsRequest) error {
+			defer wg.Done()
+			if len(req.Points) != 2 || req.Points[0].String() != "cpu value=1 1000000000" || req.Points[1].String() != "mem value=2 2000000000" {
+				t.Errorf("unexpected points: %v", req.Points)
+			}
+			return nil
+		},
+	}
+
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	conn, err := net.Dial("udp", s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, b := range []string{"cpu value=1 1000000000\n", "mem value=2 2000000000\n"} {
+		if _, err := conn.Write([]byte(b)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
+
+// PointsWriter represents a mock impl of PointsWriter.
+type PointsWriter struct {
+	WritePointsFn func(*cluster.WritePointsRequest) error
+}
+
+func (w *PointsWriter) WritePoints(p *cluster.WritePointsRequest) error {
+	return w.WritePointsFn(p)
+}
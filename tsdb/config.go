@@ -36,6 +36,35 @@ type Config struct {
 	RetentionCheckEnabled bool          `toml:"retention-check-enabled"`
 	RetentionCheckPeriod  toml.Duration `toml:"retention-check-period"`
 	RetentionCreatePeriod toml.Duration `toml:"retention-create-period"`
+
+	// Limits on the estimated cost of SELECT statements. Zero means no limit.
+	MaxSelectSeriesN int `toml:"max-select-series"`
+	MaxSelectGroupN  int `toml:"max-select-groups"`
+	MaxSelectBucketN int `toml:"max-select-buckets"`
+	MaxSelectPointN  int `toml:"max-select-points"`
+
+	// Bytes of points a query may sort in memory before spilling to disk.
+	// Zero means no limit.
+	MaxSortMemory int    `toml:"max-sort-memory"`
+	SortTempDir   string `toml:"sort-temp-dir"`
+
+	// Execute SELECT statements with the iterators of the query package.
+	IteratorEngine bool `toml:"iterator-engine"`
+
+	// Maximum time a query may run before it's aborted. Zero means no limit.
+	QueryTimeout toml.Duration `toml:"query-timeout"`
+
+	// Number of SELECT statements whose resolved sources and wildcards are
+	// cached. Zero disables the cache.
+	PlanCacheSize int `toml:"plan-cache-size"`
+
+	// Writes to the same shard are coalesced into batches of up to this many
+	// points, waiting up to the timeout for other writes. Zero disables it.
+	WriteBatchSize    int           `toml:"write-batch-size"`
+	WriteBatchTimeout toml.Duration `toml:"write-batch-timeout"`
+
+	// Points waiting to be coalesced before writes are rejected. Zero means no limit.
+	WriteBufferMaxPoints int `toml:"write-buffer-max-points"`
 }
 
 func NewConfig() Config {
@@ -46,6 +75,8 @@ func NewConfig() Config {
 		RetentionCheckEnabled: DefaultRetentionCheckEnabled,
 		RetentionCheckPeriod:  toml.Duration(DefaultRetentionCheckPeriod),
 		RetentionCreatePeriod: toml.Duration(DefaultRetentionCreatePeriod),
+		PlanCacheSize:         DefaultPlanCacheSize,
+		WriteBatchTimeout:     toml.Duration(DefaultWriteBatchTimeout),
 	}
 }
 
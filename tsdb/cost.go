@@ -0,0 +1,338 @@
+package tsdb
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+)
+
+// maxIndexSelectivity is the largest fraction of a measurement's series that
+// a condition can match for the series to be looked up in the index. Above it
+// every series is scanned and the condition is applied to each point instead.
+const maxIndexSelectivity = 0.5
+
+// QueryCost represents the estimated cost of executing a SELECT statement.
+type QueryCost struct {
+	Sources []*SourceCost
+
+	// Totals across all sources.
+	ShardN  int
+	SeriesN int
+	GroupN  int
+	PointN  int
+
+	// Number of GROUP BY time intervals in the time range of the statement.
+	// Zero if the statement isn't grouped by time.
+	BucketN int
+}
+
+// SourceCost represents the estimated cost of reading a single measurement.
+type SourceCost struct {
+	Database        string
+	RetentionPolicy string
+	Measurement     string
+
+	// Shards overlapping the time range of the statement.
+	ShardIDs []uint64
+
+	// Estimated number of series matching the condition and the number of
+	// series they're grouped into by the dimensions of the statement.
+	SeriesN int
+	GroupN  int
+
+	// Estimated number of points read from the shards for the matching
+	// series within the time range of the statement.
+	PointN int
+
+	// True if matching series are looked up in the index instead of scanning
+	// every series of the measurement.
+	IndexDriven bool
+}
+
+// Access returns "index" for index-driven execution and "scan" otherwise.
+func (c *SourceCost) Access() string {
+	if c.IndexDriven {
+		return "index"
+	}
+	return "scan"
+}
+
+// EstimateCost returns the estimated cost of executing a SELECT statement. The
+// estimate is computed from the series and tag value counts in the index and
+// the number of points stored in each shard so no points are read. The sources
+// of the statement must already be expanded.
+func (q *QueryExecutor) EstimateCost(stmt *influxql.SelectStatement) (*QueryCost, error) {
+	// Only the time portion of the condition is used to select shards.
+	timeCond, cond, err := influxql.ConditionExpr(stmt.Condition)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := stmt.GroupByInterval()
+	if err != nil {
+		return nil, err
+	}
+
+	var dimensions []string
+	for _, d := range stmt.Dimensions {
+		if ref, ok := d.Expr.(*influxql.VarRef); ok {
+			dimensions = append(dimensions, ref.Val)
+		}
+	}
+
+	cost := &QueryCost{}
+	now := time.Now()
+	for _, src := range stmt.Sources {
+		mm, ok := src.(*influxql.Measurement)
+		if !ok {
+			return nil, fmt.Errorf("invalid source type: %#v", src)
+		}
+
+		rp, err := q.MetaStore.RetentionPolicy(mm.Database, mm.RetentionPolicy)
+		if err != nil {
+			return nil, err
+		} else if rp == nil {
+			return nil, meta.ErrRetentionPolicyNotFound
+		}
+
+		c := &SourceCost{
+			Database:        mm.Database,
+			RetentionPolicy: rp.Name,
+			Measurement:     mm.Name,
+			ShardIDs:        []uint64{},
+		}
+		groups := shardGroupsByCondition(rp, timeCond, now)
+		for _, g := range groups {
+			for _, sh := range g.Shards {
+				c.ShardIDs = append(c.ShardIDs, sh.ID)
+			}
+		}
+
+		// An open time range is bounded by the shards holding the data.
+		tmin, tmax := influxql.TimeRange(timeCond)
+		if tmax.IsZero() || tmax.After(now) {
+			tmax = now
+		}
+		if tmin.IsZero() {
+			tmin = tmax
+			for _, g := range groups {
+				if g.StartTime.Before(tmin) {
+					tmin = g.StartTime
+				}
+			}
+		}
+
+		if m := q.store.Measurement(mm.Database, mm.Name); m != nil {
+			c.SeriesN, c.GroupN, c.IndexDriven = m.estimate(cond, dimensions)
+			if c.SeriesN > 0 {
+				if c.PointN, err = q.estimatePointN(m, c.SeriesN, groups, tmin, tmax, now); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		cost.Sources = append(cost.Sources, c)
+		cost.ShardN += len(c.ShardIDs)
+		cost.SeriesN += c.SeriesN
+		cost.GroupN += c.GroupN
+		cost.PointN += c.PointN
+		if n := bucketN(tmin, tmax, interval); n > cost.BucketN {
+			cost.BucketN = n
+		}
+	}
+
+	return cost, nil
+}
+
+// checkCost returns an error if the cost exceeds the executor's limits.
+func (q *QueryExecutor) checkCost(cost *QueryCost) error {
+	if q.MaxSelectSeriesN > 0 && cost.SeriesN > q.MaxSelectSeriesN {
+		return fmt.Errorf("max select series limit exceeded: estimated %d series, limit %d", cost.SeriesN, q.MaxSelectSeriesN)
+	}
+	if q.MaxSelectGroupN > 0 && cost.GroupN > q.MaxSelectGroupN {
+		return fmt.Errorf("max select group limit exceeded: estimated %d groups, limit %d", cost.GroupN, q.MaxSelectGroupN)
+	}
+	if q.MaxSelectBucketN > 0 && cost.BucketN > q.MaxSelectBucketN {
+		return fmt.Errorf("max select bucket limit exceeded: %d GROUP BY time buckets, limit %d", cost.BucketN, q.MaxSelectBucketN)
+	}
+	if q.MaxSelectPointN > 0 && cost.PointN > q.MaxSelectPointN {
+		return fmt.Errorf("max select point limit exceeded: estimated %d points, limit %d", cost.PointN, q.MaxSelectPointN)
+	}
+	return nil
+}
+
+// hasCostLimits returns true if any limit on the cost of a statement is set.
+func (q *QueryExecutor) hasCostLimits() bool {
+	return q.MaxSelectSeriesN > 0 || q.MaxSelectGroupN > 0 || q.MaxSelectBucketN > 0 || q.MaxSelectPointN > 0
+}
+
+// estimatePointN returns the estimated number of points of seriesN of the
+// measurement's series stored between tmin and tmax in the local shards of
+// groups. Points are assumed to be spread evenly across series and across
+// the time range of each shard group, up to now.
+func (q *QueryExecutor) estimatePointN(m *Measurement, seriesN int, groups []*meta.ShardGroupInfo, tmin, tmax, now time.Time) (int, error) {
+	m.mu.RLock()
+	keys := make([]string, 0, len(m.seriesByID))
+	for _, s := range m.seriesByID {
+		keys = append(keys, s.Key)
+	}
+	m.mu.RUnlock()
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	var n float64
+	for _, g := range groups {
+		// Fraction of the group's time range, up to now, within tmin and tmax.
+		start, end := g.StartTime, g.EndTime
+		if end.After(now) {
+			end = now
+		}
+		frac := 1.0
+		if d := end.Sub(start); d > 0 {
+			lo, hi := start, end
+			if tmin.After(lo) {
+				lo = tmin
+			}
+			if tmax.Before(hi) {
+				hi = tmax
+			}
+			frac = math.Max(0, math.Min(1, float64(hi.Sub(lo))/float64(d)))
+		}
+
+		for _, si := range g.Shards {
+			sh := q.store.Shard(si.ID)
+			if sh == nil {
+				continue
+			}
+			pointN, err := sh.pointN(keys)
+			if err != nil {
+				return 0, err
+			}
+			n += float64(pointN) * frac
+		}
+	}
+
+	return int(math.Ceil(n * float64(seriesN) / float64(len(keys)))), nil
+}
+
+// pointN returns the number of points stored in the shard for the series
+// keys, including points in the cache that haven't been flushed yet.
+func (s *Shard) pointN(keys []string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n int
+	for _, key := range keys {
+		n += len(s.cache[WALPartition([]byte(key))][key])
+	}
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		for _, key := range keys {
+			if b := tx.Bucket([]byte(key)); b != nil {
+				n += b.Stats().KeyN
+			}
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// bucketN returns the number of intervals, aligned to the epoch, that the
+// time range from tmin to tmax falls into. Returns zero for a zero interval.
+func bucketN(tmin, tmax time.Time, interval time.Duration) int {
+	if interval <= 0 || tmax.Before(tmin) {
+		return 0
+	}
+	d := int64(interval)
+	return int(tmax.UnixNano()/d-tmin.UnixNano()/d) + 1
+}
+
+// estimate returns the estimated number of series matching cond, the number
+// of groups they form for the dimensions, and whether the matching series
+// should be looked up in the index.
+func (m *Measurement) estimate(cond influxql.Expr, dimensions []string) (seriesN, groupN int, indexDriven bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	n := len(m.seriesIDs)
+	if n == 0 {
+		return 0, 0, false
+	}
+	seriesN = n
+	if cond != nil {
+		seriesN = m.estimateSeriesN(cond)
+	}
+
+	// Each group holds at least one series so there can't be more groups
+	// than series, however many tag values the dimensions have.
+	groupN = 1
+	for _, key := range dimensions {
+		if card := len(m.seriesByTagKeyValue[key]); card > 0 {
+			groupN *= card
+		}
+		if groupN > seriesN {
+			break
+		}
+	}
+	if groupN > seriesN {
+		groupN = seriesN
+	}
+
+	indexDriven = cond != nil && float64(seriesN) <= float64(n)*maxIndexSelectivity
+	return seriesN, groupN, indexDriven
+}
+
+// estimateSeriesN returns the estimated number of series matching expr.
+// Expressions on fields can't be narrowed by the index so they match every
+// series. Conditions combined with AND are assumed to be independent.
+func (m *Measurement) estimateSeriesN(expr influxql.Expr) int {
+	n := len(m.seriesIDs)
+
+	switch expr := expr.(type) {
+	case *influxql.ParenExpr:
+		return m.estimateSeriesN(expr.Expr)
+	case *influxql.BinaryExpr:
+		switch expr.Op {
+		case influxql.AND:
+			lhs, rhs := m.estimateSeriesN(expr.LHS), m.estimateSeriesN(expr.RHS)
+			return int(math.Ceil(float64(lhs) * float64(rhs) / float64(n)))
+		case influxql.OR:
+			if v := m.estimateSeriesN(expr.LHS) + m.estimateSeriesN(expr.RHS); v < n {
+				return v
+			}
+			return n
+		case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
+			key, ok := expr.LHS.(*influxql.VarRef)
+			if !ok {
+				return n
+			}
+			values := m.seriesByTagKeyValue[key.Val]
+			if len(values) == 0 {
+				return n
+			}
+
+			// Exact values are counted, regexes are assumed to match a
+			// single value of average size.
+			var matched int
+			switch value := expr.RHS.(type) {
+			case *influxql.StringLiteral:
+				matched = len(values[value.Val])
+			case *influxql.RegexLiteral:
+				matched = n / len(values)
+			default:
+				return n
+			}
+
+			if expr.Op == influxql.NEQ || expr.Op == influxql.NEQREGEX {
+				return n - matched
+			}
+			return matched
+		}
+	}
+	return n
+}
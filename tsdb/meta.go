@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdb/influxdb/influxql"
@@ -23,6 +24,10 @@ const (
 // DatabaseIndex is the in memory index of a collection of measurements, time series, and their tags.
 // Exported functions are goroutine safe while un-exported functions assume the caller will use the appropriate locks
 type DatabaseIndex struct {
+	// incremented when measurements, fields or tag keys are added or removed.
+	// Kept first so it's aligned for atomic access on 32-bit platforms.
+	schemaVersion uint64
+
 	// in memory metadata index, built on load and updated when new series come in
 	mu           sync.RWMutex
 	measurements map[string]*Measurement // measurement name to object and index
@@ -46,6 +51,17 @@ func (d *DatabaseIndex) Measurement(name string) *Measurement {
 	return d.measurements[name]
 }
 
+// SchemaVersion returns a number that changes whenever a measurement, field,
+// or tag key is added to or removed from the index.
+func (d *DatabaseIndex) SchemaVersion() uint64 {
+	return atomic.LoadUint64(&d.schemaVersion)
+}
+
+// schemaChanged increments the schema version.
+func (d *DatabaseIndex) schemaChanged() {
+	atomic.AddUint64(&d.schemaVersion, 1)
+}
+
 // MeasurementSeriesCounts returns the number of measurements and series currently indexed by the database.
 // Useful for reporting and monitoring.
 func (d *DatabaseIndex) MeasurementSeriesCounts() (nMeasurements int, nSeries int) {
@@ -86,6 +102,7 @@ func (s *DatabaseIndex) createMeasurementIndexIfNotExists(name string) *Measurem
 		s.measurements[name] = m
 		s.names = append(s.names, name)
 		sort.Strings(s.names)
+		s.schemaChanged()
 	}
 	return m
 }
@@ -96,7 +113,7 @@ func (db *DatabaseIndex) measurementsByExpr(expr influxql.Expr) (Measurements, e
 	switch e := expr.(type) {
 	case *influxql.BinaryExpr:
 		switch e.Op {
-		case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX:
+		case influxql.EQ, influxql.NEQ, influxql.EQREGEX, influxql.NEQREGEX, influxql.LIKE:
 			tag, ok := e.LHS.(*influxql.VarRef)
 			if !ok {
 				return nil, fmt.Errorf("left side of '%s' must be a tag name", e.Op.String())
@@ -143,6 +160,12 @@ func (db *DatabaseIndex) measurementsByExpr(expr influxql.Expr) (Measurements, e
 		}
 	case *influxql.ParenExpr:
 		return db.measurementsByExpr(e.Expr)
+	case *influxql.UnaryExpr:
+		// Push negations down into the tag comparisons they apply to.
+		if reduced := influxql.Reduce(e, nil); !isNotExpr(reduced) {
+			return db.measurementsByExpr(reduced)
+		}
+		return nil, fmt.Errorf("invalid expression: %s", e.String())
 	}
 	return nil, fmt.Errorf("%#v", expr)
 }
@@ -178,6 +201,13 @@ func (db *DatabaseIndex) measurementsByTagFilters(filters []*TagFilter) Measurem
 				if _, ok := tagVals[f.Value]; ok {
 					tagMatch = true
 				}
+			} else if f.Op == influxql.LIKE {
+				for tagVal := range tagVals {
+					if influxql.MatchLike(f.Value, tagVal) {
+						tagMatch = true
+						break
+					}
+				}
 			} else {
 				// Else, the operator is regex and we have to check all tag
 				// values against the regular expression.
@@ -189,7 +219,7 @@ func (db *DatabaseIndex) measurementsByTagFilters(filters []*TagFilter) Measurem
 				}
 			}
 
-			isEQ := (f.Op == influxql.EQ || f.Op == influxql.EQREGEX)
+			isEQ := (f.Op == influxql.EQ || f.Op == influxql.EQREGEX || f.Op == influxql.LIKE)
 
 			// tags match | operation is EQ | measurement matches
 			// --------------------------------------------------
@@ -250,6 +280,7 @@ func (db *DatabaseIndex) DropMeasurement(name string) {
 		}
 	}
 	db.names = names
+	db.schemaChanged()
 }
 
 // DropSeries removes the series keys and their tags from the index
@@ -279,6 +310,7 @@ type Measurement struct {
 	seriesByID          map[uint64]*Series // lookup table for series by their id
 	measurement         *Measurement
 	seriesByTagKeyValue map[string]map[string]seriesIDs // map from tag key to value to sorted set of series ids
+	tagValues           map[string][]string             // map from tag key to its sorted values
 	seriesIDs           seriesIDs                       // sorted list of series IDs in this measurement
 }
 
@@ -292,6 +324,7 @@ func NewMeasurement(name string, idx *DatabaseIndex) *Measurement {
 		series:              make(map[string]*Series),
 		seriesByID:          make(map[uint64]*Series),
 		seriesByTagKeyValue: make(map[string]map[string]seriesIDs),
+		tagValues:           make(map[string][]string),
 		seriesIDs:           make(seriesIDs, 0),
 	}
 }
@@ -355,8 +388,12 @@ func (m *Measurement) AddSeries(s *Series) bool {
 		if valueMap == nil {
 			valueMap = make(map[string]seriesIDs)
 			m.seriesByTagKeyValue[k] = valueMap
+			m.schemaChanged()
 		}
 		ids := valueMap[v]
+		if len(ids) == 0 {
+			m.tagValues[k] = insertTagValue(m.tagValues[k], v)
+		}
 		ids = append(ids, s.id)
 
 		// most of the time the series ID will be higher than all others because it's a new
@@ -406,6 +443,7 @@ func (m *Measurement) DropSeries(seriesID uint64) {
 			// Check to see if we have any ids, if not, remove the key
 			if len(ids) == 0 {
 				delete(values, kk)
+				m.tagValues[k] = removeTagValue(m.tagValues[k], kk)
 			} else {
 				values[kk] = ids
 			}
@@ -413,6 +451,8 @@ func (m *Measurement) DropSeries(seriesID uint64) {
 		// If we have no values, then we delete the key
 		if len(values) == 0 {
 			delete(m.seriesByTagKeyValue, k)
+			delete(m.tagValues, k)
+			m.schemaChanged()
 		} else {
 			m.seriesByTagKeyValue[k] = values
 		}
@@ -421,6 +461,43 @@ func (m *Measurement) DropSeries(seriesID uint64) {
 	return
 }
 
+// insertTagValue inserts a value into a sorted slice of tag values.
+func insertTagValue(values []string, v string) []string {
+	i := sort.SearchStrings(values, v)
+	if i < len(values) && values[i] == v {
+		return values
+	}
+	values = append(values, "")
+	copy(values[i+1:], values[i:])
+	values[i] = v
+	return values
+}
+
+// removeTagValue removes a value from a sorted slice of tag values.
+func removeTagValue(values []string, v string) []string {
+	i := sort.SearchStrings(values, v)
+	if i == len(values) || values[i] != v {
+		return values
+	}
+	return append(values[:i], values[i+1:]...)
+}
+
+// setFieldName adds a field name to the measurement.
+func (m *Measurement) setFieldName(name string) {
+	if _, ok := m.fieldNames[name]; ok {
+		return
+	}
+	m.fieldNames[name] = struct{}{}
+	m.schemaChanged()
+}
+
+// schemaChanged increments the schema version of the measurement's index.
+func (m *Measurement) schemaChanged() {
+	if m.index != nil {
+		m.index.schemaChanged()
+	}
+}
+
 // filters walks the where clause of a select statement and returns a map with all series ids
 // matching the where clause and any filter expression that should be applied to each
 func (m *Measurement) filters(stmt *influxql.SelectStatement) (map[uint64]influxql.Expr, error) {
@@ -449,6 +526,16 @@ func (m *Measurement) filters(stmt *influxql.SelectStatement) (map[uint64]influx
 	return seriesIdsToExpr, nil
 }
 
+// seriesTags returns the tags of the series with the given key.
+func (m *Measurement) seriesTags(key string) map[string]string {
+	m.index.mu.RLock()
+	defer m.index.mu.RUnlock()
+	if s := m.index.series[key]; s != nil {
+		return s.Tags
+	}
+	return nil
+}
+
 // tagSets returns the unique tag sets that exist for the given tag keys. This is used to determine
 // what composite series will be created by a group by. i.e. "group by region" should return:
 // {"region":"uswest"}, {"region":"useast"}
@@ -626,6 +713,54 @@ func (m *Measurement) idsForExpr(n *influxql.BinaryExpr) (seriesIDs, influxql.Ex
 			ids = tagVals[str.Val]
 		} else if n.Op == influxql.NEQ {
 			ids = m.seriesIDs.reject(tagVals[str.Val])
+		} else if n.Op == influxql.LIKE {
+			// Patterns that only end in a wildcard are a prefix comparison so
+			// only the range of sorted values starting with it is scanned.
+			if prefix, ok := influxql.LikePrefix(str.Val); ok {
+				values := m.tagValues[name.Val]
+				for i := sort.SearchStrings(values, prefix); i < len(values) && strings.HasPrefix(values[i], prefix); i++ {
+					ids = ids.union(tagVals[values[i]])
+				}
+			} else {
+				for k := range tagVals {
+					if influxql.MatchLike(str.Val, k) {
+						ids = ids.union(tagVals[k])
+					}
+				}
+			}
+		} else if n.Op == influxql.LT || n.Op == influxql.LTE || n.Op == influxql.GT || n.Op == influxql.GTE {
+			// Tag values are compared lexicographically. Flip the operator
+			// if the tag was on the right-hand side.
+			op := n.Op
+			if value == n.LHS {
+				switch op {
+				case influxql.LT:
+					op = influxql.GT
+				case influxql.LTE:
+					op = influxql.GTE
+				case influxql.GT:
+					op = influxql.LT
+				case influxql.GTE:
+					op = influxql.LTE
+				}
+			}
+
+			// Scan the range of sorted values on the side of the operator.
+			values := m.tagValues[name.Val]
+			i, j := 0, len(values)
+			switch op {
+			case influxql.LT:
+				j = sort.SearchStrings(values, str.Val)
+			case influxql.LTE:
+				j = sort.Search(len(values), func(i int) bool { return values[i] > str.Val })
+			case influxql.GT:
+				i = sort.Search(len(values), func(i int) bool { return values[i] > str.Val })
+			case influxql.GTE:
+				i = sort.SearchStrings(values, str.Val)
+			}
+			for _, k := range values[i:j] {
+				ids = ids.union(tagVals[k])
+			}
 		}
 		return ids, &influxql.BooleanLiteral{Val: true}, nil
 	}
@@ -662,7 +797,7 @@ func (m *Measurement) walkWhereForSeriesIds(expr influxql.Expr) (seriesIDs, map[
 	switch n := expr.(type) {
 	case *influxql.BinaryExpr:
 		switch n.Op {
-		case influxql.EQ, influxql.NEQ, influxql.LT, influxql.LTE, influxql.GT, influxql.GTE, influxql.EQREGEX, influxql.NEQREGEX:
+		case influxql.EQ, influxql.NEQ, influxql.LT, influxql.LTE, influxql.GT, influxql.GTE, influxql.EQREGEX, influxql.NEQREGEX, influxql.LIKE:
 			// Get the series IDs and filter expression for the tag or field comparison.
 			ids, expr, err := m.idsForExpr(n)
 			if err != nil {
@@ -708,11 +843,55 @@ func (m *Measurement) walkWhereForSeriesIds(expr influxql.Expr) (seriesIDs, map[
 	case *influxql.ParenExpr:
 		// walk down the tree
 		return m.walkWhereForSeriesIds(n.Expr)
+	case *influxql.IsNullExpr:
+		ref, ok := n.Expr.(*influxql.VarRef)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid expression: %s", n.String())
+		}
+
+		// Fields can be missing from any point so they're tested on each point.
+		// Tags are either set or missing for the whole series.
+		ids, filter := m.seriesIDs, influxql.Expr(n)
+		if !m.HasField(ref.Val) {
+			var tagged seriesIDs
+			for _, v := range m.seriesByTagKeyValue[ref.Val] {
+				tagged = tagged.union(v)
+			}
+
+			ids, filter = tagged, &influxql.BooleanLiteral{Val: true}
+			if !n.Not {
+				ids = m.seriesIDs.reject(tagged)
+			}
+		}
+
+		filters := map[uint64]influxql.Expr{}
+		for _, id := range ids {
+			filters[id] = filter
+		}
+		return ids, filters, nil
+	case *influxql.UnaryExpr:
+		// Push negations down into the comparisons they apply to.
+		if reduced := influxql.Reduce(n, nil); !isNotExpr(reduced) {
+			return m.walkWhereForSeriesIds(reduced)
+		}
+
+		// Otherwise the negation can only be applied to each point.
+		filters := map[uint64]influxql.Expr{}
+		for _, id := range m.seriesIDs {
+			filters[id] = n
+		}
+		return m.seriesIDs, filters, nil
 	default:
 		return nil, nil, nil
 	}
 }
 
+// isNotExpr returns true if expr is a logical negation.
+func isNotExpr(expr influxql.Expr) bool {
+	e, ok := expr.(*influxql.UnaryExpr)
+	return ok && e.Op == influxql.NOT
+}
+
 // expandExpr returns a list of expressions expanded by all possible tag combinations.
 func (m *Measurement) expandExpr(expr influxql.Expr) []tagSetExpr {
 	// Retrieve list of unique values for each tag.
@@ -3,6 +3,7 @@ package tsdb
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/influxdb/influxdb/influxql"
@@ -120,6 +121,41 @@ func TestMarshalTags(t *testing.T) {
 	}
 }
 
+// Ensure tag value prefixes and comparisons select series from the sorted tag value index.
+func TestMeasurement_idsForExpr_TagValueRange(t *testing.T) {
+	m := NewMeasurement("cpu", nil)
+	for i, host := range []string{"serverB", "serverA", "other", "serverC"} {
+		m.AddSeries(&Series{Key: "cpu,host=" + host, Tags: map[string]string{"host": host}, id: uint64(i + 1)})
+	}
+	m.DropSeries(4)
+
+	if values := m.tagValues["host"]; !reflect.DeepEqual(values, []string{"other", "serverA", "serverB"}) {
+		t.Fatalf("unexpected tag values: %v", values)
+	}
+
+	for i, tt := range []struct {
+		expr string
+		ids  seriesIDs
+	}{
+		{expr: `host LIKE 'server%'`, ids: seriesIDs{1, 2}},
+		{expr: `host LIKE 'serverA%'`, ids: seriesIDs{2}},
+		{expr: `host LIKE 'serverC%'`, ids: nil},
+		{expr: `host LIKE '%A'`, ids: seriesIDs{2}},
+		{expr: `host < 'serverA'`, ids: seriesIDs{3}},
+		{expr: `host <= 'serverA'`, ids: seriesIDs{2, 3}},
+		{expr: `host > 'serverA'`, ids: seriesIDs{1}},
+		{expr: `'serverA' > host`, ids: seriesIDs{3}},
+		{expr: `host >= 'serverA'`, ids: seriesIDs{1, 2}},
+	} {
+		ids, _, err := m.idsForExpr(MustParseExpr(tt.expr).(*influxql.BinaryExpr))
+		if err != nil {
+			t.Fatalf("%d. %s: unexpected error: %s", i, tt.expr, err)
+		} else if !ids.equals(tt.ids) {
+			t.Fatalf("%d. %s: unexpected ids: exp=%v, got=%v", i, tt.expr, tt.ids, ids)
+		}
+	}
+}
+
 func BenchmarkMarshalTags_KeyN1(b *testing.B)  { benchmarkMarshalTags(b, 1) }
 func BenchmarkMarshalTags_KeyN3(b *testing.B)  { benchmarkMarshalTags(b, 3) }
 func BenchmarkMarshalTags_KeyN5(b *testing.B)  { benchmarkMarshalTags(b, 5) }
@@ -0,0 +1,163 @@
+package tsdb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// DefaultPlanCacheSize is the default number of plans held by a PlanCache.
+const DefaultPlanCacheSize = 1000
+
+// PlanCache holds the sources and wildcards resolved for SELECT statements so
+// statements of the same shape, such as those issued by a dashboard every few
+// seconds, skip resolving them against the index again. The shards, mappers
+// and iterators of a statement depend on its time range so they're still
+// created for every execution.
+//
+// Plans are keyed by the fingerprint of the normalized statement, the types of
+// its literals, and its exact sources and dimensions, which the plan resolves
+// and which the fingerprint doesn't tell apart when they are regexes. A plan
+// is discarded when the schema of a database it reads changes and the whole
+// cache is purged when retention policies change.
+type PlanCache struct {
+	mu      sync.Mutex
+	maxSize int
+	plans   map[planKey]*selectPlan
+}
+
+// NewPlanCache returns a cache holding up to maxSize plans. A cache with a
+// size of zero holds no plans.
+func NewPlanCache(maxSize int) *PlanCache {
+	return &PlanCache{
+		maxSize: maxSize,
+		plans:   make(map[planKey]*selectPlan),
+	}
+}
+
+// planKey identifies the plans of statements with the same shape.
+type planKey struct {
+	fingerprint uint64
+	types       string
+	sources     string
+	dimensions  string
+}
+
+// newPlanKey returns the key of a normalized statement.
+func newPlanKey(stmt *influxql.SelectStatement) planKey {
+	return planKey{
+		fingerprint: influxql.Fingerprint(stmt),
+		types:       literalTypes(stmt),
+		sources:     stmt.Sources.String(),
+		dimensions:  stmt.Dimensions.String(),
+	}
+}
+
+// literalTypes returns the types of the literals of a statement in the order
+// they appear. Statements comparing to values of different types are planned
+// separately.
+func literalTypes(stmt *influxql.SelectStatement) string {
+	var a []string
+	influxql.WalkFunc(stmt, func(n influxql.Node) {
+		switch n.(type) {
+		case *influxql.BooleanLiteral, *influxql.DurationLiteral, *influxql.IntegerLiteral,
+			*influxql.NumberLiteral, *influxql.RegexLiteral, *influxql.StringLiteral, *influxql.TimeLiteral:
+			a = append(a, strings.TrimPrefix(fmt.Sprintf("%T", n), "*influxql."))
+		}
+	})
+	return strings.Join(a, ",")
+}
+
+// selectPlan represents the sources and wildcards resolved for a statement.
+type selectPlan struct {
+	sources influxql.Sources
+
+	// Fields and tag keys of the sources. Only set if the statement has a
+	// wildcard and the sources were found.
+	wildcard   bool
+	fields     []string
+	dimensions []string
+
+	// Indexes of the databases the plan was resolved against and their
+	// schema versions at the time.
+	indexes  map[string]*DatabaseIndex
+	versions map[string]uint64
+
+	// False if a database had no index so the plan can't be cached.
+	complete bool
+}
+
+// apply rewrites a statement of the same shape as the one the plan was
+// resolved for using the plan's sources and wildcards.
+func (p *selectPlan) apply(stmt *influxql.SelectStatement) *influxql.SelectStatement {
+	var sources influxql.Sources
+	for _, src := range p.sources {
+		m := *src.(*influxql.Measurement)
+		sources = append(sources, &m)
+	}
+	stmt.Sources = sources
+
+	if p.wildcard && stmt.HasWildcard() {
+		stmt = stmt.RewriteWildcards(p.fields, p.dimensions)
+	}
+	stmt.RewriteDistinct()
+	return stmt
+}
+
+// Get returns the plan for key if the schema of the databases it read hasn't
+// changed since it was resolved.
+func (c *PlanCache) Get(key planKey, store *Store) *selectPlan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.plans[key]
+	if p == nil {
+		return nil
+	}
+	for name, idx := range p.indexes {
+		if store.DatabaseIndex(name) != idx || idx.SchemaVersion() != p.versions[name] {
+			delete(c.plans, key)
+			return nil
+		}
+	}
+	return p
+}
+
+// Put adds a plan to the cache. Plans that weren't completely resolved, such as
+// those reading databases without an index, aren't added. An arbitrary plan is
+// evicted if the cache is full.
+func (c *PlanCache) Put(key planKey, p *selectPlan) {
+	if !p.complete {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxSize <= 0 {
+		return
+	}
+	if _, ok := c.plans[key]; !ok && len(c.plans) >= c.maxSize {
+		for k := range c.plans {
+			delete(c.plans, k)
+			break
+		}
+	}
+	c.plans[key] = p
+}
+
+// Purge removes every plan from the cache.
+func (c *PlanCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plans = make(map[planKey]*selectPlan)
+}
+
+// Len returns the number of plans in the cache.
+func (c *PlanCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.plans)
+}
@@ -35,6 +35,10 @@ type Point interface {
 	SetData(buf []byte)
 
 	String() string
+
+	// AppendString appends the line protocol encoding of the point to b
+	// and returns the extended buffer.
+	AppendString(b []byte) []byte
 }
 
 // point is the default implementation of Point.
@@ -103,37 +107,79 @@ func ParsePoints(buf []byte) ([]Point, error) {
 }
 
 func ParsePointsWithPrecision(buf []byte, defaultTime time.Time, precision string) ([]Point, error) {
+	points, errs := parsePoints(buf, defaultTime, precision, true)
+	if len(errs) > 0 {
+		return nil, errs[0].Err
+	}
+	return points, nil
+}
+
+// LineError represents a line of a text representation of points that
+// couldn't be parsed.
+type LineError struct {
+	Line int // line number, starting at 1
+	Err  error
+}
+
+// Error returns a string representation of the error.
+func (e *LineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+// ParsePointsPartial parses points like ParsePointsWithPrecision but skips
+// lines that can't be parsed instead of failing. Returns the points that were
+// parsed along with an error for each line that wasn't.
+func ParsePointsPartial(buf []byte, defaultTime time.Time, precision string) ([]Point, []*LineError) {
+	return parsePoints(buf, defaultTime, precision, false)
+}
+
+// parsePoints parses each line of buf as a point. If stopOnError is true then
+// parsing stops at the first line that can't be parsed.
+func parsePoints(buf []byte, defaultTime time.Time, precision string, stopOnError bool) ([]Point, []*LineError) {
 	points := []Point{}
 	var (
 		pos   int
+		line  int
 		block []byte
+		errs  []*LineError
 	)
 	for {
 		pos, block = scanTo(buf, pos, '\n')
 		pos += 1
+		line += 1
 
 		if len(block) == 0 {
 			break
 		}
 
-		// lines which start with '#' are comments
-		if start := skipWhitespace(block, 0); block[start] == '#' {
+		// lines which start with '#' are comments, blank lines are ignored
+		if start := skipWhitespace(block, 0); start == len(block) || block[start] == '#' {
 			continue
 		}
 
 		pt, err := parsePoint(block, defaultTime, precision)
 		if err != nil {
-			return nil, fmt.Errorf("unable to parse '%s': %v", string(block), err)
+			errs = append(errs, &LineError{Line: line, Err: fmt.Errorf("unable to parse '%s': %v", string(block), err)})
+			if stopOnError {
+				return nil, errs
+			}
+		} else {
+			points = append(points, pt)
 		}
-		points = append(points, pt)
 
 		if pos >= len(buf) {
 			break
 		}
 
 	}
-	return points, nil
+	return points, errs
+}
 
+// ValidPrecision returns true if precision is supported when parsing points.
+func ValidPrecision(precision string) bool {
+	switch precision {
+	case "n", "u", "ms", "s", "m", "h":
+		return true
+	}
+	return false
 }
 
 func parsePoint(buf []byte, defaultTime time.Time, precision string) (Point, error) {
@@ -727,6 +773,9 @@ func escape(in []byte) []byte {
 }
 
 func escapeString(in string) string {
+	if !strings.ContainsAny(in, `, ="`) {
+		return in
+	}
 	for b, esc := range escapeCodesStr {
 		in = strings.Replace(in, b, esc, -1)
 	}
@@ -762,13 +811,37 @@ func unescapeQuoteString(in string) string {
 	return strings.Replace(in, `\"`, `"`, -1)
 }
 
-// NewPoint returns a new point with the given measurement name, tags, fiels and timestamp
-func NewPoint(name string, tags Tags, fields Fields, time time.Time) Point {
+// NewPoint returns a new point with the given measurement name, tags, fields and timestamp.
+// Returns an error if the name, a tag key or a field name is empty, if there are no
+// fields or if a field value is NaN, infinite or of an unsupported type.
+func NewPoint(name string, tags Tags, fields Fields, time time.Time) (Point, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing measurement")
+	}
+	for k := range tags {
+		if k == "" {
+			return nil, fmt.Errorf("missing tag key")
+		}
+	}
+	if err := fields.validate(); err != nil {
+		return nil, err
+	}
+
 	return &point{
 		key:    makeKey([]byte(name), tags),
 		time:   time,
 		fields: fields.MarshalBinary(),
+	}, nil
+}
+
+// MustNewPoint returns a new point like NewPoint but panics if the point is invalid.
+// It should only be used with values known to be valid.
+func MustNewPoint(name string, tags Tags, fields Fields, time time.Time) Point {
+	p, err := NewPoint(name, tags, fields, time)
+	if err != nil {
+		panic(err.Error())
 	}
+	return p
 }
 
 func (p *point) Data() []byte {
@@ -900,11 +973,22 @@ func (p *point) GetPrecisionMultiplier(precision string) int64 {
 	return int64(d)
 }
 
+// String returns the line protocol encoding of the point. The timestamp is
+// left out if the point doesn't have one.
 func (p *point) String() string {
-	if p.Time().IsZero() {
-		return fmt.Sprintf("%s %s", p.Key(), string(p.fields))
+	return string(p.AppendString(make([]byte, 0, len(p.key)+len(p.fields)+maxInt64Digits+2)))
+}
+
+// AppendString appends the line protocol encoding of the point to b.
+func (p *point) AppendString(b []byte) []byte {
+	b = append(b, p.key...)
+	b = append(b, ' ')
+	b = append(b, p.fields...)
+	if !p.time.IsZero() {
+		b = append(b, ' ')
+		b = strconv.AppendInt(b, p.UnixNano(), 10)
 	}
-	return fmt.Sprintf("%s %s %d", p.Key(), string(p.fields), p.UnixNano())
+	return b
 }
 
 func (p *point) unmarshalBinary() Fields {
@@ -968,6 +1052,34 @@ func (t Tags) hashKey() []byte {
 
 type Fields map[string]interface{}
 
+// validate returns an error if there are no fields, a field name is empty or
+// a value can't be encoded. Nil values are allowed and left out when encoding
+// but at least one field must have a value.
+func (p Fields) validate() error {
+	n := 0
+	for k, v := range p {
+		if k == "" {
+			return fmt.Errorf("missing field name")
+		}
+		if v != nil {
+			n++
+		}
+		switch v := v.(type) {
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("%v is an unsupported value for field %s", v, k)
+			}
+		case int, int32, int64, uint64, bool, string, []byte, nil:
+		default:
+			return fmt.Errorf("unsupported type %T for field %s", v, k)
+		}
+	}
+	if n == 0 {
+		return fmt.Errorf("missing fields")
+	}
+	return nil
+}
+
 func parseNumber(val []byte) (interface{}, error) {
 	for i := 0; i < len(val); i++ {
 		if val[i] == '.' {
@@ -1028,7 +1140,7 @@ func newFieldsFromBinary(buf []byte) Fields {
 }
 
 func (p Fields) MarshalBinary() []byte {
-	b := []byte{}
+	b := make([]byte, 0, len(p)*16)
 	keys := make([]string, len(p))
 	i := 0
 	for k, _ := range p {
@@ -1039,37 +1151,34 @@ func (p Fields) MarshalBinary() []byte {
 
 	for _, k := range keys {
 		v := p[k]
-		b = append(b, []byte(escapeString(k))...)
+		if v == nil {
+			continue
+		}
+		b = append(b, escapeString(k)...)
 		b = append(b, '=')
 		switch t := v.(type) {
 		case int:
-			b = append(b, []byte(strconv.FormatInt(int64(t), 10))...)
+			b = strconv.AppendInt(b, int64(t), 10)
 		case int32:
-			b = append(b, []byte(strconv.FormatInt(int64(t), 10))...)
+			b = strconv.AppendInt(b, int64(t), 10)
 		case uint64:
-			b = append(b, []byte(strconv.FormatUint(t, 10))...)
+			b = strconv.AppendUint(b, t, 10)
 		case int64:
-			b = append(b, []byte(strconv.FormatInt(t, 10))...)
+			b = strconv.AppendInt(b, t, 10)
 		case float64:
-			// ensure there is a decimal in the encoded for
-
-			val := []byte(strconv.FormatFloat(t, 'f', -1, 64))
-			_, frac := math.Modf(t)
-			hasDecimal := frac != 0
-			b = append(b, val...)
-			if !hasDecimal {
-				b = append(b, []byte(".0")...)
+			// ensure there is a decimal in the encoded form
+			b = strconv.AppendFloat(b, t, 'f', -1, 64)
+			if _, frac := math.Modf(t); frac == 0 {
+				b = append(b, ".0"...)
 			}
 		case bool:
-			b = append(b, []byte(strconv.FormatBool(t))...)
+			b = strconv.AppendBool(b, t)
 		case []byte:
 			b = append(b, t...)
 		case string:
 			b = append(b, '"')
-			b = append(b, []byte(escapeQuoteString(t))...)
+			b = append(b, escapeQuoteString(t)...)
 			b = append(b, '"')
-		case nil:
-			// skip
 		default:
 			panic(fmt.Sprintf("unknown type: %T", v))
 		}
@@ -165,7 +165,7 @@ func TestParsePointNoFields(t *testing.T) {
 }
 
 func TestParsePointNoTimestamp(t *testing.T) {
-	test(t, "cpu value=1", NewPoint("cpu", nil, nil, time.Unix(0, 0)))
+	test(t, "cpu value=1", MustNewPoint("cpu", nil, Fields{"value": int64(1)}, time.Unix(0, 0)))
 }
 
 func TestParsePointMissingQuote(t *testing.T) {
@@ -425,7 +425,7 @@ func TestParsePointBooleanInvalid(t *testing.T) {
 func TestParsePointUnescape(t *testing.T) {
 	// commas in measuremnt name
 	test(t, `cpu\,main,regions=east\,west value=1.0`,
-		NewPoint(
+		MustNewPoint(
 			"cpu,main", // comma in the name
 			Tags{
 				"regions": "east,west",
@@ -437,7 +437,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// spaces in measurement name
 	test(t, `cpu\ load,region=east value=1.0`,
-		NewPoint(
+		MustNewPoint(
 			"cpu load", // space in the name
 			Tags{
 				"region": "east",
@@ -449,7 +449,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// commas in tag names
 	test(t, `cpu,region\,zone=east value=1.0`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"region,zone": "east", // comma in the tag name
 			},
@@ -460,7 +460,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// spaces in tag names
 	test(t, `cpu,region\ zone=east value=1.0`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"region zone": "east", // comma in the tag name
 			},
@@ -471,7 +471,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// commas in tag values
 	test(t, `cpu,regions=east\,west value=1.0`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"regions": "east,west", // comma in the tag value
 			},
@@ -482,7 +482,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// spaces in tag values
 	test(t, `cpu,regions=east\ west value=1.0`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"regions": "east west", // comma in the tag value
 			},
@@ -493,7 +493,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// commas in field names
 	test(t, `cpu,regions=east value\,ms=1.0`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"regions": "east",
 			},
@@ -504,7 +504,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// spaces in field names
 	test(t, `cpu,regions=east value\ ms=1.0`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"regions": "east",
 			},
@@ -515,7 +515,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// commas in field values
 	test(t, `cpu,regions=east value="1,0"`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"regions": "east",
 			},
@@ -526,7 +526,7 @@ func TestParsePointUnescape(t *testing.T) {
 
 	// random character escaped
 	test(t, `cpu,regions=eas\t value=1.0`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"regions": "eas\\t",
@@ -540,7 +540,7 @@ func TestParsePointUnescape(t *testing.T) {
 func TestParsePointWithTags(t *testing.T) {
 	test(t,
 		"cpu,host=serverA,region=us-east value=1.0 1000000000",
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{"host": "serverA", "region": "us-east"},
 			Fields{"value": 1.0}, time.Unix(1, 0)))
 }
@@ -554,7 +554,7 @@ func TestParsPointWithDuplicateTags(t *testing.T) {
 
 func TestParsePointWithStringField(t *testing.T) {
 	test(t, `cpu,host=serverA,region=us-east value=1.0,str="foo",str2="bar" 1000000000`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"host":   "serverA",
 				"region": "us-east",
@@ -568,7 +568,7 @@ func TestParsePointWithStringField(t *testing.T) {
 	)
 
 	test(t, `cpu,host=serverA,region=us-east str="foo \" bar" 1000000000`,
-		NewPoint("cpu",
+		MustNewPoint("cpu",
 			Tags{
 				"host":   "serverA",
 				"region": "us-east",
@@ -583,7 +583,7 @@ func TestParsePointWithStringField(t *testing.T) {
 
 func TestParsePointWithStringWithSpaces(t *testing.T) {
 	test(t, `cpu,host=serverA,region=us-east value=1.0,str="foo bar" 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"host":   "serverA",
@@ -600,7 +600,7 @@ func TestParsePointWithStringWithSpaces(t *testing.T) {
 func TestParsePointWithStringWithCommas(t *testing.T) {
 	// escaped comma
 	test(t, `cpu,host=serverA,region=us-east value=1.0,str="foo\,bar" 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"host":   "serverA",
@@ -615,7 +615,7 @@ func TestParsePointWithStringWithCommas(t *testing.T) {
 
 	// non-escaped comma
 	test(t, `cpu,host=serverA,region=us-east value=1.0,str="foo,bar" 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"host":   "serverA",
@@ -633,7 +633,7 @@ func TestParsePointWithStringWithCommas(t *testing.T) {
 func TestParsePointEscapedStringsAndCommas(t *testing.T) {
 	// non-escaped comma and quotes
 	test(t, `cpu,host=serverA,region=us-east value="{Hello\"{,}\" World}" 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"host":   "serverA",
@@ -647,7 +647,7 @@ func TestParsePointEscapedStringsAndCommas(t *testing.T) {
 
 	// escaped comma and quotes
 	test(t, `cpu,host=serverA,region=us-east value="{Hello\"{\,}\" World}" 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"host":   "serverA",
@@ -663,7 +663,7 @@ func TestParsePointEscapedStringsAndCommas(t *testing.T) {
 
 func TestParsePointWithStringWithEquals(t *testing.T) {
 	test(t, `cpu,host=serverA,region=us-east str="foo=bar",value=1.0 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"host":   "serverA",
@@ -679,7 +679,7 @@ func TestParsePointWithStringWithEquals(t *testing.T) {
 
 func TestParsePointWithBoolField(t *testing.T) {
 	test(t, `cpu,host=serverA,region=us-east true=true,t=t,T=T,TRUE=TRUE,false=false,f=f,F=F,FALSE=FALSE 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"host":   "serverA",
@@ -701,7 +701,7 @@ func TestParsePointWithBoolField(t *testing.T) {
 
 func TestParsePointUnicodeString(t *testing.T) {
 	test(t, `cpu,host=serverA,region=us-east value="wè" 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{
 				"host":   "serverA",
@@ -716,7 +716,7 @@ func TestParsePointUnicodeString(t *testing.T) {
 
 func TestNewPointNegativeFloat(t *testing.T) {
 	test(t, `cpu value=-0.64 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{},
 			Fields{
@@ -728,7 +728,7 @@ func TestNewPointNegativeFloat(t *testing.T) {
 
 func TestNewPointFloatNoDecimal(t *testing.T) {
 	test(t, `cpu value=1. 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{},
 			Fields{
@@ -740,7 +740,7 @@ func TestNewPointFloatNoDecimal(t *testing.T) {
 
 func TestNewPointFloatScientific(t *testing.T) {
 	test(t, `cpu value=6.632243e+06 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{},
 			Fields{
@@ -752,7 +752,7 @@ func TestNewPointFloatScientific(t *testing.T) {
 
 func TestNewPointLargeInteger(t *testing.T) {
 	test(t, `cpu value=6632243 1000000000`,
-		NewPoint(
+		MustNewPoint(
 			"cpu",
 			Tags{},
 			Fields{
@@ -819,13 +819,13 @@ func TestParsePointToString(t *testing.T) {
 		t.Errorf("ParsePoint() to string mismatch:\n got %v\n exp %v", got, line)
 	}
 
-	pt = NewPoint("cpu", Tags{"host": "serverA", "region": "us-east"},
+	pt = MustNewPoint("cpu", Tags{"host": "serverA", "region": "us-east"},
 		Fields{"int": 10, "float": float64(11.0), "float2": float64(12.123), "bool": false, "str": "string val"},
 		time.Unix(1, 0))
 
 	got = pt.String()
 	if line != got {
-		t.Errorf("NewPoint() to string mismatch:\n got %v\n exp %v", got, line)
+		t.Errorf("MustNewPoint() to string mismatch:\n got %v\n exp %v", got, line)
 	}
 }
 
@@ -1014,22 +1014,116 @@ cpu,host=serverA,region=us-east value=1.0 946730096789012345`,
 	}
 }
 
+func TestParsePointsPartial(t *testing.T) {
+	batch := `cpu value=1 1000000000
+cpu value= 2000000000
+	
+# comment
+mem value=3.0.0 3000000000
+mem value=4 4000000000`
+
+	pts, errs := ParsePointsPartial([]byte(batch), time.Now().UTC(), "n")
+	if len(pts) != 2 {
+		t.Fatalf("unexpected point count: %d", len(pts))
+	} else if got := pts[0].String(); got != "cpu value=1 1000000000" {
+		t.Errorf("unexpected point: %s", got)
+	} else if got := pts[1].String(); got != "mem value=4 4000000000" {
+		t.Errorf("unexpected point: %s", got)
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("unexpected error count: %d", len(errs))
+	} else if errs[0].Line != 2 || !strings.HasPrefix(errs[0].Error(), "line 2: unable to parse 'cpu value= 2000000000'") {
+		t.Errorf("unexpected error: %s", errs[0])
+	} else if errs[1].Line != 5 || !strings.HasPrefix(errs[1].Error(), "line 5: unable to parse 'mem value=3.0.0 3000000000'") {
+		t.Errorf("unexpected error: %s", errs[1])
+	}
+
+	// The same batch fails as a whole when parsed with ParsePointsWithPrecision.
+	if _, err := ParsePointsWithPrecision([]byte(batch), time.Now().UTC(), "n"); err == nil || err.Error() != errs[0].Err.Error() {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewPoint_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		tags   Tags
+		fields Fields
+		err    string
+	}{
+		{name: "", fields: Fields{"value": 1.0}, err: "missing measurement"},
+		{name: "cpu", tags: Tags{"": "serverA"}, fields: Fields{"value": 1.0}, err: "missing tag key"},
+		{name: "cpu", fields: Fields{}, err: "missing fields"},
+		{name: "cpu", fields: Fields{"value": nil}, err: "missing fields"},
+		{name: "cpu", fields: Fields{"": 1.0}, err: "missing field name"},
+		{name: "cpu", fields: Fields{"value": math.NaN()}, err: "NaN is an unsupported value for field value"},
+		{name: "cpu", fields: Fields{"value": math.Inf(-1)}, err: "-Inf is an unsupported value for field value"},
+		{name: "cpu", fields: Fields{"value": float32(1)}, err: "unsupported type float32 for field value"},
+	}
+
+	for i, tt := range tests {
+		if _, err := NewPoint(tt.name, tt.tags, tt.fields, time.Unix(0, 0)); err == nil || err.Error() != tt.err {
+			t.Errorf("%d. unexpected error: got %v, exp %s", i, err, tt.err)
+		}
+	}
+}
+
+func TestNewPoint_NilField(t *testing.T) {
+	pt := MustNewPoint("cpu", nil, Fields{"value": 1.0, "other": nil}, time.Unix(1, 0))
+	if got, exp := pt.String(), "cpu value=1.0 1000000000"; got != exp {
+		t.Errorf("String() mismatch:\n got %v\n exp %v", got, exp)
+	}
+	if _, err := ParsePointsString(pt.String()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoint_AppendString(t *testing.T) {
+	pt := MustNewPoint("cpu", Tags{"host": "serverA"}, Fields{"value": 1.0}, time.Unix(1, 0))
+	if got, exp := string(pt.AppendString([]byte("mem value=2i\n"))), "mem value=2i\ncpu,host=serverA value=1.0 1000000000"; got != exp {
+		t.Errorf("AppendString() mismatch:\n got %v\n exp %v", got, exp)
+	}
+
+	// Points without a timestamp are encoded without one.
+	pt = MustNewPoint("cpu", nil, Fields{"value": 1.0}, time.Time{})
+	if got, exp := pt.String(), "cpu value=1.0"; got != exp {
+		t.Errorf("String() mismatch:\n got %v\n exp %v", got, exp)
+	}
+}
+
+func BenchmarkNewPoint(b *testing.B) {
+	ts := time.Unix(1, 0)
+	fields := Fields{"value": 1.0, "count": 10, "name": "foo"}
+	for i := 0; i < b.N; i++ {
+		MustNewPoint("cpu", tags, fields, ts)
+	}
+}
+
+func BenchmarkPoint_AppendString(b *testing.B) {
+	pt := MustNewPoint("cpu", tags, Fields{"value": 1.0, "count": 10, "name": "foo"}, time.Unix(1, 0))
+	buf := make([]byte, 0, 1024)
+	for i := 0; i < b.N; i++ {
+		buf = pt.AppendString(buf[:0])
+	}
+}
+
 func TestNewPointEscaped(t *testing.T) {
 	// commas
-	pt := NewPoint("cpu,main", Tags{"tag,bar": "value"}, Fields{"name,bar": 1.0}, time.Unix(0, 0))
+	pt := MustNewPoint("cpu,main", Tags{"tag,bar": "value"}, Fields{"name,bar": 1.0}, time.Unix(0, 0))
 	if exp := `cpu\,main,tag\,bar=value name\,bar=1.0 0`; pt.String() != exp {
-		t.Errorf("NewPoint().String() mismatch.\ngot %v\nexp %v", pt.String(), exp)
+		t.Errorf("MustNewPoint().String() mismatch.\ngot %v\nexp %v", pt.String(), exp)
 	}
 
 	// spaces
-	pt = NewPoint("cpu main", Tags{"tag bar": "value"}, Fields{"name bar": 1.0}, time.Unix(0, 0))
+	pt = MustNewPoint("cpu main", Tags{"tag bar": "value"}, Fields{"name bar": 1.0}, time.Unix(0, 0))
 	if exp := `cpu\ main,tag\ bar=value name\ bar=1.0 0`; pt.String() != exp {
-		t.Errorf("NewPoint().String() mismatch.\ngot %v\nexp %v", pt.String(), exp)
+		t.Errorf("MustNewPoint().String() mismatch.\ngot %v\nexp %v", pt.String(), exp)
 	}
 
 	// equals
-	pt = NewPoint("cpu=main", Tags{"tag=bar": "value=foo"}, Fields{"name=bar": 1.0}, time.Unix(0, 0))
+	pt = MustNewPoint("cpu=main", Tags{"tag=bar": "value=foo"}, Fields{"name=bar": 1.0}, time.Unix(0, 0))
 	if exp := `cpu\=main,tag\=bar=value\=foo name\=bar=1.0 0`; pt.String() != exp {
-		t.Errorf("NewPoint().String() mismatch.\ngot %v\nexp %v", pt.String(), exp)
+		t.Errorf("MustNewPoint().String() mismatch.\ngot %v\nexp %v", pt.String(), exp)
 	}
 }
@@ -5,13 +5,21 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/query"
 )
 
+// Authorizer determines whether a user may execute a statement.
+type Authorizer interface {
+	AuthorizeQuery(u *meta.UserInfo, stmt influxql.Statement, database string) error
+}
+
 // QueryExecutor executes every statement in an influxdb Query. It is responsible for
 // coordinating between the local tsdb.Store, the meta.Store, and the other nodes in
 // the cluster to run the query against their local tsdb.Stores. There should be one executor
@@ -33,6 +41,57 @@ type QueryExecutor struct {
 		ExecuteStatement(stmt influxql.Statement) *influxql.Result
 	}
 
+	// If set, called for each series read by a SELECT statement. Series
+	// that it returns false for are left out of the results. This allows
+	// tenants sharing a database to be separated by a tag.
+	SeriesAuthorizer func(database, measurement string, tags map[string]string) bool
+
+	// Limits on the estimated cost of a SELECT statement. Statements
+	// exceeding them are rejected before execution. Zero means no limit.
+	MaxSelectSeriesN int
+	MaxSelectGroupN  int
+	MaxSelectBucketN int
+	MaxSelectPointN  int
+
+	// Maximum number of shards read at the same time by a single source of
+	// a statement. Defaults to the number of CPUs that can run at once.
+	ShardConcurrency int
+
+	// Maximum estimated bytes of points a sort holds in memory before
+	// spilling to temporary files in SortTempDir. Zero means no limit.
+	MaxSortMemory int
+	SortTempDir   string
+
+	// Execute SELECT statements with the iterators of the query package
+	// instead of the map/reduce engine of the influxql package. Statements
+	// using features the iterators don't implement are still executed by
+	// the map/reduce engine.
+	IteratorEngine bool
+
+	// Counts and latencies of the statements executed, reported by SHOW STATS.
+	Stats *QueryStats
+
+	// Queries being executed, listed by SHOW QUERIES and aborted by KILL
+	// QUERY. If nil, queries aren't tracked.
+	Tasks *QueryTasks
+
+	// Services reporting their own counters in SHOW STATS, one row each.
+	StatsReporters []StatsReporter
+
+	// Sources and wildcards resolved for SELECT statements. If nil, they're
+	// resolved for every statement.
+	PlanCache *PlanCache
+
+	// Maximum time a query may run before it's aborted with ErrQueryTimeout.
+	// Zero means no limit.
+	QueryTimeout time.Duration
+
+	// Runs continuous queries over historical time ranges for BACKFILL
+	// CONTINUOUS QUERY statements. If nil, the statements are rejected.
+	ContinuousQuerier interface {
+		Backfill(database, name string, start, end time.Time) error
+	}
+
 	Logger *log.Logger
 
 	// the local data store
@@ -42,14 +101,20 @@ type QueryExecutor struct {
 // NewQueryExecutor returns an initialized QueryExecutor
 func NewQueryExecutor(store *Store) *QueryExecutor {
 	return &QueryExecutor{
-		store:  store,
-		Logger: log.New(os.Stderr, "[query] ", log.LstdFlags),
+		store:            store,
+		ShardConcurrency: runtime.GOMAXPROCS(0),
+		Stats:            NewQueryStats(),
+		Tasks:            NewQueryTasks(),
+		PlanCache:        NewPlanCache(DefaultPlanCacheSize),
+		Logger:           log.New(os.Stderr, "[query] ", log.LstdFlags),
 	}
 }
 
 // Begin is for influxql/engine.go to use to get a transaction object to start the query
 func (q *QueryExecutor) Begin() (influxql.Tx, error) {
-	return newTx(q.MetaStore, q.store), nil
+	tx := newTx(q.MetaStore, q.store)
+	tx.authorizeSeries = q.SeriesAuthorizer
+	return tx, nil
 }
 
 // Authorize user u to execute query q on database.
@@ -64,16 +129,38 @@ func (q *QueryExecutor) Authorize(u *meta.UserInfo, query *influxql.Query, datab
 		// Get the first statement in the query.
 		stmt := query.Statements[0]
 		// First statement must create a root user.
-		if cu, ok := stmt.(*influxql.CreateUserStatement); !ok ||
-			cu.Privilege == nil ||
-			*cu.Privilege != influxql.AllPrivileges {
+		if cu, ok := stmt.(*influxql.CreateUserStatement); !ok || !cu.Admin {
 			return ErrAuthorize{text: "no users exist. create root user first or disable authentication"}
 		}
 		return nil
 	}
 
 	if u == nil {
-		q.Logger.Printf(authErrLogFmt, "", query.String(), database)
+		q.Logger.Printf(authErrLogFmt, "", query.Sanitize(false).String(), database)
+		return ErrAuthorize{text: "no user provided"}
+	}
+
+	// Check each statement in the query.
+	for _, stmt := range query.Statements {
+		if err := q.AuthorizeQuery(u, stmt, database); err != nil {
+			q.Logger.Printf(authErrLogFmt, u.Name, query.Sanitize(false).String(), database)
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthorizeQuery returns an error if user u is not permitted to execute stmt.
+// Privileges that don't name a database are checked against database. If no
+// user is provided then only the creation of the first admin user is allowed.
+func (q *QueryExecutor) AuthorizeQuery(u *meta.UserInfo, stmt influxql.Statement, database string) error {
+	if u == nil {
+		if count, err := q.MetaStore.UserCount(); count == 0 && err == nil {
+			if cu, ok := stmt.(*influxql.CreateUserStatement); ok && cu.Admin {
+				return nil
+			}
+			return ErrAuthorize{text: "no users exist. create root user first or disable authentication"}
+		}
 		return ErrAuthorize{text: "no user provided"}
 	}
 
@@ -82,51 +169,77 @@ func (q *QueryExecutor) Authorize(u *meta.UserInfo, query *influxql.Query, datab
 		return nil
 	}
 
-	// Check each statement in the query.
-	for _, stmt := range query.Statements {
-		// Get the privileges required to execute the statement.
-		privs := stmt.RequiredPrivileges()
-
-		// Make sure the user has each privilege required to execute
-		// the statement.
-		for _, p := range privs {
-			// Use the db name specified by the statement or the db
-			// name passed by the caller if one wasn't specified by
-			// the statement.
-			dbname := p.Name
-			if dbname == "" {
-				dbname = database
-			}
-
-			// Check if user has required privilege.
-			if !u.Authorize(p.Privilege, dbname) {
-				var msg string
-				if dbname == "" {
-					msg = "requires cluster admin"
-				} else {
-					msg = fmt.Sprintf("requires %s privilege on %s", p.Privilege.String(), dbname)
-				}
-				q.Logger.Printf(authErrLogFmt, u.Name, query.String(), database)
-				return ErrAuthorize{
-					text: fmt.Sprintf("%s not authorized to execute '%s'.  %s", u.Name, stmt.String(), msg),
-				}
-			}
-		}
+	// Other users may only list their own account.
+	if s, ok := stmt.(*influxql.ShowUsersStatement); ok {
+		s.User = u.Name
+		return nil
+	}
+
+	// Make sure the user has each privilege required to execute the statement.
+	unsatisfied := stmt.RequiredPrivileges().Dedupe().Unsatisfied(u, database)
+	if len(unsatisfied) == 0 {
+		return nil
+	}
+
+	var msg string
+	if p := unsatisfied[0]; p.Name == "" {
+		msg = "requires cluster admin"
+	} else if p.Measurement != "" {
+		msg = fmt.Sprintf("requires %s privilege on %s.%s", p.Privilege.String(), p.Name, p.Measurement)
+	} else {
+		msg = fmt.Sprintf("requires %s privilege on %s", p.Privilege.String(), p.Name)
+	}
+	return ErrAuthorize{
+		text: fmt.Sprintf("%s not authorized to execute '%s'.  %s", u.Name, influxql.SanitizeStatement(stmt, false).String(), msg),
 	}
-	return nil
 }
 
 // ExecuteQuery executes an InfluxQL query against the server.
 // It sends results down the passed in chan and closes it when done. It will close the chan
-// on the first statement that throws an error.
-func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chunkSize int) (<-chan *influxql.Result, error) {
+// on the first statement that throws an error. The rows of SELECT statements are sent as
+// they're computed, each holding up to chunkSize values, so results can be streamed. Closing the closing channel cancels the
+// query. Execution stops and the chan is closed without sending the remaining results.
+//
+// A query running longer than the timeout is aborted and ErrQueryTimeout is sent in
+// place of the results of the interrupted statement. A positive timeout overrides
+// the executor's QueryTimeout.
+func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chunkSize int, timeout time.Duration, closing <-chan struct{}) (<-chan *influxql.Result, error) {
+	if timeout <= 0 {
+		timeout = q.QueryTimeout
+	}
+
+	// Track the query so it can be listed and killed. Passwords are masked
+	// as every user can list it.
+	var id uint64
+	var kill <-chan struct{}
+	if q.Tasks != nil {
+		id, kill = q.Tasks.add(query.Sanitize(false).String(), database)
+	}
+
 	// Execute each statement. Keep the iterator external so we can
 	// track how many of the statements were executed
 	results := make(chan *influxql.Result)
 	go func() {
+		defer close(results)
+		if q.Tasks != nil {
+			defer q.Tasks.remove(id)
+		}
+
+		// Statements are interrupted when the query is canceled, is killed,
+		// or times out.
+		interrupt, stop := interruptAfter(closing, kill, timeout)
+		defer stop()
+
 		var i int
 		var stmt influxql.Statement
+		var interrupted bool
+	loop:
 		for i, stmt = range query.Statements {
+			if canceled(interrupt) {
+				interrupted = true
+				break
+			}
+
 			// If a default database wasn't passed in by the caller, check the statement.
 			// Some types of statements have an associated default database, even if it
 			// is not explicitly included.
@@ -139,17 +252,30 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 
 			// Normalize each statement.
 			if err := q.normalizeStatement(stmt, defaultDB); err != nil {
-				results <- &influxql.Result{Err: err}
+				if !sendResult(results, &influxql.Result{Err: err}, interrupt) {
+					interrupted = true
+				}
 				break
 			}
 
 			var res *influxql.Result
+			start := time.Now()
 			switch stmt := stmt.(type) {
 			case *influxql.SelectStatement:
-				if err := q.executeSelectStatement(i, stmt, results, chunkSize); err != nil {
-					results <- &influxql.Result{Err: err}
+				err := q.executeSelectStatement(i, stmt, results, chunkSize, interrupt)
+				q.Stats.Record(stmt, time.Since(start), err)
+				if err == ErrQueryCanceled {
+					interrupted = true
+					break loop
+				} else if err != nil {
+					if !sendResult(results, &influxql.Result{Err: err}, interrupt) {
+						interrupted = true
+						break loop
+					}
 					break
 				}
+			case *influxql.ExplainStatement:
+				res = q.executeExplainStatement(stmt, interrupt)
 			case *influxql.DropSeriesStatement:
 				// TODO: handle this in a cluster
 				res = q.executeDropSeriesStatement(stmt, database)
@@ -168,6 +294,14 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 				res = q.executeShowFieldKeysStatement(stmt, database)
 			case *influxql.ShowDiagnosticsStatement:
 				res = q.executeShowDiagnosticsStatement(stmt)
+			case *influxql.ShowStatsStatement:
+				res = q.executeShowStatsStatement(stmt)
+			case *influxql.BackfillContinuousQueryStatement:
+				res = q.executeBackfillContinuousQueryStatement(stmt)
+			case *influxql.ShowQueriesStatement:
+				res = q.executeShowQueriesStatement(stmt)
+			case *influxql.KillQueryStatement:
+				res = q.executeKillQueryStatement(stmt)
 			case *influxql.DeleteStatement:
 				res = &influxql.Result{Err: ErrInvalidQuery}
 			case *influxql.DropDatabaseStatement:
@@ -178,37 +312,80 @@ func (q *QueryExecutor) ExecuteQuery(query *influxql.Query, database string, chu
 				res = q.MetaStatementExecutor.ExecuteStatement(stmt)
 			}
 
+			// Plans resolved against the old retention policies are discarded.
+			if q.PlanCache != nil {
+				switch stmt.(type) {
+				case *influxql.CreateRetentionPolicyStatement, *influxql.AlterRetentionPolicyStatement,
+					*influxql.DropRetentionPolicyStatement, *influxql.DropDatabaseStatement:
+					q.PlanCache.Purge()
+				}
+			}
+
 			if res != nil {
+				q.Stats.Record(stmt, time.Since(start), res.Err)
+
 				// set the StatementID for the handler on the other side to combine results
 				res.StatementID = i
 
 				// If an error occurs then stop processing remaining statements.
-				results <- res
+				if res.Err == ErrQueryCanceled || !sendResult(results, res, interrupt) {
+					interrupted = true
+					break
+				}
 				if res.Err != nil {
 					break
 				}
 			}
 		}
 
+		// Nothing more is sent for a canceled query. A query that was killed
+		// or timed out reports it for the statement that was interrupted.
+		if interrupted {
+			if canceled(closing) {
+				return
+			}
+			err := ErrQueryTimeout
+			if canceled(kill) {
+				err = ErrQueryKilled
+			}
+			if !sendResult(results, &influxql.Result{StatementID: i, Err: err}, closing) {
+				return
+			}
+		}
+
 		// if there was an error send results that the remaining statements weren't executed
 		for ; i < len(query.Statements)-1; i++ {
-			results <- &influxql.Result{Err: ErrNotExecuted}
+			if !sendResult(results, &influxql.Result{Err: ErrNotExecuted}, closing) {
+				return
+			}
 		}
-
-		close(results)
 	}()
 
 	return results, nil
 }
 
 // executeSelectStatement plans and executes a select statement against a database.
-func (q *QueryExecutor) executeSelectStatement(statementID int, stmt *influxql.SelectStatement, results chan *influxql.Result, chunkSize int) error {
+func (q *QueryExecutor) executeSelectStatement(statementID int, stmt *influxql.SelectStatement, results chan *influxql.Result, chunkSize int, closing <-chan struct{}) error {
 	// Perform any necessary query re-writing.
 	stmt, err := q.rewriteSelectStatement(stmt)
 	if err != nil {
 		return err
 	}
 
+	// Reject the statement if it's too expensive to execute.
+	if q.hasCostLimits() {
+		cost, err := q.EstimateCost(stmt)
+		if err != nil {
+			return err
+		} else if err := q.checkCost(cost); err != nil {
+			return err
+		}
+	}
+
+	if q.IteratorEngine && query.Supported(stmt) == nil {
+		return q.executeIteratorStatement(statementID, stmt, results, chunkSize, closing)
+	}
+
 	// Plan statement execution.
 	p := influxql.NewPlanner(q)
 	e, err := p.Plan(stmt, chunkSize)
@@ -216,80 +393,310 @@ func (q *QueryExecutor) executeSelectStatement(statementID int, stmt *influxql.S
 		return err
 	}
 
-	// Execute plan.
-	ch := e.Execute()
+	// Execute plan. Execution is stopped, and its mappers released, if this
+	// returns before every row is read.
+	stop := make(chan struct{})
+	defer close(stop)
+	ch := e.Execute(stop)
 
 	// Stream results from the channel. We should send an empty result if nothing comes through.
 	resultSent := false
-	for row := range ch {
-		if row.Err != nil {
+	for {
+		var row *influxql.Row
+		select {
+		case row = <-ch:
+		case <-closing:
+			return ErrQueryCanceled
+		}
+
+		if row == nil {
+			break
+		} else if row.Err != nil {
 			return row.Err
-		} else {
-			resultSent = true
-			results <- &influxql.Result{StatementID: statementID, Series: []*influxql.Row{row}}
+		}
+
+		// Rows are sent in batches of up to chunkSize values so that callers
+		// can stream large results instead of holding them in memory.
+		resultSent = true
+		for _, row := range row.Chunk(chunkSize) {
+			if !sendResult(results, &influxql.Result{StatementID: statementID, Series: []*influxql.Row{row}}, closing) {
+				return ErrQueryCanceled
+			}
 		}
 	}
 
 	if !resultSent {
-		results <- &influxql.Result{StatementID: statementID, Series: make([]*influxql.Row, 0)}
+		if !sendResult(results, &influxql.Result{StatementID: statementID, Series: make([]*influxql.Row, 0)}, closing) {
+			return ErrQueryCanceled
+		}
 	}
 
 	return nil
 }
 
+// executeIteratorStatement executes a select statement with the iterators of
+// the query package. Each row is sent as soon as its last point is read.
+func (q *QueryExecutor) executeIteratorStatement(statementID int, stmt *influxql.SelectStatement, results chan *influxql.Result, chunkSize int, closing <-chan struct{}) error {
+	now := time.Now().UTC()
+	stmt = stmt.Reduce(&influxql.NowValuer{Now: now})
+
+	// Reject the same names as the map/reduce engine.
+	for _, src := range stmt.Sources {
+		mm := src.(*influxql.Measurement)
+		m := q.store.Measurement(mm.Database, mm.Name)
+		if m == nil {
+			return ErrMeasurementNotFound(influxql.QuoteIdent([]string{mm.Database, "", mm.Name}...))
+		}
+		if _, _, _, err := selectNames(m, stmt); err != nil {
+			return err
+		}
+	}
+
+	src := &storeSource{
+		meta:            q.MetaStore,
+		store:           q.store,
+		now:             now,
+		concurrency:     q.ShardConcurrency,
+		closing:         closing,
+		authorizeSeries: q.SeriesAuthorizer,
+	}
+	itr, err := query.Compile(stmt, src, query.CompileOptions{
+		MaxSortMemory: q.MaxSortMemory,
+		TempDir:       q.SortTempDir,
+		Closing:       closing,
+		Now:           now,
+	})
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	e, err := query.NewEmitter(itr, stmt, now)
+	if err != nil {
+		return err
+	}
+
+	resultSent := false
+	for {
+		if canceled(closing) {
+			return ErrQueryCanceled
+		}
+
+		row, err := e.Emit()
+		if err != nil {
+			return err
+		} else if row == nil {
+			break
+		}
+
+		resultSent = true
+		for _, row := range row.Chunk(chunkSize) {
+			if !sendResult(results, &influxql.Result{StatementID: statementID, Series: []*influxql.Row{row}}, closing) {
+				return ErrQueryCanceled
+			}
+		}
+	}
+
+	if !resultSent {
+		if !sendResult(results, &influxql.Result{StatementID: statementID, Series: make([]*influxql.Row, 0)}, closing) {
+			return ErrQueryCanceled
+		}
+	}
+	return nil
+}
+
+// sendResult sends a result unless the query is canceled first. Returns
+// false if the result wasn't sent.
+func sendResult(results chan *influxql.Result, res *influxql.Result, closing <-chan struct{}) bool {
+	select {
+	case results <- res:
+		return true
+	case <-closing:
+		return false
+	}
+}
+
+// canceled returns true if closing has been closed.
+func canceled(closing <-chan struct{}) bool {
+	select {
+	case <-closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// interruptAfter returns a channel that is closed when closing or kill is
+// closed or, for a positive timeout, when the timeout expires. Calling stop
+// releases the timer once the query is done.
+func interruptAfter(closing, kill <-chan struct{}, timeout time.Duration) (interrupt <-chan struct{}, stop func()) {
+	ch := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		var expired <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		select {
+		case <-closing:
+		case <-kill:
+		case <-expired:
+		case <-done:
+			return
+		}
+		close(ch)
+	}()
+	return ch, func() { close(done) }
+}
+
+// executeExplainStatement returns the shards, estimated series, and iterator
+// tree that would be used to execute a select statement. The statement is only
+// executed for EXPLAIN ANALYZE, which adds the counters of each iterator.
+func (q *QueryExecutor) executeExplainStatement(stmt *influxql.ExplainStatement, closing <-chan struct{}) *influxql.Result {
+	// Perform the same re-writing as executing the statement would.
+	s, err := q.rewriteSelectStatement(stmt.Statement)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+
+	// One row per source with the shards it touches and its estimated cost.
+	cost, err := q.EstimateCost(s)
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+	sources := &influxql.Row{
+		Name:    "sources",
+		Columns: []string{"database", "retention_policy", "measurement", "shards", "series", "groups", "points", "access"},
+	}
+	for i, c := range cost.Sources {
+		sources.Values = append(sources.Values, []interface{}{c.Database, c.RetentionPolicy, c.Measurement, c.ShardIDs, c.SeriesN, c.GroupN, c.PointN, c.Access()})
+
+		// Scan the retention policy the shards are selected from.
+		m := *s.Sources[i].(*influxql.Measurement)
+		m.RetentionPolicy = c.RetentionPolicy
+		s.Sources[i] = &m
+	}
+
+	// One row per operator in the iterator tree, indented by depth.
+	src := &storeSource{
+		meta:            q.MetaStore,
+		store:           q.store,
+		now:             time.Now(),
+		concurrency:     q.ShardConcurrency,
+		closing:         closing,
+		authorizeSeries: q.SeriesAuthorizer,
+	}
+	opt := query.CompileOptions{MaxSortMemory: q.MaxSortMemory, TempDir: q.SortTempDir, Closing: closing}
+	var plan *query.Plan
+	if stmt.Analyze {
+		// The statement is executed so it's subject to the same limits.
+		if err := q.checkCost(cost); err != nil {
+			return &influxql.Result{Err: err}
+		}
+		plan, err = query.Analyze(s, src, opt)
+	} else {
+		plan, err = query.Explain(s, src, opt)
+	}
+	if err != nil {
+		return &influxql.Result{Err: err}
+	}
+	iterators := &influxql.Row{Name: "iterators", Columns: []string{"plan"}}
+	for _, line := range plan.Lines() {
+		iterators.Values = append(iterators.Values, []interface{}{line})
+	}
+
+	return &influxql.Result{Series: influxql.Rows{sources, iterators}}
+}
+
 // rewriteSelectStatement performs any necessary query re-writing.
+// The sources and wildcards are resolved once for statements of the same shape
+// while the schema doesn't change.
 func (q *QueryExecutor) rewriteSelectStatement(stmt *influxql.SelectStatement) (*influxql.SelectStatement, error) {
-	var err error
+	if q.PlanCache == nil {
+		p, err := q.planSelectStatement(stmt)
+		if err != nil {
+			return nil, err
+		}
+		return p.apply(stmt), nil
+	}
+
+	key := newPlanKey(stmt)
+	p := q.PlanCache.Get(key, q.store)
+	if p == nil {
+		var err error
+		if p, err = q.planSelectStatement(stmt); err != nil {
+			return nil, err
+		}
+		q.PlanCache.Put(key, p)
+	}
+	return p.apply(stmt), nil
+}
+
+// planSelectStatement resolves the regex sources and wildcards of a statement.
+func (q *QueryExecutor) planSelectStatement(stmt *influxql.SelectStatement) (*selectPlan, error) {
+	p := &selectPlan{
+		indexes:  make(map[string]*DatabaseIndex),
+		versions: make(map[string]uint64),
+		complete: true,
+	}
+
+	// Record the schema versions before reading the indexes so that
+	// changes made while resolving the plan invalidate it.
+	for _, src := range stmt.Sources {
+		if m, ok := src.(*influxql.Measurement); ok {
+			if idx := q.store.DatabaseIndex(m.Database); idx == nil {
+				p.complete = false
+			} else if _, ok := p.indexes[m.Database]; !ok {
+				p.indexes[m.Database] = idx
+				p.versions[m.Database] = idx.SchemaVersion()
+			}
+		}
+	}
 
 	// Expand regex expressions in the FROM clause.
 	sources, err := q.expandSources(stmt.Sources)
 	if err != nil {
 		return nil, err
 	}
-	stmt.Sources = sources
+	p.sources = sources
 
 	// Expand wildcards in the fields or GROUP BY.
 	if stmt.HasWildcard() {
-		stmt, err = q.expandWildcards(stmt)
+		p.fields, p.dimensions, p.wildcard, err = q.expandWildcards(sources)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	stmt.RewriteDistinct()
-
-	return stmt, nil
+	return p, nil
 }
 
-// expandWildcards returns a new SelectStatement with wildcards in the fields
-// and/or GROUP BY exapnded with actual field names.
-func (q *QueryExecutor) expandWildcards(stmt *influxql.SelectStatement) (*influxql.SelectStatement, error) {
-	// If there are no wildcards in the statement, return it as-is.
-	if !stmt.HasWildcard() {
-		return stmt, nil
-	}
-
+// expandWildcards returns the field names and tag keys that wildcards in the
+// fields and/or GROUP BY of a statement reading sources expand to. Returns
+// false if a database hasn't been written to yet, in which case wildcards
+// aren't expanded.
+func (q *QueryExecutor) expandWildcards(sources influxql.Sources) (fields, dimensions []string, ok bool, err error) {
 	// Use sets to avoid duplicate field names.
 	fieldSet := map[string]struct{}{}
 	dimensionSet := map[string]struct{}{}
 
-	var fields influxql.Fields
-	var dimensions influxql.Dimensions
-
 	// Iterate measurements in the FROM clause getting the fields & dimensions for each.
-	for _, src := range stmt.Sources {
+	for _, src := range sources {
 		if m, ok := src.(*influxql.Measurement); ok {
 			// Lookup the database. The database may not exist if no data for this database
 			// was ever written to the shard.
 			db := q.store.DatabaseIndex(m.Database)
 			if db == nil {
-				return stmt, nil
+				return nil, nil, false, nil
 			}
 
 			// Lookup the measurement in the database.
-			mm := db.measurements[m.Name]
+			mm := db.Measurement(m.Name)
 			if mm == nil {
-				return nil, ErrMeasurementNotFound(m.String())
+				return nil, nil, false, ErrMeasurementNotFound(m.String())
 			}
 
 			// Get the fields for this measurement.
@@ -298,7 +705,7 @@ func (q *QueryExecutor) expandWildcards(stmt *influxql.SelectStatement) (*influx
 					continue
 				}
 				fieldSet[name] = struct{}{}
-				fields = append(fields, &influxql.Field{Expr: &influxql.VarRef{Val: name}})
+				fields = append(fields, name)
 			}
 
 			// Get the dimensions for this measurement.
@@ -307,13 +714,12 @@ func (q *QueryExecutor) expandWildcards(stmt *influxql.SelectStatement) (*influx
 					continue
 				}
 				dimensionSet[t] = struct{}{}
-				dimensions = append(dimensions, &influxql.Dimension{Expr: &influxql.VarRef{Val: t}})
+				dimensions = append(dimensions, t)
 			}
 		}
 	}
 
-	// Return a new SelectStatement with the wild cards rewritten.
-	return stmt.RewriteWildcards(fields, dimensions), nil
+	return fields, dimensions, true, nil
 }
 
 // expandSources expands regex sources and removes duplicates.
@@ -934,6 +1340,68 @@ func (q *QueryExecutor) executeShowDiagnosticsStatement(stmt *influxql.ShowDiagn
 	return &influxql.Result{Err: fmt.Errorf("SHOW DIAGNOSTICS is not implemented yet")}
 }
 
+// StatsReporter is implemented by services reporting counters in SHOW STATS.
+type StatsReporter interface {
+	StatsRow() *influxql.Row
+}
+
+// executeShowStatsStatement returns the statistics of the statements executed
+// by this node, by statement type and by query fingerprint, followed by the
+// counters of each service reporting them.
+func (q *QueryExecutor) executeShowStatsStatement(stmt *influxql.ShowStatsStatement) *influxql.Result {
+	if stmt.Host != "" {
+		return &influxql.Result{Err: fmt.Errorf("SHOW STATS ON is not implemented yet")}
+	}
+
+	rows := make([]*influxql.Row, 0)
+	if q.Stats != nil {
+		rows = append(rows, q.Stats.Rows()...)
+	}
+	for _, r := range q.StatsReporters {
+		rows = append(rows, r.StatsRow())
+	}
+	return &influxql.Result{Series: rows}
+}
+
+// executeShowQueriesStatement lists the queries being executed by this node.
+func (q *QueryExecutor) executeShowQueriesStatement(stmt *influxql.ShowQueriesStatement) *influxql.Result {
+	if q.Tasks == nil {
+		return &influxql.Result{Series: influxql.Rows{}}
+	}
+	return &influxql.Result{Series: influxql.Rows{q.Tasks.Row()}}
+}
+
+// executeKillQueryStatement aborts a query being executed by this node.
+func (q *QueryExecutor) executeKillQueryStatement(stmt *influxql.KillQueryStatement) *influxql.Result {
+	if q.Tasks == nil {
+		return &influxql.Result{Err: fmt.Errorf("no such query id: %d", stmt.QueryID)}
+	}
+	if err := q.Tasks.Kill(stmt.QueryID); err != nil {
+		return &influxql.Result{Err: err}
+	}
+	return &influxql.Result{}
+}
+
+// executeBackfillContinuousQueryStatement runs a continuous query over the
+// time range of the statement's condition. The range ends at the current time
+// if the condition has no upper bound. Returns once the range is computed.
+func (q *QueryExecutor) executeBackfillContinuousQueryStatement(stmt *influxql.BackfillContinuousQueryStatement) *influxql.Result {
+	if q.ContinuousQuerier == nil {
+		return &influxql.Result{Err: ErrContinuousQueriesDisabled}
+	}
+
+	// The condition's upper bound is inclusive, so the range ends just after it.
+	start, end := influxql.TimeRange(influxql.Reduce(stmt.Condition, &influxql.NowValuer{Now: time.Now()}))
+	if !end.IsZero() {
+		end = end.Add(time.Microsecond)
+	}
+
+	if err := q.ContinuousQuerier.Backfill(stmt.Database, stmt.Name, start, end); err != nil {
+		return &influxql.Result{Err: err}
+	}
+	return &influxql.Result{}
+}
+
 // ErrAuthorize represents an authorization error.
 type ErrAuthorize struct {
 	text string
@@ -954,8 +1422,26 @@ var (
 	// ErrNotExecuted is returned when a statement is not executed in a query.
 	// This can occur when a previous statement in the same query has errored.
 	ErrNotExecuted = errors.New("not executed")
+
+	// ErrQueryCanceled is returned when a query is canceled while executing.
+	ErrQueryCanceled = query.ErrQueryCanceled
+
+	// ErrQueryTimeout is returned when a query runs longer than its timeout.
+	ErrQueryTimeout = errors.New("query timeout")
+
+	// ErrQueryKilled is returned when a query is aborted by KILL QUERY.
+	ErrQueryKilled = errors.New("query killed")
+
+	// ErrContinuousQueriesDisabled is returned when backfilling a continuous
+	// query on a node that doesn't run continuous queries.
+	ErrContinuousQueriesDisabled = errors.New("continuous queries are disabled")
 )
 
 func ErrDatabaseNotFound(name string) error { return fmt.Errorf("database not found: %s", name) }
 
 func ErrMeasurementNotFound(name string) error { return fmt.Errorf("measurement not found: %s", name) }
+
+// IsMeasurementNotFound returns true if err was returned by ErrMeasurementNotFound.
+func IsMeasurementNotFound(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "measurement not found: ")
+}
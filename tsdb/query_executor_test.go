@@ -1,15 +1,19 @@
 package tsdb
 
 import (
+	"fmt"
 	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/influxdb/influxdb/influxql"
 	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/query"
 )
 
 var shardID = uint64(1)
@@ -19,7 +23,7 @@ func TestWritePointsAndExecuteQuery(t *testing.T) {
 	defer os.RemoveAll(store.path)
 
 	// Write first point.
-	if err := store.WriteToShard(shardID, []Point{NewPoint(
+	if err := store.WriteToShard(shardID, []Point{MustNewPoint(
 		"cpu",
 		map[string]string{"host": "server"},
 		map[string]interface{}{"value": 1.0},
@@ -29,7 +33,7 @@ func TestWritePointsAndExecuteQuery(t *testing.T) {
 	}
 
 	// Write second point.
-	if err := store.WriteToShard(shardID, []Point{NewPoint(
+	if err := store.WriteToShard(shardID, []Point{MustNewPoint(
 		"cpu",
 		map[string]string{"host": "server"},
 		map[string]interface{}{"value": 1.0},
@@ -57,13 +61,203 @@ func TestWritePointsAndExecuteQuery(t *testing.T) {
 	}
 }
 
+// Ensure a query stops sending results once it's canceled.
+func TestWritePointsAndExecuteQuery_Canceled(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	var points []Point
+	for i := 0; i < 10; i++ {
+		points = append(points, MustNewPoint("cpu", map[string]string{"host": fmt.Sprintf("server%d", i)}, map[string]interface{}{"value": 1.0}, time.Unix(int64(i), 0)))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	closing := make(chan struct{})
+	ch, err := executor.ExecuteQuery(mustParseQuery("select value from cpu group by *; select value from cpu"), "foo", 1, 0, closing)
+	if err != nil {
+		t.Fatal(err)
+	} else if res := <-ch; res.Err != nil || len(res.Series) != 1 {
+		t.Fatalf("unexpected result: %s", mustMarshalJSON(res))
+	}
+
+	// The remaining series and statements aren't sent once canceled. A
+	// result may already be in flight when the query is canceled.
+	close(closing)
+	timeout := time.After(time.Second)
+	for n := 1; ; n++ {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			} else if n >= 10 {
+				t.Fatal("results sent after cancel")
+			}
+		case <-timeout:
+			t.Fatal("results not closed")
+		}
+	}
+}
+
+// Ensure the rows of a statement are sent in batches of up to the chunk size.
+func TestWritePointsAndExecuteQuery_ChunkSize(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	// The points must be within the shard group of the metastore.
+	start := time.Now().Add(-time.Minute).Truncate(time.Second).UTC()
+	var points []Point
+	for i := 0; i < 5; i++ {
+		points = append(points, MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": float64(i)}, start.Add(time.Duration(i)*time.Second)))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	q := fmt.Sprintf("select count(value) from cpu where time >= '%s' and time < '%s' group by time(1s)", start.Format(time.RFC3339), start.Add(5*time.Second).Format(time.RFC3339))
+	ch, err := executor.ExecuteQuery(mustParseQuery(q), "foo", 2, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int
+	for res := range ch {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		for _, row := range res.Series {
+			sizes = append(sizes, len(row.Values))
+		}
+	}
+	if exp := []int{2, 2, 1}; !reflect.DeepEqual(exp, sizes) {
+		t.Fatalf("unexpected row sizes: %v", sizes)
+	}
+}
+
+// Ensure a query running longer than its timeout is aborted with ErrQueryTimeout.
+func TestWritePointsAndExecuteQuery_Timeout(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	var points []Point
+	for i := 0; i < 10; i++ {
+		points = append(points, MustNewPoint("cpu", map[string]string{"host": fmt.Sprintf("server%d", i)}, map[string]interface{}{"value": 1.0}, time.Unix(int64(i), 0)))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	ch, err := executor.ExecuteQuery(mustParseQuery("select value from cpu group by *; select value from cpu"), "foo", 1, 10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if res := <-ch; res.Err != nil || len(res.Series) != 1 {
+		t.Fatalf("unexpected result: %s", mustMarshalJSON(res))
+	}
+
+	// Stop reading until the query times out. A result may already be in
+	// flight, after which the timeout is reported for the first statement
+	// and the second isn't executed.
+	time.Sleep(50 * time.Millisecond)
+	var errs []error
+	for res := range ch {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+	}
+	if len(errs) != 2 || errs[0] != ErrQueryTimeout || errs[1] != ErrNotExecuted {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+// Ensure a running query is listed by SHOW QUERIES and aborted by KILL QUERY.
+func TestWritePointsAndExecuteQuery_Kill(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	var points []Point
+	for i := 0; i < 10; i++ {
+		points = append(points, MustNewPoint("cpu", map[string]string{"host": fmt.Sprintf("server%d", i)}, map[string]interface{}{"value": 1.0}, time.Unix(int64(i), 0)))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	ch, err := executor.ExecuteQuery(mustParseQuery("select value from cpu group by *; select value from cpu"), "foo", 1, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if res := <-ch; res.Err != nil || len(res.Series) != 1 {
+		t.Fatalf("unexpected result: %s", mustMarshalJSON(res))
+	}
+
+	// The running query is listed along with SHOW QUERIES itself.
+	res := mustExecuteResult(t, executor, "show queries")
+	var ids []interface{}
+	for _, values := range res.Series[0].Values {
+		ids = append(ids, values[0])
+	}
+	if !reflect.DeepEqual(ids, []interface{}{uint64(1), uint64(2)}) || res.Series[0].Values[1][1] != "SHOW QUERIES" {
+		t.Fatalf("unexpected queries: %s", mustMarshalJSON(res))
+	}
+
+	// A result may already be in flight, after which the kill is reported
+	// for the first statement and the second isn't executed.
+	if res := mustExecuteResult(t, executor, "kill query 1"); res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	var errs []error
+	for res := range ch {
+		if res.Err != nil {
+			errs = append(errs, res.Err)
+		}
+	}
+	if len(errs) != 2 || errs[0] != ErrQueryKilled || errs[1] != ErrNotExecuted {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	// Finished queries are no longer listed.
+	if res := mustExecuteResult(t, executor, "kill query 1"); res.Err == nil || res.Err.Error() != "no such query id: 1" {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+}
+
+// Ensure that SHOW QUERIES doesn't list passwords.
+func TestWritePointsAndExecuteQuery_ShowQueriesPassword(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	var points []Point
+	for i := 0; i < 10; i++ {
+		points = append(points, MustNewPoint("cpu", map[string]string{"host": fmt.Sprintf("server%d", i)}, map[string]interface{}{"value": 1.0}, time.Unix(int64(i), 0)))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	// The query stays running until its results are read.
+	ch, err := executor.ExecuteQuery(mustParseQuery("select value from cpu group by *; set password for bob = 'secret'"), "foo", 1, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := mustExecuteResult(t, executor, "show queries")
+	if s := res.Series[0].Values[0][1]; s != "SELECT value FROM cpu GROUP BY *;\nSET PASSWORD FOR bob = '[REDACTED]'" {
+		t.Fatalf("unexpected query: %q", s)
+	}
+
+	if res := mustExecuteResult(t, executor, "kill query 1"); res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	for range ch {
+	}
+}
+
 // Ensure that points can be written and flushed even after a restart.
 func TestWritePointsAndExecuteQuery_FlushRestart(t *testing.T) {
 	store, executor := testStoreAndExecutor()
 	defer os.RemoveAll(store.path)
 
 	// Write first point.
-	if err := store.WriteToShard(shardID, []Point{NewPoint(
+	if err := store.WriteToShard(shardID, []Point{MustNewPoint(
 		"cpu",
 		map[string]string{"host": "server"},
 		map[string]interface{}{"value": 1.0},
@@ -73,7 +267,7 @@ func TestWritePointsAndExecuteQuery_FlushRestart(t *testing.T) {
 	}
 
 	// Write second point.
-	if err := store.WriteToShard(shardID, []Point{NewPoint(
+	if err := store.WriteToShard(shardID, []Point{MustNewPoint(
 		"cpu",
 		map[string]string{"host": "server"},
 		map[string]interface{}{"value": 1.0},
@@ -113,11 +307,677 @@ func TestWritePointsAndExecuteQuery_FlushRestart(t *testing.T) {
 	}
 }
 
+// Ensure that series rejected by the series authorizer are left out of results.
+func TestWritePointsAndExecuteQuery_SeriesAuthorizer(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 3)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	executor.SeriesAuthorizer = func(database, measurement string, tags map[string]string) bool {
+		if database != "foo" {
+			t.Fatalf("unexpected database: %s", database)
+		} else if measurement != "cpu" {
+			t.Fatalf("unexpected measurement: %s", measurement)
+		}
+		return tags["host"] == "serverA"
+	}
+
+	got := executeAndGetJSON("select * from cpu", executor)
+	exepected := `[{"series":[{"name":"cpu","tags":{"host":"serverA"},"columns":["time","value"],"values":[["1970-01-01T00:00:01.000000002Z",1]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("select count(value) from cpu", executor)
+	exepected = `[{"series":[{"name":"cpu","columns":["time","count_value"],"values":[["1970-01-01T00:00:00Z",1]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+}
+
+// Ensure the iterator engine returns the same results as the map/reduce engine.
+func TestWritePointsAndExecuteQuery_IteratorEngine(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+	executor.MetaStore = &shardsMetastore{shardIDs: []uint64{shardID}}
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA", "region": "east"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB", "region": "east"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverA", "region": "east"}, map[string]interface{}{"value": 3.0}, time.Unix(3, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverC", "region": "west"}, map[string]interface{}{"value": 4.0}, time.Unix(7, 0)),
+		MustNewPoint("mem", map[string]string{"host": "serverA", "region": "east"}, map[string]interface{}{"free": 5.0}, time.Unix(4, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, q := range []string{
+		`select value from cpu`,
+		`select value from cpu group by region`,
+		`select value from cpu where host = 'serverA'`,
+		`select value from cpu where value > 1`,
+		`select value from cpu limit 1 offset 1`,
+		`select value from cpu order by time desc limit 2`,
+		`select * from cpu`,
+		`select value, free from cpu, mem`,
+		`select count(value), sum(value) from cpu`,
+		`select mean(value) from cpu group by host`,
+		`select count(value) from cpu where time >= '1970-01-01T00:00:00Z' and time < '1970-01-01T00:00:10Z' group by time(2s)`,
+		`select count(value) from cpu where time >= '1970-01-01T00:00:00Z' and time < '1970-01-01T00:00:10Z' group by time(2s), region fill(none)`,
+		`select max(value) from cpu where time >= '1970-01-01T00:00:00Z' and time < '1970-01-01T00:00:10Z' group by time(2s) fill(previous)`,
+		`select sum(value) from cpu where time >= '1970-01-01T00:00:00Z' and time < '1970-01-01T00:00:10Z' group by time(2s) fill(0)`,
+	} {
+		executor.IteratorEngine = false
+		exp := executeAndGetJSON(q, executor)
+		executor.IteratorEngine = true
+		if got := executeAndGetJSON(q, executor); exp != got {
+			t.Errorf("%d. %s\nexp: %s\ngot: %s", i, q, exp, got)
+		}
+	}
+}
+
+// Ensure raw queries ordered by time descending return the latest points first
+// in chunks, with the limit and offset counting back from the latest point.
+func TestWritePointsAndExecuteQuery_Descending(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	var points []Point
+	for i := 1; i <= 6; i++ {
+		points = append(points, MustNewPoint("cpu", map[string]string{"host": fmt.Sprintf("server%d", i%2)}, map[string]interface{}{"value": float64(i)}, time.Unix(int64(i), 0)))
+	}
+	if err := store.WriteToShard(shardID, points); err != nil {
+		t.Fatal(err)
+	}
+
+	got := executeAndGetJSON("select value from cpu order by time desc limit 2 offset 1", executor)
+	exp := `[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:05Z",5],["1970-01-01T00:00:04Z",4]]}]}]`
+	if exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+
+	ch, err := executor.ExecuteQuery(mustParseQuery("select value from cpu order by time desc"), "foo", 2, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values [][][]interface{}
+	for res := range ch {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		for _, row := range res.Series {
+			if len(row.Values) == 0 {
+				continue
+			}
+			var a [][]interface{}
+			for _, v := range row.Values {
+				a = append(a, []interface{}{v[0].(time.Time).Unix(), v[1]})
+			}
+			values = append(values, a)
+		}
+	}
+	if exp := [][][]interface{}{{{int64(6), 6.0}, {int64(5), 5.0}}, {{int64(4), 4.0}, {int64(3), 3.0}}, {{int64(2), 2.0}, {int64(1), 1.0}}}; !reflect.DeepEqual(values, exp) {
+		t.Fatalf("unexpected chunks: %v", values)
+	}
+}
+
+// Ensure EXPLAIN returns the shards, series, and iterators used by a query.
+func TestExplainStatement(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 3)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	got := executeAndGetJSON("explain select value from cpu where host = 'serverA'", executor)
+	exepected := `[{"series":[{"name":"sources","columns":["database","retention_policy","measurement","shards","series","groups","points","access"],"values":[["foo","bar","cpu",[1],1,1,1,"index"]]},{"name":"iterators","columns":["plan"],"values":[["sort ascending=true"],["  project fields=value"],["    merge"],["      scan measurement=\"foo\".\"bar\".cpu names=value,host time=[0, 9223372036854775807] condition=host = 'serverA'"]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+}
+
+// Ensure EXPLAIN ANALYZE executes the query and reports the counters of each iterator.
+func TestExplainStatement_Analyze(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 3)),
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 3.0}, time.Unix(3, 4)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ch, err := executor.ExecuteQuery(mustParseQuery("explain analyze select value from cpu where host = 'serverA'"), "foo", 20, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := <-ch
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	} else if len(res.Series) != 2 || res.Series[1].Name != "iterators" {
+		t.Fatalf("unexpected series: %s", mustMarshalJSON(res.Series))
+	}
+
+	// Durations vary so only the operators and point counts are checked. The
+	// condition on tags is pushed down so only matching series are scanned.
+	var lines []string
+	for _, values := range res.Series[1].Values {
+		line := values[0].(string)
+		lines = append(lines, line[:strings.LastIndex(line, " time=")])
+	}
+	if exp := []string{
+		"sort ascending=true (points=2 blocks=0",
+		"  project fields=value (points=2 blocks=0",
+		"    merge (points=2 blocks=0",
+		"      scan measurement=\"foo\".\"bar\".cpu names=value,host time=[0, 9223372036854775807] condition=host = 'serverA' (points=2 blocks=2",
+	}; !reflect.DeepEqual(exp, lines) {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+// Ensure EXPLAIN ANALYZE evaluates pushed down tag predicates when scanning every series.
+func TestExplainStatement_Analyze_Scan(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 3)),
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 3.0}, time.Unix(3, 4)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ch, err := executor.ExecuteQuery(mustParseQuery("explain analyze select value from cpu where host != 'serverC' and value > 1"), "foo", 20, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := <-ch
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	} else if len(res.Series) != 2 {
+		t.Fatalf("unexpected series: %s", mustMarshalJSON(res.Series))
+	} else if access := res.Series[0].Values[0][7]; access != "scan" {
+		t.Fatalf("unexpected access: %v", access)
+	}
+
+	var lines []string
+	for _, values := range res.Series[1].Values {
+		line := values[0].(string)
+		lines = append(lines, line[:strings.LastIndex(line, " time=")])
+	}
+	if exp := []string{
+		"sort ascending=true (points=2 blocks=0",
+		"  project fields=value (points=2 blocks=0",
+		"    merge (points=2 blocks=0",
+		"      filter condition=value > 1 (points=2 blocks=0",
+		"        scan measurement=\"foo\".\"bar\".cpu names=value,host time=[0, 9223372036854775807] condition=host != 'serverC' (points=3 blocks=3",
+	}; !reflect.DeepEqual(exp, lines) {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+// Ensure EXPLAIN ANALYZE pushes the limit of a raw query down into the shards.
+func TestExplainStatement_Analyze_Limit(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 3)),
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 3.0}, time.Unix(3, 4)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ch, err := executor.ExecuteQuery(mustParseQuery("explain analyze select value from cpu order by time desc limit 1"), "foo", 20, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := <-ch
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	} else if len(res.Series) != 2 {
+		t.Fatalf("unexpected series: %s", mustMarshalJSON(res.Series))
+	}
+
+	// Each series is read backwards within the shard so only the last point
+	// of each series is decoded. The series are merged and limited to the
+	// last point of the measurement.
+	var lines []string
+	for _, values := range res.Series[1].Values {
+		line := values[0].(string)
+		lines = append(lines, line[:strings.LastIndex(line, " time=")])
+	}
+	if exp := []string{
+		"limit limit=1 offset=0 (points=1 blocks=0",
+		"  project fields=value (points=2 blocks=0",
+		"    merge sorted=true (points=2 blocks=0",
+		"      scan measurement=\"foo\".\"bar\".cpu names=value time=[0, 9223372036854775807] limit=1 ascending=false (points=2 blocks=2",
+	}; !reflect.DeepEqual(exp, lines) {
+		t.Fatalf("unexpected plan:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+// Ensure shards read concurrently are merged by series and time.
+func TestStoreSource_ShardConcurrency(t *testing.T) {
+	store, _ := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	// Spread the points of each series across three shards.
+	shardIDs := []uint64{1, 2, 3}
+	for _, id := range shardIDs[1:] {
+		if err := store.CreateShard("foo", "bar", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 9; i++ {
+		host := fmt.Sprintf("server%d", i%2)
+		if err := store.WriteToShard(shardIDs[i%3], []Point{
+			MustNewPoint("cpu", map[string]string{"host": host}, map[string]interface{}{"value": float64(i)}, time.Unix(int64(i), 0)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, concurrency := range []int{1, 2, 8} {
+		src := &storeSource{
+			meta:        &shardsMetastore{shardIDs: shardIDs},
+			store:       store,
+			now:         time.Now(),
+			concurrency: concurrency,
+		}
+		itr, err := src.CreateIterator(query.IteratorOptions{
+			Database:        "foo",
+			RetentionPolicy: "bar",
+			Measurement:     "cpu",
+			Names:           []string{"value"},
+			EndTime:         math.MaxInt64,
+			Ascending:       true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for {
+			p, err := itr.Next()
+			if err != nil {
+				t.Fatal(err)
+			} else if p == nil {
+				break
+			}
+			got = append(got, fmt.Sprintf("%s/%d", p.Tags["host"], p.Time/int64(time.Second)))
+		}
+		itr.Close()
+
+		if exp := []string{
+			"server0/0", "server0/2", "server0/4", "server0/6", "server0/8",
+			"server1/1", "server1/3", "server1/5", "server1/7",
+		}; !reflect.DeepEqual(exp, got) {
+			t.Errorf("concurrency=%d: unexpected points: %v", concurrency, got)
+		}
+		if stats := itr.(query.StatsIterator).Stats(); stats.PointN != 9 || stats.BlockN != 9 {
+			t.Errorf("concurrency=%d: unexpected stats: %s", concurrency, stats.String())
+		}
+	}
+}
+
+// Ensure the series of each group are merged by time when ordered by group.
+func TestStoreSource_Ordered(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA", "region": "east"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB", "region": "east"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverC", "region": "west"}, map[string]interface{}{"value": 3.0}, time.Unix(3, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverA", "region": "east"}, map[string]interface{}{"value": 4.0}, time.Unix(4, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &storeSource{meta: executor.MetaStore, store: store, now: time.Now(), concurrency: 1}
+	itr, err := src.CreateIterator(query.IteratorOptions{
+		Database:        "foo",
+		RetentionPolicy: "bar",
+		Measurement:     "cpu",
+		Names:           []string{"value"},
+		EndTime:         math.MaxInt64,
+		Ordered:         true,
+		Dimensions:      []string{"region"},
+		Ascending:       false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+
+	var got []string
+	for {
+		p, err := itr.Next()
+		if err != nil {
+			t.Fatal(err)
+		} else if p == nil {
+			break
+		}
+		got = append(got, fmt.Sprintf("%s/%d", p.Tags["host"], p.Time/int64(time.Second)))
+	}
+	if exp := []string{"serverA/4", "serverB/2", "serverA/1", "serverC/3"}; !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected points: %v", got)
+	}
+}
+
+// Ensure the bucket and cache of a series are read backwards in descending order.
+func TestStoreSource_Descending(t *testing.T) {
+	store, _ := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	// Flush the first points into the bucket and overwrite one in the cache.
+	for i := 1; i <= 4; i++ {
+		if err := store.WriteToShard(shardID, []Point{
+			MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": float64(i)}, time.Unix(int64(i), 0)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 30.0}, time.Unix(3, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 5.0}, time.Unix(5, 0)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &storeSource{meta: &shardsMetastore{shardIDs: []uint64{shardID}}, store: store, now: time.Now()}
+	itr, err := src.CreateIterator(query.IteratorOptions{
+		Database:        "foo",
+		RetentionPolicy: "bar",
+		Measurement:     "cpu",
+		Names:           []string{"value"},
+		StartTime:       int64(2 * time.Second),
+		EndTime:         int64(4 * time.Second),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+
+	var got []string
+	for {
+		p, err := itr.Next()
+		if err != nil {
+			t.Fatal(err)
+		} else if p == nil {
+			break
+		}
+		got = append(got, fmt.Sprintf("%d=%v", p.Time/int64(time.Second), p.Values["value"]))
+	}
+	if exp := []string{"4=4", "3=30", "2=2"}; !reflect.DeepEqual(exp, got) {
+		t.Fatalf("unexpected points: %v", got)
+	}
+}
+
+// Ensure closing an iterator stops the shards that are still being read.
+func TestStoreSource_Close(t *testing.T) {
+	store, _ := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.CreateShard("foo", "bar", 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint64{shardID, 2} {
+		points := make([]Point, 2*storeBatchSize)
+		for i := range points {
+			points[i] = MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": float64(i)}, time.Unix(int64(id), int64(i)))
+		}
+		if err := store.WriteToShard(id, points); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := &storeSource{meta: &shardsMetastore{shardIDs: []uint64{shardID, 2}}, store: store, now: time.Now(), concurrency: 1}
+	itr, err := src.CreateIterator(query.IteratorOptions{
+		Database:        "foo",
+		RetentionPolicy: "bar",
+		Measurement:     "cpu",
+		Names:           []string{"value"},
+		EndTime:         math.MaxInt64,
+		Ascending:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first batch of each shard is read before closing.
+	if p, err := itr.Next(); err != nil {
+		t.Fatal(err)
+	} else if p == nil {
+		t.Fatal("expected point")
+	}
+	if err := itr.Close(); err != nil {
+		t.Fatal(err)
+	} else if stats := itr.(query.StatsIterator).Stats(); stats.PointN != 2*storeBatchSize {
+		t.Fatalf("unexpected stats: %s", stats.String())
+	}
+}
+
+// Ensure the cost of a query is estimated from the index.
+func TestQueryExecutor_EstimateCost(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA", "region": "east"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB", "region": "east"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverC", "region": "west"}, map[string]interface{}{"value": 3.0}, time.Unix(3, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverD", "region": "west"}, map[string]interface{}{"value": 4.0}, time.Unix(4, 0)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var tests = []struct {
+		s       string
+		seriesN int
+		groupN  int
+		access  string
+	}{
+		{s: `SELECT value FROM foo..cpu`, seriesN: 4, groupN: 1, access: "scan"},
+		{s: `SELECT value FROM foo..cpu WHERE host = 'serverA'`, seriesN: 1, groupN: 1, access: "index"},
+		{s: `SELECT value FROM foo..cpu WHERE host != 'serverA'`, seriesN: 3, groupN: 1, access: "scan"},
+		{s: `SELECT value FROM foo..cpu WHERE region = 'east' AND host = 'serverA'`, seriesN: 1, groupN: 1, access: "index"},
+		{s: `SELECT value FROM foo..cpu WHERE host = 'serverA' OR host = 'serverB' GROUP BY region`, seriesN: 2, groupN: 2, access: "index"},
+		{s: `SELECT value FROM foo..cpu WHERE region =~ /e/ GROUP BY host`, seriesN: 2, groupN: 2, access: "index"},
+		{s: `SELECT value FROM foo..cpu WHERE value > 1 GROUP BY region`, seriesN: 4, groupN: 2, access: "scan"},
+		{s: `SELECT value FROM foo..mem`, seriesN: 0, groupN: 0, access: "scan"},
+	}
+
+	for i, tt := range tests {
+		cost, err := executor.EstimateCost(mustParseQuery(tt.s).Statements[0].(*influxql.SelectStatement))
+		if err != nil {
+			t.Fatalf("%d. %s: %s", i, tt.s, err)
+		} else if len(cost.Sources) != 1 {
+			t.Fatalf("%d. %s: unexpected source count: %d", i, tt.s, len(cost.Sources))
+		}
+
+		c := cost.Sources[0]
+		if c.SeriesN != tt.seriesN || c.GroupN != tt.groupN || c.Access() != tt.access {
+			t.Errorf("%d. %s: unexpected cost: exp=%d/%d/%s got=%d/%d/%s", i, tt.s, tt.seriesN, tt.groupN, tt.access, c.SeriesN, c.GroupN, c.Access())
+		}
+		if !reflect.DeepEqual(c.ShardIDs, []uint64{1}) {
+			t.Errorf("%d. %s: unexpected shards: %v", i, tt.s, c.ShardIDs)
+		}
+	}
+}
+
+// Ensure queries estimated to exceed the executor's limits are rejected.
+func TestWritePointsAndExecuteQuery_MaxSelectSeriesN(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 2)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 3)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+	executor.MaxSelectSeriesN = 1
+
+	got := executeAndGetJSON("select value from cpu where host = 'serverA'", executor)
+	exepected := `[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:01.000000002Z",1]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("select value from cpu", executor)
+	exepected = `[{"error":"max select series limit exceeded: estimated 2 series, limit 1"}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+}
+
+// Ensure queries spanning too many GROUP BY time buckets are rejected.
+func TestWritePointsAndExecuteQuery_MaxSelectBucketN(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+	executor.MaxSelectBucketN = 5
+
+	got := executeAndGetJSON("select count(value) from cpu where time >= 0s and time < 10s group by time(5s)", executor)
+	if strings.Contains(got, "error") {
+		t.Fatalf("unexpected error: %s", got)
+	}
+
+	got = executeAndGetJSON("select count(value) from cpu where time >= 0s and time < 10s group by time(1s)", executor)
+	exepected := `[{"error":"max select bucket limit exceeded: 10 GROUP BY time buckets, limit 5"}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+}
+
+// Ensure queries estimated to read too many points are rejected.
+func TestWritePointsAndExecuteQuery_MaxSelectPointN(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 3.0}, time.Unix(3, 0)),
+		MustNewPoint("cpu", map[string]string{"host": "serverB"}, map[string]interface{}{"value": 4.0}, time.Unix(4, 0)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+	executor.MaxSelectPointN = 2
+
+	got := executeAndGetJSON("select value from cpu where host = 'serverB'", executor)
+	exepected := `[{"series":[{"name":"cpu","columns":["time","value"],"values":[["1970-01-01T00:00:04Z",4]]}]}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+
+	got = executeAndGetJSON("select value from cpu", executor)
+	exepected = `[{"error":"max select point limit exceeded: estimated 4 points, limit 2"}]`
+	if exepected != got {
+		t.Fatalf("exp: %s\ngot: %s", exepected, got)
+	}
+}
+
+// Ensure SHOW STATS returns the statements executed by type and fingerprint.
+func TestShowStatsStatement(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	executeAndGetJSON("select value from cpu where host = 'serverA'", executor)
+	executeAndGetJSON("select value from cpu where host = 'serverB'", executor)
+	executeAndGetJSON("select value from cpu where value > 1", executor)
+	executeAndGetJSON("show series", executor)
+
+	ch, err := executor.ExecuteQuery(mustParseQuery("show stats"), "foo", 20, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := <-ch
+	if res.Err != nil {
+		t.Fatal(res.Err)
+	} else if len(res.Series) != 2 {
+		t.Fatalf("unexpected series: %s", mustMarshalJSON(res))
+	}
+
+	// Only the type and the counts are compared, latencies vary.
+	var types []string
+	for _, v := range res.Series[0].Values {
+		types = append(types, fmt.Sprintf("%s/%s/%d/%d", v[0], v[1], v[2], v[3]))
+	}
+	if exp := []string{"Select/read/3/0", "ShowSeries/read/1/0"}; !reflect.DeepEqual(exp, types) {
+		t.Fatalf("unexpected types: %v", types)
+	}
+
+	var fingerprints []string
+	for _, v := range res.Series[1].Values {
+		fingerprints = append(fingerprints, fmt.Sprintf("%s/%s/%d", v[1], v[2], v[3]))
+	}
+	if exp := []string{
+		`Select/SELECT value FROM "foo"."foo".cpu WHERE host = '[REDACTED]'/2`,
+		`ShowSeries/SHOW SERIES/1`,
+		`Select/SELECT value FROM "foo"."foo".cpu WHERE value > 1/1`,
+	}; !reflect.DeepEqual(exp, fingerprints) {
+		t.Fatalf("unexpected fingerprints: %v", fingerprints)
+	}
+}
+
+// Ensure SHOW STATS returns a row for each service reporting its counters.
+func TestShowStatsStatement_StatsReporters(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+	executor.Stats = nil
+	executor.StatsReporters = []StatsReporter{
+		statsReporterFunc(func() *influxql.Row {
+			return &influxql.Row{Name: "udp", Columns: []string{"packets_dropped"}, Values: [][]interface{}{{int64(2)}}}
+		}),
+	}
+
+	got := executeAndGetJSON("show stats", executor)
+	if exp := `[{"series":[{"name":"udp","columns":["packets_dropped"],"values":[[2]]}]}]`; exp != got {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+}
+
+// statsReporterFunc is a StatsReporter returning the row of a function.
+type statsReporterFunc func() *influxql.Row
+
+func (fn statsReporterFunc) StatsRow() *influxql.Row { return fn() }
+
 func TestDropSeriesStatement(t *testing.T) {
 	store, executor := testStoreAndExecutor()
 	defer os.RemoveAll(store.path)
 
-	pt := NewPoint(
+	pt := MustNewPoint(
 		"cpu",
 		map[string]string{"host": "server"},
 		map[string]interface{}{"value": 1.0},
@@ -171,13 +1031,13 @@ func TestDropMeasurementStatement(t *testing.T) {
 	store, executor := testStoreAndExecutor()
 	defer os.RemoveAll(store.path)
 
-	pt := NewPoint(
+	pt := MustNewPoint(
 		"cpu",
 		map[string]string{"host": "server"},
 		map[string]interface{}{"value": 1.0},
 		time.Unix(1, 2),
 	)
-	pt2 := NewPoint(
+	pt2 := MustNewPoint(
 		"memory",
 		map[string]string{"host": "server"},
 		map[string]interface{}{"value": 1.0},
@@ -227,6 +1087,67 @@ func TestDropMeasurementStatement(t *testing.T) {
 }
 
 // mock for the metaExecutor
+// Ensure statements of the same shape share a plan until the schema or the
+// retention policies change.
+func TestQueryExecutor_PlanCache(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+	executor.MetaStatementExecutor = &metaExec{fn: func(stmt influxql.Statement) *influxql.Result {
+		return &influxql.Result{}
+	}}
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	executeAndGetJSON("select * from cpu where host = 'serverA'", executor)
+	executeAndGetJSON("select * from cpu where host = 'serverB'", executor)
+	if n := executor.PlanCache.Len(); n != 1 {
+		t.Fatalf("unexpected plan count: %d", n)
+	}
+
+	// A new field changes the schema so the wildcard is expanded again.
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 2.0, "idle": 3.0}, time.Unix(2, 0)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+	got := executeAndGetJSON("select * from cpu where host = 'serverA'", executor)
+	if !strings.Contains(got, `"columns":["time","idle","value"]`) {
+		t.Fatalf("unexpected result: %s", got)
+	}
+
+	executeAndGetJSON("create retention policy rp0 on foo duration 1h replication 1", executor)
+	if n := executor.PlanCache.Len(); n != 0 {
+		t.Fatalf("unexpected plan count after retention policy change: %d", n)
+	}
+}
+
+// Ensure statements differing only by their regex sources use separate plans.
+func TestQueryExecutor_PlanCache_RegexSources(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if err := store.WriteToShard(shardID, []Point{
+		MustNewPoint("cpu1", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		MustNewPoint("cpu2", map[string]string{"host": "serverA"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if got, exp := executeAndGetJSON("select value from /^cpu[1]$/", executor), `[{"series":[{"name":"cpu1","columns":["time","value"],"values":[["1970-01-01T00:00:01Z",1]]}]}]`; got != exp {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+	if got, exp := executeAndGetJSON("select value from /^cpu[2]$/", executor), `[{"series":[{"name":"cpu2","columns":["time","value"],"values":[["1970-01-01T00:00:02Z",2]]}]}]`; got != exp {
+		t.Fatalf("exp: %s\ngot: %s", exp, got)
+	}
+	if n := executor.PlanCache.Len(); n != 2 {
+		t.Fatalf("unexpected plan count: %d", n)
+	}
+}
+
 type metaExec struct {
 	fn func(stmt influxql.Statement) *influxql.Result
 }
@@ -235,11 +1156,39 @@ func (m *metaExec) ExecuteStatement(stmt influxql.Statement) *influxql.Result {
 	return m.fn(stmt)
 }
 
+// Ensure BACKFILL CONTINUOUS QUERY passes the time range to the continuous querier.
+func TestBackfillContinuousQueryStatement(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+
+	if got := executeAndGetJSON("BACKFILL CONTINUOUS QUERY cq ON foo WHERE time >= '2000-01-01T00:00:00Z'", executor); got != `[{"error":"continuous queries are disabled"}]` {
+		t.Fatalf("unexpected results: %s", got)
+	}
+
+	cq := &backfiller{}
+	executor.ContinuousQuerier = cq
+	if got := executeAndGetJSON("BACKFILL CONTINUOUS QUERY cq ON foo WHERE time >= '2000-01-01T00:00:00Z' AND time < '2000-01-02T00:00:00Z'", executor); got != `[{}]` {
+		t.Fatalf("unexpected results: %s", got)
+	}
+	if exp := []string{"foo/cq/2000-01-01T00:00:00Z/2000-01-02T00:00:00Z"}; !reflect.DeepEqual(cq.calls, exp) {
+		t.Fatalf("unexpected backfills: %v", cq.calls)
+	}
+}
+
+type backfiller struct {
+	calls []string
+}
+
+func (b *backfiller) Backfill(database, name string, start, end time.Time) error {
+	b.calls = append(b.calls, fmt.Sprintf("%s/%s/%s/%s", database, name, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano)))
+	return nil
+}
+
 func TestDropDatabase(t *testing.T) {
 	store, executor := testStoreAndExecutor()
 	defer os.RemoveAll(store.path)
 
-	pt := NewPoint(
+	pt := MustNewPoint(
 		"cpu",
 		map[string]string{"host": "server"},
 		map[string]interface{}{"value": 1.0},
@@ -344,6 +1293,30 @@ func TestAuthenticateIfUserCountZeroAndCreateUser(t *testing.T) {
 	}
 }
 
+// Ensure a non-admin user is only authorized to list their own account.
+func TestAuthorizeQuery_ShowUsers(t *testing.T) {
+	store, executor := testStoreAndExecutor()
+	defer os.RemoveAll(store.path)
+	executor.MetaStore = &testMetastore{userCount: 2}
+
+	stmt := &influxql.ShowUsersStatement{}
+	if err := executor.AuthorizeQuery(&meta.UserInfo{Name: "susy", Admin: true}, stmt, ""); err != nil {
+		t.Fatal(err)
+	} else if stmt.User != "" {
+		t.Fatalf("unexpected user for admin: %s", stmt.User)
+	}
+
+	if err := executor.AuthorizeQuery(&meta.UserInfo{Name: "bob"}, stmt, ""); err != nil {
+		t.Fatal(err)
+	} else if stmt.User != "bob" {
+		t.Fatalf("unexpected user: %s", stmt.User)
+	}
+
+	if err := executor.AuthorizeQuery(&meta.UserInfo{Name: "bob"}, &influxql.DropUserStatement{Name: "susy"}, ""); err == nil {
+		t.Fatal("expected authorization error")
+	}
+}
+
 func testStoreAndExecutor() (*Store, *QueryExecutor) {
 	path, _ := ioutil.TempDir("", "")
 
@@ -364,7 +1337,7 @@ func testStoreAndExecutor() (*Store, *QueryExecutor) {
 }
 
 func executeAndGetJSON(query string, executor *QueryExecutor) string {
-	ch, err := executor.ExecuteQuery(mustParseQuery(query), "foo", 20)
+	ch, err := executor.ExecuteQuery(mustParseQuery(query), "foo", 20, 0, nil)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -376,6 +1349,18 @@ func executeAndGetJSON(query string, executor *QueryExecutor) string {
 	return string(mustMarshalJSON(results))
 }
 
+// mustExecuteResult executes a single statement and returns its result.
+func mustExecuteResult(t *testing.T, executor *QueryExecutor, q string) *influxql.Result {
+	ch, err := executor.ExecuteQuery(mustParseQuery(q), "", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := <-ch
+	for range ch {
+	}
+	return res
+}
+
 type testMetastore struct {
 	userCount int
 }
@@ -441,6 +1426,25 @@ func (t *testMetastore) UserCount() (int, error) {
 	return t.userCount, nil
 }
 
+// shardsMetastore is a testMetastore whose retention policy has a single
+// shard group holding a set of shards.
+type shardsMetastore struct {
+	testMetastore
+	shardIDs []uint64
+}
+
+func (t *shardsMetastore) RetentionPolicy(database, name string) (*meta.RetentionPolicyInfo, error) {
+	g := meta.ShardGroupInfo{
+		ID:        uint64(1),
+		StartTime: time.Unix(0, 0),
+		EndTime:   time.Now().Add(time.Hour),
+	}
+	for _, id := range t.shardIDs {
+		g.Shards = append(g.Shards, meta.ShardInfo{ID: id, OwnerIDs: []uint64{1}})
+	}
+	return &meta.RetentionPolicyInfo{Name: "bar", ShardGroups: []meta.ShardGroupInfo{g}}, nil
+}
+
 // MustParseQuery parses an InfluxQL query. Panic on error.
 func mustParseQuery(s string) *influxql.Query {
 	q, err := influxql.NewParser(strings.NewReader(s)).ParseQuery()
@@ -0,0 +1,183 @@
+package tsdb
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// MaxQueryFingerprints is the maximum number of query fingerprints tracked by
+// QueryStats. Statements with new fingerprints are only counted by type once
+// the limit is reached.
+const MaxQueryFingerprints = 1000
+
+// queryLatencyBuckets are the upper bounds of the latency histogram buckets.
+// Statements taking longer than the last bound are counted in a final bucket.
+var queryLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// QueryStats records the number of statements executed and their latencies,
+// keyed by statement type and by query fingerprint.
+type QueryStats struct {
+	mu           sync.Mutex
+	types        map[string]*statementStats
+	fingerprints map[uint64]*statementStats
+}
+
+// NewQueryStats returns a new instance of QueryStats.
+func NewQueryStats() *QueryStats {
+	return &QueryStats{
+		types:        make(map[string]*statementStats),
+		fingerprints: make(map[uint64]*statementStats),
+	}
+}
+
+// statementStats holds the counters of a statement type or fingerprint.
+type statementStats struct {
+	typ   string
+	kind  influxql.StatementKind
+	query string // sanitized example of the statements with a fingerprint
+
+	n        int64
+	errN     int64
+	duration time.Duration
+	max      time.Duration
+	buckets  []int64
+}
+
+// record adds a single execution to the counters.
+func (s *statementStats) record(d time.Duration, err error) {
+	s.n++
+	if err != nil {
+		s.errN++
+	}
+	s.duration += d
+	if d > s.max {
+		s.max = d
+	}
+
+	i := sort.Search(len(queryLatencyBuckets), func(i int) bool { return d <= queryLatencyBuckets[i] })
+	s.buckets[i]++
+}
+
+// values returns the counters as row values following the columns of a type.
+func (s *statementStats) values() []interface{} {
+	var mean time.Duration
+	if s.n > 0 {
+		mean = s.duration / time.Duration(s.n)
+	}
+
+	values := []interface{}{s.n, s.errN, mean.String(), s.max.String()}
+	for _, n := range s.buckets {
+		values = append(values, n)
+	}
+	return values
+}
+
+// Record adds the execution of a statement taking d to the statistics.
+// A nil QueryStats records nothing.
+func (s *QueryStats) Record(stmt influxql.Statement, d time.Duration, err error) {
+	if s == nil {
+		return
+	}
+	typ := statementName(stmt)
+	fingerprint := influxql.Fingerprint(stmt)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.types[typ]
+	if t == nil {
+		t = newStatementStats(typ, influxql.StatementType(stmt), "")
+		s.types[typ] = t
+	}
+	t.record(d, err)
+
+	f := s.fingerprints[fingerprint]
+	if f == nil {
+		if len(s.fingerprints) >= MaxQueryFingerprints {
+			return
+		}
+		f = newStatementStats(typ, influxql.StatementType(stmt), influxql.SanitizeStatement(stmt, true).String())
+		s.fingerprints[fingerprint] = f
+	}
+	f.record(d, err)
+}
+
+// Rows returns the statistics as a row of statement types, sorted by type,
+// and a row of fingerprints, sorted by count in descending order.
+func (s *QueryStats) Rows() influxql.Rows {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	columns := []string{"count", "errors", "mean", "max"}
+	for _, d := range queryLatencyBuckets {
+		columns = append(columns, "le_"+d.String())
+	}
+	columns = append(columns, "gt_"+queryLatencyBuckets[len(queryLatencyBuckets)-1].String())
+
+	types := &influxql.Row{Name: "statements", Columns: append([]string{"type", "kind"}, columns...)}
+	names := make([]string, 0, len(s.types))
+	for typ := range s.types {
+		names = append(names, typ)
+	}
+	sort.Strings(names)
+	for _, typ := range names {
+		t := s.types[typ]
+		types.Values = append(types.Values, append([]interface{}{typ, t.kind.String()}, t.values()...))
+	}
+
+	fingerprints := &influxql.Row{Name: "fingerprints", Columns: append([]string{"fingerprint", "type", "query"}, columns...)}
+	keys := make([]uint64, 0, len(s.fingerprints))
+	for k := range s.fingerprints {
+		keys = append(keys, k)
+	}
+	sort.Sort(fingerprintsByCount{keys: keys, m: s.fingerprints})
+	for _, k := range keys {
+		f := s.fingerprints[k]
+		fingerprints.Values = append(fingerprints.Values, append([]interface{}{fmt.Sprintf("%016x", k), f.typ, f.query}, f.values()...))
+	}
+
+	return influxql.Rows{types, fingerprints}
+}
+
+// newStatementStats returns counters for a statement type or fingerprint.
+func newStatementStats(typ string, kind influxql.StatementKind, query string) *statementStats {
+	return &statementStats{typ: typ, kind: kind, query: query, buckets: make([]int64, len(queryLatencyBuckets)+1)}
+}
+
+// fingerprintsByCount sorts fingerprints by their count in descending order.
+// Fingerprints with the same count are sorted by their value.
+type fingerprintsByCount struct {
+	keys []uint64
+	m    map[uint64]*statementStats
+}
+
+func (a fingerprintsByCount) Len() int      { return len(a.keys) }
+func (a fingerprintsByCount) Swap(i, j int) { a.keys[i], a.keys[j] = a.keys[j], a.keys[i] }
+func (a fingerprintsByCount) Less(i, j int) bool {
+	if n, m := a.m[a.keys[i]].n, a.m[a.keys[j]].n; n != m {
+		return n > m
+	}
+	return a.keys[i] < a.keys[j]
+}
+
+// statementName returns the name of the type of a statement without its
+// "Statement" suffix, e.g. "Select" or "ShowSeries".
+func statementName(stmt influxql.Statement) string {
+	t := reflect.TypeOf(stmt)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.TrimSuffix(t.Name(), "Statement")
+}
@@ -0,0 +1,95 @@
+package tsdb
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+)
+
+// QueryTasks tracks the queries being executed so they can be listed by SHOW
+// QUERIES and aborted by KILL QUERY.
+type QueryTasks struct {
+	mu     sync.Mutex
+	nextID uint64
+	tasks  map[uint64]*queryTask
+}
+
+// NewQueryTasks returns a new instance of QueryTasks.
+func NewQueryTasks() *QueryTasks {
+	return &QueryTasks{tasks: make(map[uint64]*queryTask)}
+}
+
+// queryTask is a query being executed.
+type queryTask struct {
+	query    string
+	database string
+	start    time.Time
+
+	// closed when the query is killed
+	kill   chan struct{}
+	killed bool
+}
+
+// add registers a query and returns its id along with a channel that is
+// closed if it's killed.
+func (t *QueryTasks) add(query, database string) (id uint64, kill <-chan struct{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nextID++
+	task := &queryTask{query: query, database: database, start: time.Now(), kill: make(chan struct{})}
+	t.tasks[t.nextID] = task
+	return t.nextID, task.kill
+}
+
+// remove unregisters a query once it's done.
+func (t *QueryTasks) remove(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.tasks, id)
+}
+
+// Kill aborts the query with an id. The query stops at its next check for
+// interruption and reports ErrQueryKilled.
+func (t *QueryTasks) Kill(id uint64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	task := t.tasks[id]
+	if task == nil {
+		return fmt.Errorf("no such query id: %d", id)
+	}
+	if !task.killed {
+		close(task.kill)
+		task.killed = true
+	}
+	return nil
+}
+
+// Row returns the queries being executed ordered by id.
+func (t *QueryTasks) Row() *influxql.Row {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := make([]uint64, 0, len(t.tasks))
+	for id := range t.tasks {
+		ids = append(ids, id)
+	}
+	sort.Sort(uint64Slice(ids))
+
+	row := &influxql.Row{Name: "queries", Columns: []string{"qid", "query", "database", "duration"}}
+	for _, id := range ids {
+		task := t.tasks[id]
+		row.Values = append(row.Values, []interface{}{id, task.query, task.database, time.Since(task.start).String()})
+	}
+	return row
+}
+
+type uint64Slice []uint64
+
+func (a uint64Slice) Len() int           { return len(a) }
+func (a uint64Slice) Less(i, j int) bool { return a[i] < a[j] }
+func (a uint64Slice) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
@@ -611,7 +611,7 @@ func (s *Shard) createFieldsAndMeasurements(fieldsToCreate []*fieldCreate) (map[
 
 		// ensure the measurement is in the index and the field is there
 		measurement := s.index.createMeasurementIndexIfNotExists(f.measurement)
-		measurement.fieldNames[f.field.Name] = struct{}{}
+		measurement.setFieldName(f.field.Name)
 	}
 
 	return measurementsToSave, nil
@@ -680,7 +680,7 @@ func (s *Shard) loadMetadataIndex() error {
 				return err
 			}
 			for name, _ := range mf.Fields {
-				m.fieldNames[name] = struct{}{}
+				m.setFieldName(name)
 			}
 			mf.codec = newFieldCodec(mf.Fields)
 			s.measurementFields[string(k)] = mf
@@ -1200,6 +1200,63 @@ func (sc *shardCursor) Next() (key, value []byte) {
 	return sc.read()
 }
 
+// SeekReverse moves the cursor to the last position at or before seek and
+// returns its key/value pair. Use Prev() to iterate backwards from there.
+func (sc *shardCursor) SeekReverse(seek []byte) (key, value []byte) {
+	// Seek bolt cursor to the last key at or before seek.
+	if sc.cursor != nil {
+		sc.buf.key, sc.buf.value = sc.cursor.Seek(seek)
+		if sc.buf.key == nil {
+			sc.buf.key, sc.buf.value = sc.cursor.Last()
+		} else if bytes.Compare(sc.buf.key, seek) == 1 {
+			sc.buf.key, sc.buf.value = sc.cursor.Prev()
+		}
+	}
+
+	// Seek cache index to the last key at or before seek.
+	sc.index = sort.Search(len(sc.cache), func(i int) bool {
+		return bytes.Compare(sc.cache[i][0:8], seek) == 1
+	}) - 1
+
+	return sc.readReverse()
+}
+
+// Prev returns the previous key/value pair from the cursor.
+func (sc *shardCursor) Prev() (key, value []byte) {
+	// Read previous bolt key/value if not bufferred.
+	if sc.buf.key == nil && sc.cursor != nil {
+		sc.buf.key, sc.buf.value = sc.cursor.Prev()
+	}
+
+	return sc.readReverse()
+}
+
+// readReverse returns the previous key/value in the cursor buffer or cache.
+func (sc *shardCursor) readReverse() (key, value []byte) {
+	// If neither a buffer or cache exists then return nil.
+	if sc.buf.key == nil && sc.index < 0 {
+		return nil, nil
+	}
+
+	// Use the buffer if it exists and there's no cache or if it is higher than the cache.
+	if sc.buf.key != nil && (sc.index < 0 || bytes.Compare(sc.buf.key, sc.cache[sc.index][0:8]) == 1) {
+		key, value = sc.buf.key, sc.buf.value
+		sc.buf.key, sc.buf.value = nil, nil
+		return
+	}
+
+	// Otherwise read from the cache. The last of duplicate keys is the latest
+	// write so skip back through the others, and the bucket's if it has one.
+	key, value = sc.cache[sc.index][0:8], sc.cache[sc.index][8:]
+	for sc.index--; sc.index >= 0 && bytes.Equal(key, sc.cache[sc.index][0:8]); sc.index-- {
+	}
+	if bytes.Equal(key, sc.buf.key) {
+		sc.buf.key, sc.buf.value = nil, nil
+	}
+
+	return
+}
+
 // read returns the next key/value in the cursor buffer or cache.
 func (sc *shardCursor) read() (key, value []byte) {
 	// If neither a buffer or cache exists then return nil.
@@ -0,0 +1,415 @@
+package tsdb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdb/influxdb/influxql"
+	"github.com/influxdb/influxdb/meta"
+	"github.com/influxdb/influxdb/query"
+)
+
+// storeSource reads points from the shards of the local store. It is used to
+// execute statements with the iterators of the query package.
+type storeSource struct {
+	meta  metaStore
+	store *Store
+	now   time.Time
+
+	// maximum number of shards read at the same time
+	concurrency int
+
+	// closed when the query is canceled
+	closing <-chan struct{}
+
+	// optional check of whether each series may be read
+	authorizeSeries func(database, measurement string, tags map[string]string) bool
+}
+
+// CreateIterator returns an iterator over the points of a measurement in
+// every local shard overlapping the time range of opt.
+func (s *storeSource) CreateIterator(opt query.IteratorOptions) (query.Iterator, error) {
+	rp, err := s.meta.RetentionPolicy(opt.Database, opt.RetentionPolicy)
+	if err != nil {
+		return nil, err
+	} else if rp == nil {
+		return nil, meta.ErrRetentionPolicyNotFound
+	}
+
+	// Find the local shards within the time range.
+	tmin, tmax := time.Unix(0, opt.StartTime), time.Unix(0, opt.EndTime)
+	if tmax.After(s.now) {
+		tmax = s.now
+	}
+	var shards []*Shard
+	for _, g := range rp.ShardGroups {
+		if !g.Overlaps(tmin, tmax) {
+			continue
+		}
+		for _, sh := range g.Shards {
+			if shard := s.store.Shard(sh.ID); shard != nil {
+				shards = append(shards, shard)
+			}
+		}
+	}
+
+	return &storeIterator{source: s, opt: opt, shards: shards}, nil
+}
+
+// TagKeys returns the tag keys of a measurement.
+func (s *storeSource) TagKeys(database, retentionPolicy, measurement string) ([]string, error) {
+	m := s.store.Measurement(database, measurement)
+	if m == nil {
+		return nil, nil
+	}
+	return m.TagKeys(), nil
+}
+
+// series returns the series of a measurement that match a condition on tags
+// and may be read. If the condition is estimated to match few series then the
+// matching series are looked up in the index. Otherwise the condition is
+// evaluated against the tags of every series.
+func (s *storeSource) series(database string, m *Measurement, cond influxql.Expr) ([]*Series, error) {
+	var indexDriven bool
+	if cond != nil {
+		_, _, indexDriven = m.estimate(cond, nil)
+	}
+
+	m.index.mu.RLock()
+	defer m.index.mu.RUnlock()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := m.seriesIDs
+	if indexDriven {
+		var err error
+		if ids, _, err = m.walkWhereForSeriesIds(cond); err != nil {
+			return nil, err
+		}
+	}
+
+	a := make([]*Series, 0, len(ids))
+	for _, id := range ids {
+		series := m.seriesByID[id]
+		if cond != nil && !indexDriven {
+			if ok, err := m.matchTags(cond, series.Tags); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+		}
+		if s.authorizeSeries != nil && !s.authorizeSeries(database, m.Name, series.Tags) {
+			continue
+		}
+		a = append(a, series)
+	}
+	return a, nil
+}
+
+// matchTags returns true if the tags of a series match a condition on tags.
+// Tags the series doesn't have are treated as blank, as they are by the index.
+func (m *Measurement) matchTags(cond influxql.Expr, tags map[string]string) (bool, error) {
+	values := make(map[string]interface{}, len(m.seriesByTagKeyValue))
+	for k := range m.seriesByTagKeyValue {
+		values[k] = ""
+	}
+	for k, v := range tags {
+		values[k] = v
+	}
+	return influxql.EvalBool(cond, values)
+}
+
+// storeBatchSize is the number of points sent at a time by the goroutine
+// reading a shard.
+const storeBatchSize = 1000
+
+// storeIterator returns the points of a measurement from a set of shards.
+// Each shard is read by its own goroutine, which streams the points of its
+// series in batches. The shards are opened on the first call to Next().
+type storeIterator struct {
+	source *storeSource
+	opt    query.IteratorOptions
+	shards []*Shard
+
+	itr   query.Iterator
+	stats query.IteratorStats
+
+	// closed to stop the goroutines reading the shards
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Next returns the next point.
+func (itr *storeIterator) Next() (*query.Point, error) {
+	if itr.itr == nil {
+		input, err := itr.open()
+		if err != nil {
+			return nil, err
+		}
+		itr.itr = input
+	}
+	return itr.itr.Next()
+}
+
+// Close stops reading the shards and waits for their transactions to close.
+func (itr *storeIterator) Close() error {
+	if itr.done != nil {
+		close(itr.done)
+		itr.wg.Wait()
+		itr.done = nil
+	}
+	return nil
+}
+
+// Stats returns the number of points read and values decoded.
+func (itr *storeIterator) Stats() query.IteratorStats { return itr.stats }
+
+// open starts reading every shard and returns an iterator that merges the
+// shards in order. Up to the source's concurrency of shards decode points at
+// the same time. If the options have a limit then only that many points are
+// read from each series in each shard.
+//
+// Points are ordered by series unless the options ask for them to be ordered
+// by group, in which case the series of each group are merged by time.
+func (itr *storeIterator) open() (query.Iterator, error) {
+	m := itr.source.store.Measurement(itr.opt.Database, itr.opt.Measurement)
+	if m == nil {
+		return query.NewSliceIterator(nil), nil
+	}
+	series, err := itr.source.series(itr.opt.Database, m, itr.opt.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	n := itr.source.concurrency
+	if n <= 0 {
+		n = 1
+	}
+	throttle := make(chan struct{}, n)
+
+	itr.done = make(chan struct{})
+	inputs := make([]query.Iterator, len(itr.shards))
+	for i, sh := range itr.shards {
+		ch := make(chan shardBatch, 1)
+		inputs[i] = &shardIterator{ch: ch, stats: &itr.stats}
+
+		itr.wg.Add(1)
+		go func(sh *Shard) {
+			defer itr.wg.Done()
+			defer close(ch)
+			itr.readShard(sh, m.Name, series, throttle, ch)
+		}(sh)
+	}
+
+	var input query.Iterator
+	if itr.opt.Ordered {
+		input = query.NewGroupMergeIterator(inputs, itr.opt.Dimensions, itr.opt.Ascending)
+	} else {
+		input = query.NewSortedMergeIterator(inputs, itr.opt.Ascending)
+	}
+	if itr.opt.Limit > 0 {
+		input = query.NewLimitIterator(input, itr.opt.Limit, 0)
+	}
+	return input, nil
+}
+
+// shardBatch holds points read from a single shard.
+type shardBatch struct {
+	points []*query.Point
+	blockN int
+	err    error
+}
+
+// readShard sends the points of each series in a single shard to ch in the
+// order of the options. A slot of the throttle is held while decoding each
+// batch. Returns once every point is sent, after an error, or when the
+// iterator is closed.
+func (itr *storeIterator) readShard(sh *Shard, name string, series []*Series, throttle chan struct{}, ch chan<- shardBatch) {
+	send := func(b shardBatch) bool {
+		select {
+		case ch <- b:
+			return true
+		case <-itr.done:
+			return false
+		}
+	}
+
+	// Ignore shards that have no fields for the measurement.
+	codec := sh.FieldCodec(name)
+	if codec == nil {
+		return
+	}
+
+	// Obtain shard lock to copy in-cache points.
+	sh.mu.Lock()
+	txn, err := sh.db.Begin(false)
+	if err != nil {
+		sh.mu.Unlock()
+		send(shardBatch{err: err})
+		return
+	}
+	defer txn.Rollback()
+
+	// Build a cursor that merges the bucket and cache together for each series.
+	var blockN int
+	inputs := make([]query.Iterator, len(series))
+	for i, s := range series {
+		partitionID := WALPartition([]byte(s.Key))
+		cache := make([][]byte, len(sh.cache[partitionID][s.Key]))
+		copy(cache, sh.cache[partitionID][s.Key])
+
+		cur := &shardCursor{cache: cache}
+		if b := txn.Bucket([]byte(s.Key)); b != nil {
+			cur.cursor = b.Cursor()
+		}
+		inputs[i] = &seriesIterator{cursor: cur, codec: codec, name: name, tags: s.Tags, opt: &itr.opt, blockN: &blockN}
+	}
+	sh.mu.Unlock()
+
+	var input query.Iterator
+	if itr.opt.Ordered {
+		input = query.NewGroupMergeIterator(inputs, itr.opt.Dimensions, itr.opt.Ascending)
+	} else {
+		input = query.NewSortedMergeIterator(inputs, itr.opt.Ascending)
+	}
+
+	for {
+		select {
+		case throttle <- struct{}{}:
+		case <-itr.done:
+			return
+		}
+
+		var b shardBatch
+		for len(b.points) < storeBatchSize {
+			if canceled(itr.source.closing) {
+				b.err = ErrQueryCanceled
+				break
+			}
+			p, err := input.Next()
+			if err != nil {
+				b.err = err
+				break
+			} else if p == nil {
+				break
+			}
+			b.points = append(b.points, p)
+		}
+		b.blockN, blockN = blockN, 0
+		<-throttle
+
+		if !send(b) || b.err != nil || len(b.points) < storeBatchSize {
+			return
+		}
+	}
+}
+
+// shardIterator returns the points sent by the goroutine reading a shard.
+type shardIterator struct {
+	ch     <-chan shardBatch
+	points []*query.Point
+	stats  *query.IteratorStats
+}
+
+// Next returns the next point of the current batch, receiving the next batch
+// once it's empty.
+func (itr *shardIterator) Next() (*query.Point, error) {
+	for len(itr.points) == 0 {
+		b, ok := <-itr.ch
+		if !ok {
+			return nil, nil
+		} else if b.err != nil {
+			return nil, b.err
+		}
+		itr.stats.PointN += len(b.points)
+		itr.stats.BlockN += b.blockN
+		itr.points = b.points
+	}
+
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return p, nil
+}
+
+// Close is a no-op. The goroutine is stopped by the storeIterator.
+func (itr *shardIterator) Close() error { return nil }
+
+// seriesIterator returns the points of a series in a shard within the time
+// range of the options. Points without any of the names in the options are
+// skipped so they don't count towards the limit.
+type seriesIterator struct {
+	cursor *shardCursor
+	codec  *FieldCodec
+	name   string
+	tags   map[string]string
+	opt    *query.IteratorOptions
+
+	// number of values decoded by every series of the shard
+	blockN *int
+
+	init bool
+	eof  bool
+	n    int
+}
+
+// Next returns the next point of the series.
+func (itr *seriesIterator) Next() (*query.Point, error) {
+	if itr.eof || (itr.opt.Limit > 0 && itr.n >= itr.opt.Limit) {
+		return nil, nil
+	}
+
+	for k, v := itr.next(); k != nil; k, v = itr.next() {
+		timestamp := int64(btou64(k))
+		if (itr.opt.Ascending && timestamp > itr.opt.EndTime) || (!itr.opt.Ascending && timestamp < itr.opt.StartTime) {
+			break
+		}
+
+		values, err := itr.codec.DecodeFieldsWithNames(v)
+		if err != nil {
+			return nil, err
+		}
+		*itr.blockN++
+
+		if !hasAnyName(values, itr.opt.Names) {
+			continue
+		}
+
+		tags := make(map[string]string, len(itr.tags))
+		for key, value := range itr.tags {
+			tags[key] = value
+		}
+		itr.n++
+		return &query.Point{Name: itr.name, Tags: tags, Time: timestamp, Values: values}, nil
+	}
+
+	itr.eof = true
+	return nil, nil
+}
+
+// next moves the cursor in the order of the options and returns its
+// key/value pair. The first call seeks to the start of the time range.
+func (itr *seriesIterator) next() (key, value []byte) {
+	if !itr.init {
+		itr.init = true
+		if itr.opt.Ascending {
+			return itr.cursor.Seek(u64tob(uint64(itr.opt.StartTime)))
+		}
+		return itr.cursor.SeekReverse(u64tob(uint64(itr.opt.EndTime)))
+	} else if itr.opt.Ascending {
+		return itr.cursor.Next()
+	}
+	return itr.cursor.Prev()
+}
+
+// Close is a no-op.
+func (itr *seriesIterator) Close() error { return nil }
+
+// hasAnyName returns true if values has a value for any of the names.
+func hasAnyName(values map[string]interface{}, names []string) bool {
+	for _, name := range names {
+		if _, ok := values[name]; ok {
+			return true
+		}
+	}
+	return false
+}
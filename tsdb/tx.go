@@ -22,6 +22,9 @@ type tx struct {
 
 	meta  metaStore
 	store localStore
+
+	// optional check of whether each series may be read
+	authorizeSeries func(database, measurement string, tags map[string]string) bool
 }
 
 type metaStore interface {
@@ -46,6 +49,71 @@ func newTx(meta metaStore, store localStore) *tx {
 // SetNow sets the current time for the transaction.
 func (tx *tx) SetNow(now time.Time) { tx.now = now }
 
+// shardGroupsByCondition returns the shard groups of rp that overlap the time
+// intervals of timeCond. Groups in the gaps between OR-ed time ranges are skipped.
+func shardGroupsByCondition(rp *meta.RetentionPolicyInfo, timeCond influxql.Expr, now time.Time) []*meta.ShardGroupInfo {
+	intervals := influxql.TimeIntervals(timeCond)
+	var shardGroups []*meta.ShardGroupInfo
+	for _, group := range rp.ShardGroups {
+		for _, i := range intervals {
+			min, max := i.Min, i.Max
+			if max.IsZero() {
+				max = now
+			}
+			if min.IsZero() {
+				min = time.Unix(0, 0)
+			}
+
+			if group.Overlaps(min, max) {
+				g := group
+				shardGroups = append(shardGroups, &g)
+				break
+			}
+		}
+	}
+	return shardGroups
+}
+
+// selectNames returns the fields and tags of a measurement used by the select
+// and where clauses of a statement. Returns an error if a name isn't a field or
+// tag of the measurement or if the statement groups by a field.
+func selectNames(m *Measurement, stmt *influxql.SelectStatement) (selectFields, whereFields, selectTags []string, err error) {
+	for _, n := range stmt.NamesInSelect() {
+		if m.HasField(n) {
+			selectFields = append(selectFields, n)
+			continue
+		}
+		if !m.HasTagKey(n) {
+			return nil, nil, nil, fmt.Errorf("unknown field or tag name in select clause: %s", n)
+		}
+		selectTags = append(selectTags, n)
+	}
+	for _, n := range stmt.NamesInWhere() {
+		if n == "time" {
+			continue
+		}
+		if m.HasField(n) {
+			whereFields = append(whereFields, n)
+			continue
+		}
+		if !m.HasTagKey(n) {
+			return nil, nil, nil, fmt.Errorf("unknown field or tag name in where clause: %s", n)
+		}
+	}
+
+	if len(selectFields) == 0 && len(stmt.FunctionCalls()) == 0 {
+		return nil, nil, nil, fmt.Errorf("select statement must include at least one field or function call")
+	}
+
+	// Validate that group by is not a field
+	for _, n := range stmt.NamesInDimension() {
+		if !m.HasTagKey(n) {
+			return nil, nil, nil, fmt.Errorf("can not use field in group by clause: %s", n)
+		}
+	}
+	return selectFields, whereFields, selectTags, nil
+}
+
 // CreateMappers will create a set of mappers that need to be run to execute the map phase of a MapReduceJob.
 func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []string) ([]*influxql.MapReduceJob, error) {
 	jobs := []*influxql.MapReduceJob{}
@@ -68,65 +136,33 @@ func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []stri
 		tx.measurement = m
 
 		// Validate the fields and tags asked for exist and keep track of which are in the select vs the where
-		var selectFields []string
-		var whereFields []string
-		var selectTags []string
-
-		for _, n := range stmt.NamesInSelect() {
-			if m.HasField(n) {
-				selectFields = append(selectFields, n)
-				continue
-			}
-			if !m.HasTagKey(n) {
-				return nil, fmt.Errorf("unknown field or tag name in select clause: %s", n)
-			}
-			selectTags = append(selectTags, n)
-			tagKeys = append(tagKeys, n)
-		}
-		for _, n := range stmt.NamesInWhere() {
-			if n == "time" {
-				continue
-			}
-			if m.HasField(n) {
-				whereFields = append(whereFields, n)
-				continue
-			}
-			if !m.HasTagKey(n) {
-				return nil, fmt.Errorf("unknown field or tag name in where clause: %s", n)
-			}
-		}
-
-		if len(selectFields) == 0 && len(stmt.FunctionCalls()) == 0 {
-			return nil, fmt.Errorf("select statement must include at least one field or function call")
+		selectFields, whereFields, selectTags, err := selectNames(m, stmt)
+		if err != nil {
+			return nil, err
 		}
+		tagKeys = append(tagKeys, selectTags...)
 
-		// Validate that group by is not a field
-		for _, d := range stmt.Dimensions {
-			switch e := d.Expr.(type) {
-			case *influxql.VarRef:
-				if !m.HasTagKey(e.Val) {
-					return nil, fmt.Errorf("can not use field in group by clause: %s", e.Val)
-				}
-			}
+		// Only the time portion of the condition is used to select shards.
+		timeCond, _, err := influxql.ConditionExpr(stmt.Condition)
+		if err != nil {
+			return nil, err
 		}
 
 		// Grab time range from statement.
-		tmin, tmax := influxql.TimeRange(stmt.Condition)
-		if tmax.IsZero() {
-			tmax = tx.now
-		}
-		if tmin.IsZero() {
-			tmin = time.Unix(0, 0)
-		}
-
-		// Find shard groups within time range.
-		var shardGroups []*meta.ShardGroupInfo
-		for _, group := range rp.ShardGroups {
-			if group.Overlaps(tmin, tmax) {
-				g := group
-				shardGroups = append(shardGroups, &g)
+		tmin, tmax := time.Unix(0, 0), tx.now
+		if min, max, hasMin, hasMax, err := influxql.TimeRangeAsEpoch(timeCond); err != nil {
+			return nil, err
+		} else {
+			if hasMin {
+				tmin = time.Unix(0, min)
+			}
+			if hasMax {
+				tmax = time.Unix(0, max)
 			}
 		}
+
+		// Find shard groups within the time intervals of the condition.
+		shardGroups := shardGroupsByCondition(rp, timeCond, tx.now)
 		if len(shardGroups) == 0 {
 			return nil, nil
 		}
@@ -145,6 +181,11 @@ func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []stri
 			return nil, err
 		}
 
+		// remove any series that can't be read by the query.
+		if tx.authorizeSeries != nil {
+			tagSets = tx.filterTagSets(mm.Database, m, tagSets)
+		}
+
 		for _, t := range tagSets {
 			// make a job for each tagset
 			job := &influxql.MapReduceJob{
@@ -191,6 +232,7 @@ func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []stri
 					tmin:         tmin.UnixNano(),
 					tmax:         tmax.UnixNano(),
 					interval:     interval,
+					descending:   stmt.IsRawQuery && stmt.IsDescending(),
 					// multiple mappers may need to be merged together to get the results
 					// for a raw query. So each mapper will have to read at least the
 					// limit plus the offset in data points to ensure we've hit our mark
@@ -211,6 +253,24 @@ func (tx *tx) CreateMapReduceJobs(stmt *influxql.SelectStatement, tagKeys []stri
 	return jobs, nil
 }
 
+// filterTagSets returns the tag sets with only the series that the series
+// authorizer allows to be read. Tag sets left without any series are removed.
+func (tx *tx) filterTagSets(database string, m *Measurement, tagSets []*influxql.TagSet) []*influxql.TagSet {
+	var a []*influxql.TagSet
+	for _, t := range tagSets {
+		other := &influxql.TagSet{Tags: t.Tags, Key: t.Key}
+		for i, key := range t.SeriesKeys {
+			if tx.authorizeSeries(database, m.Name, m.seriesTags(key)) {
+				other.AddFilter(key, t.Filters[i])
+			}
+		}
+		if len(other.SeriesKeys) > 0 {
+			a = append(a, other)
+		}
+	}
+	return a
+}
+
 // LocalMapper implements the influxql.Mapper interface for running map tasks over a shard that is local to this server
 type LocalMapper struct {
 	cursorsEmpty     bool                   // boolean that lets us know if the cursors are empty
@@ -234,10 +294,12 @@ type LocalMapper struct {
 	selectFields     []string               // field names that occur in the select clause
 	selectTags       []string               // tag keys that occur in the select clause
 	isRaw            bool                   // if the query is a non-aggregate query
+	descending       bool                   // if the raw query reads the latest values first
 	interval         int64                  // the group by interval of the query, if any
 	limit            uint64                 // used for raw queries for LIMIT
 	perIntervalLimit int                    // used for raw queries to determine how far into a chunk we are
 	chunkSize        int                    // used for raw queries to determine how much data to read before flushing to client
+	filterErr        error                  // the first error evaluating a filter, returned by NextInterval
 }
 
 // Open opens the LocalMapper.
@@ -361,7 +423,12 @@ func (l *LocalMapper) Begin(c *influxql.Call, startingTime int64, chunkSize int)
 			l.valueBuffer[i] = nil
 			continue
 		}
-		k, v := c.Seek(u64tob(uint64(l.job.TMin)))
+		var k, v []byte
+		if l.descending {
+			k, v = c.SeekReverse(u64tob(uint64(l.job.TMax)))
+		} else {
+			k, v = c.Seek(u64tob(uint64(l.job.TMin)))
+		}
 		if k == nil {
 			l.keyBuffer[i] = 0
 			l.valueBuffer[i] = nil
@@ -402,6 +469,9 @@ func (l *LocalMapper) NextInterval() (interface{}, error) {
 
 	// Execute the map function. This local mapper acts as the iterator
 	val := l.mapFunc(l)
+	if l.filterErr != nil {
+		return nil, l.filterErr
+	}
 
 	// see if all the cursors are empty
 	l.cursorsEmpty = true
@@ -423,17 +493,28 @@ func (l *LocalMapper) NextInterval() (interface{}, error) {
 // Next returns the next matching timestamped value for the LocalMapper.
 func (l *LocalMapper) Next() (seriesKey string, timestamp int64, value interface{}) {
 	for {
+		// stop iterating once a filter can't be evaluated
+		if l.filterErr != nil {
+			return "", int64(0), nil
+		}
+
 		// if it's a raw query and we've hit the limit of the number of points to read in
 		// for either this chunk or for the absolute query, bail
 		if l.isRaw && (l.limit == 0 || l.perIntervalLimit == 0) {
 			return "", int64(0), nil
 		}
 
-		// find the minimum timestamp
+		// find the minimum timestamp, or the maximum if the latest values are read first
 		min := -1
 		minKey := int64(math.MaxInt64)
+		if l.descending {
+			minKey = math.MinInt64
+		}
 		for i, k := range l.keyBuffer {
-			if k != 0 && k <= l.tmax && k < minKey && k >= l.tmin {
+			if k == 0 || k > l.tmax || k < l.tmin {
+				continue
+			}
+			if (!l.descending && k < minKey) || (l.descending && k > minKey) {
 				min = i
 				minKey = k
 			}
@@ -457,7 +538,7 @@ func (l *LocalMapper) Next() (seriesKey string, timestamp int64, value interface
 
 				// if there's a where clause, make sure we don't need to filter this value
 				if l.filters[min] != nil {
-					if !matchesWhere(l.filters[min], fieldsWithNames) {
+					if !l.matchesWhere(l.filters[min], fieldsWithNames) {
 						value = nil
 					}
 				}
@@ -465,16 +546,17 @@ func (l *LocalMapper) Next() (seriesKey string, timestamp int64, value interface
 		} else {
 			value, err = l.decoder.DecodeByID(l.fieldID, l.valueBuffer[min])
 
-			// if there's a where clase, see if we need to filter
-			if l.filters[min] != nil {
+			// if there's a where clase, see if we need to filter. points without the
+			// field are skipped anyway, and their zero value can't be compared.
+			if err == nil && l.filters[min] != nil {
 				// see if the where is only on this field or on one or more other fields. if the latter, we'll have to decode everything
 				if len(l.whereFields) == 1 && l.whereFields[0] == l.fieldName {
-					if !matchesWhere(l.filters[min], map[string]interface{}{l.fieldName: value}) {
+					if !l.matchesWhere(l.filters[min], map[string]interface{}{l.fieldName: value}) {
 						value = nil
 					}
 				} else { // decode everything
 					fieldsWithNames, err := l.decoder.DecodeFieldsWithNames(l.valueBuffer[min])
-					if err != nil || !matchesWhere(l.filters[min], fieldsWithNames) {
+					if err != nil || !l.matchesWhere(l.filters[min], fieldsWithNames) {
 						value = nil
 					}
 				}
@@ -482,7 +564,12 @@ func (l *LocalMapper) Next() (seriesKey string, timestamp int64, value interface
 		}
 
 		// advance the cursor
-		nextKey, nextVal := l.cursors[min].Next()
+		var nextKey, nextVal []byte
+		if l.descending {
+			nextKey, nextVal = l.cursors[min].Prev()
+		} else {
+			nextKey, nextVal = l.cursors[min].Next()
+		}
 		if nextKey == nil {
 			l.keyBuffer[min] = 0
 		} else {
@@ -522,12 +609,14 @@ func (l *LocalMapper) IsEmpty(tmax int64) bool {
 	return true
 }
 
-// matchesFilter returns true if the value matches the where clause
-func matchesWhere(f influxql.Expr, fields map[string]interface{}) bool {
-	if ok, _ := influxql.Eval(f, fields).(bool); !ok {
-		return false
+// matchesWhere returns true if the value matches the where clause. The first error
+// evaluating the where clause is saved so it can be returned by NextInterval.
+func (l *LocalMapper) matchesWhere(f influxql.Expr, fields map[string]interface{}) bool {
+	ok, err := influxql.EvalBool(f, fields)
+	if err != nil && l.filterErr == nil {
+		l.filterErr = err
 	}
-	return true
+	return ok
 }
 
 // btou64 converts an 8-byte slice into an uint64.
@@ -0,0 +1,189 @@
+package tsdb
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWriteBatchSize is the default number of points a WriteBuffer
+	// coalesces into a single write to a shard.
+	DefaultWriteBatchSize = 5000
+
+	// DefaultWriteBatchTimeout is the default time a WriteBuffer waits for
+	// other writes to a shard before writing a batch.
+	DefaultWriteBatchTimeout = 10 * time.Millisecond
+)
+
+var (
+	// ErrWriteBufferFull is returned when a write is rejected because too
+	// many points are waiting to be written.
+	ErrWriteBufferFull = errors.New("write buffer full")
+)
+
+// WriteBuffer coalesces concurrent writes to the same shard into larger
+// batches so many small writes, such as those of agents writing a few points
+// every second, cost a single write to the store. A write returns once the
+// batch holding it has been written.
+//
+// A batch is written once it holds BatchSize points or once its first write
+// has waited BatchTimeout. While MaxPendingPoints points are waiting to be
+// written, writes are rejected with ErrWriteBufferFull so clients back off
+// instead of piling up.
+type WriteBuffer struct {
+	mu      sync.Mutex
+	batches map[uint64]*writeBatch
+	pending int
+	closed  bool
+
+	BatchSize    int
+	BatchTimeout time.Duration
+
+	// Maximum number of points waiting to be written. Zero means no limit.
+	MaxPendingPoints int
+
+	Store interface {
+		CreateShard(database, retentionPolicy string, shardID uint64) error
+		WriteToShard(shardID uint64, points []Point) error
+	}
+}
+
+// NewWriteBuffer returns a new instance of WriteBuffer writing to store.
+func NewWriteBuffer(store *Store) *WriteBuffer {
+	return &WriteBuffer{
+		batches:      make(map[uint64]*writeBatch),
+		BatchSize:    DefaultWriteBatchSize,
+		BatchTimeout: DefaultWriteBatchTimeout,
+		Store:        store,
+	}
+}
+
+// writeBatch represents the writes to a shard waiting to be written together.
+type writeBatch struct {
+	shardID uint64
+	writes  []*bufferedWrite
+	pointN  int
+	timer   *time.Timer
+}
+
+// bufferedWrite represents a single write waiting for its batch.
+type bufferedWrite struct {
+	points []Point
+	err    error
+	done   chan struct{}
+}
+
+// CreateShard creates a shard in the store.
+func (b *WriteBuffer) CreateShard(database, retentionPolicy string, shardID uint64) error {
+	return b.Store.CreateShard(database, retentionPolicy, shardID)
+}
+
+// WriteToShard adds points to the batch of a shard and waits for the batch to
+// be written. Returns ErrWriteBufferFull if too many points are waiting.
+// Once the buffer is closed points are written to the store directly.
+func (b *WriteBuffer) WriteToShard(shardID uint64, points []Point) error {
+	w := &bufferedWrite{points: points, done: make(chan struct{})}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return b.Store.WriteToShard(shardID, points)
+	}
+
+	// A write larger than the limit is accepted if nothing else is waiting.
+	if b.MaxPendingPoints > 0 && b.pending > 0 && b.pending+len(points) > b.MaxPendingPoints {
+		b.mu.Unlock()
+		return ErrWriteBufferFull
+	}
+	b.pending += len(points)
+
+	batch := b.batches[shardID]
+	if batch == nil {
+		batch = &writeBatch{shardID: shardID}
+		batch.timer = time.AfterFunc(b.BatchTimeout, func() { b.flush(batch) })
+		b.batches[shardID] = batch
+	}
+	batch.writes = append(batch.writes, w)
+	batch.pointN += len(points)
+
+	// Write the batch from this goroutine once it's full.
+	full := batch.pointN >= b.BatchSize
+	if full {
+		batch.timer.Stop()
+		delete(b.batches, shardID)
+	}
+	b.mu.Unlock()
+
+	if full {
+		b.write(batch)
+	}
+	<-w.done
+	return w.err
+}
+
+// flush writes a batch once its timeout expires unless it was already written.
+func (b *WriteBuffer) flush(batch *writeBatch) {
+	b.mu.Lock()
+	if b.batches[batch.shardID] != batch {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.batches, batch.shardID)
+	b.mu.Unlock()
+
+	b.write(batch)
+}
+
+// write writes the points of a batch to the store and releases its writes.
+// If the batch fails then each write is retried on its own so only the
+// writes with invalid points, such as field type conflicts, fail.
+func (b *WriteBuffer) write(batch *writeBatch) {
+	if len(batch.writes) == 1 {
+		batch.writes[0].err = b.Store.WriteToShard(batch.shardID, batch.writes[0].points)
+	} else {
+		points := make([]Point, 0, batch.pointN)
+		for _, w := range batch.writes {
+			points = append(points, w.points...)
+		}
+
+		err := b.Store.WriteToShard(batch.shardID, points)
+		for _, w := range batch.writes {
+			if err != nil && err != ErrShardNotFound {
+				w.err = b.Store.WriteToShard(batch.shardID, w.points)
+			} else {
+				w.err = err
+			}
+		}
+	}
+
+	b.mu.Lock()
+	b.pending -= batch.pointN
+	b.mu.Unlock()
+
+	for _, w := range batch.writes {
+		close(w.done)
+	}
+}
+
+// Close writes every waiting batch. Later writes go to the store directly.
+func (b *WriteBuffer) Close() error {
+	b.mu.Lock()
+	batches := b.batches
+	b.batches = make(map[uint64]*writeBatch)
+	b.closed = true
+	b.mu.Unlock()
+
+	for _, batch := range batches {
+		batch.timer.Stop()
+		b.write(batch)
+	}
+	return nil
+}
+
+// PendingPoints returns the number of points waiting to be written.
+func (b *WriteBuffer) PendingPoints() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pending
+}
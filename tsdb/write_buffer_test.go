@@ -0,0 +1,165 @@
+package tsdb
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Ensure concurrent writes to a shard are written as a single batch once the
+// batch size is reached.
+func TestWriteBuffer_BatchSize(t *testing.T) {
+	store := &writeBufferStore{}
+	b := newTestWriteBuffer(store)
+	b.BatchSize = 4
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := b.WriteToShard(1, writeBufferPoints(2, 1.0)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if exp := []int{4}; !store.hasWrites(exp) {
+		t.Fatalf("unexpected writes: exp %v, got %v", exp, store.writes)
+	} else if n := b.PendingPoints(); n != 0 {
+		t.Fatalf("unexpected pending points: %d", n)
+	}
+}
+
+// Ensure a batch is written once its timeout expires.
+func TestWriteBuffer_BatchTimeout(t *testing.T) {
+	store := &writeBufferStore{}
+	b := newTestWriteBuffer(store)
+	b.BatchTimeout = 10 * time.Millisecond
+
+	if err := b.WriteToShard(1, writeBufferPoints(2, 1.0)); err != nil {
+		t.Fatal(err)
+	} else if exp := []int{2}; !store.hasWrites(exp) {
+		t.Fatalf("unexpected writes: exp %v, got %v", exp, store.writes)
+	}
+}
+
+// Ensure only the writes with invalid points fail when a batch fails.
+func TestWriteBuffer_ErrWriteToShard(t *testing.T) {
+	store := &writeBufferStore{}
+	store.writeToShard = func(points []Point) error {
+		for _, p := range points {
+			if p.Fields()["value"] == "invalid" {
+				return ErrFieldTypeConflict
+			}
+		}
+		return nil
+	}
+	b := newTestWriteBuffer(store)
+	b.BatchSize = 3
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); errs[0] = b.WriteToShard(1, writeBufferPoints(2, 1.0)) }()
+	go func() { defer wg.Done(); errs[1] = b.WriteToShard(1, writeBufferPoints(1, "invalid")) }()
+	wg.Wait()
+
+	if errs[0] != nil {
+		t.Fatalf("unexpected error: %s", errs[0])
+	} else if errs[1] != ErrFieldTypeConflict {
+		t.Fatalf("unexpected error: %v", errs[1])
+	}
+}
+
+// Ensure writes are rejected while too many points are waiting and that
+// waiting points are written on close.
+func TestWriteBuffer_ErrWriteBufferFull(t *testing.T) {
+	store := &writeBufferStore{}
+	b := newTestWriteBuffer(store)
+	b.MaxPendingPoints = 3
+
+	done := make(chan error)
+	go func() { done <- b.WriteToShard(1, writeBufferPoints(2, 1.0)) }()
+	for b.PendingPoints() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := b.WriteToShard(2, writeBufferPoints(2, 1.0)); err != ErrWriteBufferFull {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := <-done; err != nil {
+		t.Fatal(err)
+	} else if exp := []int{2}; !store.hasWrites(exp) {
+		t.Fatalf("unexpected writes: exp %v, got %v", exp, store.writes)
+	}
+
+	// Writes after close go directly to the store.
+	if err := b.WriteToShard(2, writeBufferPoints(5, 1.0)); err != nil {
+		t.Fatal(err)
+	} else if exp := []int{2, 5}; !store.hasWrites(exp) {
+		t.Fatalf("unexpected writes: exp %v, got %v", exp, store.writes)
+	}
+}
+
+// newTestWriteBuffer returns a write buffer that only writes batches once
+// they're full.
+func newTestWriteBuffer(store *writeBufferStore) *WriteBuffer {
+	b := NewWriteBuffer(nil)
+	b.BatchSize = 100
+	b.BatchTimeout = time.Hour
+	b.Store = store
+	return b
+}
+
+// writeBufferPoints returns n points with the same value.
+func writeBufferPoints(n int, value interface{}) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = MustNewPoint("cpu", nil, map[string]interface{}{"value": value}, time.Unix(int64(i), 0))
+	}
+	return points
+}
+
+// writeBufferStore records the number of points of each write.
+type writeBufferStore struct {
+	mu           sync.Mutex
+	writes       []int
+	writeToShard func(points []Point) error
+}
+
+func (s *writeBufferStore) CreateShard(database, retentionPolicy string, shardID uint64) error {
+	return errors.New("not implemented")
+}
+
+func (s *writeBufferStore) WriteToShard(shardID uint64, points []Point) error {
+	if s.writeToShard != nil {
+		if err := s.writeToShard(points); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = append(s.writes, len(points))
+	return nil
+}
+
+// hasWrites returns true if the store received writes of the given sizes.
+func (s *writeBufferStore) hasWrites(sizes []int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.writes) != len(sizes) {
+		return false
+	}
+	for i := range sizes {
+		if s.writes[i] != sizes[i] {
+			return false
+		}
+	}
+	return true
+}